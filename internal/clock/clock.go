@@ -0,0 +1,111 @@
+// Package clock abstracts the passage of time for the timing-driven parts of
+// mDNS: probing and announcing (RFC 6762 §8), record rate limiting (RFC 6762
+// §6.2) and TTL aging (RFC 6762 §10).
+//
+// Production code uses Real. Tests substitute a Fake and advance it
+// explicitly, so multi-second protocol sequences run instantly and land on
+// timing boundaries exactly.
+package clock
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for it to pass.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// After returns a channel that receives the current time once d has
+	// elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real is the wall clock, backed by the time package.
+var Real Clock = realClock{}
+
+// realClock implements Clock with time.Now and time.After.
+type realClock struct{}
+
+// Now returns time.Now().
+func (realClock) Now() time.Time { return time.Now() }
+
+// After returns time.After(d).
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Or returns c, or Real if c is nil, so zero-value structs (e.g. test struct
+// literals) keep working on the wall clock.
+func Or(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// WithTimeout returns a copy of ctx cancelled once d has elapsed on c.
+//
+// On the real clock this is context.WithTimeout, so the deadline stays
+// visible to ctx.Deadline() and reaches socket reads (transport.Receive sets
+// it as the read deadline). On any other clock the context is cancelled when
+// c.After(d) fires, and carries no deadline.
+func WithTimeout(ctx context.Context, c Clock, d time.Duration) (context.Context, context.CancelFunc) {
+	if c = Or(c); c == Real {
+		return context.WithTimeout(ctx, d)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	fired := c.After(d)
+	go func() {
+		select {
+		case <-fired:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// Timer is a call scheduled on a Clock by AfterFunc.
+type Timer struct {
+	real *time.Timer   // Set on the real clock
+	stop chan struct{} // Closed by Stop on any other clock
+	once sync.Once
+}
+
+// AfterFunc calls f in its own goroutine once d has elapsed on c, like
+// time.AfterFunc.
+//
+// On the real clock this is time.AfterFunc. On any other clock a goroutine
+// waits on c.After(d), so a Fake runs f when advanced past the deadline.
+func AfterFunc(c Clock, d time.Duration, f func()) *Timer {
+	if c = Or(c); c == Real {
+		return &Timer{real: time.AfterFunc(d, f)}
+	}
+
+	t := &Timer{stop: make(chan struct{})}
+	fired := c.After(d)
+	go func() {
+		select {
+		case <-fired:
+			select {
+			case <-t.stop: // Stopped while both were ready
+			default:
+				f()
+			}
+		case <-t.stop:
+		}
+	}()
+	return t
+}
+
+// Stop prevents the call from running if it has not started yet. Like
+// time.Timer.Stop it does not wait for a call already in progress.
+func (t *Timer) Stop() {
+	if t.real != nil {
+		t.real.Stop()
+		return
+	}
+	t.once.Do(func() { close(t.stop) })
+}
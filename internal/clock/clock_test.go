@@ -0,0 +1,150 @@
+package clock
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestFake_AfterFiresOnAdvance verifies After channels fire only once the
+// clock has been advanced to their deadline, and that Now tracks Advance.
+func TestFake_AfterFiresOnAdvance(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	f := NewFake(start)
+
+	short := f.After(250 * time.Millisecond)
+	long := f.After(time.Second)
+	if got := f.Waiters(); got != 2 {
+		t.Fatalf("Waiters() = %d, want 2", got)
+	}
+
+	f.Advance(249 * time.Millisecond)
+	assertPending(t, short, "250ms waiter after 249ms")
+
+	f.Advance(time.Millisecond)
+	select {
+	case fired := <-short:
+		if want := start.Add(250 * time.Millisecond); !fired.Equal(want) {
+			t.Errorf("250ms waiter fired at %v, want %v", fired, want)
+		}
+	default:
+		t.Fatal("250ms waiter did not fire after 250ms")
+	}
+	assertPending(t, long, "1s waiter after 250ms")
+
+	f.Advance(time.Second)
+	select {
+	case <-long:
+	default:
+		t.Fatal("1s waiter did not fire after 1.25s")
+	}
+	if got := f.Waiters(); got != 0 {
+		t.Errorf("Waiters() = %d after all fired, want 0", got)
+	}
+	if got, want := f.Now(), start.Add(1250*time.Millisecond); !got.Equal(want) {
+		t.Errorf("Now() = %v, want %v", got, want)
+	}
+}
+
+// TestFake_AfterNonPositive verifies a zero or negative wait fires without
+// advancing the clock, as time.After does.
+func TestFake_AfterNonPositive(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	for _, d := range []time.Duration{0, -time.Second} {
+		select {
+		case <-f.After(d):
+		default:
+			t.Errorf("After(%v) did not fire immediately", d)
+		}
+	}
+	if got := f.Waiters(); got != 0 {
+		t.Errorf("Waiters() = %d, want 0", got)
+	}
+}
+
+// TestFake_WaitForWaiters verifies WaitForWaiters returns once another
+// goroutine blocks in After, so a test can advance without racing it.
+func TestFake_WaitForWaiters(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	done := make(chan struct{})
+	go func() {
+		<-f.After(time.Minute)
+		close(done)
+	}()
+
+	f.WaitForWaiters(1)
+	f.Advance(time.Minute)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("waiter not released by Advance")
+	}
+}
+
+// TestWithTimeout_FakeClock verifies a fake-clock timeout cancels the context
+// when the clock is advanced, not when wall time passes.
+func TestWithTimeout_FakeClock(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	ctx, cancel := WithTimeout(context.Background(), f, 250*time.Millisecond)
+	defer cancel()
+
+	f.WaitForWaiters(1)
+	select {
+	case <-ctx.Done():
+		t.Fatal("context done before the fake clock advanced")
+	default:
+	}
+
+	f.Advance(250 * time.Millisecond)
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("context not cancelled after advancing past the timeout")
+	}
+}
+
+// TestWithTimeout_RealClock verifies the real clock keeps the deadline on the
+// context, so it reaches socket read deadlines.
+func TestWithTimeout_RealClock(t *testing.T) {
+	ctx, cancel := WithTimeout(context.Background(), nil, time.Minute)
+	defer cancel()
+	if _, ok := ctx.Deadline(); !ok {
+		t.Error("real-clock context has no deadline")
+	}
+}
+
+// TestAfterFunc_FakeClock verifies a fake-clock AfterFunc runs once the clock
+// is advanced to its deadline, and never after Stop.
+func TestAfterFunc_FakeClock(t *testing.T) {
+	f := NewFake(time.Unix(0, 0))
+	ran := make(chan struct{})
+	AfterFunc(f, 250*time.Millisecond, func() { close(ran) })
+	stopped := AfterFunc(f, 250*time.Millisecond, func() { t.Error("stopped AfterFunc ran") })
+	stopped.Stop()
+
+	f.WaitForWaiters(2)
+	f.Advance(249 * time.Millisecond)
+	select {
+	case <-ran:
+		t.Fatal("AfterFunc ran before its deadline")
+	default:
+	}
+
+	f.Advance(time.Millisecond)
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("AfterFunc did not run after advancing past its deadline")
+	}
+}
+
+// assertPending fails the test if ch has already fired.
+func assertPending(t *testing.T, ch <-chan time.Time, what string) {
+	t.Helper()
+	select {
+	case <-ch:
+		t.Fatalf("%s fired early", what)
+	default:
+	}
+}
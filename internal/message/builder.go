@@ -1,11 +1,8 @@
 // Package message implements DNS message construction per RFC 6762.
 package message
 
-// nosemgrep: beacon-external-dependencies
 import (
-	"crypto/rand" // Standard library, required for secure DNS query ID generation per gosec G404
 	"encoding/binary"
-	"math/big"
 	"strings"
 
 	"github.com/joshuafuller/beacon/internal/errors"
@@ -33,7 +30,7 @@ import (
 //
 // Parameters:
 //   - name: The DNS name to query (e.g., "printer.local")
-//   - recordType: The DNS record type (A=1, PTR=12, TXT=16, SRV=33)
+//   - recordType: The DNS record type (A=1, PTR=12, TXT=16, AAAA=28, SRV=33)
 //
 // Returns:
 //   - query: The wire format DNS query message
@@ -44,7 +41,7 @@ func BuildQuery(name string, recordType uint16) ([]byte, error) {
 		return nil, &errors.ValidationError{
 			Field:   "recordType",
 			Value:   recordType,
-			Message: "unsupported record type (M1 supports A, PTR, SRV, TXT)",
+			Message: "unsupported record type (supported: A, AAAA, PTR, SRV, TXT)",
 		}
 	}
 
@@ -69,7 +66,7 @@ func BuildQuery(name string, recordType uint16) ([]byte, error) {
 // buildQueryHeader constructs a DNS header for an mDNS query per RFC 6762 §18.
 //
 // Header format (12 bytes):
-//   - ID (2 bytes): Transaction ID
+//   - ID (2 bytes): Transaction ID (0 per RFC 6762 §18.1)
 //   - Flags (2 bytes): QR, OPCODE, AA, TC, RD, RA, Z, RCODE
 //   - QDCOUNT (2 bytes): Number of questions (always 1 for M1)
 //   - ANCOUNT (2 bytes): Number of answers (always 0 for queries)
@@ -78,39 +75,17 @@ func BuildQuery(name string, recordType uint16) ([]byte, error) {
 //
 // FR-020: System MUST set DNS header fields per RFC 6762 §18
 func buildQueryHeader() []byte {
-	header := make([]byte, 12)
-
-	// ID: RFC 6762 §18.1 suggests 0, but M1 uses random ID for future compatibility
-	// Use crypto/rand for cryptographically secure random number generation (G404)
-	idBig, err := rand.Int(rand.Reader, big.NewInt(65536))
-	if err != nil {
-		// Fallback to 0 if crypto/rand fails (should not happen in practice)
-		idBig = big.NewInt(0)
-	}
-	// G115: rand.Int is called with upper bound 65536, so result is in range [0, 65535]
-	// Safe conversion to uint16 using modulo to ensure no overflow
-	id := uint16(idBig.Uint64() % 65536) //nolint:gosec // G115: rand.Int bounds upper limit to 65536
-	binary.BigEndian.PutUint16(header[0:2], id)
-
+	// ID: RFC 6762 §18.1: "In multicast query messages, the Query Identifier
+	// SHOULD be set to zero on transmission."
 	// Flags: Set per RFC 6762 §18
 	// QR=0 (§18.2), OPCODE=0 (§18.3), AA=0 (§18.4), TC=0 (§18.5),
 	// RD=0 (§18.6), RA=0, Z=0, RCODE=0
-	flags := uint16(0x0000)
-	binary.BigEndian.PutUint16(header[2:4], flags)
+	header := NewQueryHeader(0)
 
-	// QDCOUNT: 1 question
-	binary.BigEndian.PutUint16(header[4:6], 1)
+	// QDCOUNT: 1 question; no answer, authority or additional records
+	header.QDCount = 1
 
-	// ANCOUNT: 0 answers (queries don't have answers)
-	binary.BigEndian.PutUint16(header[6:8], 0)
-
-	// NSCOUNT: 0 authority records
-	binary.BigEndian.PutUint16(header[8:10], 0)
-
-	// ARCOUNT: 0 additional records (M1 doesn't send additional records)
-	binary.BigEndian.PutUint16(header[10:12], 0)
-
-	return header
+	return SerializeHeader(header)
 }
 
 // buildQuestionSection constructs a DNS question section per RFC 1035 §4.1.2.
@@ -197,19 +172,9 @@ func BuildResponse(answers []*ResourceRecord) ([]byte, error) {
 // FR-023: System MUST set response header fields per RFC 6762 §18
 // T012: Build response headers with QR=1, AA=1
 func buildResponseHeader(answerCount int) []byte {
-	header := make([]byte, 12)
-
 	// ID: RFC 6762 §18.1 recommends 0 for responses
-	binary.BigEndian.PutUint16(header[0:2], 0)
-
 	// Flags: QR=1 (response), AA=1 (authoritative), OPCODE=0, RCODE=0
-	// QR bit (bit 15): 1 = response
-	// AA bit (bit 10): 1 = authoritative
-	flags := protocol.FlagQR | protocol.FlagAA
-	binary.BigEndian.PutUint16(header[2:4], flags)
-
-	// QDCOUNT: 0 questions (unsolicited response)
-	binary.BigEndian.PutUint16(header[4:6], 0)
+	header := NewResponseHeader(0)
 
 	// ANCOUNT: Number of answer records
 	// G115: RFC 6762 §4.3 specifies ANCOUNT as uint16, max 65535. DNS message size limit
@@ -218,15 +183,10 @@ func buildResponseHeader(answerCount int) []byte {
 	if answerCount > 65535 { //nolint:gosec // G115: bounds checked, max message size 9000 bytes
 		answerCount = 65535 // Cap at maximum uint16
 	}
-	binary.BigEndian.PutUint16(header[6:8], uint16(answerCount))
-
-	// NSCOUNT: 0 authority records
-	binary.BigEndian.PutUint16(header[8:10], 0)
-
-	// ARCOUNT: 0 additional records (for now)
-	binary.BigEndian.PutUint16(header[10:12], 0)
+	header.ANCount = uint16(answerCount)
 
-	return header
+	// QDCOUNT, NSCOUNT, ARCOUNT: 0 (unsolicited response, no other sections)
+	return SerializeHeader(header)
 }
 
 // serializeResourceRecord serializes a resource record to wire format.
@@ -318,15 +278,19 @@ func SerializeResourceRecord(rr *ResourceRecord) ([]byte, error) {
 	record = append(record, ttlBytes...)
 
 	// RDLENGTH (2 bytes)
-	// G115: RFC 1035 §3.2.1 specifies RDLENGTH as uint16, max 65535. DNS message size
-	// limit (9000 bytes per RFC 6762) ensures rdata length never exceeds uint16.
-	// Defensive bounds check for safety.
+	// RFC 1035 §3.2.1 specifies RDLENGTH as uint16, max 65535. Longer RDATA
+	// (e.g. an unchecked TXT set) cannot be represented; capping the length
+	// would emit a corrupt record, so it is an error.
 	rdataLen := len(rr.Data)
-	if rdataLen > 65535 { //nolint:gosec // G115: bounds checked, max message size 9000 bytes
-		rdataLen = 65535 // Cap at maximum uint16
+	if rdataLen > 65535 {
+		return nil, &errors.ValidationError{
+			Field:   "RDATA",
+			Value:   rdataLen,
+			Message: "record data exceeds 65535 bytes (RDLENGTH is 16 bits, RFC 1035 §3.2.1)",
+		}
 	}
 	rdlengthBytes := make([]byte, 2)
-	binary.BigEndian.PutUint16(rdlengthBytes, uint16(rdataLen))
+	binary.BigEndian.PutUint16(rdlengthBytes, uint16(rdataLen)) //nolint:gosec // G115: bounds checked above
 	record = append(record, rdlengthBytes...)
 
 	// RDATA
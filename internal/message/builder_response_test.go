@@ -185,3 +185,22 @@ func TestBuildResponse_MultipleAnswers(t *testing.T) {
 }
 
 // Note: ResourceRecord type is now implemented in builder.go (T012 GREEN phase)
+
+// TestSerializeResourceRecord_RDATATooLong verifies RDATA longer than the
+// 16-bit RDLENGTH can express is an error, not a record with a corrupt
+// (capped) length (RFC 1035 §3.2.1).
+func TestSerializeResourceRecord_RDATATooLong(t *testing.T) {
+	rr := &ResourceRecord{
+		Name:  "big._http._tcp.local",
+		Type:  protocol.RecordTypeTXT,
+		Class: protocol.ClassIN,
+		TTL:   120,
+		Data:  make([]byte, 70000),
+	}
+	if packet, err := SerializeResourceRecord(rr); err == nil {
+		t.Fatalf("SerializeResourceRecord(70000-byte RDATA) = %d bytes, want error", len(packet))
+	}
+	if _, err := BuildResponse([]*ResourceRecord{rr}); err == nil {
+		t.Error("BuildResponse(70000-byte RDATA) error = nil, want error")
+	}
+}
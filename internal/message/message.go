@@ -9,6 +9,8 @@
 // PRIMARY TECHNICAL AUTHORITY: RFC 1035 (DNS wire format), RFC 6762 (mDNS extensions)
 package message
 
+import "github.com/joshuafuller/beacon/internal/protocol"
+
 // DNSHeader represents the DNS message header per RFC 1035 §4.1.1.
 //
 // The header is always 12 bytes and contains metadata about the message.
@@ -36,8 +38,9 @@ package message
 type DNSHeader struct {
 	// ID is the transaction ID (16 bits).
 	//
-	// RFC 6762 §18.1: Multicast DNS messages SHOULD use ID = 0 for one-shot queries.
-	// M1 uses random ID for potential future compatibility.
+	// RFC 6762 §18.1: zero in multicast queries (SHOULD) and multicast
+	// responses (MUST); a unicast response echoes the ID of the query it
+	// answers, which a legacy resolver uses to match it (§6.7).
 	ID uint16
 
 	// Flags contains bit-packed header flags (16 bits).
@@ -90,6 +93,23 @@ func (h *DNSHeader) IsResponse() bool {
 	return (h.Flags & 0x8000) != 0
 }
 
+// IsAuthoritative returns true if the AA bit is set per RFC 1035 §4.1.1.
+//
+// RFC 6762 §18.4: AA MUST be one in multicast responses.
+func (h *DNSHeader) IsAuthoritative() bool {
+	// AA bit is bit 10 (0x0400)
+	return (h.Flags & 0x0400) != 0
+}
+
+// IsTruncated returns true if the TC bit is set per RFC 1035 §4.1.1.
+//
+// RFC 6762 §18.5: In a response, TC indicates the answer did not fit in a
+// single packet (legacy unicast only; multicast responses MUST clear it).
+func (h *DNSHeader) IsTruncated() bool {
+	// TC bit is bit 9 (0x0200)
+	return (h.Flags & 0x0200) != 0
+}
+
 // GetRCODE extracts the response code from the Flags field per RFC 1035 §4.1.1.
 //
 // RCODE is bits 0-3 of the Flags field.
@@ -112,6 +132,38 @@ func (h *DNSHeader) GetOPCODE() uint8 {
 	return uint8((h.Flags >> 11) & 0x0F) //nolint:gosec // G115: bounds checked
 }
 
+// NewQueryHeader returns the header of an mDNS query per RFC 6762 §18: every
+// flag is zero (QR=0 §18.2, OPCODE=0 §18.3, AA=0 §18.4, TC=0 §18.5, RD=0
+// §18.6, RCODE=0 §18.11). The section counts are left for the caller.
+//
+// Parameters:
+//   - id: Transaction ID (RFC 6762 §18.1: SHOULD be zero for multicast)
+func NewQueryHeader(id uint16) DNSHeader {
+	return DNSHeader{ID: id}
+}
+
+// NewResponseHeader returns the header of an mDNS response per RFC 6762 §18:
+// QR=1 (§18.2), OPCODE=0 (§18.3), AA=1 (§18.4), TC=0 (§18.5) and RCODE=0
+// (§18.11). The section counts are left for the caller.
+//
+// Parameters:
+//   - id: Transaction ID (RFC 6762 §18.1: zero in multicast responses; the
+//     query's ID in a legacy unicast reply, §6.7)
+func NewResponseHeader(id uint16) DNSHeader {
+	return DNSHeader{ID: id, Flags: protocol.FlagQR | protocol.FlagAA}
+}
+
+// NewProbeHeader returns the header of a probe per RFC 6762 §8.1. A probe is
+// a query, so its flags are those of NewQueryHeader; the records it proposes
+// go in the Authority section (§8.2), counted by NSCount, which is left for
+// the caller along with the other section counts.
+//
+// Parameters:
+//   - id: Transaction ID (RFC 6762 §18.1: SHOULD be zero)
+func NewProbeHeader(id uint16) DNSHeader {
+	return NewQueryHeader(id)
+}
+
 // Question represents a DNS question section entry per RFC 1035 §4.1.2.
 //
 // The question section contains the query being asked.
@@ -142,7 +194,7 @@ type Question struct {
 
 	// QTYPE is the query type (16 bits).
 	//
-	// M1 supports: A (1), PTR (12), SRV (33), TXT (16) per FR-002.
+	// M1 supports: A (1), PTR (12), SRV (33), TXT (16) per FR-002, and AAAA (28).
 	QTYPE uint16
 
 	// QCLASS is the query class (16 bits).
@@ -193,7 +245,7 @@ type Answer struct {
 
 	// TYPE is the resource record type (16 bits).
 	//
-	// M1 supports: A (1), PTR (12), SRV (33), TXT (16) per FR-002.
+	// M1 supports: A (1), PTR (12), SRV (33), TXT (16) per FR-002, and AAAA (28).
 	TYPE uint16
 
 	// CLASS is the resource record class (16 bits).
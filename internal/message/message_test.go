@@ -188,6 +188,34 @@ func TestDNSHeader_GetOPCODE(t *testing.T) {
 	}
 }
 
+// TestDNSHeader_AATCFlags validates that IsAuthoritative() and IsTruncated()
+// extract the AA (bit 10) and TC (bit 9) flags per RFC 1035 §4.1.1.
+func TestDNSHeader_AATCFlags(t *testing.T) {
+	tests := []struct {
+		name   string
+		flags  uint16
+		wantAA bool
+		wantTC bool
+	}{
+		{name: "neither", flags: 0x8000, wantAA: false, wantTC: false},
+		{name: "AA only (typical mDNS response)", flags: 0x8400, wantAA: true, wantTC: false},
+		{name: "TC only", flags: 0x8200, wantAA: false, wantTC: true},
+		{name: "AA and TC", flags: 0x8600, wantAA: true, wantTC: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := &DNSHeader{Flags: tt.flags}
+			if got := header.IsAuthoritative(); got != tt.wantAA {
+				t.Errorf("DNSHeader.IsAuthoritative() with flags=0x%04X = %v, want %v", tt.flags, got, tt.wantAA)
+			}
+			if got := header.IsTruncated(); got != tt.wantTC {
+				t.Errorf("DNSHeader.IsTruncated() with flags=0x%04X = %v, want %v", tt.flags, got, tt.wantTC)
+			}
+		})
+	}
+}
+
 // TestDNSHeader_QueryResponseSymmetry validates that IsQuery() and IsResponse()
 // are mutually exclusive per RFC 1035 §4.1.1.
 //
@@ -246,6 +274,61 @@ func TestDNSHeader_Initialization(t *testing.T) {
 	}
 }
 
+// TestNewHeaders validates the header constructors set the RFC 6762 §18 flag
+// bits, and that the header accessors read them back, both in memory and
+// after a wire round trip through SerializeHeader and ParseHeader.
+func TestNewHeaders(t *testing.T) {
+	tests := []struct {
+		name          string
+		header        DNSHeader
+		wantFlags     uint16
+		wantResponse  bool
+		wantAuthority bool
+	}{
+		{"query", NewQueryHeader(0x1234), 0x0000, false, false},
+		{"probe", NewProbeHeader(0x1234), 0x0000, false, false},
+		{"response", NewResponseHeader(0x1234), 0x8400, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseHeader(SerializeHeader(tt.header))
+			if err != nil {
+				t.Fatalf("ParseHeader(SerializeHeader()) error = %v", err)
+			}
+			for _, h := range []DNSHeader{tt.header, parsed} {
+				if h.ID != 0x1234 {
+					t.Errorf("ID = %#04x, want 0x1234", h.ID)
+				}
+				if h.Flags != tt.wantFlags {
+					t.Errorf("Flags = %#04x, want %#04x", h.Flags, tt.wantFlags)
+				}
+				if h.IsResponse() != tt.wantResponse || h.IsQuery() == tt.wantResponse {
+					t.Errorf("IsResponse() = %v, IsQuery() = %v; want response %v", h.IsResponse(), h.IsQuery(), tt.wantResponse)
+				}
+				if h.IsAuthoritative() != tt.wantAuthority {
+					t.Errorf("IsAuthoritative() = %v, want %v", h.IsAuthoritative(), tt.wantAuthority)
+				}
+				if h.IsTruncated() {
+					t.Error("IsTruncated() = true, want false (RFC 6762 §18.5)")
+				}
+				if h.GetOPCODE() != 0 || h.GetRCODE() != 0 {
+					t.Errorf("OPCODE = %d, RCODE = %d; want 0 (RFC 6762 §18.3, §18.11)", h.GetOPCODE(), h.GetRCODE())
+				}
+				if h.QDCount != 0 || h.ANCount != 0 || h.NSCount != 0 || h.ARCount != 0 {
+					t.Errorf("counts = %d/%d/%d/%d, want all 0", h.QDCount, h.ANCount, h.NSCount, h.ARCount)
+				}
+			}
+		})
+	}
+
+	// A truncated response reads back as such
+	h := NewResponseHeader(0)
+	h.Flags |= 0x0200
+	if parsed, _ := ParseHeader(SerializeHeader(h)); !parsed.IsTruncated() || !parsed.IsResponse() {
+		t.Errorf("TC response: IsTruncated() = %v, IsResponse() = %v; want true, true", parsed.IsTruncated(), parsed.IsResponse())
+	}
+}
+
 // TestQuestion_Initialization validates that Question fields can be initialized
 // and read correctly per RFC 1035 §4.1.2.
 //
@@ -773,9 +856,9 @@ func TestParseRDATA_UnsupportedType(t *testing.T) {
 		rdata      []byte
 	}{
 		{
-			name:       "AAAA record (type 28) - not supported in M1",
-			recordType: 28,
-			rdata:      make([]byte, 16), // 16-byte IPv6 address
+			name:       "HINFO record (type 13) - not supported",
+			recordType: 13,
+			rdata:      []byte{3, 'A', 'R', 'M', 5, 'L', 'i', 'n', 'u', 'x'},
 		},
 		{
 			name:       "MX record (type 15) - not supported in M1",
@@ -33,6 +33,13 @@ import (
 //   - newOffset: The offset immediately after the name (for parsing subsequent fields)
 //   - error: WireFormatError if the name is malformed
 func ParseName(msg []byte, offset int) (name string, newOffset int, err error) {
+	return parseName(msg, offset, "")
+}
+
+// parseName is ParseName, but returns prev rather than a new string when the
+// name equals it, so re-parsing a similar message (ParseMessageInto) does not
+// allocate for names already seen.
+func parseName(msg []byte, offset int, prev string) (name string, newOffset int, err error) {
 	if offset < 0 || offset >= len(msg) {
 		return "", offset, &errors.WireFormatError{
 			Operation: "parse name",
@@ -41,7 +48,9 @@ func ParseName(msg []byte, offset int) (name string, newOffset int, err error) {
 		}
 	}
 
-	var labels []string
+	// The dotted name is assembled in place; a legal name fits in buf
+	var buf [protocol.MaxNameLength]byte
+	dotted := buf[:0]
 	jumps := 0
 	pos := offset
 	jumped := false
@@ -130,28 +139,30 @@ func ParseName(msg []byte, offset int) (name string, newOffset int, err error) {
 			}
 		}
 
-		// Extract label
-		label := string(msg[pos+1 : pos+1+int(length)])
-		labels = append(labels, label)
+		// Append label, dot-separated
+		if len(dotted) > 0 {
+			dotted = append(dotted, '.')
+		}
+		dotted = append(dotted, msg[pos+1:pos+1+int(length)]...)
 
 		// Move to next label
 		pos += 1 + int(length)
 	}
 
-	// Join labels with dots to form the complete name
-	name = strings.Join(labels, ".")
-
 	// Validate total name length per RFC 1035 §3.1
 	// Note: Wire format length includes length bytes, but MaxNameLength applies to the string representation
-	if len(name) > protocol.MaxNameLength {
+	if len(dotted) > protocol.MaxNameLength {
 		return "", offset, &errors.WireFormatError{
 			Operation: "parse name",
 			Offset:    offset,
-			Message:   fmt.Sprintf("name length %d exceeds maximum %d bytes per RFC 1035 §3.1", len(name), protocol.MaxNameLength),
+			Message:   fmt.Sprintf("name length %d exceeds maximum %d bytes per RFC 1035 §3.1", len(dotted), protocol.MaxNameLength),
 		}
 	}
 
-	return name, newOffset, nil
+	if string(dotted) == prev {
+		return prev, newOffset, nil
+	}
+	return string(dotted), newOffset, nil
 }
 
 // EncodeName encodes a DNS name into wire format per RFC 1035 §3.1.
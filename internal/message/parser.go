@@ -7,6 +7,7 @@ import (
 	"net"
 
 	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
 // SRVData represents SRV record data per RFC 2782.
@@ -28,6 +29,10 @@ type SRVData struct {
 //   - Authority section: Variable length (NSCOUNT entries, M1 ignores)
 //   - Additional section: Variable length (ARCOUNT entries, M1 ignores)
 //
+// Every record is skipped by its RDLENGTH, so records of unknown types never
+// abort the parse. EDNS0 OPT pseudo-records (RFC 6891 §6.1) are dropped from
+// the parsed sections; the header counts still reflect the wire values.
+//
 // FR-009: System MUST parse mDNS response messages per RFC 6762 wire format
 // FR-011: System MUST validate response message format and discard malformed packets
 // FR-012: System MUST decompress DNS names per RFC 1035 §4.1.4
@@ -47,48 +52,34 @@ func ParseMessage(msg []byte) (*DNSMessage, error) {
 
 	offset := 12 // Header is always 12 bytes
 
-	// Parse question section
-	questions := make([]Question, header.QDCount)
-	for i := uint16(0); i < header.QDCount; i++ {
-		question, newOffset, err := ParseQuestion(msg, offset)
-		if err != nil {
-			return nil, err
-		}
-		questions[i] = question
-		offset = newOffset
+	// Parse question section. The header counts are attacker-controlled, so
+	// preallocation is capped by what the remaining bytes could hold: a
+	// 12-byte packet claiming 65535 questions must not allocate for them.
+	questions, offset, err := parseQuestions(msg, offset, header.QDCount,
+		make([]Question, 0, capacityFor(msg, offset, header.QDCount, minQuestionSize)))
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse answer section
-	answers := make([]Answer, header.ANCount)
-	for i := uint16(0); i < header.ANCount; i++ {
-		answer, newOffset, err := ParseAnswer(msg, offset)
-		if err != nil {
-			return nil, err
-		}
-		answers[i] = answer
-		offset = newOffset
+	answers, offset, err := parseRecords(msg, offset, header.ANCount,
+		make([]Answer, 0, capacityFor(msg, offset, header.ANCount, minRecordSize)))
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse authority section (M1: ignored per FR-010, but we parse for completeness)
-	authorities := make([]Answer, header.NSCount)
-	for i := uint16(0); i < header.NSCount; i++ {
-		authority, newOffset, err := ParseAnswer(msg, offset)
-		if err != nil {
-			return nil, err
-		}
-		authorities[i] = authority
-		offset = newOffset
+	authorities, offset, err := parseRecords(msg, offset, header.NSCount,
+		make([]Answer, 0, capacityFor(msg, offset, header.NSCount, minRecordSize)))
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse additional section (M1: ignored per FR-010, but we parse for completeness)
-	additionals := make([]Answer, header.ARCount)
-	for i := uint16(0); i < header.ARCount; i++ {
-		additional, newOffset, err := ParseAnswer(msg, offset)
-		if err != nil {
-			return nil, err
-		}
-		additionals[i] = additional
-		offset = newOffset
+	additionals, _, err := parseRecords(msg, offset, header.ARCount,
+		make([]Answer, 0, capacityFor(msg, offset, header.ARCount, minRecordSize)))
+	if err != nil {
+		return nil, err
 	}
 
 	return &DNSMessage{
@@ -100,6 +91,148 @@ func ParseMessage(msg []byte) (*DNSMessage, error) {
 	}, nil
 }
 
+// ParseMessageInto is ParseMessage for memory-constrained receive paths: it
+// parses msg into dst, reusing dst's section slices, RDATA buffers and name
+// strings from an earlier parse instead of allocating new ones. Once dst has
+// grown to fit the messages received, parsing a message repeating earlier
+// names (as re-announcements and continuous queries do) allocates nothing.
+//
+// dst's previous contents are overwritten, RDATA bytes included: copy any
+// record that must outlive the next call. On error dst's contents are
+// unspecified.
+//
+// maxRecords bounds the questions and records a message may declare across
+// its sections, so a hostile header cannot make dst grow without bound; 0
+// means no limit.
+//
+// Parameters:
+//   - msg: The complete DNS message buffer
+//   - dst: Message to parse into (reused)
+//   - maxRecords: Maximum number of questions and records (0 = no limit)
+//
+// Returns:
+//   - error: WireFormatError if the message is malformed or declares more
+//     than maxRecords entries
+func ParseMessageInto(msg []byte, dst *DNSMessage, maxRecords int) error {
+	header, err := ParseHeader(msg)
+	if err != nil {
+		return err
+	}
+	total := int(header.QDCount) + int(header.ANCount) + int(header.NSCount) + int(header.ARCount)
+	if maxRecords > 0 && total > maxRecords {
+		return &errors.WireFormatError{
+			Operation: "parse message",
+			Offset:    4, // Section counts
+			Message:   fmt.Sprintf("message declares %d questions and records, limit is %d", total, maxRecords),
+		}
+	}
+
+	offset := 12 // Header is always 12 bytes
+	questions, offset, err := parseQuestions(msg, offset, header.QDCount, dst.Questions[:0])
+	if err != nil {
+		return err
+	}
+	answers, offset, err := parseRecords(msg, offset, header.ANCount, dst.Answers[:0])
+	if err != nil {
+		return err
+	}
+	authorities, offset, err := parseRecords(msg, offset, header.NSCount, dst.Authorities[:0])
+	if err != nil {
+		return err
+	}
+	additionals, _, err := parseRecords(msg, offset, header.ARCount, dst.Additionals[:0])
+	if err != nil {
+		return err
+	}
+
+	*dst = DNSMessage{
+		Header:      header,
+		Questions:   questions,
+		Answers:     answers,
+		Authorities: authorities,
+		Additionals: additionals,
+	}
+	return nil
+}
+
+// parseQuestions parses count questions starting at offset, appending them to
+// questions. An entry left in questions' spare capacity by an earlier parse
+// lends its name to the question parsed in its place (see parseName).
+//
+// Returns:
+//   - questions: questions with the parsed questions appended
+//   - newOffset: The offset immediately after the section
+//   - error: WireFormatError if a question is malformed
+func parseQuestions(msg []byte, offset int, count uint16, questions []Question) ([]Question, int, error) {
+	for i := uint16(0); i < count; i++ {
+		var prev Question
+		if n := len(questions); n < cap(questions) {
+			prev = questions[:n+1][n]
+		}
+		question, newOffset, err := parseQuestion(msg, offset, prev.QNAME)
+		if err != nil {
+			return nil, 0, err
+		}
+		questions = append(questions, question)
+		offset = newOffset
+	}
+	return questions, offset, nil
+}
+
+// parseRecords parses count resource records starting at offset, appending
+// them to records and dropping EDNS0 OPT pseudo-records (RFC 6891 §6.1). An
+// OPT record describes the sender's transport capabilities, not a name, so it
+// must not surface as an answer. An entry left in records' spare capacity by
+// an earlier parse lends its name and RDATA buffer to the record parsed in
+// its place (ParseMessageInto).
+//
+// Parameters:
+//   - msg: The complete DNS message buffer
+//   - offset: The starting offset of the first record
+//   - count: The number of records in the section (from the header)
+//   - records: Slice to append to
+//
+// Returns:
+//   - records: records with the parsed records appended, excluding OPT
+//   - newOffset: The offset immediately after the section
+//   - error: WireFormatError if a record is malformed
+func parseRecords(msg []byte, offset int, count uint16, records []Answer) ([]Answer, int, error) {
+	for i := uint16(0); i < count; i++ {
+		var prev Answer
+		if n := len(records); n < cap(records) {
+			prev = records[:n+1][n]
+		}
+		record, newOffset, err := parseAnswer(msg, offset, prev)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset = newOffset
+		if record.TYPE == uint16(protocol.RecordTypeOPT) {
+			continue // RFC 6891 §6.1.1: OPT is a pseudo-RR, not data
+		}
+		records = append(records, record)
+	}
+	return records, offset, nil
+}
+
+// Smallest wire sizes of a question (root name, QTYPE, QCLASS) and a resource
+// record (root name, TYPE, CLASS, TTL, RDLENGTH, empty RDATA).
+const (
+	minQuestionSize = 1 + 4
+	minRecordSize   = 1 + 10
+)
+
+// capacityFor returns the slice capacity to preallocate for a section of count
+// entries starting at offset: count, capped by the number of entries of at
+// least minSize bytes that fit in the rest of msg.
+func capacityFor(msg []byte, offset int, count uint16, minSize int) int {
+	remaining := len(msg) - offset
+	if remaining <= 0 {
+		return 0
+	}
+	return min(int(count), remaining/minSize)
+}
+
 // ParseHeader parses the DNS message header per RFC 1035 §4.1.1.
 //
 // Header format (12 bytes):
@@ -159,8 +292,13 @@ func ParseHeader(msg []byte) (DNSHeader, error) {
 //   - newOffset: The offset immediately after this question entry
 //   - error: WireFormatError if the question is malformed
 func ParseQuestion(msg []byte, offset int) (Question, int, error) {
+	return parseQuestion(msg, offset, "")
+}
+
+// parseQuestion is ParseQuestion, reusing prevName for an equal QNAME.
+func parseQuestion(msg []byte, offset int, prevName string) (Question, int, error) {
 	// Parse QNAME
-	qname, newOffset, err := ParseName(msg, offset)
+	qname, newOffset, err := parseName(msg, offset, prevName)
 	if err != nil {
 		return Question{}, offset, err
 	}
@@ -211,8 +349,14 @@ func ParseQuestion(msg []byte, offset int) (Question, int, error) {
 //   - newOffset: The offset immediately after this answer entry
 //   - error: WireFormatError if the answer is malformed
 func ParseAnswer(msg []byte, offset int) (Answer, int, error) {
+	return parseAnswer(msg, offset, Answer{})
+}
+
+// parseAnswer is ParseAnswer, reusing prev's NAME if equal and its RDATA
+// buffer if large enough.
+func parseAnswer(msg []byte, offset int, prev Answer) (Answer, int, error) {
 	// Parse NAME
-	name, newOffset, err := ParseName(msg, offset)
+	name, newOffset, err := parseName(msg, offset, prev.NAME)
 	if err != nil {
 		return Answer{}, offset, err
 	}
@@ -250,7 +394,11 @@ func ParseAnswer(msg []byte, offset int) (Answer, int, error) {
 	}
 
 	// Extract RDATA
-	rdata := make([]byte, rdlength)
+	rdata := prev.RDATA
+	if rdata == nil || cap(rdata) < int(rdlength) {
+		rdata = make([]byte, rdlength)
+	}
+	rdata = rdata[:rdlength]
 	copy(rdata, msg[newOffset:newOffset+int(rdlength)])
 
 	answer := Answer{
@@ -272,13 +420,14 @@ func ParseAnswer(msg []byte, offset int) (Answer, int, error) {
 //   - A (1): IPv4 address → net.IP
 //   - PTR (12): Domain name → string
 //   - TXT (16): Text strings → []string
+//   - AAAA (28): IPv6 address → net.IP (RFC 3596 §2.2)
 //   - SRV (33): Service location → SRVData
 //
 // FR-009: System MUST parse mDNS response messages per RFC 6762 wire format
 // FR-012: System MUST decompress DNS names in RDATA (PTR, SRV target)
 //
 // Parameters:
-//   - recordType: The DNS record type (A, PTR, SRV, TXT)
+//   - recordType: The DNS record type (A, AAAA, PTR, SRV, TXT)
 //   - rdata: The raw RDATA bytes
 //
 // Returns:
@@ -298,10 +447,10 @@ func ParseRDATA(recordType uint16, rdata []byte) (interface{}, error) {
 // DNS name-compression pointers in PTR and SRV targets against msg, which is
 // required to interoperate with responders that compress those names (FR-012).
 //
-// For A and TXT records (no embedded names) it is equivalent to ParseRDATA.
+// For A, AAAA and TXT records (no embedded names) it is equivalent to ParseRDATA.
 //
 // Parameters:
-//   - recordType: The DNS record type (A, PTR, SRV, TXT)
+//   - recordType: The DNS record type (A, AAAA, PTR, SRV, TXT)
 //   - msg: The complete DNS message (for compression-pointer resolution)
 //   - rdataStart: Byte offset of RDATA within msg
 //   - rdlength: Length of RDATA in bytes
@@ -326,6 +475,16 @@ func ParseRDATAInMessage(recordType uint16, msg []byte, rdataStart, rdlength int
 		}
 		return net.IPv4(rdata[0], rdata[1], rdata[2], rdata[3]), nil
 
+	case 28: // AAAA record: IPv6 address (16 bytes) per RFC 3596 §2.2
+		if len(rdata) != net.IPv6len {
+			return nil, &errors.WireFormatError{
+				Operation: "parse AAAA record",
+				Offset:    rdataStart,
+				Message:   fmt.Sprintf("invalid AAAA record length: %d bytes, expected 16", len(rdata)),
+			}
+		}
+		return net.IP(append([]byte(nil), rdata...)), nil
+
 	case 12: // PTR record: Domain name (may be compressed against msg)
 		name, _, err := ParseName(msg, rdataStart)
 		if err != nil {
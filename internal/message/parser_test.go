@@ -3,6 +3,7 @@ package message
 import (
 	goerrors "errors"
 	"net"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -360,6 +361,29 @@ func TestParseRDATA_ARecord(t *testing.T) {
 	}
 }
 
+// TestParseRDATA_AAAARecord validates that ParseRDATA parses AAAA record RDATA
+// (a 128-bit IPv6 address) per RFC 3596 §2.2 and rejects other lengths.
+func TestParseRDATA_AAAARecord(t *testing.T) {
+	expected := net.ParseIP("fd00::10")
+
+	result, err := ParseRDATA(28, expected.To16()) // TYPE = AAAA (28)
+	if err != nil {
+		t.Fatalf("ParseRDATA failed: %v", err)
+	}
+
+	ip, ok := result.(net.IP)
+	if !ok {
+		t.Fatalf("ParseRDATA returned %T, want net.IP per RFC 3596 §2.2", result)
+	}
+	if !ip.Equal(expected) {
+		t.Errorf("IP = %s, want %s per RFC 3596 §2.2", ip, expected)
+	}
+
+	if _, err := ParseRDATA(28, []byte{192, 168, 1, 100}); err == nil {
+		t.Error("ParseRDATA(AAAA, 4 bytes) succeeded, want WireFormatError")
+	}
+}
+
 // TestParseRDATA_PTRRecord validates that ParseRDATA correctly parses PTR record
 // RDATA (domain name) per RFC 1035 §3.3.12 (FR-009).
 //
@@ -499,7 +523,35 @@ func TestParseMessage_MalformedPacket(t *testing.T) {
 				0x00, 0x00, // ARCOUNT
 				// Missing question section
 			},
-			errMsg: "unexpected end",
+			errMsg: "offset out of bounds",
+		},
+		{
+			name:   "maximum counts with empty sections",
+			msg:    []byte{0x00, 0x00, 0x84, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF},
+			errMsg: "offset out of bounds",
+		},
+		{
+			name: "RDLENGTH overruns the packet",
+			msg: []byte{
+				0x00, 0x00, 0x84, 0x00,
+				0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // ANCOUNT = 1
+				0x00,                   // Root name
+				0x00, 0x01, 0x00, 0x01, // TYPE A, CLASS IN
+				0x00, 0x00, 0x00, 0x78, // TTL
+				0xFF, 0xFF, // RDLENGTH = 65535
+				192, 168, 1, 100,
+			},
+			errMsg: "truncated RDATA",
+		},
+		{
+			name: "pointer chain loop",
+			msg: []byte{
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // QDCOUNT = 1
+				0xC0, 0x0E, 0xC0, 0x10, 0xC0, 0x0C, // 12 -> 14 -> 16 -> 12
+				0x00, 0x01, 0x00, 0x01,
+			},
+			errMsg: "invalid compression pointer",
 		},
 	}
 
@@ -517,10 +569,34 @@ func TestParseMessage_MalformedPacket(t *testing.T) {
 			if !goerrors.As(err, &wireErr) {
 				t.Errorf("expected WireFormatError per FR-015, got %T", err)
 			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.errMsg)
+			}
 		})
 	}
 }
 
+// TestCapacityFor verifies section preallocation is capped by the bytes left
+// in the message, so header counts cannot force large allocations.
+func TestCapacityFor(t *testing.T) {
+	msg := make([]byte, 12+55)
+	tests := []struct {
+		offset int
+		count  uint16
+		want   int
+	}{
+		{12, 3, 3},      // Fits
+		{12, 65535, 5},  // 55 bytes hold at most 5 minimum-size records
+		{67, 65535, 0},  // Nothing left
+		{100, 65535, 0}, // Offset past the end
+	}
+	for _, tt := range tests {
+		if got := capacityFor(msg, tt.offset, tt.count, minRecordSize); got != tt.want {
+			t.Errorf("capacityFor(offset %d, count %d) = %d, want %d", tt.offset, tt.count, got, tt.want)
+		}
+	}
+}
+
 // TestParseMessage_WithCompression validates that ParseMessage correctly handles
 // DNS name compression in answers per RFC 1035 §4.1.4 (FR-012).
 //
@@ -650,3 +726,213 @@ func TestParseRDATAInMessage_CompressedPTRTarget(t *testing.T) {
 		t.Errorf("PTR target = %q, want %q (compression pointer must resolve)", name, "Inst._http._tcp.local")
 	}
 }
+
+// rawRecord encodes one resource record with an uncompressed owner name.
+func rawRecord(t *testing.T, name string, rrType uint16, rdata []byte) []byte {
+	t.Helper()
+	rr := []byte{0x00} // Root name (OPT owner)
+	if name != "" {
+		encoded, err := EncodeName(name)
+		if err != nil {
+			t.Fatalf("EncodeName(%q) error = %v", name, err)
+		}
+		rr = encoded
+	}
+	rr = append(rr,
+		byte(rrType>>8), byte(rrType),
+		0x00, 0x01, // CLASS = IN (an OPT record carries its UDP payload size here)
+		0x00, 0x00, 0x00, 0x78, // TTL = 120
+		byte(len(rdata)>>8), byte(len(rdata)),
+	)
+	return append(rr, rdata...)
+}
+
+// TestParseMessage_SkipsOPTRecords validates that EDNS0 OPT pseudo-records
+// (RFC 6891 §6.1) are skipped by RDLENGTH and not returned as records, while
+// the A/PTR answers around them still parse.
+func TestParseMessage_SkipsOPTRecords(t *testing.T) {
+	ptrTarget, err := EncodeName("printer._ipp._tcp.local")
+	if err != nil {
+		t.Fatalf("EncodeName() error = %v", err)
+	}
+	// OPT option: code 4 (EDNS0 Owner), 4 bytes of data
+	optRDATA := []byte{0x00, 0x04, 0x00, 0x04, 0xDE, 0xAD, 0xBE, 0xEF}
+
+	msg := []byte{
+		0x00, 0x00, // ID
+		0x84, 0x00, // Flags: QR=1, AA=1
+		0x00, 0x00, // QDCOUNT = 0
+		0x00, 0x03, // ANCOUNT = 3 (A, OPT, PTR)
+		0x00, 0x00, // NSCOUNT = 0
+		0x00, 0x02, // ARCOUNT = 2 (OPT, A)
+	}
+	msg = append(msg, rawRecord(t, testLocalName, 1, []byte{192, 168, 1, 100})...)
+	msg = append(msg, rawRecord(t, "", 41, optRDATA)...)
+	msg = append(msg, rawRecord(t, "_ipp._tcp.local", 12, ptrTarget)...)
+	msg = append(msg, rawRecord(t, "", 41, optRDATA)...)
+	msg = append(msg, rawRecord(t, "host.local", 1, []byte{192, 168, 1, 5})...)
+
+	parsed, err := ParseMessage(msg)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v, want OPT records tolerated", err)
+	}
+
+	if len(parsed.Answers) != 2 {
+		t.Fatalf("len(Answers) = %d, want 2 (OPT dropped)", len(parsed.Answers))
+	}
+	if a := parsed.Answers[0]; a.NAME != testLocalName || a.TYPE != 1 || !net.IP(a.RDATA).Equal(net.IPv4(192, 168, 1, 100)) {
+		t.Errorf("Answers[0] = %s type %d %v, want %s A 192.168.1.100", a.NAME, a.TYPE, a.RDATA, testLocalName)
+	}
+	ptr := parsed.Answers[1]
+	if ptr.NAME != "_ipp._tcp.local" || ptr.TYPE != 12 {
+		t.Fatalf("Answers[1] = %s type %d, want _ipp._tcp.local PTR", ptr.NAME, ptr.TYPE)
+	}
+	target, err := ParseRDATAInMessage(ptr.TYPE, msg, ptr.RDATAOffset, int(ptr.RDLENGTH))
+	if err != nil || target != "printer._ipp._tcp.local" {
+		t.Errorf("PTR target = %v (err %v), want printer._ipp._tcp.local", target, err)
+	}
+
+	if len(parsed.Additionals) != 1 || parsed.Additionals[0].NAME != "host.local" {
+		t.Errorf("Additionals = %+v, want only host.local A (OPT dropped)", parsed.Additionals)
+	}
+}
+
+// TestParseMessage_SkipsUnknownRecordTypes validates that a record of a type
+// the parser does not understand is skipped by RDLENGTH rather than aborting
+// the message, so the records after it still parse.
+func TestParseMessage_SkipsUnknownRecordTypes(t *testing.T) {
+	msg := []byte{
+		0x00, 0x00, // ID
+		0x84, 0x00, // Flags: QR=1, AA=1
+		0x00, 0x00, // QDCOUNT = 0
+		0x00, 0x02, // ANCOUNT = 2
+		0x00, 0x00, // NSCOUNT = 0
+		0x00, 0x00, // ARCOUNT = 0
+	}
+	// Type 47 (NSEC) with opaque RDATA, as sent by Apple responders
+	msg = append(msg, rawRecord(t, testLocalName, 47, []byte{0xC0, 0x0C, 0x00, 0x01, 0x40})...)
+	msg = append(msg, rawRecord(t, testLocalName, 1, []byte{10, 0, 0, 1})...)
+
+	parsed, err := ParseMessage(msg)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v, want unknown type skipped", err)
+	}
+	if len(parsed.Answers) != 2 {
+		t.Fatalf("len(Answers) = %d, want 2", len(parsed.Answers))
+	}
+	if a := parsed.Answers[1]; a.TYPE != 1 || !net.IP(a.RDATA).Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("Answers[1] = type %d %v, want A 10.0.0.1", a.TYPE, a.RDATA)
+	}
+}
+
+// serviceResponse builds a response of the size a typical announcement has:
+// an echoed question, PTR, SRV and TXT answers, and an A additional, for the
+// service instance named instance.
+func serviceResponse(tb testing.TB, instance string) []byte {
+	tb.Helper()
+	serviceType, err := EncodeName("_http._tcp.local")
+	if err != nil {
+		tb.Fatalf("EncodeName() error = %v", err)
+	}
+	instanceName, err := EncodeServiceInstanceName(instance, "_http._tcp.local")
+	if err != nil {
+		tb.Fatalf("EncodeServiceInstanceName() error = %v", err)
+	}
+	host, err := EncodeName("host.local")
+	if err != nil {
+		tb.Fatalf("EncodeName() error = %v", err)
+	}
+	record := func(name []byte, rrType uint16, rdata []byte) []byte {
+		rr := append([]byte(nil), name...)
+		rr = append(rr, byte(rrType>>8), byte(rrType), 0x00, 0x01, 0x00, 0x00, 0x00, 0x78, byte(len(rdata)>>8), byte(len(rdata)))
+		return append(rr, rdata...)
+	}
+
+	msg := []byte{
+		0x00, 0x00, // ID
+		0x84, 0x00, // Flags: QR=1, AA=1
+		0x00, 0x01, // QDCOUNT = 1
+		0x00, 0x03, // ANCOUNT = 3 (PTR, SRV, TXT)
+		0x00, 0x00, // NSCOUNT = 0
+		0x00, 0x01, // ARCOUNT = 1 (A)
+	}
+	msg = append(msg, serviceType...)
+	msg = append(msg, 0x00, 0x0C, 0x00, 0x01) // QTYPE = PTR, QCLASS = IN
+	msg = append(msg, record(serviceType, 12, instanceName)...)
+	msg = append(msg, record(instanceName, 33, append([]byte{0x00, 0x00, 0x00, 0x00, 0x1F, 0x90}, host...))...) // Port 8080
+	msg = append(msg, record(instanceName, 16, []byte("\x08path=/api\x09txtvers=1"))...)
+	msg = append(msg, record(host, 1, []byte{192, 168, 1, 5})...)
+	return msg
+}
+
+// TestParseMessageInto verifies ParseMessageInto yields what ParseMessage
+// does while reusing dst across messages of different shapes, allocates
+// nothing re-parsing a message once dst has grown, and rejects a message
+// declaring more than maxRecords entries.
+func TestParseMessageInto(t *testing.T) {
+	printer := serviceResponse(t, "Printer")
+	scanner := serviceResponse(t, "Office Scanner")
+	empty := []byte{0x00, 0x00, 0x84, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	// Empty sections are nil from ParseMessage, empty from a reused dst
+	normalize := func(m DNSMessage) DNSMessage {
+		if len(m.Questions) == 0 {
+			m.Questions = nil
+		}
+		for _, section := range []*[]Answer{&m.Answers, &m.Authorities, &m.Additionals} {
+			if len(*section) == 0 {
+				*section = nil
+			}
+		}
+		return m
+	}
+
+	var dst DNSMessage
+	for _, packet := range [][]byte{printer, empty, scanner, printer} {
+		want, err := ParseMessage(packet)
+		if err != nil {
+			t.Fatalf("ParseMessage() error = %v", err)
+		}
+		if err := ParseMessageInto(packet, &dst, 0); err != nil {
+			t.Fatalf("ParseMessageInto() error = %v", err)
+		}
+		if !reflect.DeepEqual(normalize(dst), normalize(*want)) {
+			t.Errorf("ParseMessageInto() = %+v, want %+v", dst, *want)
+		}
+	}
+
+	if allocs := testing.AllocsPerRun(100, func() { _ = ParseMessageInto(printer, &dst, 0) }); allocs != 0 {
+		t.Errorf("ParseMessageInto() re-parsing a message allocated %v times, want 0", allocs)
+	}
+
+	var wireErr *errors.WireFormatError
+	if err := ParseMessageInto(printer, &dst, 4); !goerrors.As(err, &wireErr) {
+		t.Errorf("ParseMessageInto(5 entries, limit 4) error = %v, want WireFormatError", err)
+	}
+	if err := ParseMessageInto(printer, &dst, 5); err != nil {
+		t.Errorf("ParseMessageInto(5 entries, limit 5) error = %v, want nil", err)
+	}
+}
+
+// BenchmarkParseMessage and BenchmarkParseMessageInto compare the allocations
+// of parsing a service response afresh and into a reused message.
+func BenchmarkParseMessage(b *testing.B) {
+	packet := serviceResponse(b, "Printer")
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ParseMessage(packet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseMessageInto(b *testing.B) {
+	packet := serviceResponse(b, "Printer")
+	var dst DNSMessage
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := ParseMessageInto(packet, &dst, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
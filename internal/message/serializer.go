@@ -80,16 +80,34 @@ func SerializeMessage(msg *DNSMessage) ([]byte, error) {
 	}
 
 	// Fill in header with actual counts
-	binary.BigEndian.PutUint16(buf[0:2], msg.Header.ID)
-	binary.BigEndian.PutUint16(buf[2:4], msg.Header.Flags)
-	binary.BigEndian.PutUint16(buf[4:6], uint16(len(msg.Questions)))
-	binary.BigEndian.PutUint16(buf[6:8], uint16(len(msg.Answers)))
-	binary.BigEndian.PutUint16(buf[8:10], uint16(len(msg.Authorities)))
-	binary.BigEndian.PutUint16(buf[10:12], uint16(len(msg.Additionals)))
+	header := msg.Header
+	header.QDCount = uint16(len(msg.Questions))
+	header.ANCount = uint16(len(msg.Answers))
+	header.NSCount = uint16(len(msg.Authorities))
+	header.ARCount = uint16(len(msg.Additionals))
+	putHeader(buf[:12], header)
 
 	return buf, nil
 }
 
+// SerializeHeader serializes a DNS header to its 12-byte wire format per
+// RFC 1035 §4.1.1, e.g. one from NewQueryHeader or NewResponseHeader.
+func SerializeHeader(h DNSHeader) []byte {
+	buf := make([]byte, 12)
+	putHeader(buf, h)
+	return buf
+}
+
+// putHeader writes h into the first 12 bytes of buf.
+func putHeader(buf []byte, h DNSHeader) {
+	binary.BigEndian.PutUint16(buf[0:2], h.ID)
+	binary.BigEndian.PutUint16(buf[2:4], h.Flags)
+	binary.BigEndian.PutUint16(buf[4:6], h.QDCount)
+	binary.BigEndian.PutUint16(buf[6:8], h.ANCount)
+	binary.BigEndian.PutUint16(buf[8:10], h.NSCount)
+	binary.BigEndian.PutUint16(buf[10:12], h.ARCount)
+}
+
 // serializeQuestion serializes a DNS question to wire format per RFC 1035 §4.1.2.
 func serializeQuestion(q *Question) ([]byte, error) {
 	encodedName, err := EncodeName(q.QNAME)
@@ -25,6 +25,12 @@ const (
 	//
 	// FR-004: System MUST use mDNS port 5353 and multicast address 224.0.0.251 for IPv4 queries
 	MulticastAddrIPv4 = "224.0.0.251"
+
+	// MulticastTTL is the IP TTL (IPv6 hop limit) of outgoing mDNS packets
+	// per RFC 6762 §11: "All Multicast DNS responses (including responses sent
+	// via unicast) SHOULD be sent with IP TTL set to 255." Receivers may check
+	// it to reject packets forwarded from off-link.
+	MulticastTTL = 255
 )
 
 // MulticastGroupIPv4 returns the mDNS IPv4 multicast group address.
@@ -42,7 +48,8 @@ func MulticastGroupIPv4() *net.UDPAddr {
 
 // RecordType represents a DNS record type per RFC 1035 §3.2.2.
 //
-// M1 supports A, PTR, SRV, and TXT record types.
+// M1 supports A, PTR, SRV, and TXT record types; AAAA is also supported for
+// dual-stack hosts (RFC 3596).
 //
 // FR-002: System MUST support querying for A, PTR, SRV, and TXT record types
 type RecordType uint16
@@ -74,6 +81,28 @@ const (
 	// Type value: 33
 	RecordTypeSRV RecordType = 33
 
+	// RecordTypeAAAA represents an AAAA (IPv6 address) record per RFC 3596 §2.1.
+	//
+	// Responder-side only: RDATA is the 16-byte address (RFC 3596 §2.2).
+	// Not a supported query type (see IsSupported).
+	// Type value: 28
+	RecordTypeAAAA RecordType = 28
+
+	// RecordTypeOPT represents the EDNS0 OPT pseudo-record per RFC 6891 §6.1.
+	//
+	// Carried in the Additional section by some responders; it holds
+	// transport metadata rather than data about a name, so the parser drops
+	// it instead of returning it as a record.
+	// Type value: 41
+	RecordTypeOPT RecordType = 41
+
+	// RecordTypeNSEC represents an NSEC record per RFC 4034 §4.
+	//
+	// Multicast DNS uses a restricted form to assert that a name it owns has
+	// no record of the queried type (RFC 6762 §6.1), not for DNSSEC.
+	// Type value: 47
+	RecordTypeNSEC RecordType = 47
+
 	// RecordTypeANY represents a query for all record types per RFC 1035 §3.2.3.
 	//
 	// RFC 6762 §8.1: "All probe queries SHOULD be done using... query type 'ANY' (255)"
@@ -93,6 +122,12 @@ func (rt RecordType) String() string {
 		return "TXT"
 	case RecordTypeSRV:
 		return "SRV"
+	case RecordTypeAAAA:
+		return "AAAA"
+	case RecordTypeOPT:
+		return "OPT"
+	case RecordTypeNSEC:
+		return "NSEC"
 	case RecordTypeANY:
 		return "ANY"
 	default:
@@ -104,10 +139,11 @@ func (rt RecordType) String() string {
 //
 // FR-002: System MUST support querying for A, PTR, SRV, and TXT record types
 // FR-014: System MUST return ValidationError for invalid query names or unsupported record types
+// RFC 3596 §2.1: AAAA (28) resolves dual-stack hosts alongside A
 // RFC 6762 §8.1: ANY type (255) is required for probing
 func (rt RecordType) IsSupported() bool {
 	switch rt {
-	case RecordTypeA, RecordTypePTR, RecordTypeTXT, RecordTypeSRV, RecordTypeANY:
+	case RecordTypeA, RecordTypeAAAA, RecordTypePTR, RecordTypeTXT, RecordTypeSRV, RecordTypeANY:
 		return true
 	default:
 		return false
@@ -149,7 +185,9 @@ const (
 	// RFC 6762 §18.5: In query messages, if the TC bit is set, it indicates that additional
 	// Known-Answer records may be following shortly.
 	//
-	// M1 does not implement Known-Answer suppression, so TC=0.
+	// Beacon's querier never splits its Known-Answer list, so its queries have
+	// TC=0; the responder holds TC queries from other queriers for their
+	// continuation packets (see TruncatedQueryDelayMin).
 	//
 	// FR-020: System MUST set DNS header fields per RFC 6762 §18 (TC=0 per §18.5)
 	FlagTC uint16 = 1 << 9 // 0x0200
@@ -236,6 +274,14 @@ const (
 	//
 	// FR-019: System MUST use RFC 6762 §10 TTL values (120s service records, 4500s hostname records)
 	TTLHostname = 4500
+
+	// LegacyUnicastTTLMax caps the TTLs in a legacy unicast response - 10
+	// seconds per RFC 6762 §6.7.
+	//
+	// RFC 6762 §6.7: "The resource record TTL given in a legacy unicast
+	// response SHOULD NOT be greater than ten seconds, even if the true TTL of
+	// the Multicast DNS resource record is higher."
+	LegacyUnicastTTLMax = 10
 )
 
 // Timing constants per RFC 6762 §8
@@ -252,4 +298,25 @@ const (
 	// This IS the protocol package defining the constant - nosemgrep comment prevents
 	// false positive from beacon-rfc-timing-local-const rule
 	ProbeInterval = 250 * time.Millisecond // nosemgrep: beacon-rfc-timing-local-const
+
+	// ProbeInitialDelayMax bounds the random delay before the first probe -
+	// 250 milliseconds per RFC 6762 §8.1.
+	//
+	// RFC 6762 §8.1: before probing, a host should first wait for a short
+	// random delay, uniformly distributed in the range 0-250 ms, so devices
+	// powered on simultaneously do not probe in lockstep.
+	//
+	// The delay is a SHOULD, so responders may disable it (e.g., in tests).
+	ProbeInitialDelayMax = 250 * time.Millisecond // nosemgrep: beacon-rfc-timing-local-const
+
+	// TruncatedQueryDelayMin and TruncatedQueryDelayMax bound the random delay
+	// before answering a query with the TC bit set - 400-500 milliseconds per
+	// RFC 6762 §7.2.
+	//
+	// RFC 6762 §7.2: "If the TC bit is set, the responder SHOULD delay its
+	// response by a random interval uniformly distributed in the range
+	// 400-500 ms, to allow time for the querier to send the rest of its
+	// Known-Answer list."
+	TruncatedQueryDelayMin = 400 * time.Millisecond // nosemgrep: beacon-rfc-timing-local-const
+	TruncatedQueryDelayMax = 500 * time.Millisecond // nosemgrep: beacon-rfc-timing-local-const
 )
@@ -124,6 +124,7 @@ func isValidDNSChar(ch rune) bool {
 //
 // M1 Supported Types:
 //   - A (1): IPv4 address
+//   - AAAA (28): IPv6 address (RFC 3596)
 //   - PTR (12): Pointer (service discovery)
 //   - TXT (16): Text strings (service metadata)
 //   - SRV (33): Service location
@@ -138,7 +139,7 @@ func ValidateRecordType(recordType uint16) error {
 		return &errors.ValidationError{
 			Field:   "recordType",
 			Value:   recordType,
-			Message: fmt.Sprintf("unsupported record type %d (M1 supports A=1, PTR=12, TXT=16, SRV=33 per FR-002)", recordType),
+			Message: fmt.Sprintf("unsupported record type %d (supported: A=1, PTR=12, TXT=16, AAAA=28, SRV=33)", recordType),
 		}
 	}
 	return nil
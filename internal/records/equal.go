@@ -0,0 +1,146 @@
+package records
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// RecordsEqual reports whether a and b are the same resource record, the
+// identity shared by known-answer suppression (RFC 6762 §7.1), duplicate
+// suppression (§7.4) and cache flushing (§10.2).
+//
+// Records are equal when their names match case-insensitively (RFC 1035
+// §2.3.3), their types match, their classes match ignoring the cache-flush
+// bit (§10.2), and their RDATA matches in canonical form:
+//   - PTR, SRV: names in RDATA compare case-insensitively. Both records must
+//     hold uncompressed names; expand received RDATA with ExpandRDATA first.
+//   - TXT: byte-for-byte, so the same strings in a different order differ
+//     (RFC 6763 §6: order is significant).
+//   - Other types: byte-for-byte.
+//
+// TTL is not compared: a record is the same record whatever its remaining
+// lifetime.
+//
+// Parameters:
+//   - a, b: Records to compare
+//
+// Returns:
+//   - bool: true if a and b are the same record
+func RecordsEqual(a, b *ResourceRecord) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if !strings.EqualFold(a.Name, b.Name) || a.Type != b.Type {
+		return false
+	}
+	if a.Class&^protocol.DNSClass(0x8000) != b.Class&^protocol.DNSClass(0x8000) {
+		return false
+	}
+
+	switch a.Type {
+	case protocol.RecordTypePTR:
+		return nameEqualFold(a.Data, b.Data)
+	case protocol.RecordTypeSRV:
+		// Priority, weight and port, then the target name
+		return len(a.Data) >= 6 && len(b.Data) >= 6 &&
+			bytes.Equal(a.Data[:6], b.Data[:6]) && nameEqualFold(a.Data[6:], b.Data[6:])
+	default:
+		return bytes.Equal(a.Data, b.Data)
+	}
+}
+
+// nameEqualFold compares two uncompressed wire-format names, folding ASCII
+// case only (RFC 4343 §3). Length octets are below 64, so they are never
+// folded.
+func nameEqualFold(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if asciiLower(a[i]) != asciiLower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func asciiLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + ('a' - 'A')
+	}
+	return c
+}
+
+// ExpandRDATA returns answer's RDATA with the names inside PTR and SRV records
+// decompressed against msg, the packet answer was parsed from (RFC 1035
+// §4.1.4), so it can be compared with RecordsEqual. RDATA of other types is
+// returned unchanged.
+//
+// Parameters:
+//   - msg: The complete DNS message answer was parsed from
+//   - answer: A record from message.ParseMessage(msg)
+//
+// Returns:
+//   - []byte: RDATA with uncompressed names
+//   - error: WireFormatError if a name is malformed
+func ExpandRDATA(msg []byte, answer message.Answer) ([]byte, error) {
+	switch protocol.RecordType(answer.TYPE) {
+	case protocol.RecordTypePTR:
+		return expandName(msg, answer.RDATAOffset)
+	case protocol.RecordTypeSRV:
+		if len(answer.RDATA) < 6 {
+			return nil, &errors.WireFormatError{
+				Operation: "expand SRV record",
+				Offset:    answer.RDATAOffset,
+				Message:   fmt.Sprintf("truncated SRV record: %d bytes, expected at least 6", len(answer.RDATA)),
+			}
+		}
+		target, err := expandName(msg, answer.RDATAOffset+6)
+		if err != nil {
+			return nil, err
+		}
+		return append(append([]byte(nil), answer.RDATA[:6]...), target...), nil
+	default:
+		return answer.RDATA, nil
+	}
+}
+
+// expandName copies the name at offset in msg to uncompressed wire format,
+// following compression pointers. Labels are copied as-is rather than through
+// message.ParseName, which joins labels with dots and so cannot round-trip a
+// label containing one (e.g. a service instance name).
+func expandName(msg []byte, offset int) ([]byte, error) {
+	var name []byte
+	pos := offset
+	for jumps := 0; ; {
+		if pos < 0 || pos >= len(msg) {
+			return nil, &errors.WireFormatError{Operation: "expand name", Offset: pos, Message: "name runs past end of message"}
+		}
+		length := int(msg[pos])
+		switch {
+		case length == 0:
+			return append(name, 0), nil
+		case length&0xC0 == 0xC0:
+			if pos+1 >= len(msg) {
+				return nil, &errors.WireFormatError{Operation: "expand name", Offset: pos, Message: "truncated compression pointer"}
+			}
+			if jumps++; jumps > protocol.MaxCompressionPointers {
+				return nil, &errors.WireFormatError{Operation: "expand name", Offset: pos, Message: "too many compression pointers"}
+			}
+			pos = int(msg[pos]&0x3F)<<8 | int(msg[pos+1])
+		case length > protocol.MaxLabelLength:
+			return nil, &errors.WireFormatError{Operation: "expand name", Offset: pos, Message: fmt.Sprintf("label length %d exceeds %d", length, protocol.MaxLabelLength)}
+		default:
+			if pos+1+length > len(msg) {
+				return nil, &errors.WireFormatError{Operation: "expand name", Offset: pos, Message: "label runs past end of message"}
+			}
+			name = append(name, msg[pos:pos+1+length]...)
+			pos += 1 + length
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package records
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+func equalTestService() *ServiceInfo {
+	return &ServiceInfo{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         8080,
+		IPv4Address:  []byte{192, 168, 1, 100},
+		TXTRecords:   map[string]string{"version": "1.0"},
+	}
+}
+
+// TestRecordsEqual verifies names and RDATA names compare case-insensitively,
+// the cache-flush bit and TTL are ignored, and TXT strings are order-sensitive.
+func TestRecordsEqual(t *testing.T) {
+	srv := buildSRVRecord(equalTestService())
+
+	upperSRV := *srv
+	upperSRV.Name = "MY PRINTER._HTTP._TCP.LOCAL"
+	upperSRV.Data = append(append([]byte(nil), srv.Data[:6]...), mustEncodeName(t, "MyHost.LOCAL")...)
+	upperSRV.CacheFlush = false
+	upperSRV.Class |= 0x8000
+	upperSRV.TTL = 1
+
+	otherPort := *srv
+	otherPort.Data = append([]byte(nil), srv.Data...)
+	binary.BigEndian.PutUint16(otherPort.Data[4:6], 9090)
+
+	ptr := buildPTRRecord(equalTestService())
+	upperPTR := *ptr
+	upperPTR.Name = "_HTTP._tcp.local"
+
+	txt := func(strs ...string) *ResourceRecord {
+		var data []byte
+		for _, s := range strs {
+			data = append(append(data, byte(len(s))), s...)
+		}
+		return &ResourceRecord{Name: "My Printer._http._tcp.local", Type: protocol.RecordTypeTXT, Class: protocol.ClassIN, Data: data}
+	}
+
+	tests := []struct {
+		name string
+		a, b *ResourceRecord
+		want bool
+	}{
+		{"SRV case-different names, class and TTL", srv, &upperSRV, true},
+		{"SRV different port", srv, &otherPort, false},
+		{"PTR case-different name", ptr, &upperPTR, true},
+		{"different type", srv, ptr, false},
+		{"TXT same order", txt("a=1", "b=2"), txt("a=1", "b=2"), true},
+		{"TXT different order", txt("a=1", "b=2"), txt("b=2", "a=1"), false},
+		{"TXT case-different value", txt("a=x"), txt("a=X"), false},
+		{"nil", srv, nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RecordsEqual(tt.a, tt.b); got != tt.want {
+				t.Errorf("RecordsEqual() = %v, want %v", got, tt.want)
+			}
+			if got := RecordsEqual(tt.b, tt.a); got != tt.want {
+				t.Errorf("RecordsEqual(reversed) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExpandRDATA_CompressedSRV verifies an SRV record whose target is a
+// compression pointer (RFC 1035 §4.1.4) expands to RDATA equal to the
+// uncompressed record built locally.
+func TestExpandRDATA_CompressedSRV(t *testing.T) {
+	service := equalTestService()
+
+	// Question for myhost.local at offset 12, then an SRV answer whose target
+	// points back at it
+	packet := []byte{0, 0, 0, 0, 0, 1, 0, 1, 0, 0, 0, 0}
+	packet = append(packet, mustEncodeName(t, "myhost.local")...)
+	packet = append(packet, 0, byte(protocol.RecordTypeA), 0, 1)
+	owner, err := message.EncodeServiceInstanceName(service.InstanceName, service.ServiceType)
+	if err != nil {
+		t.Fatalf("EncodeServiceInstanceName() error = %v", err)
+	}
+	packet = append(packet, owner...)
+	packet = append(packet, 0, byte(protocol.RecordTypeSRV), 0, 1, 0, 0, 0, 120)
+	packet = append(packet, 0, 8, 0, 0, 0, 0, 0x1F, 0x90, 0xC0, 12) // RDLENGTH 8; port 8080; pointer
+
+	msg, err := message.ParseMessage(packet)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	compressed := msg.Answers[0]
+
+	rdata, err := ExpandRDATA(packet, compressed)
+	if err != nil {
+		t.Fatalf("ExpandRDATA() error = %v", err)
+	}
+	received := &ResourceRecord{
+		Name:  compressed.NAME,
+		Type:  protocol.RecordType(compressed.TYPE),
+		Class: protocol.DNSClass(compressed.CLASS),
+		TTL:   compressed.TTL,
+		Data:  rdata,
+	}
+	if ours := buildSRVRecord(service); !RecordsEqual(ours, received) {
+		t.Errorf("RecordsEqual(built SRV, expanded compressed SRV) = false, want true (RDATA %x vs %x)", ours.Data, rdata)
+	}
+
+	unexpanded := *received
+	unexpanded.Data = compressed.RDATA
+	if RecordsEqual(buildSRVRecord(service), &unexpanded) {
+		t.Error("RecordsEqual() matched a still-compressed SRV target, want ExpandRDATA required")
+	}
+}
+
+func mustEncodeName(t *testing.T, name string) []byte {
+	t.Helper()
+	encoded, err := message.EncodeName(name)
+	if err != nil {
+		t.Fatalf("EncodeName(%q) error = %v", name, err)
+	}
+	return encoded
+}
@@ -2,18 +2,31 @@
 package records
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"net"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
+// TXTBoolean is the TXT value sentinel marking a boolean (valueless) key.
+//
+// RFC 6763 §6.4 distinguishes three forms of a TXT attribute: "key" (present,
+// no value), "key=" (present, empty value) and "key=value". A map value equal
+// to TXTBoolean encodes as the bare "key" form; an empty string encodes as
+// "key=". The sentinel contains NUL bytes so it cannot be mistaken for a
+// typical printable value.
+const TXTBoolean = "\x00beacon:boolean\x00"
+
 // ServiceInfo holds service information for record set building.
 //
 // This is used internally to construct the full set of resource records
-// (PTR, SRV, TXT, A) for a registered service.
+// (PTR, SRV, TXT, A, and AAAA when dual-stack) for a registered service.
 //
 // T033: ServiceInfo type for BuildRecordSet()
 type ServiceInfo struct {
@@ -22,22 +35,115 @@ type ServiceInfo struct {
 	Hostname     string            // "myhost.local"
 	Port         uint16            // 8080
 	IPv4Address  []byte            // [192, 168, 1, 100]
+	IPv6Address  []byte            // 16 bytes; nil = no AAAA record
 	TXTRecords   map[string]string // {"version": "1.0"}
+	PTROnly      bool              // Build only the PTR records (no SRV/TXT/A)
+	Subtypes     []string          // Subtype labels, e.g. "_printer" (RFC 6763 §7.1)
+
+	// ExtraIPv4Addresses and ExtraIPv6Addresses are further addresses valid
+	// on the same interface as IPv4Address and IPv6Address (an alias, or a
+	// second IPv6 prefix), each advertised in its own A or AAAA record:
+	// RFC 6762 §15 requires a response to carry all of them.
+	ExtraIPv4Addresses [][]byte
+	ExtraIPv6Addresses [][]byte
+
+	// AllowLoopback accepts a loopback IPv4Address, for a responder that
+	// deliberately advertises on loopback (same-host discovery).
+	AllowLoopback bool
+
+	// AllowPlaceholderIPv4 makes the A record carry 0.0.0.0 when IPv4Address
+	// is missing or malformed, instead of Validate failing and BuildRecordSet
+	// omitting the A record.
+	AllowPlaceholderIPv4 bool
+}
+
+// Validate checks the service's addresses before its records are built, so a
+// bad address fails registration instead of being advertised.
+//
+// The IPv4 address must be 4 bytes, not 0.0.0.0, and not loopback unless
+// AllowLoopback is set; AllowPlaceholderIPv4 waives the length and 0.0.0.0
+// checks. An IPv6 address, if set, must be 16 bytes. A PTROnly service
+// advertises no address and is not checked.
+//
+// Returns:
+//   - error: ValidationError naming the invalid address field, or nil
+func (s *ServiceInfo) Validate() error {
+	if s.PTROnly {
+		return nil
+	}
+
+	ipv4 := net.IP(s.IPv4Address)
+	switch {
+	case len(s.IPv4Address) != net.IPv4len:
+		if !s.AllowPlaceholderIPv4 {
+			return &errors.ValidationError{
+				Field:   "IPv4Address",
+				Value:   s.IPv4Address,
+				Message: fmt.Sprintf("IPv4 address must be %d bytes, got %d", net.IPv4len, len(s.IPv4Address)),
+			}
+		}
+	case ipv4.IsUnspecified():
+		if !s.AllowPlaceholderIPv4 {
+			return &errors.ValidationError{
+				Field:   "IPv4Address",
+				Value:   ipv4.String(),
+				Message: "IPv4 address is unspecified (0.0.0.0); peers could not reach the service",
+			}
+		}
+	case ipv4.IsLoopback() && !s.AllowLoopback:
+		return &errors.ValidationError{
+			Field:   "IPv4Address",
+			Value:   ipv4.String(),
+			Message: "IPv4 address is loopback; only same-host peers could reach the service",
+		}
+	}
+
+	if s.IPv6Address != nil && len(s.IPv6Address) != net.IPv6len {
+		return &errors.ValidationError{
+			Field:   "IPv6Address",
+			Value:   s.IPv6Address,
+			Message: fmt.Sprintf("IPv6 address must be %d bytes, got %d", net.IPv6len, len(s.IPv6Address)),
+		}
+	}
+	return nil
 }
 
 // BuildRecordSet constructs a complete set of resource records for a service.
 //
 // RFC 6763 §6: A registered service includes:
 //   - PTR record: _service._proto.local → instance._service._proto.local
+//   - PTR record per subtype (RFC 6763 §7.1):
+//     _subtype._sub._service._proto.local → instance._service._proto.local
 //   - SRV record: instance._service._proto.local → hostname:port
 //   - TXT record: instance._service._proto.local → key-value pairs
 //   - A record: hostname.local → IPv4 address
+//   - AAAA record: hostname.local → IPv6 address, for a dual-stack host
+//     (IPv6Address set)
+//
+// Each of ExtraIPv4Addresses and ExtraIPv6Addresses adds one more A or AAAA
+// record after the primary one; addresses of the wrong length are skipped.
+//
+// RFC 6762 §10.2: Records are classified as shared or unique, which sets the
+// cache-flush bit:
+//   - Shared (CacheFlush=false): PTR. Many responders publish PTR records under
+//     the same service type name, and all must coexist in caches; flushing
+//     would evict other hosts' instances.
+//   - Unique (CacheFlush=true): SRV, TXT, A, AAAA. Only this host owns these names
+//     (enforced by probing), so peers should discard any stale copies.
+//
+// A PTROnly service advertises only that the service type exists: the PTR
+// records are built alone, with no SRV, TXT or A record behind them (e.g. for
+// a proxy or forwarder that does not own the instance).
+//
+// The A record is omitted when IPv4Address is not 4 bytes, unless
+// AllowPlaceholderIPv4 is set; call Validate first to reject such a service.
 //
 // Parameters:
 //   - service: Service information
 //
 // Returns:
-//   - []*message.ResourceRecord: All records (PTR, SRV, TXT, A)
+//   - []*message.ResourceRecord: All records (PTR, subtype PTRs, SRV, TXT, A,
+//     then AAAA if any), or just the PTRs for a PTROnly service
 //
 // FR-032: System MUST build complete record set (PTR, SRV, TXT, A)
 // T033: Implement BuildRecordSet()
@@ -48,6 +154,16 @@ func BuildRecordSet(service *ServiceInfo) []*message.ResourceRecord {
 	ptrRecord := buildPTRRecord(service)
 	records = append(records, ptrRecord)
 
+	// RFC 6763 §7.1: _subtype._sub._service._proto.local → the same instance
+	for _, subtype := range service.Subtypes {
+		subtypeRecord := buildPTRRecord(service)
+		subtypeRecord.Name = subtype + "._sub." + service.ServiceType
+		records = append(records, subtypeRecord)
+	}
+	if service.PTROnly {
+		return records
+	}
+
 	// 2. SRV record: instance._service._proto.local → hostname:port
 	srvRecord := buildSRVRecord(service)
 	records = append(records, srvRecord)
@@ -57,8 +173,24 @@ func BuildRecordSet(service *ServiceInfo) []*message.ResourceRecord {
 	records = append(records, txtRecord)
 
 	// 4. A record: hostname.local → IPv4 address
-	aRecord := buildARecord(service)
-	records = append(records, aRecord)
+	if aRecord := buildARecord(service); aRecord != nil {
+		records = append(records, aRecord)
+	}
+	for _, address := range service.ExtraIPv4Addresses {
+		if len(address) == net.IPv4len {
+			records = append(records, buildAddressRecord(service.Hostname, protocol.RecordTypeA, address))
+		}
+	}
+
+	// 5. AAAA record: hostname.local → IPv6 address (dual-stack hosts only)
+	if len(service.IPv6Address) == 16 {
+		records = append(records, buildAAAARecord(service))
+	}
+	for _, address := range service.ExtraIPv6Addresses {
+		if len(address) == net.IPv6len {
+			records = append(records, buildAddressRecord(service.Hostname, protocol.RecordTypeAAAA, address))
+		}
+	}
 
 	return records
 }
@@ -166,6 +298,7 @@ func buildTXTRecordFromService(service *ServiceInfo) *message.ResourceRecord {
 //
 // TXT record format per RFC 6763 §6.4:
 //   - Each key-value pair: length byte + "key=value" string
+//   - Boolean keys (value TXTBoolean): length byte + "key" string
 //   - Multiple pairs concatenated
 //   - Empty TXT: single 0x00 byte
 //
@@ -186,8 +319,8 @@ func buildTXTRecord(txtRecords map[string]string) []byte {
 	// Encode each key-value pair with length prefix
 	data := make([]byte, 0, 256)
 	for key, value := range txtRecords {
-		// Format: "key=value"
-		entry := key + "=" + value
+		// Format: "key=value", or bare "key" for boolean attributes
+		entry := TXTEntryString(key, value)
 
 		// Length byte + entry string
 		entryLen := byte(len(entry))
@@ -198,6 +331,15 @@ func buildTXTRecord(txtRecords map[string]string) []byte {
 	return data
 }
 
+// TXTEntryString returns the RFC 6763 §6.4 string form of a single TXT
+// attribute: "key" when value is TXTBoolean, otherwise "key=value".
+func TXTEntryString(key, value string) string {
+	if value == TXTBoolean {
+		return key
+	}
+	return key + "=" + value
+}
+
 // buildARecord constructs an A record per RFC 1035 §3.4.1.
 //
 // A record format:
@@ -211,12 +353,17 @@ func buildTXTRecord(txtRecords map[string]string) []byte {
 // RFC 6762 §10: Hostname records (A, AAAA) use 4500 seconds (75 minutes).
 // Host IP addresses change less frequently than service discovery records.
 //
+// An IPv4Address that is not 4 bytes yields nil, or a 0.0.0.0 placeholder
+// when AllowPlaceholderIPv4 is set.
+//
 // T033: A record construction
 func buildARecord(service *ServiceInfo) *message.ResourceRecord {
-	if len(service.IPv4Address) != 4 {
-		// Invalid IPv4 address - return placeholder
-		// In production, this should return an error
-		service.IPv4Address = []byte{0, 0, 0, 0}
+	address := service.IPv4Address
+	if len(address) != net.IPv4len {
+		if !service.AllowPlaceholderIPv4 {
+			return nil
+		}
+		address = []byte{0, 0, 0, 0}
 	}
 
 	return &message.ResourceRecord{
@@ -224,15 +371,66 @@ func buildARecord(service *ServiceInfo) *message.ResourceRecord {
 		Type:       protocol.RecordTypeA,
 		Class:      protocol.ClassIN,
 		TTL:        4500, // RFC 6762 §10: 4500 seconds (75 min) for hostname records
-		Data:       service.IPv4Address,
+		Data:       address,
 		CacheFlush: true, // A is unique (one hostname = one IP)
 	}
 }
 
+// buildAAAARecord constructs an AAAA record per RFC 3596 §2.
+//
+// AAAA record format:
+//   - Name: hostname.local
+//   - Type: AAAA (28)
+//   - Class: IN (1)
+//   - TTL: 4500 seconds (hostname record, like A)
+//   - RDATA: IPv6 address (16 bytes)
+//   - CacheFlush: true (AAAA is unique per RFC 6762 §10.2)
+func buildAAAARecord(service *ServiceInfo) *message.ResourceRecord {
+	return buildAddressRecord(service.Hostname, protocol.RecordTypeAAAA, service.IPv6Address)
+}
+
+// buildAddressRecord constructs a unique (cache-flush) A or AAAA record for
+// hostname with the hostname TTL (RFC 6762 §10).
+func buildAddressRecord(hostname string, rrType protocol.RecordType, address []byte) *message.ResourceRecord {
+	return &message.ResourceRecord{
+		Name:       hostname,
+		Type:       rrType,
+		Class:      protocol.ClassIN,
+		TTL:        protocol.TTLHostname,
+		Data:       address,
+		CacheFlush: true,
+	}
+}
+
 // ResourceRecord is a type alias for message.ResourceRecord.
 // This allows tests to reference ResourceRecord without importing message package.
 type ResourceRecord = message.ResourceRecord
 
+// CloneRecordSet returns a deep copy of rs: new records with their own Data
+// slices, so the copy can be handed to another goroutine while rs is still in
+// use. A nil entry stays nil; a nil rs returns nil.
+//
+// Parameters:
+//   - rs: Records to copy
+//
+// Returns:
+//   - []*ResourceRecord: Copy sharing no memory with rs
+func CloneRecordSet(rs []*ResourceRecord) []*ResourceRecord {
+	if rs == nil {
+		return nil
+	}
+	clone := make([]*ResourceRecord, len(rs))
+	for i, rr := range rs {
+		if rr == nil {
+			continue
+		}
+		c := *rr
+		c.Data = bytes.Clone(rr.Data)
+		clone[i] = &c
+	}
+	return clone
+}
+
 // RecordSet tracks per-record, per-interface multicast timestamps for rate limiting.
 //
 // RFC 6762 §6.2: "A Multicast DNS responder MUST NOT multicast a given resource record
@@ -252,9 +450,14 @@ type RecordSet struct {
 	// Value: timestamp of last multicast (Unix nanoseconds for 250ms probe defense precision)
 	lastMulticast map[string]int64
 
-	// now returns the current time; defaults to time.Now. Exists only so tests
-	// can supply a deterministic clock and exercise the RFC 6762 §6.2 rate-limit
-	// boundaries (1s / 250ms) exactly.
+	// lastMulticastByIndex is lastMulticast for the index-keyed methods
+	// (CanMulticastByIndex, RecordMulticastByIndex). A separate map keeps
+	// interface index 2 distinct from an interface named "2".
+	lastMulticastByIndex map[indexKey]int64
+
+	// now returns the current time; defaults to time.Now. Replaced via
+	// SetClock so tests can exercise the RFC 6762 §6.2 rate-limit boundaries
+	// (1s / 250ms) exactly.
 	now func() time.Time
 }
 
@@ -263,11 +466,18 @@ type RecordSet struct {
 // T073: Constructor for RecordSet
 func NewRecordSet() *RecordSet {
 	return &RecordSet{
-		lastMulticast: make(map[string]int64),
-		now:           time.Now,
+		lastMulticast:        make(map[string]int64),
+		lastMulticastByIndex: make(map[indexKey]int64),
+		now:                  time.Now,
 	}
 }
 
+// SetClock sets the clock multicast timestamps are taken from (nil = wall
+// clock).
+func (rs *RecordSet) SetClock(c clock.Clock) {
+	rs.now = clock.Or(c).Now
+}
+
 // clockNow returns the current time, tolerating a zero-value RecordSet (e.g. a
 // struct literal) by falling back to time.Now.
 func (rs *RecordSet) clockNow() time.Time {
@@ -337,6 +547,39 @@ func (rs *RecordSet) RecordMulticast(rr *ResourceRecord, interfaceID string) {
 	rs.lastMulticast[key] = rs.clockNow().UnixNano()
 }
 
+// indexKey identifies a record on an interface given by index.
+type indexKey struct {
+	record  string
+	ifIndex int
+}
+
+// CanMulticastByIndex is CanMulticast for an interface given by index, as
+// reported with each received packet, avoiding an index-to-name lookup on the
+// response path. Index-keyed and name-keyed timestamps are tracked separately.
+//
+// RFC 6762 §6.2: at least one second between multicasts of a record on an
+// interface.
+//
+// Returns:
+//   - true: Record can be multicast (≥1 second since last multicast, or never multicast)
+//   - false: Record cannot be multicast (rate limit not yet elapsed)
+func (rs *RecordSet) CanMulticastByIndex(rr *ResourceRecord, ifIndex int) bool {
+	lastTimeNano, exists := rs.lastMulticastByIndex[indexKey{rs.buildRecordKey(rr), ifIndex}]
+	if !exists {
+		return true
+	}
+	return rs.clockNow().UnixNano()-lastTimeNano >= 1e9
+}
+
+// RecordMulticastByIndex is RecordMulticast for an interface given by index;
+// see CanMulticastByIndex.
+func (rs *RecordSet) RecordMulticastByIndex(rr *ResourceRecord, ifIndex int) {
+	if rs.lastMulticastByIndex == nil {
+		rs.lastMulticastByIndex = make(map[indexKey]int64)
+	}
+	rs.lastMulticastByIndex[indexKey{rs.buildRecordKey(rr), ifIndex}] = rs.clockNow().UnixNano()
+}
+
 // GetLastMulticast returns the last multicast time for a record on an interface.
 //
 // Returns:
@@ -394,3 +637,87 @@ func BuildGoodbyeRecords(service *ServiceInfo) []*message.ResourceRecord {
 
 	return records
 }
+
+// BuildInstanceNSECRecord constructs the negative-response NSEC record for a
+// service instance name, asserting it owns only SRV and TXT records.
+//
+// RFC 6762 §6.1: "Any time a responder receives a query for a name for which
+// it has verified exclusive ownership, for a type for which that name has no
+// records, the responder MUST [...] respond asserting the nonexistence of that
+// record using a DNS NSEC record." The TTL matches the SRV/TXT records the
+// NSEC stands in for.
+//
+// Parameters:
+//   - service: Service information
+//
+// Returns:
+//   - *message.ResourceRecord: NSEC record for "instance._service._proto.local"
+func BuildInstanceNSECRecord(service *ServiceInfo) *message.ResourceRecord {
+	// Error impossible: ServiceInfo validated by responder.Service.Validate()
+	next, _ := message.EncodeServiceInstanceName(service.InstanceName, service.ServiceType) // nosemgrep: beacon-error-swallowing
+
+	return &message.ResourceRecord{
+		Name:       service.InstanceName + "." + service.ServiceType,
+		Type:       protocol.RecordTypeNSEC,
+		Class:      protocol.ClassIN,
+		TTL:        protocol.TTLService,
+		Data:       buildNSECData(next, protocol.RecordTypeTXT, protocol.RecordTypeSRV),
+		CacheFlush: true, // NSEC is unique, like the records it describes
+	}
+}
+
+// BuildHostnameNSECRecord constructs the negative-response NSEC record for a
+// service's hostname, asserting it owns only an A record (e.g. no AAAA), or
+// only A and AAAA records for a dual-stack host (IPv6Address set).
+//
+// RFC 6762 §6.1: see BuildInstanceNSECRecord. The TTL matches the A record.
+//
+// Parameters:
+//   - service: Service information
+//
+// Returns:
+//   - *message.ResourceRecord: NSEC record for service.Hostname
+func BuildHostnameNSECRecord(service *ServiceInfo) *message.ResourceRecord {
+	// Error impossible: ServiceInfo.Hostname pre-validated by caller
+	next, _ := message.EncodeName(service.Hostname) // nosemgrep: beacon-error-swallowing
+
+	types := []protocol.RecordType{protocol.RecordTypeA}
+	if len(service.IPv6Address) == 16 {
+		types = append(types, protocol.RecordTypeAAAA)
+	}
+
+	return &message.ResourceRecord{
+		Name:       service.Hostname,
+		Type:       protocol.RecordTypeNSEC,
+		Class:      protocol.ClassIN,
+		TTL:        protocol.TTLHostname,
+		Data:       buildNSECData(next, types...),
+		CacheFlush: true, // NSEC is unique, like the records it describes
+	}
+}
+
+// buildNSECData encodes NSEC RDATA in the restricted form of RFC 6762 §6.1.
+//
+// RDATA format per RFC 4034 §4.1:
+//   - Next Domain Name: RFC 6762 §6.1: "The 'Next Domain Name' field contains
+//     the record's own name."
+//   - Type Bit Maps: window block 0 only (RFC 6762 §6.1 restricts mDNS NSEC
+//     to types below 256); window number (1 byte), bitmap length (1 byte),
+//     then one bit per type, most significant bit first
+//
+// Example: TXT (16) and SRV (33) → 00 05 00 00 80 00 40
+func buildNSECData(next []byte, types ...protocol.RecordType) []byte {
+	var bitmap [32]byte
+	length := 0
+	for _, t := range types {
+		bitmap[t/8] |= 0x80 >> (t % 8)
+		if int(t/8)+1 > length {
+			length = int(t/8) + 1
+		}
+	}
+
+	data := make([]byte, 0, len(next)+2+length)
+	data = append(data, next...)
+	data = append(data, 0, byte(length)) // Window block 0
+	return append(data, bitmap[:length]...)
+}
@@ -1,9 +1,13 @@
 package records
 
 import (
+	"bytes"
+	goerrors "errors"
 	"testing"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
@@ -165,6 +169,129 @@ func TestBuildTXTRecord_MultipleKeys(t *testing.T) {
 	}
 }
 
+// TestBuildRecordSet_CacheFlushClassification verifies the RFC 6762 §10.2
+// shared/unique split: PTR is shared (no cache-flush), SRV/TXT/A are unique,
+// and goodbye records keep the same classification.
+func TestBuildRecordSet_CacheFlushClassification(t *testing.T) {
+	service := &ServiceInfo{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         8080,
+		IPv4Address:  []byte{192, 168, 1, 100},
+	}
+	want := map[protocol.RecordType]bool{
+		protocol.RecordTypePTR: false,
+		protocol.RecordTypeSRV: true,
+		protocol.RecordTypeTXT: true,
+		protocol.RecordTypeA:   true,
+	}
+
+	for setName, set := range map[string][]*message.ResourceRecord{
+		"BuildRecordSet":      BuildRecordSet(service),
+		"BuildGoodbyeRecords": BuildGoodbyeRecords(service),
+	} {
+		if len(set) != len(want) {
+			t.Fatalf("%s() returned %d records, want %d", setName, len(set), len(want))
+		}
+		for _, rr := range set {
+			if rr.CacheFlush != want[rr.Type] {
+				t.Errorf("%s() %s CacheFlush = %v, want %v", setName, rr.Type, rr.CacheFlush, want[rr.Type])
+			}
+		}
+	}
+}
+
+// TestBuildRecordSet_PTROnly verifies a PTR-only service builds, and says
+// goodbye to, just its shared PTR record.
+func TestBuildRecordSet_PTROnly(t *testing.T) {
+	service := &ServiceInfo{
+		InstanceName: "Proxied Printer",
+		ServiceType:  "_ipp._tcp.local",
+		PTROnly:      true,
+	}
+
+	for setName, set := range map[string][]*message.ResourceRecord{
+		"BuildRecordSet":      BuildRecordSet(service),
+		"BuildGoodbyeRecords": BuildGoodbyeRecords(service),
+	} {
+		if len(set) != 1 || set[0].Type != protocol.RecordTypePTR || set[0].Name != "_ipp._tcp.local" {
+			t.Errorf("%s() = %v, want only the _ipp._tcp.local PTR", setName, set)
+		}
+	}
+}
+
+// TestBuildRecordSet_Subtypes verifies each subtype adds a shared PTR named
+// "_subtype._sub._service._proto.local" pointing at the instance (RFC 6763
+// §7.1), so it is announced and said goodbye to with the service.
+func TestBuildRecordSet_Subtypes(t *testing.T) {
+	service := &ServiceInfo{
+		InstanceName: "Printer UI",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         80,
+		IPv4Address:  []byte{192, 168, 1, 100},
+		Subtypes:     []string{"_printer", "_scanner"},
+	}
+
+	for setName, set := range map[string][]*message.ResourceRecord{
+		"BuildRecordSet":      BuildRecordSet(service),
+		"BuildGoodbyeRecords": BuildGoodbyeRecords(service),
+	} {
+		ptrs := make(map[string]*message.ResourceRecord)
+		for _, rr := range set {
+			if rr.Type == protocol.RecordTypePTR {
+				ptrs[rr.Name] = rr
+			}
+		}
+		want := ptrs["_http._tcp.local"]
+		if want == nil {
+			t.Fatalf("%s() has no _http._tcp.local PTR", setName)
+		}
+		for _, name := range []string{"_printer._sub._http._tcp.local", "_scanner._sub._http._tcp.local"} {
+			rr := ptrs[name]
+			if rr == nil {
+				t.Errorf("%s() has no %s PTR", setName, name)
+				continue
+			}
+			if !bytes.Equal(rr.Data, want.Data) || rr.TTL != want.TTL || rr.CacheFlush {
+				t.Errorf("%s() %s PTR = %+v, want shared PTR like %+v", setName, name, rr, want)
+			}
+		}
+		if len(ptrs) != 3 {
+			t.Errorf("%s() has %d PTRs, want 3", setName, len(ptrs))
+		}
+	}
+}
+
+// TestBuildTXTRecord_ValuelessKeys verifies the three RFC 6763 §6.4 attribute
+// forms encode distinctly: TXTBoolean → "key", "" → "key=", "v" → "key=v".
+func TestBuildTXTRecord_ValuelessKeys(t *testing.T) {
+	data := buildTXTRecord(map[string]string{
+		"paper": TXTBoolean,
+		"note":  "",
+		"rp":    "printers/1",
+	})
+
+	decoded, err := message.ParseRDATA(uint16(protocol.RecordTypeTXT), data)
+	if err != nil {
+		t.Fatalf("ParseRDATA() failed: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, s := range decoded.([]string) {
+		got[s] = true
+	}
+
+	for _, want := range []string{"paper", "note=", "rp=printers/1"} {
+		if !got[want] {
+			t.Errorf("buildTXTRecord() strings = %q, missing %q", decoded, want)
+		}
+	}
+	if len(got) != 3 {
+		t.Errorf("buildTXTRecord() produced %d strings, want 3", len(got))
+	}
+}
+
 // TestBuildRecordSet_RED tests building complete record set for a service.
 //
 // TDD Phase: RED
@@ -373,6 +500,45 @@ func TestBuildRecordSet_ARecord(t *testing.T) {
 	}
 }
 
+// TestBuildRecordSet_AAAARecord validates that a dual-stack service (IPv6Address
+// set) gets an AAAA record for its hostname per RFC 3596 §2, with the hostname
+// TTL and cache-flush bit of the A record, and that an IPv4-only service does
+// not.
+func TestBuildRecordSet_AAAARecord(t *testing.T) {
+	ipv6 := []byte{0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0x10}
+	service := ServiceInfo{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         8080,
+		IPv4Address:  []byte{192, 168, 1, 100},
+		IPv6Address:  ipv6,
+	}
+
+	recordSet := BuildRecordSet(&service)
+	if len(recordSet) != 5 {
+		t.Fatalf("BuildRecordSet() returned %d records, want 5 (PTR, SRV, TXT, A, AAAA)", len(recordSet))
+	}
+
+	aaaa := recordSet[4]
+	if aaaa.Type != protocol.RecordTypeAAAA || aaaa.Name != "myhost.local" {
+		t.Fatalf("records[4] = %v %q, want AAAA myhost.local", aaaa.Type, aaaa.Name)
+	}
+	if aaaa.TTL != protocol.TTLHostname || !aaaa.CacheFlush {
+		t.Errorf("AAAA TTL = %d CacheFlush = %v, want %d and true", aaaa.TTL, aaaa.CacheFlush, protocol.TTLHostname)
+	}
+	if string(aaaa.Data) != string(ipv6) {
+		t.Errorf("AAAA Data = %v, want %v", aaaa.Data, ipv6)
+	}
+
+	service.IPv6Address = nil
+	for _, record := range BuildRecordSet(&service) {
+		if record.Type == protocol.RecordTypeAAAA {
+			t.Error("BuildRecordSet() included an AAAA record for an IPv4-only service")
+		}
+	}
+}
+
 // TestResourceRecord_CanMulticast tests per-record multicast rate limiting.
 //
 // RFC 6762 §6.2: "A Multicast DNS responder MUST NOT multicast a given resource record
@@ -457,6 +623,51 @@ func TestResourceRecord_CanMulticast_PerInterface(t *testing.T) {
 	}
 }
 
+// TestResourceRecord_CanMulticastByIndex verifies the index-keyed rate limit
+// enforces the 1-second rule (RFC 6762 §6.2) and is tracked separately from
+// the name-keyed one, so interface index 2 does not collide with an interface
+// named "2".
+func TestResourceRecord_CanMulticastByIndex(t *testing.T) {
+	clk := newRecordsClock()
+	rs := NewRecordSet()
+	rs.now = clk.Now
+	rr := multicastTestRecord()
+
+	rs.RecordMulticastByIndex(rr, 2)
+	if rs.CanMulticastByIndex(rr, 2) {
+		t.Error("CanMulticastByIndex(2) = true immediately after multicast on index 2, want false")
+	}
+	if !rs.CanMulticastByIndex(rr, 3) {
+		t.Error("CanMulticastByIndex(3) = false, want true (different interface)")
+	}
+	if !rs.CanMulticast(rr, "2") {
+		t.Error(`CanMulticast("2") = false after multicast on index 2, want true (name and index keys must not collide)`)
+	}
+
+	clk.advance(500 * time.Millisecond)
+	rs.RecordMulticast(rr, "2")
+	if rs.CanMulticast(rr, "2") {
+		t.Error(`CanMulticast("2") = true immediately after multicast on "2", want false`)
+	}
+
+	clk.advance(500*time.Millisecond - time.Nanosecond)
+	if rs.CanMulticastByIndex(rr, 2) {
+		t.Error("CanMulticastByIndex(2) = true at 1s-1ns elapsed, want false")
+	}
+	clk.advance(time.Nanosecond)
+	if !rs.CanMulticastByIndex(rr, 2) {
+		t.Error("CanMulticastByIndex(2) = false at exactly 1s elapsed, want true")
+	}
+	if rs.CanMulticast(rr, "2") {
+		t.Error(`CanMulticast("2") = true 500ms after its multicast, want false`)
+	}
+
+	clk.advance(500 * time.Millisecond)
+	if !rs.CanMulticast(rr, "2") {
+		t.Error(`CanMulticast("2") = false at 1s elapsed, want true`)
+	}
+}
+
 // TestResourceRecord_CanMulticast_PerRecord tests rate limiting is per-record.
 //
 // RFC 6762 §6.2: Rate limiting is for "a given resource record" - different records
@@ -510,10 +721,6 @@ func TestResourceRecord_CanMulticast_PerRecord(t *testing.T) {
 //
 // T070 [P] [US3]: Unit test probe defense rate limit exception
 func TestResourceRecord_CanMulticast_ProbeDefense(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping timing test in short mode")
-	}
-
 	rr := &ResourceRecord{
 		Name:  "myservice._http._tcp.local",
 		Type:  protocol.RecordTypeA,
@@ -522,22 +729,40 @@ func TestResourceRecord_CanMulticast_ProbeDefense(t *testing.T) {
 		Data:  []byte{192, 168, 1, 100},
 	}
 
+	clk := newRecordsClock()
 	rs := NewRecordSet()
+	rs.now = clk.Now
 
 	// Multicast rr on eth0
 	rs.RecordMulticast(rr, "eth0")
 
 	// Immediate probe defense - denied (< 250ms)
-	canMulticast := rs.CanMulticastProbeDefense(rr, "eth0")
-	if canMulticast {
+	if rs.CanMulticastProbeDefense(rr, "eth0") {
 		t.Error("CanMulticastProbeDefense() = true immediately, want false (< 250ms)")
 	}
 
 	// Regular multicast also denied (< 1 second)
-	canMulticastRegular := rs.CanMulticast(rr, "eth0")
-	if canMulticastRegular {
+	if rs.CanMulticast(rr, "eth0") {
 		t.Error("CanMulticast() = true immediately, want false (1 second minimum for regular responses)")
 	}
+
+	clk.advance(250*time.Millisecond - time.Nanosecond)
+	if rs.CanMulticastProbeDefense(rr, "eth0") {
+		t.Error("CanMulticastProbeDefense() = true just before 250ms, want false")
+	}
+
+	clk.advance(time.Nanosecond)
+	if !rs.CanMulticastProbeDefense(rr, "eth0") {
+		t.Error("CanMulticastProbeDefense() = false at 250ms, want true")
+	}
+	if rs.CanMulticast(rr, "eth0") {
+		t.Error("CanMulticast() = true at 250ms, want false (1 second minimum for regular responses)")
+	}
+
+	clk.advance(750 * time.Millisecond)
+	if !rs.CanMulticast(rr, "eth0") {
+		t.Error("CanMulticast() = false at 1s, want true")
+	}
 }
 
 // =============================================================================
@@ -598,11 +823,13 @@ func TestBuildARecord_ValidIPv4(t *testing.T) {
 // TestBuildARecord_InvalidIPv4_TooShort tests invalid IPv4 (< 4 bytes).
 //
 // Coverage: buildARecord line 221-225 (error path - invalid IPv4)
-// When IPv4 address is invalid, function should use placeholder 0.0.0.0
+// When IPv4 address is invalid and AllowPlaceholderIPv4 is set, function
+// should use placeholder 0.0.0.0
 func TestBuildARecord_InvalidIPv4_TooShort(t *testing.T) {
 	service := &ServiceInfo{
-		Hostname:    "myhost.local",
-		IPv4Address: []byte{192, 168}, // Only 2 bytes - invalid!
+		Hostname:             "myhost.local",
+		IPv4Address:          []byte{192, 168}, // Only 2 bytes - invalid!
+		AllowPlaceholderIPv4: true,
 	}
 
 	record := buildARecord(service)
@@ -627,8 +854,9 @@ func TestBuildARecord_InvalidIPv4_TooShort(t *testing.T) {
 // Coverage: buildARecord line 221-225 (error path - invalid IPv4)
 func TestBuildARecord_InvalidIPv4_TooLong(t *testing.T) {
 	service := &ServiceInfo{
-		Hostname:    "myhost.local",
-		IPv4Address: []byte{192, 168, 1, 100, 1}, // 5 bytes - invalid!
+		Hostname:             "myhost.local",
+		IPv4Address:          []byte{192, 168, 1, 100, 1}, // 5 bytes - invalid!
+		AllowPlaceholderIPv4: true,
 	}
 
 	record := buildARecord(service)
@@ -653,8 +881,9 @@ func TestBuildARecord_InvalidIPv4_TooLong(t *testing.T) {
 // Coverage: buildARecord line 221-225 (error path - empty IPv4)
 func TestBuildARecord_EmptyIPv4(t *testing.T) {
 	service := &ServiceInfo{
-		Hostname:    "myhost.local",
-		IPv4Address: []byte{}, // Empty - invalid!
+		Hostname:             "myhost.local",
+		IPv4Address:          []byte{}, // Empty - invalid!
+		AllowPlaceholderIPv4: true,
 	}
 
 	record := buildARecord(service)
@@ -673,3 +902,94 @@ func TestBuildARecord_EmptyIPv4(t *testing.T) {
 
 	t.Log("✓ buildARecord() with empty IPv4 creates placeholder 0.0.0.0")
 }
+
+// TestBuildRecordSet_InvalidIPv4_OmitsARecord verifies an invalid IPv4
+// address is not advertised as 0.0.0.0 unless AllowPlaceholderIPv4 is set.
+func TestBuildRecordSet_InvalidIPv4_OmitsARecord(t *testing.T) {
+	service := &ServiceInfo{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         8080,
+		IPv4Address:  []byte{192, 168},
+	}
+
+	for _, rr := range BuildRecordSet(service) {
+		if rr.Type == protocol.RecordTypeA {
+			t.Errorf("BuildRecordSet() built A record %v for a 2-byte IPv4 address, want none", rr.Data)
+		}
+	}
+}
+
+// TestServiceInfo_Validate verifies Validate rejects missing, malformed,
+// unspecified and unintended loopback addresses with a ValidationError.
+func TestServiceInfo_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    ServiceInfo
+		wantErr bool
+	}{
+		{"valid", ServiceInfo{IPv4Address: []byte{192, 168, 1, 100}}, false},
+		{"missing", ServiceInfo{}, true},
+		{"wrong length", ServiceInfo{IPv4Address: []byte{192, 168, 1}}, true},
+		{"unspecified", ServiceInfo{IPv4Address: []byte{0, 0, 0, 0}}, true},
+		{"loopback", ServiceInfo{IPv4Address: []byte{127, 0, 0, 1}}, true},
+		{"loopback allowed", ServiceInfo{IPv4Address: []byte{127, 0, 0, 1}, AllowLoopback: true}, false},
+		{"placeholder allowed", ServiceInfo{AllowPlaceholderIPv4: true}, false},
+		{"PTR only", ServiceInfo{PTROnly: true}, false},
+		{"bad IPv6", ServiceInfo{IPv4Address: []byte{192, 168, 1, 100}, IPv6Address: []byte{0xfd, 0}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.info.Validate()
+			if !tt.wantErr {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			var valErr *errors.ValidationError
+			if !goerrors.As(err, &valErr) {
+				t.Errorf("Validate() error = %v, want ValidationError", err)
+			}
+		})
+	}
+}
+
+// TestCloneRecordSet verifies the copy shares no record or RDATA with the
+// original, so either can be modified without affecting the other.
+func TestCloneRecordSet(t *testing.T) {
+	original := BuildRecordSet(&ServiceInfo{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Hostname:     "myhost.local",
+		Port:         8080,
+		IPv4Address:  []byte{192, 168, 1, 100},
+		TXTRecords:   map[string]string{"version": "1.0"},
+	})
+
+	clone := CloneRecordSet(original)
+	if len(clone) != len(original) {
+		t.Fatalf("CloneRecordSet() returned %d records, want %d", len(clone), len(original))
+	}
+	for i := range clone {
+		if clone[i] == original[i] {
+			t.Fatalf("CloneRecordSet()[%d] is the original record, want a copy", i)
+		}
+		if !RecordsEqual(clone[i], original[i]) || clone[i].TTL != original[i].TTL || clone[i].CacheFlush != original[i].CacheFlush {
+			t.Errorf("CloneRecordSet()[%d] = %+v, want %+v", i, clone[i], original[i])
+		}
+		clone[i].Data[0] ^= 0xff
+		if clone[i].Data[0] == original[i].Data[0] {
+			t.Errorf("CloneRecordSet()[%d] shares its Data with the original", i)
+		}
+	}
+
+	if got := CloneRecordSet(nil); got != nil {
+		t.Errorf("CloneRecordSet(nil) = %v, want nil", got)
+	}
+	if got := CloneRecordSet([]*ResourceRecord{nil}); len(got) != 1 || got[0] != nil {
+		t.Errorf("CloneRecordSet([nil]) = %v, want [nil]", got)
+	}
+}
@@ -4,6 +4,7 @@ package records
 import (
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
@@ -17,8 +18,9 @@ type RecordTTL struct {
 	TTL        uint32    // Initial TTL in seconds
 	CreatedAt  time.Time // Creation timestamp for TTL calculation
 
-	// now returns the current time; defaults to time.Now. Exists only so tests
-	// can supply a deterministic clock and exercise the expiry boundary exactly.
+	// now returns the current time; defaults to time.Now. Set from a
+	// clock.Clock by NewRecordTTLWithClock so tests can exercise the expiry
+	// boundary exactly.
 	now func() time.Time
 }
 
@@ -42,11 +44,18 @@ func (r *RecordTTL) clockNow() time.Time {
 //
 // T017: Create records with timestamp for TTL calculation
 func NewRecordTTL(rt protocol.RecordType, ttl uint32) *RecordTTL {
+	return NewRecordTTLWithClock(rt, ttl, clock.Real)
+}
+
+// NewRecordTTLWithClock is like NewRecordTTL but takes creation and
+// remaining-TTL times from c (nil = wall clock).
+func NewRecordTTLWithClock(rt protocol.RecordType, ttl uint32, c clock.Clock) *RecordTTL {
+	c = clock.Or(c)
 	return &RecordTTL{
 		RecordType: rt,
 		TTL:        ttl,
-		CreatedAt:  time.Now(),
-		now:        time.Now,
+		CreatedAt:  c.Now(),
+		now:        c.Now,
 	}
 }
 
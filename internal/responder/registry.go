@@ -2,10 +2,18 @@
 package responder
 
 import (
+	"errors"
 	"fmt"
+	"net"
+	"slices"
+	"strings"
 	"sync"
 )
 
+// ErrFull is returned (wrapped) by Register when the registry already holds
+// its limit of services (SetLimit).
+var ErrFull = errors.New("service limit reached")
+
 // Registry manages registered mDNS services with thread-safe access.
 //
 // R006 Decision: Use sync.RWMutex for concurrent access
@@ -16,6 +24,20 @@ import (
 type Registry struct {
 	mu       sync.RWMutex
 	services map[string]*Service
+
+	// subtypes indexes instance names by lower-cased subtype name
+	// ("_printer._sub._http._tcp.local"), for answering subtype browses
+	// (RFC 6763 §7.1) without scanning every service
+	subtypes map[string][]string
+
+	// generation counts changes to the set of services (Register, Remove,
+	// Clear), so callers can tell when data derived from it is stale
+	generation uint64
+
+	// limit caps the number of services (0 = unlimited); reserved slots
+	// (Reserve) count against it
+	limit    int
+	reserved int
 }
 
 // NewRegistry creates a new service registry.
@@ -27,6 +49,7 @@ type Registry struct {
 func NewRegistry() *Registry {
 	return &Registry{
 		services: make(map[string]*Service),
+		subtypes: make(map[string][]string),
 	}
 }
 
@@ -36,12 +59,36 @@ func NewRegistry() *Registry {
 //   - service: The service to register
 //
 // Returns:
-//   - error: Error if service with same InstanceName already exists
+//   - error: Error if service with same InstanceName already exists, or
+//     wrapping ErrFull if the registry holds its limit of services
 //
 // Thread-safe: Uses write lock (RWMutex.Lock)
 //
 // T013: Implement Register with duplicate detection
 func (r *Registry) Register(service *Service) error {
+	return r.register(service, false)
+}
+
+// RegisterReserved is like Register but fills a slot taken earlier with
+// Reserve, so it cannot fail with ErrFull. The reservation is used up
+// whether or not the service is added.
+//
+// Thread-safe: Uses write lock (RWMutex.Lock)
+func (r *Registry) RegisterReserved(service *Service) error {
+	return r.register(service, true)
+}
+
+// register adds service, in a slot taken by Reserve if reserved.
+func (r *Registry) register(service *Service, reserved bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// A reserved slot is used up whether or not the service is added
+	hasSlot := reserved && r.reserved > 0
+	if hasSlot {
+		r.reserved--
+	}
+
 	if service == nil {
 		return fmt.Errorf("cannot register nil service")
 	}
@@ -50,15 +97,20 @@ func (r *Registry) Register(service *Service) error {
 		return fmt.Errorf("service InstanceName cannot be empty")
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
 	// Check for duplicate
 	if _, exists := r.services[service.InstanceName]; exists {
 		return fmt.Errorf("service with InstanceName %q already registered", service.InstanceName)
 	}
 
+	if !hasSlot && r.limit > 0 && len(r.services)+r.reserved >= r.limit {
+		return fmt.Errorf("%w (%d services)", ErrFull, r.limit)
+	}
+
 	r.services[service.InstanceName] = service
+	for _, name := range subtypeNames(service) {
+		r.subtypes[name] = append(r.subtypes[name], service.InstanceName)
+	}
+	r.generation++
 	return nil
 }
 
@@ -82,6 +134,54 @@ func (r *Registry) Get(instanceName string) (*Service, bool) {
 	return service, exists
 }
 
+// TXT returns the TXT records of a registered service.
+//
+// The returned map is shared and must not be modified: UpdateTXT replaces a
+// service's map rather than changing it, so a map once returned stays valid.
+//
+// Parameters:
+//   - instanceName: The service instance name to look up
+//
+// Returns:
+//   - map[string]string: The service's TXT records
+//   - bool: true if service exists, false otherwise
+//
+// Thread-safe: Uses read lock (RWMutex.RLock)
+func (r *Registry) TXT(instanceName string) (map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	service, exists := r.services[instanceName]
+	if !exists {
+		return nil, false
+	}
+	return service.TXT, true
+}
+
+// UpdateTXT replaces the TXT records of a registered service. The registry
+// takes ownership of txt; the caller must not modify it afterwards.
+//
+// Parameters:
+//   - instanceName: The service instance name to update
+//   - txt: New TXT records
+//
+// Returns:
+//   - error: Error if service not found
+//
+// Thread-safe: Uses write lock (RWMutex.Lock), so readers going through TXT
+// never see a partial update.
+func (r *Registry) UpdateTXT(instanceName string, txt map[string]string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	service, exists := r.services[instanceName]
+	if !exists {
+		return fmt.Errorf("service with InstanceName %q not found", instanceName)
+	}
+	service.TXT = txt
+	return nil
+}
+
 // Remove removes a service from the registry.
 //
 // Parameters:
@@ -97,14 +197,136 @@ func (r *Registry) Remove(instanceName string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if _, exists := r.services[instanceName]; !exists {
+	service, exists := r.services[instanceName]
+	if !exists {
 		return fmt.Errorf("service with InstanceName %q not found", instanceName)
 	}
 
 	delete(r.services, instanceName)
+	for _, name := range subtypeNames(service) {
+		instances := slices.DeleteFunc(r.subtypes[name], func(n string) bool { return n == instanceName })
+		if len(instances) == 0 {
+			delete(r.subtypes, name)
+		} else {
+			r.subtypes[name] = instances
+		}
+	}
+	r.generation++
 	return nil
 }
 
+// Clear removes every service from the registry in one step.
+//
+// Returns:
+//   - []*Service: The services that were registered (empty if none)
+//
+// Thread-safe: Uses write lock (RWMutex.Lock), so no concurrent Register can
+// interleave between the snapshot and the reset.
+func (r *Registry) Clear() []*Service {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	removed := make([]*Service, 0, len(r.services))
+	for _, service := range r.services {
+		removed = append(removed, service)
+	}
+	r.services = make(map[string]*Service)
+	r.subtypes = make(map[string][]string)
+	r.generation++
+	return removed
+}
+
+// Subtype returns the instance names of the services registered with the
+// given subtype, named in full as "_printer._sub._http._tcp.local"
+// (RFC 6763 §7.1). The match is case-insensitive.
+//
+// Returns:
+//   - []string: Instance names (nil if none)
+//
+// Thread-safe: Uses read lock (RWMutex.RLock)
+func (r *Registry) Subtype(subtypeName string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.subtypes[strings.ToLower(subtypeName)])
+}
+
+// SubtypeName returns the name browsed for instances of serviceType with the
+// given subtype: "_printer" and "_http._tcp.local" give
+// "_printer._sub._http._tcp.local" (RFC 6763 §7.1).
+func SubtypeName(subtype, serviceType string) string {
+	return subtype + "._sub." + serviceType
+}
+
+// subtypeNames returns the lower-cased subtype names service is indexed by.
+func subtypeNames(service *Service) []string {
+	names := make([]string, 0, len(service.Subtypes))
+	for _, subtype := range service.Subtypes {
+		names = append(names, strings.ToLower(SubtypeName(subtype, service.ServiceType)))
+	}
+	return names
+}
+
+// Generation returns a counter that changes whenever a service is registered
+// or removed, for caching data derived from the registry (e.g. the RFC 6763
+// §9 service type enumeration response).
+//
+// Thread-safe: Uses read lock (RWMutex.RLock)
+func (r *Registry) Generation() uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.generation
+}
+
+// SetLimit caps the number of services the registry holds: once it holds n,
+// Register fails with ErrFull. n <= 0 removes the cap.
+//
+// Thread-safe: Uses write lock (RWMutex.Lock)
+func (r *Registry) SetLimit(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.limit = max(n, 0)
+}
+
+// Full reports whether the registry holds its limit of services (SetLimit),
+// counting reserved slots.
+//
+// Thread-safe: Uses read lock (RWMutex.RLock)
+func (r *Registry) Full() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.limit > 0 && len(r.services)+r.reserved >= r.limit
+}
+
+// Reserve takes a slot for a service to be added later with
+// RegisterReserved, so a registration that takes time (probing) cannot find
+// the registry full at the end. An unused slot must be given back with
+// Release.
+//
+// Returns:
+//   - error: wrapping ErrFull if no slot is free
+//
+// Thread-safe: Uses write lock (RWMutex.Lock)
+func (r *Registry) Reserve() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.limit > 0 && len(r.services)+r.reserved >= r.limit {
+		return fmt.Errorf("%w (%d services)", ErrFull, r.limit)
+	}
+	r.reserved++
+	return nil
+}
+
+// Release gives back a slot taken by Reserve and not used.
+//
+// Thread-safe: Uses write lock (RWMutex.Lock)
+func (r *Registry) Release() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reserved > 0 {
+		r.reserved--
+	}
+}
+
 // List returns all registered service instance names.
 //
 // Returns:
@@ -165,5 +387,9 @@ type Service struct {
 	InstanceName string
 	ServiceType  string
 	Port         uint16
-	TXT          map[string]string
+	TXT          map[string]string // Read via Registry.TXT and replaced via UpdateTXT once registered
+	Hostname     string            // SRV target override; empty = responder hostname
+	PTROnly      bool              // Advertise only the PTR record (no SRV/TXT/A)
+	ProxyAddress net.IP            // IPv4 address of the proxied host; nil = this host's
+	Subtypes     []string          // Subtype labels, e.g. "_printer" (RFC 6763 §7.1)
 }
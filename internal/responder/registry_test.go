@@ -1,6 +1,8 @@
 package responder
 
 import (
+	"errors"
+	"slices"
 	"sync"
 	"testing"
 )
@@ -124,6 +126,207 @@ func TestRegistry_Remove_NotFound(t *testing.T) {
 	}
 }
 
+// TestRegistry_UpdateTXT tests that UpdateTXT replaces a service's TXT
+// records, leaving a map returned earlier by TXT untouched, and fails for an
+// unknown service.
+func TestRegistry_UpdateTXT(t *testing.T) {
+	registry := NewRegistry()
+
+	service := &Service{
+		InstanceName: "My Printer",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+		TXT:          map[string]string{"version": "1.0"},
+	}
+	if err := registry.Register(service); err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+	before, _ := registry.TXT(service.InstanceName)
+
+	if err := registry.UpdateTXT(service.InstanceName, map[string]string{"version": "2.0"}); err != nil {
+		t.Fatalf("UpdateTXT() error = %v, want nil", err)
+	}
+	after, exists := registry.TXT(service.InstanceName)
+	if !exists || after["version"] != "2.0" {
+		t.Errorf("TXT() = %v, %v after UpdateTXT(), want version=2.0, true", after, exists)
+	}
+	if before["version"] != "1.0" {
+		t.Errorf("TXT() map from before UpdateTXT() = %v, want it unchanged", before)
+	}
+
+	if err := registry.UpdateTXT("non-existent", nil); err == nil {
+		t.Error("UpdateTXT(non-existent) error = nil, want error")
+	}
+	if _, exists := registry.TXT("non-existent"); exists {
+		t.Error("TXT(non-existent) exists=true, want false")
+	}
+}
+
+// TestRegistry_Subtype tests that the subtype index follows Register, Remove
+// and Clear, and matches subtype names case-insensitively.
+func TestRegistry_Subtype(t *testing.T) {
+	registry := NewRegistry()
+	for _, service := range []*Service{
+		{InstanceName: "Printer UI", ServiceType: "_http._tcp.local", Port: 80, Subtypes: []string{"_printer", "_admin"}},
+		{InstanceName: "Scanner UI", ServiceType: "_http._tcp.local", Port: 80, Subtypes: []string{"_printer"}},
+		{InstanceName: "Wiki", ServiceType: "_http._tcp.local", Port: 80},
+	} {
+		if err := registry.Register(service); err != nil {
+			t.Fatalf("Register(%q) error = %v", service.InstanceName, err)
+		}
+	}
+
+	printer := SubtypeName("_printer", "_http._tcp.local")
+	got := registry.Subtype("_PRINTER._sub._http._tcp.local")
+	slices.Sort(got)
+	if want := []string{"Printer UI", "Scanner UI"}; !slices.Equal(got, want) {
+		t.Errorf("Subtype(%q) = %v, want %v", printer, got, want)
+	}
+
+	if err := registry.Remove("Printer UI"); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got := registry.Subtype(printer); !slices.Equal(got, []string{"Scanner UI"}) {
+		t.Errorf("Subtype(%q) after Remove = %v, want [Scanner UI]", printer, got)
+	}
+	if got := registry.Subtype(SubtypeName("_admin", "_http._tcp.local")); got != nil {
+		t.Errorf("Subtype(_admin) after Remove = %v, want nil", got)
+	}
+
+	registry.Clear()
+	if got := registry.Subtype(printer); got != nil {
+		t.Errorf("Subtype(%q) after Clear = %v, want nil", printer, got)
+	}
+}
+
+// TestRegistry_Clear tests that Clear empties the registry and returns the
+// removed services.
+func TestRegistry_Clear(t *testing.T) {
+	registry := NewRegistry()
+	for _, name := range []string{"A", "B", "C"} {
+		if err := registry.Register(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("Register(%q) error = %v", name, err)
+		}
+	}
+
+	removed := registry.Clear()
+	if len(removed) != 3 {
+		t.Errorf("Clear() returned %d services, want 3", len(removed))
+	}
+	if names := registry.List(); len(names) != 0 {
+		t.Errorf("List() after Clear() = %v, want empty", names)
+	}
+
+	// Registry remains usable after Clear
+	if err := registry.Register(&Service{InstanceName: "A", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Errorf("Register() after Clear() error = %v", err)
+	}
+}
+
+// TestRegistry_Generation verifies the generation changes on every Register,
+// Remove and Clear, and not on reads or failed changes.
+func TestRegistry_Generation(t *testing.T) {
+	registry := NewRegistry()
+	last := registry.Generation()
+	changed := func(op string, want bool) {
+		t.Helper()
+		got := registry.Generation()
+		if (got != last) != want {
+			t.Errorf("after %s: generation %d -> %d, want changed = %v", op, last, got, want)
+		}
+		last = got
+	}
+
+	_ = registry.Register(&Service{InstanceName: "A", ServiceType: "_http._tcp.local", Port: 80})
+	changed("Register", true)
+	_ = registry.Register(&Service{InstanceName: "A", ServiceType: "_http._tcp.local", Port: 80})
+	changed("duplicate Register", false)
+	_ = registry.ListServiceTypes()
+	changed("ListServiceTypes", false)
+	_ = registry.Remove("A")
+	changed("Remove", true)
+	_ = registry.Remove("A")
+	changed("Remove of unknown service", false)
+	_ = registry.Register(&Service{InstanceName: "B", ServiceType: "_ssh._tcp.local", Port: 22})
+	_ = registry.Clear()
+	if registry.Generation() == last {
+		t.Error("generation unchanged after Register and Clear")
+	}
+}
+
+// TestRegistry_SetLimit verifies that concurrent registrations cannot exceed
+// the limit, that the overflow fails with ErrFull, and that removing a service
+// makes room again.
+func TestRegistry_SetLimit(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetLimit(3)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- registry.Register(&Service{InstanceName: string(rune('A' + i)), ServiceType: "_http._tcp.local", Port: 80})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	full := 0
+	for err := range errs {
+		if err != nil && !errors.Is(err, ErrFull) {
+			t.Errorf("Register() error = %v, want nil or ErrFull", err)
+		}
+		if errors.Is(err, ErrFull) {
+			full++
+		}
+	}
+	if got := len(registry.List()); got != 3 || full != 7 || !registry.Full() {
+		t.Fatalf("registered %d (ErrFull %d times, Full() = %v), want 3 (7 times, true)", got, full, registry.Full())
+	}
+
+	_ = registry.Remove(registry.List()[0])
+	if registry.Full() {
+		t.Error("Full() = true after Remove, want false")
+	}
+	if err := registry.Register(&Service{InstanceName: "Z", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Errorf("Register() after Remove error = %v, want nil", err)
+	}
+}
+
+// TestRegistry_Reserve verifies that reserved slots count against the limit,
+// that RegisterReserved fills one even when the registry is otherwise full,
+// and that Release gives an unused one back.
+func TestRegistry_Reserve(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetLimit(2)
+
+	if err := registry.Reserve(); err != nil {
+		t.Fatalf("Reserve() error = %v", err)
+	}
+	if err := registry.Reserve(); err != nil {
+		t.Fatalf("second Reserve() error = %v", err)
+	}
+	if err := registry.Reserve(); !errors.Is(err, ErrFull) {
+		t.Fatalf("third Reserve() error = %v, want ErrFull", err)
+	}
+	if err := registry.Register(&Service{InstanceName: "A", ServiceType: "_http._tcp.local", Port: 80}); !errors.Is(err, ErrFull) {
+		t.Fatalf("Register() with every slot reserved error = %v, want ErrFull", err)
+	}
+
+	if err := registry.RegisterReserved(&Service{InstanceName: "B", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Fatalf("RegisterReserved() error = %v", err)
+	}
+	registry.Release()
+	if registry.Full() {
+		t.Error("Full() = true after Release, want false")
+	}
+	if err := registry.Register(&Service{InstanceName: "C", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Errorf("Register() after Release error = %v", err)
+	}
+}
+
 // TestRegistry_ConcurrentAccess_RED tests concurrent registration and retrieval.
 //
 // TDD Phase: RED
@@ -2,6 +2,7 @@
 package responder
 
 import (
+	"bytes"
 	"fmt"
 	"strings"
 
@@ -28,6 +29,18 @@ type ResponseBuilder struct {
 	maxPacketSize int // RFC 6762 §17: 9000 bytes maximum
 }
 
+// Response size bounds accepted by SetMaxPacketSize.
+const (
+	// MaxPacketSize is the RFC 6762 §17 maximum mDNS message size: "Even when
+	// fragmentation is used, a Multicast DNS packet, including IP and UDP
+	// headers, MUST NOT exceed 9000 bytes."
+	MaxPacketSize = 9000
+
+	// MinPacketSize is the classic DNS UDP message size (RFC 1035 §2.3.4),
+	// which every receiver accepts.
+	MinPacketSize = 512
+)
+
 // ServiceWithIP extends Service with IP address for testing.
 //
 // T075: Service type for ResponseBuilder tests
@@ -37,8 +50,14 @@ type ServiceWithIP struct {
 	Domain       string
 	Port         uint16
 	IPv4Address  []byte
+	IPv6Address  []byte // nil unless the host is dual-stack (AAAA record)
 	TXTRecords   map[string]string
 	Hostname     string
+	PTROnly      bool // Only the PTR record exists (records.ServiceInfo.PTROnly)
+
+	// Further addresses on the same interface (records.ServiceInfo.ExtraIPv4Addresses)
+	ExtraIPv4Addresses [][]byte
+	ExtraIPv6Addresses [][]byte
 }
 
 // NewResponseBuilder creates a new ResponseBuilder with RFC 6762 defaults.
@@ -48,15 +67,24 @@ type ServiceWithIP struct {
 // T075: ResponseBuilder constructor
 func NewResponseBuilder() *ResponseBuilder {
 	return &ResponseBuilder{
-		maxPacketSize: 9000, // RFC 6762 §17
+		maxPacketSize: MaxPacketSize, // RFC 6762 §17
 	}
 }
 
+// SetMaxPacketSize caps the serialized size of responses built by Finalize.
+//
+// Constrained links (e.g. 6LoWPAN or a 1280-byte IPv6 minimum MTU) need
+// responses well below the RFC 6762 §17 maximum to avoid fragmentation.
+// Callers validate size against MinPacketSize and MaxPacketSize.
+func (rb *ResponseBuilder) SetMaxPacketSize(size int) {
+	rb.maxPacketSize = size
+}
+
 // BuildResponse constructs an mDNS response for a query per RFC 6762 §6.
 //
 // RFC 6762 §6: For a PTR query, the response MUST contain:
 //   - Answer section: PTR record pointing to service instance
-//   - Additional section: SRV, TXT, A records (reduces round-trips)
+//   - Additional section: SRV, TXT, A (and AAAA) records (reduces round-trips)
 //
 // R005 Decision: Greedy packing - add all answer records (critical), then
 // add additional records until 9000-byte limit reached.
@@ -74,28 +102,73 @@ func (rb *ResponseBuilder) BuildResponse(service *ServiceWithIP, query *message.
 		return nil, fmt.Errorf("query cannot be nil")
 	}
 
-	// Build response header per RFC 6762 §6
-	// Flags: QR=1 (response), OPCODE=0, AA=1 (authoritative), TC=0, RD=0, RA=0, Z=0, RCODE=0
-	// Bit 15 (QR=1): 0x8000
-	// Bit 10 (AA=1): 0x0400
-	// Total: 0x8400
-	flags := uint16(0x8400) // QR=1, AA=1
-
-	response := &message.DNSMessage{
-		Header: message.DNSHeader{
-			ID:      query.Header.ID, // Match query ID
-			Flags:   flags,           // Response with authoritative answer
-			QDCount: 0,               // No questions in response per RFC 6762 §6
-			ANCount: 0,               // Will be set after building
-			NSCount: 0,               // No authority records
-			ARCount: 0,               // Will be set after building
-		},
+	response := rb.NewResponse(query)
+	knownAnswers := KnownAnswers(query)
+
+	// Answer every question this service is authoritative for
+	for _, question := range query.Questions {
+		rb.AddServiceRecords(response, service, question, knownAnswers)
+	}
+
+	rb.Finalize(response, false)
+	return response, nil
+}
+
+// NewResponse creates an empty authoritative response to query per RFC 6762 §6.
+//
+// Callers append records with AddServiceRecords (possibly for several services
+// and questions) and then call Finalize.
+func (rb *ResponseBuilder) NewResponse(query *message.DNSMessage) *message.DNSMessage {
+	// Build response header per RFC 6762 §6 and §18: QR=1, AA=1, echoing
+	// the query ID (the caller zeroes it for a multicast response, §18.1).
+	// No questions in the response (§6); the answer and additional counts
+	// are set by Finalize.
+	return &message.DNSMessage{
+		Header:      message.NewResponseHeader(query.Header.ID),
 		Questions:   []message.Question{}, // RFC 6762 §6: No questions in response
 		Answers:     []message.Answer{},   // Will populate based on query
 		Authorities: []message.Answer{},   // Empty for mDNS
 		Additionals: []message.Answer{},   // Will populate with SRV, TXT, A
 	}
+}
+
+// KnownAnswers converts a query's Answer section into the known-answer list
+// used for suppression per RFC 6762 §7.1.
+//
+// T095: Convert query known-answers (Answer section) to ResourceRecords for suppression
+func KnownAnswers(query *message.DNSMessage) []*message.ResourceRecord {
+	knownAnswers := make([]*message.ResourceRecord, 0, len(query.Answers))
+	for _, answer := range query.Answers {
+		// Convert message.Answer to message.ResourceRecord for ApplyKnownAnswerSuppression
+		knownAnswers = append(knownAnswers, &message.ResourceRecord{
+			Name:       answer.NAME,
+			Type:       protocol.RecordType(answer.TYPE),
+			Class:      protocol.DNSClass(answer.CLASS),
+			TTL:        answer.TTL,
+			Data:       answer.RDATA,
+			CacheFlush: (answer.CLASS & 0x8000) != 0,
+		})
+	}
+	return knownAnswers
+}
 
+// AddServiceRecords appends the records of service that answer question to
+// response, skipping records already present so that answers for several
+// services and questions aggregate into one message.
+//
+// Answer/additional selection per RFC 6763 §12:
+//   - PTR:  PTR answer; SRV, TXT, A, AAAA additionals
+//   - SRV:  SRV answer; A, AAAA additionals
+//   - TXT:  TXT answer
+//   - A:    A answer
+//   - AAAA: AAAA answer
+//
+// AAAA records exist only for a dual-stack service (IPv6Address set); RFC
+// 6762 §6 asks for both address families in the additional section so a
+// dual-stack querier can connect over either without another query.
+//
+// Records in the querier's known-answer list are suppressed (RFC 6762 §7.1).
+func (rb *ResponseBuilder) AddServiceRecords(response *message.DNSMessage, service *ServiceWithIP, question message.Question, knownAnswers []*message.ResourceRecord) {
 	// Convert Service to records.ServiceInfo for record building
 	serviceInfo := &records.ServiceInfo{
 		InstanceName: service.InstanceName,
@@ -103,78 +176,221 @@ func (rb *ResponseBuilder) BuildResponse(service *ServiceWithIP, query *message.
 		Hostname:     rb.getHostname(service),
 		Port:         service.Port,
 		IPv4Address:  service.IPv4Address,
+		IPv6Address:  service.IPv6Address,
 		TXTRecords:   service.TXTRecords,
+		PTROnly:      service.PTROnly,
+
+		ExtraIPv4Addresses: service.ExtraIPv4Addresses,
+		ExtraIPv6Addresses: service.ExtraIPv6Addresses,
+	}
+
+	var answerType protocol.RecordType
+	var additionalTypes []protocol.RecordType
+	switch protocol.RecordType(question.QTYPE) {
+	case protocol.RecordTypePTR:
+		answerType = protocol.RecordTypePTR
+		additionalTypes = []protocol.RecordType{protocol.RecordTypeSRV, protocol.RecordTypeTXT, protocol.RecordTypeA, protocol.RecordTypeAAAA}
+	case protocol.RecordTypeSRV:
+		answerType = protocol.RecordTypeSRV
+		additionalTypes = []protocol.RecordType{protocol.RecordTypeA, protocol.RecordTypeAAAA}
+	case protocol.RecordTypeTXT:
+		answerType = protocol.RecordTypeTXT
+	case protocol.RecordTypeA:
+		answerType = protocol.RecordTypeA
+	case protocol.RecordTypeAAAA:
+		answerType = protocol.RecordTypeAAAA
+	default:
+		return
 	}
 
 	// Build all records for this service
 	allRecords := records.BuildRecordSet(serviceInfo)
 
-	// T095: Convert query known-answers (Answer section) to ResourceRecords for suppression
-	knownAnswers := make([]*message.ResourceRecord, 0, len(query.Answers))
-	for _, answer := range query.Answers {
-		// Convert message.Answer to message.ResourceRecord for ApplyKnownAnswerSuppression
-		knownAnswers = append(knownAnswers, &message.ResourceRecord{
-			Name:       answer.NAME,
-			Type:       protocol.RecordType(answer.TYPE),
-			Class:      protocol.DNSClass(answer.CLASS),
-			TTL:        answer.TTL,
-			Data:       answer.RDATA,
-			CacheFlush: (answer.CLASS & 0x8000) != 0,
-		})
+	for _, rr := range allRecords {
+		if rr.Type == protocol.RecordTypePTR && answerType == protocol.RecordTypePTR {
+			// A subtype browse ("_printer._sub._http._tcp.local") is answered
+			// with a PTR record of that name (RFC 6763 §7.1)
+			rr.Name = question.QNAME
+		}
+		// T095: Apply known-answer suppression per RFC 6762 §7.1
+		// T096: TODO - log suppressed record
+		if rr.Type != answerType || !rb.ApplyKnownAnswerSuppression(rr, knownAnswers) {
+			continue
+		}
+		answer := rb.recordToAnswer(rr)
+		if !containsAnswer(response.Answers, answer) {
+			response.Answers = append(response.Answers, answer)
+		}
 	}
 
-	// For PTR query, answer is PTR record, additional is SRV/TXT/A
-	// For now, assume first question is PTR query (will enhance later)
-	if len(query.Questions) > 0 {
-		question := query.Questions[0]
-
-		// Check if this is a PTR query
-		if question.QTYPE == uint16(protocol.RecordTypePTR) {
-			// Add PTR record to answer section (with known-answer suppression)
-			for _, rr := range allRecords {
-				if rr.Type == protocol.RecordTypePTR {
-					// T095: Apply known-answer suppression per RFC 6762 §7.1
-					if rb.ApplyKnownAnswerSuppression(rr, knownAnswers) {
-						response.Answers = append(response.Answers, rb.recordToAnswer(rr))
-					}
-					// T096: TODO - log suppressed record
-					break
-				}
-			}
+	for _, rr := range allRecords {
+		if !containsType(additionalTypes, rr.Type) || !rb.ApplyKnownAnswerSuppression(rr, knownAnswers) {
+			continue
+		}
+		additional := rb.recordToAnswer(rr)
+		// A record already in the Answer section need not be repeated
+		if !containsAnswer(response.Answers, additional) && !containsAnswer(response.Additionals, additional) {
+			response.Additionals = append(response.Additionals, additional)
+		}
+	}
+}
+
+// AddNegativeRecord appends an NSEC record to response when question asks
+// for a type that a name owned by service does not have.
+//
+// RFC 6762 §6.1: a responder asserts the nonexistence of a record type for a
+// name it owns with an NSEC record listing the types that do exist:
+//   - Instance name ("My Printer._http._tcp.local"): SRV and TXT exist, so
+//     e.g. an AAAA or A question gets an NSEC
+//   - Hostname ("myhost.local"): only A exists, so e.g. AAAA gets an NSEC;
+//     a dual-stack service (IPv6Address set) owns A and AAAA
+//
+// ANY questions are never negative. The NSEC is suppressed if it is in the
+// querier's known-answer list (RFC 6762 §7.1).
+//
+// Returns:
+//   - bool: true if service owns question.QNAME, whether or not a record was
+//     added, so callers can stop searching
+func (rb *ResponseBuilder) AddNegativeRecord(response *message.DNSMessage, service *ServiceWithIP, question message.Question, knownAnswers []*message.ResourceRecord) bool {
+	serviceInfo := &records.ServiceInfo{
+		InstanceName: service.InstanceName,
+		ServiceType:  service.ServiceType,
+		Hostname:     rb.getHostname(service),
+		IPv6Address:  service.IPv6Address,
+	}
+
+	// Names match case-insensitively (RFC 1035 §2.3.3)
+	qtype := protocol.RecordType(question.QTYPE)
+	var nsec *message.ResourceRecord
+	switch {
+	case strings.EqualFold(question.QNAME, serviceInfo.InstanceName+"."+serviceInfo.ServiceType):
+		if qtype == protocol.RecordTypeSRV || qtype == protocol.RecordTypeTXT || qtype == protocol.RecordTypeANY {
+			return true
+		}
+		nsec = records.BuildInstanceNSECRecord(serviceInfo)
+	case strings.EqualFold(question.QNAME, serviceInfo.Hostname):
+		if qtype == protocol.RecordTypeA || qtype == protocol.RecordTypeANY ||
+			(qtype == protocol.RecordTypeAAAA && len(serviceInfo.IPv6Address) == 16) {
+			return true
+		}
+		nsec = records.BuildHostnameNSECRecord(serviceInfo)
+	default:
+		return false
+	}
+
+	if !rb.ApplyKnownAnswerSuppression(nsec, knownAnswers) {
+		return true
+	}
+	answer := rb.recordToAnswer(nsec)
+	if !containsAnswer(response.Answers, answer) {
+		response.Answers = append(response.Answers, answer)
+	}
+	return true
+}
+
+// MergeRecords appends the answer and additional records of src to response,
+// skipping records already present, so that a response built separately for
+// one service (e.g. by a custom BuildResponse) aggregates like
+// AddServiceRecords.
+func (rb *ResponseBuilder) MergeRecords(response, src *message.DNSMessage) {
+	for _, answer := range src.Answers {
+		if !containsAnswer(response.Answers, answer) {
+			response.Answers = append(response.Answers, answer)
+		}
+	}
+	for _, additional := range src.Additionals {
+		if !containsAnswer(response.Answers, additional) && !containsAnswer(response.Additionals, additional) {
+			response.Additionals = append(response.Additionals, additional)
+		}
+	}
+}
 
-			// Add SRV, TXT, A to additional section (with known-answer suppression)
-			for _, rr := range allRecords {
-				if rr.Type == protocol.RecordTypeSRV || rr.Type == protocol.RecordTypeTXT || rr.Type == protocol.RecordTypeA {
-					// T095: Apply known-answer suppression per RFC 6762 §7.1
-					if rb.ApplyKnownAnswerSuppression(rr, knownAnswers) {
-						response.Additionals = append(response.Additionals, rb.recordToAnswer(rr))
-					}
-					// T096: TODO - log suppressed record
-				}
+// AdaptForLegacyUnicast turns response into the answer to a legacy unicast
+// query (one from a source port other than 5353), per RFC 6762 §6.7:
+//
+//	the Multicast DNS responder MUST send a UDP response directly back to the
+//	querier, via unicast, to the query packet's source IP address and port.
+//	This unicast response MUST be a conventional unicast response as would be
+//	generated by a conventional Unicast DNS server; for example, it MUST
+//	repeat the query ID and the question given in the query message. In
+//	addition, the cache-flush bit described in Section 10.2 MUST NOT be set
+//	in legacy unicast responses.
+//
+// The query's questions are echoed exactly (Finalize sets QDCOUNT), the
+// cache-flush bit is cleared, and TTLs are capped at
+// protocol.LegacyUnicastTTLMax since §6.7 says they SHOULD NOT exceed ten
+// seconds. Call it after adding answers and before Finalize; the query ID is
+// already repeated by NewResponse.
+func (rb *ResponseBuilder) AdaptForLegacyUnicast(response, query *message.DNSMessage) {
+	response.Questions = append([]message.Question(nil), query.Questions...)
+
+	for _, section := range [][]message.Answer{response.Answers, response.Authorities, response.Additionals} {
+		for i := range section {
+			section[i].CLASS &^= 0x8000 // Cache-flush bit (RFC 6762 §10.2)
+			if section[i].TTL > protocol.LegacyUnicastTTLMax {
+				section[i].TTL = protocol.LegacyUnicastTTLMax
 			}
 		}
 	}
+}
+
+// Finalize sets the section counts and enforces the RFC 6762 §17 packet size
+// limit, truncating additional records if necessary.
+//
+// TC is set on a truncated response only when legacyUnicast: RFC 6762 §18.5,
+// "In multicast response messages, the TC bit MUST be zero on transmission",
+// and the same holds for unicast replies to mDNS queriers. A legacy resolver
+// (§6.7) reads TC as in unicast DNS, as a sign the answer is incomplete.
+func (rb *ResponseBuilder) Finalize(response *message.DNSMessage, legacyUnicast bool) {
+	// Additionals duplicated by answers added after them are redundant
+	additionals := response.Additionals[:0]
+	for _, additional := range response.Additionals {
+		if !containsAnswer(response.Answers, additional) {
+			additionals = append(additionals, additional)
+		}
+	}
+	response.Additionals = additionals
 
-	// Update counts
+	// Update counts (questions are echoed only in legacy unicast responses)
+	response.Header.QDCount = uint16(len(response.Questions))
 	response.Header.ANCount = uint16(len(response.Answers))
 	response.Header.ARCount = uint16(len(response.Additionals))
 
-	// Check packet size limit (RFC 6762 §17: 9000 bytes)
-	estimatedSize := rb.EstimatePacketSize(response)
-	if estimatedSize > rb.maxPacketSize {
-		// R005: Gracefully truncate additional records
+	// Check packet size limit (RFC 6762 §17: 9000 bytes, or the configured cap)
+	size := rb.WireSize(response)
+	if size > rb.maxPacketSize {
+		// R005: Gracefully truncate additional records; answers are never dropped
 		originalAdditionalCount := len(response.Additionals)
-		response.Additionals = rb.truncateAdditionals(response, estimatedSize)
+		response.Additionals = rb.truncateAdditionals(response, size)
 		response.Header.ARCount = uint16(len(response.Additionals))
 
-		// RFC 6762 §6.5: Set TC bit when truncated
-		// Bit 9 (TC=1): 0x0200
-		if len(response.Additionals) < originalAdditionalCount {
-			response.Header.Flags |= 0x0200 // Set TC bit
+		// RFC 6762 §18.5: TC only on a truncated legacy unicast response
+		if legacyUnicast && len(response.Additionals) < originalAdditionalCount {
+			response.Header.Flags |= protocol.FlagTC
 		}
 	}
+}
 
-	return response, nil
+// containsType reports whether t is in types.
+func containsType(types []protocol.RecordType, t protocol.RecordType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnswer reports whether an identical record (RFC 6762 §7.1 identity:
+// name, type, class, RDATA) is already in list.
+func containsAnswer(list []message.Answer, a message.Answer) bool {
+	for _, existing := range list {
+		if existing.TYPE == a.TYPE && existing.CLASS == a.CLASS &&
+			strings.EqualFold(existing.NAME, a.NAME) && bytes.Equal(existing.RDATA, a.RDATA) {
+			return true
+		}
+	}
+	return false
 }
 
 // EstimatePacketSize estimates the wire format size of a DNS message.
@@ -221,24 +437,66 @@ func (rb *ResponseBuilder) estimateRecordSize(answer *message.Answer) int {
 	return 50 + 10 + len(answer.RDATA)
 }
 
-// truncateAdditionals removes additional records until packet size is acceptable.
+// WireSize returns the serialized size of msg in bytes.
+//
+// message.SerializeMessage does not compress names, so unlike
+// EstimatePacketSize this is the exact size of the packet that is sent.
+func (rb *ResponseBuilder) WireSize(msg *message.DNSMessage) int {
+	// Header is always 12 bytes
+	size := 12
+
+	for _, question := range msg.Questions {
+		// QNAME + QTYPE (2) + QCLASS (2)
+		size += encodedNameSize(question.QNAME) + 4
+	}
+	for _, section := range [][]message.Answer{msg.Answers, msg.Authorities, msg.Additionals} {
+		for i := range section {
+			size += wireRecordSize(&section[i])
+		}
+	}
+
+	return size
+}
+
+// wireRecordSize returns the serialized size of a single resource record:
+// NAME + TYPE (2) + CLASS (2) + TTL (4) + RDLENGTH (2) + RDATA.
+func wireRecordSize(answer *message.Answer) int {
+	return encodedNameSize(answer.NAME) + 10 + len(answer.RDATA)
+}
+
+// encodedNameSize returns the uncompressed wire size of name, falling back to
+// the R005 estimate for names that cannot be encoded (serialization fails for
+// those anyway).
+func encodedNameSize(name string) int {
+	encoded, err := message.EncodeName(name)
+	if err != nil {
+		return 50
+	}
+	return len(encoded)
+}
+
+// truncateAdditionals drops additional records until the packet fits.
 //
 // R005 Decision: Graceful truncation - keep answer section intact (critical),
-// remove additional records (nice-to-have) until under 9000 bytes.
+// and pack additional records (nice-to-have) greedily in priority order:
+// each is kept if it still fits, so the records added first (SRV before TXT
+// before A for a PTR answer) win the remaining space.
 //
 // T077: Implement truncation
 func (rb *ResponseBuilder) truncateAdditionals(msg *message.DNSMessage, currentSize int) []message.Answer {
-	// Remove additional records one by one until under limit
-	additionals := make([]message.Answer, 0, len(msg.Additionals))
+	// Space left once every additional record is removed
 	size := currentSize
+	for i := range msg.Additionals {
+		size -= wireRecordSize(&msg.Additionals[i])
+	}
 
+	additionals := make([]message.Answer, 0, len(msg.Additionals))
 	for _, additional := range msg.Additionals {
-		recordSize := rb.estimateRecordSize(&additional)
-		if size-recordSize >= rb.maxPacketSize {
-			// Skip this record
-			size -= recordSize
-			continue
+		recordSize := wireRecordSize(&additional)
+		if size+recordSize > rb.maxPacketSize {
+			continue // Doesn't fit; a smaller later record still might
 		}
+		size += recordSize
 		additionals = append(additionals, additional)
 	}
 
@@ -247,12 +505,20 @@ func (rb *ResponseBuilder) truncateAdditionals(msg *message.DNSMessage, currentS
 
 // recordToAnswer converts a ResourceRecord to an Answer.
 //
+// RFC 6762 §10.2: The cache-flush bit (top bit of CLASS) is set for unique
+// records (SRV, TXT, A) and clear for shared records (PTR), as classified by
+// records.BuildRecordSet.
+//
 // T076: Helper for response building
 func (rb *ResponseBuilder) recordToAnswer(rr *message.ResourceRecord) message.Answer {
+	class := uint16(rr.Class)
+	if rr.CacheFlush {
+		class |= 0x8000
+	}
 	return message.Answer{
 		NAME:     rr.Name,
 		TYPE:     uint16(rr.Type),
-		CLASS:    uint16(rr.Class),
+		CLASS:    class,
 		TTL:      rr.TTL,
 		RDLENGTH: uint16(len(rr.Data)),
 		RDATA:    rr.Data,
@@ -297,7 +563,7 @@ func (rb *ResponseBuilder) ApplyKnownAnswerSuppression(ourRecord *message.Resour
 	// Check if ourRecord matches any known-answer
 	for _, knownAnswer := range knownAnswers {
 		// RFC 6762 §7.1: Records must match on Name, Type, Class, and RDATA
-		if !recordsMatch(ourRecord, knownAnswer) {
+		if !records.RecordsEqual(ourRecord, knownAnswer) {
 			continue // Not a match, check next known-answer
 		}
 
@@ -317,49 +583,3 @@ func (rb *ResponseBuilder) ApplyKnownAnswerSuppression(ourRecord *message.Resour
 	// No matching known-answer found → include in response
 	return true
 }
-
-// recordsMatch checks if two resource records match per RFC 6762 §7.1 criteria.
-//
-// RFC 6762 §7.1: Records match if Name, Type, Class, and RDATA are identical.
-//
-// Parameters:
-//   - a, b: Resource records to compare
-//
-// Returns:
-//   - true: Records match (same Name, Type, Class, RDATA)
-//   - false: Records differ
-//
-// T092: Helper for known-answer matching
-func recordsMatch(a, b *message.ResourceRecord) bool {
-	// Name comparison (case-insensitive per RFC 1035 §2.3.3)
-	// DNS names are case-insensitive: "example.local" == "EXAMPLE.LOCAL"
-	if !strings.EqualFold(a.Name, b.Name) {
-		return false
-	}
-
-	// Type must match
-	if a.Type != b.Type {
-		return false
-	}
-
-	// Class must match (ignore cache-flush bit for comparison)
-	// RFC 6762 §10.2: Cache-flush bit is NOT part of record identity
-	classA := a.Class & 0x7FFF // Mask out cache-flush bit
-	classB := b.Class & 0x7FFF
-	if classA != classB {
-		return false
-	}
-
-	// RDATA must match byte-for-byte
-	if len(a.Data) != len(b.Data) {
-		return false
-	}
-	for i := range a.Data {
-		if a.Data[i] != b.Data[i] {
-			return false
-		}
-	}
-
-	// All criteria match
-	return true
-}
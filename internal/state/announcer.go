@@ -5,6 +5,7 @@ import (
 	"net"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/records"
@@ -21,6 +22,9 @@ type Announcer struct {
 	// Transport for sending announcement packets on the wire
 	transport transport.Transport
 
+	// clock times the announcement interval (nil = wall clock)
+	clock clock.Clock
+
 	// Test hooks for injection
 	onSendAnnouncement func()
 	lastSentData       []byte
@@ -97,8 +101,7 @@ func (a *Announcer) Announce(ctx context.Context, _ string, records []byte) erro
 			if err != nil {
 				// If serialization fails, fall back to empty message
 				// This shouldn't happen in practice with valid records
-				announceMsg = make([]byte, 12)
-				announceMsg[2] = 0x84 // QR=1, AA=1
+				announceMsg = message.SerializeHeader(message.NewResponseHeader(0))
 			}
 		} else {
 			// No records set - use minimal stub for backward compatibility with tests
@@ -106,9 +109,7 @@ func (a *Announcer) Announce(ctx context.Context, _ string, records []byte) erro
 			//   ID: 0x0000
 			//   Flags: QR=1, AA=1 = 0x8400
 			//   QDCOUNT, ANCOUNT, NSCOUNT, ARCOUNT: all 0
-			announceMsg = make([]byte, 12)
-			announceMsg[2] = 0x84 // High byte: QR=1, OPCODE=0, AA=1
-			announceMsg[3] = 0x00 // Low byte: TC=0, RD=0, RA=0, Z=0, RCODE=0
+			announceMsg = message.SerializeHeader(message.NewResponseHeader(0))
 		}
 
 		a.lastAnnounceMessage = announceMsg
@@ -128,12 +129,10 @@ func (a *Announcer) Announce(ctx context.Context, _ string, records []byte) erro
 
 		// Wait 1s before next announcement (except after last)
 		if i < announcementCount-1 {
-			timer := time.NewTimer(announcementInterval)
 			select {
 			case <-ctx.Done():
-				timer.Stop()
 				return ctx.Err()
-			case <-timer.C:
+			case <-clock.Or(a.clock).After(announcementInterval):
 				// Continue to next announcement
 			}
 		}
@@ -142,6 +141,11 @@ func (a *Announcer) Announce(ctx context.Context, _ string, records []byte) erro
 	return nil
 }
 
+// SetClock sets the clock timing the announcement interval (nil = wall clock).
+func (a *Announcer) SetClock(c clock.Clock) {
+	a.clock = c
+}
+
 // GetLastAnnounceMessage returns the last sent announcement message.
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.3 validation
@@ -25,7 +25,10 @@ package state
 import (
 	"context"
 	"sync"
+	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/transport"
 )
 
@@ -83,6 +86,10 @@ type Machine struct {
 	onStateChange  func(State)
 	currentState   State
 	injectConflict bool
+	injector       *ConflictInjector
+	skipProbing    bool
+	skipAnnounce   bool
+	conflictCheck  func() bool
 }
 
 // NewMachine creates a new state machine.
@@ -113,33 +120,39 @@ func NewMachine() *Machine {
 // R001: Each service runs in its own goroutine
 // T038: Implement Machine.run() with context cancellation
 func (sm *Machine) Run(ctx context.Context, serviceName string) error {
-	// Transition to Probing
-	sm.setState(StateProbing)
+	if !sm.skipProbing {
+		// Transition to Probing
+		sm.setState(StateProbing)
 
-	// Phase 1: Probing (~750ms)
-	result := sm.prober.Probe(ctx, serviceName)
-	if result.Error != nil {
-		return result.Error
-	}
+		// Phase 1: Probing (~750ms)
+		result := sm.prober.Probe(ctx, serviceName)
+		if result.Error != nil {
+			return result.Error
+		}
 
-	if result.Conflict || sm.injectConflict {
-		// Conflict detected - stop here
-		// Caller (Responder) will handle rename/retry
-		sm.setState(StateConflictDetected)
-		return nil
+		// A conflict may also have been seen outside the prober, e.g. by the
+		// caller's receive loop (SetConflictCheck)
+		if result.Conflict || sm.injectConflict || sm.injector.take() || (sm.conflictCheck != nil && sm.conflictCheck()) {
+			// Conflict detected - stop here
+			// Caller (Responder) will handle rename/retry
+			sm.setState(StateConflictDetected)
+			return nil
+		}
 	}
 
-	// Transition to Announcing
-	sm.setState(StateAnnouncing)
-
-	// Phase 2: Announcing (~1s)
-	// Note: Records are built by Responder.Register() via BuildRecordSet()
-	// Machine doesn't need records directly - Announcer uses test hooks for unit testing
-	// Actual transport integration with records happens in US3 (Response to Queries)
-	records := []byte{} // Placeholder for announcer interface
-	err := sm.announcer.Announce(ctx, serviceName, records)
-	if err != nil {
-		return err
+	if !sm.skipAnnounce {
+		// Transition to Announcing
+		sm.setState(StateAnnouncing)
+
+		// Phase 2: Announcing (~1s)
+		// Note: Records are built by Responder.Register() via BuildRecordSet()
+		// Machine doesn't need records directly - Announcer uses test hooks for unit testing
+		// Actual transport integration with records happens in US3 (Response to Queries)
+		records := []byte{} // Placeholder for announcer interface
+		err := sm.announcer.Announce(ctx, serviceName, records)
+		if err != nil {
+			return err
+		}
 	}
 
 	// Transition to Established
@@ -180,6 +193,52 @@ func (sm *Machine) SetTransport(t transport.Transport) {
 	sm.announcer.SetTransport(t)
 }
 
+// SetInitialProbeDelay sets the bound of the random delay before the first
+// probe (RFC 6762 §8.1; 0 = probe immediately, the default).
+func (sm *Machine) SetInitialProbeDelay(max time.Duration) {
+	sm.prober.SetInitialDelay(max)
+}
+
+// SetRand sets the random source drawing the initial probe delay: randN
+// returns a duration uniform in [0, n) (nil = math/rand/v2).
+func (sm *Machine) SetRand(randN func(n time.Duration) time.Duration) {
+	sm.prober.SetRand(randN)
+}
+
+// SetOnStateChange sets a callback invoked after each state transition,
+// from the goroutine running the machine.
+func (sm *Machine) SetOnStateChange(callback func(State)) {
+	sm.onStateChange = callback
+}
+
+// SetClock sets the clock timing probing and announcing (nil = wall clock).
+//
+// A fake clock lets tests drive the ~1.75s RFC 6762 §8 sequence instantly.
+func (sm *Machine) SetClock(c clock.Clock) {
+	sm.prober.SetClock(c)
+	sm.announcer.SetClock(c)
+}
+
+// SetConflictCheck sets a check consulted once probing completes; if it
+// reports true, the machine stops in ConflictDetected instead of announcing.
+// It lets conflicts detected outside the prober (e.g. a hostname claimed by
+// another host, seen by the responder's receive loop) abort the claim.
+func (sm *Machine) SetConflictCheck(check func() bool) {
+	sm.conflictCheck = check
+}
+
+// SetServiceRecords sets the service's unique records (SRV/TXT) proposed in
+// the probes' Authority section (RFC 6762 §8.2).
+func (sm *Machine) SetServiceRecords(records []*message.ResourceRecord) {
+	sm.prober.SetServiceRecords(records)
+}
+
+// SetHostRecords sets the host address records (A/AAAA) probed for along with
+// the service name (RFC 6762 §8.1).
+func (sm *Machine) SetHostRecords(records []*message.ResourceRecord) {
+	sm.prober.SetHostRecords(records)
+}
+
 // SetInjectConflict is a test hook to inject conflict during probing.
 //
 // T062: Test hook for max rename attempts testing
@@ -187,6 +246,63 @@ func (sm *Machine) SetInjectConflict(inject bool) {
 	sm.injectConflict = inject
 }
 
+// ConflictInjector is a test hook reporting a conflict for a limited number
+// of probing runs, shared by the machines of successive rename attempts so
+// that a registration conflicts n times and then succeeds.
+//
+// T062: Test hook for rename-on-conflict testing
+type ConflictInjector struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+// NewConflictInjector creates a ConflictInjector that reports a conflict for
+// the first n probing runs.
+func NewConflictInjector(n int) *ConflictInjector {
+	return &ConflictInjector{remaining: n}
+}
+
+// take reports whether this probing run conflicts, consuming one of the
+// remaining conflicts. A nil injector never conflicts.
+func (c *ConflictInjector) take() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.remaining <= 0 {
+		return false
+	}
+	c.remaining--
+	return true
+}
+
+// SetConflictInjector is a test hook making probing report a conflict while
+// injector has conflicts remaining.
+//
+// T062: Test hook for rename-on-conflict testing
+func (sm *Machine) SetConflictInjector(injector *ConflictInjector) {
+	sm.injector = injector
+}
+
+// SetSkipProbing makes Run go straight to announcing.
+//
+// RFC 6762 §8.1: probing establishes exclusive ownership of unique records;
+// a record set made only of shared records (e.g. a lone PTR) has nothing to
+// probe for.
+func (sm *Machine) SetSkipProbing(skip bool) {
+	sm.skipProbing = skip
+}
+
+// SetSkipAnnounce makes Run go straight from probing to established,
+// sending no announcements.
+//
+// RFC 6762 §8.3 requires announcing; skipping it is only for environments
+// where peers learn the records by querying.
+func (sm *Machine) SetSkipAnnounce(skip bool) {
+	sm.skipAnnounce = skip
+}
+
 // GetProber returns the internal Prober for integration with Responder.
 //
 // US2 GREEN: Allow Responder to access Prober for message capture
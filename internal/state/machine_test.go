@@ -2,8 +2,12 @@ package state
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
 // TestMachine_Transitions_RED tests state machine transitions per RFC 6762 §8.
@@ -90,6 +94,25 @@ func TestMachine_Run_ConflictDetected(t *testing.T) {
 	}
 }
 
+// TestConflictInjector_ConflictsForFirstAttempts verifies a ConflictInjector
+// reports a conflict for exactly its first n probing runs, and that a nil
+// injector never does.
+//
+// T062: Test hook for rename-on-conflict testing
+func TestConflictInjector_ConflictsForFirstAttempts(t *testing.T) {
+	injector := NewConflictInjector(2)
+	for i, want := range []bool{true, true, false, false} {
+		if got := injector.take(); got != want {
+			t.Errorf("attempt %d: take() = %v, want %v", i+1, got, want)
+		}
+	}
+
+	var none *ConflictInjector
+	if none.take() {
+		t.Error("nil injector take() = true, want false")
+	}
+}
+
 // TestMachine_Run_StateConflictDetected_Exists tests that StateConflictDetected exists and is reachable.
 //
 // TDD Phase: GREEN (already passing - state exists from T038)
@@ -285,3 +308,52 @@ func TestMachine_ConcurrentRun(t *testing.T) {
 		}
 	}
 }
+
+// TestMachine_Run_FakeClock verifies the probe and announce intervals are
+// timed by the configured clock: advancing a fake clock through 2×250ms
+// probe waits and one 1s announce wait drives the machine to Established
+// without real delays (RFC 6762 §8.1, §8.3).
+func TestMachine_Run_FakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	machine := NewMachine()
+	machine.SetClock(fake)
+
+	var mu sync.Mutex
+	var states []State
+	machine.onStateChange = func(newState State) {
+		mu.Lock()
+		states = append(states, newState)
+		mu.Unlock()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- machine.Run(context.Background(), testServiceName)
+	}()
+
+	for _, step := range []time.Duration{protocol.ProbeInterval, protocol.ProbeInterval, time.Second} {
+		fake.WaitForWaiters(1)
+		fake.Advance(step)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Run() error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Run() did not complete after advancing the fake clock")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	wantStates := []State{StateProbing, StateAnnouncing, StateEstablished}
+	if len(states) != len(wantStates) {
+		t.Fatalf("state transitions = %v, want %v", states, wantStates)
+	}
+	for i, want := range wantStates {
+		if states[i] != want {
+			t.Errorf("state[%d] = %v, want %v", i, states[i], want)
+		}
+	}
+}
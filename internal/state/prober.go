@@ -3,10 +3,13 @@ package state
 import (
 	"context"
 	"encoding/binary"
+	"math/rand/v2"
 	"net"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/transport"
@@ -31,6 +34,16 @@ type Prober struct {
 	// Transport for sending probe packets on the wire
 	transport transport.Transport
 
+	// clock times the probe intervals (nil = wall clock)
+	clock clock.Clock
+
+	// initialDelayMax bounds the random wait before the first probe
+	// (RFC 6762 §8.1; 0 = probe immediately)
+	initialDelayMax time.Duration
+
+	// randN draws the initial delay in [0, n) (nil = math/rand/v2)
+	randN func(n time.Duration) time.Duration
+
 	// listenForResponses enables the prober to call transport.Receive() during
 	// probe intervals. When false (default), the prober only sends probes and
 	// relies on an external receive loop (e.g., Responder's query handler) to
@@ -50,6 +63,14 @@ type Prober struct {
 	incomingRecords  []message.ResourceRecord  // Incoming probe responses (test hook)
 	conflictDetector ConflictDetectorInterface // For detecting conflicts
 
+	// serviceRecords are the service's unique records (SRV/TXT), proposed in
+	// the Authority section (RFC 6762 §8.2)
+	serviceRecords []*message.ResourceRecord
+
+	// hostRecords are the host address records (A/AAAA) claimed along with
+	// the service name (RFC 6762 §8.1)
+	hostRecords []*message.ResourceRecord
+
 	// US2 GREEN: Message capture for contract test validation
 	lastProbeMessage []byte // Last sent probe message (wire format)
 }
@@ -85,6 +106,22 @@ func NewProber() *Prober {
 func (p *Prober) Probe(ctx context.Context, serviceName string) ProbeResult {
 	const probeCount = 3
 
+	// RFC 6762 §8.1: Wait a random 0-250ms first, so devices powered on
+	// together do not probe in lockstep
+	if p.initialDelayMax > 0 {
+		var delay time.Duration
+		if p.randN != nil {
+			delay = p.randN(p.initialDelayMax)
+		} else {
+			delay = rand.N(p.initialDelayMax) //nolint:gosec // G404: timing jitter, not security-sensitive
+		}
+		select {
+		case <-ctx.Done():
+			return ProbeResult{Error: ctx.Err()}
+		case <-clock.Or(p.clock).After(delay):
+		}
+	}
+
 	for i := 0; i < probeCount; i++ {
 		// Check for context cancellation
 		select {
@@ -112,18 +149,10 @@ func (p *Prober) Probe(ctx context.Context, serviceName string) ProbeResult {
 			return ProbeResult{Error: encErr}
 		}
 
-		// Build DNS header (12 bytes) + question section (encodedName + 4 bytes QTYPE+QCLASS)
-		// Header: QR=0, OPCODE=0, QDCOUNT=1, all else zero
-		header := make([]byte, 12)
-		binary.BigEndian.PutUint16(header[4:6], 1) // QDCOUNT = 1
-
-		// Question section: QNAME + QTYPE(ANY=255) + QCLASS(IN=1)
-		question := make([]byte, len(encodedName)+4)
-		copy(question, encodedName)
-		binary.BigEndian.PutUint16(question[len(encodedName):], uint16(protocol.RecordTypeANY))
-		binary.BigEndian.PutUint16(question[len(encodedName)+2:], uint16(protocol.ClassIN))
-
-		probeMsg := append(header, question...)
+		probeMsg, err := p.buildProbe(encodedName)
+		if err != nil {
+			return ProbeResult{Error: err}
+		}
 		p.lastProbeMessage = probeMsg
 
 		// Notify test hooks
@@ -161,13 +190,14 @@ func (p *Prober) Probe(ctx context.Context, serviceName string) ProbeResult {
 		// RFC 6762 §8.1: During the wait, listen for responses that indicate conflicts.
 		if i < probeCount-1 && p.transport != nil && p.listenForResponses {
 			// Listen for responses during the 250ms probe interval
-			deadline := time.Now().Add(protocol.ProbeInterval)
-			for time.Now().Before(deadline) {
-				remaining := time.Until(deadline)
+			clk := clock.Or(p.clock)
+			deadline := clk.Now().Add(protocol.ProbeInterval)
+			for clk.Now().Before(deadline) {
+				remaining := deadline.Sub(clk.Now())
 				if remaining <= 0 {
 					break
 				}
-				receiveCtx, cancelReceive := context.WithTimeout(ctx, remaining)
+				receiveCtx, cancelReceive := clock.WithTimeout(ctx, clk, remaining)
 				packet, _, _, recvErr := p.transport.Receive(receiveCtx)
 				cancelReceive()
 				if recvErr != nil {
@@ -239,12 +269,10 @@ func (p *Prober) Probe(ctx context.Context, serviceName string) ProbeResult {
 					}
 				}
 			}
-			timer := time.NewTimer(protocol.ProbeInterval)
 			select {
 			case <-ctx.Done():
-				timer.Stop()
 				return ProbeResult{Error: ctx.Err()}
-			case <-timer.C:
+			case <-clock.Or(p.clock).After(protocol.ProbeInterval):
 				// Continue to next probe
 			}
 		}
@@ -254,6 +282,54 @@ func (p *Prober) Probe(ctx context.Context, serviceName string) ProbeResult {
 	return ProbeResult{Conflict: false}
 }
 
+// probeQuestion returns a probe question for encodedName: QNAME +
+// QTYPE(ANY=255) + QCLASS(IN=1).
+func probeQuestion(encodedName []byte) []byte {
+	question := make([]byte, len(encodedName)+4)
+	copy(question, encodedName)
+	binary.BigEndian.PutUint16(question[len(encodedName):], uint16(protocol.RecordTypeANY))
+	binary.BigEndian.PutUint16(question[len(encodedName)+2:], uint16(protocol.ClassIN))
+	return question
+}
+
+// buildProbe returns the probe for the service name encodedName: a header
+// (QR=0, OPCODE=0), an ANY question for the service name and, if host
+// records are set, one for the hostname, since it is a unique name too
+// (RFC 6762 §8.1).
+//
+// RFC 6762 §8.2: Probes carry the proposed records in the Authority section
+// (the service records, then the host records) so that simultaneous probes
+// for the same name can be tie-broken. The cache-flush bit is not set in a
+// query.
+func (p *Prober) buildProbe(encodedName []byte) ([]byte, error) {
+	header := message.NewProbeHeader(0)
+	header.QDCount = 1
+	if len(p.hostRecords) > 0 {
+		header.QDCount = 2
+	}
+	header.NSCount = uint16(len(p.serviceRecords) + len(p.hostRecords)) //nolint:gosec // G115: a service has a handful of records
+
+	probeMsg := append(message.SerializeHeader(header), probeQuestion(encodedName)...)
+	if len(p.hostRecords) > 0 {
+		hostname, err := message.EncodeName(p.hostRecords[0].Name)
+		if err != nil {
+			return nil, err
+		}
+		probeMsg = append(probeMsg, probeQuestion(hostname)...)
+	}
+
+	for _, rr := range slices.Concat(p.serviceRecords, p.hostRecords) {
+		proposed := *rr
+		proposed.CacheFlush = false
+		rrBytes, err := message.SerializeResourceRecord(&proposed)
+		if err != nil {
+			return nil, err
+		}
+		probeMsg = append(probeMsg, rrBytes...)
+	}
+	return probeMsg, nil
+}
+
 // compareBytesLexicographically compares two byte slices lexicographically.
 // Returns true if a > b (we win), false otherwise.
 func compareBytesLexicographically(a, b []byte) bool {
@@ -295,6 +371,20 @@ func (p *Prober) SetConflictDetector(detector ConflictDetectorInterface) {
 	p.conflictDetector = detector
 }
 
+// SetServiceRecords sets the service's unique records (SRV/TXT) proposed in
+// the probes' Authority section (RFC 6762 §8.2). With none set (the
+// default), the Authority section holds only the host records.
+func (p *Prober) SetServiceRecords(records []*message.ResourceRecord) {
+	p.serviceRecords = records
+}
+
+// SetHostRecords sets the host address records (A/AAAA, all for one
+// hostname) to probe for along with the service name (RFC 6762 §8.1). With
+// none set (the default), only the service name is probed.
+func (p *Prober) SetHostRecords(records []*message.ResourceRecord) {
+	p.hostRecords = records
+}
+
 // GetLastProbeMessage returns the last sent probe message.
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.1 validation
@@ -316,6 +406,25 @@ func (p *Prober) SetTransport(t transport.Transport) {
 	p.transport = t
 }
 
+// SetClock sets the clock timing the probe intervals (nil = wall clock).
+func (p *Prober) SetClock(c clock.Clock) {
+	p.clock = c
+}
+
+// SetInitialDelay sets the bound of the random delay before the first probe
+// (RFC 6762 §8.1); the delay is uniform in [0, max). Zero (the default)
+// probes immediately.
+func (p *Prober) SetInitialDelay(max time.Duration) {
+	p.initialDelayMax = max
+}
+
+// SetRand sets the random source drawing the initial delay: randN returns a
+// duration uniform in [0, n) (nil = math/rand/v2). A deterministic source
+// makes the delay reproducible in tests.
+func (p *Prober) SetRand(randN func(n time.Duration) time.Duration) {
+	p.randN = randN
+}
+
 // EnableListenForResponses enables the prober to actively listen for responses
 // by calling transport.Receive() during the 250ms probe intervals.
 //
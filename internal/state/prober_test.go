@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/transport"
@@ -669,3 +670,90 @@ func TestProber_NoConflictWhenResponseDoesntMatch(t *testing.T) {
 		t.Errorf("Probe() error = %v, want nil", result.Error)
 	}
 }
+
+// firstProbeAt runs prober on fake, advancing it 1ms at a time until the first
+// probe is sent, and returns how long after the start that probe went out.
+func firstProbeAt(t *testing.T, prober *Prober, fake *clock.Fake) time.Duration {
+	t.Helper()
+	start := fake.Now()
+
+	sent := make(chan time.Time, 3)
+	prober.SetOnSendQuery(func() { sent <- fake.Now() })
+	prober.SetClock(fake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		prober.Probe(ctx, testServiceName)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// The prober waits on the clock both before the first probe and after
+	// it, so each wait for a waiter settles whether the probe was sent
+	for elapsed := time.Duration(0); elapsed <= protocol.ProbeInitialDelayMax; elapsed += time.Millisecond {
+		fake.WaitForWaiters(1)
+		select {
+		case at := <-sent:
+			return at.Sub(start)
+		default:
+		}
+		fake.Advance(time.Millisecond)
+	}
+	t.Fatalf("first probe not sent within %v", protocol.ProbeInitialDelayMax)
+	return 0
+}
+
+// TestProber_InitialDelay verifies the first probe waits a random delay within
+// [0, 250ms) (RFC 6762 §8.1).
+func TestProber_InitialDelay(t *testing.T) {
+	var delayed bool
+	for run := 0; run < 5; run++ {
+		prober := NewProber()
+		prober.SetInitialDelay(protocol.ProbeInitialDelayMax)
+
+		at := firstProbeAt(t, prober, clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)))
+		if at < 0 || at >= protocol.ProbeInitialDelayMax {
+			t.Errorf("run %d: first probe sent after %v, want within [0, %v)", run, at, protocol.ProbeInitialDelayMax)
+		}
+		if at > 0 {
+			delayed = true
+		}
+	}
+	if !delayed {
+		t.Error("first probe was never delayed in 5 runs")
+	}
+}
+
+// TestProber_SetRand verifies the initial delay is drawn from the SetRand
+// source, bounded by the configured maximum.
+func TestProber_SetRand(t *testing.T) {
+	prober := NewProber()
+	prober.SetInitialDelay(protocol.ProbeInitialDelayMax)
+	var bound time.Duration
+	prober.SetRand(func(n time.Duration) time.Duration {
+		bound = n
+		return 42 * time.Millisecond
+	})
+
+	if at := firstProbeAt(t, prober, clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))); at != 42*time.Millisecond {
+		t.Errorf("first probe sent after %v, want 42ms", at)
+	}
+	if bound != protocol.ProbeInitialDelayMax {
+		t.Errorf("random source asked for a delay below %v, want %v", bound, protocol.ProbeInitialDelayMax)
+	}
+}
+
+// TestProber_InitialDelay_Disabled verifies that with no initial delay the
+// first probe is sent immediately.
+func TestProber_InitialDelay_Disabled(t *testing.T) {
+	prober := NewProber()
+	prober.SetInitialDelay(0)
+
+	if at := firstProbeAt(t, prober, clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))); at != 0 {
+		t.Errorf("first probe sent after %v, want immediately", at)
+	}
+}
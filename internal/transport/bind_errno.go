@@ -0,0 +1,9 @@
+//go:build !windows
+
+package transport
+
+import "syscall"
+
+// errnoAddrInUse is the bind error reported when another socket holds the
+// address without sharing it.
+const errnoAddrInUse = syscall.EADDRINUSE
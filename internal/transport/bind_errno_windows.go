@@ -0,0 +1,9 @@
+//go:build windows
+
+package transport
+
+import "golang.org/x/sys/windows"
+
+// errnoAddrInUse is the bind error reported when another socket holds the
+// address without sharing it (Winsock reports WSAEADDRINUSE, not EADDRINUSE).
+const errnoAddrInUse = windows.WSAEADDRINUSE
@@ -0,0 +1,28 @@
+//go:build unix
+
+package transport
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// reuseAddrControl is the Control function of the fallback bind: it sets
+// SO_REUSEADDR alone, for sandboxes and old kernels that reject the full
+// platform option set (platformControl) with an error other than
+// ENOPROTOOPT.
+func reuseAddrControl(_, _ string, c syscall.RawConn) error {
+	var sockoptErr error
+	err := c.Control(func(fd uintptr) {
+		sockoptErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1)
+	})
+	if err != nil {
+		return fmt.Errorf("raw conn control failed: %w", err)
+	}
+	if sockoptErr != nil {
+		return fmt.Errorf("failed to set SO_REUSEADDR: %w", sockoptErr)
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+//go:build !unix
+
+package transport
+
+import "syscall"
+
+// reuseAddrControl is the Control function of the fallback bind. Off Unix
+// the platform options are already SO_REUSEADDR at most (Windows), so the
+// fallback retries them as-is.
+func reuseAddrControl(network, address string, c syscall.RawConn) error {
+	return platformControl(network, address, c)
+}
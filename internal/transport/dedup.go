@@ -0,0 +1,125 @@
+package transport
+
+import (
+	"context"
+	"hash/maphash"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+)
+
+// DefaultDedupWindow is how long DedupTransport remembers a received packet.
+// Copies of one multicast packet delivered on several interfaces arrive
+// within microseconds of each other; a second is ample while still letting a
+// querier's genuine retransmission (RFC 6762 §5.2, one second or more apart)
+// through.
+const DefaultDedupWindow = time.Second
+
+// DedupTransport wraps a Transport and drops received packets identical to
+// one received from the same source within the window.
+//
+// On a multi-homed host that joined the mDNS group on several interfaces
+// attached to the same link, each multicast packet is delivered once per
+// interface; without deduplication the responder answers every copy and the
+// querier processes each one. Packets are identified by a hash of their
+// source address and bytes, so identical queries from different hosts are
+// not merged.
+type DedupTransport struct {
+	inner  Transport
+	window time.Duration
+	seed   maphash.Seed
+
+	// now returns the current time, from the clock passed to
+	// NewDedupTransport
+	now func() time.Time
+
+	mu        sync.Mutex
+	seen      map[uint64]time.Time // Packet hash → when it was first received
+	lastPrune time.Time
+}
+
+// NewDedupTransport wraps inner so that duplicate packets received within
+// window of each other, as told by c (nil = wall clock), are dropped
+// (window <= 0 uses DefaultDedupWindow).
+func NewDedupTransport(inner Transport, window time.Duration, c clock.Clock) *DedupTransport {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+	return &DedupTransport{
+		inner:  inner,
+		window: window,
+		seed:   maphash.MakeSeed(),
+		now:    clock.Or(c).Now,
+		seen:   make(map[uint64]time.Time),
+	}
+}
+
+// Send transmits the packet via the wrapped transport.
+func (d *DedupTransport) Send(ctx context.Context, packet []byte, dest net.Addr) error {
+	return d.inner.Send(ctx, packet, dest)
+}
+
+// SendOnInterface transmits the packet out ifIndex via the wrapped transport.
+func (d *DedupTransport) SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, ifIndex int) error {
+	return d.inner.SendOnInterface(ctx, packet, dest, ifIndex)
+}
+
+// Receive reads from the wrapped transport, skipping packets that duplicate
+// one received within the window. Errors are returned as-is.
+func (d *DedupTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, error) {
+	for {
+		packet, srcAddr, ifIndex, err := d.inner.Receive(ctx)
+		if err != nil || len(packet) == 0 || !d.duplicate(packet, srcAddr) {
+			return packet, srcAddr, ifIndex, err
+		}
+	}
+}
+
+// duplicate reports whether packet from srcAddr was already received within
+// the window, remembering it if not.
+func (d *DedupTransport) duplicate(packet []byte, srcAddr net.Addr) bool {
+	var h maphash.Hash
+	h.SetSeed(d.seed)
+	if srcAddr != nil {
+		_, _ = h.WriteString(srcAddr.String())
+	}
+	_ = h.WriteByte(0)
+	_, _ = h.Write(packet)
+	key := h.Sum64()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if now.Sub(d.lastPrune) >= d.window {
+		for k, first := range d.seen {
+			if now.Sub(first) >= d.window {
+				delete(d.seen, k)
+			}
+		}
+		d.lastPrune = now
+	}
+
+	if first, ok := d.seen[key]; ok && now.Sub(first) < d.window {
+		return true
+	}
+	d.seen[key] = now
+	return false
+}
+
+// Close closes the wrapped transport.
+func (d *DedupTransport) Close() error {
+	return d.inner.Close()
+}
+
+// LocalAddr returns the wrapped transport's local address.
+func (d *DedupTransport) LocalAddr() net.Addr {
+	return d.inner.LocalAddr()
+}
+
+// Stats returns the wrapped transport's counters.
+func (d *DedupTransport) Stats() TransportStats {
+	return d.inner.Stats()
+}
@@ -0,0 +1,59 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+)
+
+// TestDedupTransport_SuppressesDuplicates verifies a packet delivered twice
+// from the same source within the window (e.g. once per interface on a
+// multi-homed host) is returned once, while the same bytes from another
+// source, or again after the window, are returned.
+func TestDedupTransport_SuppressesDuplicates(t *testing.T) {
+	mock := NewMockTransport()
+	mock.EnableBlockingReceive()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	tr := NewDedupTransport(mock, time.Second, fake)
+
+	query := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+	other := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x02}
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 5353}
+	otherSrc := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 11), Port: 5353}
+
+	receive := func() ([]byte, net.Addr, int) {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		packet, addr, ifIndex, err := tr.Receive(ctx)
+		if err != nil {
+			t.Fatalf("Receive() error = %v", err)
+		}
+		return packet, addr, ifIndex
+	}
+
+	mock.QueueReceive(query, src, 1)
+	mock.QueueReceive(query, src, 2) // Same packet via a second interface
+	mock.QueueReceive(other, src, 1)
+	mock.QueueReceive(query, otherSrc, 1)
+
+	if packet, _, ifIndex := receive(); !bytes.Equal(packet, query) || ifIndex != 1 {
+		t.Fatalf("first Receive() = %x on if %d, want the query on if 1", packet, ifIndex)
+	}
+	if packet, _, _ := receive(); !bytes.Equal(packet, other) {
+		t.Fatalf("second Receive() = %x, want %x (duplicate suppressed)", packet, other)
+	}
+	if packet, addr, _ := receive(); !bytes.Equal(packet, query) || addr.String() != otherSrc.String() {
+		t.Fatalf("third Receive() = %x from %v, want the query from %v", packet, addr, otherSrc)
+	}
+
+	fake.Advance(time.Second)
+	mock.QueueReceive(query, src, 1)
+	if packet, _, _ := receive(); !bytes.Equal(packet, query) {
+		t.Fatalf("Receive() after the window = %x, want the query again", packet)
+	}
+}
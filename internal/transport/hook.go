@@ -0,0 +1,100 @@
+package transport
+
+import (
+	"context"
+	"net"
+)
+
+// Direction identifies whether a traced packet was sent or received.
+type Direction int
+
+const (
+	// Sent marks a packet successfully handed to the network by Send.
+	Sent Direction = iota
+
+	// Received marks a packet returned by Receive.
+	Received
+)
+
+// String returns a human-readable name for the direction.
+func (d Direction) String() string {
+	switch d {
+	case Sent:
+		return "Sent"
+	case Received:
+		return "Received"
+	default:
+		return "Unknown"
+	}
+}
+
+// PacketHook observes raw mDNS packets on the wire.
+//
+// Parameters:
+//   - dir: Sent or Received
+//   - packet: DNS message in wire format (must not be modified or retained)
+//   - addr: Destination (Sent) or source (Received) address
+//   - ifIndex: Receiving (or, for SendOnInterface, sending) interface index;
+//     0 when unknown or unspecified
+type PacketHook func(dir Direction, packet []byte, addr net.Addr, ifIndex int)
+
+// HookTransport wraps a Transport and reports every packet to a PacketHook.
+//
+// Used to implement WithPacketHook in the responder and querier so users can
+// dump pcaps or pretty-print traffic when debugging interop issues. Callers
+// only install the wrapper when a hook is configured, so untraced transports
+// pay no overhead.
+type HookTransport struct {
+	inner Transport
+	hook  PacketHook
+}
+
+// NewHookTransport wraps inner so that hook observes all sent and received packets.
+func NewHookTransport(inner Transport, hook PacketHook) *HookTransport {
+	return &HookTransport{inner: inner, hook: hook}
+}
+
+// Send transmits the packet via the wrapped transport, then reports it as Sent.
+//
+// Failed sends are not reported, since the packet never reached the wire.
+func (h *HookTransport) Send(ctx context.Context, packet []byte, dest net.Addr) error {
+	if err := h.inner.Send(ctx, packet, dest); err != nil {
+		return err
+	}
+	h.hook(Sent, packet, dest, 0)
+	return nil
+}
+
+// SendOnInterface transmits the packet out ifIndex via the wrapped transport,
+// then reports it as Sent on that interface.
+func (h *HookTransport) SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, ifIndex int) error {
+	if err := h.inner.SendOnInterface(ctx, packet, dest, ifIndex); err != nil {
+		return err
+	}
+	h.hook(Sent, packet, dest, ifIndex)
+	return nil
+}
+
+// Receive reads from the wrapped transport and reports each packet as Received.
+func (h *HookTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, error) {
+	packet, srcAddr, ifIndex, err := h.inner.Receive(ctx)
+	if err == nil && len(packet) > 0 {
+		h.hook(Received, packet, srcAddr, ifIndex)
+	}
+	return packet, srcAddr, ifIndex, err
+}
+
+// Close closes the wrapped transport.
+func (h *HookTransport) Close() error {
+	return h.inner.Close()
+}
+
+// LocalAddr returns the wrapped transport's local address.
+func (h *HookTransport) LocalAddr() net.Addr {
+	return h.inner.LocalAddr()
+}
+
+// Stats returns the wrapped transport's counters.
+func (h *HookTransport) Stats() TransportStats {
+	return h.inner.Stats()
+}
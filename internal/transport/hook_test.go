@@ -0,0 +1,95 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+type hookCall struct {
+	dir     transport.Direction
+	packet  []byte
+	addr    net.Addr
+	ifIndex int
+}
+
+// TestHookTransport_ReportsSendAndReceive verifies the hook fires with the
+// correct direction, bytes, address and interface index for both directions.
+func TestHookTransport_ReportsSendAndReceive(t *testing.T) {
+	var calls []hookCall
+	mock := transport.NewMockTransport()
+	tr := transport.NewHookTransport(mock, func(dir transport.Direction, packet []byte, addr net.Addr, ifIndex int) {
+		calls = append(calls, hookCall{dir, append([]byte(nil), packet...), addr, ifIndex})
+	})
+	defer func() { _ = tr.Close() }()
+
+	ctx := context.Background()
+	sent := []byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+	dest := &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+	if err := tr.Send(ctx, sent, dest); err != nil {
+		t.Fatalf("Send() failed: %v", err)
+	}
+
+	recv := []byte{0x00, 0x00, 0x84, 0x00}
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 5353}
+	mock.QueueReceive(recv, src, 3)
+	packet, _, _, err := tr.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Receive() failed: %v", err)
+	}
+	if !bytes.Equal(packet, recv) {
+		t.Errorf("Receive() packet = %x, want %x", packet, recv)
+	}
+
+	if len(calls) != 2 {
+		t.Fatalf("hook called %d times, want 2", len(calls))
+	}
+
+	if calls[0].dir != transport.Sent || !bytes.Equal(calls[0].packet, sent) || calls[0].addr != dest || calls[0].ifIndex != 0 {
+		t.Errorf("send hook = %+v, want {Sent %x %v 0}", calls[0], sent, dest)
+	}
+	if calls[1].dir != transport.Received || !bytes.Equal(calls[1].packet, recv) || calls[1].addr != src || calls[1].ifIndex != 3 {
+		t.Errorf("receive hook = %+v, want {Received %x %v 3}", calls[1], recv, src)
+	}
+}
+
+// TestHookTransport_SkipsEmptyReceive verifies the hook is not invoked when
+// Receive yields no packet (timeouts, non-blocking mock).
+func TestHookTransport_SkipsEmptyReceive(t *testing.T) {
+	called := false
+	tr := transport.NewHookTransport(transport.NewMockTransport(), func(transport.Direction, []byte, net.Addr, int) {
+		called = true
+	})
+
+	if _, _, _, err := tr.Receive(context.Background()); err != nil {
+		t.Fatalf("Receive() failed: %v", err)
+	}
+	if called {
+		t.Error("hook invoked for empty receive")
+	}
+}
+
+// TestHookTransport_SendOnInterface verifies the egress interface is passed to
+// the wrapped transport and reported to the hook.
+func TestHookTransport_SendOnInterface(t *testing.T) {
+	var calls []hookCall
+	mock := transport.NewMockTransport()
+	tr := transport.NewHookTransport(mock, func(dir transport.Direction, packet []byte, addr net.Addr, ifIndex int) {
+		calls = append(calls, hookCall{dir, append([]byte(nil), packet...), addr, ifIndex})
+	})
+
+	dest := &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+	if err := tr.SendOnInterface(context.Background(), []byte{0x00, 0x00, 0x84, 0x00}, dest, 7); err != nil {
+		t.Fatalf("SendOnInterface() failed: %v", err)
+	}
+
+	if sends := mock.SendCalls(); len(sends) != 1 || sends[0].IfIndex != 7 {
+		t.Errorf("wrapped SendCalls = %+v, want one on interface 7", sends)
+	}
+	if len(calls) != 1 || calls[0].dir != transport.Sent || calls[0].ifIndex != 7 {
+		t.Errorf("hook calls = %+v, want one Sent on interface 7", calls)
+	}
+}
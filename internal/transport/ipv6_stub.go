@@ -32,6 +32,12 @@ func (t *UDPv6Transport) Send(_ context.Context, _ []byte, _ net.Addr) error {
 	return nil
 }
 
+// SendOnInterface transmits a packet over IPv6 on a specific interface (stub).
+func (t *UDPv6Transport) SendOnInterface(_ context.Context, _ []byte, _ net.Addr, _ int) error {
+	// Stub: Full implementation in M1.1
+	return nil
+}
+
 // Receive waits for an incoming IPv6 packet (stub).
 //
 // 007-interface-specific-addressing: Updated to return interfaceIndex
@@ -46,5 +52,17 @@ func (t *UDPv6Transport) Close() error {
 	return nil
 }
 
+// LocalAddr returns the bound IPv6 address (stub: always nil).
+func (t *UDPv6Transport) LocalAddr() net.Addr {
+	// Stub: Full implementation in M1.1
+	return nil
+}
+
+// Stats returns the IPv6 traffic counters (stub: always zero).
+func (t *UDPv6Transport) Stats() TransportStats {
+	// Stub: Full implementation in M1.1
+	return TransportStats{}
+}
+
 // Compile-time verification that UDPv6Transport implements Transport interface
 var _ Transport = (*UDPv6Transport)(nil)
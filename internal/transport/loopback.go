@@ -0,0 +1,185 @@
+package transport
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/joshuafuller/beacon/internal/errors"
+)
+
+// loopbackQueueSize is the number of undelivered packets an endpoint buffers
+// before further packets to it are dropped, as a full socket buffer would.
+const loopbackQueueSize = 256
+
+// LoopbackLink is an in-process stand-in for a multicast-capable network link.
+//
+// Transports attached to the same link exchange packets without touching the
+// OS network stack, so a responder and querier can be wired together in one
+// test process deterministically, with no multicast routing, firewall or port
+// 5353 contention. A packet sent to a multicast address reaches every other
+// endpoint on the link; a packet sent to a unicast address reaches the
+// endpoint with that address. As with IP_MULTICAST_LOOP disabled, senders do
+// not receive their own packets.
+type LoopbackLink struct {
+	mu        sync.Mutex
+	endpoints []*LoopbackTransport
+}
+
+// NewLoopbackLink creates an empty link.
+func NewLoopbackLink() *LoopbackLink {
+	return &LoopbackLink{}
+}
+
+// Attach creates a transport on the link with the given source address.
+//
+// Parameters:
+//   - addr: Address reported as the source of packets this endpoint sends,
+//     and the unicast address it receives on
+//
+// Returns:
+//   - *LoopbackTransport: Transport connected to every other endpoint on the link
+func (l *LoopbackLink) Attach(addr *net.UDPAddr) *LoopbackTransport {
+	return l.AttachOnInterface(addr, 0)
+}
+
+// AttachOnInterface is Attach for an endpoint whose Receive reports ifIndex
+// as the receiving interface, so tests can drive per-interface behavior
+// (e.g. a responder's interface addresses, RFC 6762 §15) over the link.
+//
+// Parameters:
+//   - addr: As for Attach
+//   - ifIndex: Interface index reported for every received packet (0 = unknown)
+//
+// Returns:
+//   - *LoopbackTransport: Transport connected to every other endpoint on the link
+func (l *LoopbackLink) AttachOnInterface(addr *net.UDPAddr, ifIndex int) *LoopbackTransport {
+	t := &LoopbackTransport{
+		link:    l,
+		addr:    addr,
+		ifIndex: ifIndex,
+		inbox:   make(chan loopbackPacket, loopbackQueueSize),
+		closed:  make(chan struct{}),
+	}
+
+	l.mu.Lock()
+	l.endpoints = append(l.endpoints, t)
+	l.mu.Unlock()
+	return t
+}
+
+// deliver queues packet from src on every endpoint dest addresses.
+func (l *LoopbackLink) deliver(src *LoopbackTransport, packet []byte, dest net.Addr) {
+	udpDest, _ := dest.(*net.UDPAddr)
+	multicast := udpDest != nil && udpDest.IP.IsMulticast()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, ep := range l.endpoints {
+		if ep == src {
+			continue
+		}
+		if !multicast && (udpDest == nil || !ep.addr.IP.Equal(udpDest.IP) || ep.addr.Port != udpDest.Port) {
+			continue
+		}
+
+		select {
+		case <-ep.closed:
+			continue
+		default:
+		}
+		select {
+		case ep.inbox <- loopbackPacket{data: append([]byte(nil), packet...), src: src.addr}:
+		default:
+			// Receiver not keeping up - drop, as UDP would
+		}
+	}
+}
+
+// loopbackPacket is a packet queued for a LoopbackTransport.
+type loopbackPacket struct {
+	data []byte
+	src  net.Addr
+}
+
+// LoopbackTransport is one endpoint on a LoopbackLink.
+type LoopbackTransport struct {
+	link      *LoopbackLink
+	addr      *net.UDPAddr
+	ifIndex   int
+	inbox     chan loopbackPacket
+	closed    chan struct{}
+	closeOnce sync.Once
+	stats     statsCounters
+}
+
+// Send delivers the packet to the endpoints on the link dest addresses.
+func (t *LoopbackTransport) Send(_ context.Context, packet []byte, dest net.Addr) error {
+	select {
+	case <-t.closed:
+		t.stats.recordSend(0, net.ErrClosed)
+		return &errors.NetworkError{
+			Operation: "send",
+			Err:       net.ErrClosed,
+			Details:   fmt.Sprintf("loopback transport %s is closed", t.addr),
+		}
+	default:
+	}
+
+	t.link.deliver(t, packet, dest)
+	t.stats.recordSend(len(packet), nil)
+	return nil
+}
+
+// SendOnInterface behaves like Send; a link has a single interface.
+func (t *LoopbackTransport) SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, _ int) error {
+	return t.Send(ctx, packet, dest)
+}
+
+// Receive waits for the next packet sent to this endpoint.
+//
+// The returned interface index is 0 (unknown) unless the endpoint was
+// attached with AttachOnInterface, so receivers skip per-interface checks
+// that would consult the host's real interfaces.
+func (t *LoopbackTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, error) {
+	select {
+	case pkt := <-t.inbox:
+		t.stats.recordReceive(len(pkt.data))
+		return pkt.data, pkt.src, t.ifIndex, nil
+	case <-t.closed:
+		return nil, nil, 0, &errors.NetworkError{
+			Operation: "receive",
+			Err:       net.ErrClosed,
+			Details:   fmt.Sprintf("loopback transport %s is closed", t.addr),
+		}
+	case <-ctx.Done():
+		return nil, nil, 0, &errors.NetworkError{
+			Operation: "receive",
+			Err:       ctx.Err(),
+			Details:   "context cancelled or deadline exceeded",
+		}
+	}
+}
+
+// Close detaches the endpoint; pending and future packets to it are dropped.
+func (t *LoopbackTransport) Close() error {
+	t.closeOnce.Do(func() {
+		close(t.closed)
+	})
+	return nil
+}
+
+// LocalAddr returns the endpoint's address on the link.
+func (t *LoopbackTransport) LocalAddr() net.Addr {
+	return t.addr
+}
+
+// Stats returns the endpoint's traffic counters. A link never fails a
+// delivery, so only send-after-Close counts as an error.
+func (t *LoopbackTransport) Stats() TransportStats {
+	return t.stats.snapshot()
+}
+
+// Compile-time check that LoopbackTransport implements Transport.
+var _ Transport = (*LoopbackTransport)(nil)
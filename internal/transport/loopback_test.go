@@ -0,0 +1,94 @@
+package transport_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+// TestLoopbackLink_Delivery verifies multicast reaches every other endpoint
+// (not the sender), and unicast reaches only the addressed endpoint.
+func TestLoopbackLink_Delivery(t *testing.T) {
+	link := transport.NewLoopbackLink()
+	addrA := &net.UDPAddr{IP: net.IPv4(192, 168, 77, 1), Port: 5353}
+	addrB := &net.UDPAddr{IP: net.IPv4(192, 168, 77, 2), Port: 5353}
+	addrC := &net.UDPAddr{IP: net.IPv4(192, 168, 77, 3), Port: 5353}
+	a, b, c := link.Attach(addrA), link.Attach(addrB), link.Attach(addrC)
+	defer func() { _, _, _ = a.Close(), b.Close(), c.Close() }()
+
+	ctx := context.Background()
+	multicast := []byte{0x00, 0x01}
+	if err := a.Send(ctx, multicast, protocol.MulticastGroupIPv4()); err != nil {
+		t.Fatalf("Send(multicast) error = %v", err)
+	}
+	for name, ep := range map[string]*transport.LoopbackTransport{"B": b, "C": c} {
+		packet, src := receiveWithin(t, ep, time.Second)
+		if !bytes.Equal(packet, multicast) || src.String() != addrA.String() {
+			t.Errorf("%s received %v from %v, want %v from %v", name, packet, src, multicast, addrA)
+		}
+	}
+	assertNothingReceived(t, a, "sender (no self-loopback)")
+
+	unicast := []byte{0x00, 0x02}
+	if err := b.SendOnInterface(ctx, unicast, addrC, 7); err != nil {
+		t.Fatalf("SendOnInterface(unicast) error = %v", err)
+	}
+	if packet, _ := receiveWithin(t, c, time.Second); !bytes.Equal(packet, unicast) {
+		t.Errorf("C received %v, want %v", packet, unicast)
+	}
+	assertNothingReceived(t, a, "A (unicast addressed to C)")
+}
+
+// TestLoopbackTransport_Close verifies Close unblocks Receive and makes Send
+// fail, like a closed socket.
+func TestLoopbackTransport_Close(t *testing.T) {
+	tr := transport.NewLoopbackLink().Attach(&net.UDPAddr{IP: net.IPv4(192, 168, 77, 1), Port: 5353})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, _, _, err := tr.Receive(context.Background())
+		errCh <- err
+	}()
+	if err := tr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Error("Receive() after Close returned nil error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Receive() not unblocked by Close")
+	}
+	if err := tr.Send(context.Background(), []byte{0x00}, protocol.MulticastGroupIPv4()); err == nil {
+		t.Error("Send() after Close returned nil error")
+	}
+}
+
+// receiveWithin receives one packet from tr or fails the test.
+func receiveWithin(t *testing.T, tr *transport.LoopbackTransport, timeout time.Duration) ([]byte, net.Addr) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	packet, src, _, err := tr.Receive(ctx)
+	if err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	return packet, src
+}
+
+// assertNothingReceived fails the test if tr has a packet waiting.
+func assertNothingReceived(t *testing.T, tr *transport.LoopbackTransport, who string) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if packet, _, _, err := tr.Receive(ctx); err == nil {
+		t.Errorf("%s received unexpected packet %v", who, packet)
+	}
+}
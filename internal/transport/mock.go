@@ -19,6 +19,8 @@ type MockTransport struct {
 	receiveQueue    []mockReceiveResponse // Queued responses for Receive()
 	receiveNotifyCh chan struct{}         // Signals when a new response is queued
 	blockOnReceive  bool                  // When true, Receive blocks until data or ctx cancel
+	localAddr       net.Addr              // Returned by LocalAddr (set via SetLocalAddr)
+	stats           statsCounters         // Counters returned by Stats
 }
 
 // mockReceiveResponse holds a prepared response for Receive().
@@ -26,12 +28,14 @@ type mockReceiveResponse struct {
 	Packet []byte
 	Addr   net.Addr
 	IfIdx  int
+	Err    error // Returned instead of a packet (QueueReceiveError)
 }
 
-// SendCall records a single Send() invocation.
+// SendCall records a single Send() or SendOnInterface() invocation.
 type SendCall struct {
-	Packet []byte
-	Dest   net.Addr
+	Packet  []byte
+	Dest    net.Addr
+	IfIndex int // Egress interface requested via SendOnInterface (0 for Send)
 }
 
 // NewMockTransport creates a new mock transport for testing.
@@ -54,6 +58,22 @@ func (m *MockTransport) Send(_ context.Context, packet []byte, dest net.Addr) er
 		Packet: append([]byte(nil), packet...), // Copy to avoid aliasing
 		Dest:   dest,
 	})
+	m.stats.recordSend(len(packet), nil)
+
+	return nil
+}
+
+// SendOnInterface records the call, including the requested egress interface.
+func (m *MockTransport) SendOnInterface(_ context.Context, packet []byte, dest net.Addr, ifIndex int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sendCalls = append(m.sendCalls, SendCall{
+		Packet:  append([]byte(nil), packet...), // Copy to avoid aliasing
+		Dest:    dest,
+		IfIndex: ifIndex,
+	})
+	m.stats.recordSend(len(packet), nil)
 
 	return nil
 }
@@ -73,7 +93,7 @@ func (m *MockTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, err
 		resp := m.receiveQueue[0]
 		m.receiveQueue = m.receiveQueue[1:]
 		m.mu.Unlock()
-		return resp.Packet, resp.Addr, resp.IfIdx, nil
+		return m.deliver(resp)
 	}
 	blocking := m.blockOnReceive
 	m.mu.Unlock()
@@ -93,7 +113,7 @@ func (m *MockTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, err
 			resp := m.receiveQueue[0]
 			m.receiveQueue = m.receiveQueue[1:]
 			m.mu.Unlock()
-			return resp.Packet, resp.Addr, resp.IfIdx, nil
+			return m.deliver(resp)
 		}
 		m.mu.Unlock()
 		// Spurious wake; treat as timeout
@@ -101,6 +121,16 @@ func (m *MockTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, err
 	}
 }
 
+// deliver returns a dequeued response from Receive, counting it in Stats.
+func (m *MockTransport) deliver(resp mockReceiveResponse) ([]byte, net.Addr, int, error) {
+	if resp.Err != nil {
+		m.stats.recordReceiveError()
+		return nil, nil, 0, resp.Err
+	}
+	m.stats.recordReceive(len(resp.Packet))
+	return resp.Packet, resp.Addr, resp.IfIdx, nil
+}
+
 // Close marks the transport as closed.
 func (m *MockTransport) Close() error {
 	m.mu.Lock()
@@ -110,6 +140,20 @@ func (m *MockTransport) Close() error {
 	return nil
 }
 
+// LocalAddr returns the address configured via SetLocalAddr (nil by default).
+func (m *MockTransport) LocalAddr() net.Addr {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.localAddr
+}
+
+// SetLocalAddr configures the address returned by LocalAddr.
+func (m *MockTransport) SetLocalAddr(addr net.Addr) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.localAddr = addr
+}
+
 // EnableBlockingReceive switches Receive() into blocking mode where it waits
 // for a queued response or context cancellation instead of returning immediately.
 func (m *MockTransport) EnableBlockingReceive() {
@@ -137,6 +181,25 @@ func (m *MockTransport) QueueReceive(packet []byte, addr net.Addr, ifIdx int) {
 	}
 }
 
+// QueueReceiveError queues a failed read: the next Receive() returns err
+// instead of a packet. Like QueueReceive, it enables blocking mode.
+func (m *MockTransport) QueueReceiveError(err error) {
+	m.mu.Lock()
+	m.blockOnReceive = true
+	m.receiveQueue = append(m.receiveQueue, mockReceiveResponse{Err: err})
+	m.mu.Unlock()
+	// Notify any blocked Receive() call
+	select {
+	case m.receiveNotifyCh <- struct{}{}:
+	default:
+	}
+}
+
+// Stats returns the counters of recorded sends and delivered receives.
+func (m *MockTransport) Stats() TransportStats {
+	return m.stats.snapshot()
+}
+
 // SendCalls returns all recorded Send() calls.
 //
 // This allows tests to verify:
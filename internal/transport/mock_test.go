@@ -64,3 +64,18 @@ func TestMockTransport_Send_RecordsCalls(t *testing.T) {
 		t.Errorf("Second call addr mismatch: got %v, want %v", calls[1].Dest, addr2)
 	}
 }
+
+// TestMockTransport_LocalAddr_Configurable verifies LocalAddr returns the
+// address set via SetLocalAddr.
+func TestMockTransport_LocalAddr_Configurable(t *testing.T) {
+	mock := transport.NewMockTransport()
+	if mock.LocalAddr() != nil {
+		t.Errorf("LocalAddr() = %v, want nil before SetLocalAddr", mock.LocalAddr())
+	}
+
+	want := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 49152}
+	mock.SetLocalAddr(want)
+	if got := mock.LocalAddr(); got != want {
+		t.Errorf("LocalAddr() = %v, want %v", got, want)
+	}
+}
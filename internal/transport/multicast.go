@@ -0,0 +1,119 @@
+package transport
+
+import (
+	goerrors "errors"
+	"log/slog"
+	"net"
+	"syscall"
+
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// multicastJoiner is the subset of *ipv4.PacketConn used to join the mDNS
+// group, extracted so per-interface join behavior can be tested without
+// real sockets.
+type multicastJoiner interface {
+	JoinGroup(ifi *net.Interface, group net.Addr) error
+}
+
+// multicastTTLSetter is the subset of *ipv4.PacketConn used to set the
+// multicast TTL, extracted so failure handling can be tested without real
+// sockets.
+type multicastTTLSetter interface {
+	SetMulticastTTL(ttl int) error
+}
+
+// setMulticastTTL sets the TTL of outgoing multicast packets (RFC 6762 §11).
+// A failure is logged and otherwise ignored: the OS default TTL still
+// reaches the local link.
+//
+// Returns:
+//   - bool: true if the TTL was set
+func setMulticastTTL(conn multicastTTLSetter, ttl int, logger *slog.Logger) bool {
+	if err := conn.SetMulticastTTL(ttl); err != nil {
+		logger.Warn("failed to set mDNS multicast TTL; using the OS default", "ttl", ttl, "error", err)
+		return false
+	}
+	return true
+}
+
+// unicastTTLSetter is the subset of *ipv4.PacketConn used to set the TTL of
+// unicast packets, extracted so failure handling can be tested without real
+// sockets.
+type unicastTTLSetter interface {
+	SetTTL(ttl int) error
+}
+
+// setUnicastTTL sets the TTL of outgoing unicast packets (IP_TTL), such as
+// unicast and legacy unicast responses, to 255.
+//
+// RFC 6762 §11: "All Multicast DNS responses (including responses sent via
+// unicast) SHOULD be sent with IP TTL set to 255." A failure is logged and
+// otherwise ignored, as for setMulticastTTL.
+//
+// Returns:
+//   - bool: true if the TTL was set
+func setUnicastTTL(conn unicastTTLSetter, logger *slog.Logger) bool {
+	if err := conn.SetTTL(protocol.MulticastTTL); err != nil {
+		logger.Warn("failed to set mDNS unicast TTL; using the OS default", "ttl", protocol.MulticastTTL, "error", err)
+		return false
+	}
+	return true
+}
+
+// multicastInterfaces returns the interfaces eligible for an mDNS group join:
+// UP, MULTICAST-capable (or loopback, if loopback is set) and accepted by
+// filter (nil accepts all). Linux does not flag lo as MULTICAST-capable, yet
+// group members on it receive multicast sent out lo.
+func multicastInterfaces(filter func(net.Interface) bool, loopback bool) ([]net.Interface, error) {
+	all, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	usable := make([]net.Interface, 0, len(all))
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		if iface.Flags&net.FlagMulticast == 0 && (!loopback || iface.Flags&net.FlagLoopback == 0) {
+			continue
+		}
+		if filter != nil && !filter(iface) {
+			continue
+		}
+		usable = append(usable, iface)
+	}
+	return usable, nil
+}
+
+// joinMulticastGroup joins group on every interface in ifaces.
+//
+// RFC 6762 §5: A responder must be a member of 224.0.0.251 on each link it
+// serves. A single default join covers only a system-chosen interface, so on
+// multi-homed hosts queries arriving on other links would be missed.
+// Per-interface failures are logged and skipped so one misbehaving interface
+// cannot prevent membership on the rest. An interface on which the group is
+// already joined (EADDRINUSE) counts as joined.
+//
+// Parameters:
+//   - j: Connection used to issue the joins
+//   - ifaces: Candidate interfaces (see multicastInterfaces)
+//   - group: Multicast group address
+//   - logger: Receives a warning per failed interface
+//
+// Returns:
+//   - []net.Interface: Interfaces on which the group is joined
+func joinMulticastGroup(j multicastJoiner, ifaces []net.Interface, group net.Addr, logger *slog.Logger) []net.Interface {
+	joined := make([]net.Interface, 0, len(ifaces))
+	for i := range ifaces {
+		iface := &ifaces[i]
+		if err := j.JoinGroup(iface, group); err != nil && !goerrors.Is(err, syscall.EADDRINUSE) {
+			logger.Warn("mDNS multicast join failed; queries on this interface will be missed",
+				"interface", iface.Name, "index", iface.Index, "group", group.String(), "error", err)
+			continue
+		}
+		joined = append(joined, *iface)
+	}
+	return joined
+}
@@ -0,0 +1,156 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// fakeJoiner records JoinGroup calls and fails for configured interfaces.
+type fakeJoiner struct {
+	attempted []string
+	failures  map[string]error
+}
+
+func (f *fakeJoiner) JoinGroup(ifi *net.Interface, _ net.Addr) error {
+	f.attempted = append(f.attempted, ifi.Name)
+	return f.failures[ifi.Name]
+}
+
+// TestJoinMulticastGroup_ToleratesPerInterfaceFailure verifies the group join is
+// attempted on every interface, a failure on one neither aborts the rest nor
+// counts as joined, and an EADDRINUSE (already joined by default) counts as joined.
+func TestJoinMulticastGroup_ToleratesPerInterfaceFailure(t *testing.T) {
+	ifaces := []net.Interface{
+		{Index: 1, Name: "eth0"},
+		{Index: 2, Name: "wlan0"},
+		{Index: 3, Name: "eth1"},
+	}
+	j := &fakeJoiner{failures: map[string]error{
+		"eth0":  fmt.Errorf("setsockopt: %w", syscall.EADDRINUSE),
+		"wlan0": errors.New("no such device"),
+	}}
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	joined := joinMulticastGroup(j, ifaces, &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251)}, logger)
+
+	if got := strings.Join(j.attempted, ","); got != "eth0,wlan0,eth1" {
+		t.Errorf("JoinGroup attempted on %q, want eth0,wlan0,eth1", got)
+	}
+
+	var names []string
+	for _, iface := range joined {
+		names = append(names, iface.Name)
+	}
+	if got := strings.Join(names, ","); got != "eth0,eth1" {
+		t.Errorf("joined interfaces = %q, want eth0,eth1", got)
+	}
+
+	if !strings.Contains(logs.String(), "interface=wlan0") {
+		t.Errorf("expected warning for wlan0, got logs: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "interface=eth0") {
+		t.Errorf("unexpected warning for already-joined eth0: %s", logs.String())
+	}
+}
+
+// failingTTLSetter is a multicastTTLSetter whose SetMulticastTTL fails.
+type failingTTLSetter struct{}
+
+func (failingTTLSetter) SetMulticastTTL(int) error { return errors.New("operation not supported") }
+
+// TestSetMulticastTTL_ToleratesFailure verifies a platform refusing the
+// multicast TTL is logged rather than failing transport creation.
+func TestSetMulticastTTL_ToleratesFailure(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	if setMulticastTTL(failingTTLSetter{}, 255, logger) {
+		t.Error("setMulticastTTL() = true, want false when SetMulticastTTL fails")
+	}
+	if !strings.Contains(logs.String(), "ttl=255") {
+		t.Errorf("expected warning naming the TTL, got logs: %s", logs.String())
+	}
+}
+
+// failingUnicastTTLSetter is a unicastTTLSetter whose SetTTL fails.
+type failingUnicastTTLSetter struct{}
+
+func (failingUnicastTTLSetter) SetTTL(int) error { return errors.New("operation not supported") }
+
+// TestSetUnicastTTL_ToleratesFailure verifies a platform refusing the unicast
+// TTL is logged rather than failing transport creation.
+func TestSetUnicastTTL_ToleratesFailure(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	if setUnicastTTL(failingUnicastTTLSetter{}, logger) {
+		t.Error("setUnicastTTL() = true, want false when SetTTL fails")
+	}
+	if !strings.Contains(logs.String(), "ttl=255") {
+		t.Errorf("expected warning naming the TTL, got logs: %s", logs.String())
+	}
+}
+
+// TestMulticastInterfaces_Filter verifies the interface filter narrows the
+// interfaces the mDNS group is joined on.
+func TestMulticastInterfaces_Filter(t *testing.T) {
+	all, err := multicastInterfaces(nil, false)
+	if err != nil {
+		t.Fatalf("multicastInterfaces(nil, false) error = %v", err)
+	}
+	if len(all) == 0 {
+		t.Skip("no multicast-capable interfaces")
+	}
+
+	excluded := all[0].Name
+	filtered, err := multicastInterfaces(func(iface net.Interface) bool { return iface.Name != excluded }, false)
+	if err != nil {
+		t.Fatalf("multicastInterfaces(filter) error = %v", err)
+	}
+	if len(filtered) != len(all)-1 {
+		t.Errorf("filtered interfaces = %d, want %d", len(filtered), len(all)-1)
+	}
+	for _, iface := range filtered {
+		if iface.Name == excluded {
+			t.Errorf("filtered interfaces include excluded %s", excluded)
+		}
+	}
+}
+
+// TestMulticastInterfaces_Loopback verifies the loopback interface is only
+// eligible for a group join when loopback is requested.
+func TestMulticastInterfaces_Loopback(t *testing.T) {
+	hasLoopback := func(ifaces []net.Interface) bool {
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagLoopback != 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	with, err := multicastInterfaces(nil, true)
+	if err != nil {
+		t.Fatalf("multicastInterfaces(loopback) error = %v", err)
+	}
+	if !hasLoopback(with) {
+		t.Skip("no UP loopback interface")
+	}
+
+	without, err := multicastInterfaces(nil, false)
+	if err != nil {
+		t.Fatalf("multicastInterfaces() error = %v", err)
+	}
+	for _, iface := range without {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagMulticast == 0 {
+			t.Errorf("non-multicast loopback %s eligible without loopback requested", iface.Name)
+		}
+	}
+}
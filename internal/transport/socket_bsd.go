@@ -0,0 +1,55 @@
+//go:build freebsd || netbsd || openbsd || dragonfly
+
+package transport
+
+import (
+	"fmt"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// setSocketOptions configures platform-specific socket options for the BSDs.
+// Sets SO_REUSEADDR and SO_REUSEPORT to enable coexistence with other mDNS
+// daemons (e.g. Avahi or mdnsd from ports) on port 5353.
+//
+// Per F-9 REQ-F9-2: SO_REUSEPORT required for multi-daemon coexistence.
+// BSD semantics match macOS: SO_REUSEPORT lets multiple sockets bind the same
+// multicast address and port.
+func setSocketOptions(fd uintptr) error {
+	// SO_REUSEADDR: Allow binding to address already in use (BSD standard)
+	if err := unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEADDR, 1); err != nil {
+		return fmt.Errorf("failed to set SO_REUSEADDR: %w", err)
+	}
+
+	// SO_REUSEPORT: Allow multiple sockets to bind to same port
+	if err := unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1); err != nil {
+		return fmt.Errorf("failed to set SO_REUSEPORT: %w", err)
+	}
+
+	return nil
+}
+
+// getKernelVersion returns empty string on the BSDs (not applicable).
+func getKernelVersion() string {
+	return "" // Not applicable on BSD
+}
+
+// Control function for net.ListenConfig on the BSDs.
+// This is called by UDPv4Transport during socket creation.
+func platformControl(_, _ string, c syscall.RawConn) error {
+	var sockoptErr error
+	err := c.Control(func(fd uintptr) {
+		sockoptErr = setSocketOptions(fd)
+	})
+	if err != nil {
+		return fmt.Errorf("raw conn control failed: %w", err)
+	}
+	return sockoptErr
+}
+
+// PlatformControl returns the platform-specific control function for net.ListenConfig.
+// This is the public API for other packages to use socket options.
+func PlatformControl(network, address string, c syscall.RawConn) error {
+	return platformControl(network, address, c)
+}
@@ -0,0 +1,27 @@
+//go:build freebsd || netbsd || openbsd || dragonfly
+
+package transport
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestNewUDPv4Transport_ReuseOptions_BSD verifies the transport's own socket
+// was created with SO_REUSEADDR and SO_REUSEPORT applied before bind.
+func TestNewUDPv4Transport_ReuseOptions_BSD(t *testing.T) {
+	tr, err := NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = tr.Close() }()
+
+	// BSD getsockopt reports the option flag value rather than 1
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEADDR); got == 0 {
+		t.Error("SO_REUSEADDR not set on transport socket")
+	}
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEPORT); got == 0 {
+		t.Error("SO_REUSEPORT not set on transport socket")
+	}
+}
@@ -0,0 +1,68 @@
+//go:build darwin
+
+package transport
+
+import (
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestSetSocketOptions_macOS verifies SO_REUSEADDR and SO_REUSEPORT are set on macOS.
+// Per F-9 REQ-F9-2: macOS requires both options for Bonjour coexistence.
+func TestSetSocketOptions_macOS(t *testing.T) {
+	// Create a UDP socket
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_UDP)
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	defer func() { _ = syscall.Close(fd) }()
+
+	// Call setSocketOptions
+	if err := setSocketOptions(uintptr(fd)); err != nil {
+		t.Fatalf("setSocketOptions() failed: %v", err)
+	}
+
+	// Verify SO_REUSEADDR is set
+	reuseAddr, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR)
+	if err != nil {
+		t.Fatalf("Failed to get SO_REUSEADDR: %v", err)
+	}
+	if reuseAddr != 1 {
+		t.Errorf("SO_REUSEADDR = %d, want 1", reuseAddr)
+	}
+
+	// Verify SO_REUSEPORT is set (macOS always supports it)
+	reusePort, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT)
+	if err != nil {
+		t.Fatalf("Failed to get SO_REUSEPORT: %v", err)
+	}
+	if reusePort != 1 {
+		t.Errorf("SO_REUSEPORT = %d, want 1", reusePort)
+	}
+
+	// Kernel version not applicable on macOS
+	version := getKernelVersion()
+	if version != "" {
+		t.Logf("macOS kernel version: %s (informational only)", version)
+	}
+}
+
+// TestNewUDPv4Transport_ReuseOptions_macOS verifies the transport's own socket
+// was created with SO_REUSEADDR and SO_REUSEPORT applied before bind.
+func TestNewUDPv4Transport_ReuseOptions_macOS(t *testing.T) {
+	tr, err := NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = tr.Close() }()
+
+	// BSD getsockopt reports the option flag value rather than 1
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEADDR); got == 0 {
+		t.Error("SO_REUSEADDR not set on transport socket")
+	}
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEPORT); got == 0 {
+		t.Error("SO_REUSEPORT not set on transport socket")
+	}
+}
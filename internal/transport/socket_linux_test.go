@@ -0,0 +1,68 @@
+//go:build linux
+
+package transport
+
+import (
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// TestSetSocketOptions_Linux verifies SO_REUSEADDR and SO_REUSEPORT are set on Linux.
+// Per F-9 REQ-F9-2: Linux kernel 3.9+ requires both options for Avahi coexistence.
+func TestSetSocketOptions_Linux(t *testing.T) {
+	// Create a UDP socket
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_UDP)
+	if err != nil {
+		t.Fatalf("Failed to create socket: %v", err)
+	}
+	defer func() { _ = syscall.Close(fd) }()
+
+	// Call setSocketOptions
+	if err := setSocketOptions(uintptr(fd)); err != nil {
+		t.Fatalf("setSocketOptions() failed: %v", err)
+	}
+
+	// Verify SO_REUSEADDR is set
+	reuseAddr, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEADDR)
+	if err != nil {
+		t.Fatalf("Failed to get SO_REUSEADDR: %v", err)
+	}
+	if reuseAddr != 1 {
+		t.Errorf("SO_REUSEADDR = %d, want 1", reuseAddr)
+	}
+
+	// Verify SO_REUSEPORT is set (or gracefully unavailable on old kernels)
+	reusePort, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_REUSEPORT)
+	if err != nil && err != unix.ENOPROTOOPT {
+		t.Fatalf("Failed to get SO_REUSEPORT: %v", err)
+	}
+	if err == nil && reusePort != 1 {
+		t.Errorf("SO_REUSEPORT = %d, want 1", reusePort)
+	}
+
+	// Verify kernel version detection works
+	version := getKernelVersion()
+	if version == "" || version == "unknown" {
+		t.Errorf("getKernelVersion() returned %q, expected valid version string", version)
+	}
+	t.Logf("Linux kernel version: %s", version)
+}
+
+// TestNewUDPv4Transport_ReuseOptions_Linux verifies the transport's own socket
+// was created with SO_REUSEADDR and SO_REUSEPORT applied before bind.
+func TestNewUDPv4Transport_ReuseOptions_Linux(t *testing.T) {
+	tr, err := NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = tr.Close() }()
+
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEADDR); got != 1 {
+		t.Errorf("SO_REUSEADDR = %d, want 1", got)
+	}
+	if got := transportSockoptInt(t, tr, unix.SOL_SOCKET, unix.SO_REUSEPORT); got != 1 {
+		t.Errorf("SO_REUSEPORT = %d, want 1", got)
+	}
+}
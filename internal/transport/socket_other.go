@@ -0,0 +1,17 @@
+//go:build !linux && !darwin && !windows && !freebsd && !netbsd && !openbsd && !dragonfly
+
+package transport
+
+import "syscall"
+
+// platformControl sets no socket options on platforms without a dedicated
+// implementation; the bind succeeds only while port 5353 is free.
+func platformControl(_, _ string, _ syscall.RawConn) error {
+	return nil
+}
+
+// PlatformControl returns the platform-specific control function for net.ListenConfig.
+// This is the public API for other packages to use socket options.
+func PlatformControl(network, address string, c syscall.RawConn) error {
+	return platformControl(network, address, c)
+}
@@ -30,3 +30,19 @@ func TestSetSocketOptions_Windows(t *testing.T) {
 	// Note: SO_REUSEPORT does not exist on Windows, so we don't test it
 	t.Log("Windows: SO_REUSEADDR set correctly, SO_REUSEPORT not supported (as expected)")
 }
+
+// TestNewUDPv4Transport_SharesPort_Windows verifies two transports can bind
+// mDNS port 5353 at once, which on Windows relies on SO_REUSEADDR alone.
+func TestNewUDPv4Transport_SharesPort_Windows(t *testing.T) {
+	first, err := NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("first NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = first.Close() }()
+
+	second, err := NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("second NewUDPv4Transport() failed while first holds port 5353: %v", err)
+	}
+	defer func() { _ = second.Close() }()
+}
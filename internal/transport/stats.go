@@ -0,0 +1,63 @@
+package transport
+
+import (
+	"sync/atomic"
+)
+
+// TransportStats is a snapshot of a transport's traffic and error counters.
+//
+// Receive loops skip failed reads and carry on, so without these counters
+// dropped or unreadable packets are invisible; they help diagnose "why isn't
+// discovery working" in the field.
+type TransportStats struct {
+	BytesSent     uint64 // Bytes of packets sent successfully
+	BytesReceived uint64 // Bytes of packets received
+	SendErrors    uint64 // Send/SendOnInterface calls that failed
+	ReceiveErrors uint64 // Reads that failed, excluding timeouts and cancellation
+
+	// ControlMessageUnavailable reports that the platform does not deliver
+	// the receiving interface index (IP_PKTINFO/IP_RECVIF), so Receive always
+	// returns interfaceIndex 0 and responses fall back to the default
+	// interface address (RFC 6762 §15 best effort).
+	ControlMessageUnavailable bool
+}
+
+// statsCounters accumulates TransportStats; safe for concurrent use, so Send
+// and Receive on different goroutines update it without locking.
+type statsCounters struct {
+	bytesSent                 atomic.Uint64
+	bytesReceived             atomic.Uint64
+	sendErrors                atomic.Uint64
+	receiveErrors             atomic.Uint64
+	controlMessageUnavailable atomic.Bool
+}
+
+// recordSend counts a send of n bytes, or a send error if err is non-nil.
+func (s *statsCounters) recordSend(n int, err error) {
+	if err != nil {
+		s.sendErrors.Add(1)
+		return
+	}
+	s.bytesSent.Add(uint64(n)) //nolint:gosec // G115: n is a packet length, never negative
+}
+
+// recordReceive counts a received packet of n bytes.
+func (s *statsCounters) recordReceive(n int) {
+	s.bytesReceived.Add(uint64(n)) //nolint:gosec // G115: n is a packet length, never negative
+}
+
+// recordReceiveError counts a failed read.
+func (s *statsCounters) recordReceiveError() {
+	s.receiveErrors.Add(1)
+}
+
+// snapshot returns the current counter values.
+func (s *statsCounters) snapshot() TransportStats {
+	return TransportStats{
+		BytesSent:                 s.bytesSent.Load(),
+		BytesReceived:             s.bytesReceived.Load(),
+		SendErrors:                s.sendErrors.Load(),
+		ReceiveErrors:             s.receiveErrors.Load(),
+		ControlMessageUnavailable: s.controlMessageUnavailable.Load(),
+	}
+}
@@ -0,0 +1,73 @@
+package transport_test
+
+import (
+	"context"
+	goerrors "errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+// TestTransportStats_CountsSendReceiveAndErrors verifies Stats counts a send,
+// a receive and a failed read.
+func TestTransportStats_CountsSendReceiveAndErrors(t *testing.T) {
+	tr := transport.NewMockTransport()
+	ctx := context.Background()
+
+	if err := tr.Send(ctx, make([]byte, 40), protocol.MulticastGroupIPv4()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	tr.QueueReceive(make([]byte, 100), &net.UDPAddr{IP: net.IPv4(192, 168, 1, 2), Port: 5353}, 0)
+	if _, _, _, err := tr.Receive(ctx); err != nil {
+		t.Fatalf("Receive() error = %v", err)
+	}
+	readErr := goerrors.New("simulated read failure")
+	tr.QueueReceiveError(readErr)
+	if _, _, _, err := tr.Receive(ctx); !goerrors.Is(err, readErr) {
+		t.Fatalf("Receive() error = %v, want simulated failure", err)
+	}
+
+	want := transport.TransportStats{BytesSent: 40, BytesReceived: 100, ReceiveErrors: 1}
+	if got := tr.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+// TestUDPv4Transport_Stats verifies the socket transport counts sent bytes,
+// does not count a receive timeout as an error, and counts a failed read.
+func TestUDPv4Transport_Stats(t *testing.T) {
+	tr, err := transport.NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = tr.Close() }()
+
+	if err := tr.Send(context.Background(), []byte{0x00, 0x00, 0x00, 0x00}, protocol.MulticastGroupIPv4()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got := tr.Stats(); got.BytesSent != 4 || got.SendErrors != 0 {
+		t.Errorf("after Send: BytesSent = %d, SendErrors = %d, want 4, 0", got.BytesSent, got.SendErrors)
+	}
+
+	// A timeout is not an error (but real mDNS traffic may arrive meanwhile)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	_, _, _, _ = tr.Receive(ctx)
+	cancel()
+	if got := tr.Stats(); got.ReceiveErrors != 0 {
+		t.Errorf("after timeout: ReceiveErrors = %d, want 0", got.ReceiveErrors)
+	}
+
+	// Reading a closed socket fails
+	if err := tr.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, _, _, err := tr.Receive(context.Background()); err == nil {
+		t.Fatal("Receive() on closed socket error = nil, want error")
+	}
+	if got := tr.Stats(); got.ReceiveErrors != 1 {
+		t.Errorf("after failed read: ReceiveErrors = %d, want 1", got.ReceiveErrors)
+	}
+}
@@ -35,6 +35,24 @@ type Transport interface {
 	//   - error: NetworkError on transmission failure
 	Send(ctx context.Context, packet []byte, dest net.Addr) error
 
+	// SendOnInterface transmits a packet out a specific network interface.
+	//
+	// RFC 6762 §15: A response must leave on the interface the query arrived
+	// on, since its address records are only valid on that link. On a host
+	// that joined the group on several interfaces, Send egresses only the
+	// default multicast interface.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation and deadline propagation
+	//   - packet: DNS message in wire format
+	//   - dest: Destination address
+	//   - ifIndex: OS interface index to send from (as returned by Receive);
+	//              zero behaves like Send
+	//
+	// Returns:
+	//   - error: NetworkError on transmission failure
+	SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, ifIndex int) error
+
 	// Receive waits for an incoming packet, respecting context cancellation/deadline.
 	//
 	// 007-interface-specific-addressing: Added interfaceIndex return value for RFC 6762 §15 compliance.
@@ -62,4 +80,16 @@ type Transport interface {
 	// Returns:
 	//   - error: NetworkError on close failure (FR-004: must propagate errors, not swallow)
 	Close() error
+
+	// LocalAddr returns the local address the transport is bound to.
+	//
+	// Useful for logging, legacy unicast replies, and tests that must target
+	// the actual bound port. Returns nil if the transport is not bound.
+	LocalAddr() net.Addr
+
+	// Stats returns a snapshot of the transport's traffic and error counters.
+	//
+	// Counters are updated atomically by Send, SendOnInterface and Receive,
+	// so Stats may be called concurrently with them.
+	Stats() TransportStats
 }
@@ -2,9 +2,12 @@ package transport
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"log/slog"
 	"net"
 	"strconv"
+	"syscall"
 
 	"golang.org/x/net/ipv4"
 
@@ -25,9 +28,69 @@ import (
 type UDPv4Transport struct {
 	conn     net.PacketConn   // Raw UDP connection
 	ipv4Conn *ipv4.PacketConn // Wrapper for control message access (IP_PKTINFO/IP_RECVIF)
+	joined   []net.Interface  // Interfaces on which 224.0.0.251 was joined
+	stats    statsCounters    // Traffic and error counters (Stats)
 }
 
-// NewUDPv4Transport creates a UDP multicast transport bound to mDNS port 5353.
+// DefaultReadBufferSize is the default socket receive buffer (SO_RCVBUF) size.
+const DefaultReadBufferSize = 64 * 1024
+
+// MinReadBufferSize is the smallest accepted receive buffer: one maximum-size
+// mDNS packet (RFC 6762 §17: 9000 bytes).
+const MinReadBufferSize = 9000
+
+// UDPv4Options configures NewUDPv4TransportWithOptions.
+type UDPv4Options struct {
+	// ReadBufferSize is the socket receive buffer size in bytes.
+	// Zero selects DefaultReadBufferSize; otherwise it must be at least
+	// MinReadBufferSize. Busy networks answering large browses may need more
+	// to avoid drops; constrained devices may want less. The OS may round or
+	// cap the value (e.g. Linux doubles it and caps at net.core.rmem_max).
+	ReadBufferSize int
+
+	// MulticastTTL is the IP TTL of outgoing multicast packets
+	// (IP_MULTICAST_TTL). Zero selects protocol.MulticastTTL (255, RFC 6762
+	// §11); otherwise it must be between 1 and 255. Setting it is best-effort:
+	// on failure the OS default applies and a warning is logged.
+	MulticastTTL int
+
+	// InterfaceFilter restricts the interfaces on which the mDNS group is
+	// joined: only those for which it returns true. Nil joins on every UP,
+	// multicast-capable interface.
+	InterfaceFilter func(net.Interface) bool
+
+	// Loopback joins the mDNS group on the loopback interface as well and
+	// loops outgoing multicast back to this host, so processes on the same
+	// host exchange mDNS over lo. By default neither happens.
+	Loopback bool
+
+	// Logger receives warnings for interfaces on which the multicast group
+	// join fails. Nil selects slog.Default().
+	Logger *slog.Logger
+
+	// control and fallbackControl replace the socket option hooks of the
+	// primary and fallback binds (platformControl, reuseAddrControl) in tests.
+	control         func(network, address string, c syscall.RawConn) error
+	fallbackControl func(network, address string, c syscall.RawConn) error
+}
+
+// ErrPortInUse is wrapped by the NetworkError NewUDPv4Transport returns when
+// mDNS port 5353 is held by another socket that does not share it, typically
+// an mDNS daemon (avahi-daemon, mDNSResponder) bound without SO_REUSEPORT.
+// Test with errors.Is.
+var ErrPortInUse = goerrors.New("mDNS port already in use")
+
+// NewUDPv4Transport creates a UDP multicast transport bound to mDNS port 5353
+// with default options (64KB receive buffer).
+//
+// Returns:
+//   - *UDPv4Transport: Configured transport ready for Send/Receive
+//   - error: NetworkError if socket creation fails
+func NewUDPv4Transport() (*UDPv4Transport, error) {
+	return NewUDPv4TransportWithOptions(UDPv4Options{})
+}
+
+// NewUDPv4TransportWithOptions creates a UDP multicast transport bound to mDNS port 5353.
 //
 // This migrates CreateSocket() from internal/network/socket.go:24-58.
 //
@@ -35,13 +98,41 @@ type UDPv4Transport struct {
 //
 // FR-004: System MUST use mDNS port 5353 and multicast address 224.0.0.251
 // FR-013: System MUST return NetworkError for socket creation failures
+// F-9 REQ-F9-1: Platform-specific socket options (SO_REUSEADDR/SO_REUSEPORT)
+// are set before bind so the port can be shared with system mDNS daemons.
+// If that bind fails, it is retried once with SO_REUSEADDR alone; the error
+// returned when both fail joins the two.
 //
 // Returns:
 //   - *UDPv4Transport: Configured transport ready for Send/Receive
-//   - error: NetworkError if socket creation fails
+//   - error: ValidationError for invalid options, NetworkError if socket creation
+//     fails (wrapping ErrPortInUse when port 5353 is held unshared)
 //
 // T021: Socket creation, multicast join
-func NewUDPv4Transport() (*UDPv4Transport, error) {
+func NewUDPv4TransportWithOptions(opts UDPv4Options) (*UDPv4Transport, error) {
+	readBufferSize := opts.ReadBufferSize
+	if readBufferSize == 0 {
+		readBufferSize = DefaultReadBufferSize
+	}
+	if readBufferSize < MinReadBufferSize {
+		return nil, &errors.ValidationError{
+			Field:   "readBufferSize",
+			Value:   opts.ReadBufferSize,
+			Message: fmt.Sprintf("read buffer size must be at least %d bytes (RFC 6762 §17 maximum packet size)", MinReadBufferSize),
+		}
+	}
+	multicastTTL := opts.MulticastTTL
+	if multicastTTL == 0 {
+		multicastTTL = protocol.MulticastTTL
+	}
+	if multicastTTL < 1 || multicastTTL > 255 {
+		return nil, &errors.ValidationError{
+			Field:   "multicastTTL",
+			Value:   opts.MulticastTTL,
+			Message: "multicast TTL must be between 1 and 255",
+		}
+	}
+
 	// Resolve mDNS multicast address
 	multicastAddr, err := net.ResolveUDPAddr("udp4", net.JoinHostPort(protocol.MulticastAddrIPv4, strconv.Itoa(protocol.Port)))
 	if err != nil {
@@ -52,24 +143,68 @@ func NewUDPv4Transport() (*UDPv4Transport, error) {
 		}
 	}
 
-	// Listen on mDNS multicast group
-	// This binds to the multicast address and joins the group automatically
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	// F-9 REQ-F9-1: Bind 0.0.0.0:5353 through a ListenConfig whose Control
+	// hook sets SO_REUSEADDR (+ SO_REUSEPORT where available) BEFORE bind, so
+	// Beacon can share the port with Avahi, Bonjour or systemd-resolved.
+	// ListenMulticastUDP sets neither option, so a second mDNS process failed
+	// to bind. Binding the wildcard address (not the group) also lets the
+	// socket receive unicast replies addressed to port 5353 (RFC 6762 §5.5).
+	// If that bind fails (e.g. a sandbox rejecting SO_REUSEPORT), retry once
+	// with SO_REUSEADDR alone before giving up; the group is joined explicitly
+	// below either way.
 	// Connection ownership transferred to UDPv4Transport, closed via t.Close() method
-	//
-	// NOTE: ListenMulticastUDP is acceptable here for M1 (IPv4-only).
-	// F-9 REQ-F9-1 requires platform-specific sockets for M2 (IPv6 + SO_REUSEPORT).
-	// This will be replaced during M2 implementation with proper socket creation.
-	conn, err := net.ListenMulticastUDP("udp4", nil, multicastAddr) // nosemgrep: beacon-socket-close-check, beacon-listen-multicast-udp
+	control, fallbackControl := opts.control, opts.fallbackControl
+	if control == nil {
+		control = platformControl
+	}
+	if fallbackControl == nil {
+		fallbackControl = reuseAddrControl
+	}
+	bindAddr := net.JoinHostPort("0.0.0.0", strconv.Itoa(protocol.Port))
+	lc := net.ListenConfig{Control: control}
+	conn, err := lc.ListenPacket(context.Background(), "udp4", bindAddr) // nosemgrep: beacon-socket-close-check
+	if err != nil {
+		primaryErr := err
+		fallback := net.ListenConfig{Control: fallbackControl}
+		conn, err = fallback.ListenPacket(context.Background(), "udp4", bindAddr) // nosemgrep: beacon-socket-close-check
+		if err != nil {
+			err = goerrors.Join(primaryErr, err)
+		} else {
+			logger.Warn("mDNS bind failed, fell back to SO_REUSEADDR-only socket",
+				"error", primaryErr)
+		}
+	}
+	if goerrors.Is(err, errnoAddrInUse) {
+		return nil, &errors.NetworkError{
+			Operation: "create socket",
+			Err:       fmt.Errorf("%w: %w", ErrPortInUse, err),
+			Details:   fmt.Sprintf("port %d already in use — is avahi-daemon/mDNSResponder running? consider SO_REUSEPORT", protocol.Port),
+		}
+	}
 	if err != nil {
 		return nil, &errors.NetworkError{
 			Operation: "create socket",
 			Err:       err,
-			Details:   fmt.Sprintf("failed to bind to multicast %s:%d", protocol.MulticastAddrIPv4, protocol.Port),
+			Details:   fmt.Sprintf("failed to bind to port %d (is another mDNS daemon holding it without SO_REUSEPORT?)", protocol.Port),
+		}
+	}
+	udpConn, ok := conn.(*net.UDPConn)
+	if !ok {
+		_ = conn.Close() // Ignore error, already returning primary error
+		return nil, &errors.NetworkError{
+			Operation: "create socket",
+			Err:       fmt.Errorf("unexpected connection type %T", conn),
+			Details:   "expected *net.UDPConn",
 		}
 	}
 
 	// Configure socket buffer
-	err = conn.SetReadBuffer(65536) // 64KB buffer for DNS messages
+	err = udpConn.SetReadBuffer(readBufferSize)
 	if err != nil {
 		_ = conn.Close() // Ignore error, already returning primary error
 		return nil, &errors.NetworkError{
@@ -83,23 +218,57 @@ func NewUDPv4Transport() (*UDPv4Transport, error) {
 	// This allows extracting interface index from IP_PKTINFO (Linux) or IP_RECVIF (macOS/BSD)
 	ipv4Conn := ipv4.NewPacketConn(conn)
 
+	// RFC 6762 §5: Join 224.0.0.251 explicitly on every usable interface.
+	// Failures are tolerated per interface; if none succeeds, fall back to a
+	// single join on the system-chosen interface, as ListenMulticastUDP did.
+	group := &net.UDPAddr{IP: multicastAddr.IP}
+	var joined []net.Interface
+	ifaces, err := multicastInterfaces(opts.InterfaceFilter, opts.Loopback)
+	if err != nil {
+		logger.Warn("failed to enumerate interfaces for mDNS multicast join; relying on default interface", "error", err)
+	} else {
+		joined = joinMulticastGroup(ipv4Conn, ifaces, group, logger)
+	}
+	if len(joined) == 0 {
+		if err := ipv4Conn.JoinGroup(nil, group); err != nil {
+			_ = conn.Close() // Ignore error, already returning primary error
+			return nil, &errors.NetworkError{
+				Operation: "join multicast group",
+				Err:       err,
+				Details:   fmt.Sprintf("failed to join %s on any interface", protocol.MulticastAddrIPv4),
+			}
+		}
+	}
+
+	// Preserve ListenMulticastUDP's behavior of not looping our own multicast
+	// packets back to this host, unless same-host exchange was requested.
+	// Best-effort: failure only means local echoes (or their absence).
+	_ = ipv4Conn.SetMulticastLoopback(opts.Loopback) // nosemgrep: beacon-error-swallowing
+
+	// RFC 6762 §11: Send with TTL 255 rather than the OS default (often 1
+	// for multicast, 64 for unicast)
+	setMulticastTTL(ipv4Conn, multicastTTL, logger)
+	setUnicastTTL(ipv4Conn, logger)
+
 	// T009: Enable interface index in control messages (RFC 6762 §15 compliance)
 	// Platform-specific: IP_PKTINFO on Linux, IP_RECVIF on macOS/BSD
 	// NOTE: This may fail on Windows (not supported). We treat this as non-fatal
 	// to allow graceful degradation to interfaceIndex=0 (single-interface behavior).
 	// When control messages are unavailable, Receive() will return interfaceIndex=0,
 	// triggering fallback to getLocalIPv4() per RFC 6762 §15 best-effort compliance.
-	err = ipv4Conn.SetControlMessage(ipv4.FlagInterface, true)
-	if err != nil {
-		// TODO T032: Add debug logging when F-6 is implemented
-		// For now, silently continue - control messages are best-effort.
-		// interfaceIndex will be 0 when cm=nil, triggering graceful degradation.
-	}
-
-	return &UDPv4Transport{
+	t := &UDPv4Transport{
 		conn:     conn,
 		ipv4Conn: ipv4Conn,
-	}, nil
+		joined:   joined,
+	}
+	if err := ipv4Conn.SetControlMessage(ipv4.FlagInterface, true); err != nil {
+		// Control messages are best-effort: interfaceIndex will be 0 when
+		// cm=nil, triggering graceful degradation. Surfaced via Stats.
+		logger.Debug("interface index control messages unavailable; responses use the default interface address", "error", err)
+		t.stats.controlMessageUnavailable.Store(true)
+	}
+
+	return t, nil
 }
 
 // Send transmits a packet to the specified destination address.
@@ -126,6 +295,7 @@ func (t *UDPv4Transport) Send(ctx context.Context, packet []byte, dest net.Addr)
 
 	// Send query to destination
 	n, err := t.conn.WriteTo(packet, dest)
+	t.stats.recordSend(n, err)
 	if err != nil {
 		return &errors.NetworkError{
 			Operation: "send query",
@@ -146,6 +316,49 @@ func (t *UDPv4Transport) Send(ctx context.Context, packet []byte, dest net.Addr)
 	return nil
 }
 
+// SendOnInterface transmits a packet out the interface with index ifIndex.
+//
+// The interface is selected per packet with an IP_PKTINFO (Linux) or
+// IP_SENDIF-style control message carrying IfIndex, so responses egress the
+// link the query arrived on (RFC 6762 §15). ifIndex 0 falls back to Send.
+func (t *UDPv4Transport) SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, ifIndex int) error {
+	if ifIndex == 0 {
+		return t.Send(ctx, packet, dest)
+	}
+
+	// Check context cancellation before sending
+	select {
+	case <-ctx.Done():
+		return &errors.NetworkError{
+			Operation: "send query",
+			Err:       ctx.Err(),
+			Details:   "context canceled before send",
+		}
+	default:
+	}
+
+	n, err := t.ipv4Conn.WriteTo(packet, &ipv4.ControlMessage{IfIndex: ifIndex}, dest)
+	t.stats.recordSend(n, err)
+	if err != nil {
+		return &errors.NetworkError{
+			Operation: "send query",
+			Err:       err,
+			Details:   fmt.Sprintf("failed to send %d bytes to %s on interface %d", len(packet), dest, ifIndex),
+		}
+	}
+
+	// Verify full message was sent
+	if n != len(packet) {
+		return &errors.NetworkError{
+			Operation: "send query",
+			Err:       fmt.Errorf("partial write: %d/%d bytes", n, len(packet)),
+			Details:   "incomplete transmission",
+		}
+	}
+
+	return nil
+}
+
 // Receive waits for an incoming packet, respecting context cancellation/deadline.
 //
 // This migrates ReceiveResponse() from internal/network/socket.go:118-155
@@ -175,6 +388,7 @@ func (t *UDPv4Transport) Receive(ctx context.Context) ([]byte, net.Addr, int, er
 	if deadline, ok := ctx.Deadline(); ok {
 		err := t.conn.SetReadDeadline(deadline)
 		if err != nil {
+			t.stats.recordReceiveError()
 			return nil, nil, 0, &errors.NetworkError{
 				Operation: "set read timeout",
 				Err:       err,
@@ -202,6 +416,7 @@ func (t *UDPv4Transport) Receive(ctx context.Context) ([]byte, net.Addr, int, er
 			}
 		}
 
+		t.stats.recordReceiveError()
 		return nil, nil, 0, &errors.NetworkError{
 			Operation: "receive response",
 			Err:       err,
@@ -215,6 +430,7 @@ func (t *UDPv4Transport) Receive(ctx context.Context) ([]byte, net.Addr, int, er
 	if cm != nil {
 		interfaceIndex = cm.IfIndex
 	}
+	t.stats.recordReceive(n)
 
 	// T054: Return copy to caller (pool owns buffer, caller owns result)
 	// This ensures caller can use result after buffer is returned to pool
@@ -223,6 +439,22 @@ func (t *UDPv4Transport) Receive(ctx context.Context) ([]byte, net.Addr, int, er
 	return result, srcAddr, interfaceIndex, nil
 }
 
+// LocalAddr returns the address the socket is bound to (e.g. 0.0.0.0:5353).
+func (t *UDPv4Transport) LocalAddr() net.Addr {
+	return t.conn.LocalAddr()
+}
+
+// Stats returns a snapshot of the transport's traffic and error counters.
+func (t *UDPv4Transport) Stats() TransportStats {
+	return t.stats.snapshot()
+}
+
+// JoinedInterfaces returns the interfaces on which the mDNS multicast group
+// was explicitly joined. Interfaces whose join failed are absent.
+func (t *UDPv4Transport) JoinedInterfaces() []net.Interface {
+	return t.joined
+}
+
 // Close releases network resources.
 //
 // This migrates CloseSocket() from internal/network/socket.go:166-179
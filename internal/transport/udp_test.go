@@ -364,3 +364,21 @@ func TestUDPv4Transport_ControlMessageUnavailable(t *testing.T) {
 	t.Log("  • udp.go:214: if cm != nil { interfaceIndex = cm.IfIndex }")
 	t.Log("  • responder.go: if interfaceIndex == 0 { fallback to getLocalIPv4() }")
 }
+
+// TestUDPv4Transport_LocalAddr_ReflectsBoundPort verifies LocalAddr reports
+// the socket's actual bound address (mDNS port 5353).
+func TestUDPv4Transport_LocalAddr_ReflectsBoundPort(t *testing.T) {
+	tr, err := transport.NewUDPv4Transport()
+	if err != nil {
+		t.Fatalf("NewUDPv4Transport() failed: %v", err)
+	}
+	defer func() { _ = tr.Close() }()
+
+	addr, ok := tr.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		t.Fatalf("LocalAddr() = %T, want *net.UDPAddr", tr.LocalAddr())
+	}
+	if addr.Port != 5353 {
+		t.Errorf("LocalAddr().Port = %d, want 5353", addr.Port)
+	}
+}
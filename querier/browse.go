@@ -0,0 +1,362 @@
+package querier
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
+)
+
+// ServiceEventType identifies what happened to a browsed service instance.
+type ServiceEventType int
+
+const (
+	// ServiceAdded marks an instance seen for the first time.
+	ServiceAdded ServiceEventType = iota
+
+	// ServiceRemoved marks an instance that left; see ServiceEvent.Reason.
+	ServiceRemoved
+)
+
+// String returns a human-readable name for the event type.
+func (t ServiceEventType) String() string {
+	switch t {
+	case ServiceAdded:
+		return "Added"
+	case ServiceRemoved:
+		return "Removed"
+	default:
+		return "Unknown"
+	}
+}
+
+// RemovalReason explains why a ServiceRemoved event was emitted.
+type RemovalReason int
+
+const (
+	// RemovalNone is the reason carried by non-removal events.
+	RemovalNone RemovalReason = iota
+
+	// RemovedGoodbye means the responder announced departure with a TTL=0
+	// record (RFC 6762 §10.1).
+	RemovedGoodbye
+
+	// RemovedExpired means the PTR record's TTL ran out without a refresh,
+	// e.g. because the host vanished without sending a goodbye (RFC 6762 §10).
+	RemovedExpired
+)
+
+// String returns a human-readable name for the removal reason.
+func (r RemovalReason) String() string {
+	switch r {
+	case RemovalNone:
+		return "None"
+	case RemovedGoodbye:
+		return "Goodbye"
+	case RemovedExpired:
+		return "Expired"
+	default:
+		return "Unknown"
+	}
+}
+
+// ServiceEvent reports a change in the set of instances seen by Browse.
+type ServiceEvent struct {
+	// Instance is the affected service. For ServiceAdded, fields not bundled
+	// in the response's additional section (RFC 6763 §12) are left unset.
+	Instance ServiceInstance
+
+	// Type is ServiceAdded or ServiceRemoved.
+	Type ServiceEventType
+
+	// Reason explains a ServiceRemoved event (RemovalNone otherwise).
+	Reason RemovalReason
+}
+
+const (
+	// browseSweepInterval is how often Browse checks cached instances for TTL expiry.
+	browseSweepInterval = 250 * time.Millisecond
+
+	// browseInitialRequery and browseMaxRequery bound the continuous-query
+	// schedule of RFC 6762 §5.2: the interval between queries doubles from
+	// one second up to a maximum of 60 minutes.
+	browseInitialRequery = 1 * time.Second
+	browseMaxRequery     = 60 * time.Minute
+
+	// browseEventBuffer is the capacity of the channel returned by Browse.
+	browseEventBuffer = 16
+)
+
+// browseEntry is an instance currently considered present by Browse.
+type browseEntry struct {
+	instance ServiceInstance
+	ttl      *records.RecordTTL // PTR TTL, restarted on every refresh
+	goodbye  bool               // TTL cut to one second by a goodbye (RFC 6762 §10.1)
+}
+
+// Browse continuously watches for instances of serviceType and reports them
+// as ServiceEvents until ctx is cancelled or the Querier is closed, at which
+// point the returned channel is closed.
+//
+// RFC 6762 §5.2: Browse is a continuous query. The PTR question is re-sent at
+// doubling intervals (1s, 2s, 4s, ... up to 60 minutes). Each instance's PTR
+// TTL is tracked; an instance is removed with reason RemovedGoodbye one
+// second after a TTL=0 record arrives unless it is refreshed meanwhile (RFC
+// 6762 §10.1), or RemovedExpired when its TTL runs out without a refresh.
+//
+// Parameters:
+//   - ctx: Context controlling how long to browse
+//   - serviceType: DNS-SD service type (e.g., "_http._tcp.local")
+//
+// Returns:
+//   - <-chan ServiceEvent: Added/Removed events; closed when browsing stops
+//   - error: ValidationError for an invalid service type, NetworkError if the
+//     Querier is closed or the initial query cannot be sent
+//
+// Example:
+//
+//	events, err := q.Browse(ctx, "_http._tcp.local")
+//	if err != nil {
+//	    return err
+//	}
+//	for ev := range events {
+//	    fmt.Printf("%s %s (%s)\n", ev.Type, ev.Instance.InstanceName, ev.Reason)
+//	}
+func (q *Querier) Browse(ctx context.Context, serviceType string) (<-chan ServiceEvent, error) {
+	if err := protocol.ValidateName(serviceType); err != nil {
+		return nil, err // Already wrapped as ValidationError
+	}
+
+	queryMsg, err := message.BuildQuery(serviceType, uint16(RecordTypePTR))
+	if err != nil {
+		return nil, err
+	}
+
+	packets, err := q.addBrowser("browse")
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 6762 §5.4: Only the first query of the series may ask for unicast
+	// replies (WithInitialQU); re-queries are plain multicast questions
+	initialMsg := queryMsg
+	if q.initialQU {
+		initialMsg = append([]byte(nil), queryMsg...)
+		setQU(initialMsg)
+	}
+	if err := q.transport.Send(ctx, initialMsg, protocol.MulticastGroupIPv4()); err != nil {
+		q.removeBrowser(packets)
+		q.wg.Done()
+		return nil, err // Already wrapped as NetworkError
+	}
+
+	events := make(chan ServiceEvent, browseEventBuffer)
+	go q.browseLoop(ctx, serviceType, queryMsg, packets, events)
+	return events, nil
+}
+
+// addBrowser registers a channel that receives a copy of every accepted
+// packet, for a continuous operation (Browse, Watch) running in its own
+// goroutine. The caller owns one q.wg count, released by q.wg.Done when the
+// goroutine exits, and unregisters the channel with removeBrowser.
+//
+// Returns:
+//   - chan inboundPacket: The registered channel
+//   - error: NetworkError if the Querier is closed
+func (q *Querier) addBrowser(operation string) (chan inboundPacket, error) {
+	packets := make(chan inboundPacket, 100)
+	q.browsersMu.Lock()
+	defer q.browsersMu.Unlock()
+	if q.ctx.Err() != nil {
+		return nil, &errors.NetworkError{
+			Operation: operation,
+			Err:       q.ctx.Err(),
+			Details:   "querier is closed",
+		}
+	}
+	if q.browsers == nil {
+		q.browsers = make(map[chan inboundPacket]struct{})
+	}
+	q.browsers[packets] = struct{}{}
+	q.wg.Add(1)
+	return packets, nil
+}
+
+// removeBrowser stops fanning received packets out to a Browse goroutine.
+func (q *Querier) removeBrowser(packets chan inboundPacket) {
+	q.browsersMu.Lock()
+	delete(q.browsers, packets)
+	q.browsersMu.Unlock()
+}
+
+// browseLoop runs one Browse: it turns received PTR answers into events,
+// sweeps for expired instances, and re-sends the query on the RFC 6762 §5.2
+// schedule.
+func (q *Querier) browseLoop(ctx context.Context, serviceType string, queryMsg []byte, packets chan inboundPacket, events chan<- ServiceEvent) {
+	defer q.wg.Done()
+	defer close(events)
+	defer q.removeBrowser(packets)
+
+	instances := make(map[string]*browseEntry)
+
+	// emit delivers an event, giving up when browsing stops. Events for
+	// instances rejected by WithInstanceFilter are dropped.
+	emit := func(ev ServiceEvent) bool {
+		if q.instanceFilter != nil && !q.instanceFilter(ev.Instance.InstanceName) {
+			return true
+		}
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-q.ctx.Done():
+			return false
+		}
+	}
+
+	c := clock.Or(q.clock)
+	sweep := c.After(browseSweepInterval)
+
+	requeryInterval := browseInitialRequery
+	requery := c.After(requeryInterval)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-q.ctx.Done():
+			return
+
+		case packet := <-packets:
+			for _, ev := range browseEvents(packet.data, packet.ifIndex, serviceType, instances, q.clock) {
+				if !emit(ev) {
+					return
+				}
+			}
+
+		case <-sweep:
+			for key, entry := range instances {
+				if !entry.ttl.IsExpired() {
+					continue
+				}
+				delete(instances, key)
+				reason := RemovedExpired
+				if entry.goodbye {
+					reason = RemovedGoodbye
+				}
+				if !emit(ServiceEvent{Instance: entry.instance, Type: ServiceRemoved, Reason: reason}) {
+					return
+				}
+			}
+			sweep = c.After(browseSweepInterval)
+
+		case <-requery:
+			// Best-effort: a failed re-query is retried on the next interval
+			q.sendFollowUp(ctx, queryMsg, protocol.MulticastGroupIPv4())
+			requeryInterval *= 2
+			if requeryInterval > browseMaxRequery {
+				requeryInterval = browseMaxRequery
+			}
+			requery = c.After(requeryInterval)
+		}
+	}
+}
+
+// browseEvents applies the PTR answers for serviceType in one response,
+// received on interface ifIndex, to instances and returns the resulting
+// events. New instances are resolved from the response's additional section;
+// known instances have their TTL restarted, or cut to one second by a TTL=0
+// goodbye, after which the sweep removes them (RFC 6762 §10.1). TTLs age on
+// c (nil = wall clock).
+func browseEvents(responseMsg []byte, ifIndex int, serviceType string, instances map[string]*browseEntry, c clock.Clock) []ServiceEvent {
+	parsedMsg, ok := decodeResponse(responseMsg)
+	if !ok {
+		return nil
+	}
+	zone := packetZone(ifIndex)
+
+	var events []ServiceEvent
+	var additionals []ResourceRecord
+	for _, answer := range parsedMsg.Answers {
+		if RecordType(answer.TYPE) != RecordTypePTR || !strings.EqualFold(answer.NAME, serviceType) {
+			continue
+		}
+		record, err := decodeRecord(responseMsg, answer, zone)
+		if err != nil {
+			continue
+		}
+		target := record.AsPTR()
+		if target == "" {
+			continue
+		}
+
+		key := strings.ToLower(target)
+		entry, known := instances[key]
+		switch {
+		case record.TTL == 0 && known:
+			// RFC 6762 §10.1: "Queriers receiving a Multicast DNS response
+			// with a TTL of zero SHOULD NOT immediately delete the record from
+			// the cache, but instead record a TTL of 1 and then delete the
+			// record one second later."
+			if !entry.goodbye {
+				entry.ttl = records.NewRecordTTLWithClock(protocol.RecordTypePTR, 1, c)
+				entry.goodbye = true
+			}
+
+		case record.TTL == 0:
+			// Goodbye for an instance never seen - nothing to remove
+
+		case known:
+			entry.ttl = records.NewRecordTTLWithClock(protocol.RecordTypePTR, record.TTL, c)
+			entry.goodbye = false
+
+		default:
+			if additionals == nil {
+				additionals = decodeAdditionals(responseMsg, parsedMsg.Additionals, zone)
+			}
+			svc := ServiceInstance{
+				InstanceName: instanceName(target, serviceType),
+				ServiceType:  serviceType,
+			}
+			resolveFromAdditionals(&svc, target, additionals)
+			instances[key] = &browseEntry{
+				instance: svc,
+				ttl:      records.NewRecordTTLWithClock(protocol.RecordTypePTR, record.TTL, c),
+			}
+			events = append(events, ServiceEvent{Instance: svc, Type: ServiceAdded})
+		}
+	}
+	return events
+}
+
+// decodeAdditionals parses a response's additional-section records, skipping
+// any with malformed RDATA. zone is the receiving interface's zone (see
+// decodeRecord).
+func decodeAdditionals(responseMsg []byte, answers []message.Answer, zone func() string) []ResourceRecord {
+	decoded := make([]ResourceRecord, 0, len(answers))
+	for _, add := range answers {
+		record, err := decodeRecord(responseMsg, add, zone)
+		if err != nil {
+			continue
+		}
+		decoded = append(decoded, record)
+	}
+	return decoded
+}
+
+// instanceName extracts the instance label from a PTR target:
+// "My Printer._http._tcp.local" → "My Printer". Targets outside serviceType
+// are returned unchanged.
+func instanceName(target, serviceType string) string {
+	if len(target) > len(serviceType)+1 && strings.EqualFold(target[len(target)-len(serviceType)-1:], "."+serviceType) {
+		return target[:len(target)-len(serviceType)-1]
+	}
+	return target
+}
@@ -0,0 +1,206 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+// buildPTRResponse builds a response with a single PTR answer for serviceType.
+func buildPTRResponse(serviceType, instance string, ttl uint32) []byte {
+	target, _ := message.EncodeName(instance)
+	msg := &message.DNSMessage{
+		Header: message.DNSHeader{Flags: 0x8400, ANCount: 1},
+		Answers: []message.Answer{
+			{NAME: serviceType, TYPE: uint16(protocol.RecordTypePTR), CLASS: 1, TTL: ttl, RDATA: target},
+		},
+	}
+	packet, _ := message.SerializeMessage(msg)
+	return packet
+}
+
+// nextEvent waits for the next Browse event or fails the test.
+func nextEvent(t *testing.T, events <-chan ServiceEvent, timeout time.Duration) ServiceEvent {
+	t.Helper()
+	select {
+	case ev, ok := <-events:
+		if !ok {
+			t.Fatal("events channel closed unexpectedly")
+		}
+		return ev
+	case <-time.After(timeout):
+		t.Fatalf("no event within %v", timeout)
+	}
+	return ServiceEvent{}
+}
+
+// TestBrowse_ExpiredWithoutRefresh verifies an instance whose PTR TTL runs out
+// without a refresh is removed with reason RemovedExpired (RFC 6762 §10), by
+// the first sweep at or after the TTL on the injected clock.
+func TestBrowse_ExpiredWithoutRefresh(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := q.Browse(ctx, "_http._tcp.local")
+	if err != nil {
+		t.Fatalf("Browse() error = %v", err)
+	}
+
+	mock.QueueReceive(buildPTRResponse("_http._tcp.local", "Inst._http._tcp.local", 1), nil, 0)
+	added := nextEvent(t, events, time.Second)
+	if added.Type != ServiceAdded || added.Instance.InstanceName != "Inst" {
+		t.Fatalf("first event = %s %q, want Added \"Inst\"", added.Type, added.Instance.InstanceName)
+	}
+
+	// Three sweeps before the 1s TTL runs out remove nothing
+	for i := 0; i < 3; i++ {
+		advanceBrowse(fake, browseSweepInterval)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("event %s/%s before the 1s TTL elapsed", ev.Type, ev.Reason)
+	default:
+	}
+
+	advanceBrowse(fake, browseSweepInterval)
+	removed := nextEvent(t, events, time.Second)
+	if removed.Type != ServiceRemoved || removed.Reason != RemovedExpired {
+		t.Fatalf("second event = %s/%s, want Removed/Expired", removed.Type, removed.Reason)
+	}
+}
+
+// TestBrowse_RequeryBackoff verifies Browse re-sends its query 1s, 2s and 4s
+// apart on the injected clock (RFC 6762 §5.2).
+func TestBrowse_RequeryBackoff(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := q.Browse(ctx, "_http._tcp.local"); err != nil {
+		t.Fatalf("Browse() error = %v", err)
+	}
+	waitForSends(t, mock, 1)
+
+	sends := 1
+	for _, gap := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		advanceBrowse(fake, gap-browseSweepInterval)
+		if got := len(mock.SendCalls()); got != sends {
+			t.Fatalf("sent %d queries %v before the re-query, want %d", got, browseSweepInterval, sends)
+		}
+		advanceBrowse(fake, browseSweepInterval)
+		sends++
+		waitForSends(t, mock, sends)
+	}
+}
+
+// advanceBrowse advances fake by d one sweep interval at a time, waiting
+// after each step until the browse loop has re-armed its sweep and re-query
+// timers.
+func advanceBrowse(fake *clock.Fake, d time.Duration) {
+	for ; d > 0; d -= browseSweepInterval {
+		fake.WaitForWaiters(2)
+		fake.Advance(browseSweepInterval)
+	}
+	fake.WaitForWaiters(2)
+}
+
+// TestBrowse_Goodbye verifies a TTL=0 PTR record removes a known instance with
+// reason RemovedGoodbye one second later on the injected clock, unless it is
+// refreshed meanwhile (RFC 6762 §10.1), and the channel closes with ctx.
+func TestBrowse_Goodbye(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := q.Browse(ctx, "_http._tcp.local")
+	if err != nil {
+		t.Fatalf("Browse() error = %v", err)
+	}
+
+	mock.QueueReceive(buildBundledPTRResponse("_http._tcp.local", "Inst._http._tcp.local",
+		"host.local", 8080, [4]byte{192, 168, 1, 5}, "path=/api"), nil, 0)
+	added := nextEvent(t, events, time.Second)
+	if added.Type != ServiceAdded || added.Instance.Hostname != "host.local" || added.Instance.Port != 8080 {
+		t.Fatalf("first event = %s %+v, want Added host.local:8080", added.Type, added.Instance)
+	}
+
+	// addMarker adds an instance, showing every packet queued before it has
+	// been applied
+	addMarker := func(name string) {
+		t.Helper()
+		mock.QueueReceive(buildPTRResponse("_http._tcp.local", name+"._http._tcp.local", 120), nil, 0)
+		if ev := nextEvent(t, events, time.Second); ev.Type != ServiceAdded || ev.Instance.InstanceName != name {
+			t.Fatalf("event = %s %q, want Added %q", ev.Type, ev.Instance.InstanceName, name)
+		}
+	}
+
+	mock.QueueReceive(buildPTRResponse("_http._tcp.local", "Inst._http._tcp.local", 0), nil, 0)
+	addMarker("Marker")
+
+	// The goodbye leaves the instance cached for one more second
+	for i := 0; i < 3; i++ {
+		advanceBrowse(fake, browseSweepInterval)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("event %s/%s within a second of the goodbye", ev.Type, ev.Reason)
+	default:
+	}
+
+	advanceBrowse(fake, browseSweepInterval)
+	removed := nextEvent(t, events, time.Second)
+	if removed.Type != ServiceRemoved || removed.Reason != RemovedGoodbye || removed.Instance.InstanceName != "Inst" {
+		t.Fatalf("event = %s/%s %q, want Removed/Goodbye \"Inst\"", removed.Type, removed.Reason, removed.Instance.InstanceName)
+	}
+
+	// A goodbye followed by a refresh within the second removes nothing
+	mock.QueueReceive(buildPTRResponse("_http._tcp.local", "Marker._http._tcp.local", 0), nil, 0)
+	mock.QueueReceive(buildPTRResponse("_http._tcp.local", "Marker._http._tcp.local", 120), nil, 0)
+	addMarker("Marker2")
+	advanceBrowse(fake, 2*time.Second)
+	select {
+	case ev := <-events:
+		t.Fatalf("event %s/%s %q after a refreshed goodbye, want none", ev.Type, ev.Reason, ev.Instance.InstanceName)
+	default:
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("unexpected event after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Error("events channel not closed after ctx cancel")
+	}
+}
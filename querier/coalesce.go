@@ -0,0 +1,198 @@
+package querier
+
+import (
+	"context"
+	goerrors "errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// roundKey identifies the question a shared query round asks.
+type roundKey struct {
+	name       string // Lowercased (RFC 1035 §2.3.3 case-insensitive)
+	recordType RecordType
+}
+
+// queryRound is one query on the wire whose aggregated response is shared by
+// every concurrent Query for the same name and type; see coalescedQuery.
+//
+// All fields but done, response, err and partial are guarded by
+// Querier.roundsMu.
+type queryRound struct {
+	done     chan struct{}      // Closed once response and err are set
+	response *Response          // Aggregated response (nil on error)
+	err      error              // As returned by runQuery
+	deadline time.Time          // When collection ends (zero = no deadline)
+	timer    *time.Timer        // Ends collection at deadline (nil = no deadline)
+	cancel   context.CancelFunc // Stops the round early
+	waiters  int                // Callers still waiting
+
+	partialMu sync.Mutex
+	partial   *Response // Response so far, for callers whose deadline comes first
+}
+
+// coalescedQuery answers Query for name and recordType by joining the round
+// already in flight for the same question, or by starting one.
+//
+// Popular names are often looked up by many goroutines at once; without
+// coalescing each would multicast its own identical query. A round collects
+// until the latest deadline among its callers (their contexts', else the
+// default timeout on the wall clock): a caller with a later deadline than the
+// round extends it, and a caller whose deadline comes first returns what the
+// round has collected by then. A round is not tied to any one caller: it stops
+// early only once every caller has gone.
+//
+// Returns:
+//   - *Response: The round's response; each caller gets its own copy
+//   - error: As for Query; ctx.Err() if ctx is cancelled before the round ends
+func (q *Querier) coalescedQuery(ctx context.Context, name string, recordType RecordType) (*Response, error) {
+	key := roundKey{name: strings.ToLower(name), recordType: recordType}
+	deadline, hasDeadline := ctx.Deadline()
+	if !hasDeadline && q.defaultTimeout > 0 {
+		// Bound the wait here too: the round may collect for longer
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.defaultTimeout)
+		defer cancel()
+		deadline, _ = ctx.Deadline()
+	}
+
+	q.roundsMu.Lock()
+	round, ok := q.rounds[key]
+	if !ok || !round.extendTo(deadline) {
+		// No round in flight, or the one in flight is already ending
+		round = q.startRound(key, name, recordType, deadline)
+	}
+	round.waiters++
+	q.roundsMu.Unlock()
+
+	select {
+	case <-round.done:
+	case <-ctx.Done():
+		if !goerrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			q.leaveRound(key, round)
+			return nil, ctx.Err()
+		}
+		if !q.roundEndsBy(round, deadline) {
+			// The round collects on for a later caller: return what it has
+			q.leaveRound(key, round)
+			return round.snapshot(), nil
+		}
+		// The round ends with this caller's deadline: wait for what it collected
+		<-round.done
+	}
+
+	if round.err != nil {
+		return nil, round.err
+	}
+	return round.response.clone(), nil
+}
+
+// extendTo makes round collect until at least deadline (zero = no deadline),
+// and reports whether it will. It fails only for a round whose collection has
+// already ended. The caller holds Querier.roundsMu.
+func (round *queryRound) extendTo(deadline time.Time) bool {
+	if round.timer == nil {
+		return true // Collects until every caller has gone
+	}
+	if !deadline.IsZero() && !deadline.After(round.deadline) {
+		return true
+	}
+	if !round.timer.Stop() {
+		return false // Deadline already passed
+	}
+	round.deadline = deadline
+	if deadline.IsZero() {
+		round.timer = nil
+	} else {
+		round.timer.Reset(time.Until(deadline))
+	}
+	return true
+}
+
+// roundEndsBy reports whether round's collection ends by deadline.
+func (q *Querier) roundEndsBy(round *queryRound, deadline time.Time) bool {
+	q.roundsMu.Lock()
+	defer q.roundsMu.Unlock()
+	return round.timer != nil && !round.deadline.After(deadline)
+}
+
+// snapshot returns a copy of the response round has collected so far.
+func (round *queryRound) snapshot() *Response {
+	round.partialMu.Lock()
+	defer round.partialMu.Unlock()
+	if round.partial == nil {
+		return &Response{Records: make([]ResourceRecord, 0)}
+	}
+	return round.partial.clone()
+}
+
+// startRound registers a round for key, collecting until deadline, and runs
+// it in the background. The caller holds q.roundsMu.
+//
+// Collection ends on a timer rather than a context deadline so that later
+// callers can extend it (see extendTo). Like Query's timeouts, the timer runs
+// on the wall clock.
+func (q *Querier) startRound(key roundKey, name string, recordType RecordType, deadline time.Time) *queryRound {
+	ctx, cancel := context.WithCancel(context.Background())
+	round := &queryRound{done: make(chan struct{}), deadline: deadline, cancel: cancel}
+	if !deadline.IsZero() {
+		round.timer = time.AfterFunc(time.Until(deadline), cancel)
+	}
+	if q.rounds == nil {
+		q.rounds = make(map[roundKey]*queryRound)
+	}
+	q.rounds[key] = round
+
+	go func() {
+		response, err := q.runQuery(ctx, name, recordType, 0, 0, func(partial *Response) {
+			round.partialMu.Lock()
+			round.partial = partial
+			round.partialMu.Unlock()
+		})
+
+		// Unregister before publishing, so no caller joins a finished round
+		q.roundsMu.Lock()
+		if round.timer != nil {
+			round.timer.Stop()
+		}
+		if q.rounds[key] == round {
+			delete(q.rounds, key)
+		}
+		q.roundsMu.Unlock()
+		cancel()
+
+		round.response, round.err = response, err
+		close(round.done)
+	}()
+	return round
+}
+
+// leaveRound withdraws a caller from round before it ends, stopping the round
+// once no caller is left waiting for it; a later caller starts a new one.
+func (q *Querier) leaveRound(key roundKey, round *queryRound) {
+	q.roundsMu.Lock()
+	defer q.roundsMu.Unlock()
+	round.waiters--
+	if round.waiters == 0 {
+		round.cancel()
+		if q.rounds[key] == round {
+			delete(q.rounds, key)
+		}
+	}
+}
+
+// clone returns a copy of r whose record slices may be modified without
+// affecting r, so callers sharing a round do not race.
+func (r *Response) clone() *Response {
+	if r == nil {
+		return nil
+	}
+	return &Response{
+		Records:     slices.Clone(r.Records),
+		Additionals: slices.Clone(r.Additionals),
+		Packets:     slices.Clone(r.Packets),
+		goodbyes:    slices.Clone(r.goodbyes),
+	}
+}
@@ -1,13 +1,16 @@
 package querier
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
 )
 
 // =============================================================================
@@ -79,11 +82,11 @@ func TestCollectResponses_MalformedMessage(t *testing.T) {
 
 	// Inject malformed packet into response channel
 	malformed := []byte{0x00, 0x01, 0x02} // Too short - invalid DNS message
-	q.responseChan <- malformed
+	q.responseChan <- inboundPacket{data: malformed}
 
 	// Also send a valid response packet to test that collection continues
 	validPacket := buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 1})
-	q.responseChan <- validPacket
+	q.responseChan <- inboundPacket{data: validPacket}
 
 	// Start collecting in background
 	doneChan := make(chan *Response, 1)
@@ -123,7 +126,7 @@ func TestCollectResponses_InvalidResponseFlags(t *testing.T) {
 
 	// Inject packet with QR=0 (query, not response) - should be skipped
 	queryPacket := buildQueryPacket("test.local", protocol.RecordTypeA)
-	q.responseChan <- queryPacket
+	q.responseChan <- inboundPacket{data: queryPacket}
 
 	// Start collecting in background
 	doneChan := make(chan *Response, 1)
@@ -161,11 +164,11 @@ func TestCollectResponses_TypeFiltering(t *testing.T) {
 
 	// Inject PTR response when querying for A record
 	ptrPacket := buildValidResponsePacket("_http._tcp.local", protocol.RecordTypePTR, []byte{4, 't', 'e', 's', 't', 0})
-	q.responseChan <- ptrPacket
+	q.responseChan <- inboundPacket{data: ptrPacket}
 
 	// Also inject matching A record
 	aPacket := buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 1})
-	q.responseChan <- aPacket
+	q.responseChan <- inboundPacket{data: aPacket}
 
 	// Start collecting A records
 	doneChan := make(chan *Response, 1)
@@ -210,9 +213,9 @@ func TestCollectResponses_Deduplication(t *testing.T) {
 	packet2 := buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 1}) // Duplicate
 	packet3 := buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 2}) // Different IP
 
-	q.responseChan <- packet1
-	q.responseChan <- packet2 // Should be deduplicated
-	q.responseChan <- packet3
+	q.responseChan <- inboundPacket{data: packet1}
+	q.responseChan <- inboundPacket{data: packet2} // Should be deduplicated
+	q.responseChan <- inboundPacket{data: packet3}
 
 	// Start collecting
 	doneChan := make(chan *Response, 1)
@@ -254,9 +257,9 @@ func TestCollectResponses_NormalAggregation(t *testing.T) {
 	packet2 := buildValidResponsePacket("test2.local", protocol.RecordTypeA, []byte{192, 168, 1, 2})
 	packet3 := buildValidResponsePacket("test3.local", protocol.RecordTypeA, []byte{192, 168, 1, 3})
 
-	q.responseChan <- packet1
-	q.responseChan <- packet2
-	q.responseChan <- packet3
+	q.responseChan <- inboundPacket{data: packet1}
+	q.responseChan <- inboundPacket{data: packet2}
+	q.responseChan <- inboundPacket{data: packet3}
 
 	// Start collecting
 	doneChan := make(chan *Response, 1)
@@ -334,6 +337,15 @@ func buildValidResponsePacket(name string, rtype protocol.RecordType, rdata []by
 	return packet
 }
 
+// buildGoodbyePacket is buildValidResponsePacket with the answer's TTL set
+// to 0, a goodbye for that record (RFC 6762 §10.1).
+func buildGoodbyePacket(name string, rtype protocol.RecordType, rdata []byte) []byte {
+	packet := buildValidResponsePacket(name, rtype, rdata)
+	ttlOffset := len(packet) - len(rdata) - 2 - 4 // Before RDLENGTH and RDATA
+	binary.BigEndian.PutUint32(packet[ttlOffset:], 0)
+	return packet
+}
+
 // buildQueryPacket constructs a DNS query packet (QR=0) for negative testing.
 //
 // Used to test that collectResponses properly skips query packets (FR-021).
@@ -404,7 +416,7 @@ func TestCollectResponses_RetainsAdditionals(t *testing.T) {
 
 	packet := buildBundledPTRResponse("_http._tcp.local", "Inst._http._tcp.local",
 		"host.local", 8080, [4]byte{192, 168, 1, 5}, "path=/api")
-	q.responseChan <- packet
+	q.responseChan <- inboundPacket{data: packet}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
 	defer cancel()
@@ -438,6 +450,151 @@ func TestCollectResponses_RetainsAdditionals(t *testing.T) {
 	}
 }
 
+// TestCollectResponses_KeepsDistinctRawRecords verifies answers of a record
+// type beacon does not decode are deduplicated by their RDATA, so two HINFO
+// records differing only in RDATA are both returned.
+func TestCollectResponses_KeepsDistinctRawRecords(t *testing.T) {
+	q, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	const hinfoType = 13
+	arm := []byte{3, 'A', 'R', 'M', 5, 'L', 'i', 'n', 'u', 'x'}
+	x86 := []byte{3, 'x', '8', '6', 5, 'L', 'i', 'n', 'u', 'x'}
+	answers := []message.Answer{
+		{NAME: "host.local", TYPE: hinfoType, CLASS: 1, TTL: 120, RDATA: arm},
+		{NAME: "host.local", TYPE: hinfoType, CLASS: 1, TTL: 120, RDATA: x86},
+		{NAME: "host.local", TYPE: hinfoType, CLASS: 1, TTL: 120, RDATA: arm},
+	}
+	packet, err := message.SerializeMessage(&message.DNSMessage{
+		Header:  message.DNSHeader{Flags: 0x8400, ANCount: 3},
+		Answers: answers,
+	})
+	if err != nil {
+		t.Fatalf("SerializeMessage() error = %v", err)
+	}
+	q.responseChan <- inboundPacket{data: packet}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	resp, err := q.collectResponses(ctx, "host.local", RecordType(hinfoType))
+	if err != nil {
+		t.Fatalf("collectResponses error: %v", err)
+	}
+	if len(resp.Records) != 2 || !bytes.Equal(resp.Records[0].RawData, arm) || !bytes.Equal(resp.Records[1].RawData, x86) {
+		t.Errorf("Records = %+v, want the two distinct HINFO records", resp.Records)
+	}
+}
+
+// TestCollectResponses_SkipsUnknownRecordTypes verifies a record type beacon
+// does not decode (HINFO, type 13) between an A and a PTR record neither
+// aborts the packet nor hides the records after it. In the Additional section
+// it is surfaced raw: nil Data, RDATA bytes in RawData.
+func TestCollectResponses_SkipsUnknownRecordTypes(t *testing.T) {
+	q, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	const hinfoType = 13
+	hinfo := []byte{3, 'A', 'R', 'M', 5, 'L', 'i', 'n', 'u', 'x'} // CPU, OS
+	target, _ := message.EncodeName("Inst._http._tcp.local")
+	records := []message.Answer{
+		{NAME: "host.local", TYPE: uint16(protocol.RecordTypeA), CLASS: 1, TTL: 120, RDATA: []byte{192, 168, 1, 5}},
+		{NAME: "host.local", TYPE: hinfoType, CLASS: 1, TTL: 120, RDATA: hinfo},
+		{NAME: "_http._tcp.local", TYPE: uint16(protocol.RecordTypePTR), CLASS: 1, TTL: 120, RDATA: target},
+	}
+	packet, err := message.SerializeMessage(&message.DNSMessage{
+		Header:      message.DNSHeader{Flags: 0x8400, ANCount: 3, ARCount: 3},
+		Answers:     records,
+		Additionals: records,
+	})
+	if err != nil {
+		t.Fatalf("SerializeMessage() error = %v", err)
+	}
+	q.responseChan <- inboundPacket{data: packet}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	resp, err := q.collectResponses(ctx, "_http._tcp.local", RecordTypePTR)
+	if err != nil {
+		t.Fatalf("collectResponses error: %v", err)
+	}
+
+	if len(resp.Records) != 1 || resp.Records[0].AsPTR() != "Inst._http._tcp.local" {
+		t.Fatalf("Records = %+v; want the PTR after the unknown record", resp.Records)
+	}
+
+	if len(resp.Additionals) != 3 {
+		t.Fatalf("Additionals has %d records, want 3 (A, HINFO, PTR)", len(resp.Additionals))
+	}
+	if ip := resp.Additionals[0].AsA(); !ip.Equal(net.IPv4(192, 168, 1, 5)) {
+		t.Errorf("Additionals[0] A = %v, want 192.168.1.5", ip)
+	}
+	raw := resp.Additionals[1]
+	if raw.Type != hinfoType || raw.Data != nil || !bytes.Equal(raw.RawData, hinfo) {
+		t.Errorf("Additionals[1] = type %d Data %v RawData %q, want raw HINFO %q", raw.Type, raw.Data, raw.RawData, hinfo)
+	}
+	if ptr := resp.Additionals[2].AsPTR(); ptr != "Inst._http._tcp.local" {
+		t.Errorf("Additionals[2] PTR = %q, want Inst._http._tcp.local", ptr)
+	}
+}
+
+// TestCollectResponses_TXTNonUTF8 verifies a TXT segment holding bytes that
+// are not valid UTF-8 (RFC 6763 §6 allows binary values) is preserved exactly
+// by AsTXTRaw, while AsTXT still returns the printable segments around it
+// unchanged.
+func TestCollectResponses_TXTNonUTF8(t *testing.T) {
+	q, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	value := []byte{'k', 'e', 'y', '=', 0xff, 0xfe, 0x00, 0x80}
+	rdata := []byte{6, 't', 'x', 't', 'v', 'e', 'r'}
+	rdata = append(rdata, byte(len(value)))
+	rdata = append(rdata, value...)
+	rdata = append(rdata, 6, 'p', 'a', 't', 'h', '=', '/')
+
+	q.responseChan <- inboundPacket{data: buildValidResponsePacket("Inst._http._tcp.local", protocol.RecordTypeTXT, rdata)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	resp, err := q.collectResponses(ctx, "Inst._http._tcp.local", RecordTypeTXT)
+	if err != nil {
+		t.Fatalf("collectResponses error: %v", err)
+	}
+	if len(resp.Records) != 1 {
+		t.Fatalf("Records = %+v, want one TXT record", resp.Records)
+	}
+	record := resp.Records[0]
+
+	raw := record.AsTXTRaw()
+	want := [][]byte{[]byte("txtver"), value, []byte("path=/")}
+	if len(raw) != len(want) {
+		t.Fatalf("AsTXTRaw() = %q, want %q", raw, want)
+	}
+	for i := range want {
+		if !bytes.Equal(raw[i], want[i]) {
+			t.Errorf("AsTXTRaw()[%d] = %x, want %x", i, raw[i], want[i])
+		}
+	}
+
+	txt := record.AsTXT()
+	if len(txt) != 3 || txt[0] != "txtver" || txt[2] != "path=/" {
+		t.Errorf("AsTXT() = %q, want printable segments intact", txt)
+	}
+
+	ptr := ResourceRecord{Type: RecordTypePTR, Data: "x.local"}
+	if got := ptr.AsTXTRaw(); got != nil {
+		t.Errorf("AsTXTRaw() on PTR record = %q, want nil", got)
+	}
+}
+
 // TestDiscoverServices_UsesAdditionals_SingleRoundTrip verifies that when the
 // browse (PTR) response bundles SRV/TXT/A in its Additional section,
 // DiscoverServices resolves the instance from that single response WITHOUT
@@ -453,7 +610,7 @@ func TestDiscoverServices_UsesAdditionals_SingleRoundTrip(t *testing.T) {
 
 	packet := buildBundledPTRResponse("_http._tcp.local", "Inst._http._tcp.local",
 		"host.local", 8080, [4]byte{192, 168, 1, 5}, "path=/api")
-	q.responseChan <- packet
+	q.responseChan <- inboundPacket{data: packet}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
@@ -481,3 +638,215 @@ func TestDiscoverServices_UsesAdditionals_SingleRoundTrip(t *testing.T) {
 		t.Errorf("AddrIPv4 = %v, want 192.168.1.5 (from bundled A additional)", s.AddrIPv4)
 	}
 }
+
+// TestCollectResponses_GoodbyeRemovesRecord verifies a TTL=0 record withdraws
+// the matching record collected earlier and is not itself reported, while
+// other records are kept (RFC 6762 §10.1).
+func TestCollectResponses_GoodbyeRemovesRecord(t *testing.T) {
+	q, err := New()
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	q.responseChan <- inboundPacket{data: buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 1})}
+	q.responseChan <- inboundPacket{data: buildValidResponsePacket("test.local", protocol.RecordTypeA, []byte{192, 168, 1, 2})}
+	q.responseChan <- inboundPacket{data: buildGoodbyePacket("TEST.local", protocol.RecordTypeA, []byte{192, 168, 1, 1})}
+
+	response, err := q.collectResponses(ctx, "test.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("collectResponses() error = %v", err)
+	}
+	if len(response.Records) != 1 || !response.Records[0].AsA().Equal(net.IPv4(192, 168, 1, 2)) {
+		t.Errorf("collectResponses() records = %+v, want only 192.168.1.2 (192.168.1.1 said goodbye)", response.Records)
+	}
+}
+
+// buildDualStackPTRResponse is buildBundledPTRResponse with an AAAA record for
+// host bundled after the A record.
+func buildDualStackPTRResponse(serviceType, instance, host string, port uint16, ipv4 [4]byte, ipv6 net.IP) []byte {
+	ptrTarget, _ := message.EncodeName(instance)
+
+	srv := make([]byte, 6)
+	binary.BigEndian.PutUint16(srv[4:6], port)
+	hostEnc, _ := message.EncodeName(host)
+	srv = append(srv, hostEnc...)
+
+	msg := &message.DNSMessage{
+		Header: message.DNSHeader{Flags: 0x8400}, // QR=1, AA=1
+		Answers: []message.Answer{
+			{NAME: serviceType, TYPE: uint16(protocol.RecordTypePTR), CLASS: 1, TTL: 120, RDATA: ptrTarget},
+		},
+		Additionals: []message.Answer{
+			{NAME: instance, TYPE: uint16(protocol.RecordTypeSRV), CLASS: 1, TTL: 120, RDATA: srv},
+			{NAME: instance, TYPE: uint16(protocol.RecordTypeTXT), CLASS: 1, TTL: 120, RDATA: []byte{0}},
+			{NAME: host, TYPE: uint16(protocol.RecordTypeA), CLASS: 1, TTL: 120, RDATA: ipv4[:]},
+			{NAME: host, TYPE: uint16(protocol.RecordTypeAAAA), CLASS: 1, TTL: 120, RDATA: ipv6.To16()},
+		},
+	}
+	packet, _ := message.SerializeMessage(msg)
+	return packet
+}
+
+// TestDiscoverServices_AddressPreference verifies a dual-stack instance's
+// addresses are chosen and ordered by WithAddressPreference (RFC 3596).
+func TestDiscoverServices_AddressPreference(t *testing.T) {
+	ipv4 := net.IPv4(192, 168, 1, 5)
+	ipv6 := net.ParseIP("fe80::1")
+
+	tests := []struct {
+		pref AddressPreference
+		want []net.IP
+	}{
+		{Both, []net.IP{ipv4, ipv6}},
+		{PreferIPv6, []net.IP{ipv6, ipv4}},
+		{PreferIPv4, []net.IP{ipv4, ipv6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pref.String(), func(t *testing.T) {
+			q, err := New(WithAddressPreference(tt.pref))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			defer func() { _ = q.Close() }()
+
+			q.responseChan <- inboundPacket{data: buildDualStackPTRResponse("_http._tcp.local",
+				"Inst._http._tcp.local", "host.local", 8080, [4]byte{192, 168, 1, 5}, ipv6)}
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			services, err := q.DiscoverServices(ctx, "_http._tcp.local")
+			if err != nil {
+				t.Fatalf("DiscoverServices error: %v", err)
+			}
+			if len(services) != 1 {
+				t.Fatalf("got %d services, want 1", len(services))
+			}
+			s := services[0]
+			if !s.AddrIPv4.Equal(ipv4) || !s.AddrIPv6.Equal(ipv6) {
+				t.Errorf("AddrIPv4, AddrIPv6 = %v, %v; want %v, %v", s.AddrIPv4, s.AddrIPv6, ipv4, ipv6)
+			}
+			if len(s.Addrs) != len(tt.want) {
+				t.Fatalf("Addrs = %v, want %v", s.Addrs, tt.want)
+			}
+			for i := range tt.want {
+				if !s.Addrs[i].Equal(tt.want[i]) {
+					t.Errorf("Addrs = %v, want %v", s.Addrs, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestDiscoverServices_ResolvesMissingAAAA verifies a dual-stack instance
+// whose browse response bundles only the A record gets its IPv6 address from
+// a follow-up AAAA query under the default preference, and that PreferIPv4
+// sends none.
+func TestDiscoverServices_ResolvesMissingAAAA(t *testing.T) {
+	ipv4 := net.IPv4(192, 168, 1, 5)
+	ipv6 := net.ParseIP("fe80::1")
+
+	tests := []struct {
+		pref     AddressPreference
+		wantAAAA bool
+	}{
+		{Both, true},
+		{PreferIPv6, true},
+		{PreferIPv4, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pref.String(), func(t *testing.T) {
+			mock := transport.NewMockTransport()
+			mock.EnableBlockingReceive()
+			q, err := New(WithTransport(mock), WithRateLimit(false), WithAddressPreference(tt.pref))
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			defer func() { _ = q.Close() }()
+
+			mock.QueueReceive(buildBundledPTRResponse("_http._tcp.local", "Inst._http._tcp.local",
+				"host.local", 8080, [4]byte{192, 168, 1, 5}, "path=/"), nil, 0)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			type result struct {
+				services []ServiceInstance
+				err      error
+			}
+			results := make(chan result, 1)
+			go func() {
+				services, err := q.DiscoverServices(ctx, "_http._tcp.local")
+				results <- result{services, err}
+			}()
+
+			// Answer the AAAA query once it is sent
+			var r result
+			answered := false
+			for done := false; !done; {
+				select {
+				case r = <-results:
+					done = true
+				case <-time.After(5 * time.Millisecond):
+					if !answered && sentQuestion(t, mock, "host.local", protocol.RecordTypeAAAA) {
+						mock.QueueReceive(buildValidResponsePacket("host.local", protocol.RecordTypeAAAA, ipv6.To16()), nil, 0)
+						answered = true
+					}
+				}
+			}
+			if r.err != nil {
+				t.Fatalf("DiscoverServices error: %v", r.err)
+			}
+			if answered != tt.wantAAAA {
+				t.Errorf("AAAA query sent = %v, want %v", answered, tt.wantAAAA)
+			}
+			if len(r.services) != 1 {
+				t.Fatalf("got %d services, want 1", len(r.services))
+			}
+			s := r.services[0]
+			if !s.AddrIPv4.Equal(ipv4) {
+				t.Errorf("AddrIPv4 = %v, want %v", s.AddrIPv4, ipv4)
+			}
+			if tt.wantAAAA && !s.AddrIPv6.Equal(ipv6) {
+				t.Errorf("AddrIPv6 = %v, want %v (from the AAAA query)", s.AddrIPv6, ipv6)
+			}
+		})
+	}
+}
+
+// sentQuestion reports whether mock has sent a query asking for name and
+// recordType.
+func sentQuestion(t *testing.T, mock *transport.MockTransport, name string, recordType protocol.RecordType) bool {
+	t.Helper()
+	for _, call := range mock.SendCalls() {
+		msg, err := message.ParseMessage(call.Packet)
+		if err != nil {
+			t.Fatalf("ParseMessage(sent packet) error = %v", err)
+		}
+		for _, question := range msg.Questions {
+			if question.QNAME == name && question.QTYPE == uint16(recordType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// TestAddressPreference_FallsBack verifies a preferred family falls back to
+// the other when the host has no address of that family.
+func TestAddressPreference_FallsBack(t *testing.T) {
+	ipv4 := net.IPv4(192, 168, 1, 5)
+	ipv6 := net.ParseIP("fe80::1")
+
+	if got := PreferIPv6.addresses(ipv4, nil); len(got) != 1 || !got[0].Equal(ipv4) {
+		t.Errorf("PreferIPv6 with IPv4 only = %v, want [%v]", got, ipv4)
+	}
+	if got := PreferIPv4.addresses(nil, ipv6); len(got) != 1 || !got[0].Equal(ipv6) {
+		t.Errorf("PreferIPv4 with IPv6 only = %v, want [%v]", got, ipv6)
+	}
+	if got := Both.addresses(nil, nil); len(got) != 0 {
+		t.Errorf("Both with no addresses = %v, want none", got)
+	}
+}
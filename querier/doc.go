@@ -177,7 +177,7 @@
 //
 // # Limitations (M1 - Basic mDNS Querier)
 //
-//   - IPv4 only (no IPv6/AAAA records)
+//   - IPv4 multicast only (AAAA records are queried and decoded over IPv4)
 //   - Query-only (no mDNS responder functionality)
 //   - No Known Answer suppression (RFC 6762 §7.1)
 //   - No continuous monitoring (one-shot queries only)
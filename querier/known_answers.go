@@ -0,0 +1,285 @@
+package querier
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// knownAnswerKey identifies the question a remembered record answers.
+type knownAnswerKey struct {
+	name       string // Lowercased (RFC 1035 §2.3.3 case-insensitive)
+	recordType RecordType
+}
+
+// knownAnswer is a record received in an earlier response, kept so that a
+// repeated query can list it for known-answer suppression.
+type knownAnswer struct {
+	record   ResourceRecord
+	rdata    []byte    // Uncompressed wire-format RDATA
+	received time.Time // When the record arrived
+}
+
+// remainingTTL returns the record's TTL in seconds, less the time elapsed
+// since it was received (never negative).
+func (ka knownAnswer) remainingTTL(now time.Time) uint32 {
+	elapsed := uint32(now.Sub(ka.received) / time.Second) //nolint:gosec // G115: only compared against the uint32 TTL
+	if elapsed >= ka.record.TTL {
+		return 0
+	}
+	return ka.record.TTL - elapsed
+}
+
+// knownAnswerCache remembers answers from previous queries for RFC 6762 §7.1
+// known-answer suppression (enabled via WithKnownAnswers).
+//
+// A record is only listed while more than half its TTL remains: "a Multicast
+// DNS querier SHOULD NOT include records in the Known-Answer list whose
+// remaining TTL is less than half of their original TTL", since a responder
+// would answer such records anyway to refresh the cache.
+type knownAnswerCache struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	entries map[knownAnswerKey][]knownAnswer
+}
+
+// newKnownAnswerCache creates an empty cache using the wall clock.
+func newKnownAnswerCache() *knownAnswerCache {
+	return &knownAnswerCache{
+		now:     time.Now,
+		entries: make(map[knownAnswerKey][]knownAnswer),
+	}
+}
+
+// remember stores the records of a response. A record with TTL 0 (goodbye,
+// RFC 6762 §10.1) removes any remembered copy instead.
+func (c *knownAnswerCache) remember(records []ResourceRecord) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for _, rr := range records {
+		rdata, ok := encodeRDATA(rr)
+		if !ok {
+			continue // Cannot be re-encoded; simply not listed as known
+		}
+
+		key := knownAnswerKey{name: strings.ToLower(rr.Name), recordType: rr.Type}
+		kept := c.entries[key][:0]
+		for _, existing := range c.entries[key] {
+			if !bytes.Equal(existing.rdata, rdata) {
+				kept = append(kept, existing)
+			}
+		}
+		if rr.TTL > 0 {
+			kept = append(kept, knownAnswer{
+				record:   rr,
+				rdata:    rdata,
+				received: now,
+			})
+		}
+
+		if len(kept) == 0 {
+			delete(c.entries, key)
+		} else {
+			c.entries[key] = kept
+		}
+	}
+}
+
+// lookup returns the remembered answers for name and recordType that still
+// have more than half their TTL remaining, dropping the rest. Each returned
+// record's TTL is set to its remaining TTL, as a known-answer list requires.
+func (c *knownAnswerCache) lookup(name string, recordType RecordType) []knownAnswer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := knownAnswerKey{name: strings.ToLower(name), recordType: recordType}
+	now := c.now()
+	var result []knownAnswer
+	fresh := c.entries[key][:0]
+	for _, ka := range c.entries[key] {
+		remaining := ka.remainingTTL(now)
+		if remaining*2 <= ka.record.TTL {
+			continue // Half or less of the TTL left (RFC 6762 §7.1)
+		}
+		fresh = append(fresh, ka)
+
+		listed := ka
+		listed.record.TTL = remaining
+		result = append(result, listed)
+	}
+	if len(fresh) == 0 {
+		delete(c.entries, key)
+	} else {
+		c.entries[key] = fresh
+	}
+	return result
+}
+
+// maxQueryPacketSize bounds a query carrying known answers (RFC 6762 §17).
+const maxQueryPacketSize = 9000
+
+// buildQueryWithKnownAnswers builds a query for name/recordType whose Answer
+// section lists known, per RFC 6762 §7.1, as one or more packets of at most
+// maxQueryPacketSize bytes.
+//
+// RFC 6762 §7.2:
+//
+//	In this case, it should issue a Multicast DNS query containing a
+//	question and as many Known-Answer records as will fit. It MUST then set
+//	the TC (Truncated) bit in the header before sending the query. It MUST
+//	immediately follow the packet with another query packet containing no
+//	questions and as many more Known-Answer records as will fit.
+//
+// Every packet but the last has TC set. A known answer too large for any
+// packet on its own is left out.
+//
+// Returns:
+//   - [][]byte: The packets, to be sent in order without delay
+//   - error: If a packet cannot be serialized
+func buildQueryWithKnownAnswers(name string, recordType RecordType, known []knownAnswer) ([][]byte, error) {
+	answers := make([]message.Answer, 0, len(known))
+	for _, ka := range known {
+		answers = append(answers, message.Answer{
+			NAME:     ka.record.Name,
+			TYPE:     uint16(ka.record.Type),
+			CLASS:    uint16(protocol.ClassIN),
+			TTL:      ka.record.TTL,
+			RDLENGTH: uint16(len(ka.rdata)), //nolint:gosec // G115: RDATA re-encoded from a received record, bounded by packet size
+			RDATA:    ka.rdata,
+		})
+	}
+
+	questions := []message.Question{
+		{QNAME: name, QTYPE: uint16(recordType), QCLASS: uint16(protocol.ClassIN)},
+	}
+	var msgs []*message.DNSMessage
+	for len(msgs) == 0 || len(answers) > 0 {
+		msg, err := fitKnownAnswers(questions, answers)
+		if err != nil {
+			return nil, err
+		}
+		if len(msg.Questions) == 0 && len(msg.Answers) == 0 {
+			answers = answers[1:] // Too large even alone; leave it out
+			continue
+		}
+		answers = answers[len(msg.Answers):]
+		msgs = append(msgs, msg)
+		questions = nil // Continuation packets carry known answers only
+	}
+
+	packets := make([][]byte, 0, len(msgs))
+	for i, msg := range msgs {
+		if i < len(msgs)-1 {
+			msg.Header.Flags |= protocol.FlagTC // More known answers follow
+		}
+		packet, err := message.SerializeMessage(msg)
+		if err != nil {
+			return nil, err
+		}
+		packets = append(packets, packet)
+	}
+	return packets, nil
+}
+
+// fitKnownAnswers returns a query message with questions and the longest
+// prefix of answers that serializes to at most maxQueryPacketSize bytes.
+func fitKnownAnswers(questions []message.Question, answers []message.Answer) (*message.DNSMessage, error) {
+	size := func(n int) (int, error) {
+		packet, err := message.SerializeMessage(newKnownAnswerMessage(questions, answers[:n]))
+		return len(packet), err
+	}
+
+	// Common case: everything fits
+	if total, err := size(len(answers)); err != nil || total <= maxQueryPacketSize {
+		return newKnownAnswerMessage(questions, answers), err
+	}
+
+	// Packet size grows with each answer: binary search the largest count
+	fits, tooMany := 0, len(answers)
+	for tooMany-fits > 1 {
+		mid := (fits + tooMany) / 2
+		total, err := size(mid)
+		if err != nil {
+			return nil, err
+		}
+		if total <= maxQueryPacketSize {
+			fits = mid
+		} else {
+			tooMany = mid
+		}
+	}
+	return newKnownAnswerMessage(questions, answers[:fits]), nil
+}
+
+// newKnownAnswerMessage returns a query message with questions and answers.
+func newKnownAnswerMessage(questions []message.Question, answers []message.Answer) *message.DNSMessage {
+	return &message.DNSMessage{
+		Header: message.DNSHeader{
+			QDCount: uint16(len(questions)), //nolint:gosec // G115: at most one question
+			ANCount: uint16(len(answers)),   //nolint:gosec // G115: bounded by maxQueryPacketSize
+		},
+		Questions: questions,
+		Answers:   answers,
+	}
+}
+
+// encodeRDATA re-encodes a record's parsed data as uncompressed wire-format
+// RDATA. Received RDATA cannot be reused directly because its names may be
+// compression pointers into the original message (RFC 1035 §4.1.4).
+func encodeRDATA(rr ResourceRecord) ([]byte, bool) {
+	switch data := rr.Data.(type) {
+	case net.IP:
+		if ip4 := data.To4(); rr.Type == RecordTypeA && ip4 != nil {
+			return []byte(ip4), true
+		}
+		if ip6 := data.To16(); rr.Type == RecordTypeAAAA && ip6 != nil {
+			return []byte(ip6), true
+		}
+	case string:
+		if rr.Type == RecordTypePTR {
+			target, err := encodeTargetName(data)
+			return target, err == nil
+		}
+	case SRVData:
+		target, err := encodeTargetName(data.Target)
+		if err != nil {
+			return nil, false
+		}
+		rdata := make([]byte, 6, 6+len(target))
+		binary.BigEndian.PutUint16(rdata[0:2], data.Priority)
+		binary.BigEndian.PutUint16(rdata[2:4], data.Weight)
+		binary.BigEndian.PutUint16(rdata[4:6], data.Port)
+		return append(rdata, target...), true
+	case []string:
+		var rdata []byte
+		for _, s := range data {
+			if len(s) > 255 {
+				return nil, false
+			}
+			rdata = append(rdata, byte(len(s)))
+			rdata = append(rdata, s...)
+		}
+		if len(rdata) == 0 {
+			rdata = []byte{0x00} // RFC 6763 §6: empty TXT is a single zero byte
+		}
+		return rdata, true
+	}
+	return nil, false
+}
+
+// encodeTargetName encodes a PTR/SRV target, allowing the free-form instance
+// label of a service instance name (RFC 6763 §4.3).
+func encodeTargetName(name string) ([]byte, error) {
+	if parts := strings.SplitN(name, "._", 2); len(parts) == 2 {
+		return message.EncodeServiceInstanceName(parts[0], "_"+parts[1])
+	}
+	return message.EncodeName(name)
+}
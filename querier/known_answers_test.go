@@ -0,0 +1,103 @@
+package querier
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/message"
+)
+
+// TestKnownAnswerCache_HalfTTLRule verifies records are listed with their
+// remaining TTL only while more than half the original TTL remains, and that
+// a goodbye (TTL 0) removes a remembered record (RFC 6762 §7.1, §10.1).
+func TestKnownAnswerCache_HalfTTLRule(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newKnownAnswerCache()
+	c.now = func() time.Time { return now }
+
+	rr := ResourceRecord{Name: "Printer.local", Type: RecordTypeA, Class: 1, TTL: 120, Data: net.IPv4(192, 168, 1, 100)}
+	c.remember([]ResourceRecord{rr})
+
+	now = now.Add(30 * time.Second)
+	known := c.lookup("printer.local", RecordTypeA)
+	if len(known) != 1 || known[0].record.TTL != 90 {
+		t.Fatalf("lookup() at 30s = %+v, want one record with remaining TTL 90", known)
+	}
+
+	now = now.Add(30 * time.Second) // 60s: exactly half remaining
+	if known := c.lookup("printer.local", RecordTypeA); len(known) != 0 {
+		t.Errorf("lookup() at 60s = %+v, want none (half TTL elapsed)", known)
+	}
+
+	c.remember([]ResourceRecord{rr})
+	goodbye := rr
+	goodbye.TTL = 0
+	c.remember([]ResourceRecord{goodbye})
+	if known := c.lookup("printer.local", RecordTypeA); len(known) != 0 {
+		t.Errorf("lookup() after goodbye = %+v, want none", known)
+	}
+}
+
+// TestBuildQueryWithKnownAnswers_Continuation verifies a known-answer list too
+// large for one packet is split across packets of at most 9000 bytes: the
+// question and TC in the first, known answers only in the rest, and TC on
+// every packet but the last (RFC 6762 §7.2).
+func TestBuildQueryWithKnownAnswers_Continuation(t *testing.T) {
+	var known []knownAnswer
+	for i := range 500 {
+		rr := ResourceRecord{
+			Name: fmt.Sprintf("Instance %03d._http._tcp.local", i),
+			Type: RecordTypeTXT, Class: 1, TTL: 4500,
+			Data: []string{strings.Repeat("x", 100)},
+		}
+		rdata, ok := encodeRDATA(rr)
+		if !ok {
+			t.Fatalf("encodeRDATA(%s) failed", rr.Name)
+		}
+		known = append(known, knownAnswer{record: rr, rdata: rdata})
+	}
+
+	packets, err := buildQueryWithKnownAnswers("_http._tcp.local", RecordTypePTR, known)
+	if err != nil {
+		t.Fatalf("buildQueryWithKnownAnswers() error = %v", err)
+	}
+	if len(packets) < 2 {
+		t.Fatalf("got %d packets, want the known answers split across several", len(packets))
+	}
+
+	var names []string
+	for i, packet := range packets {
+		if len(packet) > maxQueryPacketSize {
+			t.Errorf("packet %d is %d bytes, want at most %d", i, len(packet), maxQueryPacketSize)
+		}
+		msg, err := message.ParseMessage(packet)
+		if err != nil {
+			t.Fatalf("ParseMessage(packet %d) error = %v", i, err)
+		}
+		wantQuestions := 0
+		if i == 0 {
+			wantQuestions = 1
+		}
+		if len(msg.Questions) != wantQuestions {
+			t.Errorf("packet %d has %d questions, want %d", i, len(msg.Questions), wantQuestions)
+		}
+		if last := i == len(packets)-1; msg.Header.IsTruncated() == last {
+			t.Errorf("packet %d TC = %v, want %v", i, msg.Header.IsTruncated(), !last)
+		}
+		for _, answer := range msg.Answers {
+			names = append(names, answer.NAME)
+		}
+	}
+
+	if len(names) != len(known) {
+		t.Fatalf("packets list %d known answers, want %d", len(names), len(known))
+	}
+	for i, name := range names {
+		if name != known[i].record.Name {
+			t.Fatalf("known answer %d = %q, want %q (in order)", i, name, known[i].record.Name)
+		}
+	}
+}
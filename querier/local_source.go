@@ -0,0 +1,148 @@
+package querier
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// localNetworksRefresh is how long the local interface networks are cached
+// before being re-read, so addresses gained or lost (DHCP renewals, links
+// coming up) are picked up without a syscall per packet.
+const localNetworksRefresh = 30 * time.Second
+
+// localSourceFilter decides whether a response came from the local link
+// (WithRequireLocalSource), or from this host itself (WithSameHostFilter).
+//
+// RFC 6762 §11: Multicast DNS traffic is link-local, and a packet whose
+// source address is not on one of the host's links (for IPv4, a local subnet)
+// may be spoofed from off-link, e.g. where multicast is bridged or forwarded
+// between networks. A source is local if it is a link-local address
+// (169.254.0.0/16, RFC 3927; fe80::/10) or lies within a network configured
+// on the receiving interface (or, when the interface is unknown, on any
+// interface).
+type localSourceFilter struct {
+	mu sync.Mutex
+
+	// networks reads the networks configured on each interface, keyed by
+	// interface index (nil = interfaceNetworks; replaced in tests)
+	networks func() (map[int][]*net.IPNet, error)
+
+	// now reads the current time (nil = time.Now; the querier's clock, see
+	// WithClock)
+	now func() time.Time
+
+	cached    map[int][]*net.IPNet
+	refreshed time.Time
+}
+
+// isLocal reports whether src, received on interface ifIndex (0 = unknown),
+// is on a local link.
+func (f *localSourceFilter) isLocal(src net.IP, ifIndex int) bool {
+	if src.IsLinkLocalUnicast() {
+		return true
+	}
+
+	nets := f.snapshot()
+	if ifIndex != 0 {
+		if ifaceNets, ok := nets[ifIndex]; ok {
+			return containsIP(ifaceNets, src)
+		}
+	}
+	for _, ifaceNets := range nets {
+		if containsIP(ifaceNets, src) {
+			return true
+		}
+	}
+	return false
+}
+
+// isOwnAddress reports whether src is one of this host's interface addresses
+// (WithSameHostFilter).
+func (f *localSourceFilter) isOwnAddress(src net.IP) bool {
+	for _, ifaceNets := range f.snapshot() {
+		for _, n := range ifaceNets {
+			if n.IP.Equal(src) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// snapshot returns the cached interface networks, re-reading them once
+// localNetworksRefresh has passed. A failed read keeps the previous networks.
+func (f *localSourceFilter) snapshot() map[int][]*net.IPNet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now
+	if now == nil {
+		now = time.Now
+	}
+	if f.cached != nil && now().Sub(f.refreshed) < localNetworksRefresh {
+		return f.cached
+	}
+
+	read := f.networks
+	if read == nil {
+		read = interfaceNetworks
+	}
+	if nets, err := read(); err == nil {
+		f.cached = nets
+	}
+	f.refreshed = now()
+	return f.cached
+}
+
+// interfaceNetworks returns the networks configured on each up interface,
+// keyed by interface index.
+func interfaceNetworks() (map[int][]*net.IPNet, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	nets := make(map[int][]*net.IPNet, len(ifaces))
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok {
+				nets[iface.Index] = append(nets[iface.Index], ipnet)
+			}
+		}
+	}
+	return nets, nil
+}
+
+// containsIP reports whether any of nets contains ip.
+func containsIP(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// loopbackInterfaceIndexes returns the indexes of the host's loopback
+// interfaces (empty if they cannot be listed).
+func loopbackInterfaceIndexes() map[int]bool {
+	indexes := make(map[int]bool)
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return indexes
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			indexes[iface.Index] = true
+		}
+	}
+	return indexes
+}
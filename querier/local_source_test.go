@@ -0,0 +1,149 @@
+package querier
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+// fakeNetworks returns a networks func reporting 192.168.1.0/24 on interface
+// 2 and 10.0.0.0/8 on interface 3.
+func fakeNetworks() (map[int][]*net.IPNet, error) {
+	_, lan, _ := net.ParseCIDR("192.168.1.0/24")
+	_, vpn, _ := net.ParseCIDR("10.0.0.0/8")
+	return map[int][]*net.IPNet{2: {lan}, 3: {vpn}}, nil
+}
+
+// TestLocalSourceFilter_IsLocal verifies sources are matched against the
+// receiving interface's networks, or every interface's when it is unknown,
+// and that link-local sources are always local (RFC 6762 §11, RFC 3927).
+func TestLocalSourceFilter_IsLocal(t *testing.T) {
+	f := &localSourceFilter{networks: fakeNetworks}
+
+	tests := []struct {
+		src     string
+		ifIndex int
+		want    bool
+	}{
+		{"192.168.1.20", 2, true},
+		{"192.168.1.20", 0, true},  // Unknown interface: any local network
+		{"192.168.1.20", 3, false}, // Local, but not on the receiving link
+		{"10.1.2.3", 3, true},
+		{"192.168.2.20", 0, false}, // Private, but no local network holds it
+		{"169.254.7.7", 3, true},   // IPv4 link-local
+		{"fe80::1", 2, true},       // IPv6 link-local
+	}
+	for _, tt := range tests {
+		if got := f.isLocal(net.ParseIP(tt.src), tt.ifIndex); got != tt.want {
+			t.Errorf("isLocal(%s, if %d) = %v, want %v", tt.src, tt.ifIndex, got, tt.want)
+		}
+	}
+}
+
+// TestLocalSourceFilter_RefreshesOnClock verifies the interface networks are
+// re-read once localNetworksRefresh has passed on the filter's clock.
+func TestLocalSourceFilter_RefreshesOnClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	reads := 0
+	f := &localSourceFilter{
+		networks: func() (map[int][]*net.IPNet, error) {
+			reads++
+			return fakeNetworks()
+		},
+		now: fake.Now,
+	}
+
+	f.snapshot()
+	fake.Advance(localNetworksRefresh - time.Second)
+	f.snapshot()
+	if reads != 1 {
+		t.Fatalf("networks read %d times before the refresh interval, want 1", reads)
+	}
+	fake.Advance(time.Second)
+	f.snapshot()
+	if reads != 2 {
+		t.Errorf("networks read %d times after the refresh interval, want 2", reads)
+	}
+}
+
+// TestQuery_DropsNonLocalSource verifies WithRequireLocalSource(true) drops a
+// response from a source outside the host's networks and keeps one from a
+// local network, and that both are accepted by default.
+func TestQuery_DropsNonLocalSource(t *testing.T) {
+	local := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 5353}
+	offLink := &net.UDPAddr{IP: net.IPv4(192, 168, 99, 20), Port: 5353}
+
+	query := func(t *testing.T, opts ...Option) []ResourceRecord {
+		t.Helper()
+		mock := transport.NewMockTransport()
+		mock.EnableBlockingReceive()
+		q, err := New(append([]Option{WithTransport(mock), WithRateLimit(false)}, opts...)...)
+		if err != nil {
+			t.Fatalf("New() failed: %v", err)
+		}
+		defer func() { _ = q.Close() }()
+		if q.localSource != nil {
+			q.localSource.networks = fakeNetworks
+		}
+
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 99, 20}), offLink, 0)
+			mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 20}), local, 0)
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+		defer cancel()
+		resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		return resp.Records
+	}
+
+	records := query(t, WithRequireLocalSource(true))
+	if len(records) != 1 || !records[0].AsA().Equal(local.IP) {
+		t.Errorf("WithRequireLocalSource(true) Query() records = %+v, want only the one from the local source %v", records, local.IP)
+	}
+
+	if records := query(t); len(records) != 2 {
+		t.Errorf("default Query() records = %+v, want both", records)
+	}
+}
+
+// TestQuery_SameHostFilter verifies WithSameHostFilter drops a response sent
+// from one of this host's addresses and keeps one from another host.
+func TestQuery_SameHostFilter(t *testing.T) {
+	self := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 5353}
+	peer := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 5353}
+
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithSameHostFilter(true))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+	q.sameHost.networks = func() (map[int][]*net.IPNet, error) {
+		return map[int][]*net.IPNet{2: {{IP: self.IP, Mask: net.CIDRMask(24, 32)}}}, nil
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 10}), self, 2)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 20}), peer, 2)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(resp.Records) != 1 || !resp.Records[0].AsA().Equal(peer.IP) {
+		t.Errorf("Query() records = %+v, want only the one from the other host %v", resp.Records, peer.IP)
+	}
+}
@@ -1,10 +1,13 @@
 package querier
 
 import (
+	"fmt"
 	"net"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/transport"
 )
 
 // Option is a functional option for configuring a Querier.
@@ -176,27 +179,450 @@ func WithRateLimitCooldown(cooldown time.Duration) Option {
 	}
 }
 
-// TODO M2 (T100): Add WithTransport() option for test isolation
-// This would allow injecting MockTransport for unit testing without real network.
-// Current gap: All querier tests use real UDP sockets, making edge cases harder to test.
+// WithTransport sets a custom transport for the Querier (primarily for testing).
 //
-// Proposed implementation:
-//   func WithTransport(t transport.Transport) Option {
-//       return func(q *Querier) error {
-//           q.transport = t
-//           return nil
-//       }
-//   }
+// If not provided, a production UDPv4Transport bound to the mDNS multicast
+// group is created. Supplying a transport lets tests inject MockTransport to
+// simulate exact mDNS responses and network failures without real sockets.
 //
-// Usage in tests:
-//   mock := transport.NewMockTransport()
-//   q, _ := New(WithTransport(mock))
+// T100: WithTransport() option for test isolation
 //
-// This enables:
-// - Testing without real network
-// - Mocking specific network failures
-// - Simulating exact mDNS responses
-// - Faster test execution
+// Example:
+//
+//	mock := transport.NewMockTransport()
+//	q, err := querier.New(querier.WithTransport(mock))
+func WithTransport(t transport.Transport) Option {
+	return func(q *Querier) error {
+		if t == nil {
+			return &errors.ValidationError{
+				Field:   "transport",
+				Value:   nil,
+				Message: "transport cannot be nil",
+			}
+		}
+
+		q.transport = t
+		return nil
+	}
+}
+
+// Direction identifies whether a packet passed to a PacketHook was sent or received.
+type Direction = transport.Direction
+
+const (
+	// Sent marks a packet the Querier transmitted (queries).
+	Sent = transport.Sent
+
+	// Received marks a packet the Querier read from the network (responses).
+	Received = transport.Received
+)
+
+// PacketHook observes a raw mDNS packet; see WithPacketHook.
+type PacketHook = transport.PacketHook
+
+// WithPacketHook installs a hook invoked for every packet the Querier's
+// transport sends and receives.
+//
+// Debugging mDNS interop issues almost always requires the raw bytes on the
+// wire; the hook is the tap point for dumping pcaps or pretty-printing traffic.
+// It runs synchronously on the send/receive path, so it should be fast, and it
+// must not modify or retain the packet slice. Received packets are reported
+// before source filtering, rate limiting and parsing.
+//
+// When no hook is set the transport is used directly, with zero overhead.
+//
+// Example:
+//
+//	q, err := querier.New(querier.WithPacketHook(
+//	    func(dir querier.Direction, packet []byte, addr net.Addr, ifIndex int) {
+//	        fmt.Printf("%s %d bytes %v (if=%d)\n", dir, len(packet), addr, ifIndex)
+//	    }))
+func WithPacketHook(hook PacketHook) Option {
+	return func(q *Querier) error {
+		q.packetHook = hook
+		return nil
+	}
+}
+
+// WithReadBufferSize sets the socket receive buffer (SO_RCVBUF) size in bytes.
+//
+// The default is 64KB. Busy networks with many responders may need a larger
+// buffer to avoid dropped packets; constrained embedded devices may want a
+// smaller one. The minimum is 9000 bytes, one maximum-size mDNS packet
+// (RFC 6762 §17). The OS may round or cap the value. Ignored when a transport
+// is supplied via WithTransport.
+//
+// Example:
+//
+//	q, err := querier.New(querier.WithReadBufferSize(256 * 1024))
+func WithReadBufferSize(bytes int) Option {
+	return func(q *Querier) error {
+		if bytes < transport.MinReadBufferSize {
+			return &errors.ValidationError{
+				Field:   "readBufferSize",
+				Value:   bytes,
+				Message: fmt.Sprintf("read buffer size must be at least %d bytes", transport.MinReadBufferSize),
+			}
+		}
+
+		q.readBufferSize = bytes
+		return nil
+	}
+}
+
+// WithMulticastTTL sets the IP TTL of the querier's multicast packets.
+//
+// RFC 6762 §11: mDNS packets SHOULD be sent with IP TTL 255, which the
+// default provides; receivers may discard packets with a lower TTL as
+// possibly forwarded from off-link. Setting the TTL is best-effort: if the
+// platform refuses it, the OS default is used. Ignored when a transport is
+// supplied via WithTransport.
+//
+// Example:
+//
+//	q, err := querier.New(querier.WithMulticastTTL(255))
+func WithMulticastTTL(ttl int) Option {
+	return func(q *Querier) error {
+		if ttl < 1 || ttl > 255 {
+			return &errors.ValidationError{
+				Field:   "multicastTTL",
+				Value:   ttl,
+				Message: "multicast TTL must be between 1 and 255",
+			}
+		}
+
+		q.multicastTTL = ttl
+		return nil
+	}
+}
+
+// WithKnownAnswers enables known-answer suppression for repeated queries.
+//
+// RFC 6762 §7.1: A querier lists the answers it already holds in the Answer
+// section of its query, and responders skip re-sending those records. With
+// this option the Querier remembers records from each Query and lists them
+// when the same name and type is queried again, while more than half of their
+// TTL remains. This meaningfully reduces LAN traffic in browse/poll loops.
+//
+// Because responders suppress the listed records, Query merges them back into
+// its Response, so callers see the same result set as without the option.
+// FindFirst does not send known answers.
+//
+// Default: Disabled (false)
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithKnownAnswers(true))
+func WithKnownAnswers(enabled bool) Option {
+	return func(q *Querier) error {
+		if enabled {
+			q.knownAnswers = newKnownAnswerCache()
+		} else {
+			q.knownAnswers = nil
+		}
+		return nil
+	}
+}
+
+// WithLoopbackDiscovery lets the Querier discover services advertised by
+// responders on the same host over the loopback interface (see
+// responder.WithLoopbackAdvertise).
+//
+// The Querier joins the mDNS group on the loopback interface, loops its
+// multicast back to this host, and accepts every response arriving on
+// loopback: only this host sends there, from whichever of its addresses the
+// OS picks, which the off-link source checks would otherwise reject. Query
+// over loopback with QueryInterface:
+//
+//	q, _ := querier.New(querier.WithLoopbackDiscovery())
+//	lo, _ := net.InterfaceByName("lo")
+//	resp, err := q.QueryInterface(ctx, *lo, "devbox.local", querier.RecordTypeA)
+func WithLoopbackDiscovery() Option {
+	return func(q *Querier) error {
+		q.loopback = true
+		return nil
+	}
+}
+
+// WithDeduplicateReceives controls whether received packets identical to one
+// received from the same source within the last second are dropped.
+//
+// On a multi-homed host whose interfaces share a link, every multicast
+// response is delivered once per interface. Query already deduplicates
+// records, but with this option the copies are dropped before parsing, so
+// Response.Packets, QueryRaw and Browse see each packet once.
+//
+// Default: Disabled (false)
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithDeduplicateReceives(true))
+func WithDeduplicateReceives(enabled bool) Option {
+	return func(q *Querier) error {
+		q.dedupReceives = enabled
+		return nil
+	}
+}
+
+// WithRequireLocalSource controls whether responses must come from a source
+// on one of the host's links.
+//
+// RFC 6762 §11: Multicast DNS is link-local. When enabled, a response is
+// dropped unless its source address is link-local (169.254.0.0/16 or
+// fe80::/10) or lies within a network configured on the interface it arrived
+// on (any interface, if the receiving interface is unknown). This hardens
+// discovery against records injected from off-link, e.g. where multicast is
+// bridged or routed between networks. Interface networks are re-read every
+// 30 seconds, so address changes are picked up.
+//
+// Leave it disabled where responders are legitimately reached across a
+// router, e.g. behind an mDNS reflector on another subnet. Responses from
+// public (non-private, non-link-local) IPv4 addresses are dropped either way.
+//
+// Default: Disabled (false)
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithRequireLocalSource(true))
+func WithRequireLocalSource(enabled bool) Option {
+	return func(q *Querier) error {
+		if enabled {
+			q.localSource = &localSourceFilter{}
+		} else {
+			q.localSource = nil
+		}
+		return nil
+	}
+}
+
+// WithSameHostFilter drops responses whose source address is one of this
+// host's own interface addresses, so an application that both advertises
+// (with a responder) and browses does not discover itself.
+//
+// Responses come back to their sender when multicast loopback is on, as it is
+// by default on most platforms and always with WithLoopbackDiscovery; other
+// mDNS stacks on the same host (Avahi, Bonjour) are filtered too. Interface
+// addresses are re-read every 30 seconds, as for WithRequireLocalSource.
+//
+// Default: Disabled (false)
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithSameHostFilter(true))
+func WithSameHostFilter(enabled bool) Option {
+	return func(q *Querier) error {
+		if enabled {
+			q.sameHost = &localSourceFilter{}
+		} else {
+			q.sameHost = nil
+		}
+		return nil
+	}
+}
+
+// Clock tells the time and waits for it to pass; see WithClock.
+//
+// Implementations provide Now() time.Time and After(d) <-chan time.Time with
+// the semantics of time.Now and time.After.
+type Clock = clock.Clock
+
+// WithClock sets the clock on which cached records age, known answers
+// (WithKnownAnswers, RFC 6762 §7.1) and browsed instances' PTR TTLs
+// (RFC 6762 §10), and which timestamps RawResponse.ReceivedAt.
+//
+// The default is the wall clock. Tests substitute a manually advanced clock
+// to expire records without waiting out real TTLs. Query timeouts still use
+// the context and WithTimeout on the wall clock.
+//
+// Parameters:
+//   - c: Clock to use (non-nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithClock(c Clock) Option {
+	return func(q *Querier) error {
+		if c == nil {
+			return &errors.ValidationError{
+				Field:   "clock",
+				Value:   nil,
+				Message: "clock cannot be nil",
+			}
+		}
+
+		q.clock = c
+		return nil
+	}
+}
+
+// AddressPreference selects which address families DiscoverServices reports
+// in ServiceInstance.Addrs when a host has both; see WithAddressPreference.
+type AddressPreference int
+
+const (
+	// Both reports every resolved address, IPv4 before IPv6, and resolves an
+	// AAAA record missing from the browse response (the default).
+	Both AddressPreference = iota
+
+	// PreferIPv6 reports every resolved address, IPv6 before IPv4, and
+	// resolves an AAAA record missing from the browse response.
+	PreferIPv6
+
+	// PreferIPv4 reports every resolved address, IPv4 before IPv6, but does
+	// not query for an AAAA record missing from the browse response.
+	PreferIPv4
+)
+
+// String returns the preference's name.
+func (p AddressPreference) String() string {
+	switch p {
+	case Both:
+		return "Both"
+	case PreferIPv6:
+		return "PreferIPv6"
+	case PreferIPv4:
+		return "PreferIPv4"
+	default:
+		return fmt.Sprintf("AddressPreference(%d)", int(p))
+	}
+}
+
+// addresses returns the non-nil addresses among ipv4 and ipv6, ordered by
+// the preference.
+func (p AddressPreference) addresses(ipv4, ipv6 net.IP) []net.IP {
+	first, second := ipv4, ipv6
+	if p == PreferIPv6 {
+		first, second = ipv6, ipv4
+	}
+
+	var addrs []net.IP
+	if first != nil {
+		addrs = append(addrs, first)
+	}
+	if second != nil {
+		addrs = append(addrs, second)
+	}
+	return addrs
+}
+
+// WithAddressPreference sets which address family DiscoverServices reports
+// for a dual-stack host (one with both A and AAAA records, RFC 3596).
+//
+// Dual-stack clients commonly want PreferIPv6: try IPv6 first, falling back
+// to IPv4, which Addrs still lists second. With PreferIPv6, an instance whose
+// AAAA record was not bundled with the browse response (RFC 6763 §12) is
+// resolved with an AAAA query as well as an A query. ServiceInstance.AddrIPv4
+// and AddrIPv6 are filled in whatever the preference; it orders Addrs.
+//
+// Default: Both
+//
+// Parameters:
+//   - pref: Both, PreferIPv6 or PreferIPv4
+//
+// Returns:
+//   - Option: Configuration function
 //
-// Decision: Deferred to M2 (current integration coverage adequate for M1.1)
-// See: specs/004-m1-1-architectural-hardening/tasks.md Phase 8, T100
+// Example:
+//
+//	q, _ := querier.New(querier.WithAddressPreference(querier.PreferIPv6))
+func WithAddressPreference(pref AddressPreference) Option {
+	return func(q *Querier) error {
+		if pref < Both || pref > PreferIPv4 {
+			return &errors.ValidationError{
+				Field:   "addressPreference",
+				Value:   pref,
+				Message: "address preference must be Both, PreferIPv6 or PreferIPv4",
+			}
+		}
+
+		q.addressPreference = pref
+		return nil
+	}
+}
+
+// WithInitialQU sets the unicast-response (QU) bit on initial queries, asking
+// responders to reply directly to this host rather than to the multicast
+// group.
+//
+// RFC 6762 §5.4: A querier SHOULD set the QU bit in the first query it sends
+// for a question, e.g. at startup, when it has no cached answers; a unicast
+// reply arrives without waiting for the responder's multicast rate limit and
+// costs other hosts nothing. Query, QueryN, QueryInterface, QueryRaw and
+// DiscoverServices each send one initial query; Browse sets the bit on its
+// first query only, not on re-queries.
+//
+// Replies are accepted whether they come back unicast or multicast: a
+// responder may still multicast (e.g. if it has not multicast the record
+// recently, RFC 6762 §5.4), and responders that ignore QU always do. When
+// another mDNS stack shares port 5353 on this host, the operating system may
+// deliver unicast replies to it instead, so the multicast fallback matters.
+//
+// Default: Disabled (false), to avoid prompting unicast floods from large
+// networks
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithInitialQU(true))
+func WithInitialQU(enabled bool) Option {
+	return func(q *Querier) error {
+		q.initialQU = enabled
+		return nil
+	}
+}
+
+// WithInstanceFilter narrows service browsing to instances whose names pass
+// keep, e.g. every printer with "Brother" in its name.
+//
+// keep receives the instance label of each PTR answer ("My Printer" for
+// "My Printer._ipp._tcp.local") after parsing: PTR records that fail it are
+// dropped from Query, QueryN and QueryInterface responses to a PTR query, so
+// DiscoverServices resolves only matching instances, and Browse emits no
+// events for them. Queries for other record types, and the ServiceTypes
+// enumeration, are unaffected. Responders still answer for every instance;
+// filtering is local.
+//
+// Default: nil (all instances)
+//
+// Example:
+//
+//	q, _ := querier.New(querier.WithInstanceFilter(func(name string) bool {
+//	    return strings.Contains(name, "Brother")
+//	}))
+func WithInstanceFilter(keep func(instanceName string) bool) Option {
+	return func(q *Querier) error {
+		q.instanceFilter = keep
+		return nil
+	}
+}
+
+// defaultMaxRecords is the WithMaxRecords default: far more records than a
+// query on a real network collects, while bounding the memory a flood of
+// responses can make it hold.
+const defaultMaxRecords = 10000
+
+// WithMaxRecords caps the number of distinct records a query collects.
+//
+// Query aggregates every unique record received within its timeout. On an
+// untrusted network, a malicious or misconfigured host flooding responses
+// could make that grow without bound. Once n records are collected, the
+// query stops listening and returns them; further records are dropped. The
+// cap applies separately to Response.Records and Response.Additionals.
+//
+// Default: 10000
+//
+// Example:
+//
+//	q, err := querier.New(querier.WithMaxRecords(500))
+func WithMaxRecords(n int) Option {
+	return func(q *Querier) error {
+		if n < 1 {
+			return &errors.ValidationError{
+				Field:   "maxRecords",
+				Value:   n,
+				Message: "max records must be at least 1",
+			}
+		}
+
+		q.maxRecords = n
+		return nil
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"net"
 	"testing"
 	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
 )
 
 // TestWithInterfaces_ValidList tests WithInterfaces with a valid interface list.
@@ -273,3 +275,73 @@ func TestOptionsErrorHandling_FailFast(t *testing.T) {
 
 	t.Log("✓ Options with valid parameters succeed (FR-004 error handling verified)")
 }
+
+// TestWithReadBufferSize_CustomValue tests that a custom receive buffer is
+// accepted and the socket still binds.
+func TestWithReadBufferSize_CustomValue(t *testing.T) {
+	q, err := New(WithReadBufferSize(256 * 1024))
+	if err != nil {
+		t.Fatalf("New(WithReadBufferSize(256KB)) failed: %v", err)
+	}
+	defer q.Close()
+}
+
+// TestWithReadBufferSize_TooSmall tests that buffers smaller than one
+// maximum-size mDNS packet (RFC 6762 §17) are rejected.
+func TestWithReadBufferSize_TooSmall(t *testing.T) {
+	q, err := New(WithReadBufferSize(1024))
+	if err == nil {
+		defer q.Close()
+		t.Fatal("New(WithReadBufferSize(1024)) error = nil, want ValidationError")
+	}
+}
+
+// TestWithMulticastTTL tests that a TTL in 1-255 is accepted and the socket
+// still binds, and that values outside it are rejected.
+func TestWithMulticastTTL(t *testing.T) {
+	q, err := New(WithMulticastTTL(64))
+	if err != nil {
+		t.Fatalf("New(WithMulticastTTL(64)) failed: %v", err)
+	}
+	q.Close()
+
+	for _, ttl := range []int{0, 256} {
+		if q, err := New(WithMulticastTTL(ttl)); err == nil {
+			q.Close()
+			t.Errorf("New(WithMulticastTTL(%d)) error = nil, want ValidationError", ttl)
+		}
+	}
+}
+
+// TestWithClock_AgesKnownAnswers verifies known answers age on the clock from
+// WithClock, and that a nil clock is rejected.
+func TestWithClock_AgesKnownAnswers(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	q, err := New(WithClock(fake), WithKnownAnswers(true))
+	if err != nil {
+		t.Fatalf("New(WithClock) failed: %v", err)
+	}
+	defer q.Close()
+
+	rr := ResourceRecord{Name: "printer.local", Type: RecordTypeA, Class: 1, TTL: 120, Data: net.IPv4(192, 168, 1, 100)}
+	q.knownAnswers.remember([]ResourceRecord{rr})
+	fake.Advance(61 * time.Second) // Past half the TTL (RFC 6762 §7.1)
+	if known := q.knownAnswers.lookup("printer.local", RecordTypeA); len(known) != 0 {
+		t.Errorf("lookup() after advancing fake clock 61s = %+v, want none", known)
+	}
+
+	if q, err := New(WithClock(nil)); err == nil {
+		defer q.Close()
+		t.Error("New(WithClock(nil)) error = nil, want ValidationError")
+	}
+}
+
+// TestWithAddressPreference_Invalid verifies an unknown preference is rejected.
+func TestWithAddressPreference_Invalid(t *testing.T) {
+	q, err := New(WithAddressPreference(AddressPreference(7)))
+	if err == nil {
+		_ = q.Close()
+		t.Fatal("New(WithAddressPreference(7)) error = nil, want ValidationError")
+	}
+}
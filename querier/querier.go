@@ -1,14 +1,18 @@
 package querier
 
 import (
+	"bytes"
 	"context"
 	goerrors "errors"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/errors"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
@@ -74,12 +78,68 @@ type Querier struct {
 	cancel context.CancelFunc
 
 	// responseChan receives incoming mDNS responses from the receiver goroutine
-	responseChan chan []byte
+	responseChan chan inboundPacket
 
 	// interfaceFilter is a custom interface selection function (if set)
 	// Used only if explicitInterfaces is nil
 	interfaceFilter func(net.Interface) bool
 
+	// packetHook observes raw packets on the wire (set via WithPacketHook)
+	packetHook PacketHook
+
+	// readBufferSize is the socket receive buffer size (0 = 64KB default)
+	readBufferSize int
+
+	// multicastTTL is the outgoing multicast IP TTL (0 = 255, WithMulticastTTL)
+	multicastTTL int
+
+	// loopback enables same-host discovery over lo (WithLoopbackDiscovery)
+	loopback bool
+
+	// dedupReceives drops duplicate received packets (WithDeduplicateReceives)
+	dedupReceives bool
+
+	// loopbackIfaces holds the indexes of the loopback interfaces, whose
+	// packets all come from this host (set when loopback is enabled)
+	loopbackIfaces map[int]bool
+
+	// browsers receives a copy of every accepted packet for each active Browse
+	// and Watch
+	browsers   map[chan inboundPacket]struct{}
+	browsersMu sync.RWMutex
+
+	// clock ages known answers and browsed instances (set via WithClock;
+	// nil = wall clock)
+	clock clock.Clock
+
+	// addressPreference chooses the addresses DiscoverServices reports
+	// (set via WithAddressPreference)
+	addressPreference AddressPreference
+
+	// instanceFilter drops browsed instances whose names fail it (set via
+	// WithInstanceFilter; nil = keep all)
+	instanceFilter func(instanceName string) bool
+
+	// initialQU sets the unicast-response bit on initial queries (set via
+	// WithInitialQU, RFC 6762 §5.4)
+	initialQU bool
+
+	// maxRecords caps the distinct records one query collects (set via
+	// WithMaxRecords; 0 = defaultMaxRecords)
+	maxRecords int
+
+	// localSource drops responses from off-link sources (set via
+	// WithRequireLocalSource; nil = disabled)
+	localSource *localSourceFilter
+
+	// sameHost drops responses sent from this host's own addresses (set via
+	// WithSameHostFilter; nil = disabled)
+	sameHost *localSourceFilter
+
+	// knownAnswers remembers earlier answers for RFC 6762 §7.1 known-answer
+	// suppression (set via WithKnownAnswers; nil = disabled)
+	knownAnswers *knownAnswerCache
+
 	// rateLimiter is the rate limiter instance (created in New() if enabled)
 	rateLimiter *security.RateLimiter
 
@@ -90,6 +150,16 @@ type Querier struct {
 	// mu protects concurrent access to Query operations
 	mu sync.Mutex
 
+	// rounds holds the Query rounds in flight, shared by concurrent identical
+	// queries (see coalescedQuery); roundsMu protects it
+	rounds   map[roundKey]*queryRound
+	roundsMu sync.Mutex
+
+	// inflight tracks queries in progress, which Close waits to drain;
+	// inflightMu orders their registration against Close
+	inflight   sync.WaitGroup
+	inflightMu sync.Mutex
+
 	// rateLimitEnabled indicates whether rate limiting is enabled (default: true)
 	// Per FR-033: Configurable via WithRateLimit()
 	rateLimitEnabled bool
@@ -115,20 +185,13 @@ type Querier struct {
 //
 //	q, err := querier.New(querier.WithTimeout(2 * time.Second))
 func New(opts ...Option) (*Querier, error) {
-	// T032: Create UDP multicast transport (migrated from network.CreateSocket)
-	tr, err := transport.NewUDPv4Transport()
-	if err != nil {
-		return nil, err // Already wrapped as NetworkError
-	}
-
 	// Create lifecycle context
 	ctx, cancel := context.WithCancel(context.Background())
 
 	// Create querier with defaults
 	q := &Querier{
-		transport:          tr,
-		defaultTimeout:     1 * time.Second,        // SC-002: discover devices within 1 second
-		responseChan:       make(chan []byte, 100), // Buffer for incoming responses
+		defaultTimeout:     1 * time.Second,               // SC-002: discover devices within 1 second
+		responseChan:       make(chan inboundPacket, 100), // Buffer for incoming responses
 		ctx:                ctx,
 		cancel:             cancel,
 		rateLimitEnabled:   true,             // FR-033: Default enabled
@@ -139,12 +202,52 @@ func New(opts ...Option) (*Querier, error) {
 	// Apply options
 	for _, opt := range opts {
 		if err := opt(q); err != nil {
-			cancel()       // Clean up context before returning error
-			_ = tr.Close() // Ignore error, already returning primary error
+			cancel() // Clean up context before returning error
 			return nil, err
 		}
 	}
 
+	// Known answers age, and interface networks are re-read, on the
+	// configured clock (WithClock)
+	if q.knownAnswers != nil {
+		q.knownAnswers.now = clock.Or(q.clock).Now
+	}
+	for _, filter := range []*localSourceFilter{q.localSource, q.sameHost} {
+		if filter != nil {
+			filter.now = clock.Or(q.clock).Now
+		}
+	}
+
+	if q.loopback {
+		q.loopbackIfaces = loopbackInterfaceIndexes()
+	}
+
+	// T032: Create UDP multicast transport (migrated from network.CreateSocket)
+	// unless one was supplied via WithTransport.
+	if q.transport == nil {
+		tr, err := transport.NewUDPv4TransportWithOptions(transport.UDPv4Options{
+			ReadBufferSize: q.readBufferSize,
+			MulticastTTL:   q.multicastTTL,
+			Loopback:       q.loopback,
+		})
+		if err != nil {
+			cancel()
+			return nil, err // Already wrapped as NetworkError
+		}
+		q.transport = tr
+	}
+
+	// Wrap the final transport for packet tracing; skipped when unset (zero overhead)
+	if q.packetHook != nil {
+		q.transport = transport.NewHookTransport(q.transport, q.packetHook)
+	}
+
+	// Drop copies of a packet delivered on several interfaces; outside the
+	// hook so traces still show every packet on the wire
+	if q.dedupReceives {
+		q.transport = transport.NewDedupTransport(q.transport, transport.DefaultDedupWindow, q.clock)
+	}
+
 	// Initialize rate limiter if enabled (after options applied)
 	if q.rateLimitEnabled {
 		q.rateLimiter = security.NewRateLimiter(
@@ -170,6 +273,16 @@ func New(opts ...Option) (*Querier, error) {
 // Query validates inputs, builds the query message, sends it to the multicast group,
 // and aggregates responses per FR-001 through FR-012.
 //
+// If a responder's answer arrives truncated (TC bit set), Query re-sends the
+// question directly to that responder once to retrieve the rest. Per-packet
+// header flags are available in Response.Packets.
+//
+// Concurrent Query calls for the same name and type are coalesced: a call
+// made while an identical query is in flight sends nothing, and returns a copy
+// of the Response that query aggregates until the call's own deadline (the
+// shared query keeps collecting for the latest one). A coalesced call
+// cancelled before its deadline returns ctx.Err().
+//
 // FR-001: System MUST construct valid mDNS query messages per RFC 6762
 // FR-002: System MUST support querying for A, PTR, SRV, and TXT record types
 // FR-003: System MUST validate queried names follow DNS naming rules
@@ -203,17 +316,89 @@ func New(opts ...Option) (*Querier, error) {
 //	    fmt.Printf("Found: %s → %v\n", record.Name, record.Data)
 //	}
 func (q *Querier) Query(ctx context.Context, name string, recordType RecordType) (*Response, error) {
-	// Protect concurrent query operations
-	q.mu.Lock()
-	defer q.mu.Unlock()
-
-	// Check context cancellation upfront
 	select {
 	case <-ctx.Done():
 		return nil, ctx.Err()
 	default:
 	}
+	return q.coalescedQuery(ctx, name, recordType)
+}
 
+// QueryN sends an mDNS query and returns as soon as n distinct matching
+// records have been collected, or at timeout with whatever arrived.
+//
+// Use it when the number of expected answers is known (e.g. N devices of a
+// kind): instead of waiting out the full timeout after all have answered,
+// QueryN returns once the n-th distinct record arrives. Records are counted
+// after the same deduplication as Query (FR-007), so repeated answers from one
+// responder do not count twice. QueryN complements FindFirst for n > 1.
+//
+// Parameters:
+//   - ctx: Context for timeout/cancellation (the configured default timeout applies if it has no deadline)
+//   - name: DNS name to query (e.g., "_http._tcp.local")
+//   - recordType: Type of record to query
+//   - n: Number of distinct records after which to return (≥ 1)
+//
+// Returns:
+//   - *Response: Aggregated response; fewer than n records if the timeout expired first
+//   - error: ValidationError for invalid inputs (including n < 1), context.Canceled/context.DeadlineExceeded, or NetworkError
+//
+// Example:
+//
+//	// Two printers expected: stop listening once both have answered
+//	response, err := q.QueryN(ctx, "_ipp._tcp.local", querier.RecordTypePTR, 2)
+func (q *Querier) QueryN(ctx context.Context, name string, recordType RecordType, n int) (*Response, error) {
+	if n < 1 {
+		return nil, &errors.ValidationError{
+			Field:   "n",
+			Value:   n,
+			Message: "minimum number of records must be at least 1",
+		}
+	}
+	return q.query(ctx, name, recordType, n, 0)
+}
+
+// QueryInterface sends an mDNS query out one network interface and returns
+// only the replies received on that interface.
+//
+// Use it to debug multi-homed discovery: "does anything answer on VLAN2?".
+// The query leaves via iface, as if IP_MULTICAST_IF were set to it, and
+// packets the transport reports as received on another interface are
+// ignored. Known-answer suppression (WithKnownAnswers) is not applied, so
+// records learned on other links neither suppress nor pad the answer.
+//
+// Replies need a transport that reports receiving interfaces; on platforms
+// where the interface index is unknown (0), no reply matches.
+//
+// Parameters:
+//   - ctx: Context for timeout/cancellation (the configured default timeout applies if it has no deadline)
+//   - iface: Interface to send on and accept replies from
+//   - name: DNS name to query (e.g., "printer.local")
+//   - recordType: Type of record to query
+//
+// Returns:
+//   - *Response: Aggregated response from replies received on iface
+//   - error: ValidationError for invalid inputs (including an interface without an index), context.Canceled/context.DeadlineExceeded, or NetworkError
+//
+// Example:
+//
+//	vlan2, _ := net.InterfaceByName("eth0.2")
+//	response, err := q.QueryInterface(ctx, *vlan2, "_ipp._tcp.local", querier.RecordTypePTR)
+func (q *Querier) QueryInterface(ctx context.Context, iface net.Interface, name string, recordType RecordType) (*Response, error) {
+	if iface.Index <= 0 {
+		return nil, &errors.ValidationError{
+			Field:   "iface",
+			Value:   iface.Name,
+			Message: fmt.Sprintf("interface index must be positive, got %d", iface.Index),
+		}
+	}
+	return q.query(ctx, name, recordType, 0, iface.Index)
+}
+
+// query implements Query, QueryN and QueryInterface. minRecords > 0 ends
+// collection once that many distinct records have arrived; ifIndex > 0 sends
+// the query out that interface and keeps only replies received on it.
+func (q *Querier) query(ctx context.Context, name string, recordType RecordType, minRecords, ifIndex int) (*Response, error) {
 	// Honor the configured default timeout when the caller's context carries no
 	// deadline, so queries are always bounded. Without this, Query on a
 	// deadline-less context (e.g. context.Background()) blocks forever in
@@ -223,7 +408,99 @@ func (q *Querier) Query(ctx context.Context, name string, recordType RecordType)
 		ctx, cancel = context.WithTimeout(ctx, q.defaultTimeout)
 		defer cancel()
 	}
+	return q.runQuery(ctx, name, recordType, minRecords, ifIndex, nil)
+}
+
+// runQuery is query without the default timeout: it collects until ctx is
+// done. If progress is non-nil it is called, from the collecting goroutine,
+// with a copy of the response so far after each packet received, finished as
+// the final response would be (see finishResponse).
+func (q *Querier) runQuery(ctx context.Context, name string, recordType RecordType, minRecords, ifIndex int, progress func(*Response)) (*Response, error) {
+	// Protect concurrent query operations; Close cancels ctx
+	ctx, release, err := q.acquireQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Check context cancellation upfront
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	known, err := q.sendQuery(ctx, name, recordType, ifIndex)
+	if err != nil {
+		return nil, q.closedOr(err)
+	}
+
+	var observe func(*Response)
+	if progress != nil {
+		observe = func(live *Response) {
+			snapshot := live.clone()
+			q.finishResponse(snapshot, name, recordType, known, ifIndex)
+			progress(snapshot)
+		}
+		observe(&Response{Records: make([]ResourceRecord, 0)}) // Known answers, before any packet
+	}
+
+	// FR-008: Aggregate responses received within timeout window
+	response, err := q.collectResponsesN(ctx, name, recordType, minRecords, ifIndex, observe)
+	if q.ctx.Err() != nil {
+		return nil, ErrClosed
+	}
+	if err != nil {
+		return response, err
+	}
+
+	if q.knownAnswers != nil && ifIndex == 0 {
+		// Remember this round's answers for the next query (goodbyes forget
+		// theirs)
+		q.knownAnswers.remember(append(append([]ResourceRecord(nil), response.Records...), response.goodbyes...))
+	}
+	q.finishResponse(response, name, recordType, known, ifIndex)
+	return response, nil
+}
+
+// finishResponse adds back to response the known answers that responders
+// suppressed, then drops PTR records failing WithInstanceFilter.
+func (q *Querier) finishResponse(response *Response, name string, recordType RecordType, known []knownAnswer, ifIndex int) {
+	if q.knownAnswers != nil && ifIndex == 0 {
+		mergeKnownAnswers(response, known)
+	}
+	if recordType == RecordTypePTR && !strings.EqualFold(name, serviceTypeEnumerationName) {
+		response.Records = q.filterInstances(response.Records, name)
+	}
+}
+
+// filterInstances drops the PTR records in records whose instance names, under
+// serviceType, fail the WithInstanceFilter predicate. Other records are kept.
+func (q *Querier) filterInstances(records []ResourceRecord, serviceType string) []ResourceRecord {
+	if q.instanceFilter == nil {
+		return records
+	}
+	kept := records[:0]
+	for _, rr := range records {
+		if target := rr.AsPTR(); target != "" && !q.instanceFilter(instanceName(target, serviceType)) {
+			continue
+		}
+		kept = append(kept, rr)
+	}
+	return kept
+}
 
+// sendQuery validates name and recordType, then sends the query to the mDNS
+// multicast group, out interface ifIndex if non-zero.
+//
+// A query pinned to one interface lists no known answers: they may have been
+// learned on other links, and would suppress the very replies the caller
+// wants to see on this one.
+//
+// Returns:
+//   - []knownAnswer: Known answers listed in the query (nil unless WithKnownAnswers)
+//   - error: ValidationError for invalid inputs, NetworkError if sending fails
+func (q *Querier) sendQuery(ctx context.Context, name string, recordType RecordType, ifIndex int) ([]knownAnswer, error) {
 	// FR-003: Validate name
 	err := protocol.ValidateName(name)
 	if err != nil {
@@ -236,20 +513,275 @@ func (q *Querier) Query(ctx context.Context, name string, recordType RecordType)
 		return nil, err // Already wrapped as ValidationError
 	}
 
-	// FR-001: Build query message
-	queryMsg, err := message.BuildQuery(name, uint16(recordType))
+	// FR-001: Build query message, listing known answers (RFC 6762 §7.1) if
+	// enabled; a long list continues in further packets (RFC 6762 §7.2)
+	var known []knownAnswer
+	var packets [][]byte
+	if q.knownAnswers != nil && ifIndex == 0 {
+		known = q.knownAnswers.lookup(name, recordType)
+		packets, err = buildQueryWithKnownAnswers(name, recordType, known)
+	} else {
+		var queryMsg []byte
+		queryMsg, err = message.BuildQuery(name, uint16(recordType))
+		packets = [][]byte{queryMsg}
+	}
 	if err != nil {
 		return nil, err
 	}
+	if q.initialQU {
+		setQU(packets[0])
+	}
 
 	// FR-005: Send query to the mDNS multicast group (224.0.0.251:5353).
-	err = q.transport.Send(ctx, queryMsg, protocol.MulticastGroupIPv4())
+	for _, packet := range packets {
+		if ifIndex != 0 {
+			err = q.transport.SendOnInterface(ctx, packet, protocol.MulticastGroupIPv4(), ifIndex)
+		} else {
+			err = q.transport.Send(ctx, packet, protocol.MulticastGroupIPv4())
+		}
+		if err != nil {
+			return nil, err // Already wrapped as NetworkError
+		}
+	}
+	return known, nil
+}
+
+// setQU sets the unicast-response (QU) bit, the top bit of QCLASS, on the
+// question of a single-question query (RFC 6762 §5.4).
+func setQU(queryMsg []byte) {
+	if _, end, err := message.ParseQuestion(queryMsg, 12); err == nil {
+		queryMsg[end-2] |= 0x80
+	}
+}
+
+// QueryRaw sends an mDNS query and returns every response packet received
+// within the timeout exactly as it arrived.
+//
+// Unlike Query, packets are not parsed, validated or deduplicated, so
+// responses the normal parser rejects (and Query silently drops) can be
+// inspected when debugging interop with quirky devices. The receive-side
+// protections still apply: oversized packets, non-link-local sources and
+// rate-limited sources are dropped before reaching QueryRaw.
+//
+// Parameters:
+//   - ctx: Context for timeout/cancellation (the default timeout applies if it has no deadline)
+//   - name: DNS name to query (e.g., "printer.local")
+//   - recordType: Type of record to query
+//
+// Returns:
+//   - []RawResponse: Received packets in arrival order
+//   - error: ValidationError for invalid inputs, context.Canceled/context.DeadlineExceeded, or NetworkError
+//
+// Example:
+//
+//	raws, err := q.QueryRaw(ctx, "printer.local", querier.RecordTypeA)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, raw := range raws {
+//	    fmt.Printf("%s via if%d: % x\n", raw.Source, raw.InterfaceIndex, raw.Packet)
+//	}
+func (q *Querier) QueryRaw(ctx context.Context, name string, recordType RecordType) ([]RawResponse, error) {
+	ctx, release, err := q.acquireQuery(ctx)
 	if err != nil {
-		return nil, err // Already wrapped as NetworkError
+		return nil, err
 	}
+	defer release()
 
-	// FR-008: Aggregate responses received within timeout window
-	return q.collectResponses(ctx, name, recordType)
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && q.defaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.defaultTimeout)
+		defer cancel()
+	}
+
+	if _, err := q.sendQuery(ctx, name, recordType, 0); err != nil {
+		return nil, q.closedOr(err)
+	}
+
+	var raws []RawResponse
+	for {
+		select {
+		case <-ctx.Done():
+			if q.ctx.Err() != nil {
+				return nil, ErrClosed
+			}
+			// Timeout is NOT an error per FR-008 - return what we collected
+			return raws, nil
+		case packet := <-q.responseChan:
+			raws = append(raws, RawResponse{
+				ReceivedAt:     packet.received,
+				Source:         packet.src,
+				Packet:         packet.data,
+				InterfaceIndex: packet.ifIndex,
+			})
+		}
+	}
+}
+
+// mergeKnownAnswers appends each known answer that responders suppressed
+// (i.e. not received again) to response.Records. A known answer withdrawn by
+// a goodbye during the query is not added back (RFC 6762 §10.1).
+func mergeKnownAnswers(response *Response, known []knownAnswer) {
+	for _, ka := range known {
+		if containsRecord(response.Records, ka.record) || containsRecord(response.goodbyes, ka.record) {
+			continue
+		}
+		response.Records = append(response.Records, ka.record)
+	}
+}
+
+// sameRecord reports whether a and b are the same record: equal type and
+// RDATA, and names equal ignoring case (RFC 1035 §2.3.3). TTLs are ignored,
+// so a goodbye matches the record it withdraws.
+func sameRecord(a, b ResourceRecord) bool {
+	return a.Type == b.Type && strings.EqualFold(a.Name, b.Name) &&
+		fmt.Sprintf("%v", a.Data) == fmt.Sprintf("%v", b.Data) && bytes.Equal(a.RawData, b.RawData)
+}
+
+// containsRecord reports whether records holds a record the same as rr.
+func containsRecord(records []ResourceRecord, rr ResourceRecord) bool {
+	for _, existing := range records {
+		if sameRecord(existing, rr) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeRecord returns records without those the same as rr.
+func removeRecord(records []ResourceRecord, rr ResourceRecord) []ResourceRecord {
+	kept := records[:0]
+	for _, existing := range records {
+		if !sameRecord(existing, rr) {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
+// ErrNotFound is returned by FindFirst when no matching record arrives before
+// the context deadline or cancellation.
+var ErrNotFound = goerrors.New("no matching mDNS record found")
+
+// ErrClosed is returned by queries made on, or in flight during, Close.
+var ErrClosed = goerrors.New("querier closed")
+
+// ErrPortInUse is returned (wrapped) by New when mDNS port 5353 is held by
+// another socket that does not share it, e.g. avahi-daemon or mDNSResponder
+// bound without SO_REUSEPORT. Test with errors.Is.
+var ErrPortInUse = transport.ErrPortInUse
+
+// FindFirst sends an mDNS query and returns the first matching answer record as
+// soon as it arrives, instead of waiting out the full timeout like Query.
+//
+// This is the common case for "is device X online?" checks and for resolving a
+// known hostname: a responder typically answers within milliseconds, so there is
+// no reason to keep listening once a match is in hand.
+//
+// A record matches when its type equals recordType and its name equals name
+// (case-insensitive per RFC 1035 §2.3.3). Malformed and non-response packets are
+// discarded exactly as in Query (FR-011, FR-021, FR-022), and goodbyes (TTL=0,
+// RFC 6762 §10.1) never match.
+//
+// Parameters:
+//   - ctx: Context for timeout/cancellation (the configured default timeout applies if it has no deadline)
+//   - name: DNS name to query (e.g., "printer.local")
+//   - recordType: Type of record to query
+//
+// Returns:
+//   - *ResourceRecord: The first matching record
+//   - error: ValidationError for invalid inputs, ErrNotFound if nothing matched by
+//     the deadline, ctx.Err() if ctx was cancelled or already done, or a NetworkError
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+//	defer cancel()
+//
+//	rr, err := q.FindFirst(ctx, "printer.local", querier.RecordTypeA)
+//	if errors.Is(err, querier.ErrNotFound) {
+//	    fmt.Println("printer is offline")
+//	}
+func (q *Querier) FindFirst(ctx context.Context, name string, recordType RecordType) (*ResourceRecord, error) {
+	// Protect concurrent query operations (shares responseChan with Query)
+	ctx, release, err := q.acquireQuery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	// Bound deadline-less contexts by the default timeout, as in Query (issue #5).
+	if _, hasDeadline := ctx.Deadline(); !hasDeadline && q.defaultTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, q.defaultTimeout)
+		defer cancel()
+	}
+
+	if err := protocol.ValidateName(name); err != nil {
+		return nil, err
+	}
+	if err := protocol.ValidateRecordType(uint16(recordType)); err != nil {
+		return nil, err
+	}
+
+	queryMsg, err := message.BuildQuery(name, uint16(recordType))
+	if err != nil {
+		return nil, err
+	}
+	if q.initialQU {
+		setQU(queryMsg)
+	}
+	if err := q.transport.Send(ctx, queryMsg, protocol.MulticastGroupIPv4()); err != nil {
+		return nil, q.closedOr(err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if q.ctx.Err() != nil {
+				return nil, ErrClosed
+			}
+			if !goerrors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, ctx.Err() // Cancelled by the caller
+			}
+			return nil, fmt.Errorf("%w: %s %s", ErrNotFound, recordType, name)
+
+		case packet := <-q.responseChan:
+			responseMsg := packet.data
+			parsedMsg, ok := decodeResponse(responseMsg)
+			if !ok {
+				continue
+			}
+			zone := packetZone(packet.ifIndex)
+
+			for _, answer := range parsedMsg.Answers {
+				if RecordType(answer.TYPE) != recordType || !strings.EqualFold(answer.NAME, name) {
+					continue
+				}
+				if answer.TTL == 0 {
+					continue // Goodbye (RFC 6762 §10.1) - the record is going away
+				}
+				record, err := decodeRecord(responseMsg, answer, zone)
+				if err != nil {
+					continue // Malformed RDATA - keep waiting (FR-011)
+				}
+				record.Source = packet.src
+				return &record, nil
+			}
+		}
+	}
 }
 
 // DiscoverServices performs a full DNS-SD discovery for the given service type.
@@ -259,7 +791,8 @@ func (q *Querier) Query(ctx context.Context, name string, recordType RecordType)
 //  1. PTR query to find service instances (browse phase)
 //  2. SRV query per instance for hostname and port
 //  3. TXT query per instance for metadata
-//  4. A query per hostname for IPv4 address
+//  4. AAAA query per hostname for IPv6 address (unless PreferIPv4)
+//  5. A query per hostname for IPv4 address
 //
 // The context deadline is split across all phases. For best results, use a
 // timeout of at least 2-3 seconds to allow time for both browsing and resolution.
@@ -309,36 +842,15 @@ func (q *Querier) DiscoverServices(ctx context.Context, serviceType string) ([]S
 			continue
 		}
 
-		svc := ServiceInstance{ServiceType: serviceType}
-
-		// Extract instance name: "My Printer._http._tcp.local" → "My Printer"
-		if strings.HasSuffix(target, "."+serviceType) {
-			svc.InstanceName = strings.TrimSuffix(target, "."+serviceType)
-		} else {
-			svc.InstanceName = target
+		svc := ServiceInstance{
+			InstanceName: instanceName(target, serviceType),
+			ServiceType:  serviceType,
 		}
 
 		// RFC 6763 §12: prefer SRV/TXT/A bundled in the browse response's
 		// additional section; fall back to explicit queries only for what is
 		// missing (issue #4 — saves up to 3 round-trips per instance).
-		if rr := findInAdditionals(ptrResp.Additionals, target, RecordTypeSRV); rr != nil {
-			if srv := rr.AsSRV(); srv != nil {
-				svc.Hostname = srv.Target
-				svc.Port = srv.Port
-			}
-		}
-		if rr := findInAdditionals(ptrResp.Additionals, target, RecordTypeTXT); rr != nil {
-			if txt := rr.AsTXT(); txt != nil {
-				svc.TXT = ParseTXT(txt)
-			}
-		}
-		if svc.Hostname != "" {
-			if rr := findInAdditionals(ptrResp.Additionals, svc.Hostname, RecordTypeA); rr != nil {
-				if ip := rr.AsA(); ip != nil {
-					svc.AddrIPv4 = ip
-				}
-			}
-		}
+		resolveFromAdditionals(&svc, target, ptrResp.Additionals)
 
 		// Fallback: SRV query for hostname + port if not bundled as an additional.
 		if svc.Hostname == "" {
@@ -371,7 +883,23 @@ func (q *Querier) DiscoverServices(ctx context.Context, serviceType string) ([]S
 			}
 		}
 
-		// Fallback: A query for IPv4 if we have a hostname but no address yet.
+		// Fallback: AAAA query if none was bundled, unless IPv4 is preferred.
+		if svc.Hostname != "" && svc.AddrIPv6 == nil && q.addressPreference != PreferIPv4 {
+			aaaaCtx, aaaaCancel := context.WithTimeout(ctx, resolveTimeout)
+			aaaaResp, aaaaErr := q.Query(aaaaCtx, svc.Hostname, RecordTypeAAAA)
+			aaaaCancel()
+			if aaaaErr == nil {
+				for i := range aaaaResp.Records {
+					if ip := aaaaResp.Records[i].AsAAAA(); ip != nil {
+						svc.AddrIPv6 = ip
+						svc.ZoneIPv6 = aaaaResp.Records[i].Zone
+						break
+					}
+				}
+			}
+		}
+
+		// Fallback: A query for IPv4 if we have a hostname but no address yet
 		if svc.Hostname != "" && svc.AddrIPv4 == nil {
 			aCtx, aCancel := context.WithTimeout(ctx, resolveTimeout)
 			aResp, aErr := q.Query(aCtx, svc.Hostname, RecordTypeA)
@@ -386,12 +914,60 @@ func (q *Querier) DiscoverServices(ctx context.Context, serviceType string) ([]S
 			}
 		}
 
+		svc.Addrs = q.addressPreference.addresses(svc.AddrIPv4, svc.AddrIPv6)
 		services = append(services, svc)
 	}
 
 	return services, nil
 }
 
+// serviceTypeEnumerationName is the DNS-SD meta-query name (RFC 6763 §9).
+const serviceTypeEnumerationName = "_services._dns-sd._udp.local"
+
+// ServiceTypes lists the service types advertised on the network, by querying
+// the DNS-SD meta-service "_services._dns-sd._udp.local" (RFC 6763 §9).
+//
+// Each responder answers with one PTR record per service type it offers;
+// the types from every response are merged into a sorted set without
+// duplicates (compared case-insensitively, RFC 6763 §4.1.2). Pass a type to
+// DiscoverServices or Browse to find its instances.
+//
+// Parameters:
+//   - ctx: Context for timeout/cancellation (the configured default timeout applies if it has no deadline)
+//
+// Returns:
+//   - []string: Service types (e.g., "_http._tcp.local"); empty if none answered
+//   - error: As for Query
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+//	defer cancel()
+//
+//	types, err := q.ServiceTypes(ctx)
+//	for _, t := range types {
+//	    fmt.Println(t) // "_http._tcp.local", "_ipp._tcp.local", ...
+//	}
+func (q *Querier) ServiceTypes(ctx context.Context) ([]string, error) {
+	resp, err := q.Query(ctx, serviceTypeEnumerationName, RecordTypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	types := []string{}
+	for i := range resp.Records {
+		serviceType := resp.Records[i].AsPTR()
+		if serviceType == "" || seen[strings.ToLower(serviceType)] {
+			continue
+		}
+		seen[strings.ToLower(serviceType)] = true
+		types = append(types, serviceType)
+	}
+	sort.Strings(types)
+	return types, nil
+}
+
 // toRecordData normalizes parsed RDATA into the querier's public types.
 // message.ParseRDATA returns the internal message.SRVData for SRV records;
 // convert it to the public SRVData so ResourceRecord.AsSRV() works (the named
@@ -409,6 +985,34 @@ func toRecordData(data interface{}) interface{} {
 	return data
 }
 
+// resolveFromAdditionals fills svc's hostname, port, TXT and addresses from
+// SRV/TXT/A/AAAA records for target bundled in a browse response's additional
+// section (RFC 6763 §12). Fields without a bundled record are left unset.
+func resolveFromAdditionals(svc *ServiceInstance, target string, additionals []ResourceRecord) {
+	if rr := findInAdditionals(additionals, target, RecordTypeSRV); rr != nil {
+		if srv := rr.AsSRV(); srv != nil {
+			svc.Hostname = srv.Target
+			svc.Port = srv.Port
+		}
+	}
+	if rr := findInAdditionals(additionals, target, RecordTypeTXT); rr != nil {
+		if txt := rr.AsTXT(); txt != nil {
+			svc.TXT = ParseTXT(txt)
+		}
+	}
+	if svc.Hostname != "" {
+		if rr := findInAdditionals(additionals, svc.Hostname, RecordTypeA); rr != nil {
+			if ip := rr.AsA(); ip != nil {
+				svc.AddrIPv4 = ip
+			}
+		}
+		if rr := findInAdditionals(additionals, svc.Hostname, RecordTypeAAAA); rr != nil {
+			svc.AddrIPv6 = rr.AsAAAA()
+			svc.ZoneIPv6 = rr.Zone
+		}
+	}
+}
+
 // findInAdditionals returns the first additional-section record matching the
 // given name and type, or nil. Used to resolve a service instance from records
 // bundled in a browse response (RFC 6763 §12) before falling back to queries.
@@ -429,14 +1033,32 @@ func findInAdditionals(additionals []ResourceRecord, name string, t RecordType)
 // FR-010: Filter answer section records
 // FR-011: Validate and discard malformed packets
 // FR-016: Continue collecting after discarding malformed packets
-func (q *Querier) collectResponses(ctx context.Context, _ string, queryType RecordType) (*Response, error) {
+func (q *Querier) collectResponses(ctx context.Context, name string, queryType RecordType) (*Response, error) {
+	return q.collectResponsesN(ctx, name, queryType, 0, 0, nil)
+}
+
+// collectResponsesN is collectResponses that also returns early, after the
+// packet that brings the number of distinct records to minRecords (if > 0),
+// and, if ifIndex > 0, ignores packets received on other interfaces. It stops
+// collecting, and returns, once the WithMaxRecords cap is reached. If observe
+// is non-nil it is called with the response so far after each valid packet.
+func (q *Querier) collectResponsesN(ctx context.Context, name string, queryType RecordType, minRecords, ifIndex int, observe func(*Response)) (*Response, error) {
 	response := &Response{
 		Records: make([]ResourceRecord, 0),
 	}
 
+	// WithMaxRecords: bound what a flood of responses can make us hold
+	maxRecords := q.maxRecords
+	if maxRecords <= 0 {
+		maxRecords = defaultMaxRecords
+	}
+
 	// Deduplication map per FR-007
 	seen := make(map[string]bool)
 
+	// Sources already sent a follow-up for a truncated response (one each)
+	followedUp := make(map[string]bool)
+
 	// Collect responses until timeout or cancellation
 	for {
 		select {
@@ -444,22 +1066,31 @@ func (q *Querier) collectResponses(ctx context.Context, _ string, queryType Reco
 			// Timeout is NOT an error per FR-008 - return what we collected
 			return response, nil
 
-		case responseMsg := <-q.responseChan:
-			// FR-009: Parse response message
-			parsedMsg, err := message.ParseMessage(responseMsg)
-			if err != nil {
-				// FR-011, FR-016: Log and continue on malformed packets
-				// In M1, we silently continue (production might log)
-				continue
+		case packet := <-q.responseChan:
+			if ifIndex != 0 && packet.ifIndex != ifIndex {
+				continue // Arrived on another interface (QueryInterface)
 			}
+			responseMsg := packet.data
 
-			// FR-021, FR-022: Validate response flags
-			err = protocol.ValidateResponse(parsedMsg.Header.Flags)
-			if err != nil {
-				// Invalid response (QR=0 or RCODE≠0) - discard per FR-011
+			// FR-009, FR-011, FR-021, FR-022: Parse and validate; discard bad packets
+			parsedMsg, ok := decodeResponse(responseMsg)
+			if !ok {
+				// FR-016: Continue collecting after discarding malformed packets
 				continue
 			}
 
+			response.Packets = append(response.Packets, PacketInfo{
+				Source: packet.src,
+				Header: Header{ID: parsedMsg.Header.ID, Flags: parsedMsg.Header.Flags},
+			})
+			zone := packetZone(packet.ifIndex)
+
+			// A truncated response carries only part of the answer: ask that
+			// responder again directly for the rest.
+			if parsedMsg.Header.IsTruncated() {
+				q.sendTruncationFollowUp(ctx, name, queryType, packet.src, followedUp)
+			}
+
 			// FR-010: Process only Answer section (ignore Authority, Additional)
 			for _, answer := range parsedMsg.Answers {
 				// Filter by query type (optional - could also return all types)
@@ -471,28 +1102,35 @@ func (q *Querier) collectResponses(ctx context.Context, _ string, queryType Reco
 
 				// Parse type-specific RDATA against the full message so compressed
 				// PTR/SRV target names (used by Avahi/Bonjour) resolve.
-				data, err := message.ParseRDATAInMessage(answer.TYPE, responseMsg, answer.RDATAOffset, int(answer.RDLENGTH))
+				record, err := decodeRecord(responseMsg, answer, zone)
 				if err != nil {
 					// Malformed RDATA - skip this record per FR-011
 					continue
 				}
+				record.Source = packet.src
 
 				// FR-007: Deduplicate identical records
-				// Key: name + type + data representation
-				dedupeKey := fmt.Sprintf("%s|%d|%v", answer.NAME, answer.TYPE, data)
+				// Key: name + type + data representation + raw RDATA (the
+				// only data of record types beacon does not decode)
+				dedupeKey := fmt.Sprintf("%s|%d|%v|%x", record.Name, record.Type, record.Data, record.RawData)
+
+				// RFC 6762 §10.1: a TTL=0 record is a goodbye - withdraw the
+				// record it names rather than report it. A later re-announcement
+				// is collected again.
+				if record.TTL == 0 {
+					response.Records = removeRecord(response.Records, record)
+					response.goodbyes = append(response.goodbyes, record)
+					delete(seen, dedupeKey)
+					continue
+				}
+
 				if seen[dedupeKey] {
 					continue // Duplicate - skip
 				}
-				seen[dedupeKey] = true
-
-				// Convert to public ResourceRecord
-				record := ResourceRecord{
-					Name:  answer.NAME,
-					Type:  RecordType(answer.TYPE),
-					Class: answer.CLASS,
-					TTL:   answer.TTL,
-					Data:  toRecordData(data),
+				if len(response.Records) >= maxRecords {
+					break // WithMaxRecords cap reached; drop the rest
 				}
+				seen[dedupeKey] = true
 
 				response.Records = append(response.Records, record)
 			}
@@ -503,28 +1141,160 @@ func (q *Querier) collectResponses(ctx context.Context, _ string, queryType Reco
 			// Parsing against the full message resolves compressed SRV/PTR target
 			// names, so bundled additionals from Avahi/Bonjour resolve too.
 			for _, add := range parsedMsg.Additionals {
-				data, err := message.ParseRDATAInMessage(add.TYPE, responseMsg, add.RDATAOffset, int(add.RDLENGTH))
+				record, err := decodeRecord(responseMsg, add, zone)
 				if err != nil {
 					continue
 				}
-				dedupeKey := fmt.Sprintf("add|%s|%d|%v", add.NAME, add.TYPE, data)
+				record.Source = packet.src
+				dedupeKey := fmt.Sprintf("add|%s|%d|%v|%x", record.Name, record.Type, record.Data, record.RawData)
+				if record.TTL == 0 {
+					response.Additionals = removeRecord(response.Additionals, record)
+					delete(seen, dedupeKey)
+					continue
+				}
 				if seen[dedupeKey] {
 					continue
 				}
+				if len(response.Additionals) >= maxRecords {
+					break // WithMaxRecords cap reached; drop the rest
+				}
 				seen[dedupeKey] = true
 
-				response.Additionals = append(response.Additionals, ResourceRecord{
-					Name:  add.NAME,
-					Type:  RecordType(add.TYPE),
-					Class: add.CLASS,
-					TTL:   add.TTL,
-					Data:  toRecordData(data),
-				})
+				response.Additionals = append(response.Additionals, record)
+			}
+
+			if observe != nil {
+				observe(response)
+			}
+
+			// QueryN: enough distinct records collected; or the
+			// WithMaxRecords cap is reached, so nothing more can be kept
+			if (minRecords > 0 && len(response.Records) >= minRecords) || len(response.Records) >= maxRecords {
+				return response, nil
 			}
 		}
 	}
 }
 
+// inboundPacket is a received mDNS packet together with its receipt metadata,
+// handed from receiveLoop to the query in progress.
+type inboundPacket struct {
+	received time.Time
+	src      net.Addr
+	data     []byte
+	ifIndex  int
+}
+
+// sendTruncationFollowUp re-sends the query as a direct unicast query to the
+// responder that sent a truncated (TC=1) response.
+//
+// RFC 6762 §18.5: Multicast responses MUST NOT set TC, but a legacy or
+// oversized unicast response may. mDNS has no TCP fallback, so the querier
+// instead re-asks the responder directly on port 5353 (RFC 6762 §5.5), once
+// per source per query so a responder that keeps truncating cannot cause a
+// query storm. The follow-up is best-effort; send failures are ignored.
+func (q *Querier) sendTruncationFollowUp(ctx context.Context, name string, queryType RecordType, src net.Addr, followedUp map[string]bool) {
+	udpAddr, ok := src.(*net.UDPAddr)
+	if !ok || udpAddr.IP == nil || name == "" {
+		return
+	}
+	key := udpAddr.IP.String()
+	if followedUp[key] {
+		return
+	}
+	followedUp[key] = true
+
+	queryMsg, err := message.BuildQuery(name, uint16(queryType))
+	if err != nil {
+		return
+	}
+	dest := &net.UDPAddr{IP: udpAddr.IP, Port: protocol.Port, Zone: udpAddr.Zone}
+	q.sendFollowUp(ctx, queryMsg, dest)
+}
+
+// decodeResponse parses a raw mDNS packet and validates it as a response.
+//
+// FR-009: Parse response message
+// FR-011: Discard malformed packets
+// FR-021, FR-022: Discard messages with QR=0 or RCODE≠0
+//
+// Returns the parsed message and true, or nil and false if the packet must be
+// discarded.
+func decodeResponse(responseMsg []byte) (*message.DNSMessage, bool) {
+	parsedMsg, err := message.ParseMessage(responseMsg)
+	if err != nil {
+		// In M1, we silently continue (production might log)
+		return nil, false
+	}
+
+	if err := protocol.ValidateResponse(parsedMsg.Header.Flags); err != nil {
+		return nil, false
+	}
+
+	return parsedMsg, true
+}
+
+// decodeRecord converts a parsed wire-format record into a public
+// ResourceRecord, parsing its RDATA against the full message so compressed
+// PTR/SRV target names resolve (FR-012).
+//
+// Records of types beacon does not decode are returned with nil Data and
+// their RDATA in RawData rather than as an error, so a response mixing known
+// and unknown types (HINFO, NSEC, ...) loses nothing. TXT records carry
+// both their decoded strings in Data and their exact RDATA in RawData.
+// A link-local AAAA record gets its Zone from zone, the receiving
+// interface's zone as returned by packetZone.
+func decodeRecord(responseMsg []byte, rr message.Answer, zone func() string) (ResourceRecord, error) {
+	record := ResourceRecord{
+		Name:  rr.NAME,
+		Type:  RecordType(rr.TYPE),
+		Class: rr.CLASS,
+		TTL:   rr.TTL,
+	}
+
+	switch record.Type {
+	case RecordTypeA, RecordTypeAAAA, RecordTypePTR, RecordTypeSRV, RecordTypeTXT:
+		data, err := message.ParseRDATAInMessage(rr.TYPE, responseMsg, rr.RDATAOffset, int(rr.RDLENGTH))
+		if err != nil {
+			return ResourceRecord{}, err
+		}
+		record.Data = toRecordData(data)
+		if record.Type == RecordTypeTXT {
+			// TXT values may be binary (RFC 6763 §6); keep the exact bytes
+			// for AsTXTRaw
+			record.RawData = append([]byte(nil), rr.RDATA...)
+		}
+	default:
+		record.RawData = append([]byte(nil), rr.RDATA...)
+	}
+	if ip := record.AsAAAA(); ip != nil && ip.IsLinkLocalUnicast() {
+		record.Zone = zone()
+	}
+	return record, nil
+}
+
+// packetZone returns the IPv6 zone of the records in a packet received on
+// interface ifIndex (0 = unknown, no zone), looked up on first use only so a
+// packet costs at most one interface lookup however many records it carries.
+func packetZone(ifIndex int) func() string {
+	return sync.OnceValue(func() string {
+		if ifIndex <= 0 {
+			return ""
+		}
+		return interfaceZone(ifIndex)
+	})
+}
+
+// interfaceZone returns the IPv6 zone for interface ifIndex: its name, or the
+// decimal index if the interface has since gone away (both forms are
+// accepted by net.Dial).
+func interfaceZone(ifIndex int) string {
+	if iface, err := net.InterfaceByIndex(ifIndex); err == nil {
+		return iface.Name
+	}
+	return strconv.Itoa(ifIndex)
+}
+
 // receiveLoop runs in a background goroutine to continuously receive mDNS responses.
 //
 // FR-006: System MUST receive responses with configurable timeout
@@ -544,7 +1314,7 @@ func (q *Querier) receiveLoop() {
 			// FR-006: Receive with short timeout to check context periodically
 			// T034: Migrated from network.ReceiveResponse to transport.Receive()
 			ctx, cancel := context.WithTimeout(q.ctx, 100*time.Millisecond)
-			responseMsg, srcAddr, _, err := q.transport.Receive(ctx) // interfaceIndex not used by querier
+			responseMsg, srcAddr, ifIndex, err := q.transport.Receive(ctx)
 			cancel()
 
 			if err != nil {
@@ -559,6 +1329,11 @@ func (q *Querier) receiveLoop() {
 				continue
 			}
 
+			// Nothing received (empty datagram or spurious wake-up)
+			if len(responseMsg) == 0 {
+				continue
+			}
+
 			// T077: Packet size validation per RFC 6762 §17 (FR-034)
 			// Fail fast - reject oversized packets before parsing
 			const maxMDNSPacketSize = 9000 // RFC 6762 §17
@@ -583,6 +1358,10 @@ func (q *Querier) receiveLoop() {
 			// - Accept link-local addresses (169.254.0.0/16) - ALWAYS valid per RFC 3927
 			// - Accept private addresses (10.x, 172.16.x, 192.168.x) - likely same subnet
 			// - Reject public/routed IPs (8.8.8.8, etc.) - definitely not link-local
+			// Packets on loopback come from same-host responders, sent from
+			// whichever host address the OS picks (WithLoopbackDiscovery)
+			fromThisHost := q.loopbackIfaces[ifIndex]
+
 			if srcIP != nil {
 				ip4 := srcIP.To4()
 				if ip4 != nil {
@@ -590,12 +1369,24 @@ func (q *Querier) receiveLoop() {
 					isLinkLocal := ip4[0] == 169 && ip4[1] == 254
 
 					// Reject public/routed IPs (definitely not link-local scope)
-					if !isLinkLocal && !security.IsPrivate(srcIP) {
+					if !isLinkLocal && !fromThisHost && !security.IsPrivate(srcIP) {
 						// Public IP - drop packet (violates RFC 6762 §2 link-local scope)
 						// TODO T076: Add debug logging (source IP + reason)
 						continue
 					}
 				}
+
+				// RFC 6762 §11: Drop responses from sources off our links
+				// (WithRequireLocalSource)
+				if q.localSource != nil && !fromThisHost && !q.localSource.isLocal(srcIP, ifIndex) {
+					continue
+				}
+
+				// Drop our own process's or host's announcements looped back
+				// to us (WithSameHostFilter)
+				if q.sameHost != nil && q.sameHost.isOwnAddress(srcIP) {
+					continue
+				}
 			}
 
 			// Apply rate limiting if enabled (FR-029: drop packets from flooding sources)
@@ -608,9 +1399,21 @@ func (q *Querier) receiveLoop() {
 				}
 			}
 
+			packet := inboundPacket{received: clock.Or(q.clock).Now(), src: srcAddr, data: responseMsg, ifIndex: ifIndex}
+
+			// Fan out to active Browse goroutines (non-blocking; slow browsers drop)
+			q.browsersMu.RLock()
+			for browser := range q.browsers {
+				select {
+				case browser <- packet:
+				default:
+				}
+			}
+			q.browsersMu.RUnlock()
+
 			// Send response to channel (non-blocking)
 			select {
-			case q.responseChan <- responseMsg:
+			case q.responseChan <- packet:
 				// Sent successfully
 			default:
 				// Channel full - drop packet (M1 behavior)
@@ -646,7 +1449,14 @@ func (q *Querier) cleanupLoop() {
 // Close gracefully shuts down the Querier and releases resources.
 //
 // Close cancels the lifecycle context, waits for background goroutines to exit,
-// and closes the UDP socket per FR-017, FR-018.
+// and closes the UDP socket per FR-017, FR-018. Queries in flight are
+// cancelled and return ErrClosed; Close waits briefly for them to return
+// before closing the socket. Queries made after Close return ErrClosed.
+//
+// Pending re-queries of Browse and Watch and truncation follow-ups are
+// cancelled: once Close is called, no further query leaves the host. mDNS has
+// no way to withdraw a question already sent, so responders that delayed
+// their answer to one (RFC 6762 §6) still multicast it.
 //
 // FR-017: System MUST close socket after query completion
 // FR-018: System MUST support graceful shutdown via context cancellation
@@ -659,12 +1469,18 @@ func (q *Querier) cleanupLoop() {
 //	}
 //	defer q.Close() // Always close to release resources
 func (q *Querier) Close() error {
-	// Cancel lifecycle context (stops receiver goroutine)
+	// Cancel lifecycle context (stops receiver goroutine and cancels
+	// in-flight queries); no query can start after this
+	q.inflightMu.Lock()
 	q.cancel()
+	q.inflightMu.Unlock()
 
 	// Wait for receiver goroutine to exit
 	q.wg.Wait()
 
+	// Let in-flight queries return ErrClosed before the socket goes away
+	drained := q.drainQueries(closeDrainTimeout)
+
 	// Close transport per FR-017
 	// T035: Migrated from network.CloseSocket to transport.Close()
 	// FR-004 FIX: Now properly propagates errors (CloseSocket was swallowing them)
@@ -673,8 +1489,93 @@ func (q *Querier) Close() error {
 		return err
 	}
 
-	// Close response channel
-	close(q.responseChan)
+	// Close response channel, unless a query stuck past the drain timeout
+	// may still read it
+	if drained {
+		close(q.responseChan)
+	}
 
 	return nil
 }
+
+// sendFollowUp sends a best-effort query following up an earlier one (a
+// Browse re-query, a Watch refresh, a truncation follow-up) unless Close has
+// begun. Close cancels q.ctx under inflightMu, so holding it across the check
+// and the send keeps any follow-up from leaving after Close. Send errors are
+// ignored; the next follow-up retries.
+func (q *Querier) sendFollowUp(ctx context.Context, queryMsg []byte, dest net.Addr) {
+	q.inflightMu.Lock()
+	defer q.inflightMu.Unlock()
+	if q.ctx.Err() != nil {
+		return
+	}
+	_ = q.transport.Send(ctx, queryMsg, dest) // nosemgrep: beacon-error-swallowing
+}
+
+// closeDrainTimeout bounds how long Close waits for in-flight queries to
+// return once cancelled.
+const closeDrainTimeout = 2 * time.Second
+
+// acquireQuery registers a query as in flight and takes q.mu, serializing it
+// with the other queries sharing responseChan. The returned context is ctx,
+// additionally cancelled when the Querier is closed.
+//
+// Returns:
+//   - context.Context: ctx bound to the Querier's lifetime
+//   - func(): Releases q.mu and the in-flight registration; call exactly once
+//   - error: ErrClosed if the Querier is closed, before or while waiting
+func (q *Querier) acquireQuery(ctx context.Context) (context.Context, func(), error) {
+	q.inflightMu.Lock()
+	if q.ctx.Err() != nil {
+		q.inflightMu.Unlock()
+		return nil, nil, ErrClosed
+	}
+	q.inflight.Add(1)
+	q.inflightMu.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(q.ctx, cancel)
+	q.mu.Lock()
+	release := func() {
+		q.mu.Unlock()
+		stop()
+		cancel()
+		q.inflight.Done()
+	}
+
+	// Closed while queued behind another query
+	if q.ctx.Err() != nil {
+		release()
+		return nil, nil, ErrClosed
+	}
+	return ctx, release, nil
+}
+
+// closedOr returns ErrClosed if the Querier has been closed, and err
+// otherwise; a send failing because Close tore down the socket is reported
+// as the close.
+func (q *Querier) closedOr(err error) error {
+	if q.ctx.Err() != nil {
+		return ErrClosed
+	}
+	return err
+}
+
+// drainQueries waits up to timeout for in-flight queries to return, and
+// reports whether they all did.
+func (q *Querier) drainQueries(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		q.inflight.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
@@ -1,9 +1,22 @@
 package querier
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	goerrors "errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
 )
 
 // BenchmarkQuery measures the query processing overhead per NFR-001.
@@ -117,6 +130,252 @@ func TestConcurrentQueries(t *testing.T) {
 	t.Logf("✓ NFR-002: Successfully handled %d concurrent queries", numQueries)
 }
 
+// TestClose_CancelsInFlightQueries verifies Close cancels queries in flight
+// and queued behind q.mu: each returns ErrClosed promptly rather than waiting
+// out its deadline, and a query made after Close fails the same way.
+func TestClose_CancelsInFlightQueries(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	const numQueries = 50
+	results := make(chan error, numQueries)
+	for i := 0; i < numQueries; i++ {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			_, err := q.Query(ctx, "inflight.local", RecordTypeA)
+			results <- err
+		}()
+	}
+
+	// Let the queries start (one collecting, the rest queued)
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	for i := 0; i < numQueries; i++ {
+		select {
+		case err := <-results:
+			if !goerrors.Is(err, ErrClosed) {
+				t.Errorf("in-flight Query() error = %v, want ErrClosed", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("%d of %d queries still running 2s after Close", numQueries-i, numQueries)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("queries took %v to return after Close, want prompt cancellation", elapsed)
+	}
+
+	if _, err := q.Query(context.Background(), "after.local", RecordTypeA); !goerrors.Is(err, ErrClosed) {
+		t.Errorf("Query() after Close error = %v, want ErrClosed", err)
+	}
+}
+
+// TestQuery_CoalescesConcurrentIdenticalQueries verifies concurrent Query
+// calls for the same name and type share one query on the wire, and every
+// caller receives the aggregated response.
+func TestQuery_CoalescesConcurrentIdenticalQueries(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	const numQueries = 50
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	results := make(chan result, numQueries)
+	for i := 0; i < numQueries; i++ {
+		go func() {
+			resp, err := q.Query(ctx, "Concurrent.local", RecordTypeA)
+			results <- result{resp, err}
+		}()
+	}
+
+	// Answer once every caller has joined the round
+	waitForRoundWaiters(t, q, "concurrent.local", RecordTypeA, numQueries)
+	mock.QueueReceive(buildValidResponsePacket("concurrent.local", protocol.RecordTypeA, []byte{192, 168, 1, 7}), nil, 0)
+
+	for i := 0; i < numQueries; i++ {
+		r := <-results
+		if r.err != nil {
+			t.Fatalf("Query() error = %v", r.err)
+		}
+		if len(r.resp.Records) != 1 || !r.resp.Records[0].AsA().Equal(net.IPv4(192, 168, 1, 7)) {
+			t.Errorf("Query() records = %+v, want the shared A record", r.resp.Records)
+		}
+	}
+	if n := len(mock.SendCalls()); n != 1 {
+		t.Errorf("%d identical concurrent queries sent %d packets, want 1", numQueries, n)
+	}
+}
+
+// TestQuery_CoalescedRoundIgnoresQuerierClock verifies a coalesced Query's
+// default timeout runs on the wall clock, not the WithClock clock: a fake
+// clock set years back neither ends the round at once nor stops it ending.
+func TestQuery_CoalescedRoundIgnoresQuerierClock(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	fake := clock.NewFake(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake), WithTimeout(300*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	results := make(chan result, 1)
+	go func() {
+		resp, err := q.Query(context.Background(), "clocked.local", RecordTypeA)
+		results <- result{resp, err}
+	}()
+
+	waitForRoundWaiters(t, q, "clocked.local", RecordTypeA, 1)
+	mock.QueueReceive(buildValidResponsePacket("clocked.local", protocol.RecordTypeA, []byte{192, 168, 1, 8}), nil, 0)
+
+	select {
+	case r := <-results:
+		if r.err != nil {
+			t.Fatalf("Query() error = %v, want the collected response", r.err)
+		}
+		if len(r.resp.Records) != 1 || !r.resp.Records[0].AsA().Equal(net.IPv4(192, 168, 1, 8)) {
+			t.Errorf("Query() records = %+v, want the A record", r.resp.Records)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Query() did not return after its default timeout")
+	}
+}
+
+// TestQuery_CoalescesShortAndLongCallers verifies a caller with a later
+// deadline joining a shorter round keeps collecting until its own deadline,
+// while the shorter caller returns at its deadline with what arrived by then;
+// both share one query on the wire.
+func TestQuery_CoalescesShortAndLongCallers(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+	query := func(timeout time.Duration) <-chan result {
+		results := make(chan result, 1)
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+			resp, err := q.Query(ctx, "shared.local", RecordTypeA)
+			results <- result{resp, err}
+		}()
+		return results
+	}
+
+	short := query(300 * time.Millisecond)
+	waitForRoundWaiters(t, q, "shared.local", RecordTypeA, 1)
+	long := query(2 * time.Second)
+	waitForRoundWaiters(t, q, "shared.local", RecordTypeA, 2)
+	mock.QueueReceive(buildValidResponsePacket("shared.local", protocol.RecordTypeA, []byte{192, 168, 1, 1}), nil, 0)
+
+	r := <-short
+	if r.err != nil {
+		t.Fatalf("short Query() error = %v", r.err)
+	}
+	if len(r.resp.Records) != 1 || !r.resp.Records[0].AsA().Equal(net.IPv4(192, 168, 1, 1)) {
+		t.Errorf("short Query() records = %+v, want the first A record", r.resp.Records)
+	}
+
+	// Arrives after the short caller's deadline, within the long caller's
+	mock.QueueReceive(buildValidResponsePacket("shared.local", protocol.RecordTypeA, []byte{192, 168, 1, 2}), nil, 0)
+
+	r = <-long
+	if r.err != nil {
+		t.Fatalf("long Query() error = %v", r.err)
+	}
+	if len(r.resp.Records) != 2 {
+		t.Errorf("long Query() records = %+v, want both A records", r.resp.Records)
+	}
+	if n := len(mock.SendCalls()); n != 1 {
+		t.Errorf("short and long concurrent queries sent %d packets, want 1", n)
+	}
+}
+
+// waitForRoundWaiters blocks until n callers wait on the query round for name
+// and recordType.
+func waitForRoundWaiters(t *testing.T, q *Querier, name string, recordType RecordType, n int) {
+	t.Helper()
+	key := roundKey{name: name, recordType: recordType}
+	deadline := time.Now().Add(5 * time.Second)
+	for joined := 0; joined < n; {
+		q.roundsMu.Lock()
+		if round, ok := q.rounds[key]; ok {
+			joined = round.waiters
+		}
+		q.roundsMu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d callers joined the round", joined, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestClose_CancelsPendingRequeries verifies Close cancels the re-query timers
+// of a running Browse and Watch: nothing is sent once Close is called, even
+// past the time the first re-query was due (RFC 6762 §5.2).
+func TestClose_CancelsPendingRequeries(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := q.Browse(ctx, "_http._tcp.local"); err != nil {
+		t.Fatalf("Browse() error = %v", err)
+	}
+	if _, err := q.Watch(ctx, "host.local", RecordTypeA); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	// A 1s TTL schedules the Watch refresh queries within the wait below
+	answer := buildValidResponsePacket("host.local", protocol.RecordTypeA, []byte{192, 168, 1, 5})
+	binary.BigEndian.PutUint32(answer[len(answer)-4-2-4:], 1) // TTL, before RDLENGTH and RDATA
+	mock.QueueReceive(answer, nil, 0)
+	time.Sleep(50 * time.Millisecond)
+
+	sent := len(mock.SendCalls())
+	if err := q.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// Past the first Browse re-query (1s) and the record's refresh queries
+	time.Sleep(browseInitialRequery + 500*time.Millisecond)
+	if late := len(mock.SendCalls()) - sent; late != 0 {
+		t.Errorf("%d queries sent after Close, want 0", late)
+	}
+}
+
 // TestWithTimeout verifies the WithTimeout option works correctly.
 //
 // This test validates the functional option pattern for configuration.
@@ -245,6 +504,34 @@ func TestParseTXT(t *testing.T) {
 	}
 }
 
+// TestParseTXTEntries validates that the RFC 6763 §6.4 attribute forms "key",
+// "key=" and "key=value" remain distinguishable after parsing.
+func TestParseTXTEntries(t *testing.T) {
+	got := ParseTXTEntries([]string{"paper", "note=", "rp=printers/1", "", "=ignored"})
+	want := []TXTEntry{
+		{Key: "paper"},
+		{Key: "note", HasValue: true},
+		{Key: "rp", Value: "printers/1", HasValue: true},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("ParseTXTEntries() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ParseTXTEntries()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	rr := &ResourceRecord{Type: RecordTypeTXT, Data: []string{"paper", "note="}}
+	if entries := rr.AsTXTEntries(); len(entries) != 2 || entries[0].HasValue || !entries[1].HasValue {
+		t.Errorf("AsTXTEntries() = %+v, want [{paper  false} {note  true}]", entries)
+	}
+	if entries := (&ResourceRecord{Type: RecordTypeA}).AsTXTEntries(); entries != nil {
+		t.Errorf("AsTXTEntries() on A record = %+v, want nil", entries)
+	}
+}
+
 // ==============================================================================
 // M1-Refactoring Integration Tests (TDD - RED Phase)
 // ==============================================================================
@@ -254,44 +541,787 @@ func TestParseTXT(t *testing.T) {
 // NOTE: Original TDD RED tests removed (T027, T028):
 // - TestQuerier_UsesTransportInterface: Obsolete, T031 is complete
 //   (Querier HAS transport field at querier.go:46-47, used throughout)
-// - TestQuerier_WorksWithMockTransport: Deferred to future milestone
-//   (WithTransport() option not implemented - all tests work without it)
+// - TestQuerier_WorksWithMockTransport: Superseded by T100 below
 //
 // Transport interface abstraction is validated via:
 // - M1-Refactoring completion (see archive/m1-refactoring/)
 // - internal/transport/transport_test.go (interface contract tests)
 // - querier/querier.go:112 (New() creates UDPv4Transport)
-//
-// TODO M2 (T100): Add test for WithTransport() option
-// After implementing WithTransport() option (see querier/options.go TODO), add:
-//
-//   func TestQuerier_WithTransport_UsesMockTransport(t *testing.T) {
-//       mock := transport.NewMockTransport()
-//       q, err := New(WithTransport(mock))
-//       if err != nil {
-//           t.Fatalf("New(WithTransport) failed: %v", err)
-//       }
-//       defer func() { _ = q.Close() }()
-//
-//       ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
-//       defer cancel()
-//
-//       _, _ = q.Query(ctx, "test.local", RecordTypeA)
-//
-//       // Verify mock recorded the Send() call
-//       calls := mock.SendCalls()
-//       if len(calls) != 1 {
-//           t.Errorf("Expected 1 Send() call, got %d", len(calls))
-//       }
-//   }
-//
-// This enables testing without real network, mocking failures, simulating responses.
+
+// T100: WithTransport() injects a transport, enabling tests without a real
+// network (mocking failures, simulating responses).
 // See: specs/004-m1-1-architectural-hardening/tasks.md Phase 8, T100
+func TestQuerier_WithTransport_UsesMockTransport(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _ = q.Query(ctx, "test.local", RecordTypeA)
+
+	// Verify mock recorded the Send() call
+	calls := mock.SendCalls()
+	if len(calls) != 1 {
+		t.Errorf("Expected 1 Send() call, got %d", len(calls))
+	}
+}
+
+// TestWithTransport_NilRejected verifies WithTransport(nil) fails fast.
+func TestWithTransport_NilRejected(t *testing.T) {
+	q, err := New(WithTransport(nil))
+	if err == nil {
+		_ = q.Close()
+		t.Fatal("New(WithTransport(nil)) should return an error")
+	}
+}
+
+// TestWithPacketHook_TracesSentAndReceived verifies the hook observes both the
+// outgoing query and the incoming response.
+func TestWithPacketHook_TracesSentAndReceived(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	var mu sync.Mutex
+	var dirs []Direction
+	var packets [][]byte
+	hook := func(dir Direction, packet []byte, _ net.Addr, _ int) {
+		mu.Lock()
+		defer mu.Unlock()
+		dirs = append(dirs, dir)
+		packets = append(packets, append([]byte(nil), packet...))
+	}
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithPacketHook(hook))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	response := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100})
+	mock.QueueReceive(response, nil, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := q.FindFirst(ctx, "printer.local", RecordTypeA); err != nil {
+		t.Fatalf("FindFirst() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	sentQuery := mock.SendCalls()[0].Packet
+	var sawSent, sawReceived bool
+	for i, dir := range dirs {
+		switch dir {
+		case Sent:
+			sawSent = sawSent || bytes.Equal(packets[i], sentQuery)
+		case Received:
+			sawReceived = sawReceived || bytes.Equal(packets[i], response)
+		}
+	}
+	if !sawSent {
+		t.Error("hook did not observe the sent query bytes")
+	}
+	if !sawReceived {
+		t.Error("hook did not observe the received response bytes")
+	}
+}
+
+// TestFindFirst_ReturnsOnFirstMatch verifies FindFirst returns as soon as a
+// matching record arrives instead of waiting for the context deadline.
+func TestFindFirst_ReturnsOnFirstMatch(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		// Non-matching record first: must be skipped
+		mock.QueueReceive(buildValidResponsePacket("other.local", protocol.RecordTypeA, []byte{192, 168, 1, 50}), nil, 0)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100}), nil, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	rr, err := q.FindFirst(ctx, "printer.local", RecordTypeA)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("FindFirst() error = %v", err)
+	}
+	if elapsed >= time.Second {
+		t.Errorf("FindFirst() took %v, expected early return well before 2s deadline", elapsed)
+	}
+	if rr.Name != "printer.local" {
+		t.Errorf("FindFirst() Name = %q, want %q", rr.Name, "printer.local")
+	}
+	if ip := rr.AsA(); !ip.Equal(net.IPv4(192, 168, 1, 100)) {
+		t.Errorf("FindFirst() AsA() = %v, want 192.168.1.100", ip)
+	}
+}
+
+// TestFindFirst_NotFound verifies FindFirst returns ErrNotFound on timeout.
+func TestFindFirst_NotFound(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err = q.FindFirst(ctx, "missing.local", RecordTypeA)
+	if !goerrors.Is(err, ErrNotFound) {
+		t.Errorf("FindFirst() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestFindFirst_Cancelled verifies FindFirst returns ctx.Err() rather than
+// ErrNotFound when ctx is cancelled while waiting, or was already done.
+func TestFindFirst_Cancelled(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+	if _, err := q.FindFirst(ctx, "missing.local", RecordTypeA); !goerrors.Is(err, context.Canceled) {
+		t.Errorf("FindFirst() after cancel error = %v, want context.Canceled", err)
+	}
+	if _, err := q.FindFirst(ctx, "missing.local", RecordTypeA); !goerrors.Is(err, context.Canceled) {
+		t.Errorf("FindFirst() with a done ctx error = %v, want context.Canceled", err)
+	}
+}
+
+// TestQuery_TruncatedResponse_SendsUnicastFollowUp verifies a TC-set response is
+// surfaced in Response.Packets and triggers exactly one direct unicast re-query
+// to the truncating responder, even if it truncates again.
+func TestQuery_TruncatedResponse_SendsUnicastFollowUp(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	truncated := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100})
+	truncated[2] |= 0x06 // AA=1, TC=1
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 100), Port: 5353}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(truncated, src, 0)
+		mock.QueueReceive(truncated, src, 0) // Second truncation: no second follow-up
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	if len(resp.Packets) != 2 {
+		t.Fatalf("Response.Packets = %d, want 2", len(resp.Packets))
+	}
+	hdr := resp.Packets[0].Header
+	if !hdr.IsTruncated() || !hdr.IsAuthoritative() || hdr.ResponseCode() != 0 {
+		t.Errorf("Header = %+v, want TC=1 AA=1 RCODE=0", hdr)
+	}
+	if resp.Packets[0].Source != src {
+		t.Errorf("Packets[0].Source = %v, want %v", resp.Packets[0].Source, src)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 2 {
+		t.Fatalf("Send called %d times, want 2 (multicast query + one unicast follow-up)", len(calls))
+	}
+	dest, ok := calls[1].Dest.(*net.UDPAddr)
+	if !ok || !dest.IP.Equal(src.IP) || dest.Port != 5353 {
+		t.Errorf("follow-up sent to %v, want %v", calls[1].Dest, src)
+	}
+	// Same question; only the random transaction ID (first 2 bytes) may differ
+	if !bytes.Equal(calls[1].Packet[2:], calls[0].Packet[2:]) {
+		t.Errorf("follow-up packet = %x, want the original query %x", calls[1].Packet, calls[0].Packet)
+	}
+}
+
+// TestQuery_WithKnownAnswers_ListsCachedRecords verifies a repeated query lists
+// the previously received record in its Answer section (RFC 6762 §7.1) and
+// that the suppressed record is still reported in the Response.
+func TestQuery_WithKnownAnswers_ListsCachedRecords(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithKnownAnswers(true))
+	if err != nil {
+		t.Fatalf("New(WithKnownAnswers) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100}), nil, 0)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	if _, err := q.Query(ctx, "printer.local", RecordTypeA); err != nil {
+		t.Fatalf("first Query() error = %v", err)
+	}
+
+	// Second query: the responder suppresses the known record, so nothing arrives
+	ctx2, cancel2 := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel2()
+	resp, err := q.Query(ctx2, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("second Query() error = %v", err)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 2 {
+		t.Fatalf("Send called %d times, want 2", len(calls))
+	}
+	if first, err := message.ParseMessage(calls[0].Packet); err != nil || len(first.Answers) != 0 {
+		t.Errorf("first query answers = %v (err %v), want none", first, err)
+	}
+	second, err := message.ParseMessage(calls[1].Packet)
+	if err != nil {
+		t.Fatalf("ParseMessage(second query) error = %v", err)
+	}
+	if len(second.Answers) != 1 {
+		t.Fatalf("second query carries %d known answers, want 1", len(second.Answers))
+	}
+	ka := second.Answers[0]
+	if ka.NAME != "printer.local" || ka.TYPE != uint16(protocol.RecordTypeA) || !bytes.Equal(ka.RDATA, []byte{192, 168, 1, 100}) {
+		t.Errorf("known answer = %s type %d rdata %v, want printer.local A 192.168.1.100", ka.NAME, ka.TYPE, ka.RDATA)
+	}
+	if ka.TTL <= 60 || ka.TTL > 120 {
+		t.Errorf("known answer TTL = %d, want remaining TTL in (60, 120]", ka.TTL)
+	}
+
+	if len(resp.Records) != 1 || !resp.Records[0].AsA().Equal(net.IPv4(192, 168, 1, 100)) {
+		t.Errorf("second Query() records = %+v, want the suppressed A record merged back", resp.Records)
+	}
+}
+
+// TestQuery_WithKnownAnswers_GoodbyeForgetsRecord verifies a goodbye for a
+// remembered record removes it: the query receiving the goodbye does not merge
+// it back, and the next query no longer lists it as known (RFC 6762 §10.1).
+func TestQuery_WithKnownAnswers_GoodbyeForgetsRecord(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithKnownAnswers(true))
+	if err != nil {
+		t.Fatalf("New(WithKnownAnswers) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	queryWith := func(packet []byte) *Response {
+		t.Helper()
+		if packet != nil {
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				mock.QueueReceive(packet, nil, 0)
+			}()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+		if err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		return resp
+	}
+
+	rdata := []byte{192, 168, 1, 100}
+	if resp := queryWith(buildValidResponsePacket("printer.local", protocol.RecordTypeA, rdata)); len(resp.Records) != 1 {
+		t.Fatalf("first Query() records = %+v, want the A record", resp.Records)
+	}
+	if resp := queryWith(buildGoodbyePacket("printer.local", protocol.RecordTypeA, rdata)); len(resp.Records) != 0 {
+		t.Errorf("Query() receiving goodbye records = %+v, want none", resp.Records)
+	}
+	if resp := queryWith(nil); len(resp.Records) != 0 {
+		t.Errorf("Query() after goodbye records = %+v, want none", resp.Records)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 3 {
+		t.Fatalf("Send called %d times, want 3", len(calls))
+	}
+	if third, err := message.ParseMessage(calls[2].Packet); err != nil || len(third.Answers) != 0 {
+		t.Errorf("query after goodbye lists %v (err %v), want no known answers", third, err)
+	}
+}
 
 // ==============================================================================
 // Phase 3: Error Propagation Validation (T064) - FR-004
 // ==============================================================================
 
+// TestQueryN_ReturnsAfterNthDistinctRecord verifies QueryN returns as soon as
+// the n-th distinct record arrives, well before the deadline, and that a
+// duplicate does not count toward n.
+func TestQueryN_ReturnsAfterNthDistinctRecord(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	first := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100})
+	second := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 101})
+	third := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 102})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(first, nil, 0)
+		mock.QueueReceive(first, nil, 0) // Duplicate: must not count
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(second, nil, 0)
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(third, nil, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	resp, err := q.QueryN(ctx, "printer.local", RecordTypeA, 2)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("QueryN() error = %v", err)
+	}
+
+	if len(resp.Records) != 2 {
+		t.Fatalf("QueryN(2) returned %d records, want 2", len(resp.Records))
+	}
+	if ip := resp.Records[1].AsA(); !ip.Equal(net.IPv4(192, 168, 1, 101)) {
+		t.Errorf("second record = %v, want 192.168.1.101", ip)
+	}
+	if elapsed >= time.Second {
+		t.Errorf("QueryN(2) took %v, want early return after the second record", elapsed)
+	}
+}
+
+// TestQueryN_RejectsNonPositiveN verifies n < 1 is a ValidationError.
+func TestQueryN_RejectsNonPositiveN(t *testing.T) {
+	q, err := New(WithTransport(transport.NewMockTransport()))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	_, err = q.QueryN(context.Background(), "printer.local", RecordTypeA, 0)
+	var valErr *errors.ValidationError
+	if !goerrors.As(err, &valErr) {
+		t.Errorf("QueryN(n=0) error = %v, want ValidationError", err)
+	}
+}
+
+// TestWithMaxRecords_CapsFlood verifies a query flooded with more distinct
+// records than the WithMaxRecords cap returns promptly with just the cap.
+func TestWithMaxRecords_CapsFlood(t *testing.T) {
+	const maxRecords = 5
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithMaxRecords(maxRecords))
+	if err != nil {
+		t.Fatalf("New(WithMaxRecords) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for i := 0; i < 4*maxRecords; i++ {
+			mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, byte(i + 1)}), nil, 0)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(resp.Records) != maxRecords {
+		t.Errorf("Query() returned %d records, want %d (the cap)", len(resp.Records), maxRecords)
+	}
+	if elapsed >= time.Second {
+		t.Errorf("Query() took %v, want an early return once the cap was reached", elapsed)
+	}
+
+	for _, n := range []int{0, -1} {
+		_, err := New(WithTransport(transport.NewMockTransport()), WithMaxRecords(n))
+		var valErr *errors.ValidationError
+		if !goerrors.As(err, &valErr) {
+			t.Errorf("New(WithMaxRecords(%d)) error = %v, want ValidationError", n, err)
+		}
+	}
+}
+
+// TestServiceTypes_DeduplicatesMetaPTRs verifies ServiceTypes queries the
+// DNS-SD meta-service (RFC 6763 §9) and merges the types from every responder
+// into a sorted list without duplicates.
+func TestServiceTypes_DeduplicatesMetaPTRs(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	const meta = "_services._dns-sd._udp.local"
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for _, serviceType := range []string{"_ipp._tcp.local", "_http._tcp.local", "_HTTP._tcp.local", "_ipp._tcp.local"} {
+			rdata, _ := message.EncodeName(serviceType)
+			mock.QueueReceive(buildValidResponsePacket(meta, protocol.RecordTypePTR, rdata), nil, 0)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	types, err := q.ServiceTypes(ctx)
+	if err != nil {
+		t.Fatalf("ServiceTypes() error = %v", err)
+	}
+
+	want := []string{"_http._tcp.local", "_ipp._tcp.local"}
+	if len(types) != len(want) || types[0] != want[0] || types[1] != want[1] {
+		t.Errorf("ServiceTypes() = %v, want %v", types, want)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) == 0 {
+		t.Fatal("ServiceTypes() sent no query")
+	}
+	query, err := message.ParseMessage(calls[0].Packet)
+	if err != nil || len(query.Questions) != 1 || query.Questions[0].QNAME != meta ||
+		query.Questions[0].QTYPE != uint16(protocol.RecordTypePTR) {
+		t.Errorf("ServiceTypes() query = %+v (%v), want PTR %s", query, err, meta)
+	}
+}
+
+// TestQuery_RecordsCarrySource verifies each record reports the address of
+// the responder that sent it, so two devices claiming one name are told apart.
+func TestQuery_RecordsCarrySource(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New(WithTransport) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	owner := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 5353}
+	squatter := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 66), Port: 5353}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 5}), owner, 0)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 66}), squatter, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(resp.Records) != 2 {
+		t.Fatalf("Query() returned %d records, want 2", len(resp.Records))
+	}
+	for _, rr := range resp.Records {
+		if rr.Source == nil {
+			t.Errorf("record %s has nil Source", rr.AsA())
+			continue
+		}
+		if got, want := rr.Source.String(), net.JoinHostPort(rr.AsA().String(), "5353"); got != want {
+			t.Errorf("record %s Source = %s, want %s", rr.AsA(), got, want)
+		}
+	}
+}
+
+// TestQuery_InstanceFilter verifies WithInstanceFilter narrows a PTR browse
+// to the instances whose names pass the predicate.
+func TestQuery_InstanceFilter(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false),
+		WithInstanceFilter(func(name string) bool { return strings.Contains(name, "Brother") }))
+	if err != nil {
+		t.Fatalf("New(WithInstanceFilter) failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 5), Port: 5353}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		for _, instance := range []string{"Brother-HL-L2350", "HP-LaserJet", "Office-Brother-MFC", "Canon-Pixma"} {
+			mock.QueueReceive(buildPTRResponse("_ipp._tcp.local", instance+"._ipp._tcp.local", 120), src, 0)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	resp, err := q.Query(ctx, "_ipp._tcp.local", RecordTypePTR)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	var got []string
+	for _, rr := range resp.Records {
+		got = append(got, rr.AsPTR())
+	}
+	slices.Sort(got)
+	want := []string{"Brother-HL-L2350._ipp._tcp.local", "Office-Brother-MFC._ipp._tcp.local"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Query() PTR targets = %v, want %v", got, want)
+	}
+}
+
+// TestQueryInterface_FiltersByInterface verifies QueryInterface sends the
+// query out the requested interface and keeps only replies received on it.
+func TestQueryInterface_FiltersByInterface(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	onVLAN2 := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{10, 0, 2, 5})
+	onEth0 := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 5})
+	unknown := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{172, 16, 0, 5})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(onEth0, nil, 2)
+		mock.QueueReceive(onVLAN2, nil, 7)
+		mock.QueueReceive(unknown, nil, 0)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	resp, err := q.QueryInterface(ctx, net.Interface{Index: 7, Name: "vlan2"}, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("QueryInterface() error = %v", err)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 1 || calls[0].IfIndex != 7 {
+		t.Fatalf("SendCalls = %+v, want one query sent on interface 7", calls)
+	}
+	if len(resp.Records) != 1 {
+		t.Fatalf("QueryInterface returned %d records, want 1 (only interface 7)", len(resp.Records))
+	}
+	if ip := resp.Records[0].AsA(); !ip.Equal(net.IPv4(10, 0, 2, 5)) {
+		t.Errorf("record = %v, want 10.0.2.5 (received on interface 7)", ip)
+	}
+
+	_, err = q.QueryInterface(ctx, net.Interface{Name: "noindex"}, "printer.local", RecordTypeA)
+	var valErr *errors.ValidationError
+	if !goerrors.As(err, &valErr) {
+		t.Errorf("QueryInterface(index 0) error = %v, want ValidationError", err)
+	}
+}
+
+// TestQuery_LinkLocalAAAAZone verifies a link-local AAAA record carries the
+// zone of the interface it arrived on, so the address can be dialed, while a
+// global address, or one received on an unknown interface, gets none.
+func TestQuery_LinkLocalAAAAZone(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("net.Interfaces() error = %v", err)
+	}
+	var known net.Interface
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			known = iface
+			break
+		}
+	}
+	if known.Index == 0 {
+		t.Skip("no loopback interface")
+	}
+
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	linkLocal := net.ParseIP("fe80::1234")
+	unknownIf := net.ParseIP("fe80::5678")
+	global := net.ParseIP("2001:db8::1")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeAAAA, linkLocal), nil, known.Index)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeAAAA, unknownIf), nil, 0)
+		mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeAAAA, global), nil, known.Index)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	resp, err := q.Query(ctx, "printer.local", RecordTypeAAAA)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	want := map[string]string{linkLocal.String(): known.Name, unknownIf.String(): "", global.String(): ""}
+	if len(resp.Records) != len(want) {
+		t.Fatalf("Query() returned %d records, want %d", len(resp.Records), len(want))
+	}
+	for i := range resp.Records {
+		rr := &resp.Records[i]
+		ip := rr.AsAAAA()
+		if zone, ok := want[ip.String()]; !ok || rr.Zone != zone {
+			t.Errorf("record %s Zone = %q, want %q", ip, rr.Zone, zone)
+		}
+		if addr := rr.AsAAAAAddr(); addr == nil || !addr.IP.Equal(ip) || addr.Zone != rr.Zone {
+			t.Errorf("record %s AsAAAAAddr() = %v, want %s%%%s", ip, addr, ip, rr.Zone)
+		}
+	}
+}
+
+// TestPacketZone verifies a packet's zone is empty for an unknown interface
+// and falls back to the decimal index for one that no longer exists.
+func TestPacketZone(t *testing.T) {
+	if zone := packetZone(0)(); zone != "" {
+		t.Errorf("packetZone(0)() = %q, want \"\"", zone)
+	}
+	if zone := packetZone(999999)(); zone != "999999" {
+		t.Errorf("packetZone(999999)() = %q, want \"999999\"", zone)
+	}
+}
+
+// TestQueryRaw_ReturnsPacketsVerbatim verifies QueryRaw returns each received
+// packet unparsed, including one the normal parser rejects, together with its
+// source address, interface index and receipt time.
+func TestQueryRaw_ReturnsPacketsVerbatim(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	valid := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100})
+	malformed := []byte{0x00, 0x00, 0x84, 0x00, 0x00, 0x00, 0x00, 0x01} // Truncated header
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 100), Port: 5353}
+
+	start := time.Now()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(valid, src, 3)
+		mock.QueueReceive(valid, src, 3) // Duplicates are kept
+		mock.QueueReceive(malformed, src, 4)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	raws, err := q.QueryRaw(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("QueryRaw() error = %v", err)
+	}
+
+	if len(mock.SendCalls()) != 1 {
+		t.Errorf("Send called %d times, want 1", len(mock.SendCalls()))
+	}
+	if len(raws) != 3 {
+		t.Fatalf("QueryRaw() returned %d packets, want 3", len(raws))
+	}
+	want := []struct {
+		packet  []byte
+		ifIndex int
+	}{{valid, 3}, {valid, 3}, {malformed, 4}}
+	for i, raw := range raws {
+		if !bytes.Equal(raw.Packet, want[i].packet) {
+			t.Errorf("raws[%d].Packet = % x, want % x", i, raw.Packet, want[i].packet)
+		}
+		if raw.Source.String() != src.String() {
+			t.Errorf("raws[%d].Source = %v, want %v", i, raw.Source, src)
+		}
+		if raw.InterfaceIndex != want[i].ifIndex {
+			t.Errorf("raws[%d].InterfaceIndex = %d, want %d", i, raw.InterfaceIndex, want[i].ifIndex)
+		}
+		if raw.ReceivedAt.Before(start) || raw.ReceivedAt.After(time.Now()) {
+			t.Errorf("raws[%d].ReceivedAt = %v, outside the query window", i, raw.ReceivedAt)
+		}
+	}
+}
+
+// TestQueryRaw_DeduplicateReceives verifies that with WithDeduplicateReceives
+// a packet delivered twice from the same source, as on a multi-homed host,
+// reaches QueryRaw once.
+func TestQueryRaw_DeduplicateReceives(t *testing.T) {
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithDeduplicateReceives(true))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	valid := buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 100})
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 100), Port: 5353}
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		mock.QueueReceive(valid, src, 1)
+		mock.QueueReceive(valid, src, 2) // Same packet via a second interface
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	raws, err := q.QueryRaw(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("QueryRaw() error = %v", err)
+	}
+	if len(raws) != 1 || raws[0].InterfaceIndex != 1 {
+		t.Errorf("QueryRaw() returned %+v, want only the first copy (interface 1)", raws)
+	}
+}
+
 // T064: Integration test - Querier.Close() handles transport close errors
 //
 // This test validates that Querier.Close() properly propagates errors from
@@ -423,3 +1453,105 @@ func TestQuery_HonorsDefaultTimeout_DeadlinelessContext(t *testing.T) {
 		t.Fatal("Query did not return within 3s; WithTimeout is not honored for a deadline-less context (issue #5)")
 	}
 }
+
+// sentQuestionClass returns the QCLASS of the first question of a sent query.
+func sentQuestionClass(t *testing.T, packet []byte) uint16 {
+	t.Helper()
+	msg, err := message.ParseMessage(packet)
+	if err != nil {
+		t.Fatalf("ParseMessage(sent query) error = %v", err)
+	}
+	if len(msg.Questions) != 1 {
+		t.Fatalf("sent query has %d questions, want 1", len(msg.Questions))
+	}
+	return msg.Questions[0].QCLASS
+}
+
+// TestWithInitialQU_SetsUnicastResponseBit verifies WithInitialQU sets the QU
+// bit (top bit of QCLASS) on the outgoing question, that it is clear by
+// default, and that replies are still collected (RFC 6762 §5.4).
+func TestWithInitialQU_SetsUnicastResponseBit(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   []Option
+		wantQU bool
+	}{
+		{"default", nil, false},
+		{"enabled", []Option{WithInitialQU(true)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := transport.NewMockTransport()
+			mock.EnableBlockingReceive()
+			q, err := New(append([]Option{WithTransport(mock)}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("New() failed: %v", err)
+			}
+			defer func() { _ = q.Close() }()
+
+			// A reply unicast straight back to us
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 20}),
+					&net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 5353}, 0)
+			}()
+			ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+			defer cancel()
+			resp, err := q.Query(ctx, "printer.local", RecordTypeA)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if len(resp.Records) != 1 {
+				t.Errorf("Query() returned %d records, want 1", len(resp.Records))
+			}
+
+			calls := mock.SendCalls()
+			if len(calls) != 1 {
+				t.Fatalf("Send called %d times, want 1", len(calls))
+			}
+			qclass := sentQuestionClass(t, calls[0].Packet)
+			if gotQU := qclass&0x8000 != 0; gotQU != tt.wantQU {
+				t.Errorf("QCLASS = %#04x, QU bit = %v, want %v", qclass, gotQU, tt.wantQU)
+			}
+			if qclass&0x7FFF != uint16(protocol.ClassIN) {
+				t.Errorf("QCLASS = %#04x, want class IN", qclass)
+			}
+		})
+	}
+}
+
+// TestWithInitialQU_BrowseFirstQueryOnly verifies Browse sets the QU bit on
+// its first query and leaves its re-queries as multicast questions
+// (RFC 6762 §5.4).
+func TestWithInitialQU_BrowseFirstQueryOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping timing test in short mode")
+	}
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	q, err := New(WithTransport(mock), WithInitialQU(true))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1500*time.Millisecond)
+	defer cancel()
+	events, err := q.Browse(ctx, "_http._tcp.local")
+	if err != nil {
+		t.Fatalf("Browse() error = %v", err)
+	}
+	for range events { // Drain until ctx expires, after the 1s re-query
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) < 2 {
+		t.Fatalf("Send called %d times, want the initial query and a re-query", len(calls))
+	}
+	if qclass := sentQuestionClass(t, calls[0].Packet); qclass&0x8000 == 0 {
+		t.Errorf("initial query QCLASS = %#04x, want QU bit set", qclass)
+	}
+	if qclass := sentQuestionClass(t, calls[1].Packet); qclass&0x8000 != 0 {
+		t.Errorf("re-query QCLASS = %#04x, want QU bit clear", qclass)
+	}
+}
@@ -4,7 +4,9 @@ package querier
 import (
 	"net"
 	"strings"
+	"time"
 
+	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 )
 
@@ -15,6 +17,8 @@ import (
 //   - RecordTypePTR: Pointer records (service discovery)
 //   - RecordTypeSRV: Service records (hostname and port)
 //   - RecordTypeTXT: Text records (service metadata)
+//
+// RecordTypeAAAA (IPv6 address records, RFC 3596) resolves dual-stack hosts.
 type RecordType uint16
 
 const (
@@ -35,6 +39,11 @@ const (
 	// Example: Query("webserver._http._tcp.local", RecordTypeTXT) → ["version=1.0", "path=/"]
 	RecordTypeTXT RecordType = RecordType(protocol.RecordTypeTXT)
 
+	// RecordTypeAAAA queries for IPv6 address records (type 28, RFC 3596).
+	//
+	// Example: Query("printer.local", RecordTypeAAAA) → fe80::1
+	RecordTypeAAAA RecordType = RecordType(protocol.RecordTypeAAAA)
+
 	// RecordTypeSRV queries for service records (type 33).
 	//
 	// Used to get service hostname and port.
@@ -58,7 +67,9 @@ type Response struct {
 	//   - Answer records (direct answers to the query)
 	//   - Additional records (supplementary information, e.g., A records for SRV targets)
 	//
-	// Per FR-010, Authority records are ignored in M1.
+	// Per FR-010, Authority records are ignored in M1. A record withdrawn by a
+	// goodbye (the same record with TTL=0, RFC 6762 §10.1) during the query
+	// is removed, and goodbyes themselves are never reported.
 	Records []ResourceRecord
 
 	// Additionals contains records from the response's Additional section.
@@ -69,6 +80,73 @@ type Response struct {
 	// answer-section records of the queried type) so existing callers are
 	// unaffected; DiscoverServices consumes them to avoid extra queries.
 	Additionals []ResourceRecord
+
+	// Packets describes each valid response packet that contributed to this
+	// Response, in arrival order, so callers can inspect header flags such as
+	// AA and TC per responder.
+	Packets []PacketInfo
+
+	// goodbyes holds the TTL=0 records received, so the known-answer cache
+	// forgets them and does not merge them back (WithKnownAnswers).
+	goodbyes []ResourceRecord
+}
+
+// PacketInfo is per-packet metadata for a received mDNS response.
+type PacketInfo struct {
+	// Source is the address the packet was received from.
+	Source net.Addr
+
+	// Header is the packet's DNS header.
+	Header Header
+}
+
+// RawResponse is a received mDNS packet as returned by [Querier.QueryRaw]:
+// the bytes exactly as they arrived, unparsed and undeduplicated.
+type RawResponse struct {
+	// ReceivedAt is when the querier received the packet.
+	ReceivedAt time.Time
+
+	// Source is the address the packet was received from.
+	Source net.Addr
+
+	// Packet is the DNS message in wire format.
+	Packet []byte
+
+	// InterfaceIndex is the receiving interface, or 0 when unknown.
+	InterfaceIndex int
+}
+
+// Header is the DNS header of a received response per RFC 1035 §4.1.1.
+type Header struct {
+	// ID is the transaction ID (zero for multicast responses per RFC 6762 §18.1).
+	ID uint16
+
+	// Flags contains the raw header flags (QR, OPCODE, AA, TC, RD, RA, Z, RCODE).
+	Flags uint16
+}
+
+// IsAuthoritative reports whether the AA (Authoritative Answer) bit is set.
+//
+// RFC 6762 §18.4: Responders MUST set AA in multicast responses.
+func (h Header) IsAuthoritative() bool {
+	dh := message.DNSHeader{Flags: h.Flags}
+	return dh.IsAuthoritative()
+}
+
+// IsTruncated reports whether the TC (Truncated) bit is set.
+//
+// A truncated response carries only part of the answer; the querier
+// automatically re-asks the sender directly (see Querier.Query).
+func (h Header) IsTruncated() bool {
+	dh := message.DNSHeader{Flags: h.Flags}
+	return dh.IsTruncated()
+}
+
+// ResponseCode returns the 4-bit RCODE. Responses with a non-zero RCODE are
+// discarded (RFC 6762 §18.11), so this is zero for every collected packet.
+func (h Header) ResponseCode() uint8 {
+	dh := message.DNSHeader{Flags: h.Flags}
+	return dh.GetRCODE()
 }
 
 // ResourceRecord represents a single DNS resource record from an mDNS response.
@@ -78,13 +156,23 @@ type Response struct {
 type ResourceRecord struct {
 	// Data contains the type-specific parsed data:
 	//   - A record: net.IP (IPv4 address)
+	//   - AAAA record: net.IP (IPv6 address)
 	//   - PTR record: string (target domain name)
 	//   - SRV record: SRVData struct
 	//   - TXT record: []string (text strings)
+	//   - Any other type: nil (see RawData)
 	//
-	// Use AsA(), AsPTR(), AsSRV(), or AsTXT() for type-safe access.
+	// Use AsA(), AsAAAA(), AsPTR(), AsSRV(), or AsTXT() for type-safe access.
 	Data interface{}
 
+	// RawData holds the record's RDATA exactly as received, for record types
+	// beacon does not decode (e.g. HINFO, NSEC) and for TXT records, whose
+	// segments may carry arbitrary binary data (see AsTXTRaw). It is nil for
+	// A, AAAA, PTR and SRV records, whose parsed form is in Data. Names inside
+	// RawData may be compression pointers into the original packet
+	// (RFC 1035 §4.1.4).
+	RawData []byte
+
 	// Name is the domain name for this record (e.g., "printer.local").
 	Name string
 
@@ -93,11 +181,26 @@ type ResourceRecord struct {
 	// Per RFC 6762, TTL=0 may indicate cache flush.
 	TTL uint32
 
-	// Type is the DNS record type (A, PTR, SRV, TXT).
+	// Type is the DNS record type (A, AAAA, PTR, SRV, TXT, or an undecoded type).
 	Type RecordType
 
 	// Class is the DNS class (typically IN=1 for Internet).
 	Class uint16
+
+	// Source is the address of the responder that sent the record, e.g.
+	// 192.168.1.5:5353. It tells apart devices answering for the same name,
+	// for conflict diagnosis or spotting a responder squatting on a name.
+	// Identical records from several responders are reported once, with the
+	// first sender's address. Set by Query, QueryN, QueryInterface and
+	// FindFirst; nil for records built by other means.
+	Source net.Addr
+
+	// Zone is the IPv6 zone (interface name) a link-local AAAA record
+	// (fe80::/10) was received on, e.g. "eth0". A link-local address is only
+	// reachable through the interface it was learned on, so dial it via
+	// AsAAAAAddr rather than the bare AsAAAA. Empty for other records and
+	// when the receiving interface is unknown.
+	Zone string
 }
 
 // SRVData represents parsed SRV record data per RFC 2782.
@@ -210,10 +313,87 @@ func (r *ResourceRecord) AsTXT() []string {
 	return txt
 }
 
+// AsTXTRaw returns the character-strings of a TXT record as raw bytes, or nil
+// if r is not a TXT record.
+//
+// RFC 6763 §6 allows TXT values to be arbitrary binary data, which AsTXT's
+// strings carry but callers may mangle when treating them as text. AsTXTRaw
+// returns each length-prefixed segment exactly as received, without string
+// conversion. Each segment is a copy; modifying it does not affect r.
+//
+// Returns:
+//   - [][]byte: One entry per character-string, in wire order
+func (r *ResourceRecord) AsTXTRaw() [][]byte {
+	if r.Type != RecordTypeTXT {
+		return nil
+	}
+
+	if r.RawData == nil {
+		// Constructed rather than received: fall back to the decoded strings
+		txt, ok := r.Data.([]string)
+		if !ok {
+			return nil
+		}
+		segments := make([][]byte, len(txt))
+		for i, s := range txt {
+			segments[i] = []byte(s)
+		}
+		return segments
+	}
+
+	var segments [][]byte
+	for offset := 0; offset < len(r.RawData); {
+		length := int(r.RawData[offset])
+		offset++
+		if offset+length > len(r.RawData) {
+			return nil // Truncated; decodeRecord rejects these
+		}
+		segments = append(segments, append([]byte(nil), r.RawData[offset:offset+length]...))
+		offset += length
+	}
+	return segments
+}
+
+// AsAAAA returns the IPv6 address for an AAAA record (RFC 3596 §2.2), or nil
+// if not an AAAA record.
+//
+// Example:
+//
+//	for _, record := range response.Records {
+//	    if ip := record.AsAAAA(); ip != nil {
+//	        fmt.Printf("Found IPv6: %s\n", ip)
+//	    }
+//	}
+func (r *ResourceRecord) AsAAAA() net.IP {
+	if r.Type != RecordTypeAAAA {
+		return nil
+	}
+
+	ip, ok := r.Data.(net.IP)
+	if !ok {
+		return nil
+	}
+
+	return ip
+}
+
+// AsAAAAAddr returns the IPv6 address of an AAAA record together with its
+// Zone, or nil if this is not an AAAA record. Unlike AsAAAA, the result is
+// dialable for link-local addresses: net.IPAddr.String formats it as
+// "fe80::1%eth0".
+func (r *ResourceRecord) AsAAAAAddr() *net.IPAddr {
+	ip := r.AsAAAA()
+	if ip == nil {
+		return nil
+	}
+	return &net.IPAddr{IP: ip, Zone: r.Zone}
+}
+
 // ParseTXT parses TXT record strings into key-value pairs per RFC 6763 §6.
 //
 // TXT records contain "key=value" pairs. Keys without "=" are treated as
-// boolean flags with empty string values. Empty strings are skipped.
+// boolean flags with empty string values. Empty strings are skipped. Use
+// ParseTXTEntries when boolean flags must be told apart from empty values.
 //
 // Example:
 //
@@ -236,6 +416,66 @@ func ParseTXT(txt []string) map[string]string {
 	return result
 }
 
+// TXTEntry is a single TXT attribute per RFC 6763 §6.4.
+//
+// HasValue distinguishes the boolean form "key" (HasValue false) from the
+// empty-value form "key=" (HasValue true, Value ""), which ParseTXT collapses.
+type TXTEntry struct {
+	// Key is the attribute name (the text before the first '=').
+	Key string
+
+	// Value is the attribute value, empty for boolean attributes.
+	Value string
+
+	// HasValue reports whether the attribute contained an '=' separator.
+	HasValue bool
+}
+
+// AsTXTEntries returns the attributes of a TXT record in wire order, or nil if
+// not a TXT record.
+//
+// Unlike ParseTXT, the result preserves the RFC 6763 §6.4 distinction between
+// boolean attributes ("key") and attributes with an empty value ("key=").
+//
+// Example:
+//
+//	for _, e := range record.AsTXTEntries() {
+//	    if !e.HasValue {
+//	        fmt.Printf("flag: %s\n", e.Key)
+//	    }
+//	}
+func (r *ResourceRecord) AsTXTEntries() []TXTEntry {
+	txt := r.AsTXT()
+	if txt == nil {
+		return nil
+	}
+	return ParseTXTEntries(txt)
+}
+
+// ParseTXTEntries parses TXT record strings into attributes per RFC 6763 §6.4.
+//
+// Empty strings are skipped, as are strings beginning with '=' (no key), which
+// RFC 6763 §6.4 requires clients to silently ignore.
+//
+// Example:
+//
+//	entries := querier.ParseTXTEntries([]string{"paper", "note=", "rp=printers/1"})
+//	// entries = [{paper "" false} {note "" true} {rp "printers/1" true}]
+func ParseTXTEntries(txt []string) []TXTEntry {
+	entries := make([]TXTEntry, 0, len(txt))
+	for _, entry := range txt {
+		if entry == "" || entry[0] == '=' {
+			continue
+		}
+		if idx := strings.IndexByte(entry, '='); idx >= 0 {
+			entries = append(entries, TXTEntry{Key: entry[:idx], Value: entry[idx+1:], HasValue: true})
+		} else {
+			entries = append(entries, TXTEntry{Key: entry})
+		}
+	}
+	return entries
+}
+
 // ServiceInstance represents a fully resolved mDNS service discovered via DNS-SD.
 //
 // This is returned by [Querier.DiscoverServices] after performing the full
@@ -256,6 +496,17 @@ type ServiceInstance struct {
 	// AddrIPv4 is the IPv4 address from the A record, or nil if unresolved.
 	AddrIPv4 net.IP
 
+	// AddrIPv6 is the IPv6 address from the AAAA record, or nil if unresolved.
+	AddrIPv6 net.IP
+
+	// ZoneIPv6 is the zone (interface name) AddrIPv6 was received on when it
+	// is link-local, or empty. Dial net.IPAddr{IP: AddrIPv6, Zone: ZoneIPv6}.
+	ZoneIPv6 string
+
+	// Addrs lists the resolved addresses to connect to, chosen and ordered by
+	// the querier's address preference (WithAddressPreference).
+	Addrs []net.IP
+
 	// TXT contains parsed key-value metadata from the TXT record.
 	TXT map[string]string
 }
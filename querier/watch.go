@@ -0,0 +1,345 @@
+package querier
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// watchRefreshFractions are the points in a record's lifetime at which Watch
+// re-queries for it (RFC 6762 §5.2): "the querier should plan to issue a
+// query at 80% of the record lifetime, and then if no answer is received, at
+// 85%, 90%, and 95%".
+var watchRefreshFractions = [...]float64{0.80, 0.85, 0.90, 0.95}
+
+// watchRefreshJitter is the random variation added to each refresh query, as
+// a fraction of the record TTL (RFC 6762 §5.2: "plus a random variation of 2%
+// of the record TTL").
+const watchRefreshJitter = 0.02
+
+// watchEventBuffer is the capacity of the channel returned by Watch.
+const watchEventBuffer = 16
+
+// watchFlushDelay is how long a record replaced by a cache-flush answer, or
+// withdrawn by a goodbye, is kept before Watch reports it removed (RFC 6762
+// §10.2: "the host should mark these records to be deleted one second from
+// now"; §10.1: "record a TTL of 1 and then delete the record one second
+// later").
+const watchFlushDelay = 1 * time.Second
+
+// watchEntry is a record currently held fresh by Watch.
+type watchEntry struct {
+	record   ResourceRecord
+	received time.Time                                 // When the record last arrived
+	refresh  [len(watchRefreshFractions)]time.Duration // Refresh query offsets from received, jittered
+	sent     int                                       // Refresh queries already sent for this lifetime
+	flushAt  time.Time                                 // When a cache-flush or goodbye evicts the record (zero = not pending)
+}
+
+// newWatchEntry starts the lifetime of record, received at now.
+func newWatchEntry(record ResourceRecord, now time.Time) *watchEntry {
+	e := &watchEntry{record: record, received: now}
+	ttl := time.Duration(record.TTL) * time.Second
+	for i, fraction := range watchRefreshFractions {
+		jitter := time.Duration(rand.Float64() * watchRefreshJitter * float64(ttl)) //nolint:gosec // G404: timing jitter, not security-sensitive
+		e.refresh[i] = time.Duration(fraction*float64(ttl)) + jitter
+	}
+	return e
+}
+
+// expires returns when the record's TTL runs out.
+func (e *watchEntry) expires() time.Time {
+	return e.received.Add(time.Duration(e.record.TTL) * time.Second)
+}
+
+// evicted reports whether the record is gone at now: its TTL has run out or
+// a pending cache-flush or goodbye is due.
+func (e *watchEntry) evicted(now time.Time) bool {
+	return !now.Before(e.expires()) || (!e.flushAt.IsZero() && !now.Before(e.flushAt))
+}
+
+// next returns when the entry next needs attention: its next refresh query,
+// or its expiry once every refresh has been sent, or a pending cache-flush or
+// goodbye if that comes first.
+func (e *watchEntry) next() time.Time {
+	next := e.expires()
+	if e.sent < len(e.refresh) {
+		next = e.received.Add(e.refresh[e.sent])
+	}
+	if !e.flushAt.IsZero() && e.flushAt.Before(next) {
+		next = e.flushAt
+	}
+	return next
+}
+
+// Watch keeps the records for name and recordType resolved, reporting every
+// change on the returned channel until ctx is cancelled or the Querier is
+// closed, at which point the channel is closed.
+//
+// RFC 6762 §5.2: Watch is a continuous query that maintains its answers. A
+// record is sent on the channel when first seen and whenever its data
+// changes (a new address, say); refreshes carrying the same data are not
+// reported. Before a record expires Watch re-queries for it at 80%, 85%, 90%
+// and 95% of its TTL (each plus up to 2% random jitter); any answer restarts
+// the record's lifetime. While no record is held, the query is re-sent at
+// doubling intervals (1s, 2s, 4s, ... up to 60 minutes), as Browse does. A
+// removed record is sent once more with TTL 0: one second after a goodbye
+// (RFC 6762 §10.1) or a cache-flush answer (RFC 6762 §10.2) withdraws it
+// unless it is asserted again meanwhile, or when its TTL runs out without a
+// refresh.
+//
+// Refresh timing follows the configured clock (WithClock).
+//
+// Parameters:
+//   - ctx: Context controlling how long to watch
+//   - name: DNS name to keep resolved (e.g., "printer.local")
+//   - recordType: Type of record to keep resolved
+//
+// Returns:
+//   - <-chan ResourceRecord: Records as they appear, change, or are removed
+//     (TTL 0); closed when watching stops
+//   - error: ValidationError for invalid inputs, NetworkError if the Querier
+//     is closed or the initial query cannot be sent
+//
+// Example:
+//
+//	updates, err := q.Watch(ctx, "printer.local", querier.RecordTypeA)
+//	if err != nil {
+//	    return err
+//	}
+//	for rr := range updates {
+//	    if rr.TTL == 0 {
+//	        fmt.Printf("%s lost %v\n", rr.Name, rr.AsA())
+//	        continue
+//	    }
+//	    fmt.Printf("%s is at %v\n", rr.Name, rr.AsA())
+//	}
+func (q *Querier) Watch(ctx context.Context, name string, recordType RecordType) (<-chan ResourceRecord, error) {
+	if err := protocol.ValidateName(name); err != nil {
+		return nil, err // Already wrapped as ValidationError
+	}
+	if err := protocol.ValidateRecordType(uint16(recordType)); err != nil {
+		return nil, err // Already wrapped as ValidationError
+	}
+
+	queryMsg, err := message.BuildQuery(name, uint16(recordType))
+	if err != nil {
+		return nil, err
+	}
+
+	packets, err := q.addBrowser("watch")
+	if err != nil {
+		return nil, err
+	}
+
+	// RFC 6762 §5.4: Only the first query of the series may ask for unicast
+	// replies (WithInitialQU)
+	initialMsg := queryMsg
+	if q.initialQU {
+		initialMsg = append([]byte(nil), queryMsg...)
+		setQU(initialMsg)
+	}
+	if err := q.transport.Send(ctx, initialMsg, protocol.MulticastGroupIPv4()); err != nil {
+		q.removeBrowser(packets)
+		q.wg.Done()
+		return nil, err // Already wrapped as NetworkError
+	}
+
+	updates := make(chan ResourceRecord, watchEventBuffer)
+	go q.watchLoop(ctx, name, recordType, queryMsg, packets, updates)
+	return updates, nil
+}
+
+// watchLoop runs one Watch: it applies received answers, sends refresh
+// queries on the RFC 6762 §5.2 schedule, and removes expired records.
+func (q *Querier) watchLoop(ctx context.Context, name string, recordType RecordType, queryMsg []byte, packets chan inboundPacket, updates chan<- ResourceRecord) {
+	defer q.wg.Done()
+	defer close(updates)
+	defer q.removeBrowser(packets)
+
+	c := clock.Or(q.clock)
+	entries := make(map[string]*watchEntry)
+
+	// emit delivers an update, giving up when watching stops.
+	emit := func(rr ResourceRecord) bool {
+		select {
+		case updates <- rr:
+			return true
+		case <-ctx.Done():
+			return false
+		case <-q.ctx.Done():
+			return false
+		}
+	}
+
+	// RFC 6762 §5.2: While nothing is held the query repeats at doubling
+	// intervals; the series restarts whenever the last record goes
+	requeryInterval := browseInitialRequery
+	requeryAt := c.Now().Add(requeryInterval)
+
+	// The timer is re-armed whenever the earliest pending deadline changes
+	var timer <-chan time.Time
+	var armed time.Time
+	rearm := func() {
+		var next time.Time
+		switch {
+		case len(entries) > 0:
+			requeryAt = time.Time{}
+		case requeryAt.IsZero():
+			requeryInterval = browseInitialRequery
+			requeryAt = c.Now().Add(requeryInterval)
+			next = requeryAt
+		default:
+			next = requeryAt
+		}
+		for _, e := range entries {
+			if t := e.next(); next.IsZero() || t.Before(next) {
+				next = t
+			}
+		}
+		switch {
+		case next.IsZero():
+			timer, armed = nil, time.Time{}
+		case !next.Equal(armed):
+			timer, armed = c.After(next.Sub(c.Now())), next
+		}
+	}
+
+	rearm()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-q.ctx.Done():
+			return
+
+		case packet := <-packets:
+			for _, rr := range watchUpdates(packet.data, packet.ifIndex, name, recordType, entries, c.Now()) {
+				if !emit(rr) {
+					return
+				}
+			}
+			rearm()
+
+		case now := <-timer:
+			armed = time.Time{}
+			requery := false
+			if len(entries) == 0 && !now.Before(requeryAt) {
+				requery = true
+				requeryInterval *= 2
+				if requeryInterval > browseMaxRequery {
+					requeryInterval = browseMaxRequery
+				}
+				requeryAt = now.Add(requeryInterval)
+			}
+			for key, e := range entries {
+				if e.evicted(now) {
+					delete(entries, key)
+					gone := e.record
+					gone.TTL = 0
+					if !emit(gone) {
+						return
+					}
+					continue
+				}
+				for e.sent < len(e.refresh) && !now.Before(e.received.Add(e.refresh[e.sent])) {
+					e.sent++
+					requery = true
+				}
+			}
+			if requery {
+				// Best-effort: a failed refresh is retried at the next fraction
+				q.sendFollowUp(ctx, queryMsg, protocol.MulticastGroupIPv4())
+			}
+			rearm()
+		}
+	}
+}
+
+// watchUpdates applies the answers for name and recordType in one response,
+// received at now on interface ifIndex, to entries and returns the records to
+// report: new or changed records, and removed ones with TTL 0.
+//
+// A goodbye (TTL 0) marks its entry for eviction one second later
+// (watchFlushDelay, RFC 6762 §10.1) rather than removing it at once.
+//
+// A record is identified by its name and uncompressed RDATA. An answer with
+// the cache-flush bit set (RFC 6762 §10.2) replaces every entry received
+// more than a second before and not also asserted by the same response: the
+// entry is marked for eviction one second later (watchFlushDelay), which any
+// answer re-asserting it cancels.
+func watchUpdates(responseMsg []byte, ifIndex int, name string, recordType RecordType, entries map[string]*watchEntry, now time.Time) []ResourceRecord {
+	parsedMsg, ok := decodeResponse(responseMsg)
+	if !ok {
+		return nil
+	}
+	zone := packetZone(ifIndex)
+
+	var updates []ResourceRecord
+	asserted := make(map[string]bool)
+	flush := false
+	for _, answer := range parsedMsg.Answers {
+		if RecordType(answer.TYPE) != recordType || !strings.EqualFold(answer.NAME, name) {
+			continue
+		}
+		record, err := decodeRecord(responseMsg, answer, zone)
+		if err != nil {
+			continue
+		}
+		key, ok := watchKey(record)
+		if !ok {
+			continue
+		}
+		flush = flush || answer.CLASS&0x8000 != 0 // Cache-flush bit (RFC 6762 §10.2)
+
+		entry, known := entries[key]
+		switch {
+		case record.TTL == 0 && known:
+			if entry.flushAt.IsZero() {
+				entry.flushAt = now.Add(watchFlushDelay)
+			}
+
+		case record.TTL == 0:
+			// Goodbye for a record never seen - nothing to remove
+
+		case known:
+			asserted[key] = true
+			*entry = *newWatchEntry(record, now)
+
+		default:
+			asserted[key] = true
+			entries[key] = newWatchEntry(record, now)
+			updates = append(updates, record)
+		}
+	}
+
+	if flush {
+		for key, e := range entries {
+			if asserted[key] || !e.flushAt.IsZero() || now.Sub(e.received) < watchFlushDelay {
+				continue
+			}
+			e.flushAt = now.Add(watchFlushDelay)
+		}
+	}
+	return updates
+}
+
+// watchKey identifies a record by its lowercased name and uncompressed RDATA,
+// so repeated answers with differently compressed names compare equal.
+func watchKey(rr ResourceRecord) (string, bool) {
+	rdata, ok := encodeRDATA(rr)
+	if !ok {
+		if rr.RawData == nil {
+			return "", false
+		}
+		rdata = rr.RawData
+	}
+	return strings.ToLower(rr.Name) + "\x00" + string(rdata), true
+}
@@ -0,0 +1,276 @@
+package querier
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+)
+
+// nextUpdate waits for the next Watch update or fails the test.
+func nextUpdate(t *testing.T, updates <-chan ResourceRecord) ResourceRecord {
+	t.Helper()
+	select {
+	case rr, ok := <-updates:
+		if !ok {
+			t.Fatal("updates channel closed unexpectedly")
+		}
+		return rr
+	case <-time.After(time.Second):
+		t.Fatal("no update within 1s")
+	}
+	return ResourceRecord{}
+}
+
+// waitForSends polls until the mock has recorded want sends, or fails the test.
+func waitForSends(t *testing.T, mock *transport.MockTransport, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for len(mock.SendCalls()) < want && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := len(mock.SendCalls()); got != want {
+		t.Fatalf("sent %d queries, want %d", got, want)
+	}
+}
+
+// TestWatch_RefreshAtTTLFractions verifies Watch re-queries a record at 80%,
+// 85%, 90% and 95% of its TTL, each within the 2% jitter window (RFC 6762
+// §5.2), and reports the record removed (TTL 0) once the TTL runs out
+// without a refresh.
+func TestWatch_RefreshAtTTLFractions(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := q.Watch(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	waitForSends(t, mock, 1)
+
+	// buildValidResponsePacket answers with TTL 120s
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, []byte{192, 168, 1, 20}), nil, 0)
+	if rr := nextUpdate(t, updates); !rr.AsA().Equal(net.IPv4(192, 168, 1, 20)) || rr.TTL != 120 {
+		t.Fatalf("first update = %+v, want 192.168.1.20 with TTL 120", rr)
+	}
+
+	const ttl = 120 * time.Second
+	advanceTo := func(offset time.Duration) {
+		fake.WaitForWaiters(1)
+		fake.Advance(start.Add(offset).Sub(fake.Now()))
+	}
+	for i, fraction := range []float64{0.80, 0.85, 0.90, 0.95} {
+		due := time.Duration(fraction * float64(ttl))
+		advanceTo(due - time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		if got := len(mock.SendCalls()); got != 1+i {
+			t.Fatalf("sent %d queries before %.0f%% of TTL, want %d", got, fraction*100, 1+i)
+		}
+		advanceTo(due + time.Duration(watchRefreshJitter*float64(ttl)))
+		waitForSends(t, mock, 2+i)
+	}
+
+	advanceTo(ttl)
+	if rr := nextUpdate(t, updates); rr.TTL != 0 || !rr.AsA().Equal(net.IPv4(192, 168, 1, 20)) {
+		t.Errorf("update after expiry = %+v, want 192.168.1.20 with TTL 0", rr)
+	}
+}
+
+// TestWatch_ChangesAndGoodbye verifies a refresh with the same data is not
+// reported, a new address is, and a goodbye reports the record with TTL 0
+// one second later on the injected clock (RFC 6762 §10.1).
+func TestWatch_ChangesAndGoodbye(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := q.Watch(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	first := []byte{192, 168, 1, 20}
+	second := []byte{192, 168, 1, 21}
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, first), nil, 0)
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, first), nil, 0)
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, second), nil, 0)
+	for i, ip := range []net.IP{first, second} {
+		if rr := nextUpdate(t, updates); !rr.AsA().Equal(ip) || rr.TTL != 120 {
+			t.Errorf("update %d = %v TTL %d, want %v TTL 120", i, rr.AsA(), rr.TTL, ip)
+		}
+	}
+
+	mock.QueueReceive(buildGoodbyePacket("printer.local", protocol.RecordTypeA, first), nil, 0)
+	assertNoUpdate(t, updates, "on a goodbye")
+	fake.Advance(time.Second - time.Millisecond)
+	assertNoUpdate(t, updates, "within a second of the goodbye")
+
+	fake.Advance(time.Millisecond)
+	if rr := nextUpdate(t, updates); !rr.AsA().Equal(net.IP(first)) || rr.TTL != 0 {
+		t.Errorf("update = %v TTL %d, want %v TTL 0", rr.AsA(), rr.TTL, net.IP(first))
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("unexpected update after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Error("updates channel not closed after ctx cancel")
+	}
+}
+
+// buildFlushResponsePacket is buildValidResponsePacket with the cache-flush
+// bit set on the answer (RFC 6762 §10.2).
+func buildFlushResponsePacket(name string, rtype protocol.RecordType, rdata []byte) []byte {
+	packet := buildValidResponsePacket(name, rtype, rdata)
+	encoded, _ := message.EncodeName(name)
+	packet[12+len(encoded)+2] |= 0x80 // High bit of CLASS
+	return packet
+}
+
+// assertNoUpdate fails the test if Watch reports anything within a moment.
+func assertNoUpdate(t *testing.T, updates <-chan ResourceRecord, when string) {
+	t.Helper()
+	select {
+	case rr := <-updates:
+		t.Fatalf("update %v TTL %d %s, want none", rr.AsA(), rr.TTL, when)
+	case <-time.After(10 * time.Millisecond):
+	}
+}
+
+// TestWatch_CacheFlushEvictsAfterOneSecond verifies a record replaced by a
+// cache-flush answer is reported removed one second later, and kept if
+// asserted again within that second (RFC 6762 §10.2).
+func TestWatch_CacheFlushEvictsAfterOneSecond(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates, err := q.Watch(ctx, "printer.local", RecordTypeA)
+	if err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+
+	first := []byte{192, 168, 1, 20}
+	second := []byte{192, 168, 1, 21}
+	third := []byte{192, 168, 1, 22}
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, first), nil, 0)
+	if rr := nextUpdate(t, updates); !rr.AsA().Equal(net.IP(first)) {
+		t.Fatalf("first update = %v, want %v", rr.AsA(), net.IP(first))
+	}
+
+	// A cache-flush answer for another address marks the first for eviction
+	fake.Advance(2 * time.Second)
+	mock.QueueReceive(buildFlushResponsePacket("printer.local", protocol.RecordTypeA, second), nil, 0)
+	if rr := nextUpdate(t, updates); !rr.AsA().Equal(net.IP(second)) || rr.TTL != 120 {
+		t.Fatalf("update = %v TTL %d, want %v TTL 120", rr.AsA(), rr.TTL, net.IP(second))
+	}
+
+	// Re-asserting it within the second cancels the eviction; the third
+	// address only shows the re-assertion has been applied
+	fake.Advance(500 * time.Millisecond)
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, first), nil, 0)
+	mock.QueueReceive(buildValidResponsePacket("printer.local", protocol.RecordTypeA, third), nil, 0)
+	if rr := nextUpdate(t, updates); !rr.AsA().Equal(net.IP(third)) {
+		t.Fatalf("update = %v, want %v", rr.AsA(), net.IP(third))
+	}
+	fake.Advance(time.Second)
+	assertNoUpdate(t, updates, "after a re-asserted record's flush deadline")
+
+	// A second flush evicts the first and third addresses a second later
+	mock.QueueReceive(buildFlushResponsePacket("printer.local", protocol.RecordTypeA, second), nil, 0)
+	assertNoUpdate(t, updates, "on a cache-flush answer")
+	fake.Advance(time.Second - time.Millisecond)
+	assertNoUpdate(t, updates, "before the one-second flush delay")
+
+	fake.Advance(time.Millisecond)
+	removed := map[string]bool{}
+	for range 2 {
+		rr := nextUpdate(t, updates)
+		if rr.TTL != 0 {
+			t.Fatalf("update = %v TTL %d, want a removal", rr.AsA(), rr.TTL)
+		}
+		removed[rr.AsA().String()] = true
+	}
+	if !removed[net.IP(first).String()] || !removed[net.IP(third).String()] {
+		t.Errorf("removed %v, want %v and %v", removed, net.IP(first), net.IP(third))
+	}
+}
+
+// TestWatch_RequeryWhileEmpty verifies Watch re-sends its query 1s, 2s and
+// 4s apart while no record answers it (RFC 6762 §5.2).
+func TestWatch_RequeryWhileEmpty(t *testing.T) {
+	fake := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+
+	q, err := New(WithTransport(mock), WithRateLimit(false), WithClock(fake))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := q.Watch(ctx, "printer.local", RecordTypeA); err != nil {
+		t.Fatalf("Watch() error = %v", err)
+	}
+	waitForSends(t, mock, 1)
+
+	for i, gap := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second} {
+		fake.WaitForWaiters(1)
+		fake.Advance(gap - time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		if got := len(mock.SendCalls()); got != 1+i {
+			t.Fatalf("sent %d queries before the %v re-query, want %d", got, gap, 1+i)
+		}
+		fake.Advance(time.Millisecond)
+		waitForSends(t, mock, 2+i)
+	}
+}
+
+// TestWatch_InvalidName verifies Watch validates its name.
+func TestWatch_InvalidName(t *testing.T) {
+	q, err := New(WithTransport(transport.NewMockTransport()))
+	if err != nil {
+		t.Fatalf("New() failed: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	if _, err := q.Watch(context.Background(), "", RecordTypeA); err == nil {
+		t.Error("Watch(\"\") error = nil, want ValidationError")
+	}
+}
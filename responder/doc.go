@@ -62,6 +62,10 @@
 //	    }
 //	}()
 //
+// To register many services at startup, RegisterAll probes and announces them
+// concurrently, taking about one 1.75s cycle in total rather than one per
+// service.
+//
 // # Conflict Resolution
 //
 // If another device on the network already claims the same name, the responder
@@ -69,6 +73,17 @@
 //
 //   - "My Service" → "My Service-2" → "My Service-3" (up to 10 attempts)
 //
+// Applications that must keep an exact name can opt out with WithNoRename:
+// Register then fails with ErrNameConflict instead.
+//
+// With WithConflictHostRename, the host itself is renamed when another device
+// claims its hostname ("myhost.local" → "myhost-2.local"), and services are
+// re-announced with the new SRV target.
+//
+// To follow registration progress, conflicts and renames programmatically
+// (metrics, UIs), install an Observer with WithObserver; it receives an Event
+// for each probe, announcement and goodbye sent.
+//
 // # Interface-Specific Addressing
 //
 // On multi-interface hosts (e.g., WiFi + Ethernet), the responder detects which
@@ -1,11 +1,22 @@
 package responder
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
+	internalresponder "github.com/joshuafuller/beacon/internal/responder"
+	"github.com/joshuafuller/beacon/internal/transport"
 )
 
 // =============================================================================
@@ -250,10 +261,239 @@ func TestHandleQuery_ServiceTypeNoMatch(t *testing.T) {
 	}
 }
 
+// TestHandleQuery_PTRQueryReturnsAllInstances tests that a PTR query for a type
+// with several registered instances yields one response listing every instance.
+//
+// RFC 6762 §6: The response must contain all records the responder is
+// authoritative for, not just the first matching service.
+func TestHandleQuery_PTRQueryReturnsAllInstances(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+	}
+
+	for _, name := range []string{"Printer A", "Printer B", "Printer C"} {
+		svc := &Service{InstanceName: name, ServiceType: "_ipp._tcp.local", Port: 631}
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	packet := buildDNSQuery("_ipp._tcp.local", uint16(protocol.RecordTypePTR))
+	if err := r.handleQuery(packet, nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1 aggregated response", len(sent))
+	}
+
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+
+	targets := map[string]bool{}
+	for _, answer := range resp.Answers {
+		if answer.TYPE != uint16(protocol.RecordTypePTR) {
+			t.Errorf("answer type = %d, want PTR", answer.TYPE)
+			continue
+		}
+		target, err := message.ParseRDATAInMessage(answer.TYPE, sent[0], answer.RDATAOffset, int(answer.RDLENGTH))
+		if err != nil {
+			t.Fatalf("ParseRDATAInMessage() error = %v", err)
+		}
+		targets[target.(string)] = true
+	}
+	if len(resp.Answers) != 3 || len(targets) != 3 {
+		t.Errorf("got %d PTR answers (%v), want 3 distinct instances", len(resp.Answers), targets)
+	}
+}
+
+// TestHandleQuery_MultipleQuestionsAggregated tests that each question in a
+// multi-question query is answered within a single response.
+func TestHandleQuery_MultipleQuestionsAggregated(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+	}
+
+	for _, svc := range []*Service{
+		{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80},
+		{InstanceName: "Shell", ServiceType: "_ssh._tcp.local", Port: 22},
+	} {
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+		}
+	}
+
+	// Two questions: PTR for each service type
+	query := &message.DNSMessage{
+		Questions: []message.Question{
+			{QNAME: "_http._tcp.local", QTYPE: uint16(protocol.RecordTypePTR), QCLASS: uint16(protocol.ClassIN)},
+			{QNAME: "_ssh._tcp.local", QTYPE: uint16(protocol.RecordTypePTR), QCLASS: uint16(protocol.ClassIN)},
+		},
+	}
+	query.Header.QDCount = 2
+	packet, err := message.SerializeMessage(query)
+	if err != nil {
+		t.Fatalf("SerializeMessage() error = %v", err)
+	}
+
+	if err := r.handleQuery(packet, nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1 aggregated response", len(sent))
+	}
+
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	names := map[string]bool{}
+	for _, answer := range resp.Answers {
+		names[answer.NAME] = true
+	}
+	if !names["_http._tcp.local"] || !names["_ssh._tcp.local"] {
+		t.Errorf("answer names = %v, want both service types answered", names)
+	}
+}
+
+// TestHandleQuery_ServiceHostnameOverride tests that a service's Hostname
+// replaces the responder hostname as its SRV target and A record name.
+func TestHandleQuery_ServiceHostnameOverride(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+
+	svc := &Service{InstanceName: "Fronted", ServiceType: "_http._tcp.local", Port: 80, Hostname: "alias.local"}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	// A first: the SRV response carries the A record as an additional, and the
+	// RFC 6762 §6.2 one-second rule would suppress an immediate A answer.
+	if err := r.handleQuery(buildDNSQuery("alias.local", uint16(protocol.RecordTypeA)), nil, 0); err != nil {
+		t.Fatalf("handleQuery(A) error = %v", err)
+	}
+	if err := r.handleQuery(buildDNSQuery("Fronted._http._tcp.local", uint16(protocol.RecordTypeSRV)), nil, 0); err != nil {
+		t.Fatalf("handleQuery(SRV) error = %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("sent %d responses, want 2", len(sent))
+	}
+
+	resp, err := message.ParseMessage(sent[1])
+	if err != nil {
+		t.Fatalf("ParseMessage(SRV response) error = %v", err)
+	}
+	if len(resp.Answers) == 0 || resp.Answers[0].TYPE != uint16(protocol.RecordTypeSRV) {
+		t.Fatalf("SRV response answers = %+v, want SRV", resp.Answers)
+	}
+	srv, err := message.ParseRDATAInMessage(resp.Answers[0].TYPE, sent[1], resp.Answers[0].RDATAOffset, int(resp.Answers[0].RDLENGTH))
+	if err != nil {
+		t.Fatalf("ParseRDATAInMessage(SRV) error = %v", err)
+	}
+	if target := srv.(message.SRVData).Target; target != "alias.local" {
+		t.Errorf("SRV target = %q, want %q", target, "alias.local")
+	}
+
+	resp, err = message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(A response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].NAME != "alias.local" {
+		t.Errorf("A response answers = %+v, want one A record for alias.local", resp.Answers)
+	}
+}
+
 // =============================================================================
 // Helper Functions
 // =============================================================================
 
+// TestHandleQuery_RespondsOnReceivingInterface verifies the response to a
+// multicast query is sent to 224.0.0.251:5353 out the interface the query
+// arrived on (RFC 6762 §15), not the default multicast interface.
+func TestHandleQuery_RespondsOnReceivingInterface(t *testing.T) {
+	// validateSourceAddress and resolveResponseIPv4 consult the real
+	// interface, so use one with an IPv4 address and query from its subnet.
+	var ifIndex int
+	var srcIP net.IP
+	ifaces, _ := net.Interfaces()
+	for _, iface := range ifaces {
+		addrs, _ := iface.Addrs()
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				ifIndex, srcIP = iface.Index, ipnet.IP.To4()
+				break
+			}
+		}
+		if ifIndex != 0 {
+			break
+		}
+	}
+	if ifIndex == 0 {
+		t.Skip("no interface with an IPv4 address")
+	}
+
+	mock := transport.NewMockTransport()
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       mock,
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+	}
+	svc := &Service{InstanceName: "Iface", ServiceType: "_http._tcp.local", Port: 80}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	src := &net.UDPAddr{IP: srcIP, Port: 5353}
+	if err := r.handleQuery(buildDNSQuery("_http._tcp.local", uint16(protocol.RecordTypePTR)), src, ifIndex); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(calls))
+	}
+	if calls[0].IfIndex != ifIndex {
+		t.Errorf("response sent on interface %d, want %d (the query's)", calls[0].IfIndex, ifIndex)
+	}
+	if calls[0].Dest == nil || calls[0].Dest.String() != protocol.MulticastGroupIPv4().String() {
+		t.Errorf("response dest = %v, want %v", calls[0].Dest, protocol.MulticastGroupIPv4())
+	}
+}
+
 // buildDNSQuery constructs a minimal DNS query packet for testing.
 //
 // Packet structure:
@@ -340,3 +580,817 @@ func encodeDomainName(name string) []byte {
 
 	return encoded
 }
+
+// TestAnswerQuestion_PTRIncludesAdditionals verifies AnswerQuestion returns
+// the PTR answer followed by the SRV, TXT and A additionals a PTR query for a
+// registered instance would receive (RFC 6763 §12).
+func TestAnswerQuestion_PTRIncludesAdditionals(t *testing.T) {
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       &MockTransport{},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "myhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080, TXTRecords: map[string]string{"path": "/"}}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	rrs, err := r.AnswerQuestion("_http._tcp.local", RecordTypePTR)
+	if err != nil {
+		t.Fatalf("AnswerQuestion() error = %v", err)
+	}
+
+	wantTypes := []RecordType{RecordTypePTR, RecordTypeSRV, RecordTypeTXT, RecordTypeA}
+	if len(rrs) != len(wantTypes) {
+		t.Fatalf("AnswerQuestion() returned %d records, want %d (PTR+SRV+TXT+A)", len(rrs), len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if rrs[i].Type != want {
+			t.Errorf("record %d type = %s, want %s", i, rrs[i].Type, want)
+		}
+	}
+	if rrs[0].Name != "_http._tcp.local" || rrs[0].CacheFlush {
+		t.Errorf("PTR = %s cache-flush=%v, want shared record for _http._tcp.local", rrs[0].Name, rrs[0].CacheFlush)
+	}
+	if a := rrs[3]; a.Name != "myhost.local" || !net.IP(a.Data).Equal(net.IPv4(192, 168, 1, 10)) || !a.CacheFlush {
+		t.Errorf("A = %s %v cache-flush=%v, want unique myhost.local 192.168.1.10", a.Name, a.Data, a.CacheFlush)
+	}
+}
+
+// TestAnswerQuestion_UnknownName verifies a question nothing matches yields
+// no records and no error, and an invalid name is a ValidationError.
+func TestAnswerQuestion_UnknownName(t *testing.T) {
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       &MockTransport{},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "myhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	rrs, err := r.AnswerQuestion("_ipp._tcp.local", RecordTypePTR)
+	if err != nil || len(rrs) != 0 {
+		t.Errorf("AnswerQuestion(unknown) = %d records, err %v; want none, nil", len(rrs), err)
+	}
+
+	if _, err := r.AnswerQuestion("", RecordTypePTR); err == nil {
+		t.Error("AnswerQuestion(\"\") error = nil, want ValidationError")
+	}
+}
+
+// TestHandleQuery_MaxResponseSizeTruncates verifies a response that would
+// exceed WithMaxResponseSize keeps every answer and drops additional records
+// to fit (RFC 6762 §17), setting TC only on a legacy unicast response
+// (§18.5).
+func TestHandleQuery_MaxResponseSizeTruncates(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+	}
+	if err := WithMaxResponseSize(512)(r); err != nil {
+		t.Fatalf("WithMaxResponseSize(512) error = %v", err)
+	}
+
+	// Three instances with ~200-byte TXT records: well over 512 bytes in full
+	for _, name := range []string{"Printer A", "Printer B", "Printer C"} {
+		svc := &Service{
+			InstanceName: name,
+			ServiceType:  "_ipp._tcp.local",
+			Port:         631,
+			TXTRecords:   map[string]string{"note": strings.Repeat("x", 200)},
+		}
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	if err := r.handleQuery(buildDNSQuery("_ipp._tcp.local", uint16(protocol.RecordTypePTR)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(sent))
+	}
+
+	if len(sent[0]) > 512 {
+		t.Errorf("response is %d bytes, want ≤ 512", len(sent[0]))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if resp.Header.IsTruncated() {
+		t.Error("TC bit set on trimmed multicast response, want clear (RFC 6762 §18.5)")
+	}
+	if len(resp.Answers) != 3 {
+		t.Errorf("response has %d answers, want all 3 PTRs kept", len(resp.Answers))
+	}
+	// Full response: SRV, TXT and A for each instance
+	if len(resp.Additionals) == 0 || len(resp.Additionals) >= 9 {
+		t.Errorf("response has %d additionals, want some but not all 9", len(resp.Additionals))
+	}
+	if len(resp.Additionals) > 0 && resp.Additionals[0].TYPE != uint16(protocol.RecordTypeSRV) {
+		t.Errorf("first additional type = %d, want SRV kept by priority", resp.Additionals[0].TYPE)
+	}
+
+	// The same response to a legacy resolver (RFC 6762 §6.7) is marked truncated
+	sent = nil
+	legacySrc := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 40000}
+	if err := r.handleQuery(buildDNSQuery("_ipp._tcp.local", uint16(protocol.RecordTypePTR)), legacySrc, 0); err != nil {
+		t.Fatalf("handleQuery(legacy) error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d legacy responses, want 1", len(sent))
+	}
+	legacy, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(legacy response) error = %v", err)
+	}
+	if !legacy.Header.IsTruncated() {
+		t.Error("TC bit not set on trimmed legacy unicast response")
+	}
+}
+
+// TestHandleQuery_NegativeResponseNSEC verifies a query for a type that one
+// of our names lacks is answered with an NSEC whose type bitmap lists the
+// types that do exist (RFC 6762 §6.1), that names match case-insensitively,
+// and that other names stay silent.
+func TestHandleQuery_NegativeResponseNSEC(t *testing.T) {
+	var sent [][]byte
+	newResponder := func(t *testing.T) *Responder {
+		t.Helper()
+		r := &Responder{
+			ctx: context.Background(),
+			transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+				sent = append(sent, packet)
+				return nil
+			}},
+			registry:        internalresponder.NewRegistry(),
+			hostname:        "test.local",
+			responseBuilder: internalresponder.NewResponseBuilder(),
+			recordSet:       records.NewRecordSet(),
+		}
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Printer", ServiceType: "_ipp._tcp.local", Port: 631}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name       string
+		qname      string
+		owner      string // Name the NSEC is for
+		wantBitmap []byte // Window 0, length, bitmap
+	}{
+		// Instance name: TXT (16) and SRV (33)
+		{"instance", "Printer._ipp._tcp.local", "Printer._ipp._tcp.local", []byte{0x00, 0x05, 0x00, 0x00, 0x80, 0x00, 0x40}},
+		// Hostname: A (1) only, so no AAAA
+		{"hostname", "test.local", "test.local", []byte{0x00, 0x01, 0x40}},
+		{"instance mixed case", "PRINTER._IPP._tcp.local", "Printer._ipp._tcp.local", []byte{0x00, 0x05, 0x00, 0x00, 0x80, 0x00, 0x40}},
+		{"hostname mixed case", "Test.LOCAL", "test.local", []byte{0x00, 0x01, 0x40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A fresh responder per case, clear of the per-record rate limit
+			r := newResponder(t)
+			sent = nil
+			if err := r.handleQuery(buildDNSQuery(tt.qname, uint16(protocol.RecordTypeAAAA)), nil, 0); err != nil {
+				t.Fatalf("handleQuery() error = %v", err)
+			}
+			if len(sent) != 1 {
+				t.Fatalf("sent %d responses, want 1 NSEC response", len(sent))
+			}
+
+			resp, err := message.ParseMessage(sent[0])
+			if err != nil {
+				t.Fatalf("ParseMessage(response) error = %v", err)
+			}
+			if len(resp.Answers) != 1 {
+				t.Fatalf("response has %d answers, want 1 NSEC", len(resp.Answers))
+			}
+			nsec := resp.Answers[0]
+			if nsec.TYPE != uint16(protocol.RecordTypeNSEC) || nsec.NAME != tt.owner || nsec.CLASS&0x8000 == 0 {
+				t.Errorf("answer = %s type %d class 0x%04x, want cache-flush NSEC for %s", nsec.NAME, nsec.TYPE, nsec.CLASS, tt.owner)
+			}
+
+			// RFC 6762 §6.1: Next Domain Name is the record's own name
+			next, offset, err := message.ParseName(nsec.RDATA, 0)
+			if err != nil || next != tt.owner {
+				t.Errorf("next domain name = %q (err %v), want %q", next, err, tt.owner)
+			}
+			if bitmap := nsec.RDATA[offset:]; !bytes.Equal(bitmap, tt.wantBitmap) {
+				t.Errorf("type bitmap = % x, want % x", bitmap, tt.wantBitmap)
+			}
+		})
+	}
+
+	// Names we don't own get nothing
+	r := newResponder(t)
+	sent = nil
+	if err := r.handleQuery(buildDNSQuery("Other._ipp._tcp.local", uint16(protocol.RecordTypeAAAA)), nil, 0); err != nil {
+		t.Fatalf("handleQuery(other) error = %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %d responses for a name we don't own, want 0", len(sent))
+	}
+}
+
+// vendorTXTBuilder is a ResponseBuilder adding a vendor TXT key to every
+// service before delegating to the default builder.
+type vendorTXTBuilder struct{}
+
+func (vendorTXTBuilder) BuildResponse(service *ServiceWithIP, query *DNSMessage) (*DNSMessage, error) {
+	svc := *service
+	svc.TXTRecords = map[string]string{"vendor": "acme"}
+	for k, v := range service.TXTRecords {
+		svc.TXTRecords[k] = v
+	}
+	return DefaultResponseBuilder().BuildResponse(&svc, query)
+}
+
+// TestHandleQuery_CustomResponseBuilder verifies a WithResponseBuilder builder
+// shapes the service records sent in response to a query.
+func TestHandleQuery_CustomResponseBuilder(t *testing.T) {
+	var sent [][]byte
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("test.local"),
+		WithResponseBuilder(vendorTXTBuilder{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080, TXTRecords: map[string]string{"path": "/"}}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	if err := r.handleQuery(buildDNSQuery("Web._http._tcp.local", uint16(protocol.RecordTypeTXT)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(sent))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].TYPE != uint16(protocol.RecordTypeTXT) {
+		t.Fatalf("answers = %+v, want one TXT record", resp.Answers)
+	}
+	rdata := resp.Answers[0].RDATA
+	if !bytes.Contains(rdata, []byte("vendor=acme")) || !bytes.Contains(rdata, []byte("path=/")) {
+		t.Errorf("TXT RDATA = %q, want both vendor=acme and path=/", rdata)
+	}
+}
+
+// TestWithResponseBuilder_Nil verifies a nil builder is rejected.
+func TestWithResponseBuilder_Nil(t *testing.T) {
+	r, err := New(context.Background(), WithTransport(&MockTransport{}), WithResponseBuilder(nil))
+	if err == nil {
+		_ = r.Close()
+		t.Fatal("New(WithResponseBuilder(nil)) error = nil, want ValidationError")
+	}
+}
+
+// TestHandleQuery_DottedInstanceName verifies a service whose instance label
+// contains dots and hyphens is found by its full name, in GetService and when
+// answering an SRV query (RFC 6763 §4.3).
+func TestHandleQuery_DottedInstanceName(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	svc := &Service{InstanceName: "Brother MFC-L2750DW v1.2", ServiceType: "_ipp._tcp.local", Port: 631}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	fullName := "Brother MFC-L2750DW v1.2._ipp._tcp.local"
+	if got, ok := r.GetService(fullName); !ok || got.InstanceName != svc.InstanceName {
+		t.Errorf("GetService(%q) = %v, %v; want the registered service", fullName, got, ok)
+	}
+	if _, ok := r.GetService("Brother MFC-L2750DW v1.2._http._tcp.local"); ok {
+		t.Error("GetService() with another service type found the service, want not found")
+	}
+
+	if err := r.handleQuery(buildDNSQuery(fullName, uint16(protocol.RecordTypeSRV)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(sent))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].TYPE != uint16(protocol.RecordTypeSRV) || resp.Answers[0].NAME != fullName {
+		t.Errorf("answers = %+v, want the SRV record of %q", resp.Answers, fullName)
+	}
+
+	// The instance label matches case-insensitively too (RFC 1035 §2.3.3)
+	sent = nil
+	if err := r.handleQuery(buildDNSQuery("BROTHER mfc-l2750dw V1.2._IPP._tcp.local", uint16(protocol.RecordTypeTXT)), nil, 0); err != nil {
+		t.Fatalf("handleQuery(mixed case) error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses to a mixed-case query, want 1", len(sent))
+	}
+	resp, err = message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 || resp.Answers[0].TYPE != uint16(protocol.RecordTypeTXT) || resp.Answers[0].NAME != fullName {
+		t.Errorf("answers = %+v, want the TXT record of %q", resp.Answers, fullName)
+	}
+}
+
+// TestHandleQuery_SubtypeBrowse verifies a subtype browse (RFC 6763 §7.1)
+// is answered with only the instances registered with that subtype, under
+// the subtype name.
+func TestHandleQuery_SubtypeBrowse(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	for _, svc := range []*Service{
+		{InstanceName: "Printer UI", ServiceType: "_http._tcp.local", Port: 80, Subtypes: []string{"_printer"}},
+		{InstanceName: "Wiki", ServiceType: "_http._tcp.local", Port: 80},
+	} {
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", svc.InstanceName, err)
+		}
+	}
+
+	const subtypeName = "_printer._sub._http._tcp.local"
+	if err := r.handleQuery(buildDNSQuery(subtypeName, uint16(protocol.RecordTypePTR)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(sent))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 {
+		t.Fatalf("got %d answers, want 1 (only the _printer instance)", len(resp.Answers))
+	}
+	answer := resp.Answers[0]
+	target, err := message.ParseRDATAInMessage(answer.TYPE, sent[0], answer.RDATAOffset, int(answer.RDLENGTH))
+	if err != nil {
+		t.Fatalf("ParseRDATAInMessage() error = %v", err)
+	}
+	if answer.TYPE != uint16(protocol.RecordTypePTR) || answer.NAME != subtypeName || target != "Printer UI._http._tcp.local" {
+		t.Errorf("answer = %s PTR(%d) %v, want %s PTR Printer UI._http._tcp.local", answer.NAME, answer.TYPE, target, subtypeName)
+	}
+
+	// Unknown subtype: nothing to answer
+	sent = nil
+	if err := r.handleQuery(buildDNSQuery("_scanner._sub._http._tcp.local", uint16(protocol.RecordTypePTR)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 0 {
+		t.Errorf("sent %d responses to an unknown subtype, want 0", len(sent))
+	}
+}
+
+// TestServiceTypeAnswers_InvalidatedOnRegistryChange verifies the cached
+// service type enumeration (RFC 6763 §9) is reused while the registry is
+// unchanged and rebuilt when a service type is registered or unregistered,
+// and that the meta-query name matches case-insensitively (RFC 1035 §2.3.3).
+func TestServiceTypeAnswers_InvalidatedOnRegistryChange(t *testing.T) {
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       &MockTransport{},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "test.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	enumerate := func() int {
+		t.Helper()
+		rrs, err := r.AnswerQuestion(serviceEnumerationName, RecordTypePTR)
+		if err != nil {
+			t.Fatalf("AnswerQuestion() error = %v", err)
+		}
+		return len(rrs)
+	}
+
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	if got := enumerate(); got != 1 {
+		t.Fatalf("service types = %d, want 1", got)
+	}
+	if rrs, err := r.AnswerQuestion("_Services._DNS-SD._udp.local", RecordTypePTR); err != nil || len(rrs) != 1 {
+		t.Errorf("AnswerQuestion(mixed-case meta-query) = %d records (err %v), want 1", len(rrs), err)
+	}
+	cached := r.serviceTypes
+	if enumerate(); r.serviceTypes != cached {
+		t.Error("service type answers rebuilt with the registry unchanged, want cached")
+	}
+
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Shell", ServiceType: "_ssh._tcp.local", Port: 22}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	if got := enumerate(); got != 2 {
+		t.Errorf("service types after registering _ssh._tcp = %d, want 2", got)
+	}
+
+	if err := r.Unregister("Shell"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if got := enumerate(); got != 1 {
+		t.Errorf("service types after unregistering _ssh._tcp = %d, want 1", got)
+	}
+}
+
+// BenchmarkServiceTypeAnswers compares answering a service type enumeration
+// from the cache with rebuilding the PTR records for every query.
+func BenchmarkServiceTypeAnswers(b *testing.B) {
+	r := &Responder{registry: internalresponder.NewRegistry()}
+	for i := 0; i < 20; i++ {
+		svc := &internalresponder.Service{InstanceName: fmt.Sprintf("Service %d", i), ServiceType: fmt.Sprintf("_svc%d._tcp.local", i), Port: 80}
+		if err := r.registry.Register(svc); err != nil {
+			b.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r.addServiceTypeAnswers(&message.DNSMessage{})
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			response := &message.DNSMessage{}
+			response.Answers = append(response.Answers, buildServiceTypeAnswers(r.registry.ListServiceTypes())...)
+		}
+	})
+}
+
+// namedInterfaceResolver is a StaticInterfaceResolver whose interfaces carry
+// the given names.
+type namedInterfaceResolver struct {
+	StaticInterfaceResolver
+	names map[int]string
+}
+
+func (n namedInterfaceResolver) Interfaces() ([]net.Interface, error) {
+	ifaces, err := n.StaticInterfaceResolver.Interfaces()
+	for i := range ifaces {
+		ifaces[i].Name = n.names[ifaces[i].Index]
+	}
+	return ifaces, err
+}
+
+// TestHandleQuery_InterfaceFilter verifies WithInterfaceFilter: a query
+// arriving on a filtered-out interface gets no response, while one on an
+// accepted interface is answered with that interface's address.
+func TestHandleQuery_InterfaceFilter(t *testing.T) {
+	var sent [][]byte
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("test.local"),
+		WithInterfaceResolver(namedInterfaceResolver{
+			StaticInterfaceResolver: StaticInterfaceResolver{1: "10.0.1.10/24", 2: "10.0.2.10/24"},
+			names:                   map[int]string{1: "eth0", 2: "guest0"},
+		}),
+		WithInterfaceFilter(func(iface net.Interface) bool { return iface.Name != "guest0" }))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	query := buildDNSQuery("test.local", uint16(protocol.RecordTypeA))
+	guestSrc := &net.UDPAddr{IP: net.IPv4(10, 0, 2, 50), Port: 5353}
+	if err := r.handleQuery(query, guestSrc, 2); err != nil {
+		t.Fatalf("handleQuery(guest0) error = %v", err)
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent %d responses to a query on filtered-out guest0, want 0", len(sent))
+	}
+
+	lanSrc := &net.UDPAddr{IP: net.IPv4(10, 0, 1, 50), Port: 5353}
+	if err := r.handleQuery(query, lanSrc, 1); err != nil {
+		t.Fatalf("handleQuery(eth0) error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses to a query on eth0, want 1", len(sent))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 || !net.IP(resp.Answers[0].RDATA).Equal(net.IPv4(10, 0, 1, 10)) {
+		t.Errorf("answers = %+v, want one A record for 10.0.1.10", resp.Answers)
+	}
+}
+
+// TestHandleQuery_AllInterfaceAddresses verifies a response advertises every
+// address valid on the receiving interface, an alias included, and none from
+// another interface (RFC 6762 §15).
+func TestHandleQuery_AllInterfaceAddresses(t *testing.T) {
+	var sent [][]byte
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("test.local"),
+		WithInterfaceResolver(StaticInterfaceResolver{
+			1: "10.0.1.10/24,10.0.1.11/24",
+			2: "10.0.2.10/24",
+		}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	src := &net.UDPAddr{IP: net.IPv4(10, 0, 1, 50), Port: 5353}
+	if err := r.handleQuery(buildDNSQuery("test.local", uint16(protocol.RecordTypeA)), src, 1); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses, want 1", len(sent))
+	}
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+
+	var addrs []string
+	for _, rr := range resp.Answers {
+		if rr.TYPE == uint16(protocol.RecordTypeA) {
+			addrs = append(addrs, net.IP(rr.RDATA).String())
+		}
+	}
+	want := []string{"10.0.1.10", "10.0.1.11"}
+	if strings.Join(addrs, ",") != strings.Join(want, ",") {
+		t.Errorf("A records = %v, want %v (both interface 1 addresses, not 10.0.2.10)", addrs, want)
+	}
+}
+
+// TestValidateSourceAddress_Loopback verifies a query arriving on the
+// loopback interface is accepted whatever host address it was sent from only
+// with WithLoopbackAdvertise, while other interfaces still require an
+// on-subnet source (RFC 6762 §6.4).
+func TestValidateSourceAddress_Loopback(t *testing.T) {
+	resolver := StaticInterfaceResolver{1: "127.0.0.1/8", 2: "10.0.1.10/24"}
+	hostAddr := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 20), Port: 5353}
+
+	if !validateSourceAddress(resolver, hostAddr, 1, true) {
+		t.Error("validateSourceAddress(host address on loopback, loopback on) = false, want true")
+	}
+	if validateSourceAddress(resolver, hostAddr, 1, false) {
+		t.Error("validateSourceAddress(host address on loopback, loopback off) = true, want false")
+	}
+	if validateSourceAddress(resolver, hostAddr, 2, true) {
+		t.Error("validateSourceAddress(off-subnet address on interface 2) = true, want false")
+	}
+}
+
+// TestHandleQuery_LegacyUnicastEchoesQuestion verifies a query from a source
+// port other than 5353 gets a conventional unicast DNS response sent back to
+// that port, repeating the query ID and question, without the cache-flush bit
+// and with TTLs of at most ten seconds (RFC 6762 §6.7), while a query from
+// port 5353 gets a multicast response with no question section (QDCOUNT=0).
+func TestHandleQuery_LegacyUnicastEchoesQuestion(t *testing.T) {
+	type sendCall struct {
+		packet []byte
+		dest   net.Addr
+	}
+	var sent []sendCall
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, dest net.Addr) error {
+			sent = append(sent, sendCall{packet, dest})
+			return nil
+		}}),
+		WithHostname("test.local"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	query := buildDNSQuery("test.local", uint16(protocol.RecordTypeA))
+	binary.BigEndian.PutUint16(query[0:2], 0x1234) // Query ID
+
+	legacySrc := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 54321}
+	if err := r.handleQuery(query, legacySrc, 0); err != nil {
+		t.Fatalf("handleQuery(legacy) error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses to a legacy query, want 1", len(sent))
+	}
+	if sent[0].dest.String() != legacySrc.String() {
+		t.Errorf("legacy response sent to %v, want unicast to %v", sent[0].dest, legacySrc)
+	}
+	resp, err := message.ParseMessage(sent[0].packet)
+	if err != nil {
+		t.Fatalf("ParseMessage(legacy response) error = %v", err)
+	}
+	if resp.Header.ID != 0x1234 {
+		t.Errorf("legacy response ID = %#x, want the query's 0x1234", resp.Header.ID)
+	}
+	want := message.Question{QNAME: "test.local", QTYPE: uint16(protocol.RecordTypeA), QCLASS: uint16(protocol.ClassIN)}
+	if resp.Header.QDCount != 1 || len(resp.Questions) != 1 || resp.Questions[0] != want {
+		t.Errorf("legacy response questions = %+v (QDCOUNT %d), want exactly %+v", resp.Questions, resp.Header.QDCount, want)
+	}
+	if len(resp.Answers) != 1 {
+		t.Fatalf("legacy response has %d answers, want 1", len(resp.Answers))
+	}
+	if a := resp.Answers[0]; a.CLASS&0x8000 != 0 || a.TTL > protocol.LegacyUnicastTTLMax {
+		t.Errorf("legacy answer CLASS %#x TTL %d, want no cache-flush bit and TTL <= %d", a.CLASS, a.TTL, protocol.LegacyUnicastTTLMax)
+	}
+
+	mdnsSrc := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+	if err := r.handleQuery(query, mdnsSrc, 0); err != nil {
+		t.Fatalf("handleQuery(mDNS) error = %v", err)
+	}
+	if len(sent) != 2 {
+		t.Fatalf("sent %d responses in total, want 2", len(sent))
+	}
+	if sent[1].dest.String() != protocol.MulticastGroupIPv4().String() {
+		t.Errorf("mDNS response sent to %v, want the multicast group", sent[1].dest)
+	}
+	resp, err = message.ParseMessage(sent[1].packet)
+	if err != nil {
+		t.Fatalf("ParseMessage(multicast response) error = %v", err)
+	}
+	if resp.Header.QDCount != 0 || len(resp.Questions) != 0 {
+		t.Errorf("multicast response questions = %+v (QDCOUNT %d), want none", resp.Questions, resp.Header.QDCount)
+	}
+}
+
+// TestHandleQuery_MessageID verifies the RFC 6762 §18.1 ID rules: a unicast
+// (QU) response echoes the query's ID, while a multicast response and the
+// responder's own probes and announcements carry ID 0.
+func TestHandleQuery_MessageID(t *testing.T) {
+	var mu sync.Mutex
+	var sent [][]byte
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("test.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	sentIDs := func() []uint16 {
+		mu.Lock()
+		defer mu.Unlock()
+		ids := make([]uint16, 0, len(sent))
+		for _, packet := range sent {
+			ids = append(ids, binary.BigEndian.Uint16(packet[0:2]))
+		}
+		sent = nil
+		return ids
+	}
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, svc); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	for i, id := range sentIDs() {
+		if id != 0 {
+			t.Errorf("probe/announcement %d ID = %#x, want 0", i+1, id)
+		}
+	}
+
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+	for _, tt := range []struct {
+		name   string
+		qu     bool
+		wantID uint16
+	}{
+		{"multicast", false, 0},
+		{"unicast (QU)", true, 0x4321},
+	} {
+		query := buildDNSQuery("test.local", uint16(protocol.RecordTypeA))
+		binary.BigEndian.PutUint16(query[0:2], 0x4321)
+		if tt.qu {
+			query[len(query)-2] |= 0x80 // QU bit, top of QCLASS (RFC 6762 §5.4)
+		}
+		fake.Advance(time.Second) // Past the RFC 6762 §6.2 per-record rate limit
+		if err := r.handleQuery(query, src, 0); err != nil {
+			t.Fatalf("handleQuery(%s) error = %v", tt.name, err)
+		}
+		ids := sentIDs()
+		if len(ids) != 1 {
+			t.Fatalf("sent %d responses to a %s query, want 1", len(ids), tt.name)
+		}
+		if ids[0] != tt.wantID {
+			t.Errorf("%s response ID = %#x, want %#x", tt.name, ids[0], tt.wantID)
+		}
+	}
+}
+
+// TestHandleQuery_CompressedKnownAnswerSuppresses verifies a known answer
+// whose RDATA compresses its name against the query (RFC 1035 §4.1.4) still
+// suppresses the matching answer (RFC 6762 §7.1).
+func TestHandleQuery_CompressedKnownAnswerSuppresses(t *testing.T) {
+	sends := 0
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(context.Context, []byte, net.Addr) error {
+			sends++
+			return nil
+		}}),
+		WithHostname("test.local"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	// PTR query for _http._tcp.local (name at offset 12) listing the known
+	// answer "Web" + pointer to offset 12, owner name also a pointer
+	query := buildDNSQuery("_http._tcp.local", uint16(protocol.RecordTypePTR))
+	binary.BigEndian.PutUint16(query[6:8], 1) // ANCOUNT
+	query = append(query, 0xC0, 12, 0, byte(protocol.RecordTypePTR), 0, 1)
+	query = binary.BigEndian.AppendUint32(query, protocol.TTLService)
+	query = append(query, 0, 6, 3, 'W', 'e', 'b', 0xC0, 12)
+
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+	if err := r.handleQuery(query, src, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if sends != 0 {
+		t.Errorf("sent %d responses, want 0: the compressed known answer should suppress the PTR", sends)
+	}
+}
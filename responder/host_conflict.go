@@ -0,0 +1,270 @@
+package responder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
+	"github.com/joshuafuller/beacon/internal/state"
+)
+
+// checkHostnameConflict renames the host if a received response claims the
+// responder hostname for another device (WithConflictHostRename). Without
+// that option the conflict is logged and fails any registration probing with
+// the hostname (ErrHostnameConflict).
+//
+// RFC 6762 §9: "If a host receives a response containing a record that
+// conflicts with one of its unique records, the host MUST immediately rename
+// the record." The host's A record is unique; a response carrying an A record
+// for our hostname whose address is not one of this host's is such a
+// conflict. Records with our own address (e.g. another responder on this host
+// sharing the name) are not. A response claiming the name being probed for a
+// rename in progress makes that probe fail (RFC 6762 §8.1).
+//
+// Parameters:
+//   - msg: Parsed response (QR=1)
+//
+// Returns:
+//   - bool: true if the host started renaming
+func (r *Responder) checkHostnameConflict(msg *message.DNSMessage) bool {
+	r.hostnameMu.RLock()
+	hostname, probed := r.hostname, r.hostProbe
+	r.hostnameMu.RUnlock()
+
+	if !r.conflictHostRename {
+		if r.claimsHostname(msg, hostname) {
+			r.hostnameMu.Lock()
+			r.hostClaims++
+			r.hostnameMu.Unlock()
+			r.log().Warn("mDNS hostname conflict; not renaming host (WithConflictHostRename is off)",
+				"hostname", hostname)
+		}
+		return false
+	}
+
+	if probed != "" && r.claimsHostname(msg, probed) {
+		r.hostnameMu.Lock()
+		if r.hostProbe == probed {
+			r.hostProbeConflict = true
+		}
+		r.hostnameMu.Unlock()
+	}
+
+	if !r.claimsHostname(msg, hostname) {
+		return false
+	}
+	return r.renameHost(hostname)
+}
+
+// claimsHostname reports whether msg carries an A record for hostname with
+// an address that is not this host's.
+func (r *Responder) claimsHostname(msg *message.DNSMessage, hostname string) bool {
+	for _, rr := range responseRecords(msg) {
+		if rr.TYPE != uint16(protocol.RecordTypeA) || rr.CLASS&0x7FFF != uint16(protocol.ClassIN) {
+			continue
+		}
+		if !strings.EqualFold(rr.NAME, hostname) || len(rr.RDATA) != net.IPv4len || rr.TTL == 0 {
+			continue // Other names, malformed RDATA, or a goodbye
+		}
+		if !r.isOwnIPv4(rr.RDATA) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseRecords returns a response's Answer and Additional records.
+func responseRecords(msg *message.DNSMessage) []message.Answer {
+	all := make([]message.Answer, 0, len(msg.Answers)+len(msg.Additionals))
+	all = append(all, msg.Answers...)
+	return append(all, msg.Additionals...)
+}
+
+// isOwnIPv4 reports whether ip is an address of this host: the advertised
+// address or any address configured on a local interface, as reported by the
+// responder's InterfaceResolver.
+func (r *Responder) isOwnIPv4(ip []byte) bool {
+	if own, err := r.localIPv4(); err == nil && bytes.Equal(own, ip) {
+		return true
+	}
+
+	resolver := r.interfaces()
+	ifaces, err := resolver.Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, iface := range ifaces {
+		addrs, err := resolver.InterfaceAddrs(iface.Index)
+		if err != nil {
+			continue // Interface vanished or unreadable; try the next
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.To4() != nil && bytes.Equal(ipnet.IP.To4(), ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// renameHost starts moving the responder off the conflicting hostname, in
+// the background so the query handler keeps receiving; see probeHostRename.
+//
+// Nothing happens if the hostname already changed since the conflict was
+// detected, a rename is already in progress (e.g. two conflicting responses
+// processed back to back), or the responder is closing. Close waits for a
+// rename it did not prevent.
+//
+// Returns:
+//   - bool: true if a rename was started
+func (r *Responder) renameHost(conflicting string) bool {
+	r.hostnameMu.Lock()
+	if r.hostname != conflicting || r.hostRenamed != nil || r.ctx.Err() != nil {
+		r.hostnameMu.Unlock()
+		return false
+	}
+	done := make(chan struct{})
+	r.hostRenamed = done
+	r.hostnameMu.Unlock()
+
+	r.log().Warn("mDNS hostname conflict; probing for a new hostname",
+		"hostname", conflicting)
+
+	go r.probeHostRename(conflicting, done)
+	return true
+}
+
+// probeHostRename probes successive names after conflicting ("myhost.local"
+// → "myhost-2.local" → "myhost-3.local" ...) until one is free, up to
+// maxRenameAttempts, then moves the responder to it and re-announces every
+// service so peers learn the new SRV targets and A record (RFC 6762 §9).
+//
+// RFC 6762 §9: the new name must be probed like the old one was before it is
+// used (RFC 6762 §8.1). The hostname is left unchanged if every name is taken
+// or the responder is closed. done is closed once the rename has ended.
+func (r *Responder) probeHostRename(conflicting string, done chan struct{}) {
+	defer func() {
+		r.hostnameMu.Lock()
+		r.hostRenamed, r.hostProbe, r.hostProbeConflict = nil, "", false
+		r.hostnameMu.Unlock()
+		close(done)
+	}()
+
+	label, domain, _ := strings.Cut(conflicting, ".")
+	for attempt := 1; attempt <= maxRenameAttempts; attempt++ {
+		label = nextConflictName(label)
+		renamed := label + "." + domain
+
+		conflict, err := r.probeHostname(renamed)
+		if err != nil {
+			r.log().Warn("failed to probe new hostname",
+				"hostname", renamed, "error", err)
+			return
+		}
+		if conflict {
+			r.log().Info("new hostname in use; trying the next one",
+				"hostname", renamed)
+			continue
+		}
+
+		if r.ctx.Err() != nil {
+			return // Closed as the probe ended; announce nothing
+		}
+		r.hostnameMu.Lock()
+		r.hostname = renamed
+		r.hostnameMu.Unlock()
+
+		r.log().Warn("mDNS hostname conflict; renamed host",
+			"old_hostname", conflicting, "new_hostname", renamed)
+
+		// Best-effort: services already answer queries under the new name
+		if err := r.reload(true); err != nil {
+			r.log().Warn("failed to re-announce services after hostname rename",
+				"hostname", renamed, "error", err)
+		}
+		return
+	}
+
+	r.log().Warn("giving up renaming host: every name tried is in use",
+		"hostname", conflicting, "attempts", maxRenameAttempts)
+}
+
+// probeHostname probes for hostname with its A record (RFC 6762 §8.1),
+// reporting whether a response claimed it meanwhile (checkHostnameConflict).
+func (r *Responder) probeHostname(hostname string) (bool, error) {
+	ipv4, err := r.localIPv4()
+	if err != nil {
+		return false, fmt.Errorf("failed to get local IPv4: %w", err)
+	}
+
+	r.hostnameMu.Lock()
+	r.hostProbe, r.hostProbeConflict = hostname, false
+	r.hostnameMu.Unlock()
+
+	machine := state.NewMachine()
+	machine.SetTransport(r.transport)
+	machine.SetSkipAnnounce(true)
+	machine.SetClock(r.clock)
+	machine.SetInitialProbeDelay(r.initialProbeDelay)
+	machine.SetRand(r.randN)
+	machine.SetServiceRecords([]*records.ResourceRecord{{
+		Name:       hostname,
+		Type:       protocol.RecordTypeA,
+		Class:      protocol.ClassIN,
+		TTL:        protocol.TTLHostname,
+		Data:       ipv4,
+		CacheFlush: true,
+	}})
+	machine.SetConflictCheck(func() bool {
+		r.hostnameMu.RLock()
+		defer r.hostnameMu.RUnlock()
+		return r.hostProbeConflict
+	})
+
+	if err := machine.Run(r.ctx, hostname); err != nil {
+		return false, err
+	}
+	return machine.GetState() == state.StateConflictDetected, nil
+}
+
+// hostClaimCount returns how many responses have claimed the hostname
+// without WithConflictHostRename; a registration fails if it changes while
+// the service probes.
+func (r *Responder) hostClaimCount() uint64 {
+	r.hostnameMu.RLock()
+	defer r.hostnameMu.RUnlock()
+	return r.hostClaims
+}
+
+// hostConflicted reports whether the host has lost hostname: it has been
+// renamed away from it, or is being.
+func (r *Responder) hostConflicted(hostname string) bool {
+	r.hostnameMu.RLock()
+	defer r.hostnameMu.RUnlock()
+	return r.hostname != hostname || r.hostRenamed != nil
+}
+
+// awaitHostRename waits for the host rename in progress, if any, to end.
+//
+// Returns:
+//   - error: ctx.Err() if ctx is done first
+func (r *Responder) awaitHostRename(ctx context.Context) error {
+	r.hostnameMu.RLock()
+	done := r.hostRenamed
+	r.hostnameMu.RUnlock()
+	if done == nil {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
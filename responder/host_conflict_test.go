@@ -0,0 +1,477 @@
+package responder
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
+	internalresponder "github.com/joshuafuller/beacon/internal/responder"
+)
+
+// buildHostAResponse builds a response claiming hostname at ip.
+func buildHostAResponse(t *testing.T, hostname string, ip [4]byte) []byte {
+	t.Helper()
+	packet, err := message.SerializeMessage(&message.DNSMessage{
+		Header: message.DNSHeader{Flags: 0x8400, ANCount: 1},
+		Answers: []message.Answer{
+			{NAME: hostname, TYPE: uint16(protocol.RecordTypeA), CLASS: uint16(protocol.ClassIN) | 0x8000, TTL: 120, RDLENGTH: 4, RDATA: ip[:]},
+		},
+	})
+	if err != nil {
+		t.Fatalf("SerializeMessage() error = %v", err)
+	}
+	return packet
+}
+
+// sentPackets collects the packets a MockTransport sends.
+type sentPackets struct {
+	mu      sync.Mutex
+	packets [][]byte
+}
+
+// all returns a copy of the packets sent so far.
+func (s *sentPackets) all() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte(nil), s.packets...)
+}
+
+// newHostConflictResponder returns a responder named "myhost.local" on the
+// fake clock whose sent packets are collected in sent.
+func newHostConflictResponder(t *testing.T, rename bool, fake *clock.Fake, sent *sentPackets) *Responder {
+	t.Helper()
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent.mu.Lock()
+			defer sent.mu.Unlock()
+			sent.packets = append(sent.packets, packet)
+			return nil
+		}},
+		registry:           internalresponder.NewRegistry(),
+		hostname:           "myhost.local",
+		responseBuilder:    internalresponder.NewResponseBuilder(),
+		recordSet:          records.NewRecordSet(),
+		ipv4Source:         func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+		conflictHostRename: rename,
+		clock:              fake,
+	}
+	svc := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	return r
+}
+
+// finishHostRename advances fake until the host rename in progress has
+// probed its way to a new hostname (or given up).
+func finishHostRename(t *testing.T, r *Responder, fake *clock.Fake) {
+	t.Helper()
+	r.hostnameMu.RLock()
+	done := r.hostRenamed
+	r.hostnameMu.RUnlock()
+	if done == nil {
+		t.Fatal("no host rename in progress")
+	}
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case <-done:
+			return
+		case <-deadline:
+			t.Fatal("host rename did not complete")
+		case <-time.After(time.Millisecond):
+		}
+		if fake.Waiters() > 0 {
+			fake.Advance(protocol.ProbeInterval)
+		}
+	}
+}
+
+// hostProbes counts the probes (QR=0) among packets that ask only for
+// hostname, as sent when probing a new hostname.
+func hostProbes(t *testing.T, packets [][]byte, hostname string) int {
+	t.Helper()
+	n := 0
+	for _, packet := range packets {
+		msg, err := message.ParseMessage(packet)
+		if err != nil {
+			t.Fatalf("ParseMessage() error = %v", err)
+		}
+		if msg.Header.IsResponse() || len(msg.Questions) != 1 || msg.Questions[0].QNAME != hostname {
+			continue
+		}
+		if len(msg.Authorities) != 1 || msg.Authorities[0].TYPE != uint16(protocol.RecordTypeA) {
+			t.Errorf("host probe authorities = %+v, want the A record for %s", msg.Authorities, hostname)
+		}
+		n++
+	}
+	return n
+}
+
+// TestHostnameConflict_RenamesHostAndSRVTarget verifies that a response
+// claiming our hostname for another address renames the host to
+// "myhost-2.local" once that name has been probed, and re-announces the
+// service with the new SRV target and A record name (RFC 6762 §8.1, §9).
+func TestHostnameConflict_RenamesHostAndSRVTarget(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	sent := &sentPackets{}
+	r := newHostConflictResponder(t, true, fake, sent)
+
+	// 192.0.2.0/24 (TEST-NET-1) is never a local address
+	if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(conflicting response) error = %v", err)
+	}
+
+	// The new name is not used before it has been probed
+	if got := r.Hostname(); got != "myhost.local" {
+		t.Fatalf("Hostname() while probing = %q, want %q", got, "myhost.local")
+	}
+	finishHostRename(t, r, fake)
+
+	if got := r.Hostname(); got != "myhost-2.local" {
+		t.Fatalf("Hostname() = %q, want %q", got, "myhost-2.local")
+	}
+	packets := sent.all()
+	if len(packets) != 4 {
+		t.Fatalf("sent %d packets, want 3 probes and 1 re-announcement", len(packets))
+	}
+	if got := hostProbes(t, packets[:3], "myhost-2.local"); got != 3 {
+		t.Errorf("sent %d probes for myhost-2.local, want 3", got)
+	}
+
+	announcement, err := message.ParseMessage(packets[3])
+	if err != nil {
+		t.Fatalf("ParseMessage(announcement) error = %v", err)
+	}
+	var srvTarget, aName string
+	for _, rr := range announcement.Answers {
+		switch rr.TYPE {
+		case uint16(protocol.RecordTypeSRV):
+			srv, err := message.ParseRDATAInMessage(rr.TYPE, packets[3], rr.RDATAOffset, int(rr.RDLENGTH))
+			if err != nil {
+				t.Fatalf("ParseRDATAInMessage(SRV) error = %v", err)
+			}
+			srvTarget = srv.(message.SRVData).Target
+		case uint16(protocol.RecordTypeA):
+			aName = rr.NAME
+		}
+	}
+	if srvTarget != "myhost-2.local" {
+		t.Errorf("re-announced SRV target = %q, want %q", srvTarget, "myhost-2.local")
+	}
+	if aName != "myhost-2.local" {
+		t.Errorf("re-announced A record name = %q, want %q", aName, "myhost-2.local")
+	}
+
+	// A second conflict on the new name moves on to "-3"
+	if err := r.handleQuery(buildHostAResponse(t, "myhost-2.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(second conflict) error = %v", err)
+	}
+	finishHostRename(t, r, fake)
+	if got := r.Hostname(); got != "myhost-3.local" {
+		t.Errorf("Hostname() after second conflict = %q, want %q", got, "myhost-3.local")
+	}
+}
+
+// TestHostnameConflict_ProbedNameTaken verifies that a response claiming the
+// name probed for a rename makes the host move on to the next name
+// (RFC 6762 §8.1, §9).
+func TestHostnameConflict_ProbedNameTaken(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	sent := &sentPackets{}
+	r := newHostConflictResponder(t, true, fake, sent)
+
+	if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(conflicting response) error = %v", err)
+	}
+
+	// Another host defends "myhost-2.local" while we probe for it
+	fake.WaitForWaiters(1)
+	if err := r.handleQuery(buildHostAResponse(t, "myhost-2.local", [4]byte{192, 0, 2, 98}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(defending response) error = %v", err)
+	}
+	finishHostRename(t, r, fake)
+
+	if got := r.Hostname(); got != "myhost-3.local" {
+		t.Fatalf("Hostname() = %q, want %q", got, "myhost-3.local")
+	}
+	packets := sent.all()
+	if got := hostProbes(t, packets, "myhost-2.local"); got != 3 {
+		t.Errorf("sent %d probes for myhost-2.local, want 3", got)
+	}
+	if got := hostProbes(t, packets, "myhost-3.local"); got != 3 {
+		t.Errorf("sent %d probes for myhost-3.local, want 3", got)
+	}
+}
+
+// TestHostnameConflict_CloseEndsRename verifies Close stops a host rename
+// probing in the background and waits for it, so nothing is probed or
+// re-announced once Close returns, and no rename starts afterwards.
+func TestHostnameConflict_CloseEndsRename(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	sent := &sentPackets{}
+	r := newHostConflictResponder(t, true, fake, sent)
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	r.queryHandlerDone = make(chan struct{})
+	r.goodbyeCount = 1 // Close sends its goodbye without waiting on the clock
+
+	if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(conflicting response) error = %v", err)
+	}
+	fake.WaitForWaiters(1) // Probing for "myhost-2.local"
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if r.hostConflicted("myhost.local") {
+		t.Errorf("after Close, hostname = %q and rename in progress = %v, want %q and none",
+			r.Hostname(), r.hostRenamed != nil, "myhost.local")
+	}
+
+	closedAt := len(sent.all())
+	if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+		t.Fatalf("handleQuery(conflict after Close) error = %v", err)
+	}
+	if r.hostConflicted("myhost.local") {
+		t.Error("host rename started after Close")
+	}
+	fake.Advance(time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	if got := len(sent.all()); got != closedAt {
+		t.Errorf("sent %d packets after Close, want none", got-closedAt)
+	}
+}
+
+// TestHostnameConflict_NoRename verifies the host keeps its name when the
+// option is disabled or the A record carries this host's own address, the
+// advertised one or another the InterfaceResolver reports.
+func TestHostnameConflict_NoRename(t *testing.T) {
+	tests := []struct {
+		name     string
+		rename   bool
+		ip       [4]byte
+		resolver InterfaceResolver
+	}{
+		{name: "option disabled", rename: false, ip: [4]byte{192, 0, 2, 99}},
+		{name: "own address", rename: true, ip: [4]byte{192, 168, 1, 10}},
+		{
+			name:     "own address on another interface",
+			rename:   true,
+			ip:       [4]byte{10, 0, 2, 10},
+			resolver: StaticInterfaceResolver{1: "192.168.1.10/24", 2: "10.0.2.10/24"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := &sentPackets{}
+			r := newHostConflictResponder(t, tt.rename, clock.NewFake(time.Unix(0, 0)), sent)
+			r.interfaceResolver = tt.resolver
+
+			if err := r.handleQuery(buildHostAResponse(t, "myhost.local", tt.ip), nil, 0); err != nil {
+				t.Fatalf("handleQuery() error = %v", err)
+			}
+			if got := r.Hostname(); got != "myhost.local" {
+				t.Errorf("Hostname() = %q, want unchanged %q", got, "myhost.local")
+			}
+			if r.hostRenamed != nil {
+				t.Error("host rename started")
+			}
+			if got := len(sent.all()); got != 0 {
+				t.Errorf("sent %d packets, want none", got)
+			}
+		})
+	}
+}
+
+// TestHostnameConflict_DuringProbing verifies the host's A record is probed
+// for along with the service name, and that a conflict on it seen while
+// probing renames the host and re-probes the service under the new hostname
+// before anything is announced (RFC 6762 §8.1, §9).
+func TestHostnameConflict_DuringProbing(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var (
+		mu     sync.Mutex
+		probes [][]byte
+	)
+	mock := &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+		if len(packet) > 2 && packet[2]&0x80 == 0 { // QR=0: a probe
+			mu.Lock()
+			probes = append(probes, packet)
+			mu.Unlock()
+		}
+		return nil
+	}}
+
+	rec := &eventRecorder{}
+	var r *Responder
+	conflicted := false
+	r, err := New(context.Background(),
+		WithTransport(mock),
+		WithHostname("myhost.local"),
+		WithConflictHostRename(true),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithObserver(ObserverFunc(func(ev Event) {
+			rec.OnEvent(ev)
+			// Another host answers for our hostname after the first probe;
+			// 192.0.2.0/24 (TEST-NET-1) is never a local address
+			if ev.Type == EventProbeSent && !conflicted {
+				conflicted = true
+				if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+					t.Errorf("handleQuery(conflicting response) error = %v", err)
+				}
+			}
+		})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	service := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if got := r.Hostname(); got != "myhost-2.local" {
+		t.Fatalf("Hostname() = %q, want %q", got, "myhost-2.local")
+	}
+	if service.InstanceName != "Web" {
+		t.Errorf("service renamed to %q; only the host conflicted", service.InstanceName)
+	}
+	want := []string{
+		"ProbeStarted(Web)",
+		"ProbeSent(Web)#1", "ProbeSent(Web)#2", "ProbeSent(Web)#3",
+		"ConflictDetected(Web)",
+		"ProbeStarted(Web)",
+		"ProbeSent(Web)#1", "ProbeSent(Web)#2", "ProbeSent(Web)#3",
+		"AnnounceSent(Web)#1", "AnnounceSent(Web)#2",
+		"Established(Web)",
+	}
+	if got := rec.summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events =\n  %v\nwant\n  %v", got, want)
+	}
+
+	// The new hostname was probed on its own before the service used it
+	mu.Lock()
+	defer mu.Unlock()
+	if got := hostProbes(t, probes, "myhost-2.local"); got != 3 {
+		t.Errorf("sent %d probes for myhost-2.local, want 3", got)
+	}
+
+	// Service probes claim the hostname: a question for it and its A record
+	// in the Authority section, after the service's SRV and TXT, under the
+	// old name and then the new one
+	var serviceProbes [][]byte
+	for _, probe := range probes {
+		if msg, err := message.ParseMessage(probe); err == nil && len(msg.Questions) == 2 {
+			serviceProbes = append(serviceProbes, probe)
+		}
+	}
+	if len(serviceProbes) != 6 {
+		t.Fatalf("sent %d service probes, want 6", len(serviceProbes))
+	}
+	for i, hostname := range map[int]string{0: "myhost.local", 5: "myhost-2.local"} {
+		probe, err := message.ParseMessage(serviceProbes[i])
+		if err != nil {
+			t.Fatalf("ParseMessage(probe %d) error = %v", i, err)
+		}
+		if len(probe.Questions) != 2 || probe.Questions[1].QNAME != hostname {
+			t.Errorf("probe %d questions = %+v, want the service name and %s", i, probe.Questions, hostname)
+		}
+		if len(probe.Authorities) != 3 || probe.Authorities[2].NAME != hostname ||
+			probe.Authorities[2].TYPE != uint16(protocol.RecordTypeA) {
+			t.Errorf("probe %d authorities = %+v, want SRV, TXT and the A record for %s", i, probe.Authorities, hostname)
+		}
+	}
+
+	for _, rr := range r.GetLastAnnouncedRecords() {
+		if rr.Type == protocol.RecordTypeA && rr.Name != "myhost-2.local" {
+			t.Errorf("announced A record for %q, want %q", rr.Name, "myhost-2.local")
+		}
+	}
+}
+
+// newClaimedHostResponder returns a responder named "myhost.local" on the
+// fake clock to which, after the first probe of a registration, another
+// device answers for the hostname.
+func newClaimedHostResponder(t *testing.T, fake *clock.Fake, rename bool) *Responder {
+	t.Helper()
+	var r *Responder
+	var claimed atomic.Bool
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("myhost.local"),
+		WithConflictHostRename(rename),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithObserver(ObserverFunc(func(ev Event) {
+			// 192.0.2.0/24 (TEST-NET-1) is never a local address
+			if ev.Type == EventProbeSent && !claimed.Swap(true) {
+				if err := r.handleQuery(buildHostAResponse(t, "myhost.local", [4]byte{192, 0, 2, 99}), nil, 0); err != nil {
+					t.Errorf("handleQuery(conflicting response) error = %v", err)
+				}
+			}
+		})))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	return r
+}
+
+// TestHostnameConflict_DuringProbingWithoutRename verifies that without
+// WithConflictHostRename a conflict on the hostname seen while probing fails
+// the registration with ErrHostnameConflict rather than being ignored.
+func TestHostnameConflict_DuringProbingWithoutRename(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := newClaimedHostResponder(t, fake, false)
+
+	service := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}
+	if err := registerOnFakeClock(t, r, fake, service); !goerrors.Is(err, ErrHostnameConflict) {
+		t.Fatalf("Register() error = %v, want ErrHostnameConflict", err)
+	}
+	if got := r.Hostname(); got != "myhost.local" {
+		t.Errorf("Hostname() = %q, want unchanged %q", got, "myhost.local")
+	}
+	if _, ok := r.GetService("Web"); ok {
+		t.Error("service registered despite the hostname conflict")
+	}
+}
+
+// TestHostnameConflict_RenameDoesNotCountAgainstService verifies that a
+// service re-probed after a host rename still gets its full
+// maxRenameAttempts for conflicts on its own name.
+func TestHostnameConflict_RenameDoesNotCountAgainstService(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r := newClaimedHostResponder(t, fake, true)
+
+	// The first attempt's conflict is the host's; the service name then
+	// conflicts until the last allowed attempt
+	r.InjectConflictForService("Web", maxRenameAttempts)
+
+	service := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if want := fmt.Sprintf("Web-%d", maxRenameAttempts); service.InstanceName != want {
+		t.Errorf("service registered as %q, want %q", service.InstanceName, want)
+	}
+	if got := r.Hostname(); got != "myhost-2.local" {
+		t.Errorf("Hostname() = %q, want %q", got, "myhost-2.local")
+	}
+}
@@ -0,0 +1,169 @@
+package responder
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/joshuafuller/beacon/internal/errors"
+)
+
+// InterfaceResolver lists the host's network interfaces and the addresses
+// configured on them; see WithInterfaceResolver.
+//
+// The responder uses it to answer a query with only the addresses valid on
+// the interface the query arrived on (RFC 6762 §15), to check the query's
+// source is on that interface's subnet (RFC 6762 §6.4), and to pick the
+// address its services advertise and the interfaces WithInterfaceFilter
+// accepts. The default reads the host's interfaces; StaticInterfaceResolver
+// simulates a multi-NIC host.
+type InterfaceResolver interface {
+	// Interfaces returns the host's network interfaces, as net.Interfaces.
+	Interfaces() ([]net.Interface, error)
+
+	// InterfaceAddrs returns the addresses (*net.IPNet) configured on the
+	// interface with index ifIndex, or an error if there is no such
+	// interface.
+	InterfaceAddrs(ifIndex int) ([]net.Addr, error)
+}
+
+// systemInterfaceResolver is the default InterfaceResolver, reading the host's
+// network interfaces.
+type systemInterfaceResolver struct{}
+
+// Interfaces returns the host's network interfaces.
+func (systemInterfaceResolver) Interfaces() ([]net.Interface, error) {
+	return net.Interfaces()
+}
+
+// InterfaceAddrs returns the addresses of the host interface ifIndex.
+func (systemInterfaceResolver) InterfaceAddrs(ifIndex int) ([]net.Addr, error) {
+	iface, err := net.InterfaceByIndex(ifIndex)
+	if err != nil {
+		// Interface not found (removed, invalid index, etc.)
+		return nil, &errors.NetworkError{
+			Operation: "lookup interface",
+			Err:       err,
+			Details:   fmt.Sprintf("interface index %d not found", ifIndex),
+		}
+	}
+
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, &errors.NetworkError{
+			Operation: "get interface addresses",
+			Err:       err,
+			Details:   fmt.Sprintf("failed to get addresses for %s", iface.Name),
+		}
+	}
+	return addrs, nil
+}
+
+// StaticInterfaceResolver is an InterfaceResolver serving a fixed set of
+// interfaces, mapping each interface index to an address in CIDR notation
+// ("10.0.1.10/24"), or to a comma-separated list of addresses for a
+// dual-stack interface ("10.0.1.10/24,fd00::10/64"). It lets tests simulate
+// a multi-NIC host deterministically.
+//
+// Example:
+//
+//	r, err := responder.New(ctx, responder.WithInterfaceResolver(responder.StaticInterfaceResolver{
+//	    1: "10.0.1.10/24",
+//	    2: "10.0.2.10/24,fd00::10/64",
+//	}))
+type StaticInterfaceResolver map[int]string
+
+// Interfaces returns the configured interfaces in index order, each up and
+// multicast-capable, and flagged loopback if its first address is. An
+// interface is named "if" followed by its index ("if1").
+//
+// Returns:
+//   - []net.Interface: The configured interfaces
+//   - error: ValidationError for an address that is not valid CIDR notation
+func (s StaticInterfaceResolver) Interfaces() ([]net.Interface, error) {
+	indexes := make([]int, 0, len(s))
+	for ifIndex := range s {
+		indexes = append(indexes, ifIndex)
+	}
+	slices.Sort(indexes)
+
+	ifaces := make([]net.Interface, 0, len(indexes))
+	for _, ifIndex := range indexes {
+		addrs, err := s.InterfaceAddrs(ifIndex)
+		if err != nil {
+			return nil, err
+		}
+		iface := net.Interface{
+			Index: ifIndex,
+			Name:  fmt.Sprintf("if%d", ifIndex),
+			Flags: net.FlagUp | net.FlagMulticast,
+		}
+		if ipnet, ok := addrs[0].(*net.IPNet); ok && ipnet.IP.IsLoopback() {
+			iface.Flags |= net.FlagLoopback
+		}
+		ifaces = append(ifaces, iface)
+	}
+	return ifaces, nil
+}
+
+// InterfaceAddrs returns the configured addresses of interface ifIndex.
+//
+// Returns:
+//   - []net.Addr: The interface's addresses as *net.IPNet, in configured order
+//   - error: NetworkError for an unconfigured index, ValidationError for an
+//     address that is not valid CIDR notation
+func (s StaticInterfaceResolver) InterfaceAddrs(ifIndex int) ([]net.Addr, error) {
+	cidrs, ok := s[ifIndex]
+	if !ok {
+		return nil, &errors.NetworkError{
+			Operation: "lookup interface",
+			Err:       fmt.Errorf("no such interface"),
+			Details:   fmt.Sprintf("interface index %d not found", ifIndex),
+		}
+	}
+
+	var addrs []net.Addr
+	for _, cidr := range strings.Split(cidrs, ",") {
+		ip, ipnet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, &errors.ValidationError{
+				Field:   "interface",
+				Value:   cidr,
+				Message: fmt.Sprintf("invalid CIDR address for interface index %d", ifIndex),
+			}
+		}
+		ipnet.IP = ip
+		addrs = append(addrs, ipnet)
+	}
+	return addrs, nil
+}
+
+// interfaces returns the configured InterfaceResolver (WithInterfaceResolver),
+// defaulting to the host's interfaces.
+func (r *Responder) interfaces() InterfaceResolver {
+	if r.interfaceResolver != nil {
+		return r.interfaceResolver
+	}
+	return systemInterfaceResolver{}
+}
+
+// servesInterface reports whether queries received on interface ifIndex are
+// answered under WithInterfaceFilter. An unknown interface (index 0) cannot
+// be attributed and is served; an index that no longer resolves is not.
+func (r *Responder) servesInterface(ifIndex int) bool {
+	if r.interfaceFilter == nil || ifIndex == 0 {
+		return true
+	}
+
+	ifaces, err := r.interfaces().Interfaces()
+	if err != nil {
+		return false
+	}
+	for _, iface := range ifaces {
+		if iface.Index == ifIndex {
+			return r.interfaceFilter(iface)
+		}
+	}
+	return false
+}
@@ -1,11 +1,21 @@
 package responder
 
 import (
+	"context"
+	goerrors "errors"
 	"fmt"
+	"maps"
+	"net"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/records"
+	"github.com/joshuafuller/beacon/internal/responder"
 	"github.com/joshuafuller/beacon/internal/state"
 )
 
@@ -17,6 +27,21 @@ import (
 // FR-032: System MUST handle registration failures gracefully
 const maxRenameAttempts = 10
 
+// ErrNameConflict is returned (wrapped) by Register when probing finds the
+// service name already in use on the network and renaming is disabled
+// (WithNoRename). Test with errors.Is.
+var ErrNameConflict = goerrors.New("service name already in use on the network")
+
+// ErrHostnameConflict is returned (wrapped) by Register when another device
+// claims the responder hostname while the service is probing and host
+// renaming is disabled (WithConflictHostRename). Test with errors.Is.
+var ErrHostnameConflict = goerrors.New("hostname already in use on the network")
+
+// ErrServiceLimitReached is returned (wrapped) by Register when the responder
+// already holds the maximum number of services set by WithMaxServices. Test
+// with errors.Is.
+var ErrServiceLimitReached = responder.ErrFull
+
 // Register registers a service with probing and announcing per RFC 6762 §8.
 //
 // IMPORTANT: Register blocks for approximately 1.75 seconds while performing
@@ -24,18 +49,52 @@ const maxRenameAttempts = 10
 // phases per RFC 6762 §8. Use a goroutine if non-blocking behavior is needed.
 //
 // Process:
-//  1. Validate service parameters
+//  1. Validate service parameters and the advertised address
 //  2. Probe for name conflicts (RFC 6762 §8.1, ~750ms)
 //  3. Announce the service (RFC 6762 §8.3, ~1s)
 //  4. Add to registry on success
 //
 // If a naming conflict is detected during probing, the service is automatically
 // renamed per RFC 6762 §9 (e.g., "My Service" → "My Service-2") and probing
-// restarts, up to 10 attempts.
+// restarts, up to 10 attempts. With WithNoRename, Register instead fails at
+// the first conflict with an error wrapping ErrNameConflict.
+//
+// Register is equivalent to RegisterContext with context.Background(); it is
+// bounded only by the responder's own lifetime.
+//
+// A resolved IPv4 address that is missing, malformed, 0.0.0.0, or loopback
+// (without WithLoopbackAdvertise) fails registration with a validation error
+// rather than being advertised.
 //
 // Returns:
-//   - error: validation error, conflict error, max attempts error, or context error
+//   - error: validation error, conflict error (ErrNameConflict with
+//     WithNoRename, ErrHostnameConflict without WithConflictHostRename),
+//     ErrServiceLimitReached (WithMaxServices), max attempts error, or
+//     context error
 func (r *Responder) Register(service *Service) error {
+	return r.RegisterContext(context.Background(), service)
+}
+
+// RegisterContext is like Register but runs probing and announcing under ctx,
+// so a single registration can be cancelled or given its own deadline
+// independently of other services. Closing the responder still cancels it.
+//
+// Parameters:
+//   - ctx: Per-call context bounding probing/announcing
+//   - service: The service to register
+//
+// Returns:
+//   - error: validation error, conflict error, max attempts error, or context
+//     error (errors.Is(err, context.Canceled/DeadlineExceeded))
+func (r *Responder) RegisterContext(ctx context.Context, service *Service) error {
+	// Derive from r.ctx so that responder shutdown still cancels the call
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if r.ctx != nil {
+		stop := context.AfterFunc(r.ctx, cancel)
+		defer stop()
+	}
+
 	if service == nil {
 		return fmt.Errorf("service cannot be nil")
 	}
@@ -45,28 +104,71 @@ func (r *Responder) Register(service *Service) error {
 		return err
 	}
 
-	// Set hostname if not provided
-	if service.Hostname == "" {
-		service.Hostname = r.hostname
+	// Merge responder-wide TXT defaults under the service's own keys
+	// (WithDefaultTXT). The merged set must still respect RFC 6763 §6.2.
+	txt := r.mergeTXT(service.TXTRecords)
+	if err := validateTXTRecords(txt); err != nil {
+		return err
 	}
 
-	// Get local IPv4 address (simplified - use first non-loopback)
-	ipv4, err := getLocalIPv4()
-	if err != nil {
-		return fmt.Errorf("failed to get local IPv4: %w", err)
+	// Take a slot before spending ~1.75s probing (WithMaxServices), so the
+	// registry cannot fill up meanwhile; it is given back on failure
+	if err := r.registry.Reserve(); err != nil {
+		return fmt.Errorf("register %q: %w", service.InstanceName, err)
+	}
+	reserved := true
+	defer func() {
+		if reserved {
+			r.registry.Release()
+		}
+	}()
+
+	// A goodbye still pending from an earlier Unregister of this name must not
+	// flush the records we are about to announce.
+	r.cancelPendingGoodbye(service.InstanceName)
+
+	// Get local IPv4 address (simplified - use first non-loopback), unless
+	// the records are for another host (RegisterProxy)
+	ipv4 := service.proxyAddress
+	var err error
+	if ipv4 == nil {
+		if ipv4, err = r.localIPv4(); err != nil {
+			return fmt.Errorf("failed to get local IPv4: %w", err)
+		}
 	}
 
+	// Services() reports the registration until it fails or is unregistered
+	requested := service.InstanceName
+	registered := false
+	defer func() {
+		if !registered {
+			r.forgetStatus(service.InstanceName)
+		}
+	}()
+
 	// RFC 6762 §9: Rename loop on conflict (max 10 attempts)
-	// Attempt probing up to maxRenameAttempts times
-	for attempt := 1; attempt <= maxRenameAttempts; attempt++ {
+	// Attempt probing up to maxRenameAttempts times. Attempts probed again
+	// after a host rename do not count: the service name was not in conflict.
+	hostRetries := 0
+	for attempt := 1; attempt-hostRetries <= maxRenameAttempts; attempt++ {
+		// Per-service hostname override, else the responder hostname (read
+		// per attempt: a host rename may happen while probing)
+		hostname := r.hostnameFor(service.Hostname)
+
 		// Build record set for this service (with current name)
 		serviceInfo := buildServiceInfo(service.InstanceName, service.ServiceType,
-			service.Hostname, service.Port, ipv4, service.TXTRecords)
+			hostname, service.Port, ipv4, txt, service.PTROnly, service.Subtypes)
+		// The host's AAAA records are probed for and announced with its A
+		// record (RFC 6762 §8.1, §8.3)
+		r.addInterfaceAddresses(serviceInfo, service.proxyAddress != nil, r.defaultInterface())
+		// Fail on a bad address rather than advertise it; loopback is only
+		// intended with WithLoopbackAdvertise
+		serviceInfo.AllowLoopback = r.loopbackAdvertise
+		if err := serviceInfo.Validate(); err != nil {
+			return fmt.Errorf("register %q: %w", service.InstanceName, err)
+		}
 		recordSet := records.BuildRecordSet(serviceInfo)
 
-		// US2 GREEN: Store record set for contract test validation
-		r.lastAnnouncedRecords = recordSet
-
 		// Create and run state machine
 		machine := state.NewMachine()
 		serviceName := service.InstanceName + "." + service.ServiceType
@@ -74,54 +176,101 @@ func (r *Responder) Register(service *Service) error {
 		// Wire transport so probes and announcements are sent on the wire
 		machine.SetTransport(r.transport)
 
-		// Apply test hooks (if any)
-		if r.injectConflict {
-			machine.SetInjectConflict(true)
-		}
+		// RFC 6762 §8.1: A lone shared PTR has no unique record to probe for
+		machine.SetSkipProbing(service.PTROnly)
+		machine.SetSkipAnnounce(r.skipAnnounce)
+		machine.SetClock(r.clock)
 
-		// US2 GREEN: Store machine for message capture (contract test support)
-		r.lastMachine = machine
+		// RFC 6762 §8.1: Random 0-250ms wait before the first probe
+		machine.SetInitialProbeDelay(r.initialProbeDelay)
+		machine.SetRand(r.randN)
 
-		// US2 GREEN: Apply callbacks to new machine (if any)
-		if r.onProbeCallback != nil {
-			prober := machine.GetProber()
-			if prober != nil {
-				prober.SetOnSendQuery(r.onProbeCallback)
-			}
-		}
-		if r.onAnnounceCallback != nil {
-			announcer := machine.GetAnnouncer()
-			if announcer != nil {
-				announcer.SetOnSendAnnouncement(r.onAnnounceCallback)
-			}
+		// RFC 6762 §8.2: Probes propose the service's unique records
+		machine.SetServiceRecords(serviceRecords(recordSet, serviceName))
+
+		// RFC 6762 §8.1: The hostname is unique too; probe for its address
+		// records. A conflict on it seen by the query handler while probing
+		// renames the host (WithConflictHostRename), and this attempt must
+		// then be re-probed under the new hostname rather than announced.
+		machine.SetHostRecords(hostRecords(recordSet, hostname))
+		claims := r.hostClaimCount()
+		if service.Hostname == "" {
+			machine.SetConflictCheck(func() bool {
+				return r.hostConflicted(hostname) || r.hostClaimCount() != claims
+			})
 		}
 
+		// A later attempt follows a rename of the service or the host, whose
+		// announcements flush stale records whatever WithCacheFlushOnAnnounce
+		// says
+		announced := r.announcedRecords(recordSet, attempt > 1)
+
+		// Apply test hooks (if any); store the machine and record set for
+		// message capture (US2 GREEN contract test support)
+		r.recordAttempt(machine, announced, requested)
+
+		// Lifecycle events (WithObserver) and OnProbe/OnAnnounce callbacks
+		r.observeMachine(machine, service, requested)
+
 		// Provide resource records to announcer for DNS message serialization
 		announcer := machine.GetAnnouncer()
 		if announcer != nil {
-			announcer.SetRecords(recordSet)
+			announcer.SetRecords(announced)
 		}
 
-		// Run state machine (probing + announcing)
-		err = machine.Run(r.ctx, serviceName)
+		// Run state machine (probing + announcing). A service answered for
+		// while announcing stays answered for until it is in the registry,
+		// or not at all once this attempt has failed.
+		err = machine.Run(ctx, serviceName)
+		finalState := machine.GetState()
+		if err != nil || finalState != state.StateEstablished {
+			r.stopAnswering(service.InstanceName)
+		}
 		if err != nil {
 			return fmt.Errorf("state machine failed: %w", err)
 		}
 
-		// Check final state
-		finalState := machine.GetState()
+		if finalState == state.StateConflictDetected && service.Hostname == "" && r.hostClaimCount() != claims {
+			// Another device claimed the hostname while probing, and nothing
+			// will rename the host (WithConflictHostRename is off)
+			return fmt.Errorf("register %q: %w", service.InstanceName, ErrHostnameConflict)
+		}
+
+		if finalState == state.StateConflictDetected && service.Hostname == "" && r.hostConflicted(hostname) {
+			// The host lost its name while probing: the service name is not
+			// in conflict, so probe again once the host has probed a new one
+			hostRetries++
+			if hostRetries > maxRenameAttempts {
+				return fmt.Errorf("max host rename attempts (%d) exceeded for service %q",
+					maxRenameAttempts, service.InstanceName)
+			}
+			if err := r.awaitHostRename(ctx); err != nil {
+				return fmt.Errorf("state machine failed: %w", err)
+			}
+			r.log().Info("hostname changed while probing; probing again",
+				"service", service.InstanceName, "old_hostname", hostname, "new_hostname", r.Hostname())
+			continue
+		}
+
+		if finalState == state.StateConflictDetected && r.noRename {
+			// The caller needs this exact name (WithNoRename): fail, don't rename
+			return fmt.Errorf("register %q: %w", service.InstanceName, ErrNameConflict)
+		}
 
 		if finalState == state.StateConflictDetected {
 			// Conflict detected - rename and retry (unless max attempts reached)
-			if attempt >= maxRenameAttempts {
+			if attempt-hostRetries >= maxRenameAttempts {
 				// Max attempts exceeded - give up
 				return fmt.Errorf("max rename attempts (%d) exceeded for service %q",
 					maxRenameAttempts, service.InstanceName)
 			}
 
 			// Rename service and try again
+			previous := service.InstanceName
 			service.Rename() // Appends "-2", "-3", etc.
-			continue         // Retry with new name
+			r.forgetStatus(previous)
+			r.emit(Event{Type: EventRenamed, Instance: service.InstanceName, Previous: previous})
+			continue // Retry with new name
 		}
 
 		if finalState != state.StateEstablished {
@@ -132,10 +281,26 @@ func (r *Responder) Register(service *Service) error {
 
 		// Success! Add to registry
 		// US5: toInternalService carries TXT records for UpdateService support
-		if err := r.registry.Register(toInternalService(service)); err != nil {
+		internalSvc := toInternalService(service)
+		internalSvc.TXT = txt
+		reserved = false
+		err = r.registry.RegisterReserved(internalSvc)
+		r.stopAnswering(service.InstanceName) // Answered from the registry from here on
+		if err != nil {
 			return fmt.Errorf("failed to add to registry: %w", err)
 		}
 
+		registered = true
+
+		// The service is ready: tell the app its final name (WithOnRegistered)
+		advertised := ipv4
+		if service.PTROnly {
+			advertised = nil
+		}
+		established := r.establishStatus(service, requested, advertised)
+		if r.onRegistered != nil {
+			r.onRegistered(established)
+		}
 		return nil // Successfully registered
 	}
 
@@ -143,6 +308,120 @@ func (r *Responder) Register(service *Service) error {
 	return fmt.Errorf("unexpected: register loop completed without result")
 }
 
+// RegisterProxy registers a service on behalf of another host, advertising
+// its SRV record with hostname as target and an A record mapping hostname to
+// target, in place of this host's name and interface address.
+//
+// This is the building block of a sleep proxy or gateway (RFC 6762 §17 style):
+// the responder answers for a device that cannot, e.g. one asleep or on
+// another link. The service is probed, announced, renamed on conflict and
+// unregistered like any other (see Register); its A record carries target
+// whichever interface a query arrives on (no RFC 6762 §15 interface
+// addressing), and no AAAA record is advertised. hostname is probed too, so
+// the proxied host's own name is defended.
+//
+// Parameters:
+//   - service: The service to register; its Hostname is set to hostname
+//   - target: IPv4 address of the proxied host
+//   - hostname: Name of the proxied host (e.g., "printer.local"); must differ
+//     from the responder's own hostname
+//
+// Returns:
+//   - error: ValidationError for an invalid target or hostname, else as Register
+func (r *Responder) RegisterProxy(service *Service, target net.IP, hostname string) error {
+	if service == nil {
+		return fmt.Errorf("service cannot be nil")
+	}
+	ipv4 := target.To4()
+	if ipv4 == nil {
+		return &errors.ValidationError{
+			Field:   "target",
+			Value:   target,
+			Message: "proxied host address must be IPv4",
+		}
+	}
+	if hostname == "" || strings.EqualFold(hostname, r.Hostname()) {
+		return &errors.ValidationError{
+			Field:   "hostname",
+			Value:   hostname,
+			Message: "proxied hostname must be set and differ from the responder's own",
+		}
+	}
+
+	service.Hostname = hostname
+	service.proxyAddress = ipv4
+	return r.Register(service)
+}
+
+// serviceRecords returns the unique records (SRV/TXT) named serviceName in a
+// service's record set: those proposed in the Authority section of its
+// probes (RFC 6762 §8.2).
+func serviceRecords(recordSet []*records.ResourceRecord, serviceName string) []*records.ResourceRecord {
+	var service []*records.ResourceRecord
+	for _, rr := range recordSet {
+		if rr.CacheFlush && strings.EqualFold(rr.Name, serviceName) {
+			service = append(service, rr)
+		}
+	}
+	return service
+}
+
+// hostRecords returns the host address records (A/AAAA) for hostname in a
+// service's record set: the host's unique records probed for along with the
+// service name (RFC 6762 §8.1).
+func hostRecords(recordSet []*records.ResourceRecord, hostname string) []*records.ResourceRecord {
+	var host []*records.ResourceRecord
+	for _, rr := range recordSet {
+		if (rr.Type == protocol.RecordTypeA || rr.Type == protocol.RecordTypeAAAA) && strings.EqualFold(rr.Name, hostname) {
+			host = append(host, rr)
+		}
+	}
+	return host
+}
+
+// RegisterAll registers several services at once, probing and announcing
+// them concurrently.
+//
+// Each service runs its own Register sequence (RFC 6762 §8) in its own
+// goroutine, so registering N services takes about one probe/announce cycle
+// (~1.75s) rather than N of them. A service whose name conflicts is renamed
+// and re-probed on its own (RFC 6762 §9) without holding up the others.
+// The services must be distinct; as with Register, renamed services have
+// their InstanceName updated in place.
+//
+// Parameters:
+//   - services: The services to register
+//
+// Returns:
+//   - []error: Register's result for each service, in order (nil on success)
+//   - error: nil if every service registered, otherwise the failures joined
+//     via errors.Join
+func (r *Responder) RegisterAll(services []*Service) ([]error, error) {
+	results := make([]error, len(services))
+
+	var wg sync.WaitGroup
+	for i, service := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.Register(service)
+		}()
+	}
+	wg.Wait()
+
+	var failures []error
+	for i, err := range results {
+		if err != nil {
+			name := "<nil>"
+			if services[i] != nil {
+				name = services[i].InstanceName
+			}
+			failures = append(failures, fmt.Errorf("register %q: %w", name, err))
+		}
+	}
+	return results, goerrors.Join(failures...)
+}
+
 // Unregister unregisters a service and sends goodbye packets per RFC 6762 §10.1.
 //
 // RFC 6762 §10.1: "A host may send unsolicited responses with TTL=0 to announce
@@ -152,8 +431,11 @@ func (r *Responder) Register(service *Service) error {
 //  1. Remove from registry
 //  2. Send goodbye announcements (TTL=0)
 //
+// The service is removed even if the goodbye cannot be sent; EventGoodbyeSent
+// is emitted only for copies that were.
+//
 // Returns:
-//   - error: if service not found or send fails
+//   - error: if service not found or the first goodbye copy fails to send
 //
 // T042: Implement Unregister() with goodbye packets
 func (r *Responder) Unregister(serviceID string) error {
@@ -164,44 +446,317 @@ func (r *Responder) Unregister(serviceID string) error {
 	}
 
 	// Get local IPv4 address for goodbye records
-	ipv4, err := getLocalIPv4()
+	ipv4, err := r.localIPv4()
 	if err != nil {
 		// If we can't get IP, still remove from registry but skip goodbye
 		_ = r.registry.Remove(svc.InstanceName) // nosemgrep: beacon-error-swallowing
 		return fmt.Errorf("failed to get local IP for goodbye: %w", err)
 	}
 
-	// Build goodbye records with TTL=0 (RFC 6762 §10.1)
-	serviceInfo := buildServiceInfo(svc.InstanceName, svc.ServiceType,
-		r.hostname, svc.Port, ipv4, svc.TXTRecords)
-	goodbyeRecords := records.BuildGoodbyeRecords(serviceInfo)
-
-	// Build and send goodbye packet
-	goodbyePacket, err := message.BuildResponse(goodbyeRecords)
+	// Build goodbye packet with TTL=0 records (RFC 6762 §10.1)
+	goodbyePacket, err := r.buildGoodbyePacket(svc.InstanceName, svc.ServiceType, r.hostnameFor(svc.Hostname), svc.Port, advertisedIPv4(svc.proxyAddress, ipv4), svc.proxyAddress != nil, svc.TXTRecords, svc.PTROnly, svc.Subtypes)
 	if err != nil {
 		// If we can't build packet, still remove from registry
 		_ = r.registry.Remove(svc.InstanceName) // nosemgrep: beacon-error-swallowing
-		return fmt.Errorf("failed to build goodbye packet: %w", err)
+		return err
+	}
+
+	// RFC 6762 §10.1: Goodbye is best-effort (SHOULD, not MUST). A failed
+	// send is reported, but the service is still removed below.
+	sendErr := r.transport.Send(r.ctx, goodbyePacket, protocol.MulticastGroupIPv4())
+	if sendErr == nil {
+		r.emit(Event{Type: EventGoodbyeSent, Instance: svc.InstanceName, Count: 1})
 	}
 
-	// RFC 6762 §10.1: Goodbye is best-effort (SHOULD, not MUST).
-	// Ignore send errors; we still remove from the registry below.
-	_ = r.transport.Send(r.ctx, goodbyePacket, protocol.MulticastGroupIPv4()) // nosemgrep: beacon-error-swallowing
+	// Repeat for peers that missed the first copy (WithGoodbyeCount);
+	// cancelled if the name is re-registered in the meantime.
+	r.scheduleGoodbyeRetransmit(svc.InstanceName, goodbyePacket)
 
 	// Remove from registry using instance name
+	r.forgetStatus(svc.InstanceName)
 	if err := r.registry.Remove(svc.InstanceName); err != nil {
 		return fmt.Errorf("service %q not registered", serviceID)
 	}
 
+	if sendErr != nil {
+		return fmt.Errorf("service %q: failed to send goodbye: %w", svc.InstanceName, sendErr)
+	}
 	return nil
 }
 
+// UnregisterAll unregisters every service and sends goodbye packets per
+// RFC 6762 §10.1, leaving the responder running.
+//
+// Intended for configuration reloads: the registry is emptied atomically, the
+// query handler keeps serving, and new services can be registered afterwards
+// without recreating the responder. Goodbyes are retransmitted like
+// Unregister's (WithGoodbyeCount, WithGoodbyeInterval).
+//
+// Returns:
+//   - error: nil on success, otherwise all goodbye failures joined via errors.Join.
+//     The registry is empty either way.
+func (r *Responder) UnregisterAll() error {
+	removed := r.registry.Clear()
+	if len(removed) == 0 {
+		return nil
+	}
+
+	ipv4, err := r.localIPv4()
+	if err != nil {
+		return fmt.Errorf("failed to get local IP for goodbye: %w", err)
+	}
+
+	var errs []error
+	for _, svc := range removed {
+		r.forgetStatus(svc.InstanceName)
+		goodbyePacket, err := r.buildGoodbyePacket(svc.InstanceName, svc.ServiceType, r.hostnameFor(svc.Hostname), svc.Port, advertisedIPv4(svc.ProxyAddress, ipv4), svc.ProxyAddress != nil, svc.TXT, svc.PTROnly, svc.Subtypes)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("service %q: %w", svc.InstanceName, err))
+			continue
+		}
+		if err := r.transport.Send(r.ctx, goodbyePacket, protocol.MulticastGroupIPv4()); err != nil {
+			errs = append(errs, fmt.Errorf("service %q: failed to send goodbye: %w", svc.InstanceName, err))
+		} else {
+			r.emit(Event{Type: EventGoodbyeSent, Instance: svc.InstanceName, Count: 1})
+		}
+		r.scheduleGoodbyeRetransmit(svc.InstanceName, goodbyePacket)
+	}
+
+	return goerrors.Join(errs...)
+}
+
+// buildGoodbyePacket encodes the service's record set with TTL=0 per RFC 6762 §10.1,
+// withdrawing the AAAA records announced alongside the A record too. proxied
+// marks a RegisterProxy service, which advertises ipv4 only.
+func (r *Responder) buildGoodbyePacket(instanceName, serviceType, hostname string, port uint16, ipv4 []byte, proxied bool, txt map[string]string, ptrOnly bool, subtypes []string) ([]byte, error) {
+	serviceInfo := buildServiceInfo(instanceName, serviceType, hostname, port, ipv4, txt, ptrOnly, subtypes)
+	r.addInterfaceAddresses(serviceInfo, proxied, r.defaultInterface())
+	goodbyePacket, err := message.BuildResponse(records.BuildGoodbyeRecords(serviceInfo))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build goodbye packet: %w", err)
+	}
+	r.recordGoodbye(goodbyePacket)
+	return goodbyePacket, nil
+}
+
+// Goodbye retransmission defaults (WithGoodbyeCount, WithGoodbyeInterval).
+//
+// RFC 6762 §10.1 sends goodbyes best-effort; repeating the packet once guards
+// against a single lost datagram without lingering long after departure.
+const (
+	defaultGoodbyeCount    = 2
+	defaultGoodbyeInterval = 250 * time.Millisecond
+)
+
+// goodbyeSchedule returns how many copies of each goodbye to send and the
+// spacing between them, applying the defaults to zero values (e.g. responders
+// built as struct literals in tests).
+func (r *Responder) goodbyeSchedule() (int, time.Duration) {
+	count, interval := r.goodbyeCount, r.goodbyeInterval
+	if count < 1 {
+		count = defaultGoodbyeCount
+	}
+	if interval <= 0 {
+		interval = defaultGoodbyeInterval
+	}
+	return count, interval
+}
+
+// scheduleGoodbyeRetransmit arranges for packet, already sent once, to be
+// multicast again until the configured goodbye count is reached, spaced by
+// the goodbye interval. It is tracked under instanceName so that a
+// re-registration can cancel it.
+func (r *Responder) scheduleGoodbyeRetransmit(instanceName string, packet []byte) {
+	count, interval := r.goodbyeSchedule()
+
+	r.goodbyeMu.Lock()
+	defer r.goodbyeMu.Unlock()
+
+	if r.pendingGoodbyes == nil {
+		r.pendingGoodbyes = make(map[string]*pendingGoodbye)
+	}
+	if prev, ok := r.pendingGoodbyes[instanceName]; ok {
+		prev.timer.Stop()
+		delete(r.pendingGoodbyes, instanceName)
+	}
+	r.armGoodbyeLocked(instanceName, packet, 1, count-1, interval)
+}
+
+// pendingGoodbye is a goodbye with retransmissions still to send.
+type pendingGoodbye struct {
+	timer     *clock.Timer // Fires the next copy
+	packet    []byte
+	sent      int // Copies already sent
+	remaining int // Copies still to send
+}
+
+// armGoodbyeLocked schedules the next of remaining goodbye retransmissions,
+// sent copies having gone out already. The caller must hold goodbyeMu.
+func (r *Responder) armGoodbyeLocked(instanceName string, packet []byte, sent, remaining int, interval time.Duration) {
+	if remaining <= 0 {
+		return
+	}
+
+	pending := &pendingGoodbye{packet: packet, sent: sent, remaining: remaining}
+	pending.timer = clock.AfterFunc(r.clock, interval, func() {
+		// Send under the lock so a concurrent cancelPendingGoodbye either
+		// prevents this send or waits for it, never letting the goodbye land
+		// after a fresh announcement.
+		r.goodbyeMu.Lock()
+		defer r.goodbyeMu.Unlock()
+
+		if r.pendingGoodbyes[instanceName] != pending {
+			return // Cancelled, superseded or taken over by Close
+		}
+		delete(r.pendingGoodbyes, instanceName)
+
+		// Nobody waits on a retransmission: a failure is only logged
+		if err := r.transport.Send(r.ctx, packet, protocol.MulticastGroupIPv4()); err != nil {
+			r.log().Warn("failed to retransmit goodbye",
+				"service", instanceName, "copy", sent+1, "error", err)
+		} else {
+			r.emit(Event{Type: EventGoodbyeSent, Instance: instanceName, Count: sent + 1})
+		}
+		r.armGoodbyeLocked(instanceName, packet, sent+1, remaining-1, interval)
+	})
+	r.pendingGoodbyes[instanceName] = pending
+}
+
+// takePendingGoodbyes stops every pending goodbye retransmission and returns
+// them by instance name, so Close can send the remaining copies itself.
+func (r *Responder) takePendingGoodbyes() map[string]*pendingGoodbye {
+	r.goodbyeMu.Lock()
+	defer r.goodbyeMu.Unlock()
+
+	pending := r.pendingGoodbyes
+	for _, goodbye := range pending {
+		goodbye.timer.Stop()
+	}
+	r.pendingGoodbyes = nil
+	return pending
+}
+
+// sendClosingGoodbyes unregisters every service for Close, sending all copies
+// of their goodbyes before returning so none is lost to the transport closing.
+// The copies still due from earlier Unregister calls (pending, as taken by
+// takePendingGoodbyes) are sent alongside. Goodbyes are batched per round, so
+// Close waits (count-1) × interval in total however many services are
+// registered.
+//
+// It returns the failures joined via errors.Join: one per service whose
+// goodbye could not be built or none of whose copies could be sent. As with
+// any retransmission, a failure to send a pending copy is only logged.
+func (r *Responder) sendClosingGoodbyes(pending map[string]*pendingGoodbye) error {
+	var errs []error
+	var goodbyes []closingGoodbye
+
+	count, interval := r.goodbyeSchedule()
+	if removed := r.registry.Clear(); len(removed) > 0 {
+		if ipv4, err := r.localIPv4(); err != nil {
+			// No address to put in the goodbye records
+			errs = append(errs, fmt.Errorf("failed to get local IP for goodbye: %w", err))
+		} else {
+			for _, svc := range removed {
+				r.forgetStatus(svc.InstanceName)
+				packet, err := r.buildGoodbyePacket(svc.InstanceName, svc.ServiceType, r.hostnameFor(svc.Hostname), svc.Port, advertisedIPv4(svc.ProxyAddress, ipv4), svc.ProxyAddress != nil, svc.TXT, svc.PTROnly, svc.Subtypes)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("service %q: %w", svc.InstanceName, err))
+					continue
+				}
+				goodbyes = append(goodbyes, closingGoodbye{name: svc.InstanceName, packet: packet, copies: count})
+			}
+		}
+	}
+	for name, goodbye := range pending {
+		goodbyes = append(goodbyes, closingGoodbye{
+			name: name, packet: goodbye.packet, sent: goodbye.sent, copies: goodbye.remaining, retransmit: true,
+		})
+	}
+
+	// RFC 6762 §10.1: Goodbye is best-effort; a service is reported only if
+	// every copy failed
+	rounds := 0
+	for _, goodbye := range goodbyes {
+		rounds = max(rounds, goodbye.copies)
+	}
+	sendErrs := make([]error, len(goodbyes))
+	delivered := make([]bool, len(goodbyes))
+loop:
+	for i := 0; i < rounds; i++ {
+		if i > 0 {
+			select {
+			case <-clock.Or(r.clock).After(interval):
+			case <-r.ctx.Done():
+				break loop // Shutting down anyway; sends would fail
+			}
+		}
+		for j, goodbye := range goodbyes {
+			if i >= goodbye.copies {
+				continue
+			}
+			copyNum := goodbye.sent + i + 1
+			if err := r.transport.Send(r.ctx, goodbye.packet, protocol.MulticastGroupIPv4()); err != nil {
+				if goodbye.retransmit {
+					r.log().Warn("failed to retransmit goodbye",
+						"service", goodbye.name, "copy", copyNum, "error", err)
+				}
+				sendErrs[j] = err
+				continue
+			}
+			delivered[j] = true
+			r.emit(Event{Type: EventGoodbyeSent, Instance: goodbye.name, Count: copyNum})
+		}
+	}
+
+	for j, goodbye := range goodbyes {
+		if !goodbye.retransmit && !delivered[j] && sendErrs[j] != nil {
+			errs = append(errs, fmt.Errorf("service %q: failed to send goodbye: %w", goodbye.name, sendErrs[j]))
+		}
+	}
+	return goerrors.Join(errs...)
+}
+
+// closingGoodbye is a goodbye sendClosingGoodbyes sends copies of.
+type closingGoodbye struct {
+	name       string
+	packet     []byte
+	sent       int  // Copies sent before Close
+	copies     int  // Copies to send
+	retransmit bool // Pending from an earlier Unregister
+}
+
+// cancelPendingGoodbye cancels any goodbye retransmission pending for
+// instanceName.
+func (r *Responder) cancelPendingGoodbye(instanceName string) {
+	r.goodbyeMu.Lock()
+	defer r.goodbyeMu.Unlock()
+
+	if pending, ok := r.pendingGoodbyes[instanceName]; ok {
+		pending.timer.Stop()
+		delete(r.pendingGoodbyes, instanceName)
+	}
+}
+
+// cancelAllPendingGoodbyes cancels every pending goodbye retransmission.
+func (r *Responder) cancelAllPendingGoodbyes() {
+	r.goodbyeMu.Lock()
+	defer r.goodbyeMu.Unlock()
+
+	for name, pending := range r.pendingGoodbyes {
+		pending.timer.Stop()
+		delete(r.pendingGoodbyes, name)
+	}
+}
+
 // GetService retrieves a registered service by service ID.
 //
 // The serviceID can be either:
 //   - Full service ID: "Instance Name._service._proto.local"
 //   - Just instance name: "Instance Name" (backward compatibility)
 //
+// The returned Service is a copy, TXT records included; modifying it does not
+// affect the registered service (use UpdateService).
+//
 // Returns:
 //   - *Service: The service if found
 //   - bool: true if service exists, false otherwise
@@ -210,23 +765,17 @@ func (r *Responder) Unregister(serviceID string) error {
 func (r *Responder) GetService(serviceID string) (*Service, bool) {
 	// Try lookup by instance name directly (works if serviceID is just the instance name)
 	if svc, found := r.registry.Get(serviceID); found {
-		return fromInternalService(svc), true
+		return r.publicService(svc), true
 	}
 
-	// serviceID might be full DNS name "Instance._service._proto.local"
-	// Extract instance name (everything before first dot)
-	// For now, iterate through all services and find matching one
-	for _, instanceName := range r.registry.List() {
-		svc, found := r.registry.Get(instanceName)
-		if !found {
-			continue
-		}
-
-		// Build full service ID and compare
-		fullID := svc.InstanceName + "." + svc.ServiceType
-		if fullID == serviceID {
-			return fromInternalService(svc), true
-		}
+	// serviceID might be the full DNS name "Instance._service._proto.local",
+	// whose instance label may itself contain dots (RFC 6763 §4.3)
+	instance, serviceType := splitDNSSDName(serviceID)
+	if instance == "" {
+		return nil, false
+	}
+	if svc, found := r.registry.Get(instance); found && strings.EqualFold(svc.ServiceType, serviceType) {
+		return r.publicService(svc), true
 	}
 
 	return nil, false
@@ -240,7 +789,8 @@ func (r *Responder) GetService(serviceID string) (*Service, bool) {
 //
 // Process:
 //  1. Find service in registry
-//  2. Update TXT records
+//  2. Update TXT records under the registry lock, so concurrent queries see
+//     either the old or the new records, never a mix
 //  3. Send announcement with updated TXT record (multicast to inform network)
 //
 // Parameters:
@@ -248,58 +798,286 @@ func (r *Responder) GetService(serviceID string) (*Service, bool) {
 //   - txtRecords: New TXT records to set
 //
 // Returns:
-//   - error: If service not found or update fails
+//   - error: If service not found, the TXT records exceed RFC 6763 §6 limits
+//     (ValidationError; the service keeps its previous TXT records), or the
+//     update fails
 //
 // T106: Implement UpdateService without re-probing (US5 GREEN)
 func (r *Responder) UpdateService(serviceID string, txtRecords map[string]string) error {
+	return r.editTXT(serviceID, func(map[string]string) (map[string]string, bool) {
+		// The registry keeps the map; the caller may go on using theirs
+		return maps.Clone(txtRecords), true
+	})
+}
+
+// SetTXTKey sets one TXT key of a registered service, leaving its other keys
+// intact, and announces the change (RFC 6762 §8.4) like UpdateService.
+//
+// TXT keys are case insensitive (RFC 6763 §6.4), so an existing key differing
+// only in case is replaced. The read-modify-write is atomic with respect to
+// other TXT updates, unlike a GetService/UpdateService round trip.
+//
+// Parameters:
+//   - serviceID: Service identifier (InstanceName or InstanceName.ServiceType)
+//   - key: TXT key (printable ASCII without '=')
+//   - value: New value (TXTBoolean for a valueless key)
+//
+// Returns:
+//   - error: If service not found, or the resulting TXT records are invalid
+//     (ValidationError; the service keeps its previous TXT records)
+//
+// Example:
+//
+//	err := r.SetTXTKey("My Printer", "status", "busy")
+func (r *Responder) SetTXTKey(serviceID, key, value string) error {
+	return r.editTXT(serviceID, func(txt map[string]string) (map[string]string, bool) {
+		deleteTXTKey(txt, key)
+		txt[key] = value
+		return txt, true
+	})
+}
+
+// DeleteTXTKey removes one TXT key (matched ignoring case, RFC 6763 §6.4)
+// from a registered service, leaving its other keys intact, and announces the
+// change (RFC 6762 §8.4). Removing an absent key is a no-op and sends
+// nothing. Keys set by WithDefaultTXT are restored by the merge and cannot be
+// removed this way.
+//
+// Parameters:
+//   - serviceID: Service identifier (InstanceName or InstanceName.ServiceType)
+//   - key: TXT key to remove
+//
+// Returns:
+//   - error: If service not found
+func (r *Responder) DeleteTXTKey(serviceID, key string) error {
+	return r.editTXT(serviceID, func(txt map[string]string) (map[string]string, bool) {
+		return txt, deleteTXTKey(txt, key)
+	})
+}
+
+// deleteTXTKey removes key, matched ignoring case, from txt and reports
+// whether it was present.
+func deleteTXTKey(txt map[string]string, key string) bool {
+	found := false
+	for k := range txt {
+		if strings.EqualFold(k, key) {
+			delete(txt, k)
+			found = true
+		}
+	}
+	return found
+}
+
+// editTXT replaces a registered service's TXT records with those returned by
+// edit, called with a copy of the current records, and announces them. If
+// edit reports no change, nothing is updated or sent. txtMu serializes edits
+// so concurrent read-modify-writes do not lose each other's keys.
+func (r *Responder) editTXT(serviceID string, edit func(map[string]string) (map[string]string, bool)) error {
+	r.txtMu.Lock()
+	defer r.txtMu.Unlock()
+
 	// Lookup service
 	svc, found := r.GetService(serviceID)
 	if !found {
 		return fmt.Errorf("service %q not found", serviceID)
 	}
 
-	// Update TXT records in registry
-	// The registry stores internal/responder.Service, so we need to update it there
-	internalSvc, found := r.registry.Get(svc.InstanceName)
-	if !found {
-		return fmt.Errorf("internal error: service %q in GetService but not in registry", svc.InstanceName)
+	// GetService returned a copy of the TXT records, which edit may modify
+	current := svc.TXTRecords
+	if current == nil {
+		current = make(map[string]string, 1)
+	}
+	txtRecords, changed := edit(current)
+	if !changed {
+		return nil
 	}
 
-	// Update TXT records
-	internalSvc.TXT = txtRecords
+	// Update TXT records (responder-wide defaults still apply underneath).
+	// The merged set must respect RFC 6763 §6 like at registration.
+	txtRecords = r.mergeTXT(txtRecords)
+	if err := validateTXTRecords(txtRecords); err != nil {
+		return err
+	}
+	if err := r.registry.UpdateTXT(svc.InstanceName, txtRecords); err != nil {
+		return err // Unregistered concurrently
+	}
 
 	// Announce updated records per RFC 6762 §8.4.
 	// The registry is already updated above; the multicast announcement below is
 	// best-effort (RFC 6762 §8.4 is a SHOULD), so failures to obtain an address,
 	// build, or send the packet do not roll back the update.
-	ipv4, err := getLocalIPv4()
+	ipv4, err := r.localIPv4()
 	if err != nil {
 		return nil // Registry updated; cannot announce without an IP (best-effort).
 	}
 
-	serviceInfo := buildServiceInfo(svc.InstanceName, svc.ServiceType,
-		r.hostname, svc.Port, ipv4, txtRecords)
-	announcedRecords := records.BuildRecordSet(serviceInfo)
+	_ = r.announce(svc.InstanceName, svc.ServiceType, r.hostnameFor(svc.Hostname), svc.Port, advertisedIPv4(svc.proxyAddress, ipv4), svc.proxyAddress != nil, txtRecords, svc.PTROnly, svc.Subtypes, false) // nosemgrep: beacon-error-swallowing
+
+	return nil
+}
+
+// Reload re-resolves the host's addresses and re-announces every registered
+// service.
+//
+// On DHCP lease renewal or an IP change (including IPv6 renumbering),
+// previously announced A and AAAA records stay in peers' caches until their
+// TTL expires. Reload multicasts fresh records with the cache-flush bit set
+// (RFC 6762 §10.2; unless WithCacheFlushOnAnnounce disables it), so peers
+// replace the stale addresses immediately without an Unregister/Register
+// cycle. Call it from a DHCP or network-change hook.
+//
+// No re-probing is done: the service names are unchanged.
+//
+// Returns:
+//   - error: if no local address can be resolved, or the first announcement failure
+func (r *Responder) Reload() error {
+	return r.reload(false)
+}
+
+// reload is Reload; renamed marks a re-announcement after a hostname rename,
+// which sets the cache-flush bit whatever WithCacheFlushOnAnnounce says.
+func (r *Responder) reload(renamed bool) error {
+	ipv4, err := r.localIPv4()
+	if err != nil {
+		return fmt.Errorf("failed to get local IPv4: %w", err)
+	}
+
+	var firstErr error
+	for _, instanceName := range r.registry.List() {
+		svc, found := r.registry.Get(instanceName)
+		if !found {
+			continue // Unregistered concurrently
+		}
+		txt, _ := r.registry.TXT(instanceName)
+		if err := r.announce(svc.InstanceName, svc.ServiceType, r.hostnameFor(svc.Hostname), svc.Port, advertisedIPv4(svc.ProxyAddress, ipv4), svc.ProxyAddress != nil, txt, svc.PTROnly, svc.Subtypes, renamed); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to announce %q: %w", svc.InstanceName, err)
+		}
+	}
+
+	return firstErr
+}
+
+// AnnounceOnInterface re-announces every registered service out interface
+// ifIndex only, advertising that interface's IPv4 address.
+//
+// When an interface comes up, peers on the new link have never seen the
+// services, while those on the other links already hold current records.
+// Reload would re-announce to all of them; call AnnounceOnInterface from a
+// network-change hook instead for the newly-up interface.
+//
+// Returns:
+//   - error: ValidationError for an index of 0 or one excluded by
+//     WithInterfaceFilter; otherwise the first announcement failure
+func (r *Responder) AnnounceOnInterface(ifIndex int) error {
+	if ifIndex <= 0 || !r.servesInterface(ifIndex) {
+		return &errors.ValidationError{
+			Field:   "ifIndex",
+			Value:   ifIndex,
+			Message: "interface index must be positive and accepted by the interface filter",
+		}
+	}
 
-	// Convert to message.ResourceRecord for BuildResponse
-	msgRecords := make([]*message.ResourceRecord, len(announcedRecords))
-	for i, rr := range announcedRecords {
-		msgRecords[i] = &message.ResourceRecord{
-			Name:       rr.Name,
-			Type:       rr.Type,
-			Class:      rr.Class,
-			TTL:        rr.TTL,
-			Data:       rr.Data,
-			CacheFlush: rr.CacheFlush,
+	var firstErr error
+	for _, instanceName := range r.registry.List() {
+		svc, found := r.registry.Get(instanceName)
+		if !found {
+			continue // Unregistered concurrently
+		}
+		if err := r.announceOnInterface(svc, ifIndex); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to announce %q on interface %d: %w", svc.InstanceName, ifIndex, err)
 		}
 	}
+	return firstErr
+}
 
-	responseBytes, err := message.BuildResponse(msgRecords)
+// announceOnInterface multicasts service's record set out interface ifIndex
+// only, carrying every address valid on that interface as a response there
+// would (RFC 6762 §15): its IPv4 address in the A record, its IPv6 address in
+// an AAAA record, and any further addresses in records of their own. The
+// records' multicast time is noted, so a query arriving within the second is
+// not answered with them again (RFC 6762 §6.2).
+func (r *Responder) announceOnInterface(service *responder.Service, ifIndex int) error {
+	ipv4, err := getIPv4ForInterface(r.interfaces(), ifIndex)
 	if err != nil {
-		return nil // Registry updated, announcement failed - best effort
+		return err
 	}
+	txt, _ := r.registry.TXT(service.InstanceName)
+	serviceInfo := buildServiceInfo(service.InstanceName, service.ServiceType, r.hostnameFor(service.Hostname), service.Port, advertisedIPv4(service.ProxyAddress, ipv4), txt, service.PTROnly, service.Subtypes)
+	r.addInterfaceAddresses(serviceInfo, service.ProxyAddress != nil, ifIndex)
+	announced := r.announcedRecords(records.BuildRecordSet(serviceInfo), false)
 
-	_ = r.transport.Send(r.ctx, responseBytes, protocol.MulticastGroupIPv4()) // nosemgrep: beacon-error-swallowing
+	responseBytes, err := message.BuildResponse(announced)
+	if err != nil {
+		return err
+	}
+	if err := r.transport.SendOnInterface(r.ctx, responseBytes, protocol.MulticastGroupIPv4(), ifIndex); err != nil {
+		return err
+	}
+	r.recordMulticast(announced, ifIndex)
+	r.markAnnounced(service.InstanceName)
+	return nil
+}
 
+// addInterfaceAddresses adds to serviceInfo, whose A record carries the IPv4
+// address of interface ifIndex, the other addresses valid on that interface
+// (RFC 6762 §15): its IPv6 address in an AAAA record, and any further
+// addresses in records of their own. A proxied (RegisterProxy) or PTR-only
+// service advertises no address of this host's, so gets none.
+func (r *Responder) addInterfaceAddresses(serviceInfo *records.ServiceInfo, proxied bool, ifIndex int) {
+	if proxied || serviceInfo.PTROnly {
+		return
+	}
+	serviceInfo.IPv6Address = r.responseIPv6(ifIndex)
+	serviceInfo.ExtraIPv4Addresses, serviceInfo.ExtraIPv6Addresses = r.extraResponseAddresses(ifIndex, serviceInfo.IPv4Address, serviceInfo.IPv6Address)
+}
+
+// recordMulticast notes that rrs were just multicast on interface ifIndex,
+// for the RFC 6762 §6.2 rate limit applied to responses there.
+func (r *Responder) recordMulticast(rrs []*ResourceRecord, ifIndex int) {
+	if r.recordSet == nil {
+		return
+	}
+	// The rate-limit state is otherwise only touched by answerQuery
+	r.answerMu.Lock()
+	defer r.answerMu.Unlock()
+	for _, rr := range rrs {
+		r.recordSet.RecordMulticastByIndex(rr, ifIndex)
+	}
+}
+
+// announce multicasts one unsolicited response carrying the service's full
+// record set per RFC 6762 §8.3, including its subtype PTRs (RFC 6763 §7.1)
+// and the AAAA records of the host's default interface (see
+// addInterfaceAddresses; none if proxied). Unique records (SRV, TXT, A, AAAA)
+// carry the cache-flush bit so peers replace any stale data, unless disabled
+// by WithCacheFlushOnAnnounce and the records do not follow a rename.
+func (r *Responder) announce(instanceName, serviceType, hostname string, port uint16, ipv4 []byte, proxied bool, txt map[string]string, ptrOnly bool, subtypes []string, renamed bool) error {
+	serviceInfo := buildServiceInfo(instanceName, serviceType, hostname, port, ipv4, txt, ptrOnly, subtypes)
+	r.addInterfaceAddresses(serviceInfo, proxied, r.defaultInterface())
+	announcedRecords := r.announcedRecords(records.BuildRecordSet(serviceInfo), renamed)
+
+	responseBytes, err := message.BuildResponse(announcedRecords)
+	if err != nil {
+		return err
+	}
+
+	if err := r.transport.Send(r.ctx, responseBytes, protocol.MulticastGroupIPv4()); err != nil {
+		return err
+	}
+	r.markAnnounced(instanceName)
 	return nil
 }
+
+// announcedRecords returns recordSet as announced: unchanged by default, or
+// following a rename (renamed), else a copy with the cache-flush bit cleared
+// (WithCacheFlushOnAnnounce(false)).
+func (r *Responder) announcedRecords(recordSet []*ResourceRecord, renamed bool) []*ResourceRecord {
+	if !r.noAnnounceFlush || renamed {
+		return recordSet
+	}
+	announced := records.CloneRecordSet(recordSet)
+	for _, rr := range announced {
+		rr.CacheFlush = false
+	}
+	return announced
+}
@@ -0,0 +1,95 @@
+package responder
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+)
+
+// networkPollInterval is how often New re-checks the host's addresses while
+// waiting for the network (WithWaitForNetwork).
+const networkPollInterval = 250 * time.Millisecond
+
+// waitForNetwork blocks until an interface has a usable address
+// (hasUsableAddress), polling every networkPollInterval on the configured
+// clock, for at most timeout. New cancels the responder's context when it
+// fails.
+//
+// Returns:
+//   - error: NetworkError if no usable address appeared within timeout, or
+//     the context error if New's context was cancelled while waiting
+func (r *Responder) waitForNetwork(timeout time.Duration) error {
+	clk := clock.Or(r.clock)
+	deadline := clk.Now().Add(timeout)
+	for {
+		addrs, err := r.networkAddrs()
+		if err == nil && hasUsableAddress(addrs) {
+			return nil
+		}
+
+		remaining := deadline.Sub(clk.Now())
+		if remaining <= 0 {
+			if err == nil {
+				err = fmt.Errorf("no usable address")
+			}
+			return &errors.NetworkError{
+				Operation: "wait for network",
+				Err:       err,
+				Details:   fmt.Sprintf("no interface had a non-loopback IPv4 or routable IPv6 address within %v", timeout),
+			}
+		}
+
+		wait := networkPollInterval
+		if remaining < wait {
+			wait = remaining
+		}
+		r.log().Debug("waiting for a network address", "remaining", remaining)
+		select {
+		case <-r.ctx.Done():
+			return r.ctx.Err()
+		case <-clk.After(wait):
+		}
+	}
+}
+
+// hasUsableAddress reports whether addrs holds an address the responder can
+// advertise: a non-loopback IPv4 address, or an IPv6 address beyond link-local.
+// An IPv6 link-local address is configured as soon as an interface comes up,
+// so it does not show that the network (e.g. DHCP) is ready.
+func hasUsableAddress(addrs []net.Addr) bool {
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() || ipnet.IP.IsUnspecified() {
+			continue
+		}
+		if ipnet.IP.To4() != nil || !ipnet.IP.IsLinkLocalUnicast() {
+			return true
+		}
+	}
+	return false
+}
+
+// networkAddrs returns the addresses of the interfaces accepted by
+// WithInterfaceFilter, as reported by the responder's InterfaceResolver.
+func (r *Responder) networkAddrs() ([]net.Addr, error) {
+	resolver := r.interfaces()
+	ifaces, err := resolver.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var addrs []net.Addr
+	for _, iface := range ifaces {
+		if r.interfaceFilter != nil && !r.interfaceFilter(iface) {
+			continue
+		}
+		ifAddrs, err := resolver.InterfaceAddrs(iface.Index)
+		if err != nil {
+			continue // Interface vanished or unreadable; try the next
+		}
+		addrs = append(addrs, ifAddrs...)
+	}
+	return addrs, nil
+}
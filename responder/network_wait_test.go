@@ -0,0 +1,163 @@
+package responder
+
+import (
+	"context"
+	goerrors "errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+)
+
+// fakeAddrSource is an InterfaceResolver for a host with one interface whose
+// addresses can change while New waits for the network.
+type fakeAddrSource struct {
+	mu    sync.Mutex
+	addrs []net.Addr
+}
+
+func (s *fakeAddrSource) set(cidr string) {
+	ip, ipnet, _ := net.ParseCIDR(cidr)
+	ipnet.IP = ip
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addrs = []net.Addr{ipnet}
+}
+
+func (s *fakeAddrSource) Interfaces() ([]net.Interface, error) {
+	return []net.Interface{{Index: 1, Name: "eth0", Flags: net.FlagUp | net.FlagMulticast}}, nil
+}
+
+func (s *fakeAddrSource) InterfaceAddrs(int) ([]net.Addr, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]net.Addr(nil), s.addrs...), nil
+}
+
+// newInBackground runs New with opts in a goroutine, returning its result.
+func newInBackground(opts ...Option) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		r, err := New(context.Background(), opts...)
+		if err == nil {
+			_ = r.Close()
+		}
+		done <- err
+	}()
+	return done
+}
+
+// TestNew_WaitForNetwork verifies New waits while the host has only loopback
+// and link-local IPv6 addresses, then succeeds once an IPv4 address appears.
+func TestNew_WaitForNetwork(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	src := &fakeAddrSource{}
+	src.set("fe80::1/64")
+
+	done := newInBackground(
+		WithTransport(&MockTransport{}),
+		WithClock(fake),
+		WithWaitForNetwork(10*time.Second),
+		WithInterfaceResolver(src))
+
+	fake.WaitForWaiters(1)
+	select {
+	case err := <-done:
+		t.Fatalf("New() returned %v before the network was up, want it to wait", err)
+	default:
+	}
+
+	fake.Advance(networkPollInterval) // Still no usable address
+	fake.WaitForWaiters(1)
+	src.set("192.168.1.10/24")
+	fake.Advance(networkPollInterval)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("New() error = %v, want nil once an address appeared", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("New() did not return after an address appeared")
+	}
+}
+
+// TestNew_WaitForNetwork_Timeout verifies New fails with a NetworkError when
+// no usable address appears within the timeout, and that a non-positive
+// timeout is rejected.
+func TestNew_WaitForNetwork_Timeout(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	src := &fakeAddrSource{}
+
+	done := newInBackground(
+		WithTransport(&MockTransport{}),
+		WithClock(fake),
+		WithWaitForNetwork(time.Second),
+		WithInterfaceResolver(src))
+
+	for i := 0; i < 4; i++ {
+		fake.WaitForWaiters(1)
+		fake.Advance(networkPollInterval)
+	}
+
+	select {
+	case err := <-done:
+		var netErr *errors.NetworkError
+		if !goerrors.As(err, &netErr) {
+			t.Fatalf("New() error = %v, want NetworkError", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("New() did not return after the timeout")
+	}
+
+	if r, err := New(context.Background(), WithWaitForNetwork(0)); err == nil {
+		_ = r.Close()
+		t.Error("New(WithWaitForNetwork(0)) error = nil, want ValidationError")
+	}
+}
+
+// TestNetworkAddrs_InterfaceFilter verifies the addresses waited for come from
+// the InterfaceResolver and skip interfaces excluded by WithInterfaceFilter.
+func TestNetworkAddrs_InterfaceFilter(t *testing.T) {
+	r := &Responder{
+		interfaceResolver: StaticInterfaceResolver{1: "127.0.0.1/8", 2: "192.168.1.10/24"},
+		interfaceFilter:   func(iface net.Interface) bool { return iface.Index != 2 },
+	}
+	addrs, err := r.networkAddrs()
+	if err != nil {
+		t.Fatalf("networkAddrs() error = %v", err)
+	}
+	if hasUsableAddress(addrs) {
+		t.Errorf("networkAddrs() = %v, want only the loopback address of interface 1", addrs)
+	}
+
+	r.interfaceFilter = nil
+	if addrs, _ := r.networkAddrs(); !hasUsableAddress(addrs) {
+		t.Errorf("networkAddrs() without a filter = %v, want interface 2's address", addrs)
+	}
+}
+
+// TestHasUsableAddress verifies which addresses show the network is ready.
+func TestHasUsableAddress(t *testing.T) {
+	tests := []struct {
+		cidr string
+		want bool
+	}{
+		{"192.168.1.10/24", true},
+		{"169.254.3.4/16", true},
+		{"2001:db8::10/64", true},
+		{"127.0.0.1/8", false},
+		{"::1/128", false},
+		{"fe80::1/64", false},
+	}
+	for _, tt := range tests {
+		ip, ipnet, _ := net.ParseCIDR(tt.cidr)
+		ipnet.IP = ip
+		if got := hasUsableAddress([]net.Addr{ipnet}); got != tt.want {
+			t.Errorf("hasUsableAddress(%s) = %v, want %v", tt.cidr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package responder
+
+import (
+	"fmt"
+
+	"github.com/joshuafuller/beacon/internal/state"
+)
+
+// EventType identifies a step in a service's lifecycle; see Observer.
+type EventType int
+
+const (
+	// EventProbeStarted marks the start of probing for a name (RFC 6762 §8.1).
+	// It is emitted again for each renamed attempt.
+	EventProbeStarted EventType = iota + 1
+
+	// EventProbeSent marks one probe query sent; Event.Count is its 1-based
+	// number within the current probing attempt.
+	EventProbeSent
+
+	// EventConflictDetected marks a probe conflict for Event.Instance: another
+	// host already uses the name (RFC 6762 §8.1, §9). It is also emitted when
+	// the host is renamed while the instance is probing
+	// (WithConflictHostRename); probing then restarts under the same instance
+	// name with the new hostname.
+	EventConflictDetected
+
+	// EventRenamed marks a rename after a conflict, from Event.Previous to
+	// Event.Instance (RFC 6762 §9).
+	EventRenamed
+
+	// EventAnnounceSent marks one unsolicited announcement sent; Event.Count
+	// is its 1-based number (RFC 6762 §8.3).
+	EventAnnounceSent
+
+	// EventEstablished marks the end of announcing: the service is registered
+	// and answers queries.
+	EventEstablished
+
+	// EventGoodbyeSent marks one TTL=0 goodbye packet sent for a departing
+	// service; Event.Count is its 1-based copy number (RFC 6762 §10.1,
+	// WithGoodbyeCount).
+	EventGoodbyeSent
+)
+
+// String returns the event type's name.
+func (t EventType) String() string {
+	switch t {
+	case EventProbeStarted:
+		return "ProbeStarted"
+	case EventProbeSent:
+		return "ProbeSent"
+	case EventConflictDetected:
+		return "ConflictDetected"
+	case EventRenamed:
+		return "Renamed"
+	case EventAnnounceSent:
+		return "AnnounceSent"
+	case EventEstablished:
+		return "Established"
+	case EventGoodbyeSent:
+		return "GoodbyeSent"
+	default:
+		return fmt.Sprintf("EventType(%d)", int(t))
+	}
+}
+
+// Event is one step in a service's lifecycle, delivered to an Observer.
+type Event struct {
+	// Type is the lifecycle step.
+	Type EventType
+
+	// Instance is the service instance name the event concerns, as it stood
+	// at the time (after the rename, for EventRenamed).
+	Instance string
+
+	// Previous is the instance name before the rename (EventRenamed only).
+	Previous string
+
+	// Count numbers repeated events, starting at 1: the probe
+	// (EventProbeSent), announcement (EventAnnounceSent) or goodbye copy
+	// (EventGoodbyeSent). Zero for other types.
+	Count int
+}
+
+// Observer receives a structured stream of service lifecycle events (probing,
+// conflicts and renames, announcing, goodbyes), e.g. to drive metrics or a
+// UI; see WithObserver.
+//
+// OnEvent is called synchronously from the goroutine doing the work (Register,
+// Unregister, Close, or a goodbye retransmission timer), possibly from several
+// goroutines at once. It must return quickly and must not call back into the
+// Responder.
+type Observer interface {
+	OnEvent(Event)
+}
+
+// ObserverFunc adapts an ordinary function to the Observer interface.
+type ObserverFunc func(Event)
+
+// OnEvent calls f(ev).
+func (f ObserverFunc) OnEvent(ev Event) {
+	f(ev)
+}
+
+// emit delivers ev to the observer, if any (WithObserver).
+func (r *Responder) emit(ev Event) {
+	if r.observer != nil {
+		r.observer.OnEvent(ev)
+	}
+}
+
+// observeMachine wires a registration attempt's state machine to the
+// observer, the status reported by Services, the services answered while
+// announcing (WithAnswerDuringAnnounce), and the OnProbe/OnAnnounce test
+// callbacks.
+//
+// State changes become ProbeStarted, ConflictDetected and Established events;
+// each probe and announcement sent becomes a numbered ProbeSent or
+// AnnounceSent event. service carries the name being claimed by this attempt;
+// requested is the name it was registered with.
+func (r *Responder) observeMachine(machine *state.Machine, service *Service, requested string) {
+	instanceName := service.InstanceName
+	machine.SetOnStateChange(func(s state.State) {
+		r.updateStatus(service, requested, func(st *ServiceStatus) { st.State = s })
+		switch s {
+		case state.StateProbing:
+			r.emit(Event{Type: EventProbeStarted, Instance: instanceName})
+		case state.StateAnnouncing:
+			r.startAnswering(service) // WithAnswerDuringAnnounce
+		case state.StateConflictDetected:
+			r.emit(Event{Type: EventConflictDetected, Instance: instanceName})
+		case state.StateEstablished:
+			r.emit(Event{Type: EventEstablished, Instance: instanceName})
+		}
+	})
+
+	// OnProbe/OnAnnounce callbacks, as set when the attempt starts
+	r.hooksMu.Lock()
+	onProbe, onAnnounce := r.onProbeCallback, r.onAnnounceCallback
+	r.hooksMu.Unlock()
+
+	probes := 0
+	machine.GetProber().SetOnSendQuery(func() {
+		probes++
+		r.emit(Event{Type: EventProbeSent, Instance: instanceName, Count: probes})
+		if onProbe != nil {
+			onProbe()
+		}
+	})
+
+	announcements := 0
+	machine.GetAnnouncer().SetOnSendAnnouncement(func() {
+		announcements++
+		r.markAnnounced(instanceName)
+		r.emit(Event{Type: EventAnnounceSent, Instance: instanceName, Count: announcements})
+		if onAnnounce != nil {
+			onAnnounce()
+		}
+	})
+}
@@ -0,0 +1,202 @@
+package responder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+)
+
+// eventRecorder is an Observer collecting events for inspection.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (er *eventRecorder) OnEvent(ev Event) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.events = append(er.events, ev)
+}
+
+// summary renders the events as "Type(instance)#count" strings for comparison.
+func (er *eventRecorder) summary() []string {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	out := make([]string, 0, len(er.events))
+	for _, ev := range er.events {
+		s := fmt.Sprintf("%s(%s)", ev.Type, ev.Instance)
+		if ev.Previous != "" {
+			s = fmt.Sprintf("%s(%s→%s)", ev.Type, ev.Previous, ev.Instance)
+		}
+		if ev.Count > 0 {
+			s += fmt.Sprintf("#%d", ev.Count)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// newObservedResponder creates a responder on a fake clock reporting to a
+// new eventRecorder, with goodbyes sent once.
+func newObservedResponder(t *testing.T) (*Responder, *clock.Fake, *eventRecorder) {
+	t.Helper()
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := &eventRecorder{}
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithObserver(rec))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	return r, fake, rec
+}
+
+// registerOnFakeClock runs Register, advancing fake whenever it waits, and
+// returns its error.
+func registerOnFakeClock(t *testing.T, r *Responder, fake *clock.Fake, service *Service) error {
+	t.Helper()
+	return runOnFakeClock(t, fake, func() error { return r.Register(service) })
+}
+
+// runOnFakeClock runs register (Register or a variant), advancing fake
+// whenever it waits, and returns its error.
+func runOnFakeClock(t *testing.T, fake *clock.Fake, register func() error) error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- register() }()
+
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-deadline:
+			t.Fatal("Register() did not complete")
+		default:
+		}
+		if fake.Waiters() > 0 {
+			fake.Advance(time.Second) // Covers both the 250ms and 1s waits
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestObserver_CleanRegistration verifies the event sequence of a
+// registration without conflicts and of the goodbye on Unregister
+// (RFC 6762 §8.1, §8.3, §10.1).
+func TestObserver_CleanRegistration(t *testing.T) {
+	r, fake, rec := newObservedResponder(t)
+
+	service := &Service{InstanceName: "Observed", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := r.Unregister("Observed"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+
+	want := []string{
+		"ProbeStarted(Observed)",
+		"ProbeSent(Observed)#1", "ProbeSent(Observed)#2", "ProbeSent(Observed)#3",
+		"AnnounceSent(Observed)#1", "AnnounceSent(Observed)#2",
+		"Established(Observed)",
+		"GoodbyeSent(Observed)#1",
+	}
+	if got := rec.summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events =\n  %v\nwant\n  %v", got, want)
+	}
+}
+
+// TestObserver_GoodbyeSendFailure verifies Unregister reports a goodbye that
+// could not be sent, emits no EventGoodbyeSent for it, and still removes
+// the service.
+func TestObserver_GoodbyeSendFailure(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := &eventRecorder{}
+	var failing atomic.Bool
+	sendErr := errors.New("network unreachable")
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(context.Context, []byte, net.Addr) error {
+			if failing.Load() {
+				return sendErr
+			}
+			return nil
+		}}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithObserver(rec))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	service := &Service{InstanceName: "Observed", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	failing.Store(true)
+	if err := r.Unregister("Observed"); !errors.Is(err, sendErr) {
+		t.Errorf("Unregister() error = %v, want %v", err, sendErr)
+	}
+	if _, ok := r.GetService("Observed"); ok {
+		t.Error("service still registered after a failed goodbye")
+	}
+	for _, ev := range rec.summary() {
+		if strings.HasPrefix(ev, "GoodbyeSent") {
+			t.Errorf("event %s emitted for a goodbye that was not sent", ev)
+		}
+	}
+}
+
+// TestObserver_ConflictThenRename verifies a probe conflict is reported,
+// followed by the rename and a fresh probing sequence under the new name
+// (RFC 6762 §9).
+func TestObserver_ConflictThenRename(t *testing.T) {
+	r, fake, rec := newObservedResponder(t)
+	r.InjectConflictForAttempts(1)
+
+	service := &Service{InstanceName: "Observed", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	want := []string{
+		"ProbeStarted(Observed)",
+		"ProbeSent(Observed)#1", "ProbeSent(Observed)#2", "ProbeSent(Observed)#3",
+		"ConflictDetected(Observed)",
+		"Renamed(Observed→Observed-2)",
+		"ProbeStarted(Observed-2)",
+		"ProbeSent(Observed-2)#1", "ProbeSent(Observed-2)#2", "ProbeSent(Observed-2)#3",
+		"AnnounceSent(Observed-2)#1", "AnnounceSent(Observed-2)#2",
+		"Established(Observed-2)",
+	}
+	if got := rec.summary(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events =\n  %v\nwant\n  %v", got, want)
+	}
+}
+
+// TestWithObserver_Nil verifies a nil observer is rejected.
+func TestWithObserver_Nil(t *testing.T) {
+	r, err := New(context.Background(), WithTransport(&MockTransport{}), WithObserver(nil))
+	if err == nil {
+		_ = r.Close()
+		t.Fatal("New(WithObserver(nil)) error = nil, want ValidationError")
+	}
+}
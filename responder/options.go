@@ -1,6 +1,18 @@
 package responder
 
 import (
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/responder"
 	"github.com/joshuafuller/beacon/internal/security"
 	"github.com/joshuafuller/beacon/internal/transport"
 )
@@ -73,7 +85,880 @@ func WithRateLimiter(rl *security.RateLimiter) Option {
 // T044: WithHostname option
 func WithHostname(hostname string) Option {
 	return func(r *Responder) error {
-		r.hostname = hostname
+		r.setHostname(hostname)
+		return nil
+	}
+}
+
+// WithDefaultTXT sets TXT key/value pairs applied to every registered service.
+//
+// Defaults are merged under each service's own TXTRecords: when a key appears in
+// both, the service-level value wins. This lets callers set common DNS-SD keys
+// (e.g. "txtvers", a model string, a path) once instead of repeating them on
+// every Service.
+//
+// If both the defaults and a service's TXTRecords are empty, the service still
+// advertises the mandatory single 0x00-byte TXT record per RFC 6763 §6.
+//
+// Parameters:
+//   - txt: Default TXT key/value pairs (copied; later changes have no effect)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithDefaultTXT(map[string]string{"txtvers": "1"}))
+func WithDefaultTXT(txt map[string]string) Option {
+	return func(r *Responder) error {
+		if len(txt) == 0 {
+			r.defaultTXT = nil
+			return nil
+		}
+		r.defaultTXT = make(map[string]string, len(txt))
+		for k, v := range txt {
+			r.defaultTXT[k] = v
+		}
+		return nil
+	}
+}
+
+// FallbackBehavior defines how the responder handles a failure to resolve the
+// IPv4 address of the interface a query arrived on.
+//
+// 007-interface-specific-addressing: Mirrors the FallbackBehavior contract in
+// specs/007-interface-specific-addressing/contracts/interface_resolver.go.
+type FallbackBehavior int
+
+const (
+	// SkipResponse does not send a response when the interface lookup fails.
+	//
+	// This is strict RFC 6762 §15 compliance: it is better not to respond than
+	// to advertise an address that is not valid on the receiving interface.
+	SkipResponse FallbackBehavior = iota
+
+	// UseGlobalIP falls back to the first non-loopback IPv4 address on the host
+	// when the interface lookup fails (best-effort).
+	//
+	// Tradeoff: On multi-interface hosts this may advertise an address from a
+	// different interface, violating RFC 6762 §15.
+	UseGlobalIP
+)
+
+// String returns a human-readable name for the fallback behavior.
+func (f FallbackBehavior) String() string {
+	switch f {
+	case SkipResponse:
+		return "SkipResponse"
+	case UseGlobalIP:
+		return "UseGlobalIP"
+	default:
+		return "Unknown"
+	}
+}
+
+// InterfaceResolutionPolicy defines how the responder handles interface
+// resolution failures while building responses.
+//
+// It applies only when the receiving interface is known (interfaceIndex > 0)
+// but its IPv4 address cannot be determined (interface removed, down, or
+// IPv6-only). When the interface is unknown (interfaceIndex = 0, e.g. control
+// messages unsupported), the responder always degrades to the default IPv4.
+type InterfaceResolutionPolicy struct {
+	// FallbackOnError defines behavior when the interface lookup fails.
+	FallbackOnError FallbackBehavior
+
+	// LogFailures emits a warning (via the responder's logger) for each
+	// interface resolution failure.
+	LogFailures bool
+}
+
+// DefaultInterfaceResolutionPolicy returns the recommended policy:
+// SkipResponse on error (strict RFC 6762 §15 compliance) with LogFailures enabled.
+func DefaultInterfaceResolutionPolicy() InterfaceResolutionPolicy {
+	return InterfaceResolutionPolicy{
+		FallbackOnError: SkipResponse,
+		LogFailures:     true,
+	}
+}
+
+// WithInterfaceResolutionPolicy sets how the responder reacts when it cannot
+// resolve the IPv4 address of the interface a query was received on.
+//
+// If not provided, DefaultInterfaceResolutionPolicy() is used.
+//
+// Parameters:
+//   - policy: Interface resolution policy
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithInterfaceResolutionPolicy(InterfaceResolutionPolicy{
+//	    FallbackOnError: UseGlobalIP,
+//	}))
+//
+// RFC 6762 §15: Responses MUST NOT include addresses not valid on the interface.
+func WithInterfaceResolutionPolicy(policy InterfaceResolutionPolicy) Option {
+	return func(r *Responder) error {
+		r.resolutionPolicy = policy
+		return nil
+	}
+}
+
+// WithInterfaceResolver sets how the responder looks up the host's interfaces
+// and their addresses: the IPv4 address advertised in a response (RFC 6762
+// §15), the subnet the query's source must be on (RFC 6762 §6.4), the
+// address registered services advertise, from an interface accepted by
+// WithInterfaceFilter, and the addresses WithWaitForNetwork waits for.
+//
+// The default reads the host's interfaces. Tests supply a
+// StaticInterfaceResolver to simulate a multi-NIC host, typically together
+// with WithTransport delivering queries on chosen interface indexes.
+//
+// Parameters:
+//   - resolver: Interface address lookup (must not be nil)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx,
+//	    WithTransport(mock),
+//	    WithInterfaceResolver(StaticInterfaceResolver{1: "10.0.1.10/24", 2: "10.0.2.10/24"}))
+func WithInterfaceResolver(resolver InterfaceResolver) Option {
+	return func(r *Responder) error {
+		if resolver == nil {
+			return &errors.ValidationError{
+				Field:   "interfaceResolver",
+				Value:   nil,
+				Message: "interface resolver cannot be nil",
+			}
+		}
+
+		r.interfaceResolver = resolver
+		return nil
+	}
+}
+
+// WithInterfaceFilter restricts the network interfaces the responder serves,
+// mirroring querier.WithInterfaceFilter. The filter is called with an
+// interface; return true to serve it.
+//
+// On a filtered-out interface the responder does not join the mDNS group,
+// ignores queries arriving there, and never advertises the interface's
+// addresses: the default A record comes from the first accepted interface
+// with an IPv4 address. Use it, for example, to keep services off a guest
+// VLAN.
+//
+// Queries whose receiving interface is unknown (interface index 0, on
+// platforms without control messages) cannot be attributed and are answered.
+//
+// Parameters:
+//   - filter: Interface selection function (nil serves every interface)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithInterfaceFilter(func(iface net.Interface) bool {
+//	    return iface.Name != "guest0"
+//	}))
+func WithInterfaceFilter(filter func(net.Interface) bool) Option {
+	return func(r *Responder) error {
+		r.interfaceFilter = filter
+		return nil
+	}
+}
+
+// WithLoopbackAdvertise makes services discoverable by processes on the same
+// host over the loopback interface, for developer workflows and single-host
+// service meshes.
+//
+// The responder joins the mDNS group on the loopback interface (which Linux
+// does not mark multicast-capable, so it is skipped by default) and loops its
+// multicast back to this host. A query arriving on loopback is answered with
+// the loopback address (127.0.0.1), per RFC 6762 §15. Where no other IPv4
+// address is available, including when WithInterfaceFilter accepts only the
+// loopback interface, services are registered advertising 127.0.0.1 rather
+// than failing.
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithLoopbackAdvertise(), WithInterfaceFilter(func(iface net.Interface) bool {
+//	    return iface.Flags&net.FlagLoopback != 0
+//	}))
+func WithLoopbackAdvertise() Option {
+	return func(r *Responder) error {
+		r.loopbackAdvertise = true
+		return nil
+	}
+}
+
+// WithLogger sets the structured logger used for responder diagnostics.
+//
+// If not provided (or nil), slog.Default() is used. The responder never
+// creates its own logger.
+//
+// Parameters:
+//   - logger: Structured logger
+//
+// Returns:
+//   - Option: Configuration function
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Responder) error {
+		r.logger = logger
+		return nil
+	}
+}
+
+// Direction identifies whether a packet passed to a PacketHook was sent or received.
+type Direction = transport.Direction
+
+const (
+	// Sent marks a packet the responder transmitted (probes, announcements,
+	// responses, goodbyes).
+	Sent = transport.Sent
+
+	// Received marks a packet the responder read from the network.
+	Received = transport.Received
+)
+
+// PacketHook observes a raw mDNS packet; see WithPacketHook.
+type PacketHook = transport.PacketHook
+
+// WithPacketHook installs a hook invoked for every packet the responder's
+// transport sends and receives.
+//
+// Debugging mDNS interop issues almost always requires the raw bytes on the
+// wire; the hook is the tap point for dumping pcaps or pretty-printing traffic.
+// It runs synchronously on the send/receive path, so it should be fast, and it
+// must not modify or retain the packet slice. The hook wraps whichever
+// transport is in effect, including one supplied via WithTransport.
+//
+// When no hook is set the transport is used directly, with zero overhead.
+//
+// Parameters:
+//   - hook: Called with the direction, wire-format packet, peer address and
+//     receiving interface index (0 for sent packets)
+//
+// Returns:
+//   - Option: Configuration function
+func WithPacketHook(hook PacketHook) Option {
+	return func(r *Responder) error {
+		r.packetHook = hook
+		return nil
+	}
+}
+
+// WithReadBufferSize sets the socket receive buffer (SO_RCVBUF) size in bytes.
+//
+// The default is 64KB. A responder answering large browses on a busy network
+// may need a larger buffer to avoid dropped queries; constrained embedded
+// devices may want a smaller one. The minimum is 9000 bytes, one maximum-size
+// mDNS packet (RFC 6762 §17). The OS may round or cap the value. Ignored when
+// a transport is supplied via WithTransport.
+//
+// Parameters:
+//   - bytes: Receive buffer size (≥ 9000)
+//
+// Returns:
+//   - Option: Configuration function
+func WithReadBufferSize(bytes int) Option {
+	return func(r *Responder) error {
+		if bytes < transport.MinReadBufferSize {
+			return &errors.ValidationError{
+				Field:   "readBufferSize",
+				Value:   bytes,
+				Message: fmt.Sprintf("read buffer size must be at least %d bytes", transport.MinReadBufferSize),
+			}
+		}
+
+		r.readBufferSize = bytes
+		return nil
+	}
+}
+
+// WithMulticastTTL sets the IP TTL of the responder's multicast packets.
+//
+// RFC 6762 §11: mDNS packets SHOULD be sent with IP TTL 255, which the
+// default provides; receivers may discard packets with a lower TTL as
+// possibly forwarded from off-link. Lower it only for interoperability with
+// equipment that mishandles TTL 255. Setting the TTL is best-effort: if the
+// platform refuses it, the OS default is used and a warning is logged.
+// Ignored when a transport is supplied via WithTransport.
+//
+// Parameters:
+//   - ttl: IP TTL (1-255)
+//
+// Returns:
+//   - Option: Configuration function
+func WithMulticastTTL(ttl int) Option {
+	return func(r *Responder) error {
+		if ttl < 1 || ttl > 255 {
+			return &errors.ValidationError{
+				Field:   "multicastTTL",
+				Value:   ttl,
+				Message: "multicast TTL must be between 1 and 255",
+			}
+		}
+
+		r.multicastTTL = ttl
+		return nil
+	}
+}
+
+// WithConflictHostRename enables renaming the host when another device claims
+// its hostname.
+//
+// RFC 6762 §9: Conflict resolution applies to the host's address records as
+// well as to service instance names. When enabled, a received response
+// carrying an A record for the responder hostname with an address not
+// belonging to this host is treated as a conflict: the host is renamed
+// ("myhost.local" → "myhost-2.local", then "-3", ...), every service using the
+// responder hostname follows (SRV targets and A records), and all services are
+// re-announced. Services with their own Service.Hostname are not renamed.
+//
+// Probes claim the hostname along with the service name (RFC 6762 §8.1). A
+// conflict on it seen while a service is probing renames the host the same
+// way, and that service is probed again under the new hostname before it is
+// announced.
+//
+// Disabled by default, since a hostname change is visible to users. Without
+// it a conflict on the hostname is only logged, except that a service
+// probing at the time fails to register with an error wrapping
+// ErrHostnameConflict. Re-probing after a host rename does not count against
+// a service's rename attempts.
+//
+// Parameters:
+//   - enabled: true to rename the host on conflict
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithHostname("myhost.local"), WithConflictHostRename(true))
+func WithConflictHostRename(enabled bool) Option {
+	return func(r *Responder) error {
+		r.conflictHostRename = enabled
+		return nil
+	}
+}
+
+// WithNoRename disables automatic renaming on a name conflict.
+//
+// By default a service whose name is already in use on the network is renamed
+// per RFC 6762 §9 ("My Service" → "My Service-2") and probed again. With
+// WithNoRename, Register instead fails at the first conflict with an error
+// wrapping ErrNameConflict, leaving the service unregistered, for
+// applications that must advertise an exact name (licensing, fixed URLs).
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, _ := New(ctx, WithNoRename())
+//	if err := r.Register(svc); errors.Is(err, ErrNameConflict) {
+//	    log.Printf("%q is taken on this network", svc.InstanceName)
+//	}
+func WithNoRename() Option {
+	return func(r *Responder) error {
+		r.noRename = true
+		return nil
+	}
+}
+
+// WithWaitForNetwork makes New wait, up to timeout, for a network interface
+// to have a usable address before binding: a non-loopback IPv4 address or a
+// routable IPv6 address (link-local IPv6 does not count, as it is configured
+// before DHCP completes). Interfaces excluded by WithInterfaceFilter are
+// ignored.
+//
+// This closes a common race on embedded devices, where a service started
+// early in boot would otherwise bind before the network is up and advertise
+// no usable address. If no address appears in time, New returns a
+// NetworkError.
+//
+// Parameters:
+//   - timeout: Maximum time to wait (> 0)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithWaitForNetwork(30*time.Second))
+func WithWaitForNetwork(timeout time.Duration) Option {
+	return func(r *Responder) error {
+		if timeout <= 0 {
+			return &errors.ValidationError{
+				Field:   "networkWait",
+				Value:   timeout,
+				Message: "network wait timeout must be positive",
+			}
+		}
+
+		r.networkWait = timeout
+		return nil
+	}
+}
+
+// WithDeduplicateReceives controls whether received packets identical to one
+// received from the same source within the last second are dropped.
+//
+// On a multi-homed host whose interfaces share a link, every multicast query
+// is delivered once per interface, and the responder would answer each copy.
+// With deduplication enabled only the first copy is processed.
+//
+// Default: Disabled (false)
+//
+// Parameters:
+//   - enabled: Whether to drop duplicate packets
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithDeduplicateReceives(true))
+func WithDeduplicateReceives(enabled bool) Option {
+	return func(r *Responder) error {
+		r.dedupReceives = enabled
+		return nil
+	}
+}
+
+// WithSkipAnnounce makes Register skip the announcing phase: once probing
+// succeeds the service is established and answers queries, but no
+// unsolicited announcements are sent. Register returns about a second sooner.
+//
+// RFC 6762 §8.3 requires at least two announcements so that peers learn of
+// the service (and flush stale cache entries) without asking. Use this only
+// where nothing relies on them, e.g. tests, or a controlled network whose
+// queriers poll. UpdateService and Reload still announce.
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithSkipAnnounce())
+func WithSkipAnnounce() Option {
+	return func(r *Responder) error {
+		r.skipAnnounce = true
+		return nil
+	}
+}
+
+// WithCacheFlushOnAnnounce controls whether announcements set the cache-flush
+// bit on unique records (SRV, TXT, A, AAAA).
+//
+// RFC 6762 §10.2: the bit tells peers to replace cached copies of a record
+// with the announced one, and is set by default. Some older responders
+// mishandle it on every announcement; disabling it leaves peers' caches to
+// expire stale copies by TTL. It is cleared on the announcements of Register,
+// UpdateService and Reload. Goodbyes, and the announcements that follow a
+// rename of a service or the host after a conflict (RFC 6762 §9), still set
+// it, as peers must drop the records they replace.
+//
+// Parameters:
+//   - enabled: false to clear the cache-flush bit on announcements
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithCacheFlushOnAnnounce(false))
+func WithCacheFlushOnAnnounce(enabled bool) Option {
+	return func(r *Responder) error {
+		r.noAnnounceFlush = !enabled
+		return nil
+	}
+}
+
+// WithAnswerDuringAnnounce controls whether a service is answered for while
+// it is still announcing.
+//
+// By default the responder answers queries for a service only once it is
+// established, so nothing is said for a name that has not finished claiming
+// it (RFC 6762 §8). Enabled, a service is answered for from its first
+// announcement (RFC 6762 §8.3): its name is already won, as probing has
+// finished, and queriers arriving during the ~1s announcing window are
+// answered at once instead of waiting for the second announcement. Service
+// type enumeration (RFC 6763 §9) still lists established services only.
+//
+// Parameters:
+//   - enabled: true to answer for announcing services
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithAnswerDuringAnnounce(true))
+func WithAnswerDuringAnnounce(enabled bool) Option {
+	return func(r *Responder) error {
+		r.answerAnnouncing = enabled
+		return nil
+	}
+}
+
+// WithMaxServices caps the number of services the responder holds, as a
+// guard against a buggy or malicious caller exhausting memory or bloating
+// responses. Once n services are registered, Register fails with an error
+// wrapping ErrServiceLimitReached until one is unregistered.
+//
+// The default is unlimited.
+//
+// Parameters:
+//   - n: Maximum number of registered services (at least 1)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithMaxServices(100))
+func WithMaxServices(n int) Option {
+	return func(r *Responder) error {
+		if n < 1 {
+			return &errors.ValidationError{
+				Field:   "maxServices",
+				Value:   n,
+				Message: "maximum number of services must be at least 1",
+			}
+		}
+
+		r.registry.SetLimit(n)
+		return nil
+	}
+}
+
+// WithGoodbyeCount sets how many times each goodbye packet is sent when a
+// service is unregistered or the responder closes.
+//
+// RFC 6762 §10.1: Goodbye packets (TTL=0) are best-effort, and a lost one
+// leaves peers caching the service until its TTL expires. The default of 2
+// tolerates a single lost packet; a lossy network may warrant 3, a quiet one 1.
+// Copies are spaced by WithGoodbyeInterval.
+//
+// Parameters:
+//   - n: Number of copies of each goodbye (≥ 1)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithGoodbyeCount(3), WithGoodbyeInterval(500*time.Millisecond))
+func WithGoodbyeCount(n int) Option {
+	return func(r *Responder) error {
+		if n < 1 {
+			return &errors.ValidationError{
+				Field:   "goodbyeCount",
+				Value:   n,
+				Message: "goodbye count must be at least 1",
+			}
+		}
+
+		r.goodbyeCount = n
+		return nil
+	}
+}
+
+// WithGoodbyeInterval sets the spacing between copies of a goodbye packet
+// (see WithGoodbyeCount). The default is 250ms.
+//
+// Close waits for all copies before closing the transport, so it blocks for
+// (count-1) × interval when services are registered.
+//
+// Parameters:
+//   - d: Interval between goodbye copies (> 0)
+//
+// Returns:
+//   - Option: Configuration function
+func WithGoodbyeInterval(d time.Duration) Option {
+	return func(r *Responder) error {
+		if d <= 0 {
+			return &errors.ValidationError{
+				Field:   "goodbyeInterval",
+				Value:   d,
+				Message: "goodbye interval must be positive",
+			}
+		}
+
+		r.goodbyeInterval = d
+		return nil
+	}
+}
+
+// WithMaxResponseSize caps the serialized size of responses.
+//
+// RFC 6762 §17: a Multicast DNS packet MUST NOT exceed 9000 bytes, which is
+// the default. Constrained links (e.g. Thread or other 6LoWPAN networks) may
+// need smaller responses to avoid IP fragmentation. When a response would
+// exceed the cap, the answer section is kept intact and additional records
+// (SRV, TXT, A accompanying a PTR answer) are dropped, later ones first. The
+// querier can then ask for the missing records directly. Only a legacy
+// unicast response (RFC 6762 §6.7) is marked with the TC bit; mDNS responses
+// never are (§18.5).
+//
+// Parameters:
+//   - bytes: Maximum response size (512-9000)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithMaxResponseSize(1232))
+func WithMaxResponseSize(bytes int) Option {
+	return func(r *Responder) error {
+		if bytes < responder.MinPacketSize || bytes > responder.MaxPacketSize {
+			return &errors.ValidationError{
+				Field:   "maxResponseSize",
+				Value:   bytes,
+				Message: fmt.Sprintf("max response size must be between %d and %d bytes", responder.MinPacketSize, responder.MaxPacketSize),
+			}
+		}
+
+		r.responseBuilder.SetMaxPacketSize(bytes)
+		return nil
+	}
+}
+
+// DNSMessage is a parsed DNS message, as passed to and returned by a
+// ResponseBuilder.
+type DNSMessage = message.DNSMessage
+
+// Answer is one resource record in a DNSMessage section, in wire form.
+type Answer = message.Answer
+
+// ServiceWithIP is a registered service as handed to a ResponseBuilder: its
+// configuration plus the hostname and IPv4 address advertised on the
+// receiving interface.
+type ServiceWithIP = responder.ServiceWithIP
+
+// ResponseBuilder builds the records one service contributes to a response;
+// see WithResponseBuilder.
+type ResponseBuilder interface {
+	// BuildResponse returns the answer and additional records service
+	// contributes in reply to query, which carries a single question and the
+	// querier's known answers (RFC 6762 §7.1). A returned error drops the
+	// response.
+	BuildResponse(service *ServiceWithIP, query *DNSMessage) (*DNSMessage, error)
+}
+
+// DefaultResponseBuilder returns the builder used when WithResponseBuilder is
+// not given, for custom builders to delegate to.
+//
+// Returns:
+//   - ResponseBuilder: Builds the RFC 6763 §12 records (PTR, SRV, TXT, A)
+func DefaultResponseBuilder() ResponseBuilder {
+	return responder.NewResponseBuilder()
+}
+
+// WithResponseBuilder replaces the builder that shapes each matched service's
+// records in query responses, e.g. to add vendor-specific TXT keys or omit
+// records on certain interfaces.
+//
+// The builder is called once per matched service and question. Its answer and
+// additional records are merged into the response, which the responder still
+// deduplicates, rate limits (RFC 6762 §6.2) and caps to the maximum response
+// size (RFC 6762 §17; WithMaxResponseSize). Service type enumeration, reverse
+// address mapping and NSEC negative answers are not affected. Wrap
+// DefaultResponseBuilder to adjust the standard records rather than rebuild
+// them.
+//
+// Parameters:
+//   - b: Response builder (must not be nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithResponseBuilder(b ResponseBuilder) Option {
+	return func(r *Responder) error {
+		if b == nil {
+			return &errors.ValidationError{
+				Field:   "responseBuilder",
+				Value:   nil,
+				Message: "response builder cannot be nil",
+			}
+		}
+		r.serviceBuilder = b
+		return nil
+	}
+}
+
+// Clock tells the time and waits for it to pass; see WithClock.
+//
+// Implementations provide Now() time.Time and After(d) <-chan time.Time with
+// the semantics of time.Now and time.After.
+type Clock = clock.Clock
+
+// WithClock sets the clock that times probing and announcing (RFC 6762 §8),
+// per-record multicast rate limiting (RFC 6762 §6.2) and the spacing of the
+// goodbyes sent by Close.
+//
+// The default is the wall clock. Tests substitute a manually advanced clock
+// so that Register's ~1.75s probe/announce sequence and rate-limit windows run
+// instantly and deterministically. Close also waits on this clock between
+// goodbye copies (WithGoodbyeCount), so a manual clock must be advanced
+// there too.
+//
+// Parameters:
+//   - c: Clock to use (non-nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithClock(c Clock) Option {
+	return func(r *Responder) error {
+		if c == nil {
+			return &errors.ValidationError{
+				Field:   "clock",
+				Value:   nil,
+				Message: "clock cannot be nil",
+			}
+		}
+
+		r.clock = c
+		return nil
+	}
+}
+
+// WithObserver installs an Observer receiving a structured event for each
+// step of every service's lifecycle: probing started, each probe sent, probe
+// conflict, rename, each announcement sent, established, and each goodbye
+// sent (RFC 6762 §8, §9, §10.1).
+//
+// Unlike WithLogger's free-form diagnostics, the event stream is meant for
+// programs: drive metrics, or show registration progress in a UI. See Observer
+// for when OnEvent is called.
+//
+// Parameters:
+//   - obs: Observer to notify (non-nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithObserver(obs Observer) Option {
+	return func(r *Responder) error {
+		if obs == nil {
+			return &errors.ValidationError{
+				Field:   "observer",
+				Value:   nil,
+				Message: "observer cannot be nil",
+			}
+		}
+
+		r.observer = obs
+		return nil
+	}
+}
+
+// WithOnRegistered sets a callback invoked once for each service that
+// becomes established, with its final status: the assigned name (which
+// differs from the requested one after a conflict rename, RFC 6762 §9), port
+// and advertised IPv4 address.
+//
+// Unlike WithObserver, which reports every lifecycle step, this is the single
+// "ready" signal an app gates its startup on. It is called from the
+// registering goroutine once the service answers queries, before Register
+// (or RegisterProxy) returns, so RegisterAll may call it from several
+// goroutines at once. RegisterServiceWithoutProbing does not call it.
+//
+// Parameters:
+//   - fn: Callback (non-nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithOnRegistered(fn func(service ServiceStatus)) Option {
+	return func(r *Responder) error {
+		if fn == nil {
+			return &errors.ValidationError{
+				Field:   "onRegistered",
+				Value:   nil,
+				Message: "registered callback cannot be nil",
+			}
+		}
+
+		r.onRegistered = fn
+		return nil
+	}
+}
+
+// WithInitialProbeDelay bounds the random delay Register waits before sending
+// its first probe.
+//
+// RFC 6762 §8.1: before probing, a host should wait a short random delay,
+// uniformly distributed in the range 0-250 ms, so that devices powered on at
+// the same moment (e.g., after a power outage) do not probe in lockstep. The
+// default draws the delay from [0, 250ms). Tests wanting deterministic timing
+// pass 0 to probe immediately.
+//
+// Parameters:
+//   - max: Upper bound of the delay (0 to disable, at most 250ms)
+//
+// Returns:
+//   - Option: Configuration function
+//
+// Example:
+//
+//	r, err := New(ctx, WithInitialProbeDelay(0)) // Probe immediately
+func WithInitialProbeDelay(max time.Duration) Option {
+	return func(r *Responder) error {
+		if max < 0 || max > protocol.ProbeInitialDelayMax {
+			return &errors.ValidationError{
+				Field:   "initialProbeDelay",
+				Value:   max,
+				Message: fmt.Sprintf("initial probe delay must be between 0 and %v", protocol.ProbeInitialDelayMax),
+			}
+		}
+
+		r.initialProbeDelay = max
+		return nil
+	}
+}
+
+// WithRandSource sets the random source drawing the responder's randomized
+// delays: the RFC 6762 §8.1 delay before the first probe
+// (WithInitialProbeDelay) and the RFC 6762 §7.2 wait for a truncated query's
+// known answers.
+//
+// The default is math/rand/v2's seeded global source. Tests pass a fixed
+// source, such as rand.NewPCG(1, 2), to make those delays reproducible. The
+// source need not be safe for concurrent use; the responder serializes its
+// calls.
+//
+// Parameters:
+//   - src: Random source (non-nil)
+//
+// Returns:
+//   - Option: Configuration function
+func WithRandSource(src rand.Source) Option {
+	return func(r *Responder) error {
+		if src == nil {
+			return &errors.ValidationError{
+				Field:   "randSource",
+				Value:   nil,
+				Message: "random source cannot be nil",
+			}
+		}
+
+		rnd := rand.New(src) //nolint:gosec // G404: timing jitter, not security-sensitive
+		var mu sync.Mutex
+		r.randN = func(n time.Duration) time.Duration {
+			mu.Lock()
+			defer mu.Unlock()
+			return time.Duration(rnd.Int64N(int64(n)))
+		}
 		return nil
 	}
 }
@@ -0,0 +1,114 @@
+package responder
+
+import (
+	"bytes"
+	"context"
+	goerrors "errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// TestRegisterProxy_AdvertisesTargetAddress verifies a proxied service's SRV
+// record targets the proxied hostname and its A record, announced and in
+// answers on any interface, carries the supplied address rather than a local
+// interface's.
+func TestRegisterProxy_AdvertisesTargetAddress(t *testing.T) {
+	var mu sync.Mutex
+	var sent [][]byte
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("gateway.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithInterfaceResolver(StaticInterfaceResolver{2: "10.0.1.10/24"}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	target := net.IPv4(192, 168, 1, 77)
+	service := &Service{InstanceName: "Sleeping Printer", ServiceType: "_ipp._tcp.local", Port: 631}
+	if err := runOnFakeClock(t, fake, func() error {
+		return r.RegisterProxy(service, target, "printer.local")
+	}); err != nil {
+		t.Fatalf("RegisterProxy() error = %v", err)
+	}
+
+	// Announcements and the answer to a query arriving on interface 2
+	fake.Advance(time.Second) // Past the RFC 6762 §6.2 per-record rate limit
+	src := &net.UDPAddr{IP: net.IPv4(10, 0, 1, 50), Port: 5353}
+	if err := r.handleQuery(buildDNSQuery("printer.local", uint16(protocol.RecordTypeA)), src, 2); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var aRecords, srvRecords int
+	for _, packet := range sent {
+		msg, err := message.ParseMessage(packet)
+		if err != nil || !msg.Header.IsResponse() {
+			continue // Probes
+		}
+		for _, rr := range append(msg.Answers, msg.Additionals...) {
+			switch protocol.RecordType(rr.TYPE) {
+			case protocol.RecordTypeA:
+				aRecords++
+				if rr.NAME != "printer.local" || !bytes.Equal(rr.RDATA, target.To4()) {
+					t.Errorf("A record %s → %v, want printer.local → %v", rr.NAME, net.IP(rr.RDATA), target)
+				}
+			case protocol.RecordTypeSRV:
+				srvRecords++
+				srv, err := message.ParseRDATAInMessage(rr.TYPE, packet, rr.RDATAOffset, int(rr.RDLENGTH))
+				if err != nil || srv.(message.SRVData).Target != "printer.local" {
+					t.Errorf("SRV record = %+v (%v), want target printer.local", srv, err)
+				}
+			}
+		}
+	}
+	// Two announcements, plus the answer to the A query
+	if aRecords != 3 || srvRecords != 2 {
+		t.Errorf("saw %d A and %d SRV records, want 3 and 2", aRecords, srvRecords)
+	}
+}
+
+// TestRegisterProxy_Validation verifies a non-IPv4 target and a hostname
+// missing or equal to the responder's own are rejected before registering.
+func TestRegisterProxy_Validation(t *testing.T) {
+	r, err := New(context.Background(), WithTransport(&MockTransport{}), WithHostname("gateway.local"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, tt := range []struct {
+		name     string
+		target   net.IP
+		hostname string
+	}{
+		{"IPv6 target", net.ParseIP("fd00::77"), "printer.local"},
+		{"no target", nil, "printer.local"},
+		{"no hostname", net.IPv4(192, 168, 1, 77), ""},
+		{"own hostname", net.IPv4(192, 168, 1, 77), "Gateway.local"},
+	} {
+		service := &Service{InstanceName: "Proxied", ServiceType: "_ipp._tcp.local", Port: 631}
+		err := r.RegisterProxy(service, tt.target, tt.hostname)
+		var valErr *errors.ValidationError
+		if !goerrors.As(err, &valErr) {
+			t.Errorf("%s: RegisterProxy() error = %v, want ValidationError", tt.name, err)
+		}
+	}
+}
@@ -1,13 +1,29 @@
 package responder
 
 import (
+	"bytes"
+	"context"
 	"net"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
 	"github.com/joshuafuller/beacon/internal/responder"
 )
 
+const (
+	// queryReceiveTimeout bounds each Receive so the query handler re-checks
+	// for shutdown even if the transport does not unblock on Close.
+	queryReceiveTimeout = 100 * time.Millisecond
+
+	// queryHandlerShutdownTimeout bounds how long Close waits for the query
+	// handler goroutine to exit.
+	queryHandlerShutdownTimeout = 2 * time.Second
+)
+
 // runQueryHandler continuously receives and processes mDNS queries.
 //
 // RFC 6762 §6: Responders SHOULD respond to queries for services they have registered.
@@ -22,7 +38,7 @@ import (
 //
 // T080: Query handler goroutine
 func (r *Responder) runQueryHandler() {
-	defer r.queryHandlerWg.Done()
+	defer close(r.queryHandlerExit)
 	for {
 		select {
 		case <-r.ctx.Done():
@@ -33,9 +49,11 @@ func (r *Responder) runQueryHandler() {
 			// Receive query with timeout
 			// 007-interface-specific-addressing T027: Extract interfaceIndex for RFC 6762 §15 compliance
 			// Task 2: Capture source address for subnet validation (RFC 6762 §6.4)
-			packet, srcAddr, interfaceIndex, err := r.transport.Receive(r.ctx)
+			ctx, cancel := context.WithTimeout(r.ctx, queryReceiveTimeout)
+			packet, srcAddr, interfaceIndex, err := r.transport.Receive(ctx)
+			cancel()
 			if err != nil {
-				// Context cancelled or transport closed
+				// Receive deadline, context cancelled or transport closed
 				select {
 				case <-r.ctx.Done():
 					return
@@ -62,14 +80,16 @@ func (r *Responder) runQueryHandler() {
 // the query was received."
 //
 // Parameters:
+//   - resolver: Interface address lookup (WithInterfaceResolver)
 //   - srcAddr: Source address of the query
 //   - interfaceIndex: OS interface index that received the query
+//   - loopback: Accept any source on a loopback interface (WithLoopbackAdvertise)
 //
 // Returns:
 //   - bool: true if source is on same subnet, false otherwise
 //
 // Task 2: Source address validation
-func validateSourceAddress(srcAddr net.Addr, interfaceIndex int) bool {
+func validateSourceAddress(resolver InterfaceResolver, srcAddr net.Addr, interfaceIndex int, loopback bool) bool {
 	// If interface index is unknown (0), skip validation (graceful degradation)
 	if interfaceIndex == 0 {
 		return true
@@ -85,14 +105,8 @@ func validateSourceAddress(srcAddr net.Addr, interfaceIndex int) bool {
 		return false // Not IPv4
 	}
 
-	// Get interface by index
-	iface, err := net.InterfaceByIndex(interfaceIndex)
-	if err != nil {
-		return false
-	}
-
 	// Get interface addresses
-	addrs, err := iface.Addrs()
+	addrs, err := resolver.InterfaceAddrs(interfaceIndex)
 	if err != nil {
 		return false
 	}
@@ -108,6 +122,12 @@ func validateSourceAddress(srcAddr net.Addr, interfaceIndex int) bool {
 		if ipnet.Contains(srcIP) {
 			return true
 		}
+
+		// Only this host sends on the loopback interface, from whichever of
+		// its addresses the OS picks (WithLoopbackAdvertise)
+		if loopback && ipnet.IP.IsLoopback() {
+			return true
+		}
 	}
 
 	// Source IP not on same subnet
@@ -148,8 +168,13 @@ func validateSourceAddress(srcAddr net.Addr, interfaceIndex int) bool {
 // T029: Added interfaceIndex parameter for interface-specific addressing
 // Task 2: Added srcAddr parameter for source address validation
 func (r *Responder) handleQuery(packet []byte, srcAddr net.Addr, interfaceIndex int) error {
+	// Queries on interfaces excluded by WithInterfaceFilter are not answered
+	if !r.servesInterface(interfaceIndex) {
+		return nil
+	}
+
 	// Task 2: RFC 6762 §6.4 - Validate source address is on same subnet
-	if !validateSourceAddress(srcAddr, interfaceIndex) {
+	if !validateSourceAddress(r.interfaces(), srcAddr, interfaceIndex, r.loopbackAdvertise) {
 		// Source not on same subnet - ignore query per RFC 6762 §6.4
 		return nil
 	}
@@ -161,181 +186,651 @@ func (r *Responder) handleQuery(packet []byte, srcAddr net.Addr, interfaceIndex
 		return err
 	}
 
-	// Ignore responses (QR=1)
+	// Responses (QR=1) are not answered, but may claim our hostname (RFC 6762 §9)
 	if msg.Header.IsResponse() {
+		r.checkHostnameConflict(msg)
+		return nil
+	}
+
+	// Known answers may compress PTR/SRV targets against this packet; expand
+	// them so they still match our records (RFC 6762 §7.1). A query deferred
+	// by deferTruncatedQuery merges answers from several packets, so this
+	// cannot wait until answering.
+	expandKnownAnswers(packet, msg)
+
+	// RFC 6762 §7.2: A query with TC set continues in further packets of
+	// known answers; it is answered once they have arrived
+	if r.deferTruncatedQuery(msg, srcAddr, interfaceIndex) {
 		return nil
 	}
 
-	// DNS-SD meta-query name per RFC 6763 §9
-	const serviceEnumerationName = "_services._dns-sd._udp.local"
+	return r.answerQuery(msg, srcAddr, interfaceIndex)
+}
+
+// answerQuery builds and sends the response to a parsed query received from
+// srcAddr on interface interfaceIndex; see handleQuery.
+//
+// It runs on the query handler goroutine and, for queries deferred by
+// deferTruncatedQuery, on their own goroutine, so answerMu serializes it.
+func (r *Responder) answerQuery(msg *message.DNSMessage, srcAddr net.Addr, interfaceIndex int) error {
+	r.answerMu.Lock()
+	defer r.answerMu.Unlock()
+
+	// Accumulate answers to every question into one response: a PTR query for
+	// a type with several instances must list all of them, and a multi-question
+	// query must answer each question (RFC 6762 §6).
+	response := r.responseBuilder.NewResponse(msg)
+	knownAnswers := responder.KnownAnswers(msg)
+
+	// RFC 6762 §5.4: Unicast only when every answered question set the QU bit
+	// (bit 15 of QCLASS); any QM question requires a multicast response.
+	answered := false
+	unicast := true
+
+	// RFC 6762 §15: Resolve the receiving interface's address once, on demand
+	var ipv4 []byte
+	resolveIPv4 := func() ([]byte, error) {
+		if ipv4 == nil {
+			ip, err := r.resolveResponseIPv4(interfaceIndex)
+			if err != nil {
+				return nil, err
+			}
+			ipv4 = ip
+		}
+		return ipv4, nil
+	}
 
-	// Process each question
 	for _, question := range msg.Questions {
-		// RFC 6763 §9: Service Type Enumeration
-		// A PTR query for "_services._dns-sd._udp.local" returns all unique service types.
-		if question.QTYPE == uint16(protocol.RecordTypePTR) && question.QNAME == serviceEnumerationName {
-			serviceTypes := r.registry.ListServiceTypes()
-			if len(serviceTypes) == 0 {
-				continue // No services registered, no response needed
+		before := len(response.Answers)
+
+		if err := r.addAnswers(response, msg, question, interfaceIndex, knownAnswers, resolveIPv4); err != nil {
+			// T031: No usable address under the configured resolution policy
+			// (skip the response rather than advertise a wrong-interface IP),
+			// or the WithResponseBuilder builder failed.
+			return nil
+		}
+
+		if len(response.Answers) > before {
+			answered = true
+			// RFC 6762 §5.4: Check QU bit to determine unicast vs multicast
+			// Task 4: QU bit handling
+			if question.QCLASS&0x8000 == 0 {
+				unicast = false
 			}
+		}
+	}
+
+	if !answered {
+		return nil
+	}
 
-			// Build PTR records: one for each unique service type
-			ptrRecords := make([]*message.ResourceRecord, 0, len(serviceTypes))
-			for _, svcType := range serviceTypes {
-				// RDATA for PTR record is the encoded service type name
-				encodedTarget, encErr := message.EncodeName(svcType)
-				if encErr != nil {
-					continue // Skip types that cannot be encoded
+	// Per-source-IP rate limiting (FR-026, RFC 6762 §6.2)
+	if r.rateLimiter != nil && srcAddr != nil {
+		srcIP := srcAddr.String()
+		if udpAddr, ok := srcAddr.(*net.UDPAddr); ok {
+			srcIP = udpAddr.IP.String()
+		}
+		if !r.rateLimiter.Allow(srcIP) {
+			return nil // Rate-limited, skip response
+		}
+	}
+
+	var dest net.Addr
+	legacy := isLegacyQuery(srcAddr)
+	if legacy {
+		// RFC 6762 §6.7: A conventional unicast DNS response, straight back
+		// to the querier's port, echoing its question
+		dest = srcAddr
+		r.responseBuilder.AdaptForLegacyUnicast(response, msg)
+	} else if unicast && srcAddr != nil {
+		// RFC 6762 §5.4: QU bit set → send unicast response to querier
+		dest = srcAddr
+	} else {
+		// RFC 6762 §5.4: QU bit clear → send multicast response to 224.0.0.251:5353
+		dest = protocol.MulticastGroupIPv4()
+
+		// RFC 6762 §18.1: "In multicast responses [...] the Query Identifier
+		// MUST be set to zero on transmission." Unicast responses keep the
+		// query's ID.
+		response.Header.ID = 0
+
+		// RFC 6762 §6.2: Drop records multicast on this interface within the last second
+		r.applyRecordRateLimit(response, interfaceIndex)
+		if len(response.Answers) == 0 {
+			return nil
+		}
+	}
+
+	r.responseBuilder.Finalize(response, legacy)
+
+	// Send response out the interface the query arrived on (RFC 6762 §15), so
+	// a query received on eth1 is not answered out eth0. Falls back to the
+	// default interface when interfaceIndex is unknown (0).
+	responsePacket := buildResponsePacket(response)
+	_ = r.transport.SendOnInterface(r.ctx, responsePacket, dest, interfaceIndex) // nosemgrep: beacon-error-swallowing
+
+	return nil
+}
+
+// AnswerQuestion returns the records the responder would send in reply to a
+// query for name and qtype, without touching the network.
+//
+// The same matching and record building as a received query is applied
+// (answer records first, then additional records per RFC 6763 §12), so the
+// result shows exactly what the responder advertises: useful for unit-testing
+// response correctness and for apps introspecting their own announcements.
+// Nothing network-dependent is applied: there is no known-answer list, no
+// per-record rate limiting and the receiving interface is unknown, so A
+// records carry the host's default address.
+//
+// Parameters:
+//   - name: Question name (e.g., "_http._tcp.local")
+//   - qtype: Question type (e.g., RecordTypePTR)
+//
+// Returns:
+//   - []*ResourceRecord: Answer then additional records; empty if nothing matches
+//   - error: ValidationError for an invalid name, or the address lookup error
+//     when a matching service's A record cannot be built
+//
+// Example:
+//
+//	rrs, err := r.AnswerQuestion("_http._tcp.local", responder.RecordTypePTR)
+//	for _, rr := range rrs {
+//	    fmt.Println(rr.Type, rr.Name)
+//	}
+func (r *Responder) AnswerQuestion(name string, qtype RecordType) ([]*ResourceRecord, error) {
+	if err := protocol.ValidateName(name); err != nil {
+		return nil, err // Already wrapped as ValidationError
+	}
+
+	question := message.Question{QNAME: name, QTYPE: uint16(qtype), QCLASS: uint16(protocol.ClassIN)}
+	response := r.responseBuilder.NewResponse(&message.DNSMessage{})
+	resolveIPv4 := func() ([]byte, error) { return r.resolveResponseIPv4(0) }
+	if err := r.addAnswers(response, &message.DNSMessage{}, question, 0, nil, resolveIPv4); err != nil {
+		return nil, err
+	}
+	r.responseBuilder.Finalize(response, false)
+
+	rrs := make([]*ResourceRecord, 0, len(response.Answers)+len(response.Additionals))
+	for _, sections := range [][]message.Answer{response.Answers, response.Additionals} {
+		for _, a := range sections {
+			rrs = append(rrs, &ResourceRecord{
+				Name:       a.NAME,
+				Type:       protocol.RecordType(a.TYPE),
+				Class:      protocol.DNSClass(a.CLASS & 0x7FFF),
+				TTL:        a.TTL,
+				Data:       a.RDATA,
+				CacheFlush: a.CLASS&0x8000 != 0,
+			})
+		}
+	}
+	return rrs, nil
+}
+
+// serviceEnumerationName is the DNS-SD meta-query name (RFC 6763 §9).
+const serviceEnumerationName = "_services._dns-sd._udp.local"
+
+// addAnswers appends the records answering question to response: service
+// type enumeration (RFC 6763 §9), reverse mapping of our IPv6 address, or
+// the records of every matching registered service.
+//
+// RFC 6762 §15 "Responding to Address Queries": "When a Multicast DNS
+// responder sends a Multicast DNS response message containing its own address
+// records in response to a query received on a particular interface, it MUST
+// include only addresses that are valid on that interface, and MUST NOT
+// include addresses configured on other interfaces." resolveIPv4 supplies that
+// address and is only called when a service matches; the interface's IPv6
+// address, if any, is added as an AAAA record alongside the A record, and any
+// further addresses on the interface (see extraResponseAddresses) get A and
+// AAAA records of their own.
+//
+// Service records come from the WithResponseBuilder builder when one is set.
+//
+// Parameters:
+//   - response: Response being built
+//   - query: Query being answered
+//   - question: Question to answer
+//   - interfaceIndex: Interface the query arrived on (0 = unknown)
+//   - knownAnswers: The query's known-answer list (RFC 6762 §7.1)
+//   - resolveIPv4: Returns the address to advertise in A records
+//
+// Returns:
+//   - error: resolveIPv4's error when no address may be advertised (T031),
+//     or the WithResponseBuilder builder's error
+//
+// T036: Inline comment citing RFC 6762 §15
+func (r *Responder) addAnswers(response, query *message.DNSMessage, question message.Question, interfaceIndex int, knownAnswers []*message.ResourceRecord, resolveIPv4 func() ([]byte, error)) error {
+	switch {
+	case question.QTYPE == uint16(protocol.RecordTypePTR) && strings.EqualFold(question.QNAME, serviceEnumerationName):
+		// RFC 6763 §9: Service Type Enumeration
+		// A PTR query for "_services._dns-sd._udp.local" returns all unique service types.
+		r.addServiceTypeAnswers(response)
+
+	case isIP6ArpaName(question.QNAME):
+		// RFC 6762 §4: Reverse mapping of our IPv6 address to the hostname
+		r.addReverseIPv6Answer(response, question, interfaceIndex, knownAnswers)
+
+	default:
+		ipv6 := sync.OnceValue(func() []byte { return r.responseIPv6(interfaceIndex) })
+		var extraIPv4, extraIPv6 [][]byte
+		extrasResolved := false
+		matched := r.matchServices(question)
+		if question.QTYPE == uint16(protocol.RecordTypeAAAA) && len(matched) > 0 &&
+			(matched[0].ProxyAddress != nil || ipv6() == nil) {
+			matched = nil // IPv4-only on this interface, or a proxied IPv4 host: no AAAA to give
+		}
+		if len(matched) == 0 {
+			// RFC 6762 §6.1: Assert the type does not exist for a name we own
+			r.addNegativeAnswer(response, question, knownAnswers, ipv6)
+		}
+		for _, service := range matched {
+			var ipv4, ipv6Addr []byte
+			if service.ProxyAddress != nil {
+				// RegisterProxy: the proxied host's address, whichever
+				// interface the query arrived on
+				ipv4 = service.ProxyAddress
+			} else if !service.PTROnly { // A PTR-only service has no A or AAAA record
+				var err error
+				if ipv4, err = resolveIPv4(); err != nil {
+					return err
+				}
+				ipv6Addr = ipv6()
+				if !extrasResolved {
+					extraIPv4, extraIPv6 = r.extraResponseAddresses(interfaceIndex, ipv4, ipv6Addr)
+					extrasResolved = true
 				}
-				ptrRecords = append(ptrRecords, &message.ResourceRecord{
-					Name:       serviceEnumerationName,
-					Type:       protocol.RecordTypePTR,
-					Class:      protocol.ClassIN,
-					TTL:        protocol.TTLHostname, // 4500s per RFC 6762 §10
-					Data:       encodedTarget,
-					CacheFlush: false, // PTR is a shared record
-				})
 			}
 
-			if len(ptrRecords) == 0 {
-				continue
+			serviceWithIP := &responder.ServiceWithIP{
+				InstanceName: service.InstanceName,
+				ServiceType:  service.ServiceType,
+				Domain:       "local",
+				Port:         service.Port,
+				IPv4Address:  ipv4,
+				IPv6Address:  ipv6Addr,
+				TXTRecords:   r.serviceTXT(service),
+				Hostname:     r.hostnameFor(service.Hostname),
+				PTROnly:      service.PTROnly,
 			}
-
-			// Build DNS response message
-			responseMsg, buildErr := message.BuildResponse(ptrRecords)
-			if buildErr != nil {
+			if service.ProxyAddress == nil && !service.PTROnly {
+				serviceWithIP.ExtraIPv4Addresses = extraIPv4
+				serviceWithIP.ExtraIPv6Addresses = extraIPv6
+			}
+			if r.serviceBuilder == nil {
+				r.responseBuilder.AddServiceRecords(response, serviceWithIP, question, knownAnswers)
 				continue
 			}
-
-			// Determine destination (unicast vs multicast based on QU bit)
-			quBit := (question.QCLASS & 0x8000) != 0
-			var dest net.Addr
-			if quBit {
-				dest = srcAddr
+			single := *query
+			single.Header.QDCount = 1
+			single.Questions = []message.Question{question}
+			built, err := r.serviceBuilder.BuildResponse(serviceWithIP, &single)
+			if err != nil {
+				return err
 			}
-			// nil dest = multicast to 224.0.0.251:5353
+			if built != nil {
+				r.responseBuilder.MergeRecords(response, built)
+			}
+		}
+	}
+	return nil
+}
 
-			_ = r.transport.Send(r.ctx, responseMsg, dest)
-			continue
+// answerableServices returns the services queries are answered for: the
+// registered (established) ones, then any still announcing with
+// WithAnswerDuringAnnounce. A service that has just been registered but not
+// yet withdrawn from the announcing set is returned once.
+func (r *Responder) answerableServices() []*responder.Service {
+	names := r.registry.List()
+	services := make([]*responder.Service, 0, len(names))
+	registered := make(map[string]bool, len(names))
+	for _, instanceName := range names {
+		if service, found := r.registry.Get(instanceName); found {
+			services = append(services, service)
+			registered[service.InstanceName] = true
 		}
+	}
 
-		// Get all registered services
-		services := r.registry.List()
+	r.announcingMu.Lock()
+	defer r.announcingMu.Unlock()
+	for name, service := range r.announcing {
+		if !registered[name] {
+			services = append(services, service)
+		}
+	}
+	return services
+}
 
-		var matchedService *responder.Service
-		for _, instanceName := range services {
-			service, found := r.registry.Get(instanceName)
-			if !found {
-				continue
-			}
+// serviceTXT returns service's current TXT records: read under the registry
+// lock for a registered service, which UpdateService may change concurrently,
+// or as created for one still announcing (WithAnswerDuringAnnounce).
+func (r *Responder) serviceTXT(service *responder.Service) map[string]string {
+	if txt, found := r.registry.TXT(service.InstanceName); found {
+		return txt
+	}
+	return service.TXT
+}
 
-			switch question.QTYPE {
-			case uint16(protocol.RecordTypePTR):
-				// PTR: match by service type (e.g., "_http._tcp.local")
-				if service.ServiceType == question.QNAME {
-					matchedService = service
-				}
-			case uint16(protocol.RecordTypeSRV), uint16(protocol.RecordTypeTXT):
-				// SRV/TXT: match by full instance name (e.g., "My Printer._http._tcp.local")
-				fullName := service.InstanceName + "." + service.ServiceType
-				if fullName == question.QNAME {
-					matchedService = service
-				}
-			case uint16(protocol.RecordTypeA):
-				// A: match by hostname (e.g., "myhost.local")
-				if r.hostname == question.QNAME {
-					matchedService = service
-				}
-			}
+// startAnswering makes queries answered for service, which has started
+// announcing, if WithAnswerDuringAnnounce is set; stopAnswering undoes it
+// once the registration attempt fails, or once the service is in the
+// registry, so it is answered for throughout.
+func (r *Responder) startAnswering(service *Service) {
+	if !r.answerAnnouncing {
+		return
+	}
+	internalSvc := toInternalService(service)
+	internalSvc.TXT = r.mergeTXT(service.TXTRecords)
 
-			if matchedService != nil {
-				break
+	r.announcingMu.Lock()
+	defer r.announcingMu.Unlock()
+	if r.announcing == nil {
+		r.announcing = make(map[string]*responder.Service)
+	}
+	r.announcing[service.InstanceName] = internalSvc
+}
+
+// stopAnswering removes name from the services answered while announcing.
+func (r *Responder) stopAnswering(name string) {
+	r.announcingMu.Lock()
+	defer r.announcingMu.Unlock()
+	delete(r.announcing, name)
+}
+
+// matchServices returns every answerable service (see answerableServices)
+// that answers question.
+//
+// PTR questions match by service type (all instances of the type) or, for a
+// subtype browse, by subtype (see matchSubtype), SRV/TXT by full instance
+// name, and A/AAAA by the service's hostname. Names match case-insensitively
+// (RFC 1035 §2.3.3).
+func (r *Responder) matchServices(question message.Question) []*responder.Service {
+	if question.QTYPE == uint16(protocol.RecordTypePTR) && isSubtypeName(question.QNAME) {
+		return r.matchSubtype(question.QNAME)
+	}
+
+	var matched []*responder.Service
+	instance, serviceType := splitDNSSDName(question.QNAME)
+	for _, service := range r.answerableServices() {
+		switch question.QTYPE {
+		case uint16(protocol.RecordTypePTR):
+			// PTR: match by service type (e.g., "_http._tcp.local")
+			if strings.EqualFold(service.ServiceType, question.QNAME) {
+				matched = append(matched, service)
+			}
+		case uint16(protocol.RecordTypeSRV), uint16(protocol.RecordTypeTXT):
+			// SRV/TXT: match by full instance name (e.g., "My Printer._http._tcp.local"),
+			// whose instance label may contain dots (RFC 6763 §4.3)
+			if strings.EqualFold(service.InstanceName, instance) && strings.EqualFold(service.ServiceType, serviceType) {
+				matched = append(matched, service)
+			}
+		case uint16(protocol.RecordTypeA), uint16(protocol.RecordTypeAAAA):
+			// A/AAAA: match by hostname (e.g., "myhost.local"), honoring
+			// per-service overrides; one service suffices since all share the
+			// host's addresses. A PTR-only service has no address record to offer.
+			if !service.PTROnly && strings.EqualFold(r.hostnameFor(service.Hostname), question.QNAME) {
+				return []*responder.Service{service}
 			}
 		}
+	}
+	return matched
+}
 
-		if matchedService == nil {
-			continue
+// matchSubtype returns the answerable services registered with the subtype
+// browsed for by subtypeName ("_printer._sub._http._tcp.local", RFC 6763
+// §7.1): the registered ones through the registry's subtype index, then any
+// still announcing (WithAnswerDuringAnnounce).
+func (r *Responder) matchSubtype(subtypeName string) []*responder.Service {
+	var matched []*responder.Service
+	for _, instanceName := range r.registry.Subtype(subtypeName) {
+		if service, found := r.registry.Get(instanceName); found {
+			matched = append(matched, service)
 		}
+	}
 
-		// We have a match! Build response with interface-specific addressing
-		//
-		// RFC 6762 §15 "Responding to Address Queries":
-		// "When a Multicast DNS responder sends a Multicast DNS response message
-		// containing its own address records in response to a query received on
-		// a particular interface, it MUST include only addresses that are valid
-		// on that interface, and MUST NOT include addresses configured on other
-		// interfaces."
-		//
-		// T036: Inline comment citing RFC 6762 §15
-		var ipv4 []byte
-		var ipErr error
-
-		// T030: Graceful fallback when interface index unavailable (interfaceIndex=0)
-		// This happens when control messages aren't supported or platform doesn't provide IP_PKTINFO
-		if interfaceIndex == 0 {
-			// Degraded mode: Use default interface IP (legacy behavior)
-			// TODO T032: Add debug logging when F-6 (Logging & Observability) is implemented
-			ipv4, ipErr = getLocalIPv4()
-		} else {
-			// RFC 6762 §15 compliance: Use ONLY the IP from the receiving interface
-			ipv4, ipErr = getIPv4ForInterface(interfaceIndex)
+	r.announcingMu.Lock()
+	defer r.announcingMu.Unlock()
+	for _, service := range r.announcing {
+		for _, subtype := range service.Subtypes {
+			if strings.EqualFold(responder.SubtypeName(subtype, service.ServiceType), subtypeName) {
+				matched = append(matched, service)
+				break
+			}
 		}
+	}
+	return matched
+}
 
-		if ipErr != nil {
-			// T031: If interface-specific IP lookup fails, skip response for this query
-			// This is correct behavior per RFC 6762 §15: Better to not respond than
-			// to respond with an incorrect (wrong interface) IP address
-			// TODO T032: Add error logging when F-6 is implemented
-			// Common failure causes: interface went down, no IPv4 configured, invalid index
-			continue
+// isSubtypeName reports whether name is a subtype browse name of the form
+// "_printer._sub._http._tcp.local" (RFC 6763 §7.1).
+func isSubtypeName(name string) bool {
+	return strings.Contains(strings.ToLower(name), "._sub.")
+}
+
+// addNegativeAnswer appends an NSEC record to response when question names a
+// service instance or hostname of ours but asks for a type it lacks, so the
+// querier learns at once that no such record exists instead of waiting out
+// its timeout (RFC 6762 §6.1). ipv6 returns the receiving interface's AAAA
+// RDATA, if any, and is only called for a question naming one of our hosts.
+func (r *Responder) addNegativeAnswer(response *message.DNSMessage, question message.Question, knownAnswers []*message.ResourceRecord, ipv6 func() []byte) {
+	for _, service := range r.answerableServices() {
+		if service.PTROnly {
+			continue // A PTR-only instance name is not ours to speak for
 		}
 
 		serviceWithIP := &responder.ServiceWithIP{
-			InstanceName: matchedService.InstanceName,
-			ServiceType:  matchedService.ServiceType,
+			InstanceName: service.InstanceName,
+			ServiceType:  service.ServiceType,
 			Domain:       "local",
-			Port:         matchedService.Port,
-			IPv4Address:  ipv4,
-			TXTRecords:   matchedService.TXT, // internal.Service uses TXT field
-			Hostname:     r.hostname,
+			Hostname:     r.hostnameFor(service.Hostname),
+		}
+		if strings.EqualFold(serviceWithIP.Hostname, question.QNAME) && service.ProxyAddress == nil {
+			serviceWithIP.IPv6Address = ipv6()
+		}
+		if r.responseBuilder.AddNegativeRecord(response, serviceWithIP, question, knownAnswers) {
+			return
 		}
+	}
+}
 
-		// Build response (T076)
-		response, err := r.responseBuilder.BuildResponse(serviceWithIP, msg)
-		if err != nil {
-			continue
+// addServiceTypeAnswers appends one shared PTR record per registered service
+// type to response, answering a DNS-SD service type enumeration query
+// (RFC 6763 §9).
+func (r *Responder) addServiceTypeAnswers(response *message.DNSMessage) {
+	response.Answers = append(response.Answers, r.serviceTypeAnswers()...)
+}
+
+// serviceTypeAnswers returns the PTR records answering a service type
+// enumeration query, rebuilt only when services were registered or removed
+// since the last call, so discovery-heavy networks enumerating types
+// repeatedly do not re-encode them per query. Callers must not modify the
+// returned records; per-record rate limiting (RFC 6762 §6.2) still applies to
+// each response.
+func (r *Responder) serviceTypeAnswers() []message.Answer {
+	// Read the generation first: a registry change racing with the rebuild
+	// leaves the cache tagged older than its contents, so it is rebuilt again
+	generation := r.registry.Generation()
+
+	r.serviceTypesMu.Lock()
+	defer r.serviceTypesMu.Unlock()
+	if r.serviceTypes != nil && r.serviceTypes.generation == generation {
+		return r.serviceTypes.answers
+	}
+
+	answers := buildServiceTypeAnswers(r.registry.ListServiceTypes())
+	r.serviceTypes = &serviceTypeCache{generation: generation, answers: answers}
+	return answers
+}
+
+// serviceTypeCache holds the service type enumeration records built for one
+// registry generation (see serviceTypeAnswers).
+type serviceTypeCache struct {
+	generation uint64
+	answers    []message.Answer
+}
+
+// buildServiceTypeAnswers builds one shared PTR record from
+// "_services._dns-sd._udp.local" to each of serviceTypes (RFC 6763 §9).
+func buildServiceTypeAnswers(serviceTypes []string) []message.Answer {
+	answers := make([]message.Answer, 0, len(serviceTypes))
+	for _, svcType := range serviceTypes {
+		// RDATA for PTR record is the encoded service type name
+		encodedTarget, encErr := message.EncodeName(svcType)
+		if encErr != nil {
+			continue // Skip types that cannot be encoded
 		}
+		answers = append(answers, message.Answer{
+			NAME:     serviceEnumerationName,
+			TYPE:     uint16(protocol.RecordTypePTR),
+			CLASS:    uint16(protocol.ClassIN), // PTR is a shared record (no cache-flush)
+			TTL:      protocol.TTLHostname,     // 4500s per RFC 6762 §10
+			RDLENGTH: uint16(len(encodedTarget)),
+			RDATA:    encodedTarget,
+		})
+	}
+	return answers
+}
+
+// isLegacyQuery reports whether a query from srcAddr is a legacy unicast
+// query: RFC 6762 §6.7 "If the source UDP port in a received Multicast DNS
+// query is not port 5353, this indicates that the querier originating the
+// query is a simple resolver".
+func isLegacyQuery(srcAddr net.Addr) bool {
+	udpAddr, ok := srcAddr.(*net.UDPAddr)
+	return ok && udpAddr.Port != protocol.Port
+}
 
-		// Per-source-IP rate limiting (FR-026, RFC 6762 §6.2)
-		if r.rateLimiter != nil && srcAddr != nil {
-			srcIP := srcAddr.String()
-			if udpAddr, ok := srcAddr.(*net.UDPAddr); ok {
-				srcIP = udpAddr.IP.String()
+// applyRecordRateLimit removes records from a multicast response that were
+// multicast on the receiving interface less than one second ago, and records
+// the multicast time of those that remain.
+//
+// RFC 6762 §6.2: "A Multicast DNS responder MUST NOT multicast a given resource
+// record on a given interface until at least one second has elapsed since the
+// last time that resource record was multicast on that particular interface."
+func (r *Responder) applyRecordRateLimit(response *message.DNSMessage, interfaceIndex int) {
+	if r.recordSet == nil {
+		return
+	}
+	filter := func(section []message.Answer) []message.Answer {
+		kept := section[:0]
+		for _, a := range section {
+			rr := &records.ResourceRecord{
+				Name:  a.NAME,
+				Type:  protocol.RecordType(a.TYPE),
+				Class: protocol.DNSClass(a.CLASS & 0x7FFF), // Cache-flush bit is not part of record identity (RFC 6762 §10.2)
+				Data:  a.RDATA,
 			}
-			if !r.rateLimiter.Allow(srcIP) {
-				continue // Rate-limited, skip response
+			if !r.recordSet.CanMulticastByIndex(rr, interfaceIndex) {
+				continue
 			}
+			r.recordSet.RecordMulticastByIndex(rr, interfaceIndex)
+			kept = append(kept, a)
 		}
+		return kept
+	}
+
+	response.Answers = filter(response.Answers)
+	response.Additionals = filter(response.Additionals)
+}
+
+// resolveResponseIPv4 returns the IPv4 address to advertise in a response to a
+// query received on interfaceIndex, applying the responder's
+// InterfaceResolutionPolicy when the interface lookup fails.
+//
+// RFC 6762 §15: Responses MUST include only addresses valid on the receiving
+// interface. With SkipResponse (the default) a lookup failure yields an error and
+// the caller sends nothing; with UseGlobalIP it degrades to getLocalIPv4().
+//
+// Returns:
+//   - []byte: IPv4 address (4 bytes)
+//   - error: lookup error when no address should be advertised
+func (r *Responder) resolveResponseIPv4(interfaceIndex int) ([]byte, error) {
+	// T030: Graceful fallback when interface index unavailable (interfaceIndex=0)
+	// This happens when control messages aren't supported or platform doesn't provide IP_PKTINFO
+	if interfaceIndex == 0 {
+		return r.localIPv4()
+	}
+
+	// RFC 6762 §15 compliance: Use ONLY the IP from the receiving interface
+	ipv4, err := getIPv4ForInterface(r.interfaces(), interfaceIndex)
+	if err == nil {
+		return ipv4, nil
+	}
+
+	// Common failure causes: interface went down, no IPv4 configured, invalid index
+	if r.resolutionPolicy.LogFailures {
+		r.log().Warn("mDNS interface address resolution failed",
+			"interface_index", interfaceIndex,
+			"fallback", r.resolutionPolicy.FallbackOnError.String(),
+			"error", err)
+	}
+
+	if r.resolutionPolicy.FallbackOnError == UseGlobalIP {
+		return r.localIPv4()
+	}
+	return nil, err
+}
+
+// responseIPv6 returns the AAAA RDATA to advertise in a response to a query
+// received on interfaceIndex, or nil when that interface has no IPv6 address
+// or is unknown; the response then carries only the A record.
+//
+// RFC 6762 §15: as for IPv4, only an address valid on the receiving interface
+// is advertised. A missing IPv6 address is not an error: most hosts are
+// IPv4-only on some links.
+func (r *Responder) responseIPv6(interfaceIndex int) []byte {
+	addr, err := r.interfaceIPv6(interfaceIndex)
+	if err != nil {
+		return nil
+	}
+	return ipv6RData(addr)
+}
+
+// extraResponseAddresses returns the addresses valid on interfaceIndex besides
+// ipv4 and ipv6, the primary ones a response advertises, so that an interface
+// with an alias or several IPv6 prefixes is advertised in full.
+//
+// RFC 6762 §15: a response "MUST include all addresses that are valid on the
+// interface on which it is sending the message". Nothing is returned for an
+// unknown interface (0), whose address is not the interface's own, or when
+// the lookup fails; the response then carries the primary addresses only.
+// Extra IPv6 addresses are only looked up alongside a primary one from the
+// interface resolver.
+func (r *Responder) extraResponseAddresses(interfaceIndex int, ipv4, ipv6 []byte) (extraIPv4, extraIPv6 [][]byte) {
+	if interfaceIndex == 0 {
+		return nil, nil
+	}
+	resolver := r.interfaces()
 
-		// RFC 6762 §5.4: Check QU bit (bit 15 of QCLASS) to determine unicast vs multicast
-		// Task 4: QU bit handling
-		quBit := (question.QCLASS & 0x8000) != 0
-
-		var dest net.Addr
-		if quBit {
-			// RFC 6762 §5.4: QU bit set → send unicast response to querier
-			dest = srcAddr
-		} else {
-			// RFC 6762 §5.4: QU bit clear → send multicast response
-			dest = nil // nil = multicast to 224.0.0.251:5353
+	if ipv4s, err := getAllIPv4ForInterface(resolver, interfaceIndex); err == nil {
+		extraIPv4 = excludeAddress(ipv4s, ipv4)
+	}
+	if ipv6 != nil && r.ipv6Source == nil {
+		if ipv6s, err := getAllIPv6ForInterface(resolver, interfaceIndex); err == nil {
+			extraIPv6 = excludeAddress(ipv6s, ipv6)
 		}
+	}
+	return extraIPv4, extraIPv6
+}
 
-		// Send response
-		responsePacket := buildResponsePacket(response)
-		_ = r.transport.Send(r.ctx, responsePacket, dest)
+// excludeAddress returns addrs without primary.
+func excludeAddress(addrs [][]byte, primary []byte) [][]byte {
+	var rest [][]byte
+	for _, addr := range addrs {
+		if !bytes.Equal(addr, primary) {
+			rest = append(rest, addr)
+		}
 	}
+	return rest
+}
 
-	return nil
+// expandKnownAnswers replaces the RDATA of each of msg's answers with its
+// uncompressed form (records.ExpandRDATA), leaving malformed ones as received.
+func expandKnownAnswers(packet []byte, msg *message.DNSMessage) {
+	for i, answer := range msg.Answers {
+		if rdata, err := records.ExpandRDATA(packet, answer); err == nil {
+			msg.Answers[i].RDATA = rdata
+		}
+	}
 }
 
 // parseMessage is a wrapper around message.ParseMessage for easier imports.
@@ -352,14 +847,7 @@ func buildResponsePacket(msg *message.DNSMessage) []byte {
 	if err != nil {
 		// Serialization failed - return minimal valid DNS response header
 		// so the responder doesn't crash on unexpected serialization errors
-		return []byte{
-			0x00, 0x00, // ID
-			0x84, 0x00, // Flags (QR=1, AA=1)
-			0x00, 0x00, // QDCount
-			0x00, 0x00, // ANCount
-			0x00, 0x00, // NSCount
-			0x00, 0x00, // ARCount
-		}
+		return message.SerializeHeader(message.NewResponseHeader(0))
 	}
 	return data
 }
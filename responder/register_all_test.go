@@ -0,0 +1,127 @@
+package responder
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+)
+
+// newBatchResponder creates a responder on a mock transport that probes
+// without the random initial delay, so registration takes a fixed ~1.75s.
+func newBatchResponder(t *testing.T, opts ...Option) *Responder {
+	t.Helper()
+	r, err := New(context.Background(), append([]Option{
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithInitialProbeDelay(0),
+	}, opts...)...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	return r
+}
+
+func batchServices(n int) []*Service {
+	services := make([]*Service, n)
+	for i := range services {
+		services[i] = &Service{
+			InstanceName: fmt.Sprintf("Batch %d", i),
+			ServiceType:  "_http._tcp.local",
+			Port:         uint16(8080 + i), //nolint:gosec // G115: small test index
+		}
+	}
+	return services
+}
+
+// TestRegisterAll_Concurrent verifies five services register in about one
+// probe/announce cycle (~1.75s, RFC 6762 §8) rather than five.
+func TestRegisterAll_Concurrent(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping timing test in short mode")
+	}
+	r := newBatchResponder(t)
+	services := batchServices(5)
+
+	start := time.Now()
+	results, err := r.RegisterAll(services)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("RegisterAll() error = %v", err)
+	}
+	if len(results) != len(services) {
+		t.Fatalf("RegisterAll() returned %d results, want %d", len(results), len(services))
+	}
+	// One cycle is ~1.75s; five sequential registrations would take ~8.75s
+	if elapsed > 3*time.Second {
+		t.Errorf("RegisterAll() took %v, want about one probe/announce cycle (~1.75s)", elapsed)
+	}
+	for _, svc := range services {
+		if _, ok := r.GetService(svc.InstanceName); !ok {
+			t.Errorf("service %q not registered", svc.InstanceName)
+		}
+	}
+}
+
+// TestRegisterAll_ConflictsRenameIndependently verifies each service resolves
+// its own probe conflict by renaming (RFC 6762 §9), and that one failure is
+// reported for its service only.
+func TestRegisterAll_ConflictsRenameIndependently(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	var (
+		mu      sync.Mutex
+		renames = map[string]string{}
+	)
+	r := newBatchResponder(t, WithClock(fake), WithGoodbyeCount(1),
+		WithObserver(ObserverFunc(func(ev Event) {
+			if ev.Type == EventRenamed {
+				mu.Lock()
+				renames[ev.Previous] = ev.Instance
+				mu.Unlock()
+			}
+		})))
+
+	// Every service conflicts on its first attempt only
+	services := batchServices(3)
+	for _, svc := range services {
+		r.InjectConflictForService(svc.InstanceName, 1)
+	}
+	services = append(services, nil)
+
+	var results []error
+	err := runOnFakeClock(t, fake, func() error {
+		var err error
+		results, err = r.RegisterAll(services)
+		return err
+	})
+
+	if err == nil {
+		t.Error("RegisterAll() error = nil, want the nil service's failure")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i, svc := range services[:3] {
+		if results[i] != nil {
+			t.Errorf("results[%d] = %v, want nil", i, results[i])
+		}
+		want := fmt.Sprintf("Batch %d-2", i)
+		if svc.InstanceName != want {
+			t.Errorf("service %d renamed to %q, want %q", i, svc.InstanceName, want)
+		}
+		if got := renames[fmt.Sprintf("Batch %d", i)]; got != want {
+			t.Errorf("Renamed event for Batch %d = %q, want %q", i, got, want)
+		}
+		if _, ok := r.GetService(want); !ok {
+			t.Errorf("service %q not registered", want)
+		}
+	}
+	if results[3] == nil {
+		t.Error("results[3] (nil service) = nil, want error")
+	}
+}
@@ -2,13 +2,19 @@ package responder
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"net"
 	"os"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/records"
 	"github.com/joshuafuller/beacon/internal/responder"
 	"github.com/joshuafuller/beacon/internal/security"
@@ -35,32 +41,90 @@ import (
 //   - responder.go      lifecycle scaffolding (struct, New, Close) and IP/dedup helpers
 //   - lifecycle.go      service management (Register, Unregister, Get, Update)
 //   - query_handler.go  incoming-query processing (RFC 6762 §6)
+//   - host_conflict.go  hostname conflict detection and rename (RFC 6762 §9)
+//   - observer.go       lifecycle event stream (WithObserver)
+//   - status.go         per-service lifecycle snapshot (Services)
 //   - testhooks.go      test-only observation/injection hooks (see file header)
 //
 // T035: Responder struct
 // T080: Added query handler goroutine support
 // T082: Added interface-specific addressing documentation
 type Responder struct {
-	ctx              context.Context
-	transport        transport.Transport
-	registry         *responder.Registry
-	hostname         string
-	queryHandlerWg   sync.WaitGroup             // Synchronize query handler goroutine shutdown
-	responseBuilder  *responder.ResponseBuilder // RFC 6762 §6 response construction
-	recordSet        *records.RecordSet         // Per-record rate limiting tracker
-	rateLimiter      *security.RateLimiter      // Per-source-IP rate limiting (FR-026)
-	queryHandlerDone chan struct{}              // Signal query handler shutdown
+	ctx                context.Context
+	cancel             context.CancelFunc // Cancels ctx on Close (nil for struct literals)
+	transport          transport.Transport
+	registry           *responder.Registry
+	hostnameMu         sync.RWMutex // Protects hostname and the host rename state below
+	hostname           string
+	hostRenamed        chan struct{}                     // Closed when the host rename in progress ends (nil = none)
+	hostProbe          string                            // Hostname probed for the rename in progress
+	hostProbeConflict  bool                              // A response claimed hostProbe while it was probed
+	hostClaims         uint64                            // Responses claiming hostname without WithConflictHostRename
+	responseBuilder    *responder.ResponseBuilder        // RFC 6762 §6 response construction
+	serviceBuilder     ResponseBuilder                   // Per-service records (WithResponseBuilder; nil = responseBuilder)
+	recordSet          *records.RecordSet                // Per-record rate limiting tracker
+	rateLimiter        *security.RateLimiter             // Per-source-IP rate limiting (FR-026)
+	queryHandlerDone   chan struct{}                     // Signal query handler shutdown
+	queryHandlerExit   chan struct{}                     // Closed by the query handler goroutine on return
+	defaultTXT         map[string]string                 // TXT defaults merged under each service (WithDefaultTXT)
+	resolutionPolicy   InterfaceResolutionPolicy         // RFC 6762 §15 interface lookup failure handling
+	logger             *slog.Logger                      // Diagnostics logger (nil = slog.Default())
+	packetHook         PacketHook                        // Wire tracing (WithPacketHook)
+	ipv4Source         func() ([]byte, error)            // Host address lookup (nil = getLocalIPv4)
+	ipv6Source         func(int) (*net.IPAddr, error)    // Interface IPv6 lookup (nil = getIPv6ForInterface)
+	interfaceResolver  InterfaceResolver                 // Interface address lookup (WithInterfaceResolver; nil = host interfaces)
+	interfaceFilter    func(net.Interface) bool          // Interfaces served (WithInterfaceFilter; nil = all)
+	loopbackAdvertise  bool                              // Serve same-host queriers over loopback (WithLoopbackAdvertise)
+	readBufferSize     int                               // Socket receive buffer size (0 = 64KB default)
+	multicastTTL       int                               // Outgoing multicast IP TTL (0 = 255, WithMulticastTTL)
+	goodbyeMu          sync.Mutex                        // Protects pendingGoodbyes
+	pendingGoodbyes    map[string]*pendingGoodbye        // Goodbye retransmissions by instance name
+	goodbyeCount       int                               // Copies of each goodbye sent (WithGoodbyeCount)
+	goodbyeInterval    time.Duration                     // Spacing between goodbye copies (WithGoodbyeInterval)
+	conflictHostRename bool                              // Rename host on A-record conflict (WithConflictHostRename)
+	noRename           bool                              // Fail Register on conflict instead of renaming (WithNoRename)
+	skipAnnounce       bool                              // Establish services without announcing (WithSkipAnnounce)
+	noAnnounceFlush    bool                              // Clear cache-flush on announcements (WithCacheFlushOnAnnounce)
+	answerAnnouncing   bool                              // Answer for services still announcing (WithAnswerDuringAnnounce)
+	announcingMu       sync.Mutex                        // Protects announcing
+	announcing         map[string]*responder.Service     // Services announcing but not yet established, by name
+	dedupReceives      bool                              // Drop duplicate received packets (WithDeduplicateReceives)
+	networkWait        time.Duration                     // How long New waits for a usable address (WithWaitForNetwork; 0 = no wait)
+	clock              clock.Clock                       // Probe/announce/rate-limit timing (WithClock; nil = wall clock)
+	observer           Observer                          // Lifecycle event stream (WithObserver)
+	onRegistered       func(ServiceStatus)               // Called once per service established (WithOnRegistered)
+	initialProbeDelay  time.Duration                     // Bound of the random pre-probe delay (WithInitialProbeDelay)
+	randN              func(time.Duration) time.Duration // Draws random delays (WithRandSource; nil = math/rand/v2)
+	serviceTypesMu     sync.Mutex                        // Protects serviceTypes
+	serviceTypes       *serviceTypeCache                 // Service type enumeration records (RFC 6763 §9)
+	txtMu              sync.Mutex                        // Serializes TXT edits (UpdateService, SetTXTKey, DeleteTXTKey)
+	statusMu           sync.Mutex                        // Protects statuses
+	answerMu           sync.Mutex                        // Serializes answerQuery (query handler and deferred TC queries)
+	truncatedMu        sync.Mutex                        // Protects truncatedQueries
+	truncatedQueries   map[string]*truncatedQuery        // TC queries awaiting known-answer continuations, by source address
+	truncatedClosed    bool                              // Close has begun: hold no more TC queries (guarded by truncatedMu)
+	truncatedWG        sync.WaitGroup                    // Goroutines answering held TC queries
+	statuses           map[string]*ServiceStatus         // Lifecycle state by assigned name (Services)
 
 	// Test-only state. These fields exist solely to support black-box contract
 	// tests (see testhooks.go); they are not part of the responder's runtime
 	// behavior. Production code paths never read them except where guarded.
-	injectConflict       bool              // Inject conflict during probing
-	lastMachine          *state.Machine    // Last state machine used for registration
-	onProbeCallback      func()            // Callback for probe events
-	onAnnounceCallback   func()            // Callback for announce events
-	lastAnnouncedRecords []*ResourceRecord // Last record set announced
+	hooksMu              sync.Mutex                         // Protects the fields below (concurrent Register calls)
+	injectConflict       bool                               // Inject conflict during probing
+	conflictInjector     *state.ConflictInjector            // Inject conflict for the first n attempts
+	serviceInjectors     map[string]*state.ConflictInjector // Per-service conflict injection, by requested name
+	lastMachine          *state.Machine                     // Last state machine used for registration
+	onProbeCallback      func()                             // Callback for probe events
+	onAnnounceCallback   func()                             // Callback for announce events
+	lastAnnouncedRecords []*ResourceRecord                  // Last record set announced
+	lastGoodbyeMessage   []byte                             // Last goodbye packet built
 }
 
+// ErrPortInUse is returned (wrapped) by New when mDNS port 5353 is held by
+// another socket that does not share it, e.g. avahi-daemon or mDNSResponder
+// bound without SO_REUSEPORT. Test with errors.Is.
+var ErrPortInUse = transport.ErrPortInUse
+
 // New creates a new mDNS responder.
 //
 // T036: Responder.New() implementation
@@ -73,32 +137,71 @@ func New(ctx context.Context, opts ...Option) (*Responder, error) {
 	}
 	hostname = hostname + ".local"
 
-	// Create transport
-	t, err := transport.NewUDPv4Transport()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create transport: %w", err)
-	}
-
+	ctx, cancel := context.WithCancel(ctx)
 	r := &Responder{
-		ctx:              ctx,
-		transport:        t,
-		registry:         responder.NewRegistry(),
-		hostname:         hostname,
-		responseBuilder:  responder.NewResponseBuilder(),
-		recordSet:        records.NewRecordSet(),
-		rateLimiter:      security.NewRateLimiter(100, 60*time.Second, 10000),
-		queryHandlerDone: make(chan struct{}),
+		ctx:               ctx,
+		cancel:            cancel,
+		registry:          responder.NewRegistry(),
+		hostname:          hostname,
+		responseBuilder:   responder.NewResponseBuilder(),
+		recordSet:         records.NewRecordSet(),
+		rateLimiter:       security.NewRateLimiter(100, 60*time.Second, 10000),
+		queryHandlerDone:  make(chan struct{}),
+		queryHandlerExit:  make(chan struct{}),
+		resolutionPolicy:  DefaultInterfaceResolutionPolicy(),
+		goodbyeCount:      defaultGoodbyeCount,
+		goodbyeInterval:   defaultGoodbyeInterval,
+		initialProbeDelay: protocol.ProbeInitialDelayMax,
 	}
 
 	// Apply options
 	for _, opt := range opts {
 		if err := opt(r); err != nil {
+			cancel()
 			return nil, fmt.Errorf("failed to apply option: %w", err)
 		}
 	}
 
+	// RFC 6762 §6.2 rate limiting runs on the configured clock (WithClock)
+	r.recordSet.SetClock(r.clock)
+
+	// On boot the network may not be up yet: wait for an address before
+	// binding (WithWaitForNetwork)
+	if r.networkWait > 0 {
+		if err := r.waitForNetwork(r.networkWait); err != nil {
+			cancel()
+			return nil, err
+		}
+	}
+
+	// Create transport unless one was supplied via WithTransport
+	if r.transport == nil {
+		t, err := transport.NewUDPv4TransportWithOptions(transport.UDPv4Options{
+			ReadBufferSize:  r.readBufferSize,
+			MulticastTTL:    r.multicastTTL,
+			InterfaceFilter: r.interfaceFilter,
+			Loopback:        r.loopbackAdvertise,
+			Logger:          r.log(),
+		})
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to create transport: %w", err)
+		}
+		r.transport = t
+	}
+
+	// Wrap the final transport for packet tracing; skipped when unset (zero overhead)
+	if r.packetHook != nil {
+		r.transport = transport.NewHookTransport(r.transport, r.packetHook)
+	}
+
+	// Drop copies of a packet delivered on several interfaces; outside the
+	// hook so traces still show every packet on the wire
+	if r.dedupReceives {
+		r.transport = transport.NewDedupTransport(r.transport, transport.DefaultDedupWindow, r.clock)
+	}
+
 	// Start query handler goroutine (T080)
-	r.queryHandlerWg.Add(1)
 	go r.runQueryHandler()
 
 	return r, nil
@@ -108,11 +211,20 @@ func New(ctx context.Context, opts ...Option) (*Responder, error) {
 //
 // Process:
 //  1. Stop query handler goroutine
-//  2. Unregister all services (sends goodbye packets)
-//  3. Close transport
+//  2. Drop queries held for their known-answer continuations (RFC 6762 §7.2)
+//  3. Unregister all services, sending each goodbye WithGoodbyeCount times
+//     along with the copies still due from earlier Unregister calls (blocks
+//     for the retransmission spacing when goodbyes are due)
+//  4. Cancel the responder's context, stopping background probing
+//  5. Close transport (unblocks the query handler's Receive)
+//  6. Wait for the query handler goroutine to exit (bounded by a timeout)
+//
+// Every step runs even if an earlier one fails, so the transport is closed
+// regardless of goodbye failures.
 //
 // Returns:
-//   - error: transport close error
+//   - error: nil on success, otherwise the per-service goodbye failures and
+//     the transport close error joined via errors.Join
 //
 // T043: Implement Close()
 // T080: Stop query handler
@@ -120,12 +232,25 @@ func (r *Responder) Close() error {
 	// Stop query handler goroutine (T080)
 	close(r.queryHandlerDone)
 
-	// Unregister all services (sends goodbye packets)
-	services := r.registry.List()
-	for _, instanceName := range services {
-		// Ignore errors - service may have been manually unregistered
-		_ = r.Unregister(instanceName)
+	// Drop TC queries held for their known-answer continuations (RFC 6762
+	// §7.2), waiting out any answer already being sent
+	r.dropTruncatedQueries()
+
+	// Unregister all services, sending every goodbye copy (WithGoodbyeCount)
+	// along with the copies still due from earlier Unregister calls, which
+	// would otherwise be lost to the transport closing.
+	// FR-015: Failures are reported, not swallowed, since a missed goodbye
+	// leaves stale records in peers' caches until their TTLs expire.
+	goodbyeErr := r.sendClosingGoodbyes(r.takePendingGoodbyes())
+
+	// Stop work still bound to the responder (e.g. probing for a new
+	// hostname); in-flight Register calls fail with a context error. A host
+	// rename stops probing and is waited out, so it cannot re-announce once
+	// Close returns; no new one starts (renameHost).
+	if r.cancel != nil {
+		r.cancel()
 	}
+	_ = r.awaitHostRename(context.Background())
 
 	// Close transport - this also unblocks the query handler goroutine's
 	// Receive() call so it can observe the queryHandlerDone signal and exit.
@@ -134,12 +259,25 @@ func (r *Responder) Close() error {
 		closeErr = r.transport.Close()
 	}
 
-	// Wait for query handler goroutine to finish after transport is closed.
-	// The goroutine will exit once Receive() returns an error from the closed
-	// transport and it checks queryHandlerDone.
-	r.queryHandlerWg.Wait()
+	// Wait for the query handler goroutine to exit. It observes
+	// queryHandlerDone once Receive() returns, either because the transport
+	// was closed or because its per-call deadline (queryReceiveTimeout)
+	// expired, so a transport whose Close does not unblock Receive still
+	// cannot hold Close forever. The wait is bounded in case handleQuery is
+	// stuck.
+	if r.queryHandlerExit != nil {
+		select {
+		case <-r.queryHandlerExit:
+		case <-time.After(queryHandlerShutdownTimeout):
+			r.log().Warn("mDNS query handler did not exit before shutdown timeout",
+				"timeout", queryHandlerShutdownTimeout)
+		}
+	}
 
-	return closeErr
+	if goodbyeErr == nil {
+		return closeErr
+	}
+	return goerrors.Join(goodbyeErr, closeErr)
 }
 
 // ResourceRecord is a type alias for records.ResourceRecord.
@@ -158,10 +296,23 @@ func (r *Responder) Close() error {
 // US2 GREEN: Contract test support for validating resource records
 type ResourceRecord = records.ResourceRecord
 
+// RecordType is a DNS resource record type (RFC 1035 §3.2.2), as used by
+// AnswerQuestion and ResourceRecord.Type.
+type RecordType = protocol.RecordType
+
+// Record types answered by the responder.
+const (
+	RecordTypeA   = protocol.RecordTypeA
+	RecordTypePTR = protocol.RecordTypePTR
+	RecordTypeTXT = protocol.RecordTypeTXT
+	RecordTypeSRV = protocol.RecordTypeSRV
+	RecordTypeANY = protocol.RecordTypeANY
+)
+
 // buildServiceInfo assembles a records.ServiceInfo from individual service
 // fields. Shared by Register, Unregister, and UpdateService so the record-set
 // inputs are constructed in exactly one place.
-func buildServiceInfo(instanceName, serviceType, hostname string, port uint16, ipv4 []byte, txt map[string]string) *records.ServiceInfo {
+func buildServiceInfo(instanceName, serviceType, hostname string, port uint16, ipv4 []byte, txt map[string]string, ptrOnly bool, subtypes []string) *records.ServiceInfo {
 	return &records.ServiceInfo{
 		InstanceName: instanceName,
 		ServiceType:  serviceType,
@@ -169,9 +320,69 @@ func buildServiceInfo(instanceName, serviceType, hostname string, port uint16, i
 		Port:         port,
 		IPv4Address:  ipv4,
 		TXTRecords:   txt,
+		PTROnly:      ptrOnly,
+		Subtypes:     subtypes,
 	}
 }
 
+// log returns the responder's logger, falling back to slog.Default() when
+// WithLogger was not supplied (or the Responder was built as a struct literal).
+func (r *Responder) log() *slog.Logger {
+	if r.logger != nil {
+		return r.logger
+	}
+	return slog.Default()
+}
+
+// mergeTXT returns the effective TXT records for a service: the responder's
+// defaults (WithDefaultTXT) overlaid with the service's own TXT records, so
+// service-level keys win on collision. It returns txt unchanged when no defaults
+// are configured, and nil when both are empty so the record builder still emits
+// the mandatory single 0x00-byte TXT record (RFC 6763 §6).
+func (r *Responder) mergeTXT(txt map[string]string) map[string]string {
+	if len(r.defaultTXT) == 0 {
+		return txt
+	}
+	merged := make(map[string]string, len(r.defaultTXT)+len(txt))
+	for k, v := range r.defaultTXT {
+		merged[k] = v
+	}
+	for k, v := range txt {
+		merged[k] = v
+	}
+	return merged
+}
+
+// localIPv4 returns the host's default IPv4 address via the configured
+// address source, re-resolved on every call so Reload picks up changes.
+func (r *Responder) localIPv4() ([]byte, error) {
+	if r.ipv4Source != nil {
+		return r.ipv4Source()
+	}
+	ipv4, err := getLocalIPv4(r.interfaces(), r.interfaceFilter)
+	if err != nil && r.loopbackAdvertise {
+		// Same-host discovery only (WithLoopbackAdvertise)
+		return net.IPv4(127, 0, 0, 1).To4(), nil
+	}
+	return ipv4, err
+}
+
+// defaultInterface returns the index of the interface whose IPv4 address
+// localIPv4 resolves, so announcements and goodbyes carry that interface's
+// IPv6 addresses alongside it. It returns 0 (unknown) when an address source
+// replaces the lookup or no interface qualifies (e.g. the loopback fallback of
+// WithLoopbackAdvertise).
+func (r *Responder) defaultInterface() int {
+	if r.ipv4Source != nil {
+		return 0
+	}
+	_, ifIndex, err := defaultIPv4Interface(r.interfaces(), r.interfaceFilter)
+	if err != nil {
+		return 0
+	}
+	return ifIndex
+}
+
 // toInternalService converts a public Service to the internal registry type.
 func toInternalService(s *Service) *responder.Service {
 	return &responder.Service{
@@ -179,20 +390,69 @@ func toInternalService(s *Service) *responder.Service {
 		ServiceType:  s.ServiceType,
 		Port:         s.Port,
 		TXT:          s.TXTRecords,
+		Hostname:     s.Hostname,
+		PTROnly:      s.PTROnly,
+		ProxyAddress: s.proxyAddress,
+		Subtypes:     slices.Clone(s.Subtypes),
 	}
 }
 
-// fromInternalService converts an internal registry Service to the public type.
-func fromInternalService(s *responder.Service) *Service {
+// publicService converts a registered internal Service to the public type,
+// with a copy of its TXT records read under the registry lock, so the caller
+// may modify them freely.
+func (r *Responder) publicService(s *responder.Service) *Service {
+	txt, _ := r.registry.TXT(s.InstanceName)
 	return &Service{
 		InstanceName: s.InstanceName,
 		ServiceType:  s.ServiceType,
 		Port:         s.Port,
-		TXTRecords:   s.TXT,
+		TXTRecords:   maps.Clone(txt),
+		Hostname:     s.Hostname,
+		PTROnly:      s.PTROnly,
+		Subtypes:     slices.Clone(s.Subtypes),
+		proxyAddress: s.ProxyAddress,
+	}
+}
+
+// advertisedIPv4 returns the IPv4 address a service's A record carries: the
+// proxied host's address for a service registered with RegisterProxy, else
+// local, this host's address.
+func advertisedIPv4(proxyAddress net.IP, local []byte) []byte {
+	if proxyAddress != nil {
+		return proxyAddress
+	}
+	return local
+}
+
+// hostnameFor returns a service's SRV target: its own Hostname override if
+// set, otherwise the responder hostname.
+func (r *Responder) hostnameFor(serviceHostname string) string {
+	if serviceHostname != "" {
+		return serviceHostname
 	}
+	return r.Hostname()
+}
+
+// Hostname returns the responder's current hostname (e.g., "myhost.local").
+//
+// It differs from the configured name after a hostname conflict rename
+// (WithConflictHostRename).
+func (r *Responder) Hostname() string {
+	r.hostnameMu.RLock()
+	defer r.hostnameMu.RUnlock()
+	return r.hostname
 }
 
-// getLocalIPv4 gets the first non-loopback IPv4 address from any interface.
+// setHostname replaces the responder hostname.
+func (r *Responder) setHostname(hostname string) {
+	r.hostnameMu.Lock()
+	defer r.hostnameMu.Unlock()
+	r.hostname = hostname
+}
+
+// getLocalIPv4 gets the first non-loopback IPv4 address from an interface
+// listed by resolver and accepted by filter (WithInterfaceFilter; nil accepts
+// every interface).
 //
 // DEPRECATED for query response building: Use getIPv4ForInterface(interfaceIndex) instead
 // to comply with RFC 6762 §15 (interface-specific addressing).
@@ -206,24 +466,41 @@ func fromInternalService(s *responder.Service) *Service {
 //   - error: if no suitable address found
 //
 // T037: Marked as deprecated for response building (007-interface-specific-addressing)
-func getLocalIPv4() ([]byte, error) {
-	addrs, err := net.InterfaceAddrs()
+func getLocalIPv4(resolver InterfaceResolver, filter func(net.Interface) bool) ([]byte, error) {
+	ipv4, _, err := defaultIPv4Interface(resolver, filter)
+	return ipv4, err
+}
+
+// defaultIPv4Interface is getLocalIPv4 that also returns the index of the
+// interface the address is configured on.
+func defaultIPv4Interface(resolver InterfaceResolver, filter func(net.Interface) bool) ([]byte, int, error) {
+	ifaces, err := resolver.Interfaces()
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
-			if ipv4 := ipnet.IP.To4(); ipv4 != nil {
-				return ipv4, nil
+	for _, iface := range ifaces {
+		if filter != nil && !filter(iface) {
+			continue
+		}
+		addrs, err := resolver.InterfaceAddrs(iface.Index)
+		if err != nil {
+			continue // Interface vanished or unreadable; try the next
+		}
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && !ipnet.IP.IsLoopback() {
+				if ipv4 := ipnet.IP.To4(); ipv4 != nil {
+					return ipv4, iface.Index, nil
+				}
 			}
 		}
 	}
 
-	return nil, fmt.Errorf("no non-loopback IPv4 address found")
+	return nil, 0, fmt.Errorf("no non-loopback IPv4 address found")
 }
 
-// getIPv4ForInterface returns the IPv4 address assigned to the specified network interface.
+// getIPv4ForInterface returns the IPv4 address assigned to the specified network
+// interface, as reported by resolver.
 //
 // RFC 6762 §15 "Responding to Address Queries" (lines 1020-1024):
 //
@@ -238,6 +515,7 @@ func getLocalIPv4() ([]byte, error) {
 // 007-interface-specific-addressing: T014-T020 implementation
 //
 // Parameters:
+//   - resolver: Interface address lookup (WithInterfaceResolver)
 //   - ifIndex: Network interface index (from Transport.Receive or ipv4.ControlMessage.IfIndex)
 //
 // Returns:
@@ -247,20 +525,116 @@ func getLocalIPv4() ([]byte, error) {
 // Edge Cases:
 //   - Interface not found (removed/down) → NetworkError
 //   - Interface has no IPv4 address (IPv6-only) → ValidationError
-//   - Interface has multiple IPs → returns first IPv4 (consistent behavior)
+//   - Interface has multiple IPs → returns first IPv4 (getAllIPv4ForInterface
+//     returns them all)
 //
 // Example:
 //
-//	ipv4, err := getIPv4ForInterface(2)  // Look up interface index 2 (e.g., wlan0)
+//	ipv4, err := getIPv4ForInterface(r.interfaces(), 2)  // Look up interface index 2 (e.g., wlan0)
 //	if err != nil {
 //	    // Handle error: skip response or fall back to getLocalIPv4()
 //	}
 //	// Use ipv4 in A record for mDNS response
-func getIPv4ForInterface(ifIndex int) ([]byte, error) {
-	// T015: Look up interface by index
+func getIPv4ForInterface(resolver InterfaceResolver, ifIndex int) ([]byte, error) {
+	ipv4s, err := getAllIPv4ForInterface(resolver, ifIndex)
+	if err != nil {
+		return nil, err
+	}
+	return ipv4s[0], nil
+}
+
+// getAllIPv4ForInterface returns every IPv4 address assigned to the specified
+// network interface, in the order resolver reports them; the first is the one
+// getIPv4ForInterface returns.
+//
+// RFC 6762 §15: a response "MUST include all addresses that are valid on the
+// interface", so an interface with an alias is advertised with an A record
+// per address.
+//
+// Returns:
+//   - [][]byte: IPv4 addresses (4 bytes each), at least one
+//   - error: NetworkError if interface not found, ValidationError if no IPv4 address
+func getAllIPv4ForInterface(resolver InterfaceResolver, ifIndex int) ([][]byte, error) {
+	// T015-T016: Look up the interface's addresses (NetworkError if not found)
+	addrs, err := resolver.InterfaceAddrs(ifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// T017: Filter for IPv4 addresses
+	var ipv4s [][]byte
+	for _, addr := range addrs {
+		if ipnet, ok := addr.(*net.IPNet); ok {
+			if ipv4 := ipnet.IP.To4(); ipv4 != nil {
+				ipv4s = append(ipv4s, ipv4)
+			}
+		}
+	}
+
+	if len(ipv4s) == 0 {
+		// T019: No IPv4 found on this interface
+		return nil, &errors.ValidationError{
+			Field:   "interface",
+			Value:   ifIndex,
+			Message: "no IPv4 address found on interface",
+		}
+	}
+	return ipv4s, nil
+}
+
+// getAllIPv6ForInterface returns every advertisable IPv6 address assigned to
+// the specified network interface as 16-byte AAAA RDATA, in the order resolver
+// reports them. Loopback and multicast addresses are skipped, as in
+// selectIPv6.
+//
+// Returns:
+//   - [][]byte: IPv6 addresses (16 bytes each), at least one
+//   - error: NetworkError if interface not found, ValidationError if no IPv6 address
+func getAllIPv6ForInterface(resolver InterfaceResolver, ifIndex int) ([][]byte, error) {
+	addrs, err := resolver.InterfaceAddrs(ifIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	var ipv6s [][]byte
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() != nil || ipnet.IP.To16() == nil {
+			continue
+		}
+		if ipnet.IP.IsLoopback() || ipnet.IP.IsMulticast() {
+			continue
+		}
+		ipv6s = append(ipv6s, ipnet.IP.To16())
+	}
+
+	if len(ipv6s) == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "interface",
+			Value:   ifIndex,
+			Message: "no IPv6 address found on interface",
+		}
+	}
+	return ipv6s, nil
+}
+
+// getIPv6ForInterface returns the IPv6 address to advertise on the specified
+// network interface, with its zone set when the address is link-local.
+//
+// RFC 6762 §15: As with IPv4, only addresses valid on the receiving interface
+// may be advertised. A link-local address (fe80::/10) is only meaningful
+// together with its interface, so the returned net.IPAddr carries the interface
+// name as Zone and String() renders it as "fe80::1%eth0".
+//
+// Parameters:
+//   - ifIndex: Network interface index (from Transport.Receive)
+//
+// Returns:
+//   - *net.IPAddr: IPv6 address; Zone is the interface name for link-local addresses
+//   - error: NetworkError if interface not found, ValidationError if no IPv6 address
+func getIPv6ForInterface(ifIndex int) (*net.IPAddr, error) {
 	iface, err := net.InterfaceByIndex(ifIndex)
 	if err != nil {
-		// T018: Interface not found (removed, invalid index, etc.)
 		return nil, &errors.NetworkError{
 			Operation: "lookup interface",
 			Err:       err,
@@ -268,7 +642,6 @@ func getIPv4ForInterface(ifIndex int) ([]byte, error) {
 		}
 	}
 
-	// T016: Get all addresses for this interface
 	addrs, err := iface.Addrs()
 	if err != nil {
 		return nil, &errors.NetworkError{
@@ -278,19 +651,52 @@ func getIPv4ForInterface(ifIndex int) ([]byte, error) {
 		}
 	}
 
-	// T017: Filter for first IPv4 address
-	for _, addr := range addrs {
-		if ipnet, ok := addr.(*net.IPNet); ok {
-			if ipv4 := ipnet.IP.To4(); ipv4 != nil {
-				return ipv4, nil
-			}
-		}
+	if addr := selectIPv6(addrs, iface.Name); addr != nil {
+		return addr, nil
 	}
 
-	// T019: No IPv4 found on this interface
 	return nil, &errors.ValidationError{
 		Field:   "interface",
 		Value:   iface.Name,
-		Message: "no IPv4 address found on interface",
+		Message: "no IPv6 address found on interface",
 	}
 }
+
+// selectIPv6 picks the IPv6 address to advertise from an interface's addresses.
+//
+// Global (and unique-local) unicast addresses are preferred because they need
+// no scope; otherwise the first link-local address is returned with Zone set to
+// ifaceName. Loopback, multicast, and IPv4 addresses are ignored.
+//
+// Returns nil if the interface has no usable IPv6 address.
+func selectIPv6(addrs []net.Addr, ifaceName string) *net.IPAddr {
+	var linkLocal net.IP
+	for _, addr := range addrs {
+		ipnet, ok := addr.(*net.IPNet)
+		if !ok || ipnet.IP.To4() != nil || ipnet.IP.To16() == nil {
+			continue
+		}
+
+		ip := ipnet.IP
+		switch {
+		case ip.IsGlobalUnicast():
+			return &net.IPAddr{IP: ip}
+		case ip.IsLinkLocalUnicast() && linkLocal == nil:
+			linkLocal = ip
+		}
+	}
+
+	if linkLocal == nil {
+		return nil
+	}
+	return &net.IPAddr{IP: linkLocal, Zone: ifaceName}
+}
+
+// ipv6RData encodes an IPv6 address as AAAA RDATA per RFC 3596 §2.2.
+//
+// The wire format is the bare 16-byte address; the zone is local scope
+// information and is never transmitted (receivers infer it from the interface
+// the response arrived on).
+func ipv6RData(addr *net.IPAddr) []byte {
+	return append([]byte(nil), addr.IP.To16()...)
+}
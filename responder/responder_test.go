@@ -4,17 +4,26 @@ import (
 	"bytes"
 	"context"
 	goerrors "errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
 	"net"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/errors"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/records"
 	internalresponder "github.com/joshuafuller/beacon/internal/responder"
 	"github.com/joshuafuller/beacon/internal/security"
+	"github.com/joshuafuller/beacon/internal/transport"
 )
 
 // TestResponder_New_RED tests Responder initialization.
@@ -407,19 +416,367 @@ func TestResponder_Register_MaxRenameAttempts(t *testing.T) {
 	}
 }
 
-// TestResponder_Register_RenameOnConflict tests that Register() renames on conflict.
-//
-// TDD Phase: RED
+// TestResponder_Register_NoRename verifies that with WithNoRename the first
+// conflict fails Register with ErrNameConflict, without renaming the service
+// or adding it to the registry.
+func TestResponder_Register_NoRename(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := &eventRecorder{}
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithObserver(rec),
+		WithNoRename())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	r.InjectConflictDuringProbing(true)
+
+	service := &Service{InstanceName: "Licensed Service", ServiceType: "_http._tcp.local", Port: 8080}
+	err = registerOnFakeClock(t, r, fake, service)
+	if !goerrors.Is(err, ErrNameConflict) {
+		t.Fatalf("Register() error = %v, want ErrNameConflict", err)
+	}
+
+	if service.InstanceName != "Licensed Service" {
+		t.Errorf("InstanceName = %q, want it unchanged", service.InstanceName)
+	}
+	if _, exists := r.registry.Get("Licensed Service"); exists {
+		t.Error("service in registry after a conflict with WithNoRename, want absent")
+	}
+	for _, ev := range rec.summary() {
+		if strings.HasPrefix(ev, "Renamed") || ev == "ProbeStarted(Licensed Service-2)" {
+			t.Errorf("event %s after a conflict with WithNoRename, want no rename", ev)
+		}
+	}
+}
+
+// TestResponder_Register_MaxServices verifies that with WithMaxServices(2) two
+// services register and the third fails with ErrServiceLimitReached, and that
+// a limit below 1 is rejected.
+func TestResponder_Register_MaxServices(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithMaxServices(2))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	for _, name := range []string{"First", "Second"} {
+		service := &Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 8080}
+		if err := registerOnFakeClock(t, r, fake, service); err != nil {
+			t.Fatalf("Register(%q) error = %v, want nil", name, err)
+		}
+	}
+
+	third := &Service{InstanceName: "Third", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.Register(third); !goerrors.Is(err, ErrServiceLimitReached) {
+		t.Fatalf("Register(\"Third\") error = %v, want ErrServiceLimitReached", err)
+	}
+	if _, exists := r.registry.Get("Third"); exists {
+		t.Error("third service in registry beyond the limit, want absent")
+	}
+
+	if r, err := New(context.Background(), WithMaxServices(0)); err == nil {
+		_ = r.Close()
+		t.Error("New(WithMaxServices(0)) error = nil, want ValidationError")
+	}
+}
+
+// TestResponder_Register_MaxServicesReservesSlot verifies that a service still
+// probing holds its slot, so a concurrent Register fails at once rather than
+// after probing, and that a failed registration gives the slot back.
+func TestResponder_Register_MaxServicesReservesSlot(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithMaxServices(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	probing := make(chan error, 1)
+	go func() {
+		probing <- r.RegisterContext(ctx, &Service{InstanceName: "First", ServiceType: "_http._tcp.local", Port: 8080})
+	}()
+	fake.WaitForWaiters(1) // First is probing
+
+	second := &Service{InstanceName: "Second", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.Register(second); !goerrors.Is(err, ErrServiceLimitReached) {
+		t.Fatalf("Register(\"Second\") while First probes error = %v, want ErrServiceLimitReached", err)
+	}
+
+	cancel()
+	if err := <-probing; !goerrors.Is(err, context.Canceled) {
+		t.Fatalf("RegisterContext(\"First\") error = %v, want context.Canceled", err)
+	}
+	if err := registerOnFakeClock(t, r, fake, second); err != nil {
+		t.Errorf("Register(\"Second\") after First failed error = %v, want nil", err)
+	}
+}
+
+// TestResponder_Register_InvalidAddress verifies Register fails with a
+// ValidationError, sending nothing, when the resolved IPv4 address is
+// malformed, 0.0.0.0 or loopback, instead of advertising it.
+func TestResponder_Register_InvalidAddress(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		ipv4 []byte
+	}{
+		{"missing", nil},
+		{"wrong length", []byte{192, 168, 1}},
+		{"unspecified", []byte{0, 0, 0, 0}},
+		{"loopback", []byte{127, 0, 0, 1}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var sends atomic.Int32
+			r, err := New(context.Background(),
+				WithTransport(&MockTransport{sendFunc: func(context.Context, []byte, net.Addr) error {
+					sends.Add(1)
+					return nil
+				}}),
+				WithHostname("testhost.local"))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer func() { _ = r.Close() }()
+			r.ipv4Source = func() ([]byte, error) { return tt.ipv4, nil }
+
+			service := &Service{InstanceName: "Misaddressed", ServiceType: "_http._tcp.local", Port: 8080}
+			err = r.Register(service)
+			var valErr *errors.ValidationError
+			if !goerrors.As(err, &valErr) || valErr.Field != "IPv4Address" {
+				t.Fatalf("Register() error = %v, want ValidationError for IPv4Address", err)
+			}
+			if n := sends.Load(); n != 0 {
+				t.Errorf("sent %d packets for an invalid address, want 0", n)
+			}
+			if _, exists := r.registry.Get("Misaddressed"); exists {
+				t.Error("service in registry after Register failed")
+			}
+		})
+	}
+}
+
+// TestResponder_Register_SkipAnnounce verifies that with WithSkipAnnounce
+// Register probes but sends no announcement (RFC 6762 §8.3), and the service
+// is still established, in the registry and answering queries.
+func TestResponder_Register_SkipAnnounce(t *testing.T) {
+	var mu sync.Mutex
+	var responses int
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	rec := &eventRecorder{}
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			if msg, err := message.ParseMessage(packet); err == nil && msg.Header.IsResponse() {
+				mu.Lock()
+				responses++
+				mu.Unlock()
+			}
+			return nil
+		}}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithObserver(rec),
+		WithSkipAnnounce())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	service := &Service{InstanceName: "Quiet", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	mu.Lock()
+	announced := responses
+	mu.Unlock()
+	if announced != 0 {
+		t.Errorf("sent %d responses during Register, want no announcements", announced)
+	}
+	for _, ev := range rec.summary() {
+		if strings.HasPrefix(ev, "AnnounceSent") {
+			t.Errorf("event %s with WithSkipAnnounce, want none", ev)
+		}
+	}
+
+	statuses := r.Services()
+	if len(statuses) != 1 || statuses[0].State != StateEstablished {
+		t.Fatalf("Services() = %+v, want Quiet established", statuses)
+	}
+	if _, exists := r.registry.Get("Quiet"); !exists {
+		t.Fatal("service not in registry after Register with WithSkipAnnounce")
+	}
+
+	if err := r.handleQuery(buildDNSQuery("_http._tcp.local", uint16(protocol.RecordTypePTR)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if responses != 1 {
+		t.Errorf("sent %d responses to a PTR query, want 1", responses)
+	}
+}
+
+// TestResponder_AnswersOnlyWhenEstablished verifies a service is not answered
+// for while probing, nor by default while announcing, so a name that could
+// still be lost is never advertised (RFC 6762 §8); WithAnswerDuringAnnounce
+// answers from the first announcement.
+func TestResponder_AnswersOnlyWhenEstablished(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		relaxed bool
+	}{
+		{"default", false},
+		{"WithAnswerDuringAnnounce", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Legacy unicast replies go straight back to the querier, apart
+			// from the multicast probes and announcements
+			legacySrc := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 54321}
+			var replies atomic.Int32
+			fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			r, err := New(context.Background(),
+				WithTransport(&MockTransport{sendFunc: func(_ context.Context, _ []byte, dest net.Addr) error {
+					if dest.String() == legacySrc.String() {
+						replies.Add(1)
+					}
+					return nil
+				}}),
+				WithHostname("testhost.local"),
+				WithClock(fake),
+				WithGoodbyeCount(1),
+				WithAnswerDuringAnnounce(tt.relaxed))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer func() { _ = r.Close() }()
+			r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+			query := buildDNSQuery("Pending._http._tcp.local", uint16(protocol.RecordTypeSRV))
+			answered := func() bool {
+				before := replies.Load()
+				if err := r.handleQuery(query, legacySrc, 0); err != nil {
+					t.Fatalf("handleQuery() error = %v", err)
+				}
+				return replies.Load() > before
+			}
+
+			service := &Service{InstanceName: "Pending", ServiceType: "_http._tcp.local", Port: 8080}
+			done := make(chan error, 1)
+			go func() { done <- r.Register(service) }()
+
+			// Query at each wait of the registration, then move it along
+			seen := make(map[ServiceState]bool)
+			deadline := time.After(5 * time.Second)
+		register:
+			for {
+				select {
+				case err := <-done:
+					if err != nil {
+						t.Fatalf("Register() error = %v", err)
+					}
+					break register
+				case <-deadline:
+					t.Fatal("Register() did not complete")
+				default:
+				}
+				if fake.Waiters() == 0 {
+					time.Sleep(time.Millisecond)
+					continue
+				}
+				statuses := r.Services()
+				if len(statuses) == 1 {
+					st := statuses[0].State
+					seen[st] = true
+					want := st == StateAnnouncing && tt.relaxed
+					if got := answered(); got != want {
+						t.Errorf("answered while %v = %v, want %v", st, got, want)
+					}
+				}
+				fake.Advance(time.Second)
+			}
+
+			if !seen[StateProbing] || !seen[StateAnnouncing] {
+				t.Errorf("queried in states %v, want probing and announcing", seen)
+			}
+			if !answered() {
+				t.Error("not answered once established")
+			}
+		})
+	}
+}
+
+// TestAnswerableServices_HandOverToRegistry verifies a service registered
+// while still in the announcing set (WithAnswerDuringAnnounce) is answered for
+// once, so the registry can take it over before it leaves that set without a
+// window where it is not answered for.
+func TestAnswerableServices_HandOverToRegistry(t *testing.T) {
+	r := &Responder{
+		registry:         internalresponder.NewRegistry(),
+		hostname:         "testhost.local",
+		answerAnnouncing: true,
+	}
+	service := &Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}
+	r.startAnswering(service)
+	if err := r.registry.Register(toInternalService(service)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if services := r.answerableServices(); len(services) != 1 {
+		t.Errorf("answerableServices() returned %d services, want 1", len(services))
+	}
+	r.stopAnswering(service.InstanceName)
+	if services := r.answerableServices(); len(services) != 1 {
+		t.Errorf("answerableServices() after stopAnswering returned %d services, want 1", len(services))
+	}
+}
+
+// TestResponder_Register_RenameOnConflict verifies a service whose first
+// probe conflicts is renamed with a numeric suffix and then registers.
 //
 // RFC 6762 §9: Service renamed with numeric suffix on conflict
 // FR-030: System MUST rename service on conflict
-// TestResponder_Register_RenameOnConflict verifies the rename-on-conflict loop.
-//
-// Coverage: The rename loop logic is exercised by TestResponder_Register_MaxRenameAttempts
-// which verifies the loop runs up to maxRenameAttempts. Individual rename behavior
-// (e.g., "My Service" → "My Service-2") is tested by TestService_Rename in service_test.go.
-// Full end-to-end conflict resolution with real conflict injection requires a dedicated
-// transport that can inject conflicts after N probes - tracked as future enhancement.
+func TestResponder_Register_RenameOnConflict(t *testing.T) {
+	r, fake, rec := newObservedResponder(t)
+	r.InjectConflictForAttempts(1)
+
+	service := &Service{InstanceName: "My Service", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v, want nil after one conflict", err)
+	}
+
+	if service.InstanceName != "My Service-2" {
+		t.Errorf("InstanceName = %q, want %q", service.InstanceName, "My Service-2")
+	}
+	if _, exists := r.registry.Get("My Service-2"); !exists {
+		t.Error("renamed service not in registry")
+	}
+	if _, exists := r.registry.Get("My Service"); exists {
+		t.Error("original name still in registry after rename")
+	}
+	if got := rec.summary(); !slices.Contains(got, "Renamed(My Service→My Service-2)") {
+		t.Errorf("events = %v, want a rename to %q", got, "My Service-2")
+	}
+}
 
 // =============================================================================
 // User Story 5: Multi-Service Support Tests (TDD - RED Phase)
@@ -670,7 +1027,7 @@ func TestGetIPv4ForInterface_ValidInterface(t *testing.T) {
 	}
 
 	// Test: getIPv4ForInterface should return the interface's IPv4 address
-	ipv4, err := getIPv4ForInterface(testIface.Index)
+	ipv4, err := getIPv4ForInterface(systemInterfaceResolver{}, testIface.Index)
 	if err != nil {
 		t.Fatalf("getIPv4ForInterface(%d) error = %v, want nil", testIface.Index, err)
 	}
@@ -690,7 +1047,7 @@ func TestGetIPv4ForInterface_InvalidIndex(t *testing.T) {
 	// Use an impossibly high interface index
 	invalidIndex := 9999
 
-	ipv4, err := getIPv4ForInterface(invalidIndex)
+	ipv4, err := getIPv4ForInterface(systemInterfaceResolver{}, invalidIndex)
 	if err == nil {
 		t.Fatalf("getIPv4ForInterface(%d) error = nil, want NetworkError", invalidIndex)
 	}
@@ -708,6 +1065,89 @@ func TestGetIPv4ForInterface_InvalidIndex(t *testing.T) {
 	t.Logf("✓ Invalid interface index %d → NetworkError: %v", invalidIndex, err)
 }
 
+// TestSelectIPv6_LinkLocalCarriesZone tests that a link-local-only interface
+// yields its own link-local address scoped to that interface.
+func TestSelectIPv6_LinkLocalCarriesZone(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.IPv4(192, 168, 1, 10), Mask: net.CIDRMask(24, 32)},
+		&net.IPNet{IP: net.ParseIP("fe80::1c2b:3cff:fe4d:5e6f"), Mask: net.CIDRMask(64, 128)},
+	}
+
+	got := selectIPv6(addrs, "eth0")
+	if got == nil {
+		t.Fatal("selectIPv6() = nil, want link-local address")
+	}
+	if !got.IP.Equal(net.ParseIP("fe80::1c2b:3cff:fe4d:5e6f")) {
+		t.Errorf("selectIPv6() IP = %v, want fe80::1c2b:3cff:fe4d:5e6f", got.IP)
+	}
+	if got.String() != "fe80::1c2b:3cff:fe4d:5e6f%eth0" {
+		t.Errorf("selectIPv6().String() = %q, want zone %%eth0", got.String())
+	}
+
+	rdata := ipv6RData(got)
+	if len(rdata) != net.IPv6len || !net.IP(rdata).Equal(got.IP) {
+		t.Errorf("ipv6RData() = %x, want 16-byte address without zone", rdata)
+	}
+}
+
+// TestSelectIPv6_PrefersGlobal tests that a global address wins over
+// link-local and is returned without a zone.
+func TestSelectIPv6_PrefersGlobal(t *testing.T) {
+	addrs := []net.Addr{
+		&net.IPNet{IP: net.ParseIP("::1"), Mask: net.CIDRMask(128, 128)},
+		&net.IPNet{IP: net.ParseIP("fe80::1"), Mask: net.CIDRMask(64, 128)},
+		&net.IPNet{IP: net.ParseIP("2001:db8::10"), Mask: net.CIDRMask(64, 128)},
+	}
+
+	got := selectIPv6(addrs, "eth0")
+	if got == nil || !got.IP.Equal(net.ParseIP("2001:db8::10")) {
+		t.Fatalf("selectIPv6() = %v, want 2001:db8::10", got)
+	}
+	if got.Zone != "" {
+		t.Errorf("selectIPv6() Zone = %q, want empty for global address", got.Zone)
+	}
+
+	if selectIPv6(addrs[:1], "lo") != nil {
+		t.Error("selectIPv6(loopback only) should return nil")
+	}
+}
+
+// TestGetIPv6ForInterface_MatchesInterface tests that the selected address
+// belongs to the queried interface and link-local results are scoped to it.
+func TestGetIPv6ForInterface_MatchesInterface(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatalf("net.Interfaces() failed: %v", err)
+	}
+
+	tested := false
+	for _, iface := range ifaces {
+		got, err := getIPv6ForInterface(iface.Index)
+		if err != nil {
+			continue // No usable IPv6 on this interface
+		}
+		tested = true
+
+		addrs, _ := iface.Addrs()
+		owned := false
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.Equal(got.IP) {
+				owned = true
+			}
+		}
+		if !owned {
+			t.Errorf("getIPv6ForInterface(%d) = %v, not an address of %s", iface.Index, got, iface.Name)
+		}
+		if got.IP.IsLinkLocalUnicast() && got.Zone != iface.Name {
+			t.Errorf("getIPv6ForInterface(%d) Zone = %q, want %q", iface.Index, got.Zone, iface.Name)
+		}
+	}
+
+	if !tested {
+		t.Skip("no interface with a usable IPv6 address")
+	}
+}
+
 // TestGetIPv4ForInterface_LoopbackInterface tests loopback handling.
 //
 // Loopback should work (it has an IPv4), but typically not used for mDNS responses
@@ -731,7 +1171,7 @@ func TestGetIPv4ForInterface_LoopbackInterface(t *testing.T) {
 	}
 
 	// Loopback should return its IPv4 address (127.0.0.1)
-	ipv4, err := getIPv4ForInterface(loopbackIndex)
+	ipv4, err := getIPv4ForInterface(systemInterfaceResolver{}, loopbackIndex)
 	if err != nil {
 		t.Fatalf("getIPv4ForInterface(loopback=%d) error = %v, want nil", loopbackIndex, err)
 	}
@@ -795,7 +1235,7 @@ func TestGetIPv4ForInterface_MultipleInterfaces(t *testing.T) {
 
 	// Test each interface returns its own IP
 	for _, iface := range validIfaces {
-		ipv4, err := getIPv4ForInterface(iface.index)
+		ipv4, err := getIPv4ForInterface(systemInterfaceResolver{}, iface.index)
 		if err != nil {
 			t.Errorf("getIPv4ForInterface(%d) error = %v, want nil", iface.index, err)
 			continue
@@ -816,8 +1256,8 @@ func TestGetIPv4ForInterface_MultipleInterfaces(t *testing.T) {
 
 	// KEY TEST: Verify different interfaces return DIFFERENT IPs (RFC 6762 §15)
 	if len(validIfaces) >= 2 {
-		ip1, err1 := getIPv4ForInterface(validIfaces[0].index)
-		ip2, err2 := getIPv4ForInterface(validIfaces[1].index)
+		ip1, err1 := getIPv4ForInterface(systemInterfaceResolver{}, validIfaces[0].index)
+		ip2, err2 := getIPv4ForInterface(systemInterfaceResolver{}, validIfaces[1].index)
 
 		// If either lookup failed, we can't compare
 		if err1 != nil || err2 != nil {
@@ -878,7 +1318,7 @@ func BenchmarkGetIPv4ForInterface(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		_, err := getIPv4ForInterface(testIndex)
+		_, err := getIPv4ForInterface(systemInterfaceResolver{}, testIndex)
 		if err != nil {
 			b.Fatalf("getIPv4ForInterface(%d) failed: %v", testIndex, err)
 		}
@@ -895,7 +1335,7 @@ func BenchmarkGetIPv4ForInterface_CacheMiss(b *testing.B) {
 	b.ReportAllocs()
 
 	for i := 0; i < b.N; i++ {
-		_, _ = getIPv4ForInterface(invalidIndex) // Expect error
+		_, _ = getIPv4ForInterface(systemInterfaceResolver{}, invalidIndex) // Expect error
 	}
 }
 
@@ -930,6 +1370,97 @@ func TestHandleQuery_RejectsWrongSubnet(t *testing.T) {
 	// Full per-interface source filtering is deferred to M2 (requires per-interface transports).
 }
 
+// TestResponder_Close_JoinsQueryHandler runs New/Register/Close in a loop and
+// verifies no query handler goroutine outlives Close. The mock transport's
+// Close does not unblock Receive, so exit relies on the per-Receive deadline.
+func TestResponder_Close_JoinsQueryHandler(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	for i := 0; i < 20; i++ {
+		mock := transport.NewMockTransport()
+		mock.EnableBlockingReceive()
+
+		r, err := New(context.Background(), WithTransport(mock), WithHostname("leak.local"))
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+		r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+		svc := &Service{InstanceName: "Leak", ServiceType: "_http._tcp.local", Port: 80}
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+		}
+
+		time.Sleep(5 * time.Millisecond) // Let the query handler block in Receive
+
+		start := time.Now()
+		if err := r.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= queryHandlerShutdownTimeout {
+			t.Fatalf("Close() took %v: query handler did not exit before the shutdown timeout", elapsed)
+		}
+		select {
+		case <-r.queryHandlerExit:
+		default:
+			t.Fatal("Close() returned before the query handler goroutine exited")
+		}
+	}
+
+	// Allow unrelated runtime goroutines to settle before comparing
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > baseline {
+		t.Errorf("goroutines after 20 New/Close cycles = %d, baseline %d (leak)", n, baseline)
+	}
+}
+
+// TestResponder_Close_ReportsGoodbyeFailures verifies Close reports a service
+// whose goodbye could not be sent, joined with the transport close error, and
+// still closes the transport (FR-015, RFC 6762 §10.1).
+func TestResponder_Close_ReportsGoodbyeFailures(t *testing.T) {
+	sendErr := goerrors.New("network unreachable")
+	closeErr := goerrors.New("close failed")
+	var closed bool
+	mock := &MockTransport{
+		sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			if bytes.Contains(packet, []byte("Broken")) {
+				return sendErr
+			}
+			return nil
+		},
+		closeFunc: func() error {
+			closed = true
+			return closeErr
+		},
+	}
+	r, err := New(context.Background(), WithTransport(mock), WithHostname("testhost.local"), WithGoodbyeCount(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	for _, name := range []string{"Broken", "Healthy"} {
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	err = r.Close()
+	if !closed {
+		t.Error("Close() did not close the transport after a goodbye failure")
+	}
+	if !goerrors.Is(err, sendErr) {
+		t.Fatalf("Close() error = %v, want it to wrap the goodbye send error", err)
+	}
+	if !goerrors.Is(err, closeErr) {
+		t.Errorf("Close() error = %v, want it to wrap the transport close error", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, `"Broken"`) || strings.Contains(msg, `"Healthy"`) {
+		t.Errorf("Close() error = %q, want only the Broken service reported", msg)
+	}
+}
+
 // MockTransport is a test double for Transport interface.
 type MockTransport struct {
 	sendFunc    func(ctx context.Context, packet []byte, dest net.Addr) error
@@ -944,6 +1475,10 @@ func (m *MockTransport) Send(ctx context.Context, packet []byte, dest net.Addr)
 	return nil
 }
 
+func (m *MockTransport) SendOnInterface(ctx context.Context, packet []byte, dest net.Addr, _ int) error {
+	return m.Send(ctx, packet, dest)
+}
+
 func (m *MockTransport) Receive(ctx context.Context) ([]byte, net.Addr, int, error) {
 	if m.receiveFunc != nil {
 		return m.receiveFunc(ctx)
@@ -958,6 +1493,14 @@ func (m *MockTransport) Close() error {
 	return nil
 }
 
+func (m *MockTransport) LocalAddr() net.Addr {
+	return nil
+}
+
+func (m *MockTransport) Stats() transport.TransportStats {
+	return transport.TransportStats{}
+}
+
 // TestUnregister_SendsGoodbyePackets tests that Unregister() sends goodbye packets with TTL=0.
 //
 // RFC 6762 §10.1: "To provide immediate notification when a host shuts down or a service
@@ -966,9 +1509,12 @@ func (m *MockTransport) Close() error {
 //
 // Task 1: Goodbye Packets implementation test
 func TestUnregister_SendsGoodbyePackets(t *testing.T) {
+	var mu sync.Mutex
 	var sentPacket []byte
 	mockTransport := &MockTransport{
 		sendFunc: func(ctx context.Context, packet []byte, dest net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
 			sentPacket = packet
 			return nil
 		},
@@ -980,6 +1526,8 @@ func TestUnregister_SendsGoodbyePackets(t *testing.T) {
 		hostname:  "test.local",
 		ctx:       context.Background(),
 	}
+	// No Close here: stop the goodbye retransmission outliving the test
+	defer r.cancelAllPendingGoodbyes()
 
 	service := &Service{
 		InstanceName: "test",
@@ -1001,11 +1549,72 @@ func TestUnregister_SendsGoodbyePackets(t *testing.T) {
 		t.Fatalf("Unregister failed: %v", err)
 	}
 
+	mu.Lock()
+	defer mu.Unlock()
 	if sentPacket == nil {
 		t.Fatal("Expected goodbye packet to be sent, got nil")
 	}
 }
 
+// TestSubtypePTRs_AnnouncedAndSaidGoodbye verifies a service's subtype PTRs
+// ("_printer._sub._http._tcp.local", RFC 6763 §7.1) are announced with its
+// other records and withdrawn by its goodbye (RFC 6762 §10.1).
+func TestSubtypePTRs_AnnouncedAndSaidGoodbye(t *testing.T) {
+	var mu sync.Mutex
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:   internalresponder.NewRegistry(),
+		hostname:   "test.local",
+		ipv4Source: func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	defer r.cancelAllPendingGoodbyes()
+	if err := r.RegisterServiceWithoutProbing(&Service{
+		InstanceName: "Printer UI", ServiceType: "_http._tcp.local", Port: 80, Subtypes: []string{"_printer"},
+	}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	// subtypeTTL returns the TTL of the subtype PTR in the last packet sent
+	subtypeTTL := func() (uint32, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(sent) == 0 {
+			return 0, false
+		}
+		resp, err := message.ParseMessage(sent[len(sent)-1])
+		if err != nil {
+			t.Fatalf("ParseMessage() error = %v", err)
+		}
+		for _, answer := range resp.Answers {
+			if answer.TYPE == uint16(protocol.RecordTypePTR) && answer.NAME == "_printer._sub._http._tcp.local" {
+				return answer.TTL, true
+			}
+		}
+		return 0, false
+	}
+
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if ttl, ok := subtypeTTL(); !ok || ttl == 0 {
+		t.Errorf("announcement subtype PTR TTL = %d (present %v), want a live record", ttl, ok)
+	}
+
+	if err := r.Unregister("Printer UI"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if ttl, ok := subtypeTTL(); !ok || ttl != 0 {
+		t.Errorf("goodbye subtype PTR TTL = %d (present %v), want 0", ttl, ok)
+	}
+}
+
 // TestHandleQuery_QUBitUnicastResponse tests QU bit handling for unicast responses.
 //
 // RFC 6762 §5.4: "When a Multicast DNS querier sends a query with the QU bit set,
@@ -1322,6 +1931,117 @@ func TestHandleQuery_AQuery(t *testing.T) {
 	t.Logf("A query for %q sent %d response packet(s)", "testhost.local", len(sentPackets))
 }
 
+// TestUpdateService_RejectsOversizedTXT verifies UpdateService rejects TXT
+// records over RFC 6763 §6 limits, leaving the service's TXT records and the
+// network untouched.
+func TestUpdateService_RejectsOversizedTXT(t *testing.T) {
+	sent := 0
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(context.Context, []byte, net.Addr) error {
+			sent++
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "testhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	svc := &Service{InstanceName: "Big", ServiceType: "_http._tcp.local", Port: 80, TXTRecords: map[string]string{"version": "1.0"}}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	err := r.UpdateService("Big", oversizedTXT())
+	var valErr *errors.ValidationError
+	if !goerrors.As(err, &valErr) {
+		t.Fatalf("UpdateService(oversized TXT) error = %v, want ValidationError", err)
+	}
+	if got, _ := r.GetService("Big"); got.TXTRecords["version"] != "1.0" || len(got.TXTRecords) != 1 {
+		t.Errorf("TXT records after rejected update = %v, want the previous set", got.TXTRecords)
+	}
+	if sent != 0 {
+		t.Errorf("sent %d packets for a rejected update, want 0", sent)
+	}
+}
+
+// TestSetTXTKey_MergesAndAnnounces verifies SetTXTKey and DeleteTXTKey change
+// one TXT key, leaving the others intact, and announce the result (RFC 6762
+// §8.4); an invalid key or an absent key to delete sends nothing.
+func TestSetTXTKey_MergesAndAnnounces(t *testing.T) {
+	var mu sync.Mutex
+	var sent [][]byte
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("testhost.local"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	announcements := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(sent)
+	}
+
+	svc := &Service{InstanceName: "Printer", ServiceType: "_ipp._tcp.local", Port: 631,
+		TXTRecords: map[string]string{"txtvers": "1", "status": "idle"}}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	if err := r.SetTXTKey("Printer", "status", "busy"); err != nil {
+		t.Fatalf("SetTXTKey() error = %v", err)
+	}
+	got, _ := r.GetService("Printer")
+	if len(got.TXTRecords) != 2 || got.TXTRecords["status"] != "busy" || got.TXTRecords["txtvers"] != "1" {
+		t.Errorf("TXT records after SetTXTKey = %v, want txtvers=1 and status=busy", got.TXTRecords)
+	}
+	if n := announcements(); n != 1 {
+		t.Errorf("sent %d packets for SetTXTKey, want 1 announcement", n)
+	}
+
+	// TXT keys are case insensitive (RFC 6763 §6.4): replaced, not duplicated
+	if err := r.SetTXTKey("Printer._ipp._tcp.local", "Status", "offline"); err != nil {
+		t.Fatalf("SetTXTKey(Status) error = %v", err)
+	}
+	got, _ = r.GetService("Printer")
+	if len(got.TXTRecords) != 2 || got.TXTRecords["Status"] != "offline" {
+		t.Errorf("TXT records after SetTXTKey(Status) = %v, want Status=offline replacing status", got.TXTRecords)
+	}
+
+	if err := r.DeleteTXTKey("Printer", "status"); err != nil {
+		t.Fatalf("DeleteTXTKey() error = %v", err)
+	}
+	got, _ = r.GetService("Printer")
+	if len(got.TXTRecords) != 1 || got.TXTRecords["txtvers"] != "1" {
+		t.Errorf("TXT records after DeleteTXTKey = %v, want only txtvers=1", got.TXTRecords)
+	}
+	if n := announcements(); n != 3 {
+		t.Errorf("sent %d packets after three edits, want 3 announcements", n)
+	}
+
+	if err := r.DeleteTXTKey("Printer", "missing"); err != nil {
+		t.Errorf("DeleteTXTKey(absent key) error = %v, want nil", err)
+	}
+	var valErr *errors.ValidationError
+	if err := r.SetTXTKey("Printer", "a=b", "c"); !goerrors.As(err, &valErr) {
+		t.Errorf("SetTXTKey(key with '=') error = %v, want ValidationError", err)
+	}
+	if err := r.SetTXTKey("Nobody", "status", "busy"); err == nil {
+		t.Error("SetTXTKey(unknown service) error = nil, want error")
+	}
+	if n := announcements(); n != 3 {
+		t.Errorf("sent %d packets, want no announcement for no-op or rejected edits", n)
+	}
+}
+
 // TestUpdateService_SendsAnnouncement tests that UpdateService sends a multicast
 // announcement after updating TXT records per RFC 6762 §8.4.
 func TestUpdateService_SendsAnnouncement(t *testing.T) {
@@ -1406,3 +2126,1199 @@ func TestUpdateService_SendsAnnouncement(t *testing.T) {
 
 	t.Logf("UpdateService sent %d announcement packet(s), registry updated correctly", len(sentPackets))
 }
+
+// TestUpdateService_ConcurrentWithReaders hammers UpdateService, GetService
+// and TXT queries for one service from several goroutines; run under -race.
+// Every read must see a whole TXT set, never a mix of two updates, and the
+// final records must be one writer's last update.
+func TestUpdateService_ConcurrentWithReaders(t *testing.T) {
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       &MockTransport{},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "testhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		ipv4Source:      func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil },
+	}
+	svc := &Service{InstanceName: "Printer", ServiceType: "_ipp._tcp.local", Port: 631,
+		TXTRecords: map[string]string{"a": "init", "b": "init"}}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	query := buildQueryPacket(t, "Printer._ipp._tcp.local", uint16(protocol.RecordTypeTXT), uint16(protocol.ClassIN))
+	src := &net.UDPAddr{IP: net.ParseIP("192.168.1.100"), Port: 5353}
+
+	const writers, updates = 4, 50
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range updates {
+				v := fmt.Sprintf("w%d-%d", w, i)
+				if err := r.UpdateService("Printer", map[string]string{"a": v, "b": v}); err != nil {
+					t.Errorf("UpdateService() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range updates {
+				got, found := r.GetService("Printer")
+				if !found {
+					t.Error("GetService() found = false, want true")
+					return
+				}
+				if got.TXTRecords["a"] != got.TXTRecords["b"] {
+					t.Errorf("GetService() TXT = %v, want a and b from the same update", got.TXTRecords)
+				}
+				got.TXTRecords["a"] = "scribbled" // A copy: must not reach the registry
+				if err := r.handleQuery(query, src, 0); err != nil {
+					t.Errorf("handleQuery() error = %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	got, _ := r.GetService("Printer")
+	final := got.TXTRecords["a"]
+	if got.TXTRecords["b"] != final || !strings.HasSuffix(final, fmt.Sprintf("-%d", updates-1)) {
+		t.Errorf("final TXT = %v, want one writer's last update", got.TXTRecords)
+	}
+}
+
+// TestReload_AnnouncesNewAddress tests that Reload re-resolves the host
+// addresses and re-announces every service with cache-flush A and AAAA
+// records for the new IPv4 and IPv6 addresses.
+func TestReload_AnnouncesNewAddress(t *testing.T) {
+	var mu sync.Mutex
+	var sentPackets [][]byte
+
+	mockTransport := &MockTransport{
+		sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sentPackets = append(sentPackets, packet)
+			return nil
+		},
+	}
+
+	currentIP := []byte{192, 168, 1, 10}
+	currentIPv6 := net.ParseIP("2001:db8::10")
+	r := &Responder{
+		ctx:             context.Background(),
+		transport:       mockTransport,
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "testhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		ipv4Source:      func() ([]byte, error) { return currentIP, nil },
+		ipv6Source:      func(int) (*net.IPAddr, error) { return &net.IPAddr{IP: currentIPv6}, nil },
+	}
+
+	for _, name := range []string{"Web", "Files"} {
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	// Simulate a DHCP renumbering and a new IPv6 prefix
+	currentIP = []byte{10, 0, 0, 42}
+	currentIPv6 = net.ParseIP("2001:db8:1::42")
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(sentPackets) != 2 {
+		t.Fatalf("Reload() sent %d announcements, want 2 (one per service)", len(sentPackets))
+	}
+
+	for i, pkt := range sentPackets {
+		msg, err := message.ParseMessage(pkt)
+		if err != nil {
+			t.Fatalf("announcement %d: ParseMessage() error = %v", i, err)
+		}
+
+		foundA, foundAAAA := false, false
+		for _, rr := range msg.Answers {
+			switch rr.TYPE {
+			case uint16(protocol.RecordTypeA):
+				foundA = true
+				if !bytes.Equal(rr.RDATA, []byte{10, 0, 0, 42}) {
+					t.Errorf("announcement %d: A RDATA = %v, want 10.0.0.42", i, net.IP(rr.RDATA))
+				}
+			case uint16(protocol.RecordTypeAAAA):
+				foundAAAA = true
+				if !net.IP(rr.RDATA).Equal(currentIPv6) {
+					t.Errorf("announcement %d: AAAA RDATA = %v, want %v", i, net.IP(rr.RDATA), currentIPv6)
+				}
+			default:
+				continue
+			}
+			if rr.CLASS&0x8000 == 0 {
+				t.Errorf("announcement %d: %s record missing cache-flush bit (RFC 6762 §10.2)", i, protocol.RecordType(rr.TYPE))
+			}
+		}
+		if !foundA {
+			t.Errorf("announcement %d: no A record", i)
+		}
+		if !foundAAAA {
+			t.Errorf("announcement %d: no AAAA record", i)
+		}
+	}
+}
+
+// TestReload_AddressError tests that Reload reports a failed address lookup.
+func TestReload_AddressError(t *testing.T) {
+	r := &Responder{
+		ctx:        context.Background(),
+		transport:  &MockTransport{},
+		registry:   internalresponder.NewRegistry(),
+		hostname:   "testhost.local",
+		ipv4Source: func() ([]byte, error) { return nil, goerrors.New("no address") },
+	}
+
+	if err := r.Reload(); err == nil {
+		t.Error("Reload() error = nil, want address lookup error")
+	}
+}
+
+// TestAnnounceOnInterface_TargetsNewInterface verifies announcing for a
+// newly-up interface sends only out that interface, advertising its own
+// address rather than another interface's.
+func TestAnnounceOnInterface_TargetsNewInterface(t *testing.T) {
+	mock := transport.NewMockTransport()
+	r := &Responder{
+		ctx:               context.Background(),
+		transport:         mock,
+		registry:          internalresponder.NewRegistry(),
+		hostname:          "testhost.local",
+		responseBuilder:   internalresponder.NewResponseBuilder(),
+		interfaceResolver: StaticInterfaceResolver{1: "10.0.1.10/24", 2: "10.0.2.10/24"},
+	}
+	for _, name := range []string{"Web", "Files"} {
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	if err := r.AnnounceOnInterface(2); err != nil {
+		t.Fatalf("AnnounceOnInterface(2) error = %v", err)
+	}
+
+	calls := mock.SendCalls()
+	if len(calls) != 2 {
+		t.Fatalf("AnnounceOnInterface(2) sent %d packets, want 2 (one per service)", len(calls))
+	}
+	for i, call := range calls {
+		if call.IfIndex != 2 {
+			t.Errorf("announcement %d sent on interface %d, want 2", i, call.IfIndex)
+		}
+		msg, err := message.ParseMessage(call.Packet)
+		if err != nil {
+			t.Fatalf("announcement %d: ParseMessage() error = %v", i, err)
+		}
+		var addrs []net.IP
+		for _, rr := range msg.Answers {
+			if rr.TYPE == uint16(protocol.RecordTypeA) {
+				addrs = append(addrs, net.IP(rr.RDATA))
+			}
+		}
+		if len(addrs) != 1 || !addrs[0].Equal(net.IPv4(10, 0, 2, 10)) {
+			t.Errorf("announcement %d: A records = %v, want only 10.0.2.10 (interface 2)", i, addrs)
+		}
+	}
+
+	for _, ifIndex := range []int{0, 3} {
+		if err := r.AnnounceOnInterface(ifIndex); err == nil {
+			t.Errorf("AnnounceOnInterface(%d) error = nil, want error", ifIndex)
+		}
+	}
+	if n := len(mock.SendCalls()); n != 2 {
+		t.Errorf("failed announcements sent %d packets, want none", n-2)
+	}
+}
+
+// TestAnnounceOnInterface_AllAddresses verifies announcing for an interface
+// advertises every address on it (RFC 6762 §15), AAAA included, and notes
+// the multicast so a query within the second is not answered with the same
+// records again (RFC 6762 §6.2).
+func TestAnnounceOnInterface_AllAddresses(t *testing.T) {
+	mock := transport.NewMockTransport()
+	r := &Responder{
+		ctx:               context.Background(),
+		transport:         mock,
+		registry:          internalresponder.NewRegistry(),
+		hostname:          "testhost.local",
+		responseBuilder:   internalresponder.NewResponseBuilder(),
+		recordSet:         records.NewRecordSet(),
+		interfaceResolver: StaticInterfaceResolver{2: "10.0.2.10/24,10.0.2.11/24,fd00:2::10/64"},
+	}
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	if err := r.AnnounceOnInterface(2); err != nil {
+		t.Fatalf("AnnounceOnInterface(2) error = %v", err)
+	}
+	calls := mock.SendCalls()
+	if len(calls) != 1 {
+		t.Fatalf("AnnounceOnInterface(2) sent %d packets, want 1", len(calls))
+	}
+	msg, err := message.ParseMessage(calls[0].Packet)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+
+	var addrs []string
+	for _, rr := range msg.Answers {
+		if rr.TYPE == uint16(protocol.RecordTypeA) || rr.TYPE == uint16(protocol.RecordTypeAAAA) {
+			addrs = append(addrs, net.IP(rr.RDATA).String())
+		}
+		if r.recordSet.CanMulticastByIndex(&records.ResourceRecord{
+			Name: rr.NAME, Type: protocol.RecordType(rr.TYPE), Class: protocol.ClassIN, Data: rr.RDATA,
+		}, 2) {
+			t.Errorf("%s %s record not rate limited on interface 2 after its announcement", rr.NAME, protocol.RecordType(rr.TYPE))
+		}
+	}
+	slices.Sort(addrs)
+	if want := []string{"10.0.2.10", "10.0.2.11", "fd00:2::10"}; !slices.Equal(addrs, want) {
+		t.Errorf("announced addresses = %v, want %v", addrs, want)
+	}
+}
+
+// TestWithDefaultTXT_MergePrecedence verifies that WithDefaultTXT defaults are
+// merged under a service's explicit TXT records, with service keys winning.
+func TestWithDefaultTXT_MergePrecedence(t *testing.T) {
+	r := &Responder{
+		registry: internalresponder.NewRegistry(),
+		hostname: "testhost.local",
+		ctx:      context.Background(),
+	}
+	opt := WithDefaultTXT(map[string]string{"txtvers": "1", "path": "/"})
+	if err := opt(r); err != nil {
+		t.Fatalf("WithDefaultTXT() error = %v", err)
+	}
+
+	svc := &Service{
+		InstanceName: "Merged",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+		TXTRecords:   map[string]string{"path": "/api", "model": "X1"},
+	}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	got, found := r.GetService("Merged")
+	if !found {
+		t.Fatal("GetService(Merged) not found")
+	}
+	want := map[string]string{"txtvers": "1", "path": "/api", "model": "X1"}
+	if len(got.TXTRecords) != len(want) {
+		t.Fatalf("TXTRecords = %v, want %v", got.TXTRecords, want)
+	}
+	for k, v := range want {
+		if got.TXTRecords[k] != v {
+			t.Errorf("TXTRecords[%q] = %q, want %q", k, got.TXTRecords[k], v)
+		}
+	}
+
+	// The caller's service map must not be mutated by the merge.
+	if _, ok := svc.TXTRecords["txtvers"]; ok {
+		t.Error("service TXTRecords was mutated with default keys")
+	}
+}
+
+// TestWithDefaultTXT_EmptyKeepsMandatoryZeroByte verifies that empty defaults
+// plus an empty service TXT still yield the RFC 6763 §6 single 0x00 TXT record.
+func TestWithDefaultTXT_EmptyKeepsMandatoryZeroByte(t *testing.T) {
+	r := &Responder{}
+	if err := WithDefaultTXT(map[string]string{})(r); err != nil {
+		t.Fatalf("WithDefaultTXT() error = %v", err)
+	}
+
+	txt := r.mergeTXT(nil)
+	if len(txt) != 0 {
+		t.Fatalf("mergeTXT(nil) = %v, want empty", txt)
+	}
+
+	info := buildServiceInfo("Empty", "_http._tcp.local", "testhost.local", 80, []byte{10, 0, 0, 1}, txt, false, nil)
+	for _, rr := range records.BuildRecordSet(info) {
+		if rr.Type == protocol.RecordTypeTXT && !bytes.Equal(rr.Data, []byte{0x00}) {
+			t.Errorf("TXT RDATA = %v, want [0x00]", rr.Data)
+		}
+	}
+}
+
+// TestResolveResponseIPv4_SkipResponsePolicy verifies that the default
+// SkipResponse policy drops the response when the interface lookup fails and
+// logs a warning when LogFailures is set (RFC 6762 §15 strict compliance).
+func TestResolveResponseIPv4_SkipResponsePolicy(t *testing.T) {
+	var logBuf bytes.Buffer
+	r := &Responder{
+		resolutionPolicy: DefaultInterfaceResolutionPolicy(),
+		logger:           slog.New(slog.NewTextHandler(&logBuf, nil)),
+	}
+
+	ipv4, err := r.resolveResponseIPv4(999999) // No such interface
+	if err == nil {
+		t.Fatalf("resolveResponseIPv4(invalid) = %v, nil; want error under SkipResponse", ipv4)
+	}
+	if !bytes.Contains(logBuf.Bytes(), []byte("interface address resolution failed")) {
+		t.Errorf("expected warning log with LogFailures=true, got %q", logBuf.String())
+	}
+}
+
+// TestResolveResponseIPv4_UseGlobalIPPolicy verifies that UseGlobalIP falls
+// back to the host's default IPv4 when the interface lookup fails.
+func TestResolveResponseIPv4_UseGlobalIPPolicy(t *testing.T) {
+	if _, err := getLocalIPv4(systemInterfaceResolver{}, nil); err != nil {
+		t.Skipf("no non-loopback IPv4 on this host: %v", err)
+	}
+
+	var logBuf bytes.Buffer
+	r := &Responder{logger: slog.New(slog.NewTextHandler(&logBuf, nil))}
+	if err := WithInterfaceResolutionPolicy(InterfaceResolutionPolicy{FallbackOnError: UseGlobalIP})(r); err != nil {
+		t.Fatalf("WithInterfaceResolutionPolicy() error = %v", err)
+	}
+
+	ipv4, err := r.resolveResponseIPv4(999999)
+	if err != nil {
+		t.Fatalf("resolveResponseIPv4(invalid) error = %v, want fallback to global IP", err)
+	}
+	if len(ipv4) != 4 {
+		t.Errorf("resolveResponseIPv4(invalid) = %v, want 4-byte IPv4", ipv4)
+	}
+	if logBuf.Len() != 0 {
+		t.Errorf("expected no log with LogFailures=false, got %q", logBuf.String())
+	}
+}
+
+// TestWithReadBufferSize verifies the option is validated and that a
+// responder with a custom receive buffer still binds.
+func TestWithReadBufferSize(t *testing.T) {
+	r, err := New(context.Background(), WithReadBufferSize(128*1024))
+	if err != nil {
+		t.Fatalf("New(WithReadBufferSize(128KB)) error = %v", err)
+	}
+	_ = r.Close()
+
+	if r, err := New(context.Background(), WithReadBufferSize(512)); err == nil {
+		_ = r.Close()
+		t.Error("New(WithReadBufferSize(512)) error = nil, want ValidationError")
+	}
+}
+
+// TestWithMulticastTTL verifies the option is validated and that a responder
+// with a custom multicast TTL still binds.
+func TestWithMulticastTTL(t *testing.T) {
+	r, err := New(context.Background(), WithMulticastTTL(64))
+	if err != nil {
+		t.Fatalf("New(WithMulticastTTL(64)) error = %v", err)
+	}
+	_ = r.Close()
+
+	for _, ttl := range []int{0, 256} {
+		if r, err := New(context.Background(), WithMulticastTTL(ttl)); err == nil {
+			_ = r.Close()
+			t.Errorf("New(WithMulticastTTL(%d)) error = nil, want ValidationError", ttl)
+		}
+	}
+}
+
+// TestStaticInterfaceResolver verifies configured interfaces resolve to their
+// IPv4 address, an unknown index is a NetworkError, and an IPv6-only
+// interface has no IPv4 address.
+func TestStaticInterfaceResolver(t *testing.T) {
+	resolver := StaticInterfaceResolver{1: "10.0.1.10/24", 2: "fe80::1/64"}
+
+	if ipv4, err := getIPv4ForInterface(resolver, 1); err != nil || !net.IP(ipv4).Equal(net.IPv4(10, 0, 1, 10)) {
+		t.Errorf("getIPv4ForInterface(1) = %v, %v; want 10.0.1.10", ipv4, err)
+	}
+
+	var netErr *errors.NetworkError
+	if _, err := getIPv4ForInterface(resolver, 3); !goerrors.As(err, &netErr) {
+		t.Errorf("getIPv4ForInterface(3) error = %v, want NetworkError", err)
+	}
+
+	var valErr *errors.ValidationError
+	if _, err := getIPv4ForInterface(resolver, 2); !goerrors.As(err, &valErr) {
+		t.Errorf("getIPv4ForInterface(2) error = %v, want ValidationError (IPv6-only)", err)
+	}
+
+	if r, err := New(context.Background(), WithInterfaceResolver(nil)); err == nil {
+		_ = r.Close()
+		t.Error("New(WithInterfaceResolver(nil)) error = nil, want ValidationError")
+	}
+}
+
+// TestGetLocalIPv4_InterfaceFilter verifies the advertised address comes from
+// the first interface the resolver lists that WithInterfaceFilter accepts,
+// skipping loopback addresses.
+func TestGetLocalIPv4_InterfaceFilter(t *testing.T) {
+	resolver := StaticInterfaceResolver{1: "127.0.0.1/8", 2: "10.0.1.10/24", 3: "10.0.2.10/24"}
+
+	if ipv4, err := getLocalIPv4(resolver, nil); err != nil || !net.IP(ipv4).Equal(net.IPv4(10, 0, 1, 10)) {
+		t.Errorf("getLocalIPv4(no filter) = %v, %v; want 10.0.1.10", ipv4, err)
+	}
+
+	skip2 := func(iface net.Interface) bool { return iface.Name != "if2" }
+	if ipv4, err := getLocalIPv4(resolver, skip2); err != nil || !net.IP(ipv4).Equal(net.IPv4(10, 0, 2, 10)) {
+		t.Errorf("getLocalIPv4(filter out if2) = %v, %v; want 10.0.2.10", ipv4, err)
+	}
+
+	loopbackOnly := func(iface net.Interface) bool { return iface.Flags&net.FlagLoopback != 0 }
+	if ipv4, err := getLocalIPv4(resolver, loopbackOnly); err == nil {
+		t.Errorf("getLocalIPv4(loopback only) = %v, nil; want error", ipv4)
+	}
+}
+
+// TestReregister_CancelsPendingGoodbye verifies that re-registering a name
+// immediately after Unregister cancels the pending goodbye retransmission, so
+// no stale TTL=0 packet follows the fresh announcement and flushes peers'
+// caches.
+func TestReregister_CancelsPendingGoodbye(t *testing.T) {
+	type sentPacket struct {
+		at       time.Time
+		goodbye  bool
+		announce bool
+	}
+	var mu sync.Mutex
+	var sent []sentPacket
+
+	mockTransport := &MockTransport{
+		sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			msg, err := message.ParseMessage(packet)
+			if err != nil || !msg.Header.IsResponse() || len(msg.Answers) == 0 {
+				return nil // Probes are queries; ignore
+			}
+			goodbye := true
+			for _, rr := range msg.Answers {
+				if rr.TTL != 0 {
+					goodbye = false
+				}
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, sentPacket{at: time.Now(), goodbye: goodbye, announce: !goodbye})
+			return nil
+		},
+	}
+
+	r, err := New(context.Background(), WithTransport(mockTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	svc := &Service{InstanceName: "Flappy", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	if err := r.Unregister("Flappy"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+
+	// Immediately re-register (full probe + announce, ~1.75s) — longer than
+	// the goodbye retransmit delay, so an uncancelled goodbye would fire
+	// in the middle of it.
+	if err := r.Register(&Service{InstanceName: "Flappy", ServiceType: "_http._tcp.local", Port: 8080}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// Allow any stray retransmission to fire
+	time.Sleep(defaultGoodbyeInterval + 200*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	var lastAnnounce time.Time
+	goodbyes := 0
+	for _, p := range sent {
+		if p.announce {
+			lastAnnounce = p.at
+		} else {
+			goodbyes++
+		}
+	}
+	if lastAnnounce.IsZero() {
+		t.Fatal("re-registration sent no announcement")
+	}
+	if goodbyes != 1 {
+		t.Errorf("sent %d goodbye packets, want exactly 1 (retransmission must be cancelled)", goodbyes)
+	}
+	for _, p := range sent {
+		if p.goodbye && p.at.After(lastAnnounce) {
+			t.Errorf("stale goodbye sent at %v after announcement at %v", p.at, lastAnnounce)
+		}
+	}
+}
+
+// TestRegisterContext_CancelMidProbe verifies that cancelling the per-call
+// context during probing returns promptly with a context error and leaves the
+// service unregistered.
+func TestRegisterContext_CancelMidProbe(t *testing.T) {
+	r, err := New(context.Background(), WithTransport(&MockTransport{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel) // Probing takes ~750ms
+
+	start := time.Now()
+	err = r.RegisterContext(ctx, &Service{InstanceName: "Slow", ServiceType: "_http._tcp.local", Port: 8080})
+	elapsed := time.Since(start)
+
+	if !goerrors.Is(err, context.Canceled) {
+		t.Fatalf("RegisterContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed > 500*time.Millisecond {
+		t.Errorf("RegisterContext() returned after %v, want prompt return after cancel", elapsed)
+	}
+	if _, found := r.GetService("Slow"); found {
+		t.Error("cancelled registration left service in registry")
+	}
+}
+
+// TestRegisterContext_ResponderCloseCancels verifies the per-call context is
+// derived from the responder's context, so shutdown still cancels it.
+func TestRegisterContext_ResponderCloseCancels(t *testing.T) {
+	rctx, rcancel := context.WithCancel(context.Background())
+	r, err := New(rctx, WithTransport(&MockTransport{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	time.AfterFunc(100*time.Millisecond, rcancel)
+
+	err = r.RegisterContext(context.Background(), &Service{InstanceName: "Slow", ServiceType: "_http._tcp.local", Port: 8080})
+	if !goerrors.Is(err, context.Canceled) {
+		t.Fatalf("RegisterContext() error = %v, want context.Canceled", err)
+	}
+}
+
+// TestUnregisterAll_SendsGoodbyesAndEmptiesRegistry verifies UnregisterAll
+// attempts a goodbye for every service, empties the registry even when a send
+// fails, reports the failure, and leaves the responder usable.
+func TestUnregisterAll_SendsGoodbyesAndEmptiesRegistry(t *testing.T) {
+	var mu sync.Mutex
+	goodbyes := make(map[string]int)
+
+	mockTransport := &MockTransport{
+		sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			msg, err := message.ParseMessage(packet)
+			if err != nil || !msg.Header.IsResponse() || len(msg.Answers) == 0 || msg.Answers[0].TTL != 0 {
+				return nil // Only goodbyes are of interest
+			}
+			for _, rr := range msg.Answers {
+				if rr.TYPE != uint16(protocol.RecordTypeSRV) {
+					continue
+				}
+				mu.Lock()
+				goodbyes[rr.NAME]++
+				mu.Unlock()
+				if strings.HasPrefix(rr.NAME, "Broken") {
+					return goerrors.New("network unreachable")
+				}
+			}
+			return nil
+		},
+	}
+
+	r, err := New(context.Background(), WithTransport(mockTransport))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	for _, name := range []string{"Alpha", "Beta", "Broken"} {
+		svc := &Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 8080}
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	err = r.UnregisterAll()
+	if err == nil || !strings.Contains(err.Error(), "Broken") {
+		t.Errorf("UnregisterAll() error = %v, want failure naming \"Broken\"", err)
+	}
+
+	if names := r.registry.List(); len(names) != 0 {
+		t.Errorf("registry after UnregisterAll() = %v, want empty", names)
+	}
+
+	mu.Lock()
+	for _, name := range []string{"Alpha", "Beta", "Broken"} {
+		if goodbyes[name+"._http._tcp.local"] == 0 {
+			t.Errorf("no goodbye sent for %q", name)
+		}
+	}
+	mu.Unlock()
+
+	// Responder stays usable: the same names can be registered again
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Alpha", ServiceType: "_http._tcp.local", Port: 8080}); err != nil {
+		t.Errorf("RegisterServiceWithoutProbing() after UnregisterAll() error = %v", err)
+	}
+}
+
+// goodbyeRecorder returns a MockTransport recording, as read from clk, the
+// send time of every goodbye (all-TTL=0 response) packet.
+func goodbyeRecorder(mu *sync.Mutex, times *[]time.Time, clk clock.Clock) *MockTransport {
+	return &MockTransport{
+		sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			msg, err := message.ParseMessage(packet)
+			if err != nil || !msg.Header.IsResponse() || len(msg.Answers) == 0 {
+				return nil
+			}
+			for _, rr := range msg.Answers {
+				if rr.TTL != 0 {
+					return nil // Announcement
+				}
+			}
+			mu.Lock()
+			*times = append(*times, clk.Now())
+			mu.Unlock()
+			return nil
+		},
+	}
+}
+
+// waitForGoodbyes waits until times holds n goodbyes.
+func waitForGoodbyes(t *testing.T, mu *sync.Mutex, times *[]time.Time, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := len(*times)
+		mu.Unlock()
+		if got >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("sent %d goodbyes, want %d", got, n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// TestWithGoodbyeCount_UnregisterSendsConfiguredCopies verifies Unregister
+// sends exactly WithGoodbyeCount goodbyes spaced by WithGoodbyeInterval.
+func TestWithGoodbyeCount_UnregisterSendsConfiguredCopies(t *testing.T) {
+	const interval = 100 * time.Millisecond
+	var mu sync.Mutex
+	var times []time.Time
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(), WithTransport(goodbyeRecorder(&mu, &times, fake)), WithClock(fake),
+		WithGoodbyeCount(3), WithGoodbyeInterval(interval))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	if err := r.Unregister("Web"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+
+	waitForGoodbyes(t, &mu, &times, 1)
+	for n := 2; n <= 3; n++ {
+		fake.WaitForWaiters(1)
+		fake.Advance(interval)
+		waitForGoodbyes(t, &mu, &times, n)
+	}
+
+	// The last copy schedules no further one
+	fake.Advance(10 * interval)
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 3 {
+		t.Fatalf("sent %d goodbyes, want 3", len(times))
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap != interval {
+			t.Errorf("gap between goodbye %d and %d = %v, want %v", i, i+1, gap, interval)
+		}
+	}
+}
+
+// TestWithGoodbyeCount_CloseSendsAllCopies verifies Close sends every goodbye
+// copy before returning, rather than dropping retransmissions.
+func TestWithGoodbyeCount_CloseSendsAllCopies(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	var mu sync.Mutex
+	var times []time.Time
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(), WithTransport(goodbyeRecorder(&mu, &times, fake)), WithClock(fake),
+		WithGoodbyeCount(3), WithGoodbyeInterval(interval))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	for _, name := range []string{"Web", "Printer"} {
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+	_ = runOnFakeClock(t, fake, r.Close)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 6 {
+		t.Errorf("Close() sent %d goodbyes, want 6 (3 copies × 2 services)", len(times))
+	}
+}
+
+// TestClose_FlushesPendingGoodbyes verifies Close sends the goodbye copies
+// still due from an earlier Unregister instead of dropping them.
+func TestClose_FlushesPendingGoodbyes(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(), WithTransport(goodbyeRecorder(&mu, &times, fake)), WithClock(fake),
+		WithGoodbyeCount(3), WithGoodbyeInterval(50*time.Millisecond))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	for _, name := range []string{"Web", "Printer"} {
+		if err := r.RegisterServiceWithoutProbing(&Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 80}); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+	if err := r.Unregister("Web"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	waitForGoodbyes(t, &mu, &times, 1) // Two copies of Web's goodbye now pending
+
+	_ = runOnFakeClock(t, fake, r.Close)
+
+	// Nothing left to fire after Close
+	fake.Advance(time.Second)
+	time.Sleep(10 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 6 {
+		t.Errorf("sent %d goodbyes, want 6 (3 copies × 2 services)", len(times))
+	}
+}
+
+// TestWithGoodbyeOptions_Validation verifies count < 1 and non-positive
+// intervals are rejected.
+func TestWithGoodbyeOptions_Validation(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"count 0":     WithGoodbyeCount(0),
+		"interval 0":  WithGoodbyeInterval(0),
+		"interval -1": WithGoodbyeInterval(-time.Second),
+	} {
+		t.Run(name, func(t *testing.T) {
+			r, err := New(context.Background(), WithTransport(&MockTransport{}), opt)
+			if err == nil {
+				_ = r.Close()
+				t.Fatal("New() error = nil, want ValidationError")
+			}
+			var valErr *errors.ValidationError
+			if !goerrors.As(err, &valErr) {
+				t.Errorf("New() error = %v, want ValidationError", err)
+			}
+		})
+	}
+}
+
+// TestWithMaxResponseSize_Validation verifies sizes outside 512-9000 bytes
+// are rejected.
+func TestWithMaxResponseSize_Validation(t *testing.T) {
+	for _, size := range []int{0, 511, 9001} {
+		r, err := New(context.Background(), WithTransport(&MockTransport{}), WithMaxResponseSize(size))
+		if err == nil {
+			_ = r.Close()
+			t.Errorf("New(WithMaxResponseSize(%d)) error = nil, want ValidationError", size)
+			continue
+		}
+		var valErr *errors.ValidationError
+		if !goerrors.As(err, &valErr) {
+			t.Errorf("New(WithMaxResponseSize(%d)) error = %v, want ValidationError", size, err)
+		}
+	}
+}
+
+// TestGetLastAnnouncedRecords_ConcurrentWithRegister reads and scribbles on
+// GetLastAnnouncedRecords while a registration probes and announces; run under
+// -race. Each call returns a copy, so neither the announcer nor later callers
+// see the changes.
+func TestGetLastAnnouncedRecords_ConcurrentWithRegister(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(), WithTransport(&MockTransport{}), WithHostname("testhost.local"),
+		WithClock(fake), WithGoodbyeCount(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				for _, rr := range r.GetLastAnnouncedRecords() {
+					rr.TTL = 0
+					for i := range rr.Data {
+						rr.Data[i] = 0xff
+					}
+				}
+			}
+		}()
+	}
+
+	service := &Service{InstanceName: "Printer", ServiceType: "_ipp._tcp.local", Port: 631}
+	err = runOnFakeClock(t, fake, func() error { return r.Register(service) })
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	var aRecords int
+	for _, rr := range r.GetLastAnnouncedRecords() {
+		if rr.TTL == 0 {
+			t.Errorf("%s record TTL = 0, want the built TTL", rr.Type)
+		}
+		if rr.Type == protocol.RecordTypeA {
+			aRecords++
+			if !bytes.Equal(rr.Data, []byte{192, 168, 1, 10}) {
+				t.Errorf("A record data = %v, want 192.168.1.10", rr.Data)
+			}
+		}
+	}
+	if aRecords != 1 {
+		t.Errorf("GetLastAnnouncedRecords() has %d A records, want 1", aRecords)
+	}
+}
+
+// TestRegister_PTROnlyService verifies a PTR-only service is announced
+// without probing, carries only its PTR record, and is answered without
+// fabricated SRV/TXT/A additionals.
+func TestRegister_PTROnlyService(t *testing.T) {
+	var mu sync.Mutex
+	var sent []*message.DNSMessage
+	mock := &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+		msg, err := message.ParseMessage(packet)
+		if err != nil {
+			return nil
+		}
+		mu.Lock()
+		sent = append(sent, msg)
+		mu.Unlock()
+		return nil
+	}}
+	r, err := New(context.Background(), WithTransport(mock), WithHostname("testhost.local"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	// No port: there is no SRV record to carry one
+	if err := r.Register(&Service{InstanceName: "Proxied", ServiceType: "_ipp._tcp.local", PTROnly: true}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	built := r.GetLastAnnouncedRecords()
+	if len(built) != 1 || built[0].Type != protocol.RecordTypePTR {
+		t.Fatalf("built records = %v, want only a PTR", built)
+	}
+
+	mu.Lock()
+	announcements := sent
+	sent = nil
+	mu.Unlock()
+	if len(announcements) == 0 {
+		t.Fatal("no announcement sent")
+	}
+	for _, msg := range announcements {
+		if !msg.Header.IsResponse() {
+			t.Errorf("sent a query (probe); PTR-only services must not probe")
+			continue
+		}
+		if len(msg.Answers) != 1 || msg.Answers[0].TYPE != uint16(protocol.RecordTypePTR) || len(msg.Additionals) != 0 {
+			t.Errorf("announcement has %d answers, %d additionals; want a lone PTR", len(msg.Answers), len(msg.Additionals))
+		}
+	}
+
+	if err := r.handleQuery(buildDNSQuery("_ipp._tcp.local", uint16(protocol.RecordTypePTR)), nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d responses to PTR query, want 1", len(sent))
+	}
+	if resp := sent[0]; len(resp.Answers) != 1 || len(resp.Additionals) != 0 {
+		t.Errorf("response has %d answers, %d additionals; want 1 PTR and no additionals", len(resp.Answers), len(resp.Additionals))
+	}
+}
+
+// TestWithClock_RegisterOnFakeClock verifies Register's probe and announce
+// intervals run on the clock from WithClock: advancing a fake clock completes
+// registration without the ~1.75s real-time sequence (RFC 6762 §8).
+func TestWithClock_RegisterOnFakeClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithInitialProbeDelay(0)) // Fixed steps below; no random pre-probe wait
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Register(&Service{InstanceName: "Fake Clock", ServiceType: "_http._tcp.local", Port: 8080})
+	}()
+
+	// Two 250ms probe waits, then one 1s wait between announcements
+	for _, step := range []time.Duration{protocol.ProbeInterval, protocol.ProbeInterval, time.Second} {
+		fake.WaitForWaiters(1)
+		fake.Advance(step)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Register() did not complete after advancing the fake clock")
+	}
+	if _, ok := r.GetService("Fake Clock"); !ok {
+		t.Error("service not registered")
+	}
+}
+
+// TestWithInitialProbeDelay_Validation verifies the initial probe delay is
+// bounded by RFC 6762 §8.1's 250ms, and that 0 (disabled) is accepted.
+func TestWithInitialProbeDelay_Validation(t *testing.T) {
+	tests := []struct {
+		delay   time.Duration
+		wantErr bool
+	}{
+		{0, false},
+		{100 * time.Millisecond, false},
+		{protocol.ProbeInitialDelayMax, false},
+		{-time.Millisecond, true},
+		{protocol.ProbeInitialDelayMax + time.Millisecond, true},
+	}
+	for _, tt := range tests {
+		r, err := New(context.Background(), WithTransport(&MockTransport{}), WithInitialProbeDelay(tt.delay))
+		if err == nil {
+			if r.initialProbeDelay != tt.delay {
+				t.Errorf("WithInitialProbeDelay(%v) set %v", tt.delay, r.initialProbeDelay)
+			}
+			_ = r.Close()
+		}
+		if (err != nil) != tt.wantErr {
+			t.Errorf("WithInitialProbeDelay(%v) error = %v, wantErr %v", tt.delay, err, tt.wantErr)
+		}
+	}
+}
+
+// TestWithRandSource_DeterministicProbeDelay verifies the RFC 6762 §8.1
+// pre-probe delay is drawn from the WithRandSource source: a fixed seed gives
+// the same delay as a fresh source with that seed, to the nanosecond.
+func TestWithRandSource_DeterministicProbeDelay(t *testing.T) {
+	want := time.Duration(rand.New(rand.NewPCG(1, 2)).Int64N(int64(protocol.ProbeInitialDelayMax)))
+
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	start := fake.Now()
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1),
+		WithRandSource(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer func() { _ = r.Close() }()
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	probes := make(chan time.Time, 3)
+	r.OnProbe(func() { probes <- fake.Now() })
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Register(&Service{InstanceName: "Seeded", ServiceType: "_http._tcp.local", Port: 8080})
+	}()
+
+	// Short of the drawn delay by 1ns, then onto it
+	fake.WaitForWaiters(1)
+	fake.Advance(want - 1)
+	fake.Advance(1)
+	select {
+	case at := <-probes:
+		if got := at.Sub(start); got != want {
+			t.Errorf("first probe sent after %v, want %v", got, want)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no probe sent once the drawn delay elapsed")
+	}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			return
+		case <-time.After(time.Millisecond):
+			fake.Advance(time.Second)
+		}
+	}
+}
+
+// TestWithRandSource_Nil verifies a nil random source is rejected.
+func TestWithRandSource_Nil(t *testing.T) {
+	_, err := New(context.Background(), WithTransport(&MockTransport{}), WithRandSource(nil))
+	var valErr *errors.ValidationError
+	if !goerrors.As(err, &valErr) {
+		t.Errorf("New(WithRandSource(nil)) error = %v, want ValidationError", err)
+	}
+}
+
+// TestWithCacheFlushOnAnnounce verifies the option sets the cache-flush bit
+// on the unique records of Register's and UpdateService's announcements,
+// while announcements following a conflict rename and goodbyes always set it
+// (RFC 6762 §9, §10.2). The shared PTR record never does.
+func TestWithCacheFlushOnAnnounce(t *testing.T) {
+	tests := []struct {
+		name           string
+		enabled        bool
+		conflicts      int
+		wantRegister   bool // Cache-flush on Register's announcements
+		wantReannounce bool // Cache-flush on UpdateService's announcement
+	}{
+		{"default", true, 0, true, true},
+		{"disabled", false, 0, false, false},
+		{"disabled after rename", false, 1, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var sent []*message.DNSMessage
+			// takeSent returns the responses sent since the last call
+			takeSent := func() []*message.DNSMessage {
+				mu.Lock()
+				defer mu.Unlock()
+				msgs := sent
+				sent = nil
+				return msgs
+			}
+			fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+			r, err := New(context.Background(),
+				WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+					if msg, err := message.ParseMessage(packet); err == nil && msg.Header.IsResponse() {
+						mu.Lock()
+						sent = append(sent, msg)
+						mu.Unlock()
+					}
+					return nil
+				}}),
+				WithHostname("testhost.local"),
+				WithClock(fake),
+				WithGoodbyeCount(1),
+				WithCacheFlushOnAnnounce(tt.enabled))
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer func() { _ = r.Close() }()
+			r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+			r.InjectConflictForAttempts(tt.conflicts)
+
+			// checkFlush checks the cache-flush bit of the answers in msgs:
+			// want on unique records, clear on the shared PTR
+			checkFlush := func(what string, msgs []*message.DNSMessage, want bool) {
+				t.Helper()
+				if len(msgs) == 0 {
+					t.Fatalf("no %s sent", what)
+				}
+				for _, msg := range msgs {
+					for _, rr := range msg.Answers {
+						rtype, flush := protocol.RecordType(rr.TYPE), rr.CLASS&0x8000 != 0
+						if rtype == protocol.RecordTypePTR {
+							if flush {
+								t.Errorf("%s PTR cache-flush = true, want false (shared record)", what)
+							}
+						} else if flush != want {
+							t.Errorf("%s %s cache-flush = %v, want %v", what, rtype, flush, want)
+						}
+					}
+				}
+			}
+
+			service := &Service{InstanceName: "Printer", ServiceType: "_ipp._tcp.local", Port: 631}
+			if err := runOnFakeClock(t, fake, func() error { return r.Register(service) }); err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+			checkFlush("announcement", takeSent(), tt.wantRegister)
+
+			if err := r.UpdateService(service.InstanceName, map[string]string{"status": "idle"}); err != nil {
+				t.Fatalf("UpdateService() error = %v", err)
+			}
+			checkFlush("update announcement", takeSent(), tt.wantReannounce)
+
+			if err := r.Unregister(service.InstanceName); err != nil {
+				t.Fatalf("Unregister() error = %v", err)
+			}
+			checkFlush("goodbye", takeSent(), true)
+		})
+	}
+}
+
+// TestWithClock_Nil verifies a nil clock is rejected.
+func TestWithClock_Nil(t *testing.T) {
+	r, err := New(context.Background(), WithTransport(&MockTransport{}), WithClock(nil))
+	if err == nil {
+		_ = r.Close()
+		t.Fatal("New(WithClock(nil)) error = nil, want ValidationError")
+	}
+	var valErr *errors.ValidationError
+	if !goerrors.As(err, &valErr) {
+		t.Errorf("New(WithClock(nil)) error = %v, want ValidationError", err)
+	}
+}
@@ -0,0 +1,160 @@
+package responder
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// ip6ArpaSuffix is the domain of IPv6 reverse-mapping names (RFC 3596 §2.5).
+const ip6ArpaSuffix = ".ip6.arpa"
+
+// reverseIPv6Name returns the ip6.arpa reverse-mapping name of an IPv6
+// address.
+//
+// RFC 3596 §2.5: "An IPv6 address is represented as a name in the IP6.ARPA
+// domain by a sequence of nibbles separated by dots with the suffix
+// '.IP6.ARPA'. The sequence of nibbles is encoded in reverse order, i.e., the
+// low-order nibble is encoded first." Each nibble is a lowercase hex digit,
+// giving 32 single-character labels:
+//
+//	2001:db8::1 → "1.0.0.0.…0.8.b.d.0.1.0.0.2.ip6.arpa"
+//
+// Returns "" if ip is not an IPv6 address.
+func reverseIPv6Name(ip net.IP) string {
+	ip16 := ip.To16()
+	if ip16 == nil || ip.To4() != nil {
+		return ""
+	}
+
+	const hexDigits = "0123456789abcdef"
+	var b strings.Builder
+	b.Grow(net.IPv6len*4 + len(ip6ArpaSuffix))
+	for i := net.IPv6len - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip16[i]&0x0F])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip16[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString(ip6ArpaSuffix[1:])
+	return b.String()
+}
+
+// isIP6ArpaName reports whether name is under the ip6.arpa reverse-mapping
+// domain (case-insensitive, RFC 1035 §2.3.3).
+func isIP6ArpaName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ip6ArpaSuffix)
+}
+
+// addReverseIPv6Answer answers a reverse-mapping PTR question for an IPv6
+// address advertised on the receiving interface with the responder hostname.
+//
+// RFC 6762 §4: a Multicast DNS responder answers reverse-mapping queries for
+// its own addresses in "ip6.arpa.". As with forward address records, only the
+// addresses valid on the receiving interface are answered (RFC 6762 §15):
+// the primary one and any further prefixes (extraResponseAddresses). The
+// record is unique to this host, so the cache-flush bit is set (RFC 6762
+// §10.2), and it carries a host name in its RDATA, so it uses the 120-second
+// TTL (RFC 6762 §10).
+//
+// Parameters:
+//   - response: Response being built; the PTR is appended to its answers
+//   - question: Question to answer (PTR or ANY for a name under ip6.arpa)
+//   - interfaceIndex: Interface the query arrived on (0 = unknown)
+//   - knownAnswers: The query's known-answer list (RFC 6762 §7.1)
+func (r *Responder) addReverseIPv6Answer(response *message.DNSMessage, question message.Question, interfaceIndex int, knownAnswers []*message.ResourceRecord) {
+	if question.QTYPE != uint16(protocol.RecordTypePTR) && question.QTYPE != uint16(protocol.RecordTypeANY) {
+		return
+	}
+	if !isIP6ArpaName(question.QNAME) {
+		return
+	}
+
+	name := r.ownReverseIPv6Name(question.QNAME, interfaceIndex)
+	if name == "" {
+		return // Not an address of ours on this interface
+	}
+
+	target, err := message.EncodeName(r.Hostname())
+	if err != nil {
+		return
+	}
+
+	record := &message.ResourceRecord{
+		Name:       name,
+		Type:       protocol.RecordTypePTR,
+		Class:      protocol.ClassIN,
+		TTL:        protocol.TTLService,
+		Data:       target,
+		CacheFlush: true,
+	}
+	if !r.responseBuilder.ApplyKnownAnswerSuppression(record, knownAnswers) {
+		return // Querier already has it (RFC 6762 §7.1)
+	}
+
+	response.Answers = append(response.Answers, message.Answer{
+		NAME:     name,
+		TYPE:     uint16(protocol.RecordTypePTR),
+		CLASS:    uint16(protocol.ClassIN) | 0x8000,
+		TTL:      protocol.TTLService,
+		RDLENGTH: uint16(len(target)), //nolint:gosec // G115: encoded name is at most 255 bytes
+		RDATA:    target,
+	})
+}
+
+// interfaceIPv6 returns the IPv6 address advertised on the given interface.
+//
+// Returns a ValidationError when the interface is unknown (0): unlike IPv4,
+// there is no host-wide fallback, since a link-local address is only
+// meaningful on its own link. With WithInterfaceResolver the address comes
+// from the resolver, a link-local one zoned by interface index.
+func (r *Responder) interfaceIPv6(interfaceIndex int) (*net.IPAddr, error) {
+	if r.ipv6Source != nil {
+		return r.ipv6Source(interfaceIndex)
+	}
+	if interfaceIndex == 0 {
+		return nil, &errors.ValidationError{
+			Field:   "interfaceIndex",
+			Value:   interfaceIndex,
+			Message: "receiving interface unknown; cannot select an IPv6 address",
+		}
+	}
+	if r.interfaceResolver == nil {
+		return getIPv6ForInterface(interfaceIndex)
+	}
+
+	addrs, err := r.interfaceResolver.InterfaceAddrs(interfaceIndex)
+	if err != nil {
+		return nil, err
+	}
+	if addr := selectIPv6(addrs, strconv.Itoa(interfaceIndex)); addr != nil {
+		return addr, nil
+	}
+	return nil, &errors.ValidationError{
+		Field:   "interface",
+		Value:   interfaceIndex,
+		Message: "no IPv6 address found on interface",
+	}
+}
+
+// ownReverseIPv6Name returns the ip6.arpa name of the IPv6 address advertised
+// on interface interfaceIndex that qname names (case-insensitively), or ""
+// if qname names none of them.
+func (r *Responder) ownReverseIPv6Name(qname string, interfaceIndex int) string {
+	addr, err := r.interfaceIPv6(interfaceIndex)
+	if err != nil {
+		return ""
+	}
+	primary := ipv6RData(addr)
+	_, extra := r.extraResponseAddresses(interfaceIndex, nil, primary)
+	for _, ip := range append([][]byte{primary}, extra...) {
+		if name := reverseIPv6Name(ip); strings.EqualFold(name, qname) {
+			return name
+		}
+	}
+	return ""
+}
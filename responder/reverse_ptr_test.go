@@ -0,0 +1,148 @@
+package responder
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/records"
+	internalresponder "github.com/joshuafuller/beacon/internal/responder"
+)
+
+// TestReverseIPv6Name verifies the nibble-reversed ip6.arpa encoding of
+// RFC 3596 §2.5 and that the 34-label name survives EncodeName/ParseName.
+func TestReverseIPv6Name(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want string
+	}{
+		{"2001:db8::567:89ab", "b.a.9.8.7.6.5.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa"},
+		{"fe80::1", "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.e.f.ip6.arpa"},
+		{"192.168.1.10", ""}, // IPv4 has no ip6.arpa name
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got := reverseIPv6Name(net.ParseIP(tt.ip))
+			if got != tt.want {
+				t.Fatalf("reverseIPv6Name(%s) = %q, want %q", tt.ip, got, tt.want)
+			}
+			if got == "" {
+				return
+			}
+			if labels := strings.Split(got, "."); len(labels) != 34 {
+				t.Errorf("name has %d labels, want 34 (32 nibbles + ip6 + arpa)", len(labels))
+			}
+
+			encoded, err := message.EncodeName(got)
+			if err != nil {
+				t.Fatalf("EncodeName(%q) error = %v", got, err)
+			}
+			decoded, _, err := message.ParseName(encoded, 0)
+			if err != nil {
+				t.Fatalf("ParseName() error = %v", err)
+			}
+			if decoded != got {
+				t.Errorf("round trip = %q, want %q", decoded, got)
+			}
+		})
+	}
+}
+
+// TestHandleQuery_ReverseIPv6PTR verifies a PTR query for the ip6.arpa name
+// of the receiving interface's IPv6 address is answered with the hostname,
+// and queries for other addresses are not (RFC 6762 §4, §15).
+func TestHandleQuery_ReverseIPv6PTR(t *testing.T) {
+	var sent [][]byte
+	ours := net.ParseIP("2001:db8::567:89ab")
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:        internalresponder.NewRegistry(),
+		hostname:        "myhost.local",
+		responseBuilder: internalresponder.NewResponseBuilder(),
+		recordSet:       records.NewRecordSet(),
+		ipv6Source: func(int) (*net.IPAddr, error) {
+			return &net.IPAddr{IP: ours}, nil
+		},
+	}
+
+	// Names are case-insensitive (RFC 1035 §2.3.3)
+	query := buildDNSQuery(strings.ToUpper(reverseIPv6Name(ours)), uint16(protocol.RecordTypePTR))
+	if err := r.handleQuery(query, nil, 0); err != nil {
+		t.Fatalf("handleQuery() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d packets, want 1 reverse PTR response", len(sent))
+	}
+
+	resp, err := message.ParseMessage(sent[0])
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	if len(resp.Answers) != 1 {
+		t.Fatalf("response has %d answers, want 1", len(resp.Answers))
+	}
+	ptr := resp.Answers[0]
+	if ptr.TYPE != uint16(protocol.RecordTypePTR) || ptr.CLASS != uint16(protocol.ClassIN)|0x8000 || ptr.TTL != protocol.TTLService {
+		t.Errorf("answer type/class/TTL = %d/0x%04x/%d, want PTR/IN+cache-flush/%d", ptr.TYPE, ptr.CLASS, ptr.TTL, protocol.TTLService)
+	}
+	target, err := message.ParseRDATAInMessage(ptr.TYPE, sent[0], ptr.RDATAOffset, int(ptr.RDLENGTH))
+	if err != nil || target != "myhost.local" {
+		t.Errorf("PTR target = %v (err %v), want myhost.local", target, err)
+	}
+
+	// Another host's address: not ours to answer
+	other := buildDNSQuery(reverseIPv6Name(net.ParseIP("2001:db8::1")), uint16(protocol.RecordTypePTR))
+	if err := r.handleQuery(other, nil, 0); err != nil {
+		t.Fatalf("handleQuery(other) error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %d packets after query for another address, want no new response", len(sent))
+	}
+}
+
+// TestHandleQuery_ReverseIPv6PTR_ExtraPrefix verifies a PTR query for the
+// ip6.arpa name of any IPv6 address advertised on the receiving interface is
+// answered, not only the primary one's (RFC 6762 §4, §15).
+func TestHandleQuery_ReverseIPv6PTR_ExtraPrefix(t *testing.T) {
+	var sent [][]byte
+	r := &Responder{
+		ctx: context.Background(),
+		transport: &MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			sent = append(sent, packet)
+			return nil
+		}},
+		registry:          internalresponder.NewRegistry(),
+		hostname:          "myhost.local",
+		responseBuilder:   internalresponder.NewResponseBuilder(),
+		recordSet:         records.NewRecordSet(),
+		interfaceResolver: StaticInterfaceResolver{1: "10.0.1.10/24,2001:db8::10/64,2001:db8:1::10/64"},
+	}
+
+	// A querier on the interface's subnet (RFC 6762 §11)
+	src := &net.UDPAddr{IP: net.IPv4(10, 0, 1, 20), Port: 5353}
+	for _, ip := range []string{"2001:db8::10", "2001:db8:1::10"} {
+		sent = nil
+		name := reverseIPv6Name(net.ParseIP(ip))
+		if err := r.handleQuery(buildDNSQuery(name, uint16(protocol.RecordTypePTR)), src, 1); err != nil {
+			t.Fatalf("handleQuery(%s) error = %v", ip, err)
+		}
+		if len(sent) != 1 {
+			t.Fatalf("sent %d packets for %s, want 1 reverse PTR response", len(sent), ip)
+		}
+		resp, err := message.ParseMessage(sent[0])
+		if err != nil {
+			t.Fatalf("ParseMessage(response) error = %v", err)
+		}
+		if len(resp.Answers) != 1 || resp.Answers[0].NAME != name || resp.Answers[0].TYPE != uint16(protocol.RecordTypePTR) {
+			t.Errorf("answers for %s = %+v, want one PTR for %s", ip, resp.Answers, name)
+		}
+	}
+}
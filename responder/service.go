@@ -2,9 +2,17 @@ package responder
 
 import (
 	"fmt"
+	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/joshuafuller/beacon/internal/errors"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/records"
+	"github.com/joshuafuller/beacon/internal/responder"
 )
 
 // Service represents an mDNS service to be registered per RFC 6763.
@@ -31,15 +39,52 @@ type Service struct {
 	Port uint16
 
 	// TXTRecords contains optional service metadata as key-value pairs.
-	// RFC 6763 §6.2: Total size SHOULD NOT exceed 1300 bytes.
+	// RFC 6763 §6.2: Total size SHOULD NOT exceed 1300 bytes; larger sets are
+	// rejected rather than split across several TXT records (RFC 6763 §6.1
+	// asks for a single record), as is any "key=value" string over 255 bytes.
 	// RFC 6763 §6: If empty, a single TXT record with 0x00 byte MUST be created.
+	// RFC 6763 §6.4: An empty value encodes as "key="; use TXTBoolean as the
+	// value to advertise a boolean attribute encoded as a bare "key".
 	TXTRecords map[string]string
 
-	// Hostname is the hostname for the A/AAAA record (optional).
-	// If not provided, system hostname will be used.
+	// Hostname overrides the SRV target and A record name for this service
+	// only (optional), e.g. an alias for a service fronted by another host.
+	// If not provided, the responder hostname (WithHostname or system
+	// hostname) will be used.
 	Hostname string
+
+	// PTROnly advertises only that the service type exists: just the PTR
+	// record (ServiceType → InstanceName) is announced and answered, with no
+	// SRV, TXT or A record, e.g. for a proxy or forwarder that does not own
+	// the instance. Port, TXTRecords and Hostname are ignored, and no probing
+	// is done since PTR records are shared (RFC 6762 §8.1).
+	PTROnly bool
+
+	// Subtypes lists the service's subtypes (optional), e.g. "_printer" for
+	// an _http._tcp web interface of a printer. The instance is then also
+	// answered for a browse of "_printer._sub._http._tcp.local", letting
+	// clients find just the instances they can use (RFC 6763 §7.1). Each
+	// subtype is a single label of 1-63 octets; by convention it starts with
+	// an underscore.
+	Subtypes []string
+
+	// proxyAddress is the IPv4 address advertised in the A record in place
+	// of this host's interface address (RegisterProxy).
+	proxyAddress net.IP
 }
 
+// TXTBoolean is the TXTRecords value that marks a boolean (valueless) key.
+//
+// RFC 6763 §6.4: "key" (attribute present, no value) is distinct from "key="
+// (attribute present, empty value). Use TXTBoolean for the former:
+//
+//	TXTRecords: map[string]string{
+//	    "paper":  responder.TXTBoolean, // encodes as "paper"
+//	    "note":   "",                   // encodes as "note="
+//	    "rp":     "printers/1",         // encodes as "rp=printers/1"
+//	}
+const TXTBoolean = records.TXTBoolean
+
 // Validate validates the service fields per RFC 6762/6763 requirements.
 //
 // RFC 6763 §4: Service Instance Names
@@ -53,33 +98,157 @@ type Service struct {
 // T032: Implement Service.Validate()
 func (s *Service) Validate() error {
 	// Validate InstanceName
-	if s.InstanceName == "" {
+	if err := ValidateInstanceName(s.InstanceName); err != nil {
+		return err
+	}
+
+	// Validate ServiceType format
+	if err := ValidateServiceType(s.ServiceType); err != nil {
+		return err
+	}
+
+	// Validate Port (uint16 guarantees 0-65535 range, only check for zero);
+	// a PTR-only service has no SRV record to carry one
+	if s.Port == 0 && !s.PTROnly {
+		return fmt.Errorf("port must be in range 1-65535 (got 0)")
+	}
+
+	// Validate TXT keys and size
+	if err := validateTXTRecords(s.TXTRecords); err != nil {
+		return err
+	}
+
+	// Validate optional per-service hostname override
+	if s.Hostname != "" {
+		if err := validateHostname(s.Hostname); err != nil {
+			return err
+		}
+	}
+
+	return validateSubtypes(s.Subtypes, s.ServiceType)
+}
+
+// validateSubtypes checks each subtype is a single label of 1-63 octets
+// (RFC 6763 §7.2), listed once, whose subtype name
+// ("_printer._sub._http._tcp.local") fits in a DNS name.
+func validateSubtypes(subtypes []string, serviceType string) error {
+	seen := make(map[string]bool, len(subtypes))
+	for _, subtype := range subtypes {
+		if subtype == "" || len(subtype) > 63 || strings.Contains(subtype, ".") {
+			return fmt.Errorf("invalid subtype %q: must be a single label of 1-63 octets (RFC 6763 §7.2)", subtype)
+		}
+		key := strings.ToLower(subtype)
+		if seen[key] {
+			return fmt.Errorf("duplicate subtype %q", subtype)
+		}
+		seen[key] = true
+		if _, err := message.EncodeName(responder.SubtypeName(subtype, serviceType)); err != nil {
+			return fmt.Errorf("invalid subtype %q: %w", subtype, err)
+		}
+	}
+	return nil
+}
+
+// ValidateInstanceName reports whether name is a valid service instance name
+// per RFC 6763 §4.1.1, without attempting a registration.
+//
+// Instance names are a single DNS label of arbitrary UTF-8 ("My Printer" is
+// fine, including spaces and dots), so the hostname character rules do not
+// apply. Requirements:
+//   - 1-63 octets (RFC 1035 §2.3.4 label limit)
+//   - Valid UTF-8 containing no ASCII control characters (RFC 6763 §4.1.1)
+//
+// Use it for immediate form validation before calling Register; Register
+// applies the same rules.
+//
+// Returns:
+//   - error: describing the first violated rule, nil if valid
+func ValidateInstanceName(name string) error {
+	if name == "" {
 		return fmt.Errorf("instance name cannot be empty")
 	}
 
 	// RFC 1035 §2.3.4: Labels are 1-63 octets
-	if len(s.InstanceName) > 63 {
-		return fmt.Errorf("instance name exceeds 63 octets (got %d)", len(s.InstanceName))
+	if len(name) > 63 {
+		return fmt.Errorf("instance name exceeds 63 octets (got %d)", len(name))
 	}
 
-	// Validate ServiceType format
-	if err := validateServiceType(s.ServiceType); err != nil {
+	// RFC 6763 §4.1.1: "<Instance> portion ... MUST NOT contain ASCII control characters"
+	if !utf8.ValidString(name) {
+		return fmt.Errorf("instance name %q is not valid UTF-8 (RFC 6763 §4.1.1)", name)
+	}
+	for i, r := range name {
+		if r < 0x20 || r == 0x7F {
+			return fmt.Errorf("instance name %q contains control character %U at position %d (RFC 6763 §4.1.1)", name, r, i)
+		}
+	}
+
+	return nil
+}
+
+// ValidateServiceType reports whether serviceType is a valid DNS-SD service
+// type, without attempting a registration.
+//
+// Requirements:
+//   - Format "_service._proto.local" with proto "_tcp" or "_udp" (RFC 6763 §7)
+//   - Service name of lowercase letters, digits, and hyphens, not beginning or
+//     ending with a hyphen (RFC 6763 §7)
+//   - Labels ≤63 octets and encoded name ≤255 octets (RFC 1035 §3.1)
+//
+// Use it for immediate form validation before calling Register; Register
+// applies the same rules.
+//
+// Returns:
+//   - error: describing the first violated rule, nil if valid
+func ValidateServiceType(serviceType string) error {
+	if err := validateServiceType(serviceType); err != nil {
 		return err
 	}
 
-	// Validate Port (uint16 guarantees 0-65535 range, only check for zero)
-	if s.Port == 0 {
-		return fmt.Errorf("port must be in range 1-65535 (got 0)")
+	// RFC 6763 §7: "must not begin or end with a hyphen"
+	name := serviceType[1:strings.IndexByte(serviceType, '.')]
+	if strings.HasPrefix(name, "-") || strings.HasSuffix(name, "-") {
+		return fmt.Errorf("invalid service type %q: hyphen cannot be first or last character in service name %q (RFC 6763 §7)", serviceType, name)
 	}
 
-	// Validate TXT records size
-	if err := validateTXTRecordsSize(s.TXTRecords); err != nil {
-		return err
+	// RFC 1035 §3.1 length limits (label ≤63, name ≤255)
+	if _, err := message.EncodeName(serviceType); err != nil {
+		return fmt.Errorf("invalid service type %q: %w", serviceType, err)
 	}
 
 	return nil
 }
 
+// splitDNSSDName splits a service instance name such as
+// "Brother MFC-L2750DW._ipp._tcp.local" into its instance label and service
+// type.
+//
+// RFC 6763 §4.3: the instance portion is a single DNS label of arbitrary
+// UTF-8, which may itself contain dots, so the name cannot be split at the
+// first dot. Instead the service type is found by its "_service._proto"
+// suffix: the protocol label "_tcp" or "_udp" (RFC 6763 §7), preceded by an
+// underscore-prefixed service label and followed by the domain.
+//
+// Returns:
+//   - instance: The instance label (e.g., "Brother MFC-L2750DW"); empty if
+//     fullName is not an instance name
+//   - serviceType: The service type (e.g., "_ipp._tcp.local"); empty if
+//     fullName is not an instance name
+func splitDNSSDName(fullName string) (instance, serviceType string) {
+	lower := strings.ToLower(fullName)
+	proto := max(strings.LastIndex(lower, "._tcp."), strings.LastIndex(lower, "._udp."))
+	if proto < 0 {
+		return "", ""
+	}
+
+	// The service label cannot contain a dot; the instance label can
+	dot := strings.LastIndexByte(fullName[:proto], '.')
+	if dot <= 0 || !strings.HasPrefix(fullName[dot+1:proto], "_") {
+		return "", ""
+	}
+	return fullName[:dot], fullName[dot+1:]
+}
+
 // Rename renames the service by appending or incrementing a numeric suffix per RFC 6762 §9.
 //
 // RFC 6762 §9: "If a host receives a response containing a record that conflicts
@@ -99,11 +268,18 @@ func (s *Service) Validate() error {
 // FR-030: System MUST rename service on conflict (US2)
 // T061: Implement Service.Rename() (GREEN phase)
 func (s *Service) Rename() {
+	s.InstanceName = nextConflictName(s.InstanceName)
+}
+
+// nextConflictName returns name with its numeric conflict suffix appended
+// ("-2") or incremented ("-2" → "-3"), truncated to a 63-octet label.
+// Used for both service instance names and host labels (RFC 6762 §9).
+func nextConflictName(name string) string {
 	// Pattern: matches "-N" suffix at end of string where N is a positive integer
 	// E.g., "My Service-2", "Printer-10"
 	suffixPattern := regexp.MustCompile(`^(.+)-(\d+)$`)
 
-	if matches := suffixPattern.FindStringSubmatch(s.InstanceName); matches != nil {
+	if matches := suffixPattern.FindStringSubmatch(name); matches != nil {
 		// Name already has a suffix - increment it
 		baseName := matches[1]  // "My Service"
 		suffixStr := matches[2] // "2"
@@ -117,14 +293,14 @@ func (s *Service) Rename() {
 		newName := fmt.Sprintf("%s-%d", baseName, suffix)
 
 		// Truncate if needed to fit within 63-octet limit
-		s.InstanceName = truncateToFit(newName, 63)
-	} else {
-		// Name has no suffix - append "-2"
-		newName := s.InstanceName + "-2"
-
-		// Truncate if needed to fit within 63-octet limit
-		s.InstanceName = truncateToFit(newName, 63)
+		return truncateToFit(newName, 63)
 	}
+
+	// Name has no suffix - append "-2"
+	newName := name + "-2"
+
+	// Truncate if needed to fit within 63-octet limit
+	return truncateToFit(newName, 63)
 }
 
 // truncateToFit truncates a name to fit within maxLen octets while preserving suffix.
@@ -242,12 +418,95 @@ func describeInvalidServiceType(serviceType string) error {
 	return fmt.Errorf("invalid service type %q: must have the form _service._proto.local (e.g. %q)", serviceType, example)
 }
 
+// validateHostname validates a per-service SRV target hostname.
+//
+// The name must be a valid DNS name (RFC 1035 §3.1: labels ≤63 octets, name
+// ≤255 octets) and, since the responder answers A queries for it over mDNS,
+// must lie in the ".local" domain (RFC 6762 §3).
+func validateHostname(hostname string) error {
+	if _, err := message.EncodeName(hostname); err != nil {
+		return fmt.Errorf("invalid hostname %q: %w", hostname, err)
+	}
+	if !strings.HasSuffix(strings.ToLower(hostname), ".local") {
+		return fmt.Errorf("invalid hostname %q: must end in \".local\" (RFC 6762 §3)", hostname)
+	}
+	return nil
+}
+
+// validateTXTRecords validates a TXT record set's keys (validateTXTKeys) and
+// size (validateTXTRecordsSize).
+func validateTXTRecords(txtRecords map[string]string) error {
+	if err := validateTXTKeys(txtRecords); err != nil {
+		return err
+	}
+	return validateTXTRecordsSize(txtRecords)
+}
+
+// validateTXTKeys validates TXT keys per RFC 6763 §6.4: "The key MUST be at
+// least one character" of printable US-ASCII (0x20-0x7E) excluding '='.
+// Keys are case insensitive, so two keys differing only in case would
+// advertise the same attribute twice.
+//
+// Keys are checked in sorted order so the reported key is deterministic.
+//
+// Returns:
+//   - error: ValidationError naming the offending key, nil if valid
+func validateTXTKeys(txtRecords map[string]string) error {
+	keys := make([]string, 0, len(txtRecords))
+	for key := range txtRecords {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	seen := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if key == "" {
+			return &errors.ValidationError{
+				Field:   "TXTRecords",
+				Value:   key,
+				Message: "TXT key cannot be empty (RFC 6763 §6.4)",
+			}
+		}
+		for i := 0; i < len(key); i++ {
+			if c := key[i]; c < 0x20 || c > 0x7E || c == '=' {
+				return &errors.ValidationError{
+					Field:   "TXTRecords",
+					Value:   key,
+					Message: fmt.Sprintf("TXT key %q must be printable ASCII without '=' (RFC 6763 §6.4)", key),
+				}
+			}
+		}
+		folded := strings.ToLower(key)
+		if other, dup := seen[folded]; dup {
+			return &errors.ValidationError{
+				Field:   "TXTRecords",
+				Value:   key,
+				Message: fmt.Sprintf("TXT keys %q and %q differ only in case (RFC 6763 §6.4)", other, key),
+			}
+		}
+		seen[folded] = key
+	}
+	return nil
+}
+
 // validateTXTRecordsSize validates that TXT records don't exceed RFC limits.
 //
+// RFC 6763 §6.1: Each "key=value" string is a DNS character-string, prefixed
+// by a length byte, so it cannot exceed 255 bytes. Longer entries would be
+// written with a wrapped length byte and corrupt the record.
+//
 // RFC 6763 §6.2: "The total size of a typical DNS-SD TXT record is intended to be
 // small -- 200 bytes or less. In cases where more data is justified, the maximum
 // SHOULD NOT exceed 1300 bytes."
 //
+// Oversized sets are rejected rather than split across several TXT records:
+// RFC 6763 §6.1 asks for a single TXT record per instance, and the 1300-byte
+// cap keeps the record far below the 65535-byte RDLENGTH limit (RFC 1035
+// §3.2.1).
+//
+// Returns:
+//   - error: ValidationError naming the oversized entry or total, nil if valid
+//
 // T032: TXT record size validation
 func validateTXTRecordsSize(txtRecords map[string]string) error {
 	if len(txtRecords) == 0 {
@@ -257,16 +516,39 @@ func validateTXTRecordsSize(txtRecords map[string]string) error {
 
 	// Calculate total size: length byte + key=value for each pair
 	totalSize := 0
+	longKey, longLen := "", 0
 	for key, value := range txtRecords {
-		// Each entry: length byte + "key=value"
-		entrySize := 1 + len(key) + 1 + len(value) // 1 for '=', 1 for length prefix
-		totalSize += entrySize
+		// Each entry: length byte + "key=value" (or bare "key" for TXTBoolean)
+		entry := records.TXTEntryString(key, value)
+		totalSize += 1 + len(entry)
+		if len(entry) > maxTXTStringLength && len(entry) > longLen {
+			longKey, longLen = key, len(entry)
+		}
 	}
 
 	// RFC 6763 §6.2: SHOULD NOT exceed 1300 bytes
-	if totalSize > 1300 {
-		return fmt.Errorf("TXT records exceed 1300 bytes (got %d)", totalSize)
+	if totalSize > maxTXTRecordsSize {
+		return &errors.ValidationError{
+			Field:   "TXTRecords",
+			Value:   totalSize,
+			Message: fmt.Sprintf("TXT records exceed %d bytes (got %d)", maxTXTRecordsSize, totalSize),
+		}
+	}
+
+	// RFC 6763 §6.1: Each string fits one length byte
+	if longLen > 0 {
+		return &errors.ValidationError{
+			Field:   "TXTRecords",
+			Value:   longKey,
+			Message: fmt.Sprintf("TXT entry exceeds %d bytes (got %d)", maxTXTStringLength, longLen),
+		}
 	}
 
 	return nil
 }
+
+// TXT size limits enforced by validateTXTRecordsSize.
+const (
+	maxTXTStringLength = 255  // RFC 6763 §6.1: one length-prefixed string
+	maxTXTRecordsSize  = 1300 // RFC 6763 §6.2: whole TXT RDATA
+)
@@ -1,7 +1,12 @@
 package responder
 
 import (
+	goerrors "errors"
+	"fmt"
+	"strings"
 	"testing"
+
+	"github.com/joshuafuller/beacon/internal/errors"
 )
 
 // TestService_Validate_RED tests service validation per RFC 6763 §4.
@@ -246,6 +251,85 @@ func TestService_Validate_Port(t *testing.T) {
 	}
 }
 
+// TestService_Validate_Hostname tests the optional per-service hostname override.
+func TestService_Validate_Hostname(t *testing.T) {
+	tests := []struct {
+		name        string
+		hostname    string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "valid - empty (use responder hostname)", hostname: ""},
+		{name: "valid - alias.local", hostname: "alias.local"},
+		{name: "invalid - not .local", hostname: "alias.example.com", wantErr: true, errContains: "must end in"},
+		{name: "invalid - label too long", hostname: strings.Repeat("a", 64) + ".local", wantErr: true, errContains: "invalid hostname"},
+		{name: "invalid - empty label", hostname: "alias..local", wantErr: true, errContains: "invalid hostname"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{
+				InstanceName: "Test Service",
+				ServiceType:  "_http._tcp.local",
+				Port:         8080,
+				Hostname:     tt.hostname,
+			}
+
+			err := service.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Validate() error = nil, want error containing %q", tt.errContains)
+				} else if !contains(err.Error(), tt.errContains) {
+					t.Errorf("Validate() error = %q, want error containing %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+// TestService_Validate_Subtypes tests subtype validation per RFC 6763 §7.2.
+func TestService_Validate_Subtypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		subtypes    []string
+		wantErr     bool
+		errContains string
+	}{
+		{name: "valid - none", subtypes: nil},
+		{name: "valid - _printer and _admin", subtypes: []string{"_printer", "_admin"}},
+		{name: "invalid - empty", subtypes: []string{""}, wantErr: true, errContains: "single label"},
+		{name: "invalid - dotted", subtypes: []string{"_a._b"}, wantErr: true, errContains: "single label"},
+		{name: "invalid - label too long", subtypes: []string{strings.Repeat("a", 64)}, wantErr: true, errContains: "single label"},
+		{name: "invalid - duplicate", subtypes: []string{"_printer", "_Printer"}, wantErr: true, errContains: "duplicate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &Service{
+				InstanceName: "Test Service",
+				ServiceType:  "_http._tcp.local",
+				Port:         8080,
+				Subtypes:     tt.subtypes,
+			}
+
+			err := service.Validate()
+
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Validate() error = nil, want error containing %q", tt.errContains)
+				} else if !contains(err.Error(), tt.errContains) {
+					t.Errorf("Validate() error = %q, want error containing %q", err.Error(), tt.errContains)
+				}
+			} else if err != nil {
+				t.Errorf("Validate() error = %v, want nil", err)
+			}
+		})
+	}
+}
+
 // TestService_Validate_TXTRecords tests TXT record size validation per RFC 6763 §6.2.
 //
 // TDD Phase: RED
@@ -470,3 +554,173 @@ func hasSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+// TestValidateInstanceName tests the public instance name validator per
+// RFC 6763 §4.1.1 and RFC 1035 §2.3.4.
+func TestValidateInstanceName(t *testing.T) {
+	tests := []struct {
+		name         string
+		instanceName string
+		errContains  string // empty = valid
+	}{
+		{name: "valid - simple", instanceName: "Printer"},
+		{name: "valid - spaces and punctuation", instanceName: "My Printer (2nd floor)"},
+		{name: "valid - dots allowed in single label", instanceName: "v1.2 Server"},
+		{name: "valid - UTF-8", instanceName: "Café Drucker"},
+		{name: "valid - exactly 63 octets", instanceName: strings.Repeat("a", 63)},
+		{name: "invalid - empty", instanceName: "", errContains: "cannot be empty"},
+		{name: "invalid - 64 octets", instanceName: strings.Repeat("a", 64), errContains: "exceeds 63 octets"},
+		{name: "invalid - control character", instanceName: "Bad\nName", errContains: "control character"},
+		{name: "invalid - malformed UTF-8", instanceName: "Bad\xffName", errContains: "not valid UTF-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateInstanceName(tt.instanceName)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("ValidateInstanceName(%q) = %v, want nil", tt.instanceName, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ValidateInstanceName(%q) = %v, want error containing %q", tt.instanceName, err, tt.errContains)
+			}
+		})
+	}
+}
+
+// TestSplitDNSSDName verifies instance names are split at the service type
+// suffix, not the first dot, since the instance label may contain dots
+// (RFC 6763 §4.3).
+func TestSplitDNSSDName(t *testing.T) {
+	tests := []struct {
+		fullName     string
+		wantInstance string
+		wantType     string
+	}{
+		{"Brother MFC-L2750DW._ipp._tcp.local", "Brother MFC-L2750DW", "_ipp._tcp.local"},
+		{"v1.2 Server._http._tcp.local", "v1.2 Server", "_http._tcp.local"},
+		{"a.b.c-d._ssh._tcp.local", "a.b.c-d", "_ssh._tcp.local"},
+		{"Speaker._raop._udp.local", "Speaker", "_raop._udp.local"},
+		{"Printer._IPP._TCP.local", "Printer", "_IPP._TCP.local"},
+		{"_http._tcp.local", "", ""},       // Service type alone
+		{"myhost.local", "", ""},           // Hostname
+		{"Printer.ipp._tcp.local", "", ""}, // Service label lacks underscore
+	}
+	for _, tt := range tests {
+		instance, serviceType := splitDNSSDName(tt.fullName)
+		if instance != tt.wantInstance || serviceType != tt.wantType {
+			t.Errorf("splitDNSSDName(%q) = (%q, %q), want (%q, %q)", tt.fullName, instance, serviceType, tt.wantInstance, tt.wantType)
+		}
+	}
+}
+
+// TestValidateServiceType mirrors the EncodeName validation cases
+// (RFC 1035 §3.1) through the public service type validator.
+func TestValidateServiceType(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceType string
+		errContains string // empty = valid
+	}{
+		{name: "valid - _http._tcp.local", serviceType: "_http._tcp.local"},
+		{name: "valid - _airplay._udp.local", serviceType: "_airplay._udp.local"},
+		{name: "valid - inner hyphen", serviceType: "_my-service._tcp.local"},
+		{name: "invalid - empty", serviceType: "", errContains: "cannot be empty"},
+		{name: "invalid - empty label (consecutive dots)", serviceType: "_http.._tcp.local", errContains: "_service._proto.local"},
+		{name: "invalid - label exceeds 63 bytes", serviceType: "_" + strings.Repeat("a", 63) + "._tcp.local", errContains: "exceeds maximum length 63 bytes"},
+		{name: "invalid - character (space)", serviceType: "_my service._tcp.local", errContains: "not allowed"},
+		{name: "invalid - hyphen at start of service name", serviceType: "_-http._tcp.local", errContains: "hyphen cannot be first or last"},
+		{name: "invalid - hyphen at end of service name", serviceType: "_http-._tcp.local", errContains: "hyphen cannot be first or last"},
+		{name: "invalid - missing leading underscore", serviceType: "http._tcp.local", errContains: "begin with an underscore"},
+		{name: "invalid - bad protocol", serviceType: "_http._sctp.local", errContains: "protocol label"},
+		{name: "invalid - wrong domain", serviceType: "_http._tcp.example.com", errContains: ".local"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateServiceType(tt.serviceType)
+			if tt.errContains == "" {
+				if err != nil {
+					t.Errorf("ValidateServiceType(%q) = %v, want nil", tt.serviceType, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errContains) {
+				t.Errorf("ValidateServiceType(%q) = %v, want error containing %q", tt.serviceType, err, tt.errContains)
+			}
+		})
+	}
+}
+
+// oversizedTXT returns TXT records totalling more than the 65535 bytes a
+// single TXT record's RDLENGTH can carry, in 250-byte entries.
+func oversizedTXT() map[string]string {
+	txt := make(map[string]string, 300)
+	for i := 0; i < 300; i++ {
+		txt[fmt.Sprintf("k%03d", i)] = strings.Repeat("v", 245) // "k000=" + 245 = 250 bytes
+	}
+	return txt
+}
+
+// TestService_Validate_OversizedTXT verifies TXT data beyond a single
+// record's limits is rejected with a ValidationError instead of being encoded
+// with a corrupt length: entries over 255 bytes (RFC 6763 §6.1) and sets over
+// 65535 bytes, which the 1300-byte cap (RFC 6763 §6.2) catches first.
+func TestService_Validate_OversizedTXT(t *testing.T) {
+	tests := []struct {
+		name string
+		txt  map[string]string
+	}{
+		{"total over 65535 bytes", oversizedTXT()},
+		{"entry over 255 bytes", map[string]string{"key": strings.Repeat("v", 300)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &Service{InstanceName: "Big", ServiceType: "_http._tcp.local", Port: 80, TXTRecords: tt.txt}
+			err := svc.Validate()
+			var valErr *errors.ValidationError
+			if !goerrors.As(err, &valErr) || valErr.Field != "TXTRecords" {
+				t.Errorf("Validate() error = %v, want TXTRecords ValidationError", err)
+			}
+		})
+	}
+}
+
+// TestService_Validate_TXTKeys verifies malformed TXT sets are rejected at
+// Register time with a ValidationError naming the offending key: an entry
+// over 255 bytes (RFC 6763 §6.1), and keys that are empty, contain '=' or
+// non-printable bytes, or duplicate another key ignoring case (RFC 6763 §6.4).
+func TestService_Validate_TXTKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		txt     map[string]string
+		wantKey string
+	}{
+		{"over-long value", map[string]string{"path": "/", "blob": strings.Repeat("v", 260)}, "blob"},
+		{"key with '='", map[string]string{"a=b": "c"}, "a=b"},
+		{"empty key", map[string]string{"": "value"}, ""},
+		{"non-printable key", map[string]string{"tab\tkey": "v"}, "tab\tkey"},
+		{"duplicate keys ignoring case", map[string]string{"Path": "/a", "path": "/b"}, "path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &Service{InstanceName: "Keys", ServiceType: "_http._tcp.local", Port: 80, TXTRecords: tt.txt}
+			err := svc.Validate()
+			var valErr *errors.ValidationError
+			if !goerrors.As(err, &valErr) || valErr.Field != "TXTRecords" {
+				t.Fatalf("Validate() error = %v, want TXTRecords ValidationError", err)
+			}
+			if valErr.Value != tt.wantKey {
+				t.Errorf("ValidationError.Value = %q, want offending key %q", valErr.Value, tt.wantKey)
+			}
+		})
+	}
+
+	valid := &Service{InstanceName: "Keys", ServiceType: "_http._tcp.local", Port: 80,
+		TXTRecords: map[string]string{"txtvers": "1", "Paper": TXTBoolean, "note": ""}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() with valid keys error = %v, want nil", err)
+	}
+}
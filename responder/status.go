@@ -0,0 +1,157 @@
+package responder
+
+import (
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/state"
+)
+
+// ServiceState is a service's registration state (RFC 6762 §8).
+type ServiceState = state.State
+
+// Service registration states, as reported by Services.
+const (
+	// StateProbing: checking the name is unique on the link (RFC 6762 §8.1)
+	StateProbing = state.StateProbing
+
+	// StateAnnouncing: name claimed; announcing the records (RFC 6762 §8.3)
+	StateAnnouncing = state.StateAnnouncing
+
+	// StateEstablished: registered and answering queries
+	StateEstablished = state.StateEstablished
+
+	// StateConflictDetected: probing found the name taken; a rename follows
+	// (RFC 6762 §9)
+	StateConflictDetected = state.StateConflictDetected
+)
+
+// ServiceStatus is a snapshot of one service's registration; see Services.
+type ServiceStatus struct {
+	// InstanceName is the name the service was registered with.
+	InstanceName string
+
+	// AssignedName is the name the service currently holds, which differs
+	// from InstanceName after a conflict rename ("My Service-2", RFC 6762 §9).
+	AssignedName string
+
+	// ServiceType is the service type (e.g., "_http._tcp.local").
+	ServiceType string
+
+	// Port is the service port.
+	Port uint16
+
+	// IPv4 is the address advertised in the service's A record, chosen when
+	// it was registered (the proxied host's for RegisterProxy). It is the
+	// zero Addr until the service is established, for a PTR-only service,
+	// and for one placed without probing.
+	IPv4 netip.Addr
+
+	// State is the service's registration state.
+	State ServiceState
+
+	// LastAnnounce is when the service's records were last announced
+	// (registration, UpdateService or Reload); zero if never.
+	LastAnnounce time.Time
+}
+
+// Services returns a snapshot of every service being registered or
+// registered, sorted by assigned name.
+//
+// Unlike GetService, which returns a registered service's configuration,
+// Services reports each service's live lifecycle state: registrations still
+// probing or announcing appear too, under the name currently being claimed.
+// This is the data a status endpoint or TUI needs.
+//
+// Returns:
+//   - []ServiceStatus: One entry per service (empty if none)
+func (r *Responder) Services() []ServiceStatus {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	byName := make(map[string]ServiceStatus, len(r.statuses))
+	for name, st := range r.statuses {
+		byName[name] = *st
+	}
+
+	// Services placed in the registry without the state machine (e.g.
+	// RegisterServiceWithoutProbing) are established
+	for _, name := range r.registry.List() {
+		if _, ok := byName[name]; ok {
+			continue
+		}
+		if svc, found := r.registry.Get(name); found {
+			byName[name] = ServiceStatus{
+				InstanceName: svc.InstanceName,
+				AssignedName: svc.InstanceName,
+				ServiceType:  svc.ServiceType,
+				Port:         svc.Port,
+				State:        StateEstablished,
+			}
+		}
+	}
+
+	out := make([]ServiceStatus, 0, len(byName))
+	for _, st := range byName {
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AssignedName < out[j].AssignedName })
+	return out
+}
+
+// updateStatus applies update to the status tracked under assignedName,
+// creating it from service (registered as requested) if absent.
+func (r *Responder) updateStatus(service *Service, requested string, update func(*ServiceStatus)) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	if r.statuses == nil {
+		r.statuses = make(map[string]*ServiceStatus)
+	}
+	st, ok := r.statuses[service.InstanceName]
+	if !ok {
+		st = &ServiceStatus{
+			InstanceName: requested,
+			AssignedName: service.InstanceName,
+			ServiceType:  service.ServiceType,
+			Port:         service.Port,
+		}
+		r.statuses[service.InstanceName] = st
+	}
+	update(st)
+}
+
+// markAnnounced records an announcement of the service assigned name, at the
+// time on the configured clock (WithClock).
+func (r *Responder) markAnnounced(name string) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+
+	if st, ok := r.statuses[name]; ok {
+		st.LastAnnounce = clock.Or(r.clock).Now()
+	}
+}
+
+// establishStatus records that the service assigned name reached Established
+// advertising ipv4 (nil for none), and returns its status.
+func (r *Responder) establishStatus(service *Service, requested string, ipv4 []byte) ServiceStatus {
+	var established ServiceStatus
+	r.updateStatus(service, requested, func(st *ServiceStatus) {
+		st.State = StateEstablished
+		if addr, ok := netip.AddrFromSlice(ipv4); ok {
+			st.IPv4 = addr.Unmap()
+		}
+		established = *st
+	})
+	return established
+}
+
+// forgetStatus stops tracking the service assigned name (unregistered,
+// renamed or failed).
+func (r *Responder) forgetStatus(name string) {
+	r.statusMu.Lock()
+	defer r.statusMu.Unlock()
+	delete(r.statuses, name)
+}
@@ -0,0 +1,110 @@
+package responder
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// TestServices_ReportsLifecycleState verifies Services reports a registration
+// in progress as Probing, a just-registered service as Established with its
+// assigned (renamed) name and last announcement time, and drops it on
+// Unregister.
+func TestServices_ReportsLifecycleState(t *testing.T) {
+	r, fake, _ := newObservedResponder(t)
+
+	// First attempt conflicts, so the service is renamed (RFC 6762 §9)
+	r.InjectConflictDuringProbing(true)
+	r.observer = ObserverFunc(func(ev Event) {
+		if ev.Type == EventConflictDetected {
+			r.InjectConflictDuringProbing(false)
+		}
+	})
+
+	service := &Service{InstanceName: "Status", ServiceType: "_http._tcp.local", Port: 8080}
+	done := make(chan error, 1)
+	go func() { done <- r.Register(service) }()
+
+	fake.WaitForWaiters(1) // Waiting between probes
+	probing := r.Services()
+	if len(probing) != 1 || probing[0].State != StateProbing || probing[0].AssignedName != "Status" {
+		t.Errorf("Services() while probing = %+v, want Status in Probing", probing)
+	}
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				t.Fatalf("Register() error = %v", err)
+			}
+		default:
+			if fake.Waiters() > 0 {
+				fake.Advance(time.Second)
+			}
+			time.Sleep(time.Millisecond)
+			continue
+		}
+		break
+	}
+
+	got := r.Services()
+	if len(got) != 1 {
+		t.Fatalf("Services() = %+v, want one service", got)
+	}
+	want := ServiceStatus{
+		InstanceName: "Status",
+		AssignedName: "Status-2",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+		State:        StateEstablished,
+		IPv4:         netip.AddrFrom4([4]byte{192, 168, 1, 10}),
+		LastAnnounce: fake.Now(), // Second announcement; the clock has not moved since
+	}
+	if got[0] != want {
+		t.Errorf("Services()[0] = %+v, want %+v", got[0], want)
+	}
+
+	if err := r.Unregister("Status-2"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	if got := r.Services(); len(got) != 0 {
+		t.Errorf("Services() after Unregister = %+v, want none", got)
+	}
+}
+
+// TestWithOnRegistered_FiresOnceWithFinalDetails verifies the registered
+// callback fires exactly once per service, after a conflict rename, with the
+// assigned name, port and advertised address.
+func TestWithOnRegistered_FiresOnceWithFinalDetails(t *testing.T) {
+	r, fake, _ := newObservedResponder(t)
+	var got []ServiceStatus
+	if err := WithOnRegistered(func(service ServiceStatus) { got = append(got, service) })(r); err != nil {
+		t.Fatalf("WithOnRegistered() error = %v", err)
+	}
+	r.InjectConflictForAttempts(1)
+
+	service := &Service{InstanceName: "Ready", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := registerOnFakeClock(t, r, fake, service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if len(got) != 1 {
+		t.Fatalf("callback fired %d times, want 1: %+v", len(got), got)
+	}
+	want := ServiceStatus{
+		InstanceName: "Ready",
+		AssignedName: "Ready-2",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+		State:        StateEstablished,
+		IPv4:         netip.AddrFrom4([4]byte{192, 168, 1, 10}),
+		LastAnnounce: fake.Now(),
+	}
+	if got[0] != want {
+		t.Errorf("callback status = %+v, want %+v", got[0], want)
+	}
+
+	if err := WithOnRegistered(nil)(r); err == nil {
+		t.Error("WithOnRegistered(nil) error = nil, want ValidationError")
+	}
+}
@@ -1,58 +1,55 @@
 package responder
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/records"
+	"github.com/joshuafuller/beacon/internal/state"
+)
 
 // This file contains test-only hooks on the Responder.
 //
 // They exist solely to enable black-box contract tests (in package
 // tests/contract and responder's own *_test.go) to observe and inject protocol
-// behavior — captured probe/announce messages, probe/announce callbacks, fast
-// registration without the ~1.75s probing delay, and conflict injection — without
-// reaching into internal packages or weakening the F-2 layer boundary.
+// behavior — captured probe/announce/goodbye messages, probe/announce
+// callbacks, fast registration without the ~1.75s probing delay, and conflict
+// injection — without reaching into internal packages or weakening the F-2
+// layer boundary.
 //
 // They are NOT part of the responder's runtime behavior. If the contract tests
 // are ever moved white-box (into package responder with an export_test.go), this
 // surface can be removed from the public API entirely. See B2 in the refactor
 // punch-list for that deliberate, deferred decision.
 
-// OnProbe sets a callback to be called when a probe is sent.
+// OnProbe sets a callback to be called when a probe is sent. Set it before
+// Register. WithObserver is the supported way to follow probing
+// (EventProbeSent).
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.1 validation
 func (r *Responder) OnProbe(callback func()) {
-	// Store callback for future machines
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
 	r.onProbeCallback = callback
-
-	// Also apply to current machine if it exists
-	if r.lastMachine != nil {
-		prober := r.lastMachine.GetProber()
-		if prober != nil {
-			prober.SetOnSendQuery(callback)
-		}
-	}
 }
 
-// OnAnnounce sets a callback to be called when an announcement is sent.
+// OnAnnounce sets a callback to be called when an announcement is sent. Set
+// it before Register. WithObserver is the supported way to follow announcing
+// (EventAnnounceSent).
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.3 validation
 func (r *Responder) OnAnnounce(callback func()) {
-	// Store callback for future machines
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
 	r.onAnnounceCallback = callback
-
-	// Also apply to current machine if it exists
-	if r.lastMachine != nil {
-		announcer := r.lastMachine.GetAnnouncer()
-		if announcer != nil {
-			announcer.SetOnSendAnnouncement(callback)
-		}
-	}
 }
 
 // GetLastProbeMessage returns the last sent probe message.
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.1 validation
 func (r *Responder) GetLastProbeMessage() []byte {
-	if r.lastMachine != nil {
-		prober := r.lastMachine.GetProber()
+	if machine := r.lastRegistrationMachine(); machine != nil {
+		prober := machine.GetProber()
 		if prober != nil {
 			return prober.GetLastProbeMessage()
 		}
@@ -60,12 +57,29 @@ func (r *Responder) GetLastProbeMessage() []byte {
 	return nil
 }
 
+// GetLastProbe returns the last sent probe message, parsed: ANY questions for
+// the claimed service name and hostname, and the proposed records in the
+// Authority section (RFC 6762 §8.1, §8.2).
+//
+// Returns:
+//   - *DNSMessage: The parsed probe
+//   - error: if no probe was sent, or it does not parse
+//
+// US2 GREEN: Contract test support for RFC 6762 §8.1 validation
+func (r *Responder) GetLastProbe() (*DNSMessage, error) {
+	probe := r.GetLastProbeMessage()
+	if probe == nil {
+		return nil, fmt.Errorf("no probe sent")
+	}
+	return message.ParseMessage(probe)
+}
+
 // GetLastAnnounceMessage returns the last sent announcement message.
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.3 validation
 func (r *Responder) GetLastAnnounceMessage() []byte {
-	if r.lastMachine != nil {
-		announcer := r.lastMachine.GetAnnouncer()
+	if machine := r.lastRegistrationMachine(); machine != nil {
+		announcer := machine.GetAnnouncer()
 		if announcer != nil {
 			return announcer.GetLastAnnounceMessage()
 		}
@@ -73,19 +87,32 @@ func (r *Responder) GetLastAnnounceMessage() []byte {
 	return nil
 }
 
-// GetLastAnnouncedRecords returns the last announced record set.
+// GetLastAnnouncedRecords returns a copy of the last announced record set,
+// which the caller may keep or modify while a registration runs.
 //
 // US2 GREEN: Contract test support for RFC 6762 §8.3 and RFC 6763 §6 validation
 func (r *Responder) GetLastAnnouncedRecords() []*ResourceRecord {
-	return r.lastAnnouncedRecords
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	return records.CloneRecordSet(r.lastAnnouncedRecords)
+}
+
+// GetLastGoodbyeMessage returns the last goodbye packet built by Unregister,
+// UnregisterAll or Close, or nil if none was.
+//
+// US2 GREEN: Contract test support for RFC 6762 §10.1 validation
+func (r *Responder) GetLastGoodbyeMessage() []byte {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	return r.lastGoodbyeMessage
 }
 
 // GetLastAnnounceDest returns the last announcement destination address.
 //
 // US2 GREEN: Contract test support for RFC 6762 §5 multicast address validation
 func (r *Responder) GetLastAnnounceDest() string {
-	if r.lastMachine != nil {
-		announcer := r.lastMachine.GetAnnouncer()
+	if machine := r.lastRegistrationMachine(); machine != nil {
+		announcer := machine.GetAnnouncer()
 		if announcer != nil {
 			return announcer.GetLastDestAddr()
 		}
@@ -111,7 +138,10 @@ func (r *Responder) RegisterServiceWithoutProbing(service *Service) error {
 	if err := service.Validate(); err != nil {
 		return err
 	}
-	return r.registry.Register(toInternalService(service))
+	r.cancelPendingGoodbye(service.InstanceName)
+	internalSvc := toInternalService(service)
+	internalSvc.TXT = r.mergeTXT(service.TXTRecords)
+	return r.registry.Register(internalSvc)
 }
 
 // InjectConflictDuringProbing is a test hook to inject conflicts during probing.
@@ -121,9 +151,48 @@ func (r *Responder) RegisterServiceWithoutProbing(service *Service) error {
 //
 // T062: Test hook for max rename attempts testing
 func (r *Responder) InjectConflictDuringProbing(inject bool) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
 	r.injectConflict = inject
 }
 
+// InjectConflictForAttempts is a test hook making probing report a conflict
+// for the first n registration attempts, after which probing succeeds.
+//
+// Unlike InjectConflictDuringProbing, which conflicts on every attempt, this
+// exercises a real rename: with n = 1, "My Service" conflicts once and
+// registers as "My Service-2". The count is shared by all registrations; n = 0
+// disables it.
+//
+// T062: Test hook for rename-on-conflict testing
+func (r *Responder) InjectConflictForAttempts(n int) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	r.conflictInjector = state.NewConflictInjector(n)
+}
+
+// InjectConflictForService is a test hook making probing report a conflict
+// for the first n registration attempts of the service registered as
+// instanceName, renames included, after which its probing succeeds.
+//
+// Unlike InjectConflictForAttempts, the count belongs to that one service,
+// so concurrent registrations (RegisterAll) each see their own conflicts.
+// n = 0 removes it.
+//
+// T062: Test hook for rename-on-conflict testing
+func (r *Responder) InjectConflictForService(instanceName string, n int) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	if n <= 0 {
+		delete(r.serviceInjectors, instanceName)
+		return
+	}
+	if r.serviceInjectors == nil {
+		r.serviceInjectors = make(map[string]*state.ConflictInjector)
+	}
+	r.serviceInjectors[instanceName] = state.NewConflictInjector(n)
+}
+
 // InjectSimultaneousProbe is a test hook for injecting simultaneous probe scenarios.
 //
 // This method is currently a stub placeholder for future simultaneous probe testing
@@ -136,3 +205,35 @@ func (r *Responder) InjectConflictDuringProbing(inject bool) {
 //
 // T062: Test hook infrastructure for conflict scenarios
 func (r *Responder) InjectSimultaneousProbe([]byte, []byte) {}
+
+// recordAttempt stores a registration attempt's machine and record set for the
+// GetLast* hooks, and applies any conflict injection to the machine: the
+// service's own (InjectConflictForService, keyed by the requested name), else
+// the shared one.
+func (r *Responder) recordAttempt(machine *state.Machine, recordSet []*ResourceRecord, requested string) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	r.lastMachine = machine
+	r.lastAnnouncedRecords = records.CloneRecordSet(recordSet) // recordSet stays with the announcer
+	machine.SetInjectConflict(r.injectConflict)
+	if injector, ok := r.serviceInjectors[requested]; ok {
+		machine.SetConflictInjector(injector)
+	} else {
+		machine.SetConflictInjector(r.conflictInjector)
+	}
+}
+
+// recordGoodbye stores a goodbye packet for GetLastGoodbyeMessage.
+func (r *Responder) recordGoodbye(packet []byte) {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	r.lastGoodbyeMessage = packet
+}
+
+// lastRegistrationMachine returns the state machine of the latest
+// registration attempt, or nil.
+func (r *Responder) lastRegistrationMachine() *state.Machine {
+	r.hooksMu.Lock()
+	defer r.hooksMu.Unlock()
+	return r.lastMachine
+}
@@ -0,0 +1,137 @@
+package responder
+
+import (
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// truncatedQuery is a query with the TC bit set, held while the querier sends
+// the rest of its known-answer list (RFC 6762 §7.2).
+type truncatedQuery struct {
+	// msg is the query, its Answers extended by each continuation packet
+	msg *message.DNSMessage
+
+	srcAddr        net.Addr
+	interfaceIndex int
+
+	// complete is closed when a continuation without TC arrives: the
+	// known-answer list is whole and the query can be answered at once
+	complete chan struct{}
+}
+
+// deferTruncatedQuery implements the responder side of RFC 6762 §7.2:
+//
+//	If the TC bit is set, the responder SHOULD delay its response by a random
+//	interval uniformly distributed in the range 400-500 ms, to allow time for
+//	the querier to send the rest of its Known-Answer list.
+//
+// A query with TC set is held, keyed by its source address. Packets that
+// follow from the same source are continuations: their known answers (and
+// any questions) are added to the held query. It is answered once, with
+// suppression against every packet's known answers, when a continuation
+// without TC arrives or the delay expires, whichever is first.
+//
+// A query without a source address cannot be matched with its continuations
+// and is answered at once.
+//
+// Returns:
+//   - bool: true if msg was held or merged into a held query, false if the
+//     caller should answer it now
+func (r *Responder) deferTruncatedQuery(msg *message.DNSMessage, srcAddr net.Addr, interfaceIndex int) bool {
+	if srcAddr == nil {
+		return false
+	}
+	key := srcAddr.String()
+	truncated := msg.Header.IsTruncated()
+
+	r.truncatedMu.Lock()
+	defer r.truncatedMu.Unlock()
+
+	if pending, ok := r.truncatedQueries[key]; ok {
+		// Continuation of a held query
+		pending.msg.Questions = append(pending.msg.Questions, msg.Questions...)
+		pending.msg.Answers = append(pending.msg.Answers, msg.Answers...)
+		if !truncated {
+			delete(r.truncatedQueries, key)
+			close(pending.complete)
+		}
+		return true
+	}
+
+	if !truncated {
+		return false
+	}
+
+	if r.truncatedClosed {
+		return true // Closing: drop it rather than answer after Close
+	}
+	if r.truncatedQueries == nil {
+		r.truncatedQueries = make(map[string]*truncatedQuery)
+	}
+	pending := &truncatedQuery{
+		msg:            msg,
+		srcAddr:        srcAddr,
+		interfaceIndex: interfaceIndex,
+		complete:       make(chan struct{}),
+	}
+	r.truncatedQueries[key] = pending
+	r.truncatedWG.Add(1)
+	go r.awaitContinuation(key, pending)
+	return true
+}
+
+// dropTruncatedQueries stops holding TC queries for Close: queries held are
+// dropped unanswered, and any answer already being sent is waited for, so
+// none goes out once Close has returned.
+func (r *Responder) dropTruncatedQueries() {
+	r.truncatedMu.Lock()
+	r.truncatedClosed = true
+	r.truncatedQueries = nil
+	r.truncatedMu.Unlock()
+
+	r.truncatedWG.Wait()
+}
+
+// awaitContinuation answers the held query pending once its known-answer list
+// is complete or the RFC 6762 §7.2 delay expires. A query still held at Close
+// is dropped.
+func (r *Responder) awaitContinuation(key string, pending *truncatedQuery) {
+	defer r.truncatedWG.Done()
+
+	spread := protocol.TruncatedQueryDelayMax - protocol.TruncatedQueryDelayMin
+	delay := protocol.TruncatedQueryDelayMin + r.randDuration(spread)
+
+	select {
+	case <-pending.complete:
+	case <-clock.Or(r.clock).After(delay):
+		r.truncatedMu.Lock()
+		if r.truncatedQueries[key] == pending {
+			delete(r.truncatedQueries, key)
+		}
+		r.truncatedMu.Unlock()
+	case <-r.queryHandlerDone:
+		return
+	}
+
+	// Both cases may have been ready at once with Close under way
+	select {
+	case <-r.queryHandlerDone:
+		return
+	default:
+	}
+	_ = r.answerQuery(pending.msg, pending.srcAddr, pending.interfaceIndex) // nosemgrep: beacon-error-swallowing
+}
+
+// randDuration returns a random duration in [0, n), from the WithRandSource
+// source if set.
+func (r *Responder) randDuration(n time.Duration) time.Duration {
+	if r.randN != nil {
+		return r.randN(n)
+	}
+	return rand.N(n) //nolint:gosec // G404: timing jitter, not security-sensitive
+}
@@ -0,0 +1,217 @@
+package responder
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/clock"
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+)
+
+// newTruncatedQueryResponder returns a responder on a fake clock serving the
+// _http._tcp.local instances A, B and C, and a func returning the packets it
+// has sent.
+func newTruncatedQueryResponder(t *testing.T) (*Responder, *clock.Fake, func() [][]byte) {
+	t.Helper()
+	var mu sync.Mutex
+	var sent [][]byte
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(_ context.Context, packet []byte, _ net.Addr) error {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, packet)
+			return nil
+		}}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+
+	for _, name := range []string{"A", "B", "C"} {
+		svc := &Service{InstanceName: name, ServiceType: "_http._tcp.local", Port: 8080}
+		if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+			t.Fatalf("RegisterServiceWithoutProbing(%q) error = %v", name, err)
+		}
+	}
+
+	return r, fake, func() [][]byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([][]byte(nil), sent...)
+	}
+}
+
+// buildKnownAnswerPacket builds a query packet listing PTR known answers for
+// the given _http._tcp.local instances, with a PTR question if withQuestion
+// and the TC bit if truncated.
+func buildKnownAnswerPacket(t *testing.T, withQuestion, truncated bool, instances ...string) []byte {
+	t.Helper()
+	msg := &message.DNSMessage{}
+	if truncated {
+		msg.Header.Flags = protocol.FlagTC
+	}
+	if withQuestion {
+		msg.Questions = []message.Question{{QNAME: "_http._tcp.local", QTYPE: uint16(protocol.RecordTypePTR), QCLASS: uint16(protocol.ClassIN)}}
+	}
+	for _, instance := range instances {
+		target, err := message.EncodeName(instance + "._http._tcp.local")
+		if err != nil {
+			t.Fatalf("EncodeName() error = %v", err)
+		}
+		msg.Answers = append(msg.Answers, message.Answer{
+			NAME: "_http._tcp.local", TYPE: uint16(protocol.RecordTypePTR), CLASS: uint16(protocol.ClassIN),
+			TTL: protocol.TTLHostname, RDATA: target,
+		})
+	}
+	msg.Header.QDCount = uint16(len(msg.Questions))
+	msg.Header.ANCount = uint16(len(msg.Answers))
+	packet, err := message.SerializeMessage(msg)
+	if err != nil {
+		t.Fatalf("SerializeMessage() error = %v", err)
+	}
+	return packet
+}
+
+// answeredInstances returns the PTR targets answered in response, sorted.
+func answeredInstances(t *testing.T, response []byte) []string {
+	t.Helper()
+	msg, err := message.ParseMessage(response)
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	var targets []string
+	for _, a := range msg.Answers {
+		if a.TYPE != uint16(protocol.RecordTypePTR) {
+			continue
+		}
+		target, err := message.ParseRDATAInMessage(a.TYPE, response, a.RDATAOffset, int(a.RDLENGTH))
+		if err != nil {
+			t.Fatalf("ParseRDATAInMessage(PTR) error = %v", err)
+		}
+		targets = append(targets, target.(string))
+	}
+	sort.Strings(targets)
+	return targets
+}
+
+// waitForSent waits for the responder to have sent n packets.
+func waitForSent(t *testing.T, sent func() [][]byte, n int) [][]byte {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if packets := sent(); len(packets) >= n {
+			return packets
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("sent %d packets, want %d", len(sent()), n)
+	return nil
+}
+
+// TestHandleQuery_TruncatedQuery_FinalContinuation verifies a query with TC
+// set is not answered until its continuation arrives, and that the single
+// response then omits the known answers of both packets (RFC 6762 §7.2).
+func TestHandleQuery_TruncatedQuery_FinalContinuation(t *testing.T) {
+	r, _, sent := newTruncatedQueryResponder(t)
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+
+	if err := r.handleQuery(buildKnownAnswerPacket(t, true, true, "A"), src, 0); err != nil {
+		t.Fatalf("handleQuery(TC query) error = %v", err)
+	}
+	if n := len(sent()); n != 0 {
+		t.Fatalf("sent %d responses before the continuation, want 0", n)
+	}
+
+	if err := r.handleQuery(buildKnownAnswerPacket(t, false, false, "B"), src, 0); err != nil {
+		t.Fatalf("handleQuery(continuation) error = %v", err)
+	}
+	packets := waitForSent(t, sent, 1)
+	if got := answeredInstances(t, packets[0]); len(got) != 1 || got[0] != "C._http._tcp.local" {
+		t.Errorf("answered %v, want only C._http._tcp.local (A and B are known answers)", got)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if n := len(sent()); n != 1 {
+		t.Errorf("sent %d responses, want exactly 1", n)
+	}
+}
+
+// TestHandleQuery_TruncatedQuery_Timeout verifies a query whose last packet
+// still has TC set is answered once the RFC 6762 §7.2 delay of 400-500ms
+// expires, with the known answers received so far suppressed.
+func TestHandleQuery_TruncatedQuery_Timeout(t *testing.T) {
+	r, fake, sent := newTruncatedQueryResponder(t)
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+
+	if err := r.handleQuery(buildKnownAnswerPacket(t, true, true, "A"), src, 0); err != nil {
+		t.Fatalf("handleQuery(TC query) error = %v", err)
+	}
+	if err := r.handleQuery(buildKnownAnswerPacket(t, false, true, "B"), src, 0); err != nil {
+		t.Fatalf("handleQuery(continuation) error = %v", err)
+	}
+
+	fake.WaitForWaiters(1)
+	fake.Advance(protocol.TruncatedQueryDelayMin - time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if n := len(sent()); n != 0 {
+		t.Fatalf("sent %d responses before the delay expired, want 0", n)
+	}
+
+	fake.Advance(protocol.TruncatedQueryDelayMax - protocol.TruncatedQueryDelayMin + time.Millisecond)
+	packets := waitForSent(t, sent, 1)
+	if got := answeredInstances(t, packets[0]); len(got) != 1 || got[0] != "C._http._tcp.local" {
+		t.Errorf("answered %v, want only C._http._tcp.local (A and B are known answers)", got)
+	}
+}
+
+// TestClose_DropsTruncatedQuery verifies a query held for its known-answer
+// continuation at Close is dropped, and one arriving after is not held, so
+// nothing is answered once Close has returned.
+func TestClose_DropsTruncatedQuery(t *testing.T) {
+	var sent atomic.Int32
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	r, err := New(context.Background(),
+		WithTransport(&MockTransport{sendFunc: func(context.Context, []byte, net.Addr) error {
+			sent.Add(1)
+			return nil
+		}}),
+		WithHostname("testhost.local"),
+		WithClock(fake),
+		WithGoodbyeCount(1))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	r.ipv4Source = func() ([]byte, error) { return []byte{192, 168, 1, 10}, nil }
+	svc := &Service{InstanceName: "C", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+	src := &net.UDPAddr{IP: net.IPv4(192, 168, 1, 50), Port: 5353}
+
+	if err := r.handleQuery(buildKnownAnswerPacket(t, true, true, "A"), src, 0); err != nil {
+		t.Fatalf("handleQuery(TC query) error = %v", err)
+	}
+	fake.WaitForWaiters(1)
+	_ = r.Close()
+	closed := sent.Load() // Announcement and goodbye
+
+	if err := r.handleQuery(buildKnownAnswerPacket(t, true, true, "B"), src, 0); err != nil {
+		t.Fatalf("handleQuery(TC query after Close) error = %v", err)
+	}
+	fake.Advance(protocol.TruncatedQueryDelayMax)
+	time.Sleep(20 * time.Millisecond)
+	if n := sent.Load() - closed; n != 0 {
+		t.Errorf("sent %d responses after Close, want 0", n)
+	}
+}
@@ -1,21 +1,130 @@
 package contract
 
 import (
+	"context"
+	"net"
 	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/internal/message"
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
+	"github.com/joshuafuller/beacon/responder"
 )
 
-// TestRFC6762_Section15_InterfaceSpecificAddresses validates RFC 6762 §15:
-// "Responding to Address Queries"
-//
-// Interface-specific addressing is validated by comprehensive integration tests in
-// tests/integration/multi_interface_test.go which use real network interfaces:
-//   - TestMultiNICServer_VLANIsolation: Different interfaces return different IPs
-//   - TestMultiNICServer_InterfaceIndexValidation: Interface -> IP mapping
-//   - TestDockerVPNExclusion: Docker/VPN interface handling
+// multiNICHost simulates a host with three interfaces on different VLANs.
+var multiNICHost = responder.StaticInterfaceResolver{
+	1: "10.0.1.10/24", // Management
+	2: "10.0.2.10/24", // Production
+	3: "10.0.3.10/24", // Backup
+}
+
+// queryOnInterface delivers a PTR query for _http._tcp.local from src on
+// interface ifIndex to a responder on the simulated multi-NIC host, and
+// returns the addresses of the A records in its response (nil if it sent
+// none).
+func queryOnInterface(t *testing.T, src net.IP, ifIndex int) []net.IP {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mock := transport.NewMockTransport()
+	mock.EnableBlockingReceive()
+	r, err := responder.New(ctx,
+		responder.WithTransport(mock),
+		responder.WithHostname("multinic.local"),
+		responder.WithInterfaceResolver(multiNICHost))
+	if err != nil {
+		cancel()
+		t.Fatalf("responder.New() error = %v, want nil", err)
+	}
+	defer func() {
+		cancel()
+		_ = r.Close()
+	}()
+
+	svc := &responder.Service{InstanceName: "Web", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	query, err := buildPTRQuery("_http._tcp.local")
+	if err != nil {
+		t.Fatalf("buildPTRQuery() error = %v", err)
+	}
+	mock.QueueReceive(query, &net.UDPAddr{IP: src, Port: 5353}, ifIndex)
+
+	var calls []transport.SendCall
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for len(calls) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		calls = mock.SendCalls()
+	}
+	if len(calls) == 0 {
+		return nil
+	}
+	if calls[0].IfIndex != ifIndex {
+		t.Errorf("response sent on interface %d, want %d (RFC 6762 §15)", calls[0].IfIndex, ifIndex)
+	}
+
+	msg, err := message.ParseMessage(calls[0].Packet)
+	if err != nil {
+		t.Fatalf("ParseMessage(response) error = %v", err)
+	}
+	var addrs []net.IP
+	for _, section := range [][]message.Answer{msg.Answers, msg.Additionals} {
+		for _, a := range section {
+			if a.TYPE == uint16(protocol.RecordTypeA) {
+				addrs = append(addrs, net.IP(a.RDATA))
+			}
+		}
+	}
+	return addrs
+}
+
+// TestRFC6762_Section15_InterfaceSpecificAddresses validates RFC 6762 §15
+// "Responding to Address Queries" on a simulated three-VLAN host:
 //
-// These integration tests provide more realistic RFC 6762 §15 validation than
-// mocked transport contract tests. The original test scaffolds (T022-T025) were
-// superseded and are no longer needed.
+//	When a Multicast DNS responder sends a Multicast DNS response message
+//	containing its own address records in response to a query received on
+//	a particular interface, it MUST include only addresses that are valid
+//	on that interface, and MUST NOT include addresses configured on other
+//	interfaces.
 func TestRFC6762_Section15_InterfaceSpecificAddresses(t *testing.T) {
-	t.Log("RFC 6762 §15 validated by integration tests in tests/integration/multi_interface_test.go")
+	tests := []struct {
+		name    string
+		src     string
+		ifIndex int
+		want    string
+	}{
+		{"query on interface 1 returns only interface 1 IP", "10.0.1.50", 1, "10.0.1.10"},
+		{"query on interface 2 returns only interface 2 IP", "10.0.2.50", 2, "10.0.2.10"},
+		{"query on interface 3 returns only interface 3 IP", "10.0.3.50", 3, "10.0.3.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs := queryOnInterface(t, net.ParseIP(tt.src), tt.ifIndex)
+			if len(addrs) != 1 || !addrs[0].Equal(net.ParseIP(tt.want)) {
+				t.Errorf("A records = %v, want only %s", addrs, tt.want)
+			}
+		})
+	}
+}
+
+// TestRFC6762_Section15_UnknownInterfaceSkipsResponse validates that a query
+// received on an interface whose address cannot be resolved gets no response
+// under the default SkipResponse policy, rather than one advertising another
+// interface's address.
+func TestRFC6762_Section15_UnknownInterfaceSkipsResponse(t *testing.T) {
+	if addrs := queryOnInterface(t, net.ParseIP("10.0.4.50"), 4); addrs != nil {
+		t.Errorf("A records = %v, want no response", addrs)
+	}
+}
+
+// TestRFC6762_Section6_4_OffSubnetSourceIgnored validates that a query whose
+// source is not on the receiving interface's subnet is ignored: a source on
+// interface 2's subnet arriving on interface 1 is not answered.
+func TestRFC6762_Section6_4_OffSubnetSourceIgnored(t *testing.T) {
+	if addrs := queryOnInterface(t, net.ParseIP("10.0.2.50"), 1); addrs != nil {
+		t.Errorf("A records = %v, want no response", addrs)
+	}
 }
@@ -6,6 +6,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/protocol"
+	"github.com/joshuafuller/beacon/internal/transport"
 	"github.com/joshuafuller/beacon/responder"
 )
 
@@ -144,6 +146,61 @@ func TestRFC6762_Probing_QueryFormat(t *testing.T) {
 	}
 }
 
+// TestRFC6762_Probing_AuthoritySection tests the parsed probe per RFC 6762
+// §8.1 and §8.2: an ANY question for the claimed service name, and the
+// proposed SRV and A records in the Authority section.
+func TestRFC6762_Probing_AuthoritySection(t *testing.T) {
+	ctx := context.Background()
+	r, err := responder.New(ctx, responder.WithTransport(transport.NewMockTransport()), responder.WithHostname("probe-host.local"))
+	if err != nil {
+		t.Fatalf("responder.New() error = %v, want nil", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if _, err := r.GetLastProbe(); err == nil {
+		t.Error("GetLastProbe() before Register error = nil, want error")
+	}
+
+	service := &responder.Service{
+		InstanceName: "RFC Test Service",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+	}
+	if err := r.Register(service); err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+
+	probe, err := r.GetLastProbe()
+	if err != nil {
+		t.Fatalf("GetLastProbe() error = %v, want nil", err)
+	}
+
+	const serviceName = "RFC Test Service._http._tcp.local"
+	if len(probe.Questions) == 0 || probe.Questions[0].QNAME != serviceName ||
+		probe.Questions[0].QTYPE != uint16(protocol.RecordTypeANY) {
+		t.Errorf("probe questions = %+v, want ANY %s first", probe.Questions, serviceName)
+	}
+
+	var srv, a bool
+	for _, rr := range probe.Authorities {
+		switch {
+		case rr.TYPE == uint16(protocol.RecordTypeSRV) && rr.NAME == serviceName:
+			srv = true
+			if port := binary.BigEndian.Uint16(rr.RDATA[4:6]); port != 8080 {
+				t.Errorf("proposed SRV port = %d, want 8080", port)
+			}
+		case rr.TYPE == uint16(protocol.RecordTypeA) && rr.NAME == "probe-host.local":
+			a = true
+		}
+		if rr.CLASS&0x8000 != 0 {
+			t.Errorf("proposed %s record has the cache-flush bit set, want clear in a query", protocol.RecordType(rr.TYPE))
+		}
+	}
+	if !srv || !a {
+		t.Errorf("probe authorities = %+v, want the proposed SRV and A records", probe.Authorities)
+	}
+}
+
 // TestRFC6762_Probing_ConflictDetection_RED tests conflict detection per RFC 6762 §8.1.
 //
 // TDD Phase: RED
@@ -1,6 +1,7 @@
 package contract
 
 import (
+	"bytes"
 	"context"
 	"encoding/binary"
 	"testing"
@@ -249,3 +250,56 @@ func TestRFC6762_TTL_GoodbyePackets(t *testing.T) {
 		}
 	}
 }
+
+// TestRFC6762_TTL_GetLastGoodbyeMessage tests the goodbye capture hook: after
+// Unregister, GetLastGoodbyeMessage returns the packet sent, whose records
+// all carry TTL=0 (RFC 6762 §10.1).
+func TestRFC6762_TTL_GetLastGoodbyeMessage(t *testing.T) {
+	ctx := context.Background()
+	mock := transport.NewMockTransport()
+	r, err := responder.New(ctx, responder.WithTransport(mock))
+	if err != nil {
+		t.Fatalf("responder.New() error = %v, want nil", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	if msg := r.GetLastGoodbyeMessage(); msg != nil {
+		t.Errorf("GetLastGoodbyeMessage() before any goodbye = %d bytes, want nil", len(msg))
+	}
+
+	svc := &responder.Service{
+		InstanceName: "Test Service",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+	}
+	if err := r.Register(svc); err != nil {
+		t.Fatalf("Register() error = %v, want nil", err)
+	}
+	callsBefore := len(mock.SendCalls())
+	if err := r.Unregister("Test Service"); err != nil {
+		t.Fatalf("Unregister() error = %v, want nil", err)
+	}
+
+	goodbye := r.GetLastGoodbyeMessage()
+	calls := mock.SendCalls()
+	if len(calls) == callsBefore || !bytes.Equal(goodbye, calls[callsBefore].Packet) {
+		t.Fatalf("GetLastGoodbyeMessage() = %d bytes, want the goodbye packet sent", len(goodbye))
+	}
+
+	parsed, err := message.ParseMessage(goodbye)
+	if err != nil {
+		t.Fatalf("ParseMessage(goodbye) error = %v", err)
+	}
+	foundTypes := make(map[uint16]bool)
+	for i, ans := range parsed.Answers {
+		if ans.TTL != 0 {
+			t.Errorf("goodbye answer[%d] TTL = %d, want 0 (RFC 6762 §10.1)", i, ans.TTL)
+		}
+		foundTypes[ans.TYPE] = true
+	}
+	for _, rtype := range []protocol.RecordType{protocol.RecordTypePTR, protocol.RecordTypeSRV, protocol.RecordTypeTXT, protocol.RecordTypeA} {
+		if !foundTypes[uint16(rtype)] {
+			t.Errorf("goodbye missing %s record", rtype)
+		}
+	}
+}
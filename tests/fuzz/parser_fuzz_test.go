@@ -5,8 +5,10 @@
 package fuzz
 
 import (
+	goerrors "errors"
 	"testing"
 
+	"github.com/joshuafuller/beacon/internal/errors"
 	"github.com/joshuafuller/beacon/internal/message"
 )
 
@@ -257,13 +259,59 @@ func FuzzParseMessage(f *testing.F) {
 	}
 	f.Add(emptyMessage)
 
-	// Fuzz function: ParseMessage must not panic on any input
-	f.Fuzz(func(_ *testing.T, data []byte) {
-		// The critical requirement is: NO PANICS (NFR-003)
-		// We don't care about errors, only that the parser doesn't crash
-		_, _ = message.ParseMessage(data)
+	// Seed corpus: RDLENGTH claims more bytes than the packet holds
+	rdlengthOverrun := []byte{
+		0x12, 0x34, 0x84, 0x00,
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, // ANCOUNT = 1
+		0x04, 't', 'e', 's', 't', 0x00,
+		0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x78,
+		0xFF, 0xFF, // RDLENGTH = 65535
+		192, 168, 1, 100,
+	}
+	f.Add(rdlengthOverrun)
+
+	// Seed corpus: Header only, every count at its maximum
+	maxCounts := []byte{0x12, 0x34, 0x84, 0x00, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
+	f.Add(maxCounts)
+
+	// Seed corpus: Chain of compression pointers, each to the previous one
+	pointerChain := []byte{
+		0x12, 0x34, 0x00, 0x00,
+		0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // QDCOUNT = 1
+		0xC0, 0x0E, // -> 14
+		0xC0, 0x10, // -> 16
+		0xC0, 0x12, // -> 18
+		0xC0, 0x0C, // -> 12 (loop)
+		0x00, 0x01, 0x00, 0x01,
+	}
+	f.Add(pointerChain)
+
+	// Fuzz function: ParseMessage must not panic on any input (NFR-003), and
+	// must reject malformed input with a WireFormatError. The input size is
+	// bounded by the RFC 6762 §17 maximum packet size.
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) > 9000 {
+			return
+		}
+
+		msg, err := message.ParseMessage(data)
+		if err != nil {
+			var wireErr *errors.WireFormatError
+			if !goerrors.As(err, &wireErr) {
+				t.Fatalf("ParseMessage() error = %T (%v), want *errors.WireFormatError", err, err)
+			}
+			return
+		}
 
-		// If we reach here without panic, the test passes
-		// ParseMessage may return an error for malformed data, which is expected
+		// A parsed message accounts for every record its header announced
+		// (OPT pseudo-records are dropped from the sections)
+		if len(msg.Questions) != int(msg.Header.QDCount) {
+			t.Fatalf("parsed %d questions, header QDCOUNT = %d", len(msg.Questions), msg.Header.QDCount)
+		}
+		for _, a := range append(append(msg.Answers, msg.Authorities...), msg.Additionals...) {
+			if len(a.RDATA) != int(a.RDLENGTH) || a.RDATAOffset+len(a.RDATA) > len(data) {
+				t.Fatalf("record %q RDATA len=%d offset=%d, RDLENGTH=%d, packet=%d bytes", a.NAME, len(a.RDATA), a.RDATAOffset, a.RDLENGTH, len(data))
+			}
+		}
 	})
 }
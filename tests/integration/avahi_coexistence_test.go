@@ -4,8 +4,8 @@ package integration
 
 import (
 	"context"
+	"errors"
 	"net"
-	"os"
 	"syscall"
 	"testing"
 	"time"
@@ -61,12 +61,8 @@ func TestAvahiCoexistence(t *testing.T) {
 	q, err := querier.New()
 	if err != nil {
 		// Check if error is "address already in use" - this indicates SO_REUSEPORT failure
-		if opErr, ok := err.(*net.OpError); ok {
-			if sysErr, ok := opErr.Err.(*os.SyscallError); ok {
-				if sysErr.Err == syscall.EADDRINUSE {
-					t.Fatalf("✗ SC-001/SC-002 FAIL: Address already in use - SO_REUSEPORT not working: %v", err)
-				}
-			}
+		if errors.Is(err, querier.ErrPortInUse) {
+			t.Fatalf("✗ SC-001/SC-002 FAIL: Address already in use - SO_REUSEPORT not working: %v", err)
 		}
 		t.Fatalf("Failed to create Beacon querier: %v", err)
 	}
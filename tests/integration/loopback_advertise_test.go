@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/joshuafuller/beacon/querier"
+	"github.com/joshuafuller/beacon/responder"
+)
+
+// loopbackInterface returns the host's UP loopback interface, skipping the
+// test if there is none.
+func loopbackInterface(t *testing.T) net.Interface {
+	t.Helper()
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Skipf("cannot list interfaces: %v", err)
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 && iface.Flags&net.FlagUp != 0 {
+			return iface
+		}
+	}
+	t.Skip("no UP loopback interface")
+	return net.Interface{}
+}
+
+// TestLoopbackAdvertise_SameHostDiscovery registers a service on a responder
+// with WithLoopbackAdvertise and resolves it from a querier on the same host
+// over real loopback multicast on port 5353: the SRV record points at the
+// responder's host, whose A record is the loopback address.
+func TestLoopbackAdvertise_SameHostDiscovery(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+	lo := loopbackInterface(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	r, err := responder.New(ctx,
+		responder.WithHostname("devbox-loopback.local"),
+		responder.WithLoopbackAdvertise(),
+		responder.WithInterfaceFilter(func(iface net.Interface) bool { return iface.Index == lo.Index }))
+	if err != nil {
+		t.Skipf("cannot open mDNS socket: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	q, err := querier.New(querier.WithLoopbackDiscovery(), querier.WithRateLimit(false))
+	if err != nil {
+		t.Skipf("cannot open mDNS socket: %v", err)
+	}
+	defer func() { _ = q.Close() }()
+
+	svc := &responder.Service{InstanceName: "DevAPI", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.RegisterServiceWithoutProbing(svc); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	queryCtx, queryCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer queryCancel()
+	srv, err := q.QueryInterface(queryCtx, lo, "DevAPI._http._tcp.local", querier.RecordTypeSRV)
+	if err != nil {
+		t.Fatalf("QueryInterface(SRV) error = %v", err)
+	}
+	if len(srv.Records) == 0 || srv.Records[0].AsSRV() == nil {
+		t.Fatal("no SRV answer over loopback")
+	}
+	if got := srv.Records[0].AsSRV(); got.Target != "devbox-loopback.local" || got.Port != 8080 {
+		t.Errorf("SRV = %s:%d, want devbox-loopback.local:8080", got.Target, got.Port)
+	}
+
+	aCtx, aCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer aCancel()
+	a, err := q.QueryInterface(aCtx, lo, "devbox-loopback.local", querier.RecordTypeA)
+	if err != nil {
+		t.Fatalf("QueryInterface(A) error = %v", err)
+	}
+	if len(a.Records) == 0 || !a.Records[0].AsA().IsLoopback() {
+		t.Errorf("A records = %+v, want a loopback address", a.Records)
+	}
+}
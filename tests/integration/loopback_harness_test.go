@@ -0,0 +1,96 @@
+package integration
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/joshuafuller/beacon/internal/transport"
+	"github.com/joshuafuller/beacon/querier"
+	"github.com/joshuafuller/beacon/responder"
+)
+
+// LoopbackPair is a responder and querier wired to each other over an
+// in-process transport.LoopbackLink.
+//
+// Packets flow exactly as on a real link (queries multicast to the responder,
+// responses multicast or unicast back), but never touch the OS network stack,
+// so end-to-end tests run deterministically in CI without multicast routing
+// or port 5353.
+type LoopbackPair struct {
+	Responder *responder.Responder
+	Querier   *querier.Querier
+
+	// Link can Attach further endpoints, e.g. a second querier or a raw
+	// transport injecting crafted packets.
+	Link *transport.LoopbackLink
+}
+
+// Addresses of the pair's endpoints on the link. Private addresses, so the
+// querier's link-local source filter (RFC 6762 §2) accepts the responder.
+var (
+	loopbackResponderAddr = &net.UDPAddr{IP: net.IPv4(192, 168, 77, 1), Port: 5353}
+	loopbackQuerierAddr   = &net.UDPAddr{IP: net.IPv4(192, 168, 77, 2), Port: 5353}
+
+	// loopbackResponderIPv6 is the responder's IPv6 address on the link of a
+	// NewDualStackLoopbackPair (a unique local address, RFC 4193).
+	loopbackResponderIPv6 = net.ParseIP("fd00:77::1")
+)
+
+// loopbackDualStackIfIndex is the interface index a NewDualStackLoopbackPair
+// responder reports for received queries.
+const loopbackDualStackIfIndex = 1
+
+// NewLoopbackPair creates a wired responder ("testhost.local") and querier,
+// both closed when the test ends.
+func NewLoopbackPair(t *testing.T) *LoopbackPair {
+	t.Helper()
+
+	link := transport.NewLoopbackLink()
+	return newLoopbackPair(t, link, responder.WithTransport(link.Attach(loopbackResponderAddr)))
+}
+
+// NewDualStackLoopbackPair is NewLoopbackPair with the responder on a
+// dual-stack interface: queries arrive on interface
+// loopbackDualStackIfIndex, whose addresses are loopbackResponderAddr's IPv4
+// address and loopbackResponderIPv6, so responses carry both A and AAAA
+// records (RFC 6762 §15).
+func NewDualStackLoopbackPair(t *testing.T) *LoopbackPair {
+	t.Helper()
+
+	link := transport.NewLoopbackLink()
+	return newLoopbackPair(t, link,
+		responder.WithTransport(link.AttachOnInterface(loopbackResponderAddr, loopbackDualStackIfIndex)),
+		responder.WithInterfaceResolver(responder.StaticInterfaceResolver{
+			loopbackDualStackIfIndex: loopbackResponderAddr.IP.String() + "/24," + loopbackResponderIPv6.String() + "/64",
+		}))
+}
+
+// newLoopbackPair creates the responder with opts and a querier on link.
+func newLoopbackPair(t *testing.T, link *transport.LoopbackLink, opts ...responder.Option) *LoopbackPair {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r, err := responder.New(ctx, append(opts, responder.WithHostname("testhost.local"))...)
+	if err != nil {
+		cancel()
+		t.Fatalf("responder.New() failed: %v", err)
+	}
+
+	q, err := querier.New(
+		querier.WithTransport(link.Attach(loopbackQuerierAddr)),
+		querier.WithRateLimit(false))
+	if err != nil {
+		cancel()
+		_ = r.Close()
+		t.Fatalf("querier.New() failed: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = q.Close()
+		_ = r.Close()
+		cancel()
+	})
+
+	return &LoopbackPair{Responder: r, Querier: q, Link: link}
+}
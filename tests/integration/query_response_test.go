@@ -7,13 +7,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/joshuafuller/beacon/internal/clock"
 	"github.com/joshuafuller/beacon/internal/message"
 	"github.com/joshuafuller/beacon/internal/protocol"
 	"github.com/joshuafuller/beacon/internal/transport"
+	"github.com/joshuafuller/beacon/querier"
 	"github.com/joshuafuller/beacon/responder"
 )
 
-// TestQueryResponse_ResponseLatency tests end-to-end query response behavior.
+// TestQueryResponse_ResponseLatency tests end-to-end query response latency.
 //
 // RFC 6762 §6: "When a host... is able to answer every question in the query message,
 // and for all of those answer records it has previously verified that the name, rrtype,
@@ -22,72 +24,61 @@ import (
 //
 // SC-006: Response time MUST be <100ms for registered services
 //
-// T072 [US3]: Integration test query registered service, verify response sent
+// T072 [US3]: Integration test query registered service, verify response sent.
+// Runs over the in-process loopback harness with the responder on a fake
+// clock: the clock stands still while the query is answered, so an answer
+// proves the responder imposed no delay, whatever the machine's load.
 func TestQueryResponse_ResponseLatency(t *testing.T) {
-	if testing.Short() {
-		t.Skip("Skipping integration test in short mode")
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-
-	// Create mock transport and responder
-	mock := transport.NewMockTransport()
-	r, err := responder.New(ctx, responder.WithTransport(mock), responder.WithHostname("testhost.local"))
-	if err != nil {
-		t.Fatalf("Failed to create responder: %v", err)
-	}
-	defer func() {
-		cancel() // Cancel context first to unblock query handler's blocking Receive()
-		_ = r.Close()
-	}()
-
-	// Register service (probing/announcing proceeds quickly with mock transport)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	link := transport.NewLoopbackLink()
+	pair := newLoopbackPair(t, link,
+		responder.WithTransport(link.Attach(loopbackResponderAddr)),
+		responder.WithClock(fake),
+		responder.WithGoodbyeCount(1)) // Close then sends without waiting on the clock
+
+	// Register service (probing/announcing runs over the loopback link),
+	// advancing the fake clock through its waits
 	service := &responder.Service{
 		InstanceName: "TestPrinter",
 		ServiceType:  "_http._tcp.local",
 		Port:         8080,
 	}
-
-	err = r.Register(service)
-	if err != nil {
-		t.Fatalf("Failed to register service: %v", err)
+	registered := make(chan error, 1)
+	go func() { registered <- pair.Responder.Register(service) }()
+	for done := false; !done; {
+		select {
+		case err := <-registered:
+			if err != nil {
+				t.Fatalf("Failed to register service: %v", err)
+			}
+			done = true
+		case <-time.After(time.Millisecond):
+			if fake.Waiters() > 0 {
+				fake.Advance(time.Second)
+			}
+		}
 	}
 
-	// Record send count before query to isolate query-response packets
-	callsBefore := len(mock.SendCalls())
-
-	// Build a PTR query for the registered service type
-	queryPacket := buildTestQuery(t, "_http._tcp.local", uint16(protocol.RecordTypePTR), 0x0001)
-
-	srcAddr := &net.UDPAddr{IP: net.ParseIP("192.168.1.100"), Port: 5353}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
 
-	// Inject query into the mock transport's receive queue.
-	// The query handler goroutine will pick this up via transport.Receive().
-	mock.QueueReceive(queryPacket, srcAddr, 0)
-
-	// Wait for query handler to process (should be well under 100ms)
-	time.Sleep(200 * time.Millisecond)
-
-	// Verify a response was sent
-	allCalls := mock.SendCalls()
-	responseCalls := allCalls[callsBefore:]
-
-	if len(responseCalls) == 0 {
-		t.Fatal("No response packet sent after PTR query injection")
+	queried := fake.Now()
+	resp, err := pair.Querier.QueryN(ctx, "_http._tcp.local", querier.RecordTypePTR, 1)
+	if err != nil {
+		t.Fatalf("QueryN() error = %v", err)
 	}
 
-	// Verify the response is a valid DNS response (QR=1)
-	responsePacket := responseCalls[0].Packet
-	if len(responsePacket) < 12 {
-		t.Fatalf("Response packet too short: %d bytes", len(responsePacket))
+	if len(resp.Records) == 0 {
+		t.Fatal("No PTR answer received for registered service")
 	}
-
-	flags := binary.BigEndian.Uint16(responsePacket[2:4])
-	if flags&0x8000 == 0 {
-		t.Error("Response QR bit not set (expected QR=1 for response)")
+	if got := resp.Records[0].AsPTR(); got != "TestPrinter._http._tcp.local" {
+		t.Errorf("PTR answer = %q, want %q", got, "TestPrinter._http._tcp.local")
 	}
 
-	t.Logf("Response received: %d bytes, flags=0x%04x", len(responsePacket), flags)
+	// SC-006: Answered at once, with no delay on the responder's clock
+	if elapsed := fake.Now().Sub(queried); elapsed != 0 {
+		t.Errorf("responder clock advanced %v before answering, want 0 (SC-006)", elapsed)
+	}
 }
 
 // TestQueryResponse_PTRQueryWithAdditionalRecords tests PTR query response structure.
@@ -96,7 +87,9 @@ func TestQueryResponse_ResponseLatency(t *testing.T) {
 //   - Answer section: PTR record
 //   - Additional section: SRV, TXT, A records (reduces round-trips)
 //
-// T072 [US3]: Verify PTR response includes PTR record in answers
+// T072 [US3]: Verify PTR response carries the PTR as its sole answer and the
+// SRV, TXT and A records in the additional section, with matching ANCOUNT and
+// ARCOUNT on the wire
 func TestQueryResponse_PTRQueryWithAdditionalRecords(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -171,6 +164,183 @@ func TestQueryResponse_PTRQueryWithAdditionalRecords(t *testing.T) {
 			t.Logf("  answer[%d]: name=%s type=%d", i, ans.NAME, ans.TYPE)
 		}
 	}
+	if len(parsed.Answers) != 1 {
+		t.Errorf("Response has %d answers, want the PTR record alone", len(parsed.Answers))
+	}
+
+	// RFC 6763 §12.1: SRV, TXT and A go in the additional section, not answers
+	additionalTypes := make(map[uint16]bool)
+	for _, add := range parsed.Additionals {
+		additionalTypes[add.TYPE] = true
+	}
+	for _, want := range []protocol.RecordType{protocol.RecordTypeSRV, protocol.RecordTypeTXT, protocol.RecordTypeA} {
+		if !additionalTypes[uint16(want)] {
+			t.Errorf("Response additional section missing %v record", want)
+		}
+	}
+
+	// Header counts must describe the sections as serialized
+	if ancount := binary.BigEndian.Uint16(responsePacket[6:8]); int(ancount) != len(parsed.Answers) {
+		t.Errorf("ANCOUNT = %d, want %d", ancount, len(parsed.Answers))
+	}
+	if arcount := binary.BigEndian.Uint16(responsePacket[10:12]); int(arcount) != len(parsed.Additionals) {
+		t.Errorf("ARCOUNT = %d, want %d", arcount, len(parsed.Additionals))
+	}
+}
+
+// TestQueryResponse_PTRSectionsSeenByQuerier verifies a querier keeps the
+// sections of a PTR response apart: Records holds only the PTR answer, and
+// the supplementary SRV, TXT and A records arrive in Additionals
+// (RFC 6762 §6, RFC 6763 §12.1).
+func TestQueryResponse_PTRSectionsSeenByQuerier(t *testing.T) {
+	pair := NewLoopbackPair(t)
+
+	service := &responder.Service{
+		InstanceName: "Sections",
+		ServiceType:  "_http._tcp.local",
+		Port:         8080,
+		TXTRecords:   map[string]string{"path": "/"},
+	}
+	if err := pair.Responder.Register(service); err != nil {
+		t.Fatalf("Failed to register service: %v", err)
+	}
+
+	// Query rather than QueryN: the announcements sent while registering (all
+	// records in the answer section, RFC 6762 §8.3) may still be queued, so
+	// collect for the whole window to include the response itself.
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	resp, err := pair.Querier.Query(ctx, "_http._tcp.local", querier.RecordTypePTR)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	if len(resp.Records) != 1 || resp.Records[0].Type != querier.RecordTypePTR {
+		t.Fatalf("Records = %+v, want the PTR answer alone", resp.Records)
+	}
+
+	additionalTypes := make(map[querier.RecordType]bool)
+	for _, add := range resp.Additionals {
+		additionalTypes[add.Type] = true
+	}
+	for _, want := range []querier.RecordType{querier.RecordTypeSRV, querier.RecordTypeTXT, querier.RecordTypeA} {
+		if !additionalTypes[want] {
+			t.Errorf("Additionals missing %v record: %+v", want, resp.Additionals)
+		}
+	}
+	if additionalTypes[querier.RecordTypePTR] {
+		t.Error("PTR answer repeated in Additionals")
+	}
+}
+
+// TestQueryResponse_DualStackAdditionals verifies a responder on a dual-stack
+// interface bundles both A and AAAA records with a PTR answer (RFC 6762 §6,
+// RFC 6763 §12.1), and DiscoverServices surfaces both address families from
+// them.
+func TestQueryResponse_DualStackAdditionals(t *testing.T) {
+	pair := NewDualStackLoopbackPair(t)
+
+	service := &responder.Service{InstanceName: "DualStack", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := pair.Responder.RegisterServiceWithoutProbing(service); err != nil {
+		t.Fatalf("RegisterServiceWithoutProbing() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	resp, err := pair.Querier.Query(ctx, "_http._tcp.local", querier.RecordTypePTR)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+
+	var ipv4, ipv6 net.IP
+	for i := range resp.Additionals {
+		add := &resp.Additionals[i]
+		if add.Name != "testhost.local" {
+			continue
+		}
+		if ip := add.AsA(); ip != nil {
+			ipv4 = ip
+		}
+		if ip := add.AsAAAA(); ip != nil {
+			ipv6 = ip
+		}
+	}
+	if !ipv4.Equal(loopbackResponderAddr.IP) {
+		t.Errorf("A additional = %v, want %v", ipv4, loopbackResponderAddr.IP)
+	}
+	if !ipv6.Equal(loopbackResponderIPv6) {
+		t.Errorf("AAAA additional = %v, want %v", ipv6, loopbackResponderIPv6)
+	}
+
+	// RFC 6762 §6.2: the responder multicasts a record at most once per
+	// second, so let the PTR answer's rate limit expire before browsing again
+	time.Sleep(time.Second)
+
+	discoverCtx, discoverCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer discoverCancel()
+	services, err := pair.Querier.DiscoverServices(discoverCtx, "_http._tcp.local")
+	if err != nil {
+		t.Fatalf("DiscoverServices() error = %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("DiscoverServices() = %d instances, want 1", len(services))
+	}
+	if got := services[0]; !got.AddrIPv4.Equal(loopbackResponderAddr.IP) || !got.AddrIPv6.Equal(loopbackResponderIPv6) {
+		t.Errorf("DiscoverServices() addresses = %v / %v, want %v / %v",
+			got.AddrIPv4, got.AddrIPv6, loopbackResponderAddr.IP, loopbackResponderIPv6)
+	}
+}
+
+// TestQueryResponse_DualStackAnnounceAndGoodbye verifies a responder on a
+// dual-stack interface probes for and announces its AAAA record with the A
+// record at Register (RFC 6762 §8.1, §8.3), and withdraws it at Unregister
+// (RFC 6762 §10.1), so no stale IPv6 address outlives the service in peers'
+// caches.
+func TestQueryResponse_DualStackAnnounceAndGoodbye(t *testing.T) {
+	pair := NewDualStackLoopbackPair(t)
+	r := pair.Responder
+
+	service := &responder.Service{InstanceName: "DualStack", ServiceType: "_http._tcp.local", Port: 8080}
+	if err := r.Register(service); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	probe, err := r.GetLastProbe()
+	if err != nil {
+		t.Fatalf("GetLastProbe() error = %v", err)
+	}
+	if !hasAAAA(probe.Authorities, loopbackResponderIPv6, protocol.TTLHostname) {
+		t.Errorf("probe authorities = %+v, want the host's AAAA record", probe.Authorities)
+	}
+
+	announcement, err := message.ParseMessage(r.GetLastAnnounceMessage())
+	if err != nil {
+		t.Fatalf("ParseMessage(announcement) error = %v", err)
+	}
+	if !hasAAAA(announcement.Answers, loopbackResponderIPv6, protocol.TTLHostname) {
+		t.Errorf("announcement answers = %+v, want the host's AAAA record", announcement.Answers)
+	}
+
+	if err := r.Unregister("DualStack"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+	goodbye, err := message.ParseMessage(r.GetLastGoodbyeMessage())
+	if err != nil {
+		t.Fatalf("ParseMessage(goodbye) error = %v", err)
+	}
+	if !hasAAAA(goodbye.Answers, loopbackResponderIPv6, 0) {
+		t.Errorf("goodbye answers = %+v, want the host's AAAA record with TTL 0", goodbye.Answers)
+	}
+}
+
+// hasAAAA reports whether rrs hold an AAAA record for ip with the given TTL.
+func hasAAAA(rrs []message.Answer, ip net.IP, ttl uint32) bool {
+	for _, rr := range rrs {
+		if rr.TYPE == uint16(protocol.RecordTypeAAAA) && net.IP(rr.RDATA).Equal(ip) && rr.TTL == ttl {
+			return true
+		}
+	}
+	return false
 }
 
 // TestQueryResponse_QUBitHandling tests unicast response per RFC 6762 §5.4.
@@ -253,12 +423,13 @@ func TestQueryResponse_QUBitHandling(t *testing.T) {
 		t.Fatal("No response sent for multicast query")
 	}
 
-	// Verify multicast response: dest should be nil (multicast to 224.0.0.251:5353)
+	// Verify multicast response: dest is the mDNS group 224.0.0.251:5353
+	// (a nil dest cannot be sent by the UDP transport)
 	mcDest := mcResponseCalls[0].Dest
-	if mcDest != nil {
-		t.Errorf("Multicast query response dest = %v, want nil (multicast)", mcDest)
+	if mcDest == nil || mcDest.String() != protocol.MulticastGroupIPv4().String() {
+		t.Errorf("Multicast query response dest = %v, want %v", mcDest, protocol.MulticastGroupIPv4())
 	} else {
-		t.Log("Multicast response correctly sent to nil (multicast address)")
+		t.Logf("Multicast response correctly sent to %v", mcDest)
 	}
 }
 